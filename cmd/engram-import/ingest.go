@@ -0,0 +1,281 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/thebtf/engram/internal/chunking"
+	gochunking "github.com/thebtf/engram/internal/chunking/golang"
+	mdchunking "github.com/thebtf/engram/internal/chunking/markdown"
+	"github.com/thebtf/engram/internal/collections"
+	"github.com/thebtf/engram/internal/config"
+)
+
+// remoteDocument mirrors an entry of GET /api/collections/{collection}/documents.
+type remoteDocument struct {
+	Path string `json:"path"`
+	Hash string `json:"hash"`
+}
+
+// remoteChunk mirrors a chunk in PUT /api/collections/{collection}/documents.
+type remoteChunk struct {
+	Kind       string `json:"kind,omitempty"`
+	Name       string `json:"name,omitempty"`
+	ParentName string `json:"parent_name,omitempty"`
+	Language   string `json:"language,omitempty"`
+	Text       string `json:"text"`
+	StartLine  int    `json:"start_line"`
+	EndLine    int    `json:"end_line"`
+}
+
+// ingestStats counts what one ingest-collection run did.
+type ingestStats struct {
+	ingested, unchanged, removed, skipped, errors int
+}
+
+// apiClient is a minimal JSON client for the engram REST API.
+type apiClient struct {
+	http  *http.Client
+	base  string
+	token string
+}
+
+// do sends body (if non-nil) as JSON and decodes the response into out (if
+// non-nil). Any non-2xx status is an error carrying the response text.
+func (c *apiClient) do(method, path string, body, out any) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("marshal request: %w", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequest(method, c.base+path, reader)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("%s %s: HTTP %d: %s", method, path, resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return fmt.Errorf("decode response: %w", err)
+		}
+	}
+	return nil
+}
+
+func runIngestCollection(args []string) {
+	fs := flag.NewFlagSet("ingest-collection", flag.ExitOnError)
+	configPath := fs.String("config", config.GetCollectionConfigPath(), "Collections YAML file")
+	baseDir := fs.String("base", ".", "Directory that collection roots and document paths are relative to")
+	server := fs.String("server", "", "Server URL (overrides ENGRAM_URL)")
+	dryRun := fs.Bool("dry-run", false, "Report what would be ingested or removed without changing anything")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: engram-import ingest-collection [flags] [collection...]")
+		fmt.Fprintln(fs.Output(), "Ingests every collection with roots when none are named.")
+		fs.PrintDefaults()
+	}
+	_ = fs.Parse(args)
+
+	registry, err := collections.Load(*configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "load %s: %v\n", *configPath, err)
+		os.Exit(1)
+	}
+	var selected []*collections.Collection
+	if fs.NArg() == 0 {
+		for _, c := range registry.All() {
+			if len(c.Roots) > 0 {
+				selected = append(selected, c)
+			}
+		}
+		if len(selected) == 0 {
+			fmt.Fprintf(os.Stderr, "no collection in %s has roots to ingest\n", *configPath)
+			os.Exit(1)
+		}
+	}
+	for _, name := range fs.Args() {
+		c, ok := registry.Get(name)
+		if !ok {
+			fmt.Fprintf(os.Stderr, "unknown collection %q in %s\n", name, *configPath)
+			os.Exit(1)
+		}
+		selected = append(selected, c)
+	}
+
+	client := &apiClient{
+		http:  &http.Client{Timeout: 90 * time.Second},
+		base:  resolveServerURL(*server),
+		token: os.Getenv("ENGRAM_API_TOKEN"),
+	}
+	opts := chunking.DefaultChunkOptions()
+	manager := chunking.NewManager([]chunking.Chunker{
+		mdchunking.NewChunker(opts),
+		gochunking.NewChunker(opts),
+	}, opts)
+
+	var total ingestStats
+	for _, c := range selected {
+		stats := ingestCollection(client, manager, c, *baseDir, *dryRun)
+		fmt.Printf("%s: %d ingested, %d unchanged, %d removed, %d skipped, %d errors\n",
+			c.Name, stats.ingested, stats.unchanged, stats.removed, stats.skipped, stats.errors)
+		total.errors += stats.errors
+	}
+	if total.errors > 0 {
+		os.Exit(1)
+	}
+}
+
+// ingestCollection syncs one collection: files whose content hash differs
+// from the server's copy are chunked and upserted, and active documents whose
+// files are gone are deactivated.
+func ingestCollection(client *apiClient, manager *chunking.Manager, c *collections.Collection, baseDir string, dryRun bool) ingestStats {
+	var stats ingestStats
+	fmt.Printf("Collection %s\n", c.Name)
+
+	files, err := c.Files(baseDir)
+	if err != nil {
+		fmt.Printf("  ERROR %v\n", err)
+		stats.errors++
+		return stats
+	}
+	endpoint := "/api/collections/" + url.PathEscape(c.Name) + "/documents"
+	var remote []remoteDocument
+	if err := client.do(http.MethodGet, endpoint, nil, &remote); err != nil {
+		fmt.Printf("  ERROR list documents: %v\n", err)
+		stats.errors++
+		return stats
+	}
+	remoteHash := make(map[string]string, len(remote))
+	for _, d := range remote {
+		remoteHash[d.Path] = d.Hash
+	}
+
+	local := make(map[string]bool, len(files))
+	for _, docPath := range files {
+		local[docPath] = true
+		data, err := os.ReadFile(filepath.Join(baseDir, filepath.FromSlash(docPath)))
+		if err != nil {
+			fmt.Printf("  ERROR read %s: %v\n", docPath, err)
+			stats.errors++
+			continue
+		}
+		if len(bytes.TrimSpace(data)) == 0 || !utf8.Valid(data) || bytes.IndexByte(data, 0) >= 0 {
+			fmt.Printf("  SKIPPED %s: empty or binary\n", docPath)
+			stats.skipped++
+			continue
+		}
+		sum := sha256.Sum256(data)
+		if remoteHash[docPath] == hex.EncodeToString(sum[:]) {
+			stats.unchanged++
+			continue
+		}
+
+		content := string(data)
+		chunks := chunkDocument(manager, docPath, content)
+		if dryRun {
+			fmt.Printf("  WOULD INGEST %s (%d chunks)\n", docPath, len(chunks))
+			stats.ingested++
+			continue
+		}
+		body := map[string]any{
+			"path":    docPath,
+			"title":   documentTitle(docPath, content),
+			"content": content,
+			"chunks":  chunks,
+		}
+		if err := client.do(http.MethodPut, endpoint, body, nil); err != nil {
+			fmt.Printf("  ERROR %s: %v\n", docPath, err)
+			stats.errors++
+			continue
+		}
+		fmt.Printf("  INGESTED %s (%d chunks)\n", docPath, len(chunks))
+		stats.ingested++
+	}
+
+	for _, d := range remote {
+		if local[d.Path] {
+			continue
+		}
+		if dryRun {
+			fmt.Printf("  WOULD REMOVE %s\n", d.Path)
+			stats.removed++
+			continue
+		}
+		if err := client.do(http.MethodDelete, endpoint+"?path="+url.QueryEscape(d.Path), nil, nil); err != nil {
+			fmt.Printf("  ERROR remove %s: %v\n", d.Path, err)
+			stats.errors++
+			continue
+		}
+		fmt.Printf("  REMOVED %s\n", d.Path)
+		stats.removed++
+	}
+	return stats
+}
+
+// chunkDocument splits content with the chunker for docPath's extension,
+// falling back to plain-text chunks when it does not parse.
+func chunkDocument(manager *chunking.Manager, docPath, content string) []remoteChunk {
+	ctx := context.Background()
+	chunks, err := manager.ChunkContent(ctx, docPath, content)
+	if err != nil {
+		chunks, _ = chunking.NewManager(nil, chunking.DefaultChunkOptions()).ChunkContent(ctx, docPath, content)
+	}
+	result := make([]remoteChunk, len(chunks))
+	for i, c := range chunks {
+		result[i] = remoteChunk{
+			Kind:       string(c.Type),
+			Name:       c.Name,
+			ParentName: c.ParentName,
+			Language:   string(c.Language),
+			Text:       c.Content,
+			StartLine:  c.StartLine,
+			EndLine:    c.EndLine,
+		}
+	}
+	return result
+}
+
+// documentTitle returns the first level-one heading of a markdown document,
+// or "" for anything else.
+func documentTitle(docPath, content string) string {
+	switch strings.ToLower(filepath.Ext(docPath)) {
+	case ".md", ".mdx":
+	default:
+		return ""
+	}
+	for _, line := range strings.Split(content, "\n") {
+		if title, ok := strings.CutPrefix(strings.TrimSpace(line), "# "); ok {
+			return strings.TrimSpace(title)
+		}
+	}
+	return ""
+}
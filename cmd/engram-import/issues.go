@@ -0,0 +1,190 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/thebtf/engram/internal/issuefile"
+)
+
+// issueImportResponse mirrors the POST /api/issues/import response.
+type issueImportResponse struct {
+	Results []struct {
+		ExternalID    string `json:"external_id"`
+		Action        string `json:"action"`
+		ID            int64  `json:"id"`
+		CommentsAdded int    `json:"comments_added"`
+	} `json:"results"`
+	Errors []struct {
+		ExternalID string `json:"external_id"`
+		Title      string `json:"title"`
+		Error      string `json:"error"`
+	} `json:"errors"`
+	Created       int `json:"created"`
+	Updated       int `json:"updated"`
+	Unchanged     int `json:"unchanged"`
+	CommentsAdded int `json:"comments_added"`
+}
+
+func runIssuesExport(args []string) {
+	fs := flag.NewFlagSet("issues-export", flag.ExitOnError)
+	project := fs.String("project", "", "Export only issues targeted at this project")
+	status := fs.String("status", "", "Comma-separated statuses to export (default: all)")
+	format := fs.String("format", "json", "Output format: json (one file) or markdown (one file per issue)")
+	output := fs.String("o", "", "Output file (json, default stdout) or directory (markdown, required)")
+	server := fs.String("server", "", "Server URL (overrides ENGRAM_URL)")
+	_ = fs.Parse(args)
+
+	if *format != "json" && *format != "markdown" {
+		fmt.Fprintf(os.Stderr, "unknown format %q: must be json or markdown\n", *format)
+		os.Exit(1)
+	}
+	if *format == "markdown" && *output == "" {
+		fmt.Fprintln(os.Stderr, "-o <directory> is required for markdown output")
+		os.Exit(1)
+	}
+
+	query := url.Values{}
+	if *project != "" {
+		query.Set("project", *project)
+	}
+	if *status != "" {
+		query.Set("status", *status)
+	}
+	client := &apiClient{
+		http:  &http.Client{Timeout: 5 * time.Minute},
+		base:  resolveServerURL(*server),
+		token: os.Getenv("ENGRAM_API_TOKEN"),
+	}
+	var doc issuefile.Document
+	if err := client.do(http.MethodGet, "/api/issues/export?"+query.Encode(), nil, &doc); err != nil {
+		fmt.Fprintf(os.Stderr, "export issues: %v\n", err)
+		os.Exit(1)
+	}
+
+	if *format == "markdown" {
+		paths, err := issuefile.WriteDir(*output, doc.Issues)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "write markdown: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Fprintf(os.Stderr, "Exported %d issues to %s\n", len(paths), *output)
+		return
+	}
+
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "marshal issues: %v\n", err)
+		os.Exit(1)
+	}
+	data = append(data, '\n')
+	if *output == "" {
+		_, _ = os.Stdout.Write(data)
+		return
+	}
+	if err := os.WriteFile(*output, data, 0o644); err != nil {
+		fmt.Fprintf(os.Stderr, "write %s: %v\n", *output, err)
+		os.Exit(1)
+	}
+	fmt.Fprintf(os.Stderr, "Exported %d issues to %s\n", len(doc.Issues), *output)
+}
+
+func runIssuesImport(args []string) {
+	fs := flag.NewFlagSet("issues-import", flag.ExitOnError)
+	project := fs.String("project", "", "Target project for issues that do not name one (e.g. plain GitHub exports)")
+	server := fs.String("server", "", "Server URL (overrides ENGRAM_URL)")
+	dryRun := fs.Bool("dry-run", false, "Parse and list the issues without importing them")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: engram-import issues-import [flags] <file.json | markdown-directory>")
+		fmt.Fprintln(fs.Output(), "Re-importing the same issues updates them in place; nothing is duplicated.")
+		fs.PrintDefaults()
+	}
+	_ = fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	doc, errs := readIssueFile(fs.Arg(0))
+	for _, err := range errs {
+		fmt.Printf("  ERROR %v\n", err)
+	}
+	if doc == nil {
+		os.Exit(1)
+	}
+	fmt.Printf("Read %d issues from %s\n", len(doc.Issues), fs.Arg(0))
+
+	if *dryRun {
+		for _, issue := range doc.Issues {
+			id := issue.ExternalID
+			if id == "" {
+				id = issue.HTMLURL
+			}
+			fmt.Printf("  %s  %s (%d comments)\n", id, issue.Title, len(issue.Comments))
+		}
+		if len(errs) > 0 {
+			os.Exit(1)
+		}
+		return
+	}
+
+	query := url.Values{}
+	query.Set("source_project", "engram-import")
+	if *project != "" {
+		query.Set("project", *project)
+	}
+	client := &apiClient{
+		http:  &http.Client{Timeout: 5 * time.Minute},
+		base:  resolveServerURL(*server),
+		token: os.Getenv("ENGRAM_API_TOKEN"),
+	}
+	var resp issueImportResponse
+	if err := client.do(http.MethodPost, "/api/issues/import?"+query.Encode(), doc, &resp); err != nil {
+		fmt.Fprintf(os.Stderr, "import issues: %v\n", err)
+		os.Exit(1)
+	}
+
+	for _, r := range resp.Results {
+		if r.Action == "unchanged" {
+			continue
+		}
+		fmt.Printf("  %s #%d %s (+%d comments)\n", strings.ToUpper(r.Action), r.ID, r.ExternalID, r.CommentsAdded)
+	}
+	for _, e := range resp.Errors {
+		fmt.Printf("  ERROR %s %q: %s\n", e.ExternalID, e.Title, e.Error)
+	}
+	fmt.Printf("\nResults: %d created, %d updated, %d unchanged, %d comments added, %d errors\n",
+		resp.Created, resp.Updated, resp.Unchanged, resp.CommentsAdded, len(resp.Errors)+len(errs))
+	if len(resp.Errors) > 0 || len(errs) > 0 {
+		os.Exit(1)
+	}
+}
+
+// readIssueFile reads a JSON issue file, or every Markdown file in a
+// directory. Markdown files that fail to parse are returned as errors
+// alongside the issues that did parse.
+func readIssueFile(path string) (*issuefile.Document, []error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, []error{err}
+	}
+	if info.IsDir() {
+		issues, errs := issuefile.ReadDir(path)
+		return &issuefile.Document{Version: issuefile.Version, Issues: issues}, errs
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, []error{err}
+	}
+	doc, err := issuefile.UnmarshalDocument(data)
+	if err != nil {
+		return nil, []error{fmt.Errorf("%s: %w", path, err)}
+	}
+	return doc, nil
+}
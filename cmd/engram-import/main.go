@@ -1,5 +1,7 @@
-// Command engram-import provides CLI utilities for importing feedback files
-// and triggering server-side purge-rebuild operations.
+// Command engram-import provides CLI utilities for importing feedback files,
+// ingesting document collections from disk, exporting and importing issues,
+// rotating the vault master key and rewrapping vault data keys, and triggering server-side purge-rebuild
+// operations.
 package main
 
 import (
@@ -23,6 +25,16 @@ func main() {
 	switch os.Args[1] {
 	case "import-feedback":
 		runImportFeedback(os.Args[2:])
+	case "ingest-collection":
+		runIngestCollection(os.Args[2:])
+	case "issues-export":
+		runIssuesExport(os.Args[2:])
+	case "issues-import":
+		runIssuesImport(os.Args[2:])
+	case "vault-rotate-key":
+		runVaultRotateKey(os.Args[2:])
+	case "vault-rewrap-keys":
+		runVaultRewrapKeys(os.Args[2:])
 	case "purge-rebuild":
 		runPurgeRebuild()
 	default:
@@ -37,11 +49,17 @@ func printUsage() {
 	fmt.Println()
 	fmt.Println("Commands:")
 	fmt.Println("  import-feedback   Send feedback_*.md files to engram server for LLM processing.")
+	fmt.Println("  ingest-collection Chunk and upload files under collection roots; remove documents whose files are gone.")
+	fmt.Println("  issues-export     Write issues and comments to a JSON file or a Markdown directory.")
+	fmt.Println("  issues-import     Import issues from a JSON file (engram or GitHub) or a Markdown directory.")
+	fmt.Println("  vault-rotate-key  Replace the vault master key (admin).")
+	fmt.Println("  vault-rewrap-keys Rewrap project data keys after switching KEK (admin).")
 	fmt.Println("  purge-rebuild     Print instructions for the server-side purge-rebuild operation.")
 	fmt.Println()
 	fmt.Println("Environment:")
 	fmt.Println("  ENGRAM_URL        Server URL (default: http://localhost:37777)")
 	fmt.Println("  ENGRAM_API_TOKEN  Authentication token")
+	fmt.Println("  COLLECTION_CONFIG Collections YAML (default: ~/.config/engram/collections.yml)")
 }
 
 func runImportFeedback(args []string) {
@@ -50,14 +68,7 @@ func runImportFeedback(args []string) {
 	server := fs.String("server", "", "Server URL (overrides ENGRAM_URL)")
 	_ = fs.Parse(args)
 
-	serverURL := *server
-	if serverURL == "" {
-		serverURL = os.Getenv("ENGRAM_URL")
-	}
-	if serverURL == "" {
-		serverURL = "http://localhost:37777"
-	}
-	serverURL = strings.TrimRight(serverURL, "/")
+	serverURL := resolveServerURL(*server)
 	token := os.Getenv("ENGRAM_API_TOKEN")
 
 	dirs := findMemoryDirs()
@@ -147,6 +158,19 @@ func runImportFeedback(args []string) {
 		imported, dupes, skipped, errors)
 }
 
+// resolveServerURL returns override, else ENGRAM_URL, else the local default,
+// without a trailing slash.
+func resolveServerURL(override string) string {
+	serverURL := override
+	if serverURL == "" {
+		serverURL = os.Getenv("ENGRAM_URL")
+	}
+	if serverURL == "" {
+		serverURL = "http://localhost:37777"
+	}
+	return strings.TrimRight(serverURL, "/")
+}
+
 func runPurgeRebuild() {
 	fmt.Println("purge-rebuild is executed via the engram server API.")
 	fmt.Println()
@@ -0,0 +1,228 @@
+package main
+
+import (
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/thebtf/engram/internal/crypto"
+)
+
+// dataKeyRewrap mirrors crypto.DataKeyRewrap.
+type dataKeyRewrap struct {
+	FromKEK string `json:"from_kek"`
+	ToKEK   string `json:"to_kek"`
+	Failed  []struct {
+		Project string `json:"project"`
+		Error   string `json:"error"`
+	} `json:"failed"`
+	Pending   int `json:"pending"`
+	Verified  int `json:"verified"`
+	Rewrapped int `json:"rewrapped"`
+	Current   int `json:"current"`
+	Foreign   int `json:"foreign"`
+}
+
+// print reports a rewrap, or its dry run.
+func (d *dataKeyRewrap) print(dryRun bool) {
+	fmt.Printf("Data keys %s -> %s\n", d.FromKEK, d.ToKEK)
+	for _, f := range d.Failed {
+		fmt.Printf("  FAILED %s: %s\n", f.Project, f.Error)
+	}
+	if dryRun {
+		fmt.Printf("Dry run: %d of %d data keys unwrap with the old KEK, %d failed, %d already rewrapped, %d under another KEK\n",
+			d.Verified, d.Pending, len(d.Failed), d.Current, d.Foreign)
+		return
+	}
+	fmt.Printf("Rewrapped %d data keys (%d already rewrapped, %d under another KEK left alone)\n", d.Rewrapped, d.Current, d.Foreign)
+}
+
+// vaultRotateResponse mirrors the POST /api/vault/rotate response.
+type vaultRotateResponse struct {
+	Rotation struct {
+		FromFingerprint string `json:"from_fingerprint"`
+		ToFingerprint   string `json:"to_fingerprint"`
+		Failed          []struct {
+			Project string `json:"project"`
+			Key     string `json:"key"`
+			Error   string `json:"error"`
+			ID      int64  `json:"id"`
+		} `json:"failed"`
+		Pending        int  `json:"pending"`
+		Verified       int  `json:"verified"`
+		Rotated        int  `json:"rotated"`
+		AlreadyRotated int  `json:"already_rotated"`
+		Foreign        int  `json:"foreign"`
+		Batches        int  `json:"batches"`
+		ToDataKeys     bool `json:"to_data_keys"`
+	} `json:"rotation"`
+	DataKeys          *dataKeyRewrap `json:"data_keys"`
+	ActiveFingerprint string         `json:"active_fingerprint"`
+	KeyFile           string         `json:"key_file"`
+	KeyFileBackup     string         `json:"key_file_backup"`
+	ActionRequired    string         `json:"action_required"`
+}
+
+func runVaultRotateKey(args []string) {
+	fs := flag.NewFlagSet("vault-rotate-key", flag.ExitOnError)
+	newKeyFile := fs.String("new-key-file", "", "File holding the new key (64 hex chars); default ENGRAM_NEW_ENCRYPTION_KEY")
+	oldKeyFile := fs.String("old-key-file", "", "File holding the old key; default ENGRAM_OLD_ENCRYPTION_KEY, else the server's current key")
+	batchSize := fs.Int("batch-size", 0, "Credentials re-encrypted per transaction (default 100)")
+	dryRun := fs.Bool("dry-run", false, "Only verify that every credential decrypts with the old key")
+	server := fs.String("server", "", "Server URL (overrides ENGRAM_URL)")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: engram-import vault-rotate-key [flags]")
+		fmt.Fprintln(fs.Output(), "Replaces the vault master key (generate one with: openssl rand -hex 32). Project data keys wrapped")
+		fmt.Fprintln(fs.Output(), "by the master key are rewrapped; credentials still under the master key move to their project's data key.")
+		fmt.Fprintln(fs.Output(), "Requires an admin token. Keys travel in the request body: use an HTTPS server URL.")
+		fmt.Fprintln(fs.Output(), "An interrupted rotation is resumed by running the same command again.")
+		fs.PrintDefaults()
+	}
+	_ = fs.Parse(args)
+
+	newKey, err := readVaultKey(*newKeyFile, "ENGRAM_NEW_ENCRYPTION_KEY")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "new key: %v\n", err)
+		os.Exit(1)
+	}
+	if newKey == "" {
+		fs.Usage()
+		os.Exit(1)
+	}
+	oldKey, err := readVaultKey(*oldKeyFile, "ENGRAM_OLD_ENCRYPTION_KEY")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "old key: %v\n", err)
+		os.Exit(1)
+	}
+
+	client := &apiClient{
+		http:  &http.Client{Timeout: 30 * time.Minute},
+		base:  resolveServerURL(*server),
+		token: os.Getenv("ENGRAM_API_TOKEN"),
+	}
+	body := map[string]any{
+		"new_key":    newKey,
+		"old_key":    oldKey,
+		"batch_size": *batchSize,
+		"dry_run":    *dryRun,
+	}
+	var resp vaultRotateResponse
+	if err := client.do(http.MethodPost, "/api/vault/rotate", body, &resp); err != nil {
+		fmt.Fprintf(os.Stderr, "rotate vault key: %v\n", err)
+		if !*dryRun {
+			fmt.Fprintln(os.Stderr, "Run with -dry-run to list credentials that do not decrypt with the old key.")
+		}
+		os.Exit(1)
+	}
+
+	r := resp.Rotation
+	if resp.DataKeys != nil {
+		resp.DataKeys.print(*dryRun)
+		fmt.Println()
+	}
+	to := r.ToFingerprint
+	if r.ToDataKeys {
+		to = "project data keys"
+	}
+	fmt.Printf("Credentials under key %s -> %s\n", r.FromFingerprint, to)
+	for _, f := range r.Failed {
+		fmt.Printf("  FAILED #%d %s/%s: %s\n", f.ID, f.Project, f.Key, f.Error)
+	}
+	if *dryRun {
+		fmt.Printf("\nDry run: %d of %d credentials decrypt with the old key, %d failed, %d under another key\n",
+			r.Verified, r.Pending, len(r.Failed), r.AlreadyRotated+r.Foreign)
+		if len(r.Failed) > 0 || (resp.DataKeys != nil && len(resp.DataKeys.Failed) > 0) {
+			os.Exit(1)
+		}
+		return
+	}
+	fmt.Printf("\nRe-encrypted %d credentials in %d batches (%d under another key left alone)\n",
+		r.Rotated, r.Batches, r.AlreadyRotated+r.Foreign)
+	if resp.KeyFileBackup != "" {
+		fmt.Printf("Server key file %s now holds the new key; the old key is at %s\n", resp.KeyFile, resp.KeyFileBackup)
+	}
+	if resp.ActionRequired != "" {
+		fmt.Printf("ACTION REQUIRED: %s\n", resp.ActionRequired)
+	}
+}
+
+func runVaultRewrapKeys(args []string) {
+	fs := flag.NewFlagSet("vault-rewrap-keys", flag.ExitOnError)
+	provider := fs.String("from", "", "Previous KEK provider: master, file, passphrase or localkms")
+	keyFile := fs.String("from-key-file", "", "master: local file holding the previous master key; file: the previous KEK file path on the server")
+	salt := fs.String("from-salt", "", "passphrase: previous salt as hex (default: the server's vault-kek.salt)")
+	kdf := fs.String("from-kdf", "", "passphrase: previous KDF, argon2id or scrypt (default argon2id)")
+	kmsKeystore := fs.String("from-kms-keystore", "", "localkms: previous keystore path on the server")
+	kmsKey := fs.String("from-kms-key", "", "localkms: previous key name (default engram)")
+	dryRun := fs.Bool("dry-run", false, "Only verify that every data key unwraps with the previous KEK")
+	server := fs.String("server", "", "Server URL (overrides ENGRAM_URL)")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: engram-import vault-rewrap-keys -from <provider> [flags]")
+		fmt.Fprintln(fs.Output(), "Rewraps every project data key wrapped by the previous KEK with the KEK the server now runs with")
+		fmt.Fprintln(fs.Output(), "(ENGRAM_VAULT_KEK). Run it after switching KEK and restarting. Credentials are not re-encrypted.")
+		fmt.Fprintln(fs.Output(), "A previous passphrase is read from ENGRAM_OLD_VAULT_KEK_PASSPHRASE. Requires an admin token.")
+		fs.PrintDefaults()
+	}
+	_ = fs.Parse(args)
+	if *provider == "" {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	spec := map[string]any{
+		"provider":     *provider,
+		"salt":         *salt,
+		"kdf":          *kdf,
+		"kms_keystore": *kmsKeystore,
+		"kms_key":      *kmsKey,
+		"passphrase":   os.Getenv("ENGRAM_OLD_VAULT_KEK_PASSPHRASE"),
+	}
+	if *provider == "master" {
+		key, err := readVaultKey(*keyFile, "ENGRAM_OLD_ENCRYPTION_KEY")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "previous key: %v\n", err)
+			os.Exit(1)
+		}
+		spec["key"] = key
+	} else {
+		spec["key_file"] = *keyFile
+	}
+
+	client := &apiClient{
+		http:  &http.Client{Timeout: 10 * time.Minute},
+		base:  resolveServerURL(*server),
+		token: os.Getenv("ENGRAM_API_TOKEN"),
+	}
+	var resp dataKeyRewrap
+	body := map[string]any{"previous": spec, "dry_run": *dryRun}
+	if err := client.do(http.MethodPost, "/api/vault/data-keys/rewrap", body, &resp); err != nil {
+		fmt.Fprintf(os.Stderr, "rewrap data keys: %v\n", err)
+		os.Exit(1)
+	}
+	resp.print(*dryRun)
+	if *dryRun && len(resp.Failed) > 0 {
+		os.Exit(1)
+	}
+}
+
+// readVaultKey returns a hex key read from path, or from the environment
+// variable env when path is empty. Empty when neither is set.
+func readVaultKey(path, env string) (string, error) {
+	if path == "" {
+		if v := os.Getenv(env); v != "" {
+			if _, err := crypto.ParseKey(v); err != nil {
+				return "", fmt.Errorf("%s: %w", env, err)
+			}
+			return v, nil
+		}
+		return "", nil
+	}
+	key, err := crypto.ReadKeyFile(path)
+	if err != nil {
+		return "", fmt.Errorf("read %s: %w", path, err)
+	}
+	return hex.EncodeToString(key), nil
+}
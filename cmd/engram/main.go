@@ -15,6 +15,7 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"log/slog"
 	"os"
@@ -25,6 +26,7 @@ import (
 
 	"github.com/thebtf/engram/internal/config"
 	"github.com/thebtf/engram/internal/control"
+	"github.com/thebtf/engram/internal/handlers/engramcore"
 	"github.com/thebtf/engram/internal/handlers/serverevents"
 	"github.com/thebtf/engram/internal/module"
 	"github.com/thebtf/engram/internal/module/dispatcher"
@@ -139,6 +141,8 @@ func main() {
 			case "graceful-restart":
 				go handleGracefulRestart(logger, pipeline, disp, filepath.Join(dd, "modules"))
 				return "ACK"
+			case "outbox-status", "outbox-flush":
+				return handleOutboxCommand(reg, cmd)
 			default:
 				return "ERR unknown command"
 			}
@@ -202,6 +206,46 @@ func main() {
 	}
 }
 
+// outboxController is implemented by the module owning the offline outbox
+// (engramcore).
+type outboxController interface {
+	OutboxStatus(ctx context.Context) (engramcore.OutboxStatus, error)
+	FlushOutbox() error
+}
+
+// handleOutboxCommand answers the outbox-status and outbox-flush control
+// commands. outbox-status returns the outbox summary as one line of JSON;
+// outbox-flush asks the replayer to try the server now and returns ACK.
+func handleOutboxCommand(reg *registry.Registry, cmd string) string {
+	proxy, _, ok := reg.GetProxyToolProvider()
+	if !ok {
+		return "ERR outbox unavailable"
+	}
+	ob, ok := proxy.(outboxController)
+	if !ok {
+		return "ERR outbox unavailable"
+	}
+
+	if cmd == "outbox-flush" {
+		if err := ob.FlushOutbox(); err != nil {
+			return "ERR " + err.Error()
+		}
+		return "ACK"
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	st, err := ob.OutboxStatus(ctx)
+	if err != nil {
+		return "ERR " + err.Error()
+	}
+	b, err := json.Marshal(st)
+	if err != nil {
+		return "ERR " + err.Error()
+	}
+	return string(b)
+}
+
 // handleGracefulRestart executes the full graceful-restart sequence:
 //  1. Log INFO
 //  2. Drain — stop accepting new tool calls (5 s sleep)
@@ -27,4 +27,20 @@ var (
 	// adapters MAY collapse it into ErrInvalidCredentials at the wire to
 	// avoid leaking revocation state to anonymous attackers.
 	ErrRevoked = errors.New("auth: token revoked")
+
+	// ErrExpired signals that the bearer matched an api_tokens row whose
+	// expires_at has passed — including a rotated token whose grace window
+	// is over. Transport adapters map it like ErrRevoked.
+	ErrExpired = errors.New("auth: token expired")
 )
+
+// ErrProjectForbidden signals that a project-scoped keycard addressed a
+// project outside its allowlist, or did not name a project on a request that
+// would otherwise span every project. Transport adapters map it to 403
+// Forbidden (HTTP) and codes.PermissionDenied (gRPC).
+var ErrProjectForbidden = errors.New("auth: project not allowed for this keycard")
+
+// ErrPermissionDenied signals that the bearer's role lacks the permission an
+// operation needs (see Authorize). Transport adapters map it to 403 Forbidden
+// (HTTP) and codes.PermissionDenied (gRPC).
+var ErrPermissionDenied = errors.New("auth: permission denied")
@@ -27,8 +27,10 @@ const (
 	SourceSession Source = "session"
 )
 
-// Role is the role string carried in the request context. Existing call sites
-// compare against the literal strings "admin", "read-write", "read-only".
+// Role is the role string carried in the request context: one of
+// BuiltinRoles ("admin", "operator", "read-write", "read-only") or the name
+// of a custom role. What a role may do is decided by its permissions (see
+// Identity.Can), not by comparing role names.
 type Role string
 
 const (
@@ -57,25 +59,42 @@ type Identity struct {
 	// KeycardID is the api_tokens.id (UUID) when Source == SourceClient.
 	// Empty string for SourceMaster and SourceSession.
 	KeycardID string
+
+	// Projects is the keycard's project allowlist (api_tokens.allowed_projects):
+	// exact project IDs and path.Match glob patterns. Empty means the bearer
+	// may reach every project, which is always the case for SourceMaster and
+	// SourceSession.
+	Projects []string
+
+	// Permissions are what Role grants, resolved at authentication. Treat
+	// the slice as read-only.
+	Permissions []Permission
 }
 
 // Admin returns an Identity for a successful master-token match.
 func Admin() Identity {
-	return Identity{Role: RoleAdmin, Source: SourceMaster}
+	return Identity{Role: RoleAdmin, Source: SourceMaster, Permissions: BuiltinRoles[RoleAdmin]}
 }
 
 // Client returns an Identity for a successful client-keycard match. scope is
-// the api_tokens.scope value ("read-write" or "read-only"); keycardID is the
-// api_tokens.id used by audit logs and revocation lookup.
-func Client(scope string, keycardID string) Identity {
-	return Identity{Role: Role(scope), Source: SourceClient, KeycardID: keycardID}
+// the api_tokens.scope value ("read-write", "read-only" or a custom role,
+// whose permissions the validator fills in); keycardID is the api_tokens.id
+// used by audit logs and revocation lookup; projects is the keycard's
+// project allowlist (none means unrestricted).
+func Client(scope string, keycardID string, projects ...string) Identity {
+	id := Identity{Role: Role(scope), Source: SourceClient, KeycardID: keycardID, Permissions: BuiltinRoles[Role(scope)]}
+	if len(projects) > 0 {
+		id.Projects = append([]string(nil), projects...)
+	}
+	return id
 }
 
 // Session returns an Identity for a successful session-cookie authentication.
 // role is the resolved role string ("admin" for HMAC cookie, or the user role
-// for engram_auth cookie).
+// for engram_auth cookie). A custom role carries no permissions here; use
+// Validator.SessionIdentity to resolve it.
 func Session(role string) Identity {
-	return Identity{Role: Role(role), Source: SourceSession}
+	return Identity{Role: Role(role), Source: SourceSession, Permissions: BuiltinRoles[Role(role)]}
 }
 
 // IsAdmin reports whether the bearer holds admin role regardless of source.
@@ -86,7 +105,34 @@ func (i Identity) IsAdmin() bool {
 }
 
 // IsSessionAdmin is the gate for issuance/revocation endpoints (FR-6 / C4).
-// True only when both Role is admin AND Source is a browser session.
+// True only when the bearer holds PermAdminTokens AND Source is a browser
+// session.
 func (i Identity) IsSessionAdmin() bool {
-	return i.Role == RoleAdmin && i.Source == SourceSession
+	return i.Can(PermAdminTokens) && i.Source == SourceSession
+}
+
+// IsProjectScoped reports whether the bearer is restricted to a project
+// allowlist. Callers use it to decide whether a request must name a project
+// before it may touch project data.
+func (i Identity) IsProjectScoped() bool {
+	return len(i.Projects) > 0
+}
+
+// CanAccessProject reports whether the bearer may reach project. An unscoped
+// identity may reach every project; a scoped one only projects matching an
+// allowlist entry, and never the empty project (which stores read as "all
+// projects").
+func (i Identity) CanAccessProject(project string) bool {
+	if !i.IsProjectScoped() {
+		return true
+	}
+	if project == "" {
+		return false
+	}
+	for _, pattern := range i.Projects {
+		if MatchProject(pattern, project) {
+			return true
+		}
+	}
+	return false
 }
@@ -0,0 +1,307 @@
+// Package oidc implements the relying-party side of an OpenID Connect
+// authorization-code login with PKCE (RFC 7636), for the dashboard.
+//
+// It covers what the dashboard needs and nothing more: provider discovery,
+// the authorization URL, the code exchange and ID token verification
+// (RS256/384/512 and ES256/384 signatures against the provider's JWKS, plus
+// the iss, aud, azp, exp, iat and nonce claims). Mapping the verified claims
+// to an engram user role is done by RoleMapping.
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultScopes are requested when Config.Scopes is empty.
+var DefaultScopes = []string{"openid", "email", "profile"}
+
+// ErrInvalidIDToken is returned (wrapped) when an ID token fails
+// verification.
+var ErrInvalidIDToken = errors.New("oidc: invalid ID token")
+
+// clockSkew is how far the ID token's exp and iat may be off from the local
+// clock.
+const clockSkew = 2 * time.Minute
+
+// Config configures a Provider.
+type Config struct {
+	// IssuerURL is the provider's issuer; discovery reads
+	// IssuerURL + "/.well-known/openid-configuration".
+	IssuerURL string
+	// ClientID and ClientSecret identify the dashboard to the provider. A
+	// public client (PKCE only) leaves ClientSecret empty.
+	ClientID     string
+	ClientSecret string
+	// RedirectURL is the dashboard's callback, registered with the provider.
+	RedirectURL string
+	// Scopes requested at login; DefaultScopes when empty. "openid" is
+	// always included.
+	Scopes []string
+	// HTTPClient is used for discovery, JWKS and token requests;
+	// a client with a 10s timeout when nil.
+	HTTPClient *http.Client
+}
+
+// Claims are the verified claims of an ID token.
+type Claims struct {
+	Subject       string
+	Email         string
+	EmailVerified *bool
+	Name          string
+	// Raw holds every claim, for RoleMapping.
+	Raw map[string]any
+}
+
+// discovery is the part of the provider metadata a Provider uses.
+type discovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// Provider is an OpenID Connect provider the dashboard logs in with. The
+// provider metadata and signing keys are fetched on first use and cached;
+// an unknown key ID refetches the keys once, to follow key rotation.
+type Provider struct {
+	cfg    Config
+	client *http.Client
+
+	mu   sync.Mutex
+	meta *discovery
+	keys map[string]any // kid → *rsa.PublicKey or *ecdsa.PublicKey
+}
+
+// New returns a Provider for cfg. It does not contact the provider.
+func New(cfg Config) (*Provider, error) {
+	if cfg.IssuerURL == "" || cfg.ClientID == "" || cfg.RedirectURL == "" {
+		return nil, errors.New("oidc: issuer URL, client ID and redirect URL are required")
+	}
+	cfg.IssuerURL = strings.TrimSuffix(cfg.IssuerURL, "/")
+	scopes := cfg.Scopes
+	if len(scopes) == 0 {
+		scopes = DefaultScopes
+	}
+	cfg.Scopes = []string{"openid"}
+	for _, s := range scopes {
+		if s != "" && s != "openid" {
+			cfg.Scopes = append(cfg.Scopes, s)
+		}
+	}
+	client := cfg.HTTPClient
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &Provider{cfg: cfg, client: client}, nil
+}
+
+// Issuer returns the configured issuer URL.
+func (p *Provider) Issuer() string {
+	return p.cfg.IssuerURL
+}
+
+// metadata returns the provider metadata, fetching it on first use.
+func (p *Provider) metadata(ctx context.Context) (*discovery, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.meta != nil {
+		return p.meta, nil
+	}
+	var meta discovery
+	if err := p.getJSON(ctx, p.cfg.IssuerURL+"/.well-known/openid-configuration", &meta); err != nil {
+		return nil, fmt.Errorf("oidc: discovery: %w", err)
+	}
+	if strings.TrimSuffix(meta.Issuer, "/") != p.cfg.IssuerURL {
+		return nil, fmt.Errorf("oidc: discovery: issuer %q does not match the configured %q", meta.Issuer, p.cfg.IssuerURL)
+	}
+	if meta.AuthorizationEndpoint == "" || meta.TokenEndpoint == "" || meta.JWKSURI == "" {
+		return nil, errors.New("oidc: discovery: provider metadata lacks an authorization, token or JWKS endpoint")
+	}
+	p.meta = &meta
+	return p.meta, nil
+}
+
+// AuthCodeURL returns the provider URL that starts a login. state is echoed
+// back to the callback, nonce is bound into the ID token, and verifier is
+// the PKCE code verifier (see NewVerifier) whose S256 challenge is sent.
+func (p *Provider) AuthCodeURL(ctx context.Context, state, nonce, verifier string) (string, error) {
+	meta, err := p.metadata(ctx)
+	if err != nil {
+		return "", err
+	}
+	q := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {p.cfg.ClientID},
+		"redirect_uri":          {p.cfg.RedirectURL},
+		"scope":                 {strings.Join(p.cfg.Scopes, " ")},
+		"state":                 {state},
+		"nonce":                 {nonce},
+		"code_challenge":        {Challenge(verifier)},
+		"code_challenge_method": {"S256"},
+	}
+	sep := "?"
+	if strings.Contains(meta.AuthorizationEndpoint, "?") {
+		sep = "&"
+	}
+	return meta.AuthorizationEndpoint + sep + q.Encode(), nil
+}
+
+// Exchange redeems an authorization code with its PKCE verifier and returns
+// the claims of the verified ID token, which must carry nonce.
+func (p *Provider) Exchange(ctx context.Context, code, verifier, nonce string) (*Claims, error) {
+	meta, err := p.metadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {p.cfg.RedirectURL},
+		"code_verifier": {verifier},
+	}
+	if p.cfg.ClientSecret == "" {
+		form.Set("client_id", p.cfg.ClientID)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, meta.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("oidc: token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if p.cfg.ClientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(p.cfg.ClientID), url.QueryEscape(p.cfg.ClientSecret))
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("oidc: token request: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("oidc: token response: %w", err)
+	}
+	var tok struct {
+		IDToken          string `json:"id_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err := json.Unmarshal(body, &tok); err != nil {
+		return nil, fmt.Errorf("oidc: token response (HTTP %d): %w", resp.StatusCode, err)
+	}
+	if resp.StatusCode != http.StatusOK || tok.Error != "" {
+		return nil, fmt.Errorf("oidc: token endpoint returned HTTP %d: %s %s", resp.StatusCode, tok.Error, tok.ErrorDescription)
+	}
+	if tok.IDToken == "" {
+		return nil, errors.New("oidc: token response has no id_token")
+	}
+	return p.Verify(ctx, tok.IDToken, nonce)
+}
+
+// Verify checks an ID token's signature and claims and returns its claims.
+func (p *Provider) Verify(ctx context.Context, rawIDToken, nonce string) (*Claims, error) {
+	meta, err := p.metadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+	raw, err := p.verifySignature(ctx, meta, rawIDToken)
+	if err != nil {
+		return nil, err
+	}
+	return p.checkClaims(raw, nonce, time.Now())
+}
+
+// checkClaims validates the registered claims of a signature-verified token.
+func (p *Provider) checkClaims(raw map[string]any, nonce string, now time.Time) (*Claims, error) {
+	invalid := func(format string, args ...any) error {
+		return fmt.Errorf("%w: "+format, append([]any{ErrInvalidIDToken}, args...)...)
+	}
+	if iss, _ := raw["iss"].(string); strings.TrimSuffix(iss, "/") != p.cfg.IssuerURL {
+		return nil, invalid("issuer %q", iss)
+	}
+	var audiences []string
+	switch aud := raw["aud"].(type) {
+	case string:
+		audiences = []string{aud}
+	case []any:
+		for _, a := range aud {
+			if s, ok := a.(string); ok {
+				audiences = append(audiences, s)
+			}
+		}
+	}
+	if !slices.Contains(audiences, p.cfg.ClientID) {
+		return nil, invalid("audience %v does not include the client", audiences)
+	}
+	if azp, ok := raw["azp"].(string); ok && azp != p.cfg.ClientID {
+		return nil, invalid("authorized party %q", azp)
+	}
+	exp, ok := raw["exp"].(float64)
+	if !ok || now.After(time.Unix(int64(exp), 0).Add(clockSkew)) {
+		return nil, invalid("token expired")
+	}
+	if iat, ok := raw["iat"].(float64); ok && time.Unix(int64(iat), 0).After(now.Add(clockSkew)) {
+		return nil, invalid("token issued in the future")
+	}
+	if got, _ := raw["nonce"].(string); got != nonce {
+		return nil, invalid("nonce mismatch")
+	}
+	sub, _ := raw["sub"].(string)
+	if sub == "" {
+		return nil, invalid("no subject")
+	}
+
+	claims := &Claims{Subject: sub, Raw: raw}
+	claims.Email, _ = raw["email"].(string)
+	claims.Name, _ = raw["name"].(string)
+	if v, ok := raw["email_verified"].(bool); ok {
+		claims.EmailVerified = &v
+	}
+	return claims, nil
+}
+
+// getJSON fetches url and decodes its JSON body into v.
+func (p *Provider) getJSON(ctx context.Context, url string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s: HTTP %d", url, resp.StatusCode)
+	}
+	return json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(v)
+}
+
+// NewVerifier returns a random PKCE code verifier (43 URL-safe characters).
+// It also serves for state and nonce values.
+func NewVerifier() (string, error) {
+	b := make([]byte, 32)
+	if _, err := io.ReadFull(rand.Reader, b); err != nil {
+		return "", fmt.Errorf("oidc: random: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// Challenge returns the S256 PKCE code challenge for verifier.
+func Challenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
@@ -0,0 +1,156 @@
+package oidc_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/thebtf/engram/internal/auth/oidc"
+	"github.com/thebtf/engram/internal/auth/oidc/oidctest"
+)
+
+const redirectURL = "http://engram.test/api/auth/oidc/callback"
+
+// authorize runs the authorization step against the stub issuer and returns
+// the code and state it redirects back with.
+func authorize(t *testing.T, p *oidc.Provider, state, nonce, verifier string) (string, string) {
+	t.Helper()
+	authURL, err := p.AuthCodeURL(context.Background(), state, nonce, verifier)
+	require.NoError(t, err)
+	u, err := url.Parse(authURL)
+	require.NoError(t, err)
+	assert.Equal(t, "S256", u.Query().Get("code_challenge_method"))
+	assert.Equal(t, oidc.Challenge(verifier), u.Query().Get("code_challenge"))
+	assert.Equal(t, "openid email profile", u.Query().Get("scope"))
+
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+	resp, err := client.Get(authURL)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusFound, resp.StatusCode)
+	back, err := url.Parse(resp.Header.Get("Location"))
+	require.NoError(t, err)
+	return back.Query().Get("code"), back.Query().Get("state")
+}
+
+func newProvider(t *testing.T, iss *oidctest.Issuer) *oidc.Provider {
+	t.Helper()
+	p, err := oidc.New(oidc.Config{
+		IssuerURL:    iss.URL,
+		ClientID:     iss.ClientID,
+		ClientSecret: iss.ClientSecret,
+		RedirectURL:  redirectURL,
+	})
+	require.NoError(t, err)
+	return p
+}
+
+func TestProvider_AuthorizationCodeFlow(t *testing.T) {
+	for _, secret := range []string{"s3cret", ""} {
+		t.Run("secret="+secret, func(t *testing.T) {
+			iss := oidctest.New("engram", secret)
+			defer iss.Close()
+			iss.Claims(map[string]any{"sub": "u-42", "email": "ada@example.com", "email_verified": true, "groups": []string{"staff"}})
+			p := newProvider(t, iss)
+
+			verifier, err := oidc.NewVerifier()
+			require.NoError(t, err)
+			code, state := authorize(t, p, "state-1", "nonce-1", verifier)
+			assert.Equal(t, "state-1", state)
+
+			claims, err := p.Exchange(context.Background(), code, verifier, "nonce-1")
+			require.NoError(t, err)
+			assert.Equal(t, "u-42", claims.Subject)
+			assert.Equal(t, "ada@example.com", claims.Email)
+			require.NotNil(t, claims.EmailVerified)
+			assert.True(t, *claims.EmailVerified)
+
+			_, err = p.Exchange(context.Background(), code, verifier, "nonce-1")
+			assert.Error(t, err, "a code is redeemable once")
+		})
+	}
+}
+
+func TestProvider_RejectsWrongVerifierAndNonce(t *testing.T) {
+	iss := oidctest.New("engram", "")
+	defer iss.Close()
+	p := newProvider(t, iss)
+
+	verifier, _ := oidc.NewVerifier()
+	other, _ := oidc.NewVerifier()
+	code, _ := authorize(t, p, "s", "n", verifier)
+	_, err := p.Exchange(context.Background(), code, other, "n")
+	assert.ErrorContains(t, err, "PKCE")
+
+	code, _ = authorize(t, p, "s", "n", verifier)
+	_, err = p.Exchange(context.Background(), code, verifier, "another-nonce")
+	assert.True(t, errors.Is(err, oidc.ErrInvalidIDToken), "nonce mismatch: %v", err)
+}
+
+func TestProvider_VerifyClaims(t *testing.T) {
+	iss := oidctest.New("engram", "")
+	defer iss.Close()
+	p := newProvider(t, iss)
+	now := time.Now()
+	valid := func() map[string]any {
+		return map[string]any{
+			"iss": iss.URL, "aud": "engram", "sub": "u-1", "nonce": "n",
+			"iat": now.Unix(), "exp": now.Add(time.Minute).Unix(),
+		}
+	}
+
+	_, err := p.Verify(context.Background(), iss.SignToken(valid()), "n")
+	require.NoError(t, err)
+
+	cases := map[string]func(map[string]any){
+		"expired":        func(c map[string]any) { c["exp"] = now.Add(-time.Hour).Unix() },
+		"wrong audience": func(c map[string]any) { c["aud"] = "someone-else" },
+		"wrong issuer":   func(c map[string]any) { c["iss"] = "https://evil.example" },
+		"foreign azp":    func(c map[string]any) { c["aud"] = []string{"engram", "other"}; c["azp"] = "other" },
+		"no subject":     func(c map[string]any) { delete(c, "sub") },
+	}
+	for name, mutate := range cases {
+		claims := valid()
+		mutate(claims)
+		_, err := p.Verify(context.Background(), iss.SignToken(claims), "n")
+		assert.True(t, errors.Is(err, oidc.ErrInvalidIDToken), "%s: %v", name, err)
+	}
+
+	token := iss.SignToken(valid())
+	_, err = p.Verify(context.Background(), token[:len(token)-4]+"AAAA", "n")
+	assert.True(t, errors.Is(err, oidc.ErrInvalidIDToken), "tampered signature: %v", err)
+}
+
+func TestProvider_DiscoveryIssuerMismatch(t *testing.T) {
+	iss := oidctest.New("engram", "")
+	defer iss.Close()
+	p, err := oidc.New(oidc.Config{IssuerURL: iss.URL + "/realms/other", ClientID: "engram", RedirectURL: redirectURL})
+	require.NoError(t, err)
+	_, err = p.AuthCodeURL(context.Background(), "s", "n", "v")
+	assert.Error(t, err)
+}
+
+func TestRoleMapping(t *testing.T) {
+	claims := func(groups any) *oidc.Claims {
+		return &oidc.Claims{Raw: map[string]any{"groups": groups}}
+	}
+	m := oidc.RoleMapping{Claim: "groups", AdminValues: []string{"engram-admins"}}
+	assert.Equal(t, oidc.RoleAdmin, m.Role(claims([]any{"staff", "engram-admins"})))
+	assert.Equal(t, oidc.RoleAdmin, m.Role(claims("engram-admins")))
+	assert.Equal(t, oidc.RoleOperator, m.Role(claims([]any{"staff"})))
+	assert.Equal(t, oidc.RoleOperator, m.Role(claims(nil)))
+	assert.True(t, m.Authoritative())
+
+	m.OperatorValues = []string{"engram-users"}
+	assert.Equal(t, oidc.RoleOperator, m.Role(claims([]any{"engram-users"})))
+	assert.Equal(t, "", m.Role(claims([]any{"staff"})), "not in any mapped group")
+
+	assert.Equal(t, oidc.RoleOperator, oidc.RoleMapping{}.Role(claims(nil)))
+	assert.False(t, oidc.RoleMapping{}.Authoritative())
+}
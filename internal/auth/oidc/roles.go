@@ -0,0 +1,63 @@
+package oidc
+
+import "slices"
+
+// Engram user roles a login can map to (gorm User.Role).
+const (
+	RoleAdmin    = "admin"
+	RoleOperator = "operator"
+)
+
+// RoleMapping maps ID token claims to an engram user role. Claim names a
+// string or string-array claim ("groups", "roles", ...). A login whose claim
+// holds one of AdminValues is an admin; otherwise, one holding one of
+// OperatorValues — or any login when OperatorValues is empty — is an
+// operator. Everyone else is refused.
+type RoleMapping struct {
+	Claim          string
+	AdminValues    []string
+	OperatorValues []string
+}
+
+// Role returns the role claims map to, or "" when the login is refused.
+func (m RoleMapping) Role(claims *Claims) string {
+	values := claimValues(claims.Raw[m.Claim])
+	if m.Claim != "" && len(m.AdminValues) > 0 && slices.ContainsFunc(values, func(v string) bool {
+		return slices.Contains(m.AdminValues, v)
+	}) {
+		return RoleAdmin
+	}
+	if len(m.OperatorValues) == 0 {
+		return RoleOperator
+	}
+	if m.Claim != "" && slices.ContainsFunc(values, func(v string) bool {
+		return slices.Contains(m.OperatorValues, v)
+	}) {
+		return RoleOperator
+	}
+	return ""
+}
+
+// Authoritative reports whether the mapping can grant admin, in which case
+// the provider is the source of truth for roles: each login re-applies the
+// mapped role to an existing user.
+func (m RoleMapping) Authoritative() bool {
+	return m.Claim != "" && len(m.AdminValues) > 0
+}
+
+// claimValues flattens a string or string-array claim.
+func claimValues(v any) []string {
+	switch c := v.(type) {
+	case string:
+		return []string{c}
+	case []any:
+		out := make([]string, 0, len(c))
+		for _, item := range c {
+			if s, ok := item.(string); ok {
+				out = append(out, s)
+			}
+		}
+		return out
+	}
+	return nil
+}
@@ -0,0 +1,152 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"slices"
+)
+
+// Permission is a named capability checked by Authorize. Roles bundle
+// permissions; every transport maps the operation it is about to run to one
+// permission (HTTP by route, gRPC by method, MCP by tool and action).
+type Permission string
+
+const (
+	// PermMemoryRead covers reading memories, observations, sessions,
+	// context, search, analytics and stats.
+	PermMemoryRead Permission = "memory:read"
+	// PermMemoryWrite covers storing, editing and rating memories and
+	// recording sessions and events.
+	PermMemoryWrite Permission = "memory:write"
+	// PermIssuesRead covers listing, reading and exporting issues.
+	PermIssuesRead Permission = "issues:read"
+	// PermIssuesWrite covers filing, updating, claiming, linking and
+	// importing issues.
+	PermIssuesWrite Permission = "issues:write"
+	// PermDocsRead covers reading documents and collections.
+	PermDocsRead Permission = "docs:read"
+	// PermDocsWrite covers creating, ingesting, editing and removing
+	// documents.
+	PermDocsWrite Permission = "docs:write"
+	// PermRulesWrite covers creating and changing behavioral rules. Rules
+	// are read with PermMemoryRead.
+	PermRulesWrite Permission = "rules:write"
+	// PermVaultRead covers listing credentials and revealing their values,
+	// still subject to each credential's access policy.
+	PermVaultRead Permission = "vault:read"
+	// PermVaultWrite covers storing and deleting credentials.
+	PermVaultWrite Permission = "vault:write"
+	// PermVaultAdmin covers credential access policies, the access log and
+	// key rotation, and bypasses credential access policies.
+	PermVaultAdmin Permission = "vault:admin"
+	// PermSystemWrite covers server operations: webhooks, updates and
+	// restarts, project removal and bulk imports.
+	PermSystemWrite Permission = "system:write"
+	// PermAdminTokens covers issuing, rotating and revoking keycards, from a
+	// browser session only.
+	PermAdminTokens Permission = "admin:tokens"
+	// PermAdminUsers covers dashboard users, invitations and roles. It can
+	// grant any other permission, so it is as strong as admin.
+	PermAdminUsers Permission = "admin:users"
+)
+
+// AllPermissions lists every permission, in display order.
+var AllPermissions = []Permission{
+	PermMemoryRead, PermMemoryWrite,
+	PermIssuesRead, PermIssuesWrite,
+	PermDocsRead, PermDocsWrite,
+	PermRulesWrite,
+	PermVaultRead, PermVaultWrite, PermVaultAdmin,
+	PermSystemWrite,
+	PermAdminTokens, PermAdminUsers,
+}
+
+// RoleOperator is the non-admin dashboard user role (users.role).
+const RoleOperator Role = "operator"
+
+// readWritePermissions is everything but administration: what read-write
+// keycards and operators could do before permissions existed.
+var readWritePermissions = []Permission{
+	PermMemoryRead, PermMemoryWrite,
+	PermIssuesRead, PermIssuesWrite,
+	PermDocsRead, PermDocsWrite,
+	PermRulesWrite,
+	PermVaultRead, PermVaultWrite,
+	PermSystemWrite,
+}
+
+// BuiltinRoles are the roles that always exist, with their permissions.
+// Custom roles (see RoleSource) cannot reuse these names.
+var BuiltinRoles = map[Role][]Permission{
+	RoleAdmin:     AllPermissions,
+	RoleOperator:  readWritePermissions,
+	RoleReadWrite: readWritePermissions,
+	RoleReadOnly:  {PermMemoryRead, PermIssuesRead, PermDocsRead, PermVaultRead},
+}
+
+// IsBuiltinRole reports whether name is one of BuiltinRoles.
+func IsBuiltinRole(name string) bool {
+	_, ok := BuiltinRoles[Role(name)]
+	return ok
+}
+
+// ValidPermission reports whether p is one of AllPermissions.
+func ValidPermission(p Permission) bool {
+	return slices.Contains(AllPermissions, p)
+}
+
+// RoleSource looks up custom roles. The production binding is
+// *gormdb.RoleStore.
+type RoleSource interface {
+	// RolePermissions returns the permissions of the custom role name, and
+	// false when no such role exists.
+	RolePermissions(ctx context.Context, name string) ([]string, bool, error)
+}
+
+// ResolveRole resolves name to its permissions: a built-in role, or a custom
+// role from src (which may be nil). Unknown permission strings are dropped so a stale
+// role row cannot grant anything new. The bool is false for unknown roles.
+func ResolveRole(ctx context.Context, src RoleSource, name string) ([]Permission, bool, error) {
+	if perms, ok := BuiltinRoles[Role(name)]; ok {
+		return slices.Clone(perms), true, nil
+	}
+	if src == nil {
+		return nil, false, nil
+	}
+	raw, ok, err := src.RolePermissions(ctx, name)
+	if err != nil || !ok {
+		return nil, false, err
+	}
+	perms := make([]Permission, 0, len(raw))
+	for _, p := range raw {
+		if ValidPermission(Permission(p)) {
+			perms = append(perms, Permission(p))
+		}
+	}
+	return perms, true, nil
+}
+
+// Can reports whether the bearer holds permission p.
+func (i Identity) Can(p Permission) bool {
+	return slices.Contains(i.Permissions, p)
+}
+
+// Require returns nil when the bearer holds p, and an error wrapping
+// ErrPermissionDenied otherwise.
+func (i Identity) Require(p Permission) error {
+	if i.Can(p) {
+		return nil
+	}
+	return fmt.Errorf("%w: role %q lacks %s", ErrPermissionDenied, i.Role, p)
+}
+
+// Authorize is the policy check every transport runs before an operation
+// that needs p. A context without an Identity passes: authentication is
+// disabled, or the call is in-process (stdio MCP, background jobs).
+func Authorize(ctx context.Context, p Permission) error {
+	id, ok := IdentityFrom(ctx)
+	if !ok {
+		return nil
+	}
+	return id.Require(p)
+}
@@ -0,0 +1,44 @@
+package auth_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/thebtf/engram/internal/auth"
+)
+
+func TestBuiltinRoles(t *testing.T) {
+	for _, p := range auth.AllPermissions {
+		assert.True(t, auth.Admin().Can(p), "admin holds %s", p)
+	}
+
+	rw := auth.Client("read-write", "uuid-1")
+	operator := auth.Session("operator")
+	for _, id := range []auth.Identity{rw, operator} {
+		assert.True(t, id.Can(auth.PermMemoryWrite))
+		assert.True(t, id.Can(auth.PermVaultRead))
+		assert.False(t, id.Can(auth.PermVaultAdmin))
+		assert.False(t, id.Can(auth.PermAdminTokens))
+		assert.False(t, id.Can(auth.PermAdminUsers))
+	}
+
+	ro := auth.Client("read-only", "uuid-2")
+	assert.True(t, ro.Can(auth.PermIssuesRead))
+	assert.False(t, ro.Can(auth.PermIssuesWrite))
+	assert.False(t, ro.Can(auth.PermMemoryWrite))
+
+	assert.Empty(t, auth.Client("custom", "uuid-3").Permissions, "custom roles are resolved by the validator")
+}
+
+func TestAuthorize(t *testing.T) {
+	assert.NoError(t, auth.Authorize(context.Background(), auth.PermVaultRead), "no identity: auth disabled or in-process")
+
+	ctx := auth.WithIdentity(context.Background(), auth.Client("read-only", "uuid-1"))
+	assert.NoError(t, auth.Authorize(ctx, auth.PermVaultRead))
+	err := auth.Authorize(ctx, auth.PermVaultWrite)
+	assert.True(t, errors.Is(err, auth.ErrPermissionDenied), "got %v", err)
+	assert.Contains(t, err.Error(), "vault:write")
+}
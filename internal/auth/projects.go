@@ -0,0 +1,67 @@
+package auth
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// maxProjectPatterns bounds a keycard allowlist. Every authenticated request
+// from the keycard walks the list, so it is kept short.
+const maxProjectPatterns = 100
+
+// MatchProject reports whether project matches an allowlist entry. Entries
+// without glob metacharacters match exactly; the rest use path.Match syntax,
+// so "acme-*" matches "acme-api" and "acme/*" matches "acme/api" but not
+// "acme/api/v2" (a star never crosses a slash).
+func MatchProject(pattern, project string) bool {
+	if !strings.ContainsAny(pattern, `*?[\`) {
+		return pattern == project
+	}
+	ok, err := path.Match(pattern, project)
+	return err == nil && ok
+}
+
+// NormalizeProjectPatterns validates a keycard allowlist and returns it
+// trimmed, without blanks and duplicates. A nil or empty result means the
+// keycard is unrestricted.
+func NormalizeProjectPatterns(patterns []string) ([]string, error) {
+	out := make([]string, 0, len(patterns))
+	seen := make(map[string]bool, len(patterns))
+	for _, p := range patterns {
+		p = strings.TrimSpace(p)
+		if p == "" || seen[p] {
+			continue
+		}
+		if len(p) > 500 {
+			return nil, fmt.Errorf("project pattern is too long (%d chars, max 500)", len(p))
+		}
+		if _, err := path.Match(p, ""); err != nil {
+			return nil, fmt.Errorf("invalid project pattern %q: %w", p, err)
+		}
+		seen[p] = true
+		out = append(out, p)
+	}
+	if len(out) > maxProjectPatterns {
+		return nil, fmt.Errorf("too many project patterns (%d, max %d)", len(out), maxProjectPatterns)
+	}
+	return out, nil
+}
+
+// CheckProjects returns ErrProjectForbidden unless the identity may reach
+// every one of projects. A scoped identity must name at least one project:
+// with none, the request would read or write across all projects.
+func (i Identity) CheckProjects(projects ...string) error {
+	if !i.IsProjectScoped() {
+		return nil
+	}
+	if len(projects) == 0 {
+		return fmt.Errorf("%w: the request must name a project", ErrProjectForbidden)
+	}
+	for _, p := range projects {
+		if !i.CanAccessProject(p) {
+			return fmt.Errorf("%w: %q", ErrProjectForbidden, p)
+		}
+	}
+	return nil
+}
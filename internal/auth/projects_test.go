@@ -0,0 +1,59 @@
+package auth_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/thebtf/engram/internal/auth"
+)
+
+func TestMatchProject(t *testing.T) {
+	t.Parallel()
+	cases := []struct {
+		pattern, project string
+		want             bool
+	}{
+		{"acme", "acme", true},
+		{"acme", "acme-api", false},
+		{"acme-*", "acme-api", true},
+		{"acme-*", "globex-api", false},
+		{"acme/*", "acme/api", true},
+		{"acme/*", "acme/api/v2", false},
+		{"proj-?", "proj-1", true},
+		{"[", "[", false},
+	}
+	for _, c := range cases {
+		assert.Equal(t, c.want, auth.MatchProject(c.pattern, c.project), "%q vs %q", c.pattern, c.project)
+	}
+}
+
+func TestNormalizeProjectPatterns(t *testing.T) {
+	t.Parallel()
+
+	got, err := auth.NormalizeProjectPatterns([]string{" acme ", "", "acme", "acme-*"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"acme", "acme-*"}, got)
+
+	_, err = auth.NormalizeProjectPatterns([]string{"acme-["})
+	assert.Error(t, err)
+}
+
+func TestIdentity_CheckProjects(t *testing.T) {
+	t.Parallel()
+	scoped := auth.Client("read-write", "uuid-1", "acme", "labs-*")
+
+	assert.True(t, scoped.IsProjectScoped())
+	assert.NoError(t, scoped.CheckProjects("acme", "labs-x"))
+	assert.True(t, errors.Is(scoped.CheckProjects("acme", "globex"), auth.ErrProjectForbidden))
+	assert.True(t, errors.Is(scoped.CheckProjects(), auth.ErrProjectForbidden),
+		"a scoped keycard must name a project")
+	assert.False(t, scoped.CanAccessProject(""))
+
+	unscoped := auth.Client("read-write", "uuid-2")
+	assert.False(t, unscoped.IsProjectScoped())
+	assert.NoError(t, unscoped.CheckProjects())
+	assert.True(t, auth.Admin().CanAccessProject("anything"))
+}
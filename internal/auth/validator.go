@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"strings"
+	"time"
 
 	"golang.org/x/crypto/bcrypt"
 
@@ -70,7 +71,8 @@ type TokenStoreReader interface {
 	// FindByPrefix returns all NON-revoked tokens whose token_prefix column
 	// equals prefix. The contract on the engram side already filters
 	// revoked rows; the validator therefore does not need to inspect the
-	// Revoked field on returned rows.
+	// Revoked field on returned rows. Expired rows ARE returned: the
+	// validator rejects them itself so the rejection can say why.
 	FindByPrefix(ctx context.Context, prefix string) ([]gormdb.APIToken, error)
 }
 
@@ -84,6 +86,7 @@ type TokenStoreReader interface {
 type Validator struct {
 	masterToken string           // Tier 1 — operator key from server-host env.
 	store       TokenStoreReader // Tier 2 — dashboard-issued keycards.
+	roles       RoleSource       // custom roles; nil = built-in roles only.
 }
 
 // NewValidator constructs a Validator bound to the given master token and
@@ -95,6 +98,26 @@ func NewValidator(masterToken string, store TokenStoreReader) *Validator {
 	return &Validator{masterToken: masterToken, store: store}
 }
 
+// SetRoleSource lets keycards and sessions carry custom roles, resolved
+// through src on every authentication so role edits apply immediately. Call
+// it before the validator is shared.
+func (v *Validator) SetRoleSource(src RoleSource) {
+	v.roles = src
+}
+
+// SessionIdentity returns the Identity of a browser session whose user has
+// role. A custom role is resolved through the role source; a role that no
+// longer exists grants nothing.
+func (v *Validator) SessionIdentity(ctx context.Context, role string) (Identity, error) {
+	id := Session(role)
+	perms, _, err := ResolveRole(ctx, v.roles, role)
+	if err != nil {
+		return Identity{}, fmt.Errorf("auth: role lookup: %w", err)
+	}
+	id.Permissions = perms
+	return id, nil
+}
+
 // Validate runs the two-tier authentication chain on raw and returns the
 // resulting Identity, or an error matching ErrEmptyToken / ErrInvalidCredentials
 // / a wrapped store error.
@@ -107,7 +130,7 @@ func NewValidator(masterToken string, store TokenStoreReader) *Validator {
 //     touching the database, preventing token-shape probing from generating DB
 //     load.
 //  4. Prefix lookup → bcrypt loop over candidates → SourceClient Identity on
-//     the first match.
+//     the first match, or ErrExpired when its expires_at has passed.
 //  5. No match → ErrInvalidCredentials.
 //
 // The validator deliberately does NOT distinguish "no candidate" from "bcrypt
@@ -164,20 +187,34 @@ func (v *Validator) Validate(ctx context.Context, raw string) (Identity, error)
 			[]byte(raw),
 		)
 		if err == nil {
+			if exp := candidates[i].ExpiresAt; exp != nil && !time.Now().Before(*exp) {
+				return Identity{}, fmt.Errorf("%w: keycard %s expired at %s",
+					ErrExpired, candidates[i].ID, exp.UTC().Format(time.RFC3339))
+			}
 			// Defense-in-depth: api_tokens.scope is plain text. A row with
 			// scope="admin" (data corruption, malicious INSERT, future
 			// schema drift) MUST NOT promote a worker keycard to admin.
-			// Whitelist the two values issuance is allowed to write.
-			switch Role(candidates[i].Scope) {
+			// Besides the two built-in keycard roles, only custom roles
+			// (which can never be named like a built-in one) are allowed.
+			scope := candidates[i].Scope
+			switch Role(scope) {
 			case RoleReadWrite, RoleReadOnly:
-				return Client(candidates[i].Scope, candidates[i].ID), nil
-			default:
+				return Client(scope, candidates[i].ID, candidates[i].AllowedProjects...), nil
+			}
+			perms, ok, err := ResolveRole(ctx, v.roles, scope)
+			if err != nil {
+				return Identity{}, fmt.Errorf("auth: role lookup: %w", err)
+			}
+			if !ok || IsBuiltinRole(scope) {
 				return Identity{}, fmt.Errorf(
-					"auth: keycard %s has unexpected scope %q (allowed: %q, %q)",
-					candidates[i].ID, candidates[i].Scope,
+					"auth: keycard %s has unexpected scope %q (allowed: %q, %q or a custom role)",
+					candidates[i].ID, scope,
 					RoleReadWrite, RoleReadOnly,
 				)
 			}
+			id := Client(scope, candidates[i].ID, candidates[i].AllowedProjects...)
+			id.Permissions = perms
+			return id, nil
 		}
 		// bcrypt.ErrMismatchedHashAndPassword is the expected miss; only
 		// log on unexpected errors (bcrypt cost/format issues).
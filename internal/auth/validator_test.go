@@ -5,6 +5,7 @@ import (
 	"errors"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -169,6 +170,98 @@ func TestValidate_ValidPrefix_ReadOnlyScope(t *testing.T) {
 	assert.Equal(t, auth.SourceClient, id.Source)
 }
 
+func TestValidate_ProjectScopedKeycard(t *testing.T) {
+	t.Parallel()
+	raw := "engram_cafef00d000000000000000000000003"
+	keycard := makeKeycard(t, "uuid-scoped", raw, "read-write", false)
+	keycard.AllowedProjects = []string{"acme", "labs-*"}
+	store := &stubStore{byPrefix: map[string][]gormdb.APIToken{"cafef00d": {keycard}}}
+	v := auth.NewValidator("master-secret", store)
+
+	id, err := v.Validate(context.Background(), raw)
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"acme", "labs-*"}, id.Projects)
+	assert.True(t, id.CanAccessProject("labs-vision"))
+	assert.False(t, id.CanAccessProject("globex"))
+}
+
+// stubRoles is a RoleSource fake.
+type stubRoles map[string][]string
+
+func (r stubRoles) RolePermissions(_ context.Context, name string) ([]string, bool, error) {
+	perms, ok := r[name]
+	return perms, ok, nil
+}
+
+func TestValidate_CustomRoleKeycard(t *testing.T) {
+	t.Parallel()
+	raw := "engram_cafef00d000000000000000000000005"
+	ci := makeKeycard(t, "uuid-ci", raw, "ci-reporter", false)
+	store := &stubStore{byPrefix: map[string][]gormdb.APIToken{"cafef00d": {ci}}}
+	v := auth.NewValidator("master-secret", store)
+
+	_, err := v.Validate(context.Background(), raw)
+	assert.Error(t, err, "a custom role needs a role source")
+
+	v.SetRoleSource(stubRoles{"ci-reporter": {"issues:read", "issues:write", "bogus:perm"}})
+	id, err := v.Validate(context.Background(), raw)
+	require.NoError(t, err)
+	assert.Equal(t, auth.Role("ci-reporter"), id.Role)
+	assert.Equal(t, []auth.Permission{auth.PermIssuesRead, auth.PermIssuesWrite}, id.Permissions,
+		"unknown permission strings are dropped")
+	assert.True(t, id.Can(auth.PermIssuesWrite))
+	assert.False(t, id.Can(auth.PermVaultRead))
+
+	for _, scope := range []string{"admin", "operator", "deleted-role"} {
+		keycard := makeKeycard(t, "uuid-"+scope, raw, scope, false)
+		store.byPrefix["cafef00d"] = []gormdb.APIToken{keycard}
+		_, err := v.Validate(context.Background(), raw)
+		assert.Error(t, err, "scope %q must not authenticate", scope)
+	}
+}
+
+func TestValidator_SessionIdentity(t *testing.T) {
+	t.Parallel()
+	v := auth.NewValidator("master-secret", &stubStore{})
+	v.SetRoleSource(stubRoles{"auditor": {"memory:read", "vault:admin"}})
+
+	id, err := v.SessionIdentity(context.Background(), "auditor")
+	require.NoError(t, err)
+	assert.Equal(t, auth.SourceSession, id.Source)
+	assert.True(t, id.Can(auth.PermVaultAdmin))
+	assert.False(t, id.IsSessionAdmin())
+
+	id, err = v.SessionIdentity(context.Background(), "admin")
+	require.NoError(t, err)
+	assert.True(t, id.IsSessionAdmin())
+
+	id, err = v.SessionIdentity(context.Background(), "removed")
+	require.NoError(t, err)
+	assert.Empty(t, id.Permissions, "a role that no longer exists grants nothing")
+}
+
+func TestValidate_ExpiredKeycard(t *testing.T) {
+	t.Parallel()
+	raw := "engram_cafef00d000000000000000000000004"
+	past := time.Now().Add(-time.Minute)
+	future := time.Now().Add(time.Hour)
+	expired := makeKeycard(t, "uuid-expired", raw, "read-write", false)
+	expired.ExpiresAt = &past
+	v := auth.NewValidator("master-secret", &stubStore{byPrefix: map[string][]gormdb.APIToken{"cafef00d": {expired}}})
+
+	_, err := v.Validate(context.Background(), raw)
+	assert.True(t, errors.Is(err, auth.ErrExpired), "expected ErrExpired, got %v", err)
+
+	live := makeKeycard(t, "uuid-live", raw, "read-write", false)
+	live.ExpiresAt = &future
+	v = auth.NewValidator("master-secret", &stubStore{byPrefix: map[string][]gormdb.APIToken{"cafef00d": {live}}})
+
+	id, err := v.Validate(context.Background(), raw)
+	require.NoError(t, err)
+	assert.Equal(t, "uuid-live", id.KeycardID)
+}
+
 func TestValidate_PrefixCollision_TwoCandidates_OneMatches(t *testing.T) {
 	t.Parallel()
 	rawA := "engram_facade00000000000000000000000aaa"
@@ -261,3 +354,6 @@ func TestValidate_MasterAndKeycardDistinguishable(t *testing.T) {
 // If gormdb.TokenStore changes its FindByPrefix signature, this file fails to
 // compile, surfacing the contract drift immediately.
 var _ auth.TokenStoreReader = (*gormdb.TokenStore)(nil)
+
+// Likewise for gormdb.RoleStore and auth.RoleSource.
+var _ auth.RoleSource = (*gormdb.RoleStore)(nil)
@@ -40,7 +40,11 @@ func (c *Chunker) Chunk(ctx context.Context, filePath string) ([]chunking.Chunk,
 	if err != nil {
 		return nil, fmt.Errorf("read file: %w", err)
 	}
+	return c.ChunkContent(ctx, filePath, string(content))
+}
 
+// ChunkContent parses Go source supplied as a string and returns semantic code chunks.
+func (c *Chunker) ChunkContent(ctx context.Context, filePath, content string) ([]chunking.Chunk, error) {
 	// Parse the Go file
 	fset := token.NewFileSet()
 	file, err := parser.ParseFile(fset, filePath, content, parser.ParseComments)
@@ -49,7 +53,7 @@ func (c *Chunker) Chunk(ctx context.Context, filePath string) ([]chunking.Chunk,
 	}
 
 	chunks := make([]chunking.Chunk, 0)
-	sourceLines := strings.Split(string(content), "\n")
+	sourceLines := strings.Split(content, "\n")
 
 	// Extract chunks from declarations
 	for _, decl := range file.Decls {
@@ -212,3 +212,36 @@ func Calculate(a, b int) int {
 		t.Errorf("Expected doc comment '%s', got '%s'", expectedComment, chunk.DocComment)
 	}
 }
+
+func TestGoChunker_ChunkContent(t *testing.T) {
+	chunker := NewChunker(chunking.DefaultChunkOptions())
+
+	// The path is recorded on the chunks but never read.
+	chunks, err := chunker.ChunkContent(context.Background(), "does/not/exist.go", `package store
+
+type Store struct{}
+
+// Get fetches a value.
+func (s *Store) Get(key string) string {
+	return key
+}
+`)
+	if err != nil {
+		t.Fatalf("ChunkContent() failed: %v", err)
+	}
+	if len(chunks) != 2 {
+		t.Fatalf("Expected 2 chunks (Store, Store.Get), got %d", len(chunks))
+	}
+
+	get := chunks[1]
+	if get.Identifier() != "Store.Get" || get.FilePath != "does/not/exist.go" {
+		t.Errorf("Unexpected chunk %s in %s", get.Identifier(), get.FilePath)
+	}
+	if get.LineRange() != "L6-L8" {
+		t.Errorf("Expected L6-L8, got %s", get.LineRange())
+	}
+
+	if _, err := chunker.ChunkContent(context.Background(), "bad.go", "not go"); err == nil {
+		t.Error("Expected parse error")
+	}
+}
@@ -69,6 +69,82 @@ func (m *Manager) ChunkFile(ctx context.Context, filePath string) ([]Chunk, erro
 	return filtered, nil
 }
 
+// ChunkContent chunks content already held in memory, picking the chunker by
+// filePath's extension. Content that no registered chunker handles (plain
+// text, YAML, ...) becomes a single ChunkTypeText chunk, and a chunker that
+// cannot work from memory is an error.
+//
+// Unlike ChunkFile, which skips chunks larger than MaxChunkSize, ChunkContent
+// splits them at line boundaries, so every line of the content stays in some
+// chunk. This is what document search needs: a skipped chunk is text that can
+// never be found.
+func (m *Manager) ChunkContent(ctx context.Context, filePath, content string) ([]Chunk, error) {
+	if content == "" {
+		return nil, nil
+	}
+
+	var chunks []Chunk
+	ext := strings.ToLower(filepath.Ext(filePath))
+	if chunker, ok := m.chunkers[ext]; ok {
+		cc, ok := chunker.(ContentChunker)
+		if !ok {
+			return nil, fmt.Errorf("chunker for extension %s cannot chunk in-memory content", ext)
+		}
+		var err error
+		chunks, err = cc.ChunkContent(ctx, filePath, content)
+		if err != nil {
+			return nil, fmt.Errorf("chunk %s: %w", filePath, err)
+		}
+	} else {
+		chunks = []Chunk{{
+			FilePath:  filePath,
+			Type:      ChunkTypeText,
+			Content:   content,
+			StartLine: 1,
+			EndLine:   strings.Count(strings.TrimSuffix(content, "\n"), "\n") + 1,
+		}}
+	}
+
+	result := make([]Chunk, 0, len(chunks))
+	for _, chunk := range chunks {
+		if m.options.MinLines > 0 && chunk.EndLine-chunk.StartLine+1 < m.options.MinLines {
+			continue
+		}
+		if m.options.MaxChunkSize > 0 && len(chunk.Content) > m.options.MaxChunkSize {
+			result = append(result, splitChunk(chunk, m.options.MaxChunkSize)...)
+			continue
+		}
+		result = append(result, chunk)
+	}
+	return result, nil
+}
+
+// splitChunk cuts an oversized chunk into consecutive pieces of at most
+// maxSize bytes, breaking only between lines. A single line longer than
+// maxSize becomes a piece of its own. Pieces keep the chunk's name and type
+// so search results still point at the enclosing section or symbol.
+func splitChunk(chunk Chunk, maxSize int) []Chunk {
+	lines := strings.Split(chunk.Content, "\n")
+	pieces := make([]Chunk, 0, len(chunk.Content)/maxSize+1)
+	start, size := 0, 0
+	flush := func(end int) {
+		piece := chunk
+		piece.Content = strings.Join(lines[start:end], "\n")
+		piece.StartLine = chunk.StartLine + start
+		piece.EndLine = chunk.StartLine + end - 1
+		pieces = append(pieces, piece)
+		start, size = end, 0
+	}
+	for i, line := range lines {
+		if i > start && size+len(line)+1 > maxSize {
+			flush(i)
+		}
+		size += len(line) + 1
+	}
+	flush(len(lines))
+	return pieces
+}
+
 // ChunkFiles chunks multiple files in parallel.
 // Returns a map of file path to chunks, and any errors encountered.
 // Errors for individual files do not stop processing of other files.
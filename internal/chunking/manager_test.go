@@ -4,6 +4,7 @@ import (
 	"context"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -160,3 +161,68 @@ func TestManager_SupportedExtensions(t *testing.T) {
 		}
 	}
 }
+
+// contentChunker is a mock chunker that works from in-memory content and
+// returns the whole content as one named chunk.
+type contentChunker struct{ mockChunkerWithExts }
+
+func (c *contentChunker) ChunkContent(ctx context.Context, filePath, content string) ([]Chunk, error) {
+	return []Chunk{{
+		FilePath:  filePath,
+		Type:      ChunkTypeFunction,
+		Name:      "Whole",
+		Content:   content,
+		StartLine: 1,
+		EndLine:   strings.Count(content, "\n") + 1,
+	}}, nil
+}
+
+func TestManager_ChunkContent(t *testing.T) {
+	manager := NewManager([]Chunker{
+		&contentChunker{mockChunkerWithExts{exts: []string{".go"}}},
+		&mockChunkerWithExts{exts: []string{".py"}},
+	}, ChunkOptions{MaxChunkSize: 10})
+
+	// Registered content chunker: oversized chunks are split between lines.
+	chunks, err := manager.ChunkContent(context.Background(), "a.go", "aaaa\nbbbb\ncccc\ndddddddddddddd\ne")
+	if err != nil {
+		t.Fatalf("ChunkContent failed: %v", err)
+	}
+	want := []struct {
+		content    string
+		start, end int
+	}{
+		{"aaaa\nbbbb", 1, 2},
+		{"cccc", 3, 3},
+		{"dddddddddddddd", 4, 4},
+		{"e", 5, 5},
+	}
+	if len(chunks) != len(want) {
+		t.Fatalf("Expected %d chunks, got %d: %+v", len(want), len(chunks), chunks)
+	}
+	for i, w := range want {
+		c := chunks[i]
+		if c.Content != w.content || c.StartLine != w.start || c.EndLine != w.end || c.Name != "Whole" {
+			t.Errorf("chunk %d = %q %s %s, want %q L%d-L%d", i, c.Content, c.Name, c.LineRange(), w.content, w.start, w.end)
+		}
+	}
+
+	// No chunker for the extension: the content becomes a text chunk.
+	chunks, err = manager.ChunkContent(context.Background(), "notes.txt", "one\ntwo\n")
+	if err != nil {
+		t.Fatalf("ChunkContent failed: %v", err)
+	}
+	if len(chunks) != 1 || chunks[0].Type != ChunkTypeText || chunks[0].LineRange() != "L1-L2" {
+		t.Errorf("Expected one text chunk L1-L2, got %+v", chunks)
+	}
+
+	// A chunker that can only read from disk cannot chunk content.
+	if _, err := manager.ChunkContent(context.Background(), "a.py", "x = 1"); err == nil {
+		t.Error("Expected error for chunker without ChunkContent")
+	}
+
+	chunks, err = manager.ChunkContent(context.Background(), "a.go", "")
+	if err != nil || len(chunks) != 0 {
+		t.Errorf("Expected no chunks for empty content, got %v, %v", chunks, err)
+	}
+}
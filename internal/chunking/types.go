@@ -27,6 +27,8 @@ const (
 	ChunkTypeConst ChunkType = "const"
 	// ChunkTypeVar represents variable declarations.
 	ChunkTypeVar ChunkType = "var"
+	// ChunkTypeText represents a span of a file no language chunker handles.
+	ChunkTypeText ChunkType = "text"
 )
 
 // Language represents a programming language.
@@ -104,6 +106,13 @@ type Chunker interface {
 	SupportedExtensions() []string
 }
 
+// ContentChunker is implemented by chunkers that can chunk content already
+// held in memory, such as documents ingested through the API, without reading
+// the file from disk. filePath is recorded on the chunks but never opened.
+type ContentChunker interface {
+	ChunkContent(ctx context.Context, filePath, content string) ([]Chunk, error)
+}
+
 // ChunkOptions provides options for chunking behavior.
 type ChunkOptions struct {
 	// MaxChunkSize is the maximum size of a chunk in bytes.
@@ -9,10 +9,17 @@ import (
 )
 
 // Collection describes a named document namespace.
+//
+// Roots, Include and Exclude drive filesystem ingestion (engram-import
+// ingest-collection): Roots are directories walked relative to the base
+// directory, and Include/Exclude are globs over the resulting document paths.
 type Collection struct {
 	Name        string            `yaml:"name"`
 	Description string            `yaml:"description"`
 	PathContext  map[string]string `yaml:"path_context"`
+	Roots       []string          `yaml:"roots"`
+	Include     []string          `yaml:"include"`
+	Exclude     []string          `yaml:"exclude"`
 }
 
 // Config is the top-level YAML structure.
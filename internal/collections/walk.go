@@ -0,0 +1,123 @@
+package collections
+
+import (
+	"fmt"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Includes reports whether a document path (slash-separated, relative to the
+// base directory) belongs to the collection: it must match an Include glob,
+// or any path when Include is empty, and no Exclude glob. Exclude wins.
+//
+// Globs use path.Match syntax per segment plus "**", which matches any number
+// of segments including none, so "docs/**/*.md" also matches "docs/a.md" and
+// "vendor/**" matches "vendor" itself. A glob without a slash is matched
+// against the base name only, so "*.md" selects markdown at any depth.
+func (c *Collection) Includes(docPath string) bool {
+	if matchAny(c.Exclude, docPath) {
+		return false
+	}
+	return len(c.Include) == 0 || matchAny(c.Include, docPath)
+}
+
+// Files walks the collection's roots under baseDir and returns the document
+// paths it selects, slash-separated, relative to baseDir and sorted. A root
+// that is missing or outside baseDir is an error, so a typo cannot look like
+// a collection whose files were all deleted. Directories an Exclude glob
+// matches, and .git directories, are not descended into.
+func (c *Collection) Files(baseDir string) ([]string, error) {
+	if len(c.Roots) == 0 {
+		return nil, fmt.Errorf("collection %q has no roots", c.Name)
+	}
+	base, err := filepath.Abs(baseDir)
+	if err != nil {
+		return nil, fmt.Errorf("resolve base dir: %w", err)
+	}
+
+	seen := make(map[string]bool)
+	var files []string
+	for _, root := range c.Roots {
+		dir := root
+		if !filepath.IsAbs(dir) {
+			dir = filepath.Join(base, dir)
+		}
+		rel, err := filepath.Rel(base, dir)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return nil, fmt.Errorf("collection %q: root %q is outside %s", c.Name, root, base)
+		}
+
+		err = filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			rel, err := filepath.Rel(base, p)
+			if err != nil {
+				return err
+			}
+			docPath := filepath.ToSlash(rel)
+			if d.IsDir() {
+				if p != dir && (d.Name() == ".git" || matchAny(c.Exclude, docPath)) {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if !d.Type().IsRegular() || seen[docPath] || !c.Includes(docPath) {
+				return nil
+			}
+			seen[docPath] = true
+			files = append(files, docPath)
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("collection %q: walk %s: %w", c.Name, root, err)
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// matchAny reports whether docPath matches any of the globs.
+func matchAny(globs []string, docPath string) bool {
+	for _, glob := range globs {
+		if matchGlob(glob, docPath) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchGlob matches one glob against docPath as described on Includes.
+// Malformed globs match nothing.
+func matchGlob(glob, docPath string) bool {
+	glob = strings.TrimPrefix(glob, "./")
+	if !strings.Contains(glob, "/") {
+		ok, _ := path.Match(glob, path.Base(docPath))
+		return ok
+	}
+	return matchSegments(strings.Split(glob, "/"), strings.Split(docPath, "/"))
+}
+
+func matchSegments(glob, parts []string) bool {
+	for len(glob) > 0 {
+		if glob[0] == "**" {
+			for i := 0; i <= len(parts); i++ {
+				if matchSegments(glob[1:], parts[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(parts) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(glob[0], parts[0]); !ok {
+			return false
+		}
+		glob, parts = glob[1:], parts[1:]
+	}
+	return len(parts) == 0
+}
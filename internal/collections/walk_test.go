@@ -0,0 +1,81 @@
+package collections
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatchGlob(t *testing.T) {
+	tests := []struct {
+		glob string
+		path string
+		want bool
+	}{
+		{"*.md", "docs/adr/0001-outbox.md", true},
+		{"*.md", "docs/adr/0001-outbox.go", false},
+		{"docs/*.md", "docs/a.md", true},
+		{"docs/*.md", "docs/adr/a.md", false},
+		{"docs/**/*.md", "docs/a.md", true},
+		{"docs/**/*.md", "docs/adr/old/a.md", true},
+		{"docs/**", "docs", true},
+		{"**/drafts/**", "docs/drafts/x.md", true},
+		{"./docs/**", "docs/x.md", true},
+		{"docs/[", "docs/[", false},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, matchGlob(tt.glob, tt.path), "%s vs %s", tt.glob, tt.path)
+	}
+}
+
+func TestCollectionIncludes(t *testing.T) {
+	c := &Collection{Include: []string{"*.md", "*.go"}, Exclude: []string{"**/drafts/**", "*_test.go"}}
+	assert.True(t, c.Includes("docs/arch.md"))
+	assert.True(t, c.Includes("cmd/main.go"))
+	assert.False(t, c.Includes("cmd/main_test.go"), "exclude wins")
+	assert.False(t, c.Includes("docs/drafts/idea.md"))
+	assert.False(t, c.Includes("docs/logo.png"))
+
+	assert.True(t, (&Collection{}).Includes("anything/at/all.bin"), "empty include selects everything")
+}
+
+func TestCollectionFiles(t *testing.T) {
+	base := t.TempDir()
+	for _, f := range []string{
+		"docs/arch.md",
+		"docs/adr/0001-outbox.md",
+		"docs/drafts/wip.md",
+		"docs/logo.png",
+		"docs/.git/HEAD.md",
+		"adr/0002-keys.md",
+		"README.md",
+	} {
+		p := filepath.Join(base, filepath.FromSlash(f))
+		require.NoError(t, os.MkdirAll(filepath.Dir(p), 0o755))
+		require.NoError(t, os.WriteFile(p, []byte("# "+f), 0o600))
+	}
+
+	c := &Collection{
+		Name:    "arch",
+		Roots:   []string{"docs", "adr", "docs/adr"},
+		Include: []string{"*.md"},
+		Exclude: []string{"docs/drafts/**"},
+	}
+	files, err := c.Files(base)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"adr/0002-keys.md",
+		"docs/adr/0001-outbox.md",
+		"docs/arch.md",
+	}, files, "overlapping roots list a file once; README outside the roots is ignored")
+
+	_, err = (&Collection{Name: "x", Roots: []string{"missing"}}).Files(base)
+	assert.Error(t, err)
+	_, err = (&Collection{Name: "x", Roots: []string{".."}}).Files(base)
+	assert.ErrorContains(t, err, "outside")
+	_, err = (&Collection{Name: "x"}).Files(base)
+	assert.ErrorContains(t, err, "no roots")
+}
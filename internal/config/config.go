@@ -64,9 +64,16 @@ type Config struct {
 	ContextMaxTokens          int      `json:"context_max_tokens"` // Token budget for context injection (default: 8000, 0=unlimited)
 	StoreMemoryHardLimit      int      `json:"store_memory_hard_limit"`      // Max chars for store_memory content (default: 10000)
 	StoreMemorySoftLimit      int      `json:"store_memory_soft_limit"`      // Chars above which content is truncated (default: 1000)
-	StoreMemoryDedupThreshold float64  `json:"store_memory_dedup_threshold"` // Cosine similarity for dedup (default: 0.92)
+	StoreMemoryDedupThreshold float64  `json:"store_memory_dedup_threshold"` // Cosine similarity for dedup (default: 0.92, 0=off; ENGRAM_STORE_MEMORY_DEDUP_THRESHOLD)
 	EncryptionKeyFile         string   `json:"-"`                            // env-only: ENGRAM_ENCRYPTION_KEY_FILE (path to vault.key)
 	EncryptionKey             string   `json:"-"`                            // env-only: ENGRAM_ENCRYPTION_KEY (hex-encoded 256-bit key)
+	VaultKEK                  string   `json:"-"`                            // env-only: ENGRAM_VAULT_KEK (master, file, passphrase or localkms; default master)
+	VaultKEKFile              string   `json:"-"`                            // env-only: ENGRAM_VAULT_KEK_FILE (key file for the file KEK)
+	VaultKEKPassphrase        string   `json:"-"`                            // env-only: ENGRAM_VAULT_KEK_PASSPHRASE
+	VaultKEKSalt              string   `json:"-"`                            // env-only: ENGRAM_VAULT_KEK_SALT (hex; default: auto-generated vault-kek.salt)
+	VaultKEKKDF               string   `json:"-"`                            // env-only: ENGRAM_VAULT_KEK_KDF (argon2id or scrypt; default argon2id)
+	VaultKMSKeystore          string   `json:"-"`                            // env-only: ENGRAM_VAULT_KMS_KEYSTORE (local KMS keystore; default: kms-keystore.json)
+	VaultKMSKey               string   `json:"-"`                            // env-only: ENGRAM_VAULT_KMS_KEY (local KMS key name; default engram)
 	AlwaysInjectLimit         int      `json:"always_inject_limit"` // ENGRAM_ALWAYS_INJECT_LIMIT (default: 20)
 	ProjectInjectLimit        int      `json:"project_inject_limit"` // ENGRAM_PROJECT_INJECT_LIMIT (default: 15)
 	InjectUnified             bool     `json:"inject_unified"`      // ENGRAM_INJECT_UNIFIED (default: true) — emergency rollback flag; removed after two release cycles
@@ -89,6 +96,37 @@ type Config struct {
 	AuthentikEnabled        bool     `json:"authentik_enabled"`
 	AuthentikAutoProvision  bool     `json:"authentik_auto_provision"`
 	AuthentikTrustedProxies []string `json:"authentik_trusted_proxies"`
+
+	// OpenID Connect dashboard login (authorization code + PKCE)
+	// ENGRAM_OIDC_ISSUER: issuer URL; enables OIDC login together with the client ID (default: empty)
+	// ENGRAM_OIDC_CLIENT_ID / ENGRAM_OIDC_CLIENT_SECRET: client registered with the issuer (secret optional for public clients)
+	// ENGRAM_OIDC_REDIRECT_URL: the dashboard's /api/auth/oidc/callback URL as registered with the issuer
+	// ENGRAM_OIDC_SCOPES: comma-separated scopes (default: openid,email,profile)
+	// ENGRAM_OIDC_ROLE_CLAIM: claim holding groups/roles (default: groups)
+	// ENGRAM_OIDC_ADMIN_VALUES: comma-separated claim values that map to admin (default: empty — nobody)
+	// ENGRAM_OIDC_OPERATOR_VALUES: comma-separated claim values that map to operator (default: empty — everyone else)
+	// ENGRAM_OIDC_AUTO_PROVISION: create users on first OIDC login (default: false)
+	// ENGRAM_OIDC_PROVIDER_NAME: label of the login button (default: SSO)
+	OIDCIssuer         string   `json:"oidc_issuer"`
+	OIDCClientID       string   `json:"oidc_client_id"`
+	OIDCClientSecret   string   `json:"-"`
+	OIDCRedirectURL    string   `json:"oidc_redirect_url"`
+	OIDCScopes         []string `json:"oidc_scopes"`
+	OIDCRoleClaim      string   `json:"oidc_role_claim"`
+	OIDCAdminValues    []string `json:"oidc_admin_values"`
+	OIDCOperatorValues []string `json:"oidc_operator_values"`
+	OIDCAutoProvision  bool     `json:"oidc_auto_provision"`
+	OIDCProviderName   string   `json:"oidc_provider_name"`
+
+	// Embedding backend for semantic memory search
+	// ENGRAM_EMBEDDING_PROVIDER: local (default, in-process hashed n-grams), openai (any OpenAI-compatible API) or none
+	// ENGRAM_EMBEDDING_MODEL / ENGRAM_EMBEDDING_BASE_URL / ENGRAM_EMBEDDING_API_KEY: openai provider only
+	// ENGRAM_EMBEDDING_DIMENSIONS: vector length (local default: 384; openai: model default)
+	EmbeddingProvider   string `json:"embedding_provider"`
+	EmbeddingModel      string `json:"embedding_model"`
+	EmbeddingBaseURL    string `json:"embedding_base_url"`
+	EmbeddingAPIKey     string `json:"-"` // env-only: ENGRAM_EMBEDDING_API_KEY
+	EmbeddingDimensions int    `json:"embedding_dimensions"`
 }
 
 var (
@@ -183,6 +221,9 @@ func Default() *Config {
 		InjectUnified:                  true, // Use unified RetrieveRelevant path for inject (FR-3). Set ENGRAM_INJECT_UNIFIED=false for emergency rollback.
 		EnforceSourceProject:           true, // Enforce source/project scoping on store/recall (T010)
 		OutcomeRecorderIntervalMinutes: 15,
+		EmbeddingProvider:              "local",
+		OIDCRoleClaim:                  "groups",
+		OIDCProviderName:               "SSO",
 		SignalWeights: map[string]float64{
 			"git_commit":   1.0,
 			"pr_created":   2.0,
@@ -295,6 +336,32 @@ func Load() (*Config, error) {
 	} else if v := strings.TrimSpace(os.Getenv("ENGRAM_ENCRYPTION_KEY")); v != "" {
 		cfg.EncryptionKey = v
 	}
+	if v := strings.TrimSpace(os.Getenv("ENGRAM_VAULT_KEK")); v != "" {
+		cfg.VaultKEK = v
+	}
+	if v := strings.TrimSpace(os.Getenv("ENGRAM_VAULT_KEK_FILE")); v != "" {
+		cfg.VaultKEKFile = v
+	}
+	if v := strings.TrimSpace(os.Getenv("ENGRAM_VAULT_KEK_PASSPHRASE")); v != "" {
+		cfg.VaultKEKPassphrase = v
+	}
+	if v := strings.TrimSpace(os.Getenv("ENGRAM_VAULT_KEK_SALT")); v != "" {
+		cfg.VaultKEKSalt = v
+	}
+	if v := strings.TrimSpace(os.Getenv("ENGRAM_VAULT_KEK_KDF")); v != "" {
+		cfg.VaultKEKKDF = v
+	}
+	if v := strings.TrimSpace(os.Getenv("ENGRAM_VAULT_KMS_KEYSTORE")); v != "" {
+		cfg.VaultKMSKeystore = v
+	}
+	if v := strings.TrimSpace(os.Getenv("ENGRAM_VAULT_KMS_KEY")); v != "" {
+		cfg.VaultKMSKey = v
+	}
+	if v := strings.TrimSpace(os.Getenv("ENGRAM_STORE_MEMORY_DEDUP_THRESHOLD")); v != "" {
+		if f, err := strconv.ParseFloat(v, 64); err == nil && f >= 0 && f <= 1 {
+			cfg.StoreMemoryDedupThreshold = f
+		}
+	}
 	if v := strings.TrimSpace(os.Getenv("ENGRAM_ALWAYS_INJECT_LIMIT")); v != "" {
 		if n, err := strconv.Atoi(v); err == nil && n > 0 {
 			cfg.AlwaysInjectLimit = n
@@ -331,6 +398,38 @@ func Load() (*Config, error) {
 	if v := strings.TrimSpace(os.Getenv("ENGRAM_AUTHENTIK_TRUSTED_PROXIES")); v != "" {
 		cfg.AuthentikTrustedProxies = splitTrim(v)
 	}
+
+	// OpenID Connect dashboard login
+	if v := strings.TrimSpace(os.Getenv("ENGRAM_OIDC_ISSUER")); v != "" {
+		cfg.OIDCIssuer = v
+	}
+	if v := strings.TrimSpace(os.Getenv("ENGRAM_OIDC_CLIENT_ID")); v != "" {
+		cfg.OIDCClientID = v
+	}
+	if v := strings.TrimSpace(os.Getenv("ENGRAM_OIDC_CLIENT_SECRET")); v != "" {
+		cfg.OIDCClientSecret = v
+	}
+	if v := strings.TrimSpace(os.Getenv("ENGRAM_OIDC_REDIRECT_URL")); v != "" {
+		cfg.OIDCRedirectURL = v
+	}
+	if v := strings.TrimSpace(os.Getenv("ENGRAM_OIDC_SCOPES")); v != "" {
+		cfg.OIDCScopes = splitTrim(v)
+	}
+	if v := strings.TrimSpace(os.Getenv("ENGRAM_OIDC_ROLE_CLAIM")); v != "" {
+		cfg.OIDCRoleClaim = v
+	}
+	if v := strings.TrimSpace(os.Getenv("ENGRAM_OIDC_ADMIN_VALUES")); v != "" {
+		cfg.OIDCAdminValues = splitTrim(v)
+	}
+	if v := strings.TrimSpace(os.Getenv("ENGRAM_OIDC_OPERATOR_VALUES")); v != "" {
+		cfg.OIDCOperatorValues = splitTrim(v)
+	}
+	if v := strings.TrimSpace(os.Getenv("ENGRAM_OIDC_AUTO_PROVISION")); v == "true" || v == "1" {
+		cfg.OIDCAutoProvision = true
+	}
+	if v := strings.TrimSpace(os.Getenv("ENGRAM_OIDC_PROVIDER_NAME")); v != "" {
+		cfg.OIDCProviderName = v
+	}
 	if v := strings.TrimSpace(os.Getenv("ENGRAM_AUTH_SKIP_LOCAL")); v == "true" || v == "1" {
 		cfg.AuthSkipLocal = true
 	}
@@ -338,6 +437,25 @@ func Load() (*Config, error) {
 		cfg.AuthTrustedProxy = v
 	}
 
+	// Embedding backend
+	if v := strings.TrimSpace(os.Getenv("ENGRAM_EMBEDDING_PROVIDER")); v != "" {
+		cfg.EmbeddingProvider = v
+	}
+	if v := strings.TrimSpace(os.Getenv("ENGRAM_EMBEDDING_MODEL")); v != "" {
+		cfg.EmbeddingModel = v
+	}
+	if v := strings.TrimSpace(os.Getenv("ENGRAM_EMBEDDING_BASE_URL")); v != "" {
+		cfg.EmbeddingBaseURL = v
+	}
+	if v := strings.TrimSpace(os.Getenv("ENGRAM_EMBEDDING_API_KEY")); v != "" {
+		cfg.EmbeddingAPIKey = v
+	}
+	if v := strings.TrimSpace(os.Getenv("ENGRAM_EMBEDDING_DIMENSIONS")); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			cfg.EmbeddingDimensions = n
+		}
+	}
+
 	return cfg, nil
 }
 
@@ -9,8 +9,13 @@
 // a single response line terminated by '\n'. Connection is then closed.
 //
 //	graceful-restart\n  →  ACK\n
+//	outbox-status\n     →  {"enabled":true,"pending":N,"failed":M,...}\n
+//	outbox-flush\n      →  ACK\n
 //	<unknown>\n         →  ERR unknown command\n
 //
+// The outbox commands report and drain the offline outbox of tool calls
+// queued while the engram server was unreachable.
+//
 // # Socket path
 //
 // Unix/macOS:  ${ENGRAM_DATA_DIR}/run/engram.sock
@@ -0,0 +1,283 @@
+package crypto
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/thebtf/engram/pkg/models"
+)
+
+// DataKeyStore persists wrapped per-project data keys. The gorm
+// CredentialDataKeyStore implements it.
+type DataKeyStore interface {
+	// GetDataKey returns the project's data key, or nil when it has none.
+	GetDataKey(ctx context.Context, project string) (*models.CredentialDataKey, error)
+	// CreateDataKey stores key unless its project already has one and
+	// returns the project's stored key: key itself, or the one a concurrent
+	// writer created first.
+	CreateDataKey(ctx context.Context, key *models.CredentialDataKey) (*models.CredentialDataKey, error)
+	// ListDataKeys returns every project's data key.
+	ListDataKeys(ctx context.Context) ([]*models.CredentialDataKey, error)
+	// RewrapDataKey replaces the project's wrapped key if it is still wrapped
+	// by fromKEKID, and reports whether it was.
+	RewrapDataKey(ctx context.Context, project, fromKEKID string, wrapped []byte, toKEKID string) (bool, error)
+}
+
+// ErrDataKeyKEKMismatch is returned by Keyring.ForProject when a project's
+// data key is wrapped by a KEK the keyring does not hold.
+var ErrDataKeyKEKMismatch = errors.New("data key is wrapped by another KEK")
+
+// ErrRewrapVerifyFailed is returned by Keyring.Rewrap when some data keys do
+// not unwrap with the old KEK. Nothing is rewrapped in that case.
+var ErrRewrapVerifyFailed = errors.New("data keys failed to unwrap with the old KEK")
+
+// Keyring implements envelope encryption for credentials. Each project has
+// its own random data key, which encrypts the project's credentials and is
+// stored only wrapped by the KEK. A leaked data key exposes one project, and
+// replacing the KEK rewraps the data keys without touching any credential.
+type Keyring struct {
+	kek      KEKProvider
+	store    DataKeyStore
+	legacy   *Vault
+	vaults   map[string]*Vault
+	previous []KEKProvider
+	mu       sync.Mutex
+}
+
+// NewKeyring returns a keyring that wraps new data keys with kek. Data keys
+// wrapped by one of previous still unwrap, which keeps them readable while a
+// KEK rotation is rewrapping them. legacy is the vault master key: credentials
+// encrypted directly with it, before envelope encryption, stay decryptable.
+func NewKeyring(kek KEKProvider, store DataKeyStore, legacy *Vault, previous ...KEKProvider) *Keyring {
+	return &Keyring{
+		kek:      kek,
+		store:    store,
+		legacy:   legacy,
+		previous: previous,
+		vaults:   make(map[string]*Vault),
+	}
+}
+
+// KEK returns the provider new data keys are wrapped with.
+func (k *Keyring) KEK() KEKProvider {
+	return k.kek
+}
+
+// Legacy returns the vault master key the keyring was created with.
+func (k *Keyring) Legacy() *Vault {
+	return k.legacy
+}
+
+// dataKeyAAD binds a wrapped data key to its project, so a wrapped key copied
+// onto another project's row does not unwrap.
+func dataKeyAAD(project string) []byte {
+	return []byte("engram/credential-data-key/" + project)
+}
+
+// ForProject returns a Vault that encrypts with project's data key, creating
+// and storing the key on first use. The Vault also decrypts credentials
+// encrypted with the legacy master key. Its Fingerprint is the data key's and
+// is what credentials encrypted with it record.
+func (k *Keyring) ForProject(ctx context.Context, project string) (*Vault, error) {
+	if project == "" {
+		return nil, fmt.Errorf("data key: project must not be empty")
+	}
+	k.mu.Lock()
+	v, ok := k.vaults[project]
+	k.mu.Unlock()
+	if ok {
+		return v, nil
+	}
+
+	rec, err := k.store.GetDataKey(ctx, project)
+	if err != nil {
+		return nil, fmt.Errorf("load data key for project %q: %w", project, err)
+	}
+	if rec == nil {
+		if rec, err = k.createDataKey(ctx, project); err != nil {
+			return nil, err
+		}
+	}
+	dataKey, err := k.unwrap(ctx, rec)
+	if err != nil {
+		return nil, err
+	}
+	if fp := computeFingerprint(dataKey); fp != rec.Fingerprint {
+		return nil, fmt.Errorf("data key for project %q: fingerprint %s does not match the stored %s", project, fp, rec.Fingerprint)
+	}
+
+	v = &Vault{key: dataKey, fingerprint: rec.Fingerprint, source: "data_key"}
+	if k.legacy != nil {
+		v = v.WithDecryptKeys(k.legacy)
+	}
+	k.mu.Lock()
+	k.vaults[project] = v
+	k.mu.Unlock()
+	return v, nil
+}
+
+// createDataKey generates, wraps and stores a data key for project. When
+// another writer got there first, its key is returned instead.
+func (k *Keyring) createDataKey(ctx context.Context, project string) (*models.CredentialDataKey, error) {
+	dataKey, err := randomBytes(32)
+	if err != nil {
+		return nil, fmt.Errorf("generate data key: %w", err)
+	}
+	wrapped, err := k.kek.Wrap(ctx, dataKey, dataKeyAAD(project))
+	if err != nil {
+		return nil, fmt.Errorf("wrap data key for project %q: %w", project, err)
+	}
+	rec, err := k.store.CreateDataKey(ctx, &models.CredentialDataKey{
+		Project:     project,
+		WrappedKey:  wrapped,
+		KEKID:       k.kek.ID(),
+		Fingerprint: computeFingerprint(dataKey),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("store data key for project %q: %w", project, err)
+	}
+	return rec, nil
+}
+
+// unwrap opens rec with whichever of the keyring's KEKs wrapped it.
+func (k *Keyring) unwrap(ctx context.Context, rec *models.CredentialDataKey) ([]byte, error) {
+	for _, p := range append([]KEKProvider{k.kek}, k.previous...) {
+		if p.ID() == rec.KEKID {
+			return p.Unwrap(ctx, rec.WrappedKey, dataKeyAAD(rec.Project))
+		}
+	}
+	return nil, fmt.Errorf("project %q: %w %s (current KEK is %s); rewrap the data keys from the old KEK",
+		rec.Project, ErrDataKeyKEKMismatch, rec.KEKID, k.kek.ID())
+}
+
+// Fingerprints returns the fingerprints of every stored data key and of the
+// legacy master key and its decrypt-only keys: the keys credentials may
+// legitimately be recorded under.
+func (k *Keyring) Fingerprints(ctx context.Context) ([]string, error) {
+	keys, err := k.store.ListDataKeys(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list data keys: %w", err)
+	}
+	fps := make([]string, 0, len(keys)+1)
+	for _, rec := range keys {
+		fps = append(fps, rec.Fingerprint)
+	}
+	if k.legacy != nil {
+		fps = append(fps, k.legacy.Fingerprint())
+		fps = append(fps, k.legacy.DecryptOnlyFingerprints()...)
+	}
+	return fps, nil
+}
+
+// DataKeys returns every stored data key, still wrapped.
+func (k *Keyring) DataKeys(ctx context.Context) ([]*models.CredentialDataKey, error) {
+	return k.store.ListDataKeys(ctx)
+}
+
+// DataKeyRewrapFailure is one data key that did not unwrap with the old KEK.
+type DataKeyRewrapFailure struct {
+	Project string `json:"project"`
+	Error   string `json:"error"`
+}
+
+// DataKeyRewrap reports the outcome of Keyring.Rewrap. Pending counts the data
+// keys wrapped by the old KEK when the run started, Current those already
+// wrapped by the new one, and Foreign those wrapped by neither.
+type DataKeyRewrap struct {
+	FromKEK   string                 `json:"from_kek"`
+	ToKEK     string                 `json:"to_kek"`
+	Failed    []DataKeyRewrapFailure `json:"failed"`
+	Pending   int                    `json:"pending"`
+	Verified  int                    `json:"verified"`
+	Rewrapped int                    `json:"rewrapped"`
+	Current   int                    `json:"current"`
+	Foreign   int                    `json:"foreign"`
+	DryRun    bool                   `json:"dry_run"`
+}
+
+// Rewrap re-wraps every data key wrapped by from with the keyring's KEK.
+// Credentials are not touched: their data keys do not change. Every pending
+// key is unwrapped first; if any fails, the failures are returned with
+// ErrRewrapVerifyFailed and nothing is changed. With dryRun only that check
+// runs. Each key is swapped individually and only if still wrapped by from,
+// so an interrupted run is finished by running it again.
+func (k *Keyring) Rewrap(ctx context.Context, from KEKProvider, dryRun bool) (*DataKeyRewrap, error) {
+	if from == nil {
+		return nil, fmt.Errorf("rewrap data keys: old KEK is required")
+	}
+	if from.ID() == k.kek.ID() {
+		return nil, fmt.Errorf("rewrap data keys: old and new KEK are the same (%s)", from.ID())
+	}
+	result := &DataKeyRewrap{
+		FromKEK: from.ID(),
+		ToKEK:   k.kek.ID(),
+		Failed:  make([]DataKeyRewrapFailure, 0),
+		DryRun:  dryRun,
+	}
+	keys, err := k.store.ListDataKeys(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("rewrap data keys: %w", err)
+	}
+
+	type pendingKey struct {
+		rec     *models.CredentialDataKey
+		dataKey []byte
+	}
+	var pending []pendingKey
+	for _, rec := range keys {
+		switch rec.KEKID {
+		case from.ID():
+			result.Pending++
+		case k.kek.ID():
+			result.Current++
+			continue
+		default:
+			result.Foreign++
+			continue
+		}
+		dataKey, err := from.Unwrap(ctx, rec.WrappedKey, dataKeyAAD(rec.Project))
+		if err == nil && computeFingerprint(dataKey) != rec.Fingerprint {
+			err = fmt.Errorf("unwrapped key fingerprint does not match %s", rec.Fingerprint)
+		}
+		if err != nil {
+			result.Failed = append(result.Failed, DataKeyRewrapFailure{Project: rec.Project, Error: err.Error()})
+			continue
+		}
+		result.Verified++
+		pending = append(pending, pendingKey{rec: rec, dataKey: dataKey})
+	}
+	if len(result.Failed) > 0 && !dryRun {
+		return result, fmt.Errorf("rewrap data keys: %d %w; nothing was rewrapped", len(result.Failed), ErrRewrapVerifyFailed)
+	}
+	if dryRun {
+		return result, nil
+	}
+
+	for _, p := range pending {
+		wrapped, err := k.kek.Wrap(ctx, p.dataKey, dataKeyAAD(p.rec.Project))
+		if err != nil {
+			return result, fmt.Errorf("rewrap data key for project %q: %w", p.rec.Project, err)
+		}
+		swapped, err := k.store.RewrapDataKey(ctx, p.rec.Project, from.ID(), wrapped, k.kek.ID())
+		if err != nil {
+			return result, fmt.Errorf("store rewrapped data key for project %q: %w", p.rec.Project, err)
+		}
+		if swapped {
+			result.Rewrapped++
+		}
+	}
+	return result, nil
+}
+
+// randomBytes returns n bytes from crypto/rand.
+func randomBytes(n int) ([]byte, error) {
+	b := make([]byte, n)
+	if _, err := io.ReadFull(rand.Reader, b); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
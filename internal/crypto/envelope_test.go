@@ -0,0 +1,188 @@
+package crypto_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/thebtf/engram/internal/crypto"
+	"github.com/thebtf/engram/pkg/models"
+)
+
+// memDataKeyStore is an in-memory crypto.DataKeyStore.
+type memDataKeyStore struct {
+	keys map[string]*models.CredentialDataKey
+	mu   sync.Mutex
+}
+
+func newMemDataKeyStore() *memDataKeyStore {
+	return &memDataKeyStore{keys: make(map[string]*models.CredentialDataKey)}
+}
+
+func (m *memDataKeyStore) GetDataKey(_ context.Context, project string) (*models.CredentialDataKey, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if k, ok := m.keys[project]; ok {
+		c := *k
+		return &c, nil
+	}
+	return nil, nil
+}
+
+func (m *memDataKeyStore) CreateDataKey(_ context.Context, key *models.CredentialDataKey) (*models.CredentialDataKey, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if k, ok := m.keys[key.Project]; ok {
+		c := *k
+		return &c, nil
+	}
+	c := *key
+	m.keys[key.Project] = &c
+	return key, nil
+}
+
+func (m *memDataKeyStore) ListDataKeys(_ context.Context) ([]*models.CredentialDataKey, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	out := make([]*models.CredentialDataKey, 0, len(m.keys))
+	for _, k := range m.keys {
+		c := *k
+		out = append(out, &c)
+	}
+	return out, nil
+}
+
+func (m *memDataKeyStore) RewrapDataKey(_ context.Context, project, fromKEKID string, wrapped []byte, toKEKID string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	k, ok := m.keys[project]
+	if !ok || k.KEKID != fromKEKID {
+		return false, nil
+	}
+	k.WrappedKey, k.KEKID = wrapped, toKEKID
+	return true, nil
+}
+
+func TestKeyring_PerProjectDataKeys(t *testing.T) {
+	ctx := context.Background()
+	master := mustVault(t, testHexKey)
+	store := newMemDataKeyStore()
+	kr := crypto.NewKeyring(crypto.NewMasterKEK(master), store, master)
+
+	a, err := kr.ForProject(ctx, "project-a")
+	if err != nil {
+		t.Fatalf("ForProject a: %v", err)
+	}
+	b, err := kr.ForProject(ctx, "project-b")
+	if err != nil {
+		t.Fatalf("ForProject b: %v", err)
+	}
+	if a.Fingerprint() == b.Fingerprint() || a.Fingerprint() == master.Fingerprint() {
+		t.Fatalf("projects must get distinct data keys: a=%s b=%s master=%s", a.Fingerprint(), b.Fingerprint(), master.Fingerprint())
+	}
+
+	ct, err := a.Encrypt("secret-a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := b.Decrypt(ct); err == nil {
+		t.Error("project b's data key must not decrypt project a's credential")
+	}
+	if _, err := master.Decrypt(ct); err == nil {
+		t.Error("the master key alone must not decrypt envelope-encrypted credentials")
+	}
+
+	// Credentials encrypted with the master key before envelope encryption
+	// stay readable.
+	legacyCT, _ := master.Encrypt("legacy")
+	if pt, err := a.Decrypt(legacyCT); err != nil || pt != "legacy" {
+		t.Errorf("Decrypt legacy credential = %q, %v", pt, err)
+	}
+
+	// A fresh keyring (a restart) unwraps the stored key instead of making a new one.
+	again, err := crypto.NewKeyring(crypto.NewMasterKEK(master), store, master).ForProject(ctx, "project-a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pt, err := again.Decrypt(ct); err != nil || pt != "secret-a" {
+		t.Errorf("Decrypt after restart = %q, %v", pt, err)
+	}
+
+	fps, err := kr.Fingerprints(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fps) != 3 {
+		t.Errorf("Fingerprints = %v, want two data keys and the master key", fps)
+	}
+
+	// A wrapped key moved onto another project's row does not unwrap.
+	store.keys["project-b"].WrappedKey = store.keys["project-a"].WrappedKey
+	if _, err := crypto.NewKeyring(crypto.NewMasterKEK(master), store, master).ForProject(ctx, "project-b"); err == nil {
+		t.Error("a data key wrapped for project a must not unwrap for project b")
+	}
+}
+
+func TestKeyring_Rewrap(t *testing.T) {
+	ctx := context.Background()
+	master := mustVault(t, testHexKey)
+	oldKEK := crypto.NewMasterKEK(master)
+	newKEK, err := crypto.NewPassphraseKEK("a long enough passphrase", bytes.Repeat([]byte{1}, 16), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := newMemDataKeyStore()
+	oldRing := crypto.NewKeyring(oldKEK, store, master)
+
+	var cts [][]byte
+	for _, p := range []string{"p1", "p2", "p3"} {
+		v, err := oldRing.ForProject(ctx, p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ct, _ := v.Encrypt("secret-" + p)
+		cts = append(cts, ct)
+	}
+
+	// Before the rewrap the new KEK cannot open the old data keys.
+	newRing := crypto.NewKeyring(newKEK, store, master)
+	if _, err := newRing.ForProject(ctx, "p1"); !errors.Is(err, crypto.ErrDataKeyKEKMismatch) {
+		t.Fatalf("ForProject before rewrap: err = %v, want ErrDataKeyKEKMismatch", err)
+	}
+
+	dry, err := newRing.Rewrap(ctx, oldKEK, true)
+	if err != nil {
+		t.Fatalf("dry run: %v", err)
+	}
+	if dry.Pending != 3 || dry.Verified != 3 || dry.Rewrapped != 0 {
+		t.Errorf("dry run = %+v", dry)
+	}
+
+	res, err := newRing.Rewrap(ctx, oldKEK, false)
+	if err != nil {
+		t.Fatalf("Rewrap: %v", err)
+	}
+	if res.Rewrapped != 3 {
+		t.Errorf("Rewrapped = %d, want 3", res.Rewrapped)
+	}
+	for i, p := range []string{"p1", "p2", "p3"} {
+		v, err := newRing.ForProject(ctx, p)
+		if err != nil {
+			t.Fatalf("ForProject %s after rewrap: %v", p, err)
+		}
+		if pt, err := v.Decrypt(cts[i]); err != nil || pt != "secret-"+p {
+			t.Errorf("%s: Decrypt = %q, %v", p, pt, err)
+		}
+	}
+
+	// Running it again finds nothing left to do.
+	again, err := newRing.Rewrap(ctx, oldKEK, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.Pending != 0 || again.Current != 3 {
+		t.Errorf("second run = %+v", again)
+	}
+}
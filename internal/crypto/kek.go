@@ -0,0 +1,239 @@
+package crypto
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/scrypt"
+
+	"github.com/thebtf/engram/internal/config"
+)
+
+// KEKProvider wraps and unwraps data keys with a key-encryption key (KEK).
+// Implementations backed by a remote KMS send the data key to the service;
+// the KEK itself never has to leave it.
+//
+// aad binds a wrapped key to its use, like a KMS encryption context: Unwrap
+// fails unless it is given the aad the key was wrapped with.
+type KEKProvider interface {
+	// ID identifies the KEK. It is stored with every key it wraps, so it must
+	// stay the same across restarts and change whenever the key material does.
+	ID() string
+	Wrap(ctx context.Context, dataKey, aad []byte) ([]byte, error)
+	Unwrap(ctx context.Context, wrapped, aad []byte) ([]byte, error)
+}
+
+// KEK provider names accepted by ENGRAM_VAULT_KEK.
+const (
+	KEKProviderMaster     = "master"
+	KEKProviderFile       = "file"
+	KEKProviderPassphrase = "passphrase"
+	KEKProviderLocalKMS   = "localkms"
+)
+
+// Key derivation functions for the passphrase KEK.
+const (
+	KDFArgon2id = "argon2id"
+	KDFScrypt   = "scrypt"
+)
+
+// KEKSpec describes a KEK provider and the material it needs. Only the
+// fields of the selected Provider are used.
+type KEKSpec struct {
+	Provider    string `json:"provider"`
+	Key         string `json:"key,omitempty"`      // master: hex key (default: the vault master key)
+	KeyFile     string `json:"key_file,omitempty"` // file
+	Passphrase  string `json:"passphrase,omitempty"`
+	Salt        string `json:"salt,omitempty"` // passphrase: hex (default: auto-generated vault-kek.salt)
+	KDF         string `json:"kdf,omitempty"`  // passphrase: argon2id (default) or scrypt
+	KMSKeystore string `json:"kms_keystore,omitempty"`
+	KMSKey      string `json:"kms_key,omitempty"`
+}
+
+// KEKSpecFromConfig returns the KEK configured with the ENGRAM_VAULT_KEK* and
+// ENGRAM_VAULT_KMS_* env vars.
+func KEKSpecFromConfig(cfg *config.Config) KEKSpec {
+	return KEKSpec{
+		Provider:    cfg.VaultKEK,
+		KeyFile:     cfg.VaultKEKFile,
+		Passphrase:  cfg.VaultKEKPassphrase,
+		Salt:        cfg.VaultKEKSalt,
+		KDF:         cfg.VaultKEKKDF,
+		KMSKeystore: cfg.VaultKMSKeystore,
+		KMSKey:      cfg.VaultKMSKey,
+	}
+}
+
+// NewKEK returns the KEK provider configured in cfg. master is the vault
+// master key, used by the default "master" provider.
+func NewKEK(cfg *config.Config, master *Vault) (KEKProvider, error) {
+	return OpenKEK(KEKSpecFromConfig(cfg), master)
+}
+
+// OpenKEK returns the KEK provider described by spec:
+//   - master (default): the vault master key, or spec.Key when set
+//   - file: a 32-byte key read from spec.KeyFile
+//   - passphrase: spec.Passphrase stretched with argon2id or scrypt
+//   - localkms: key spec.KMSKey (default "engram") in the local KMS keystore
+func OpenKEK(spec KEKSpec, master *Vault) (KEKProvider, error) {
+	switch strings.ToLower(spec.Provider) {
+	case "", KEKProviderMaster:
+		if spec.Key != "" {
+			key, err := ParseKey(spec.Key)
+			if err != nil {
+				return nil, fmt.Errorf("master KEK: %w", err)
+			}
+			return newStaticKEK(KEKProviderMaster, key), nil
+		}
+		if master == nil {
+			return nil, fmt.Errorf("master KEK: vault master key not available")
+		}
+		return NewMasterKEK(master), nil
+	case KEKProviderFile:
+		if spec.KeyFile == "" {
+			return nil, fmt.Errorf("file KEK: ENGRAM_VAULT_KEK_FILE is required")
+		}
+		return NewFileKEK(spec.KeyFile)
+	case KEKProviderPassphrase:
+		var salt []byte
+		var err error
+		if spec.Salt != "" {
+			if salt, err = hex.DecodeString(strings.TrimSpace(spec.Salt)); err != nil {
+				return nil, fmt.Errorf("passphrase KEK: decode salt: %w", err)
+			}
+		} else if salt, err = loadOrCreateSalt(defaultKeyPath("vault-kek.salt")); err != nil {
+			return nil, fmt.Errorf("passphrase KEK: %w", err)
+		}
+		return NewPassphraseKEK(spec.Passphrase, salt, spec.KDF)
+	case KEKProviderLocalKMS:
+		path := spec.KMSKeystore
+		if path == "" {
+			path = defaultKeyPath("kms-keystore.json")
+		}
+		kms, err := OpenLocalKMS(path)
+		if err != nil {
+			return nil, err
+		}
+		name := spec.KMSKey
+		if name == "" {
+			name = "engram"
+		}
+		return kms.Key(name)
+	default:
+		return nil, fmt.Errorf("unknown KEK provider %q (want master, file, passphrase or localkms)", spec.Provider)
+	}
+}
+
+// staticKEK wraps data keys with AES-256-GCM under a 32-byte key held in
+// memory. The master, file and passphrase providers are all static KEKs that
+// differ only in where the key comes from.
+type staticKEK struct {
+	id  string
+	key []byte
+}
+
+func newStaticKEK(kind string, key []byte) *staticKEK {
+	return &staticKEK{id: kind + ":" + computeFingerprint(key), key: append([]byte(nil), key...)}
+}
+
+func (k *staticKEK) ID() string { return k.id }
+
+func (k *staticKEK) Wrap(_ context.Context, dataKey, aad []byte) ([]byte, error) {
+	return seal(k.key, dataKey, aad)
+}
+
+func (k *staticKEK) Unwrap(_ context.Context, wrapped, aad []byte) ([]byte, error) {
+	dataKey, err := open(k.key, wrapped, aad)
+	if err != nil {
+		return nil, fmt.Errorf("unwrap data key with %s: %w", k.id, err)
+	}
+	return dataKey, nil
+}
+
+// NewMasterKEK returns a KEK that wraps with the vault master key. This is
+// the default, so existing deployments need no new key material.
+func NewMasterKEK(v *Vault) KEKProvider {
+	return newStaticKEK(KEKProviderMaster, v.key)
+}
+
+// NewFileKEK returns a KEK whose key is read from path (64 hex chars or 32
+// raw bytes, like vault.key).
+func NewFileKEK(path string) (KEKProvider, error) {
+	key, err := loadKeyFromFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("file KEK %q: %w", path, err)
+	}
+	return newStaticKEK(KEKProviderFile, key), nil
+}
+
+// Passphrase KEK derivation parameters: argon2id with the RFC 9106 second
+// recommended profile, scrypt with the parameters recommended for
+// interactive logins in 2017.
+const (
+	argon2Time    = 3
+	argon2Memory  = 64 * 1024
+	argon2Threads = 4
+	scryptN       = 1 << 15
+	scryptR       = 8
+	scryptP       = 1
+	minSaltLen    = 16
+)
+
+// NewPassphraseKEK returns a KEK derived from passphrase and salt with kdf
+// (argon2id when empty, or scrypt). The same passphrase, salt and kdf always
+// give the same KEK, so losing the salt loses the data keys as surely as
+// losing the passphrase.
+func NewPassphraseKEK(passphrase string, salt []byte, kdf string) (KEKProvider, error) {
+	if passphrase == "" {
+		return nil, fmt.Errorf("passphrase KEK: ENGRAM_VAULT_KEK_PASSPHRASE is required")
+	}
+	if len(salt) < minSaltLen {
+		return nil, fmt.Errorf("passphrase KEK: salt must be at least %d bytes, got %d", minSaltLen, len(salt))
+	}
+	var key []byte
+	switch strings.ToLower(kdf) {
+	case "", KDFArgon2id:
+		kdf = KDFArgon2id
+		key = argon2.IDKey([]byte(passphrase), salt, argon2Time, argon2Memory, argon2Threads, 32)
+	case KDFScrypt:
+		var err error
+		if key, err = scrypt.Key([]byte(passphrase), salt, scryptN, scryptR, scryptP, 32); err != nil {
+			return nil, fmt.Errorf("passphrase KEK: scrypt: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("passphrase KEK: unknown KDF %q (want argon2id or scrypt)", kdf)
+	}
+	return newStaticKEK(KEKProviderPassphrase+"-"+strings.ToLower(kdf), key), nil
+}
+
+// loadOrCreateSalt reads a hex salt from path, generating and saving a random
+// 16-byte one on first use.
+func loadOrCreateSalt(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err == nil {
+		salt, err := hex.DecodeString(strings.TrimSpace(string(data)))
+		if err != nil {
+			return nil, fmt.Errorf("decode salt file %q: %w", path, err)
+		}
+		return salt, nil
+	}
+	if !os.IsNotExist(err) {
+		return nil, fmt.Errorf("read salt file %q: %w", path, err)
+	}
+	salt, err := randomBytes(minSaltLen)
+	if err != nil {
+		return nil, fmt.Errorf("generate salt: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, fmt.Errorf("create salt directory %q: %w", filepath.Dir(path), err)
+	}
+	if err := saveKeyToFile(path, salt); err != nil {
+		return nil, fmt.Errorf("save salt to %q: %w", path, err)
+	}
+	return salt, nil
+}
@@ -0,0 +1,111 @@
+package crypto_test
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/thebtf/engram/internal/crypto"
+)
+
+func TestKEKProviders_WrapUnwrap(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	salt := bytes.Repeat([]byte{7}, 16)
+
+	keyFile := filepath.Join(dir, "kek.key")
+	if err := os.WriteFile(keyFile, []byte(otherHexKey), 0600); err != nil {
+		t.Fatal(err)
+	}
+	fileKEK, err := crypto.NewFileKEK(keyFile)
+	if err != nil {
+		t.Fatalf("NewFileKEK: %v", err)
+	}
+	argonKEK, err := crypto.NewPassphraseKEK("correct horse battery staple", salt, "")
+	if err != nil {
+		t.Fatalf("NewPassphraseKEK argon2id: %v", err)
+	}
+	scryptKEK, err := crypto.NewPassphraseKEK("correct horse battery staple", salt, crypto.KDFScrypt)
+	if err != nil {
+		t.Fatalf("NewPassphraseKEK scrypt: %v", err)
+	}
+	kms, err := crypto.OpenLocalKMS(filepath.Join(dir, "kms.json"))
+	if err != nil {
+		t.Fatalf("OpenLocalKMS: %v", err)
+	}
+	kmsKEK, err := kms.Key("engram")
+	if err != nil {
+		t.Fatalf("LocalKMS.Key: %v", err)
+	}
+
+	dataKey := bytes.Repeat([]byte{42}, 32)
+	aad := []byte("project-a")
+	for _, kek := range []crypto.KEKProvider{crypto.NewMasterKEK(mustVault(t, testHexKey)), fileKEK, argonKEK, scryptKEK, kmsKEK} {
+		t.Run(kek.ID(), func(t *testing.T) {
+			wrapped, err := kek.Wrap(ctx, dataKey, aad)
+			if err != nil {
+				t.Fatalf("Wrap: %v", err)
+			}
+			if bytes.Contains(wrapped, dataKey) {
+				t.Error("wrapped key contains the plaintext data key")
+			}
+			got, err := kek.Unwrap(ctx, wrapped, aad)
+			if err != nil || !bytes.Equal(got, dataKey) {
+				t.Errorf("Unwrap = %x, %v", got, err)
+			}
+			if _, err := kek.Unwrap(ctx, wrapped, []byte("project-b")); err == nil {
+				t.Error("Unwrap with another project's aad must fail")
+			}
+		})
+	}
+
+	if argonKEK.ID() == scryptKEK.ID() {
+		t.Error("argon2id and scrypt KEKs must have different IDs")
+	}
+	again, _ := crypto.NewPassphraseKEK("correct horse battery staple", salt, crypto.KDFArgon2id)
+	if again.ID() != argonKEK.ID() {
+		t.Error("the same passphrase and salt must derive the same KEK")
+	}
+	other, _ := crypto.NewPassphraseKEK("wrong passphrase", salt, "")
+	if other.ID() == argonKEK.ID() {
+		t.Error("a different passphrase must derive a different KEK")
+	}
+	if _, err := crypto.NewPassphraseKEK("pw", []byte("short"), ""); err == nil {
+		t.Error("short salt must be rejected")
+	}
+
+	// A reopened keystore unwraps what the first instance wrapped.
+	wrapped, _ := kmsKEK.Wrap(ctx, dataKey, aad)
+	reopened, err := crypto.OpenLocalKMS(filepath.Join(dir, "kms.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sameKey, _ := reopened.Key("engram")
+	if got, err := sameKey.Unwrap(ctx, wrapped, aad); err != nil || !bytes.Equal(got, dataKey) {
+		t.Errorf("reopened keystore Unwrap = %x, %v", got, err)
+	}
+}
+
+func TestOpenKEK(t *testing.T) {
+	master := mustVault(t, testHexKey)
+	kek, err := crypto.OpenKEK(crypto.KEKSpec{}, master)
+	if err != nil {
+		t.Fatalf("OpenKEK default: %v", err)
+	}
+	if kek.ID() != crypto.NewMasterKEK(master).ID() {
+		t.Errorf("default KEK = %s, want the master key", kek.ID())
+	}
+	byKey, err := crypto.OpenKEK(crypto.KEKSpec{Provider: "master", Key: testHexKey}, nil)
+	if err != nil || byKey.ID() != kek.ID() {
+		t.Errorf("master KEK from an explicit key = %v, %v", byKey, err)
+	}
+	if _, err := crypto.OpenKEK(crypto.KEKSpec{Provider: "hsm"}, master); err == nil || !strings.Contains(err.Error(), "unknown KEK provider") {
+		t.Errorf("unknown provider error = %v", err)
+	}
+	if _, err := crypto.OpenKEK(crypto.KEKSpec{Provider: "passphrase", Salt: "00112233445566778899aabbccddeeff"}, master); err == nil {
+		t.Error("passphrase KEK without a passphrase must fail")
+	}
+}
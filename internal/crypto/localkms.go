@@ -0,0 +1,136 @@
+package crypto
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// LocalKMS is a local stand-in for a remote key management service. Named
+// keys live in a JSON keystore file (0600) and, as with a real KMS, never
+// leave it: callers only get KEKProviders that wrap and unwrap through the
+// keystore. It exists so the envelope code path can be exercised end to end
+// without cloud credentials; a remote KMS provider implements KEKProvider the
+// same way.
+type LocalKMS struct {
+	keys map[string][]byte
+	path string
+	mu   sync.Mutex
+}
+
+// localKMSKeystore is the on-disk keystore format: key name -> hex key.
+type localKMSKeystore struct {
+	Keys map[string]string `json:"keys"`
+}
+
+// OpenLocalKMS opens the keystore at path. A missing file is an empty
+// keystore; it is created when the first key is.
+func OpenLocalKMS(path string) (*LocalKMS, error) {
+	kms := &LocalKMS{path: path, keys: make(map[string][]byte)}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return kms, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("local KMS: read keystore %q: %w", path, err)
+	}
+	var ks localKMSKeystore
+	if err := json.Unmarshal(data, &ks); err != nil {
+		return nil, fmt.Errorf("local KMS: parse keystore %q: %w", path, err)
+	}
+	for name, hexKey := range ks.Keys {
+		key, err := ParseKey(hexKey)
+		if err != nil {
+			return nil, fmt.Errorf("local KMS: key %q: %w", name, err)
+		}
+		kms.keys[name] = key
+	}
+	return kms, nil
+}
+
+// Key returns a KEK provider for the named key, creating the key and saving
+// the keystore if it does not exist yet.
+func (m *LocalKMS) Key(name string) (KEKProvider, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.keys[name]; !ok {
+		key, err := randomBytes(32)
+		if err != nil {
+			return nil, fmt.Errorf("local KMS: generate key %q: %w", name, err)
+		}
+		m.keys[name] = key
+		if err := m.save(); err != nil {
+			delete(m.keys, name)
+			return nil, err
+		}
+	}
+	return &localKMSKey{kms: m, name: name}, nil
+}
+
+// save writes the keystore. The caller holds m.mu.
+func (m *LocalKMS) save() error {
+	ks := localKMSKeystore{Keys: make(map[string]string, len(m.keys))}
+	for name, key := range m.keys {
+		ks.Keys[name] = hex.EncodeToString(key)
+	}
+	data, err := json.MarshalIndent(ks, "", "  ")
+	if err != nil {
+		return fmt.Errorf("local KMS: encode keystore: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(m.path), 0700); err != nil {
+		return fmt.Errorf("local KMS: create keystore directory: %w", err)
+	}
+	if err := os.WriteFile(m.path, data, 0600); err != nil {
+		return fmt.Errorf("local KMS: write keystore %q: %w", m.path, err)
+	}
+	return nil
+}
+
+// localKMSKey is one named key of a LocalKMS.
+type localKMSKey struct {
+	kms  *LocalKMS
+	name string
+}
+
+// ID is stable for the key's lifetime: a KMS key is addressed by name, not
+// by its material.
+func (k *localKMSKey) ID() string { return KEKProviderLocalKMS + ":" + k.name }
+
+func (k *localKMSKey) Wrap(ctx context.Context, dataKey, aad []byte) ([]byte, error) {
+	key, err := k.material(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return seal(key, dataKey, aad)
+}
+
+func (k *localKMSKey) Unwrap(ctx context.Context, wrapped, aad []byte) ([]byte, error) {
+	key, err := k.material(ctx)
+	if err != nil {
+		return nil, err
+	}
+	dataKey, err := open(key, wrapped, aad)
+	if err != nil {
+		return nil, fmt.Errorf("unwrap data key with %s: %w", k.ID(), err)
+	}
+	return dataKey, nil
+}
+
+// material looks the key up in the keystore, honouring ctx the way a remote
+// call would.
+func (k *localKMSKey) material(ctx context.Context) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	k.kms.mu.Lock()
+	defer k.kms.mu.Unlock()
+	key, ok := k.kms.keys[k.name]
+	if !ok {
+		return nil, fmt.Errorf("local KMS: key %q not found", k.name)
+	}
+	return key, nil
+}
@@ -0,0 +1,97 @@
+package crypto
+
+import (
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// ParseKey decodes a hex-encoded 32-byte master key (64 hex chars), the
+// format of ENGRAM_ENCRYPTION_KEY.
+func ParseKey(hexKey string) ([]byte, error) {
+	key, err := hex.DecodeString(strings.TrimSpace(hexKey))
+	if err != nil {
+		return nil, fmt.Errorf("decode key: %w", err)
+	}
+	if len(key) != 32 {
+		return nil, fmt.Errorf("key must be 32 bytes (64 hex chars), got %d bytes", len(key))
+	}
+	return key, nil
+}
+
+// ReadKeyFile reads a key file in either format NewVault accepts: 64 hex
+// chars or 32 raw bytes.
+func ReadKeyFile(path string) ([]byte, error) {
+	return loadKeyFromFile(path)
+}
+
+// NewVaultFromKey creates a Vault for an explicit 32-byte key. source is
+// reported by KeySource.
+func NewVaultFromKey(key []byte, source string) (*Vault, error) {
+	if len(key) != 32 {
+		return nil, fmt.Errorf("key must be 32 bytes, got %d bytes", len(key))
+	}
+	key = append([]byte(nil), key...)
+	return &Vault{key: key, fingerprint: computeFingerprint(key), source: source}, nil
+}
+
+// WithDecryptKeys returns a copy of v that encrypts with v's key but can also
+// decrypt with the master and decrypt-only keys of others. Keys with v's own
+// fingerprint and duplicates are ignored. It is used while rotating the
+// master key, when credentials exist under both the old and the new key.
+func (v *Vault) WithDecryptKeys(others ...*Vault) *Vault {
+	out := &Vault{key: v.key, fingerprint: v.fingerprint, source: v.source, keyFile: v.keyFile}
+	seen := map[string]bool{v.fingerprint: true}
+	add := func(k *Vault) {
+		if seen[k.fingerprint] {
+			return
+		}
+		seen[k.fingerprint] = true
+		out.decryptOnly = append(out.decryptOnly, &Vault{key: k.key, fingerprint: k.fingerprint, source: k.source})
+	}
+	for _, k := range append([]*Vault{v}, others...) {
+		if k == nil {
+			continue
+		}
+		add(k)
+		for _, d := range k.decryptOnly {
+			add(d)
+		}
+	}
+	return out
+}
+
+// DecryptOnlyFingerprints returns the fingerprints of the decrypt-only keys.
+func (v *Vault) DecryptOnlyFingerprints() []string {
+	fps := make([]string, 0, len(v.decryptOnly))
+	for _, d := range v.decryptOnly {
+		fps = append(fps, d.fingerprint)
+	}
+	return fps
+}
+
+// KeyFile returns the file the master key was loaded from, or "" when it came
+// from the environment or was supplied directly.
+func (v *Vault) KeyFile() string {
+	return v.keyFile
+}
+
+// ReplaceKeyFile writes next's master key to path as hex with 0600
+// permissions, first renaming the existing file to
+// <path>.<old fingerprint>.bak. It returns the backup path. The rename keeps
+// the previous key recoverable if a rotation has to be undone.
+func ReplaceKeyFile(path string, next *Vault) (string, error) {
+	old, err := loadKeyFromFile(path)
+	if err != nil {
+		return "", fmt.Errorf("read current key file %q: %w", path, err)
+	}
+	backup := fmt.Sprintf("%s.%s.bak", path, computeFingerprint(old))
+	if err := os.Rename(path, backup); err != nil {
+		return "", fmt.Errorf("back up key file %q: %w", path, err)
+	}
+	if err := saveKeyToFile(path, next.key); err != nil {
+		return backup, fmt.Errorf("write new key file %q (previous key kept at %q): %w", path, backup, err)
+	}
+	return backup, nil
+}
@@ -0,0 +1,103 @@
+package crypto_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/thebtf/engram/internal/crypto"
+)
+
+const otherHexKey = "2122232425262728292a2b2c2d2e2f303132333435363738393a3b3c3d3e3f40"
+
+func mustVault(t *testing.T, hexKey string) *crypto.Vault {
+	t.Helper()
+	key, err := crypto.ParseKey(hexKey)
+	if err != nil {
+		t.Fatalf("ParseKey: %v", err)
+	}
+	v, err := crypto.NewVaultFromKey(key, "test")
+	if err != nil {
+		t.Fatalf("NewVaultFromKey: %v", err)
+	}
+	return v
+}
+
+func TestParseKey(t *testing.T) {
+	if _, err := crypto.ParseKey(" " + testHexKey + "\n"); err != nil {
+		t.Errorf("ParseKey with whitespace: %v", err)
+	}
+	for _, bad := range []string{"", "zz", testHexKey[:32]} {
+		if _, err := crypto.ParseKey(bad); err == nil {
+			t.Errorf("ParseKey(%q): expected error", bad)
+		}
+	}
+}
+
+func TestVault_WithDecryptKeys(t *testing.T) {
+	oldVault := mustVault(t, testHexKey)
+	newVault := mustVault(t, otherHexKey)
+
+	oldCT, err := oldVault.Encrypt("old-secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := newVault.Decrypt(oldCT); err == nil {
+		t.Fatal("new key alone must not decrypt old ciphertext")
+	}
+
+	rotated := newVault.WithDecryptKeys(oldVault, newVault)
+	if rotated.Fingerprint() != newVault.Fingerprint() {
+		t.Errorf("fingerprint = %s, want the new key's %s", rotated.Fingerprint(), newVault.Fingerprint())
+	}
+	if got := rotated.DecryptOnlyFingerprints(); len(got) != 1 || got[0] != oldVault.Fingerprint() {
+		t.Errorf("decrypt-only fingerprints = %v, want [%s]", got, oldVault.Fingerprint())
+	}
+	if !rotated.MatchesFingerprint(oldVault.Fingerprint()) || !rotated.MatchesFingerprint(newVault.Fingerprint()) {
+		t.Error("rotated vault must match both fingerprints")
+	}
+	if pt, err := rotated.Decrypt(oldCT); err != nil || pt != "old-secret" {
+		t.Errorf("Decrypt old ciphertext = %q, %v", pt, err)
+	}
+
+	// New ciphertext is under the new key only.
+	newCT, err := rotated.Encrypt("new-secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pt, err := newVault.Decrypt(newCT); err != nil || pt != "new-secret" {
+		t.Errorf("new key Decrypt = %q, %v", pt, err)
+	}
+	if _, err := oldVault.Decrypt(newCT); err == nil {
+		t.Error("old key must not decrypt new ciphertext")
+	}
+}
+
+func TestReplaceKeyFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "vault.key")
+	if err := os.WriteFile(path, []byte(testHexKey), 0600); err != nil {
+		t.Fatal(err)
+	}
+	oldVault := mustVault(t, testHexKey)
+	newVault := mustVault(t, otherHexKey)
+
+	backup, err := crypto.ReplaceKeyFile(path, newVault)
+	if err != nil {
+		t.Fatalf("ReplaceKeyFile: %v", err)
+	}
+	if !strings.HasSuffix(backup, "."+oldVault.Fingerprint()+".bak") {
+		t.Errorf("backup path = %q", backup)
+	}
+	if key, err := crypto.ReadKeyFile(backup); err != nil || len(key) != 32 {
+		t.Errorf("backup key: %v", err)
+	}
+	key, err := crypto.ReadKeyFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	written, _ := crypto.NewVaultFromKey(key, "file")
+	if written.Fingerprint() != newVault.Fingerprint() {
+		t.Errorf("key file fingerprint = %s, want %s", written.Fingerprint(), newVault.Fingerprint())
+	}
+}
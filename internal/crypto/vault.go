@@ -20,10 +20,14 @@ import (
 )
 
 // Vault provides AES-256-GCM encryption using a single master key.
+// During and after a key rotation it may also hold decrypt-only keys
+// (see WithDecryptKeys) so credentials under the previous key stay readable.
 type Vault struct {
 	key         []byte // 32 bytes for AES-256
 	fingerprint string // SHA-256(key)[:16] hex
-	source      string // how the key was loaded: "env", "file", "auto_generated"
+	source      string // how the key was loaded: "env", "file", "auto_generated", "rotated"
+	keyFile     string // file the key was loaded from ("file" and "auto_generated" sources)
+	decryptOnly []*Vault
 }
 
 // NewVault creates a Vault by loading or generating the encryption key.
@@ -53,22 +57,17 @@ func NewVault(cfg *config.Config) (*Vault, error) {
 			return nil, fmt.Errorf("load key from ENGRAM_ENCRYPTION_KEY_FILE %q: %w", cfg.EncryptionKeyFile, err)
 		}
 		log.Info().Str("file", cfg.EncryptionKeyFile).Msg("vault: loaded key from file")
-		return &Vault{key: key, fingerprint: computeFingerprint(key), source: "file"}, nil
+		return &Vault{key: key, fingerprint: computeFingerprint(key), source: "file", keyFile: cfg.EncryptionKeyFile}, nil
 
 	default:
-		// Prefer /data/vault.key (Docker persistent volume) over ~/.engram/vault.key.
-		// This prevents key loss when containers are recreated.
-		keyFile := filepath.Join(config.DataDir(), "vault.key")
-		if altDir := "/data"; isDir(altDir) {
-			keyFile = filepath.Join(altDir, "vault.key")
-		}
+		keyFile := defaultKeyPath("vault.key")
 		if _, statErr := os.Stat(keyFile); statErr == nil {
 			key, err = loadKeyFromFile(keyFile)
 			if err != nil {
 				return nil, fmt.Errorf("load auto-generated key from %q: %w", keyFile, err)
 			}
 			log.Info().Str("file", keyFile).Msg("vault: loaded auto-generated key")
-			return &Vault{key: key, fingerprint: computeFingerprint(key), source: "auto_generated"}, nil
+			return &Vault{key: key, fingerprint: computeFingerprint(key), source: "auto_generated", keyFile: keyFile}, nil
 		} else if !os.IsNotExist(statErr) {
 			// Stat failed for a reason other than the file not existing (e.g.,
 			// permission denied, I/O error). Returning here prevents accidentally
@@ -88,7 +87,7 @@ func NewVault(cfg *config.Config) (*Vault, error) {
 			log.Warn().
 				Str("file", keyFile).
 				Msg("vault: auto-generated new encryption key — BACK UP THIS FILE to avoid losing access to stored credentials")
-			return &Vault{key: key, fingerprint: computeFingerprint(key), source: "auto_generated"}, nil
+			return &Vault{key: key, fingerprint: computeFingerprint(key), source: "auto_generated", keyFile: keyFile}, nil
 		}
 	}
 }
@@ -96,7 +95,39 @@ func NewVault(cfg *config.Config) (*Vault, error) {
 // Encrypt encrypts plaintext using AES-256-GCM.
 // Returns nonce (12B) || ciphertext || GCM tag as a single byte slice.
 func (v *Vault) Encrypt(plaintext string) ([]byte, error) {
-	block, err := aes.NewCipher(v.key)
+	return seal(v.key, []byte(plaintext), nil)
+}
+
+// Decrypt decrypts ciphertext produced by Encrypt.
+// Expected format: nonce (12B) || ciphertext || GCM tag (16B).
+// When the master key fails to authenticate the ciphertext, the decrypt-only
+// keys are tried in order; the master key's error is returned if none fits.
+func (v *Vault) Decrypt(ciphertext []byte) (string, error) {
+	plaintext, err := v.decrypt(ciphertext)
+	if err == nil {
+		return plaintext, nil
+	}
+	for _, other := range v.decryptOnly {
+		if pt, otherErr := other.decrypt(ciphertext); otherErr == nil {
+			return pt, nil
+		}
+	}
+	return "", err
+}
+
+// decrypt decrypts ciphertext with the master key only.
+func (v *Vault) decrypt(ciphertext []byte) (string, error) {
+	plaintext, err := open(v.key, ciphertext, nil)
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
+
+// seal encrypts plaintext with AES-256-GCM under key, authenticating aad.
+// Returns nonce (12B) || ciphertext || GCM tag as a single byte slice.
+func seal(key, plaintext, aad []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
 	if err != nil {
 		return nil, fmt.Errorf("create AES cipher: %w", err)
 	}
@@ -113,36 +144,33 @@ func (v *Vault) Encrypt(plaintext string) ([]byte, error) {
 
 	// gcm.Seal(dst, nonce, plaintext, additionalData) appends ciphertext+tag to dst.
 	// Using nonce as dst prepends the nonce to the output: nonce || ciphertext || tag.
-	ciphertext := gcm.Seal(nonce, nonce, []byte(plaintext), nil)
-	return ciphertext, nil
+	return gcm.Seal(nonce, nonce, plaintext, aad), nil
 }
 
-// Decrypt decrypts ciphertext produced by Encrypt.
-// Expected format: nonce (12B) || ciphertext || GCM tag (16B).
-func (v *Vault) Decrypt(ciphertext []byte) (string, error) {
-	block, err := aes.NewCipher(v.key)
+// open decrypts ciphertext produced by seal with the same key and aad.
+func open(key, ciphertext, aad []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
 	if err != nil {
-		return "", fmt.Errorf("create AES cipher: %w", err)
+		return nil, fmt.Errorf("create AES cipher: %w", err)
 	}
 
 	gcm, err := cipher.NewGCM(block)
 	if err != nil {
-		return "", fmt.Errorf("create GCM: %w", err)
+		return nil, fmt.Errorf("create GCM: %w", err)
 	}
 
 	nonceSize := gcm.NonceSize()
 	minLen := nonceSize + gcm.Overhead()
 	if len(ciphertext) < minLen {
-		return "", fmt.Errorf("ciphertext too short: got %d bytes, need at least %d", len(ciphertext), minLen)
+		return nil, fmt.Errorf("ciphertext too short: got %d bytes, need at least %d", len(ciphertext), minLen)
 	}
 
 	nonce, data := ciphertext[:nonceSize], ciphertext[nonceSize:]
-	plaintext, err := gcm.Open(nil, nonce, data, nil)
+	plaintext, err := gcm.Open(nil, nonce, data, aad)
 	if err != nil {
-		return "", fmt.Errorf("decrypt: %w", err)
+		return nil, fmt.Errorf("decrypt: %w", err)
 	}
-
-	return string(plaintext), nil
+	return plaintext, nil
 }
 
 // Fingerprint returns the first 16 hex chars of SHA-256(key).
@@ -155,10 +183,16 @@ func (v *Vault) KeySource() string {
 	return v.source
 }
 
-// MatchesFingerprint reports whether fp matches this vault's key fingerprint.
+// MatchesFingerprint reports whether fp matches this vault's key fingerprint
+// or the fingerprint of one of its decrypt-only keys, i.e. whether Decrypt can
+// open a credential recorded under fp.
 // Uses constant-time comparison to avoid timing side-channels on key-derived material.
 func (v *Vault) MatchesFingerprint(fp string) bool {
-	return subtle.ConstantTimeCompare([]byte(v.fingerprint), []byte(fp)) == 1
+	match := subtle.ConstantTimeCompare([]byte(v.fingerprint), []byte(fp)) == 1
+	for _, other := range v.decryptOnly {
+		match = other.MatchesFingerprint(fp) || match
+	}
+	return match
 }
 
 // VaultExists reports whether a vault key is already configured (env var set or
@@ -204,6 +238,16 @@ func loadKeyFromFile(path string) ([]byte, error) {
 	return nil, fmt.Errorf("key file must contain 32 raw bytes or 64 hex chars, got %d bytes", len(data))
 }
 
+// defaultKeyPath returns where an auto-generated key file called name lives.
+// Prefers /data (Docker persistent volume) over ~/.engram, which prevents key
+// loss when containers are recreated.
+func defaultKeyPath(name string) string {
+	if altDir := "/data"; isDir(altDir) {
+		return filepath.Join(altDir, name)
+	}
+	return filepath.Join(config.DataDir(), name)
+}
+
 // isDir returns true if path exists and is a directory.
 func isDir(path string) bool {
 	info, err := os.Stat(path)
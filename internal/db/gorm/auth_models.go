@@ -1,13 +1,17 @@
 package gorm
 
-import "time"
+import (
+	"time"
+
+	"github.com/thebtf/engram/pkg/models"
+)
 
 // User represents a dashboard operator.
 type User struct {
 	ID           int64      `gorm:"primaryKey;autoIncrement" json:"id"`
 	Email        string     `gorm:"uniqueIndex;size:255;not null" json:"email"`
 	PasswordHash string     `gorm:"size:255;not null;default:''" json:"-"`
-	Role         string     `gorm:"size:20;not null;default:operator" json:"role"`
+	Role         string     `gorm:"size:64;not null;default:operator" json:"role"`
 	Disabled     bool       `gorm:"not null;default:false" json:"disabled"`
 	CreatedAt    time.Time  `gorm:"not null" json:"created_at"`
 	LastLoginAt  *time.Time `json:"last_login_at,omitempty"`
@@ -15,6 +19,19 @@ type User struct {
 
 func (User) TableName() string { return "users" }
 
+// Role is a custom role: a named bundle of auth permissions that dashboard
+// users (users.role) and keycards (api_tokens.scope) can be assigned. The
+// built-in roles (admin, operator, read-write, read-only) are not stored.
+type Role struct {
+	Name        string                 `gorm:"primaryKey;size:64" json:"name"`
+	Description string                 `gorm:"type:text;not null;default:''" json:"description"`
+	Permissions models.JSONStringArray `gorm:"type:jsonb;not null;default:'[]'" json:"permissions"`
+	CreatedAt   time.Time              `gorm:"not null" json:"created_at"`
+	UpdatedAt   time.Time              `gorm:"not null" json:"updated_at"`
+}
+
+func (Role) TableName() string { return "roles" }
+
 // Invitation is a single-use registration code.
 type Invitation struct {
 	ID        int64      `gorm:"primaryKey;autoIncrement" json:"id"`
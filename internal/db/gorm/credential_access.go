@@ -0,0 +1,214 @@
+package gorm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/thebtf/engram/pkg/models"
+)
+
+// ErrCredentialAccessDenied is returned by CheckCredentialAccess when a
+// credential's policy does not allow the caller to decrypt it.
+var ErrCredentialAccessDenied = errors.New("credential access denied")
+
+// Transports recorded in CredentialAccessLog.Source.
+const (
+	CredentialAccessSourceREST = "rest"
+	CredentialAccessSourceMCP  = "mcp"
+)
+
+// CredentialAccessor identifies who is asking to decrypt a credential.
+// Admin is true for admin identities and when authentication is disabled.
+type CredentialAccessor struct {
+	KeycardID string
+	Role      string
+	Project   string
+	SessionID string
+	Source    string
+	Admin     bool
+}
+
+// CredentialPolicy is the access policy of one credential. Empty
+// AllowedProjects limits the credential to its own project; an empty
+// AllowedKeycards leaves keycards unrestricted.
+type CredentialPolicy struct {
+	Access          string   `json:"access"`
+	AllowedProjects []string `json:"allowed_projects"`
+	AllowedKeycards []string `json:"allowed_keycards"`
+}
+
+// Validate normalizes the policy in place: an empty access level becomes
+// reveal and blank or duplicate list entries are dropped.
+func (p *CredentialPolicy) Validate() error {
+	switch p.Access {
+	case "":
+		p.Access = models.CredentialAccessReveal
+	case models.CredentialAccessReveal, models.CredentialAccessReadOnly:
+	default:
+		return fmt.Errorf("invalid access %q: must be %q or %q", p.Access, models.CredentialAccessReveal, models.CredentialAccessReadOnly)
+	}
+	p.AllowedProjects = compactPolicyList(p.AllowedProjects)
+	p.AllowedKeycards = compactPolicyList(p.AllowedKeycards)
+	return nil
+}
+
+// compactPolicyList trims entries and drops blanks and duplicates, keeping order.
+func compactPolicyList(in []string) []string {
+	out := make([]string, 0, len(in))
+	for _, v := range in {
+		v = strings.TrimSpace(v)
+		if v != "" && !slices.Contains(out, v) {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// CheckCredentialAccess reports whether a may decrypt cred. Admins may always
+// decrypt. Everyone else needs reveal access, a caller project in
+// AllowedProjects (the credential's own project when the list is empty) and,
+// when AllowedKeycards is non-empty, a keycard in it. The returned error
+// wraps ErrCredentialAccessDenied and says which rule failed.
+func CheckCredentialAccess(cred *models.Credential, a CredentialAccessor) error {
+	if a.Admin {
+		return nil
+	}
+	if cred.Access == models.CredentialAccessReadOnly {
+		return fmt.Errorf("%w: credential is read-only", ErrCredentialAccessDenied)
+	}
+	allowedProjects := cred.AllowedProjects
+	if len(allowedProjects) == 0 {
+		allowedProjects = []string{cred.Project}
+	}
+	if !slices.Contains(allowedProjects, a.Project) {
+		if a.Project == "" {
+			return fmt.Errorf("%w: credential is restricted to other projects and the caller project is unknown", ErrCredentialAccessDenied)
+		}
+		return fmt.Errorf("%w: project %q is not allowed", ErrCredentialAccessDenied, a.Project)
+	}
+	if len(cred.AllowedKeycards) > 0 && !slices.Contains(cred.AllowedKeycards, a.KeycardID) {
+		return fmt.Errorf("%w: keycard is not allowed", ErrCredentialAccessDenied)
+	}
+	return nil
+}
+
+// SetPolicy replaces the access policy of the credential matching (project, key)
+// and returns the updated credential.
+// Returns a wrapped gorm.ErrRecordNotFound if no active row exists.
+func (s *CredentialStore) SetPolicy(ctx context.Context, project, key string, policy CredentialPolicy) (*models.Credential, error) {
+	if project == "" {
+		return nil, fmt.Errorf("project: must not be empty")
+	}
+	if key == "" {
+		return nil, fmt.Errorf("key: must not be empty")
+	}
+	if err := policy.Validate(); err != nil {
+		return nil, err
+	}
+	var row Credential
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("project = ? AND key = ? AND deleted_at IS NULL", project, key).First(&row).Error; err != nil {
+			return err
+		}
+		row.Access = policy.Access
+		row.AllowedProjects = policy.AllowedProjects
+		row.AllowedKeycards = policy.AllowedKeycards
+		row.UpdatedAt = time.Now().UTC()
+		return tx.Model(&Credential{}).Where("id = ?", row.ID).Updates(map[string]any{
+			"access":           row.Access,
+			"allowed_projects": row.AllowedProjects,
+			"allowed_keycards": row.AllowedKeycards,
+			"updated_at":       row.UpdatedAt,
+		}).Error
+	})
+	if err != nil {
+		return nil, fmt.Errorf("set credential policy %q/%q: %w", project, key, err)
+	}
+	return credentialRowToModel(&row), nil
+}
+
+// LogAccess records one decrypt attempt on cred by a. reason is empty for
+// allowed reads and explains the refusal for denied ones.
+func (s *CredentialStore) LogAccess(ctx context.Context, cred *models.Credential, a CredentialAccessor, allowed bool, reason string) error {
+	entry := &CredentialAccessLog{
+		CredentialID:      cred.ID,
+		CredentialProject: cred.Project,
+		CredentialKey:     cred.Key,
+		KeycardID:         a.KeycardID,
+		Role:              a.Role,
+		CallerProject:     a.Project,
+		SessionID:         a.SessionID,
+		Source:            a.Source,
+		Allowed:           allowed,
+		Reason:            reason,
+		CreatedAt:         time.Now().UTC(),
+	}
+	if err := s.db.WithContext(ctx).Create(entry).Error; err != nil {
+		return fmt.Errorf("log credential access %q/%q: %w", cred.Project, cred.Key, err)
+	}
+	return nil
+}
+
+// AuthorizeReveal checks a against cred's policy and logs the attempt either
+// way. It returns the CheckCredentialAccess error for a denied attempt, or the
+// logging error: a read that cannot be audited must not be served.
+func (s *CredentialStore) AuthorizeReveal(ctx context.Context, cred *models.Credential, a CredentialAccessor) error {
+	denied := CheckCredentialAccess(cred, a)
+	reason := ""
+	if denied != nil {
+		reason = strings.TrimPrefix(denied.Error(), ErrCredentialAccessDenied.Error()+": ")
+	}
+	if err := s.LogAccess(ctx, cred, a, denied == nil, reason); err != nil {
+		return err
+	}
+	return denied
+}
+
+// CredentialAccessFilter selects entries from the credential access log.
+// Zero fields do not filter. Limit defaults to 100 and is capped at 1000.
+type CredentialAccessFilter struct {
+	Since      time.Time
+	Project    string
+	Key        string
+	KeycardID  string
+	Limit      int
+	DeniedOnly bool
+}
+
+// ListAccessLog returns credential access log entries matching f, newest first.
+func (s *CredentialStore) ListAccessLog(ctx context.Context, f CredentialAccessFilter) ([]CredentialAccessLog, error) {
+	limit := f.Limit
+	if limit <= 0 {
+		limit = 100
+	}
+	if limit > 1000 {
+		limit = 1000
+	}
+	q := s.db.WithContext(ctx).Model(&CredentialAccessLog{})
+	if f.Project != "" {
+		q = q.Where("credential_project = ?", f.Project)
+	}
+	if f.Key != "" {
+		q = q.Where("credential_key = ?", f.Key)
+	}
+	if f.KeycardID != "" {
+		q = q.Where("keycard_id = ?", f.KeycardID)
+	}
+	if !f.Since.IsZero() {
+		q = q.Where("created_at >= ?", f.Since)
+	}
+	if f.DeniedOnly {
+		q = q.Where("allowed = FALSE")
+	}
+	entries := make([]CredentialAccessLog, 0)
+	if err := q.Order("created_at DESC, id DESC").Limit(limit).Find(&entries).Error; err != nil {
+		return nil, fmt.Errorf("list credential access log: %w", err)
+	}
+	return entries, nil
+}
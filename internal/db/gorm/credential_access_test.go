@@ -0,0 +1,116 @@
+package gorm
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/thebtf/engram/pkg/models"
+)
+
+func TestCheckCredentialAccess(t *testing.T) {
+	t.Parallel()
+
+	restricted := &models.Credential{
+		Access:          models.CredentialAccessReveal,
+		AllowedProjects: []string{"prod"},
+		AllowedKeycards: []string{"kc-1"},
+	}
+	readOnly := &models.Credential{Access: models.CredentialAccessReadOnly}
+	noPolicy := &models.Credential{Project: "dev", Access: models.CredentialAccessReveal}
+
+	tests := []struct {
+		name     string
+		cred     *models.Credential
+		accessor CredentialAccessor
+		allowed  bool
+	}{
+		{"no policy, own project", noPolicy, CredentialAccessor{Project: "dev", KeycardID: "kc-2"}, true},
+		{"no policy, other project", noPolicy, CredentialAccessor{Project: "prod", KeycardID: "kc-1"}, false},
+		{"no policy, unknown project", noPolicy, CredentialAccessor{KeycardID: "kc-1"}, false},
+		{"admin reads no-policy credential", noPolicy, CredentialAccessor{Admin: true}, true},
+		{"matching project and keycard", restricted, CredentialAccessor{Project: "prod", KeycardID: "kc-1"}, true},
+		{"wrong project", restricted, CredentialAccessor{Project: "dev", KeycardID: "kc-1"}, false},
+		{"unknown project", restricted, CredentialAccessor{KeycardID: "kc-1"}, false},
+		{"wrong keycard", restricted, CredentialAccessor{Project: "prod", KeycardID: "kc-2"}, false},
+		{"no keycard", restricted, CredentialAccessor{Project: "prod"}, false},
+		{"admin bypasses restrictions", restricted, CredentialAccessor{Admin: true}, true},
+		{"read-only", readOnly, CredentialAccessor{Project: "prod"}, false},
+		{"admin reads read-only", readOnly, CredentialAccessor{Admin: true}, true},
+	}
+	for _, tt := range tests {
+		err := CheckCredentialAccess(tt.cred, tt.accessor)
+		if tt.allowed {
+			assert.NoError(t, err, tt.name)
+		} else {
+			assert.True(t, errors.Is(err, ErrCredentialAccessDenied), "%s: err = %v", tt.name, err)
+		}
+	}
+}
+
+func TestCredentialPolicy_Validate(t *testing.T) {
+	t.Parallel()
+
+	p := CredentialPolicy{AllowedProjects: []string{" prod ", "", "prod", "staging"}}
+	require.NoError(t, p.Validate())
+	assert.Equal(t, models.CredentialAccessReveal, p.Access)
+	assert.Equal(t, []string{"prod", "staging"}, p.AllowedProjects)
+	assert.Equal(t, []string{}, p.AllowedKeycards)
+
+	bad := CredentialPolicy{Access: "write"}
+	assert.Error(t, bad.Validate())
+}
+
+// TestCredentialStore_PolicyAndAccessLog sets a policy, authorizes one allowed
+// and one denied read and checks both land in the access log.
+func TestCredentialStore_PolicyAndAccessLog(t *testing.T) {
+	db, cleanup := openTestDB(t)
+	defer cleanup()
+	defer db.Exec(`DELETE FROM credential_access_log WHERE credential_project = 'test-credential-store'`)
+
+	cs := NewCredentialStore(&Store{DB: db})
+	ctx := context.Background()
+	const testProject = "test-credential-store"
+
+	_, err := cs.Create(ctx, &models.Credential{
+		Project:                  testProject,
+		Key:                      "db-password-acl",
+		EncryptedSecret:          []byte("ciphertext"),
+		EncryptionKeyFingerprint: "fp",
+	})
+	require.NoError(t, err)
+
+	cred, err := cs.SetPolicy(ctx, testProject, "db-password-acl", CredentialPolicy{AllowedProjects: []string{"prod"}})
+	require.NoError(t, err)
+	assert.Equal(t, models.CredentialAccessReveal, cred.Access)
+	assert.Equal(t, []string{"prod"}, cred.AllowedProjects)
+
+	fetched, err := cs.Get(ctx, testProject, "db-password-acl")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"prod"}, fetched.AllowedProjects)
+
+	since := time.Now().Add(-time.Minute)
+	require.NoError(t, cs.AuthorizeReveal(ctx, fetched, CredentialAccessor{Project: "prod", KeycardID: "kc-1", SessionID: "s1", Source: CredentialAccessSourceMCP}))
+	err = cs.AuthorizeReveal(ctx, fetched, CredentialAccessor{Project: "dev", KeycardID: "kc-2", Source: CredentialAccessSourceREST})
+	require.ErrorIs(t, err, ErrCredentialAccessDenied)
+
+	entries, err := cs.ListAccessLog(ctx, CredentialAccessFilter{Project: testProject, Key: "db-password-acl", Since: since})
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.False(t, entries[0].Allowed)
+	assert.Equal(t, "kc-2", entries[0].KeycardID)
+	assert.Equal(t, `project "dev" is not allowed`, entries[0].Reason)
+	assert.True(t, entries[1].Allowed)
+	assert.Equal(t, "s1", entries[1].SessionID)
+
+	denied, err := cs.ListAccessLog(ctx, CredentialAccessFilter{Project: testProject, DeniedOnly: true, Since: since})
+	require.NoError(t, err)
+	assert.Len(t, denied, 1)
+
+	_, err = cs.SetPolicy(ctx, testProject, "missing", CredentialPolicy{})
+	assert.Error(t, err)
+}
@@ -0,0 +1,112 @@
+package gorm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/thebtf/engram/pkg/models"
+)
+
+// CredentialDataKeyStore persists per-project credential data keys in the
+// credential_data_keys table (migration 117). Keys are stored wrapped; this
+// store never sees them in the clear. It implements crypto.DataKeyStore.
+type CredentialDataKeyStore struct {
+	db *gorm.DB
+}
+
+// NewCredentialDataKeyStore creates a new CredentialDataKeyStore backed by the given Store.
+func NewCredentialDataKeyStore(store *Store) *CredentialDataKeyStore {
+	return &CredentialDataKeyStore{db: store.DB}
+}
+
+// GetDataKey returns the project's data key, or nil when it has none.
+func (s *CredentialDataKeyStore) GetDataKey(ctx context.Context, project string) (*models.CredentialDataKey, error) {
+	var row CredentialDataKey
+	err := s.db.WithContext(ctx).Where("project = ?", project).First(&row).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get data key for project %q: %w", project, err)
+	}
+	return dataKeyRowToModel(&row), nil
+}
+
+// CreateDataKey inserts key unless its project already has a data key, and
+// returns the project's stored key. Two writers racing to create the first
+// key of a project therefore both end up with the same one.
+func (s *CredentialDataKeyStore) CreateDataKey(ctx context.Context, key *models.CredentialDataKey) (*models.CredentialDataKey, error) {
+	if key == nil || key.Project == "" {
+		return nil, fmt.Errorf("data key project must not be empty")
+	}
+	if len(key.WrappedKey) == 0 || key.KEKID == "" || key.Fingerprint == "" {
+		return nil, fmt.Errorf("data key for project %q: wrapped key, KEK id and fingerprint are required", key.Project)
+	}
+	row := CredentialDataKey{
+		Project:     key.Project,
+		WrappedKey:  key.WrappedKey,
+		KEKID:       key.KEKID,
+		Fingerprint: key.Fingerprint,
+	}
+	if err := s.db.WithContext(ctx).
+		Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "project"}}, DoNothing: true}).
+		Create(&row).Error; err != nil {
+		return nil, fmt.Errorf("create data key for project %q: %w", key.Project, err)
+	}
+	stored, err := s.GetDataKey(ctx, key.Project)
+	if err != nil {
+		return nil, err
+	}
+	if stored == nil {
+		return nil, fmt.Errorf("create data key for project %q: row missing after insert", key.Project)
+	}
+	return stored, nil
+}
+
+// ListDataKeys returns every project's data key, ordered by project.
+func (s *CredentialDataKeyStore) ListDataKeys(ctx context.Context) ([]*models.CredentialDataKey, error) {
+	var rows []CredentialDataKey
+	if err := s.db.WithContext(ctx).Order("project").Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("list data keys: %w", err)
+	}
+	keys := make([]*models.CredentialDataKey, 0, len(rows))
+	for i := range rows {
+		keys = append(keys, dataKeyRowToModel(&rows[i]))
+	}
+	return keys, nil
+}
+
+// RewrapDataKey replaces the project's wrapped key and KEK id, but only while
+// the key is still wrapped by fromKEKID. It reports whether the row changed.
+// The condition makes a rewrap safe to repeat and to run concurrently.
+func (s *CredentialDataKeyStore) RewrapDataKey(ctx context.Context, project, fromKEKID string, wrapped []byte, toKEKID string) (bool, error) {
+	result := s.db.WithContext(ctx).Model(&CredentialDataKey{}).
+		Where("project = ? AND kek_id = ?", project, fromKEKID).
+		Updates(map[string]any{
+			"wrapped_key": wrapped,
+			"kek_id":      toKEKID,
+			"rotated_at":  time.Now(),
+		})
+	if result.Error != nil {
+		return false, fmt.Errorf("rewrap data key for project %q: %w", project, result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// dataKeyRowToModel converts a GORM row to the shared model type.
+func dataKeyRowToModel(row *CredentialDataKey) *models.CredentialDataKey {
+	return &models.CredentialDataKey{
+		ID:          row.ID,
+		Project:     row.Project,
+		WrappedKey:  row.WrappedKey,
+		KEKID:       row.KEKID,
+		Fingerprint: row.Fingerprint,
+		CreatedAt:   row.CreatedAt,
+		RotatedAt:   row.RotatedAt,
+	}
+}
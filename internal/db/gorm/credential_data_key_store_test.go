@@ -0,0 +1,54 @@
+package gorm
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/thebtf/engram/pkg/models"
+)
+
+func TestCredentialDataKeyStore(t *testing.T) {
+	db, cleanup := openTestDB(t)
+	defer cleanup()
+
+	ks := NewCredentialDataKeyStore(&Store{DB: db})
+	ctx := context.Background()
+	const project = "test-credential-data-key"
+	db.Exec(`DELETE FROM credential_data_keys WHERE project = ?`, project)
+	defer db.Exec(`DELETE FROM credential_data_keys WHERE project = ?`, project)
+
+	got, err := ks.GetDataKey(ctx, project)
+	require.NoError(t, err)
+	assert.Nil(t, got)
+
+	first, err := ks.CreateDataKey(ctx, &models.CredentialDataKey{
+		Project: project, WrappedKey: []byte("wrapped-1"), KEKID: "kek-old", Fingerprint: "fp-1",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "fp-1", first.Fingerprint)
+
+	// A second create for the same project returns the key that won.
+	second, err := ks.CreateDataKey(ctx, &models.CredentialDataKey{
+		Project: project, WrappedKey: []byte("wrapped-2"), KEKID: "kek-old", Fingerprint: "fp-2",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, first.ID, second.ID)
+	assert.Equal(t, []byte("wrapped-1"), second.WrappedKey)
+
+	ok, err := ks.RewrapDataKey(ctx, project, "kek-other", []byte("x"), "kek-new")
+	require.NoError(t, err)
+	assert.False(t, ok, "rewrap from the wrong KEK must not change the row")
+
+	ok, err = ks.RewrapDataKey(ctx, project, "kek-old", []byte("wrapped-new"), "kek-new")
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	got, err = ks.GetDataKey(ctx, project)
+	require.NoError(t, err)
+	assert.Equal(t, "kek-new", got.KEKID)
+	assert.Equal(t, []byte("wrapped-new"), got.WrappedKey)
+	assert.Equal(t, "fp-1", got.Fingerprint)
+}
@@ -0,0 +1,232 @@
+package gorm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// CredentialCipher encrypts and decrypts credential secrets under one master
+// key. *crypto.Vault implements it.
+type CredentialCipher interface {
+	Encrypt(plaintext string) ([]byte, error)
+	Decrypt(ciphertext []byte) (string, error)
+	Fingerprint() string
+}
+
+// DefaultRotationBatchSize is the number of credentials re-encrypted per
+// transaction when RotateKey is given no batch size.
+const DefaultRotationBatchSize = 100
+
+// ErrRotationVerifyFailed is returned by RotateKey when some credentials do
+// not decrypt with the old key. Nothing is re-encrypted in that case.
+var ErrRotationVerifyFailed = errors.New("credentials failed to decrypt with the old key")
+
+// CredentialRotationFailure is one credential that did not decrypt with the
+// old key during verification.
+type CredentialRotationFailure struct {
+	Project string `json:"project"`
+	Key     string `json:"key"`
+	Error   string `json:"error"`
+	ID      int64  `json:"id"`
+}
+
+// CredentialCipherFunc returns the cipher a project's credentials are
+// encrypted with. Keyring.ForProject, wrapped, is one.
+type CredentialCipherFunc func(ctx context.Context, project string) (CredentialCipher, error)
+
+// CredentialRotation reports the outcome of RotateKey and MoveToDataKeys.
+// Pending counts the credentials under the old key when the run started
+// (after a crash, the ones still left); AlreadyRotated those already under the
+// new key, and Foreign those under neither key, which rotation leaves alone.
+// When moving to data keys there is no single new key: ToDataKeys is set and
+// every credential not under the old key counts as Foreign.
+type CredentialRotation struct {
+	FromFingerprint string                      `json:"from_fingerprint"`
+	ToFingerprint   string                      `json:"to_fingerprint"`
+	Failed          []CredentialRotationFailure `json:"failed"`
+	Pending         int                         `json:"pending"`
+	Verified        int                         `json:"verified"`
+	Rotated         int                         `json:"rotated"`
+	AlreadyRotated  int                         `json:"already_rotated"`
+	Foreign         int                         `json:"foreign"`
+	Batches         int                         `json:"batches"`
+	DryRun          bool                        `json:"dry_run"`
+	ToDataKeys      bool                        `json:"to_data_keys,omitempty"`
+}
+
+// RotateKey re-encrypts every credential stored under from's key with to's
+// key, batchSize rows per transaction. Each row's secret and
+// encryption_key_fingerprint change together, so a run interrupted by a crash
+// is resumed by running it again: rows already under the new key are skipped
+// and only the remainder is processed.
+//
+// Every pending row is first decrypted with the old key. If any fails, the
+// failures are returned with ErrRotationVerifyFailed and nothing is changed.
+// With dryRun the verification is all that runs.
+func (s *CredentialStore) RotateKey(ctx context.Context, from, to CredentialCipher, batchSize int, dryRun bool) (*CredentialRotation, error) {
+	if from == nil || to == nil {
+		return nil, fmt.Errorf("rotate credentials: old and new keys are required")
+	}
+	if from.Fingerprint() == to.Fingerprint() {
+		return nil, fmt.Errorf("rotate credentials: old and new keys are the same (fingerprint %s)", from.Fingerprint())
+	}
+	return s.rotate(ctx, from, staticCipher(to), to.Fingerprint(), batchSize, dryRun)
+}
+
+// MoveToDataKeys re-encrypts every credential stored under from's key with
+// its project's data key, as returned by keys. It is how credentials that
+// predate envelope encryption, encrypted directly with the master key, move
+// under per-project data keys. Batching, verification, resumption and dryRun
+// work as in RotateKey.
+func (s *CredentialStore) MoveToDataKeys(ctx context.Context, from CredentialCipher, keys CredentialCipherFunc, batchSize int, dryRun bool) (*CredentialRotation, error) {
+	if from == nil || keys == nil {
+		return nil, fmt.Errorf("rotate credentials: old key and data keys are required")
+	}
+	return s.rotate(ctx, from, keys, "", batchSize, dryRun)
+}
+
+// staticCipher returns a CredentialCipherFunc that gives every project c.
+func staticCipher(c CredentialCipher) CredentialCipherFunc {
+	return func(context.Context, string) (CredentialCipher, error) { return c, nil }
+}
+
+// rotate implements RotateKey and MoveToDataKeys. toFP is the new key's
+// fingerprint, or empty when the new key depends on the project.
+func (s *CredentialStore) rotate(ctx context.Context, from CredentialCipher, to CredentialCipherFunc, toFP string, batchSize int, dryRun bool) (*CredentialRotation, error) {
+	fromFP := from.Fingerprint()
+	if batchSize <= 0 {
+		batchSize = DefaultRotationBatchSize
+	}
+	result := &CredentialRotation{
+		FromFingerprint: fromFP,
+		ToFingerprint:   toFP,
+		Failed:          make([]CredentialRotationFailure, 0),
+		DryRun:          dryRun,
+		ToDataKeys:      toFP == "",
+	}
+
+	type count struct {
+		dst   *int
+		where string
+		args  []any
+	}
+	counts := []count{{&result.Pending, "encryption_key_fingerprint = ?", []any{fromFP}}}
+	if toFP != "" {
+		counts = append(counts,
+			count{&result.AlreadyRotated, "encryption_key_fingerprint = ?", []any{toFP}},
+			count{&result.Foreign, "encryption_key_fingerprint NOT IN ?", []any{[]string{fromFP, toFP}}},
+		)
+	} else {
+		counts = append(counts, count{&result.Foreign, "encryption_key_fingerprint <> ?", []any{fromFP}})
+	}
+	for _, c := range counts {
+		var n int64
+		if err := s.db.WithContext(ctx).Model(&Credential{}).Where("deleted_at IS NULL").Where(c.where, c.args...).Count(&n).Error; err != nil {
+			return nil, fmt.Errorf("rotate credentials: count: %w", err)
+		}
+		*c.dst = int(n)
+	}
+
+	// Verification pass: keyset-paginate the pending rows and decrypt each.
+	var lastID int64
+	for {
+		var rows []Credential
+		if err := s.db.WithContext(ctx).
+			Where("deleted_at IS NULL AND encryption_key_fingerprint = ? AND id > ?", fromFP, lastID).
+			Order("id").Limit(batchSize).
+			Find(&rows).Error; err != nil {
+			return nil, fmt.Errorf("rotate credentials: verify: %w", err)
+		}
+		if len(rows) == 0 {
+			break
+		}
+		for i := range rows {
+			if _, err := from.Decrypt(rows[i].EncryptedSecret); err != nil {
+				result.Failed = append(result.Failed, CredentialRotationFailure{
+					ID: rows[i].ID, Project: rows[i].Project, Key: rows[i].Key, Error: err.Error(),
+				})
+				continue
+			}
+			result.Verified++
+		}
+		lastID = rows[len(rows)-1].ID
+	}
+	if len(result.Failed) > 0 && !dryRun {
+		return result, fmt.Errorf("rotate credentials: %d %w; nothing was rotated", len(result.Failed), ErrRotationVerifyFailed)
+	}
+	if dryRun {
+		return result, nil
+	}
+
+	// Rotation pass: lock and rewrite batches until no row is left under the
+	// old key. Rows stored under the old key while this runs are picked up too.
+	for {
+		n, err := s.rotateBatch(ctx, from, to, batchSize)
+		if err != nil {
+			return result, fmt.Errorf("rotate credentials: batch %d (after %d rotated): %w", result.Batches+1, result.Rotated, err)
+		}
+		if n == 0 {
+			break
+		}
+		result.Batches++
+		result.Rotated += n
+		log.Info().
+			Str("from", fromFP).
+			Str("to", toFP).
+			Bool("to_data_keys", result.ToDataKeys).
+			Int("batch", result.Batches).
+			Int("rotated", result.Rotated).
+			Msg("credential key rotation: batch committed")
+	}
+	return result, nil
+}
+
+// rotateBatch re-encrypts up to limit credentials still under from's key in
+// one transaction, each with the cipher to returns for its project, and
+// returns how many it rotated.
+func (s *CredentialStore) rotateBatch(ctx context.Context, from CredentialCipher, to CredentialCipherFunc, limit int) (int, error) {
+	rotated := 0
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var rows []Credential
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("deleted_at IS NULL AND encryption_key_fingerprint = ?", from.Fingerprint()).
+			Order("id").Limit(limit).
+			Find(&rows).Error; err != nil {
+			return err
+		}
+		for i := range rows {
+			plaintext, err := from.Decrypt(rows[i].EncryptedSecret)
+			if err != nil {
+				return fmt.Errorf("decrypt credential %d (%s/%s): %w", rows[i].ID, rows[i].Project, rows[i].Key, err)
+			}
+			next, err := to(ctx, rows[i].Project)
+			if err != nil {
+				return fmt.Errorf("new key for credential %d (project %s): %w", rows[i].ID, rows[i].Project, err)
+			}
+			if next.Fingerprint() == from.Fingerprint() {
+				return fmt.Errorf("new key for project %s is the old key (fingerprint %s)", rows[i].Project, from.Fingerprint())
+			}
+			secret, err := next.Encrypt(plaintext)
+			if err != nil {
+				return fmt.Errorf("encrypt credential %d: %w", rows[i].ID, err)
+			}
+			if err := tx.Model(&Credential{}).Where("id = ?", rows[i].ID).Updates(map[string]any{
+				"encrypted_secret":           secret,
+				"encryption_key_fingerprint": next.Fingerprint(),
+			}).Error; err != nil {
+				return err
+			}
+			rotated++
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return rotated, nil
+}
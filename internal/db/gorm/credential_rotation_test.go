@@ -0,0 +1,148 @@
+package gorm
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/thebtf/engram/pkg/models"
+)
+
+// prefixCipher is a stand-in CredentialCipher that "encrypts" by prefixing
+// the plaintext with its fingerprint.
+type prefixCipher string
+
+func (c prefixCipher) Fingerprint() string { return string(c) }
+
+func (c prefixCipher) Encrypt(plaintext string) ([]byte, error) {
+	return []byte(string(c) + ":" + plaintext), nil
+}
+
+func (c prefixCipher) Decrypt(ciphertext []byte) (string, error) {
+	plaintext, ok := strings.CutPrefix(string(ciphertext), string(c)+":")
+	if !ok {
+		return "", fmt.Errorf("wrong key")
+	}
+	return plaintext, nil
+}
+
+func TestCredentialStore_RotateKey(t *testing.T) {
+	db, cleanup := openTestDB(t)
+	defer cleanup()
+
+	cs := NewCredentialStore(&Store{DB: db})
+	ctx := context.Background()
+	const testProject = "test-credential-store"
+	oldKey, newKey := prefixCipher("rotate-old"), prefixCipher("rotate-new")
+	// Other test data in the shared database may use any fingerprint; only
+	// rows under the two test keys are asserted on.
+	db.Exec(`DELETE FROM credentials WHERE encryption_key_fingerprint IN (?, ?)`, string(oldKey), string(newKey))
+
+	for i := 0; i < 5; i++ {
+		secret, _ := oldKey.Encrypt(fmt.Sprintf("secret-%d", i))
+		_, err := cs.Create(ctx, &models.Credential{
+			Project:                  testProject,
+			Key:                      fmt.Sprintf("rotate-%d", i),
+			EncryptedSecret:          secret,
+			EncryptionKeyFingerprint: oldKey.Fingerprint(),
+		})
+		require.NoError(t, err)
+	}
+
+	_, err := cs.RotateKey(ctx, oldKey, oldKey, 2, false)
+	assert.Error(t, err, "same key must be rejected")
+
+	dry, err := cs.RotateKey(ctx, oldKey, newKey, 2, true)
+	require.NoError(t, err)
+	assert.Equal(t, 5, dry.Pending)
+	assert.Equal(t, 5, dry.Verified)
+	assert.Equal(t, 0, dry.Rotated)
+	// The dry run changed nothing.
+	cred, err := cs.Get(ctx, testProject, "rotate-0")
+	require.NoError(t, err)
+	assert.Equal(t, oldKey.Fingerprint(), cred.EncryptionKeyFingerprint)
+
+	// Simulate a crash after the first batch: rotate one row by hand, then
+	// run the rotation, which must finish the remaining four.
+	_, err = cs.rotateBatch(ctx, oldKey, staticCipher(newKey), 1)
+	require.NoError(t, err)
+	res, err := cs.RotateKey(ctx, oldKey, newKey, 2, false)
+	require.NoError(t, err)
+	assert.Equal(t, 4, res.Pending)
+	assert.Equal(t, 1, res.AlreadyRotated)
+	assert.Equal(t, 4, res.Rotated)
+	assert.Equal(t, 2, res.Batches)
+
+	for i := 0; i < 5; i++ {
+		cred, err := cs.Get(ctx, testProject, fmt.Sprintf("rotate-%d", i))
+		require.NoError(t, err)
+		assert.Equal(t, newKey.Fingerprint(), cred.EncryptionKeyFingerprint)
+		plaintext, err := newKey.Decrypt(cred.EncryptedSecret)
+		require.NoError(t, err)
+		assert.Equal(t, fmt.Sprintf("secret-%d", i), plaintext)
+	}
+
+	// A row that does not decrypt blocks the whole rotation.
+	_, err = cs.Create(ctx, &models.Credential{
+		Project:                  testProject,
+		Key:                      "rotate-corrupt",
+		EncryptedSecret:          []byte("garbage"),
+		EncryptionKeyFingerprint: newKey.Fingerprint(),
+	})
+	require.NoError(t, err)
+	res, err = cs.RotateKey(ctx, newKey, oldKey, 2, false)
+	require.ErrorIs(t, err, ErrRotationVerifyFailed)
+	require.Len(t, res.Failed, 1)
+	assert.Equal(t, "rotate-corrupt", res.Failed[0].Key)
+	assert.Equal(t, 0, res.Rotated)
+}
+
+func TestCredentialStore_MoveToDataKeys(t *testing.T) {
+	db, cleanup := openTestDB(t)
+	defer cleanup()
+
+	cs := NewCredentialStore(&Store{DB: db})
+	ctx := context.Background()
+	const testProject = "test-credential-store"
+	master := prefixCipher("move-master")
+	dataKeys := map[string]prefixCipher{testProject: "move-dk-a", testProject + "-b": "move-dk-b"}
+	db.Exec(`DELETE FROM credentials WHERE encryption_key_fingerprint IN (?, ?, ?)`, string(master), "move-dk-a", "move-dk-b")
+	db.Exec(`DELETE FROM credentials WHERE project = ?`, testProject+"-b")
+	defer db.Exec(`DELETE FROM credentials WHERE project = ?`, testProject+"-b")
+
+	for project := range dataKeys {
+		secret, _ := master.Encrypt("secret-" + project)
+		_, err := cs.Create(ctx, &models.Credential{
+			Project:                  project,
+			Key:                      "move-legacy",
+			EncryptedSecret:          secret,
+			EncryptionKeyFingerprint: master.Fingerprint(),
+		})
+		require.NoError(t, err)
+	}
+
+	keys := func(_ context.Context, project string) (CredentialCipher, error) {
+		c, ok := dataKeys[project]
+		if !ok {
+			return nil, fmt.Errorf("no data key for %s", project)
+		}
+		return c, nil
+	}
+	res, err := cs.MoveToDataKeys(ctx, master, keys, 10, false)
+	require.NoError(t, err)
+	assert.True(t, res.ToDataKeys)
+	assert.Equal(t, 2, res.Rotated)
+
+	for project, dk := range dataKeys {
+		cred, err := cs.Get(ctx, project, "move-legacy")
+		require.NoError(t, err)
+		assert.Equal(t, dk.Fingerprint(), cred.EncryptionKeyFingerprint)
+		plaintext, err := dk.Decrypt(cred.EncryptedSecret)
+		require.NoError(t, err)
+		assert.Equal(t, "secret-"+project, plaintext)
+	}
+}
@@ -62,10 +62,16 @@ func (s *CredentialStore) Create(ctx context.Context, cred *models.Credential) (
 		EncryptionKeyFingerprint: cred.EncryptionKeyFingerprint,
 		Scope:                    cred.Scope,
 		EditedBy:                 cred.EditedBy,
+		Access:                   cred.Access,
+		AllowedProjects:          append(models.JSONStringArray(nil), cred.AllowedProjects...),
+		AllowedKeycards:          append(models.JSONStringArray(nil), cred.AllowedKeycards...),
 		Version:                  1,
 		CreatedAt:                now,
 		UpdatedAt:                now,
 	}
+	if row.Access == "" {
+		row.Access = models.CredentialAccessReveal
+	}
 	if cred.Version > 0 {
 		row.Version = cred.Version
 	}
@@ -222,16 +228,17 @@ func (s *CredentialStore) CountCredentials(ctx context.Context) (int64, error) {
 }
 
 // CountWithDifferentFingerprint counts active credentials whose
-// encryption_key_fingerprint differs from currentFingerprint.
+// encryption_key_fingerprint differs from currentFingerprint and from every
+// one of otherFingerprints (decrypt-only keys, per-project data keys).
 // A non-zero result means some credentials were encrypted with a different key
 // (key rotation happened or the wrong key is in use).
 // Mirrors ObservationStore.CountCredentialsWithDifferentFingerprint signature.
-func (s *CredentialStore) CountWithDifferentFingerprint(ctx context.Context, currentFingerprint string) (int64, error) {
+func (s *CredentialStore) CountWithDifferentFingerprint(ctx context.Context, currentFingerprint string, otherFingerprints ...string) (int64, error) {
 	var count int64
 	err := s.db.WithContext(ctx).
 		Model(&Credential{}).
 		Where("deleted_at IS NULL").
-		Where("encryption_key_fingerprint != '' AND encryption_key_fingerprint != ?", currentFingerprint).
+		Where("encryption_key_fingerprint != '' AND encryption_key_fingerprint NOT IN ?", append([]string{currentFingerprint}, otherFingerprints...)).
 		Count(&count).Error
 	if err != nil {
 		return 0, fmt.Errorf("count mismatched credentials: %w", err)
@@ -240,17 +247,17 @@ func (s *CredentialStore) CountWithDifferentFingerprint(ctx context.Context, cur
 }
 
 // DeleteOrphanedByFingerprint hard-deletes (permanent DELETE) all active credentials
-// whose encryption_key_fingerprint differs from currentFingerprint.
-// These rows cannot be decrypted with the current key and are irrecoverable.
+// whose encryption_key_fingerprint differs from currentFingerprint and from
+// every one of otherFingerprints. These rows cannot be decrypted with the current key and are irrecoverable.
 // Returns the number of rows deleted.
 // Mirrors ObservationStore.DeleteOrphanedCredentials signature.
-func (s *CredentialStore) DeleteOrphanedByFingerprint(ctx context.Context, currentFingerprint string) (int64, error) {
+func (s *CredentialStore) DeleteOrphanedByFingerprint(ctx context.Context, currentFingerprint string, otherFingerprints ...string) (int64, error) {
 	if currentFingerprint == "" {
 		return 0, fmt.Errorf("currentFingerprint must not be empty")
 	}
 	result := s.db.WithContext(ctx).
 		Where("deleted_at IS NULL").
-		Where("encryption_key_fingerprint != '' AND encryption_key_fingerprint != ?", currentFingerprint).
+		Where("encryption_key_fingerprint != '' AND encryption_key_fingerprint NOT IN ?", append([]string{currentFingerprint}, otherFingerprints...)).
 		Delete(&Credential{})
 	if result.Error != nil {
 		return 0, fmt.Errorf("delete orphaned credentials (fingerprint %q): %w", currentFingerprint, result.Error)
@@ -268,6 +275,9 @@ func credentialRowToModel(row *Credential) *models.Credential {
 		EncryptionKeyFingerprint: row.EncryptionKeyFingerprint,
 		Scope:                    row.Scope,
 		EditedBy:                 row.EditedBy,
+		Access:                   row.Access,
+		AllowedProjects:          []string(row.AllowedProjects),
+		AllowedKeycards:          []string(row.AllowedKeycards),
 		Version:                  row.Version,
 		CreatedAt:                row.CreatedAt,
 		UpdatedAt:                row.UpdatedAt,
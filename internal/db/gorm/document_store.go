@@ -6,18 +6,13 @@ import (
 	"crypto/sha256"
 	"database/sql"
 	"encoding/hex"
-	"errors"
 	"fmt"
+	"strings"
 
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
 )
 
-// ErrChunkStorageUnsupported is returned by chunk methods when content_chunks
-// table has been dropped in v5. Callers should treat this as a graceful
-// "feature removed" signal and degrade accordingly.
-var ErrChunkStorageUnsupported = errors.New("chunk storage unsupported: content_chunks table dropped in v5")
-
 // DocumentStore provides document and chunk persistence for content-addressable storage.
 type DocumentStore struct {
 	db    *gorm.DB
@@ -110,23 +105,136 @@ func (s *DocumentStore) ListDocuments(ctx context.Context, collection string, ac
 	return docs, nil
 }
 
-// UpsertChunks returns ErrChunkStorageUnsupported in v5.
-// content_chunks was dropped in migration 085; callers should degrade gracefully.
-func (s *DocumentStore) UpsertChunks(_ context.Context, _ string, _ []ContentChunk) error {
-	return ErrChunkStorageUnsupported
+// UpsertChunks replaces the chunks stored for a content hash. Seq is assigned
+// from the slice order; Hash on the given chunks is ignored.
+func (s *DocumentStore) UpsertChunks(ctx context.Context, hash string, chunks []ContentChunk) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("hash = ?", hash).Delete(&ContentChunk{}).Error; err != nil {
+			return fmt.Errorf("delete chunks: %w", err)
+		}
+		if len(chunks) == 0 {
+			return nil
+		}
+		rows := make([]ContentChunk, len(chunks))
+		for i, chunk := range chunks {
+			chunk.Hash = hash
+			chunk.Seq = i
+			rows[i] = chunk
+		}
+		if err := tx.CreateInBatches(rows, 200).Error; err != nil {
+			return fmt.Errorf("insert chunks: %w", err)
+		}
+		return nil
+	})
+}
+
+// ChunksExist reports whether any chunks are stored for a content hash.
+func (s *DocumentStore) ChunksExist(ctx context.Context, hash string) (bool, error) {
+	var exists bool
+	if err := s.db.WithContext(ctx).
+		Raw(`SELECT EXISTS (SELECT 1 FROM content_chunks WHERE hash = ?)`, hash).
+		Scan(&exists).Error; err != nil {
+		return false, fmt.Errorf("check chunks: %w", err)
+	}
+	return exists, nil
+}
+
+// ChunkSearchParams holds the inputs for DocumentStore.SearchChunks.
+// Query uses websearch_to_tsquery syntax, as in MemorySearchParams.
+// An empty Collection searches every collection.
+type ChunkSearchParams struct {
+	Query      string
+	Collection string
+	Limit      int
 }
 
-// SearchChunks returns ErrChunkStorageUnsupported in v5.
-// content_chunks was dropped in migration 085; callers should treat this as
-// "feature removed", not as "zero results found".
-func (s *DocumentStore) SearchChunks(_ context.Context, _ []float32, _ string, _ int) ([]ContentChunk, error) {
-	return nil, ErrChunkStorageUnsupported
+// ChunkSearchHit is one ranked chunk of an active document.
+// Snippet is a ts_headline excerpt with matched terms wrapped in ** markers.
+type ChunkSearchHit struct {
+	Collection string
+	Path       string
+	Title      string
+	Snippet    string
+	Chunk      ContentChunk
+	Score      float64
 }
 
-// ChunksExist returns ErrChunkStorageUnsupported in v5.
-// content_chunks was dropped in migration 085.
-func (s *DocumentStore) ChunksExist(_ context.Context, _ string) (bool, error) {
-	return false, ErrChunkStorageUnsupported
+// chunkSearchRow is the scan target for the SearchChunks query.
+type chunkSearchRow struct {
+	ContentChunk
+	Collection string  `gorm:"column:collection"`
+	Path       string  `gorm:"column:path"`
+	Title      string  `gorm:"column:title"`
+	Snippet    string  `gorm:"column:snippet"`
+	Score      float64 `gorm:"column:score"`
+}
+
+// SearchChunks runs a ranked full-text query over the chunks of active
+// documents (migration 108), parsed under both dictionaries like
+// MemoryStore.Search. A body shared by several documents yields one hit per
+// document. Ranking happens before snippets are built, so ts_headline only
+// runs for the rows returned.
+func (s *DocumentStore) SearchChunks(ctx context.Context, params ChunkSearchParams) ([]ChunkSearchHit, error) {
+	query := strings.TrimSpace(params.Query)
+	if query == "" {
+		return nil, fmt.Errorf("query: must not be empty")
+	}
+	limit := params.Limit
+	if limit <= 0 {
+		limit = 10
+	}
+	if limit > MaxPaginationLimit {
+		limit = MaxPaginationLimit
+	}
+
+	var rows []chunkSearchRow
+	err := s.db.WithContext(ctx).Raw(`
+		WITH q AS (
+			SELECT websearch_to_tsquery('english', @query) || websearch_to_tsquery('simple', @query) AS tsq
+		),
+		ranked AS (
+			SELECT d.id AS document_id, c.hash, c.seq,
+			       ts_rank_cd(c.search_vector, q.tsq)::float8 AS score
+			FROM content_chunks c
+			CROSS JOIN q
+			JOIN documents d ON d.hash = c.hash AND d.active = true
+			WHERE c.search_vector @@ q.tsq
+			  AND (@collection = '' OR d.collection = @collection)
+			ORDER BY score DESC, d.collection, d.path, c.seq
+			LIMIT @limit
+		)
+		SELECT c.hash, c.seq, c.kind, c.name, c.parent_name, c.language,
+		       c.start_line, c.end_line, c.text, c.created_at,
+		       d.collection, d.path, COALESCE(d.title, '') AS title, r.score,
+		       ts_headline('english', c.text, q.tsq, @headline) AS snippet
+		FROM ranked r
+		JOIN documents d ON d.id = r.document_id
+		JOIN content_chunks c ON c.hash = r.hash AND c.seq = r.seq
+		CROSS JOIN q
+		ORDER BY r.score DESC, d.collection, d.path, c.seq`,
+		map[string]any{
+			"query":      query,
+			"collection": params.Collection,
+			"headline":   memorySearchHeadlineOptions,
+			"limit":      limit,
+		},
+	).Scan(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("search chunks: %w", err)
+	}
+
+	hits := make([]ChunkSearchHit, len(rows))
+	for i, row := range rows {
+		hits[i] = ChunkSearchHit{
+			Collection: row.Collection,
+			Path:       row.Path,
+			Title:      row.Title,
+			Snippet:    row.Snippet,
+			Chunk:      row.ContentChunk,
+			Score:      row.Score,
+		}
+	}
+	return hits, nil
 }
 
 // DeactivateDocument marks a document as inactive.
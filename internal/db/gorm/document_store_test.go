@@ -0,0 +1,67 @@
+package gorm
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestDocumentStore_Chunks covers UpsertChunks, ChunksExist and SearchChunks,
+// including that chunks of deactivated documents are not returned.
+func TestDocumentStore_Chunks(t *testing.T) {
+	db, cleanup := openTestDB(t)
+	defer cleanup()
+	defer db.Exec(`DELETE FROM documents WHERE collection LIKE 'test-chunks-%'`)
+
+	ds := NewDocumentStore(&Store{DB: db})
+	ctx := context.Background()
+
+	body := "# Storage\n\nThe outbox drains to Postgres.\n\n## Retries\n\nExponential backoff with jitter."
+	doc, err := ds.UpsertDocument(ctx, "test-chunks-arch", "docs/storage.md", "Storage", body)
+	require.NoError(t, err)
+	hash := doc.Hash.String
+
+	exists, err := ds.ChunksExist(ctx, hash)
+	require.NoError(t, err)
+	assert.False(t, exists)
+
+	require.NoError(t, ds.UpsertChunks(ctx, hash, []ContentChunk{
+		{Kind: "section", Name: "Storage", Language: "markdown", StartLine: 1, EndLine: 4, Text: "# Storage\n\nThe outbox drains to Postgres."},
+		{Kind: "section", Name: "Retries", Language: "markdown", StartLine: 5, EndLine: 7, Text: "## Retries\n\nExponential backoff with jitter."},
+	}))
+	exists, err = ds.ChunksExist(ctx, hash)
+	require.NoError(t, err)
+	assert.True(t, exists)
+
+	hits, err := ds.SearchChunks(ctx, ChunkSearchParams{Query: "backoff", Collection: "test-chunks-arch"})
+	require.NoError(t, err)
+	require.Len(t, hits, 1)
+	assert.Equal(t, "docs/storage.md", hits[0].Path)
+	assert.Equal(t, "Storage", hits[0].Title)
+	assert.Equal(t, "Retries", hits[0].Chunk.Name)
+	assert.Equal(t, 1, hits[0].Chunk.Seq)
+	assert.Equal(t, 5, hits[0].Chunk.StartLine)
+	assert.Contains(t, hits[0].Snippet, "**backoff**")
+
+	hits, err = ds.SearchChunks(ctx, ChunkSearchParams{Query: "backoff", Collection: "test-chunks-other"})
+	require.NoError(t, err)
+	assert.Empty(t, hits, "collection filter")
+
+	// Re-chunking replaces the previous chunk set.
+	require.NoError(t, ds.UpsertChunks(ctx, hash, []ContentChunk{{Kind: "text", StartLine: 1, EndLine: 7, Text: body}}))
+	hits, err = ds.SearchChunks(ctx, ChunkSearchParams{Query: "outbox", Collection: "test-chunks-arch"})
+	require.NoError(t, err)
+	require.Len(t, hits, 1)
+	assert.Equal(t, 0, hits[0].Chunk.Seq)
+	assert.Equal(t, "text", hits[0].Chunk.Kind)
+
+	require.NoError(t, ds.DeactivateDocument(ctx, "test-chunks-arch", "docs/storage.md"))
+	hits, err = ds.SearchChunks(ctx, ChunkSearchParams{Query: "outbox", Collection: "test-chunks-arch"})
+	require.NoError(t, err)
+	assert.Empty(t, hits, "inactive documents are not searched")
+
+	_, err = ds.SearchChunks(ctx, ChunkSearchParams{Query: "  "})
+	assert.Error(t, err)
+}
@@ -0,0 +1,223 @@
+package gorm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// DefaultIssueLease is the claim lease used when the caller does not ask
+	// for one.
+	DefaultIssueLease = 30 * time.Minute
+	// MaxIssueLease caps a single claim or renewal; longer work renews.
+	MaxIssueLease = 24 * time.Hour
+)
+
+// HasLiveClaim reports whether a session holds an unexpired claim on the issue.
+func (i *Issue) HasLiveClaim(now time.Time) bool {
+	return i.AssigneeSession != "" && i.LeaseExpiresAt != nil && i.LeaseExpiresAt.After(now)
+}
+
+// validateIssueLease checks a claim or renewal lease.
+func validateIssueLease(lease time.Duration) error {
+	if lease < time.Minute || lease > MaxIssueLease {
+		return fmt.Errorf("invalid lease %s: must be between 1m and %s", lease, MaxIssueLease)
+	}
+	return nil
+}
+
+// ClaimIssue assigns an unresolved issue to agent/session until now+lease.
+// An open issue becomes acknowledged. The claim succeeds when the issue is
+// unclaimed, its lease has expired, or session already holds it (which renews
+// the lease and keeps the original claimed_at); a live claim by another
+// session is an error naming the holder. A new claim, but not a reclaim of a
+// live claim by the same session, is recorded in the issue's events.
+func (s *IssueStore) ClaimIssue(ctx context.Context, id int64, agent, session string, lease time.Duration) (*Issue, error) {
+	if session == "" {
+		return nil, fmt.Errorf("session is required to claim issue %d", id)
+	}
+	if err := validateIssueLease(lease); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	var claimed Issue
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var before Issue
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&before, id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("issue %d not found", id)
+			}
+			return err
+		}
+
+		result := tx.Model(&Issue{}).
+			Where("id = ? AND status IN ?", id, UnresolvedIssueStatuses).
+			Where("(assignee_session = '' OR assignee_session = ? OR lease_expires_at IS NULL OR lease_expires_at <= ?)", session, now).
+			Updates(map[string]any{
+				"assignee_agent":   agent,
+				"assignee_session": session,
+				"claimed_at":       gorm.Expr("CASE WHEN assignee_session = ? AND claimed_at IS NOT NULL THEN claimed_at ELSE ? END", session, now),
+				"lease_expires_at": now.Add(lease),
+				"acknowledged_at":  gorm.Expr("CASE WHEN status = 'open' THEN ? ELSE acknowledged_at END", now),
+				"status":           gorm.Expr("CASE WHEN status = 'open' THEN 'acknowledged' ELSE status END"),
+				"updated_at":       now,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+
+		if err := tx.First(&claimed, id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("issue %d not found", id)
+			}
+			return err
+		}
+		if result.RowsAffected == 0 {
+			if claimed.HasLiveClaim(now) {
+				return fmt.Errorf("issue %d is claimed by session %q (agent %s) until %s",
+					id, claimed.AssigneeSession, claimed.AssigneeAgent, claimed.LeaseExpiresAt.UTC().Format(time.RFC3339))
+			}
+			return fmt.Errorf("issue %d is %s — only open, acknowledged or reopened issues can be claimed", id, claimed.Status)
+		}
+
+		if before.AssigneeSession == session && before.HasLiveClaim(now) {
+			return nil
+		}
+		actor := issueActor(ctx, "", agent)
+		actor.Session = session
+		events := []IssueEvent{actor.event(id, IssueEventClaimed, "assignee_session", before.AssigneeSession, session)}
+		events = append(events, actor.statusEvent(id, before.Status, claimed.Status)...)
+		return recordIssueEvents(tx, now, events...)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("claim issue: %w", err)
+	}
+	return &claimed, nil
+}
+
+// RenewIssueLease extends session's live claim on an issue to now+lease.
+// An expired lease cannot be renewed; the session claims the issue again.
+// Renewals are routine and are not recorded as issue events.
+func (s *IssueStore) RenewIssueLease(ctx context.Context, id int64, session string, lease time.Duration) (*Issue, error) {
+	if err := validateIssueLease(lease); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	var renewed Issue
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&Issue{}).
+			Where("id = ? AND assignee_session = ? AND assignee_session <> '' AND lease_expires_at > ?", id, session, now).
+			Updates(map[string]any{
+				"lease_expires_at": now.Add(lease),
+				"updated_at":       now,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+
+		if err := tx.First(&renewed, id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("issue %d not found", id)
+			}
+			return err
+		}
+		if result.RowsAffected == 0 {
+			if renewed.AssigneeSession == session && session != "" {
+				return fmt.Errorf("lease on issue %d has expired — claim it again", id)
+			}
+			return fmt.Errorf("issue %d is not claimed by session %q", id, session)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("renew issue lease: %w", err)
+	}
+	return &renewed, nil
+}
+
+// ReleaseIssue drops session's claim on an issue. An acknowledged issue goes
+// back to open so the next session picks it up, as when a lease expires.
+func (s *IssueStore) ReleaseIssue(ctx context.Context, id int64, session string) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var issue Issue
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&issue, id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("issue %d not found", id)
+			}
+			return fmt.Errorf("release issue: %w", err)
+		}
+		if session == "" || issue.AssigneeSession != session {
+			return fmt.Errorf("issue %d is not claimed by session %q", id, session)
+		}
+
+		now := time.Now()
+		if err := tx.Model(&Issue{}).Where("id = ?", id).Updates(releasedIssueUpdates(now)).Error; err != nil {
+			return fmt.Errorf("release issue: %w", err)
+		}
+		actor := issueActor(ctx, "", issue.AssigneeAgent)
+		actor.Session = session
+		return recordIssueEvents(tx, now, releasedIssueEvents(actor, &issue, IssueEventReleased)...)
+	})
+}
+
+// ExpireIssueLeases releases every claim on an unresolved issue whose lease
+// ended at or before now, returning acknowledged issues to open. Resolved and
+// closed issues keep their last assignee as a record of who did the work.
+func (s *IssueStore) ExpireIssueLeases(ctx context.Context, now time.Time) (int64, error) {
+	var expired []Issue
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Select("id", "status", "assignee_agent", "assignee_session").
+			Where("assignee_session <> '' AND lease_expires_at <= ? AND status IN ?", now, UnresolvedIssueStatuses).
+			Find(&expired).Error; err != nil {
+			return err
+		}
+		if len(expired) == 0 {
+			return nil
+		}
+
+		ids := make([]int64, len(expired))
+		var events []IssueEvent
+		for i := range expired {
+			ids[i] = expired[i].ID
+			events = append(events, releasedIssueEvents(IssueActor{}, &expired[i], IssueEventLeaseExpired)...)
+		}
+		if err := tx.Model(&Issue{}).Where("id IN ?", ids).Updates(releasedIssueUpdates(now)).Error; err != nil {
+			return err
+		}
+		return recordIssueEvents(tx, now, events...)
+	})
+	if err != nil {
+		return 0, fmt.Errorf("expire issue leases: %w", err)
+	}
+	return int64(len(expired)), nil
+}
+
+// releasedIssueEvents records the end of issue's claim, and its return to
+// open when it was acknowledged, as releasedIssueUpdates applies them.
+func releasedIssueEvents(actor IssueActor, issue *Issue, eventType string) []IssueEvent {
+	events := []IssueEvent{actor.event(issue.ID, eventType, "assignee_session", issue.AssigneeSession, "")}
+	if issue.Status == "acknowledged" {
+		events = append(events, actor.statusEvent(issue.ID, "acknowledged", "open")...)
+	}
+	return events
+}
+
+// releasedIssueUpdates clears a claim and returns an acknowledged issue to open.
+func releasedIssueUpdates(now time.Time) map[string]any {
+	return map[string]any{
+		"assignee_agent":   "",
+		"assignee_session": "",
+		"claimed_at":       nil,
+		"lease_expires_at": nil,
+		"status":           gorm.Expr("CASE WHEN status = 'acknowledged' THEN 'open' ELSE status END"),
+		"updated_at":       now,
+	}
+}
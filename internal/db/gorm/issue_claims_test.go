@@ -0,0 +1,74 @@
+package gorm
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestIssueStore_Claims covers ClaimIssue, RenewIssueLease, ReleaseIssue,
+// ExpireIssueLeases and hiding issues claimed by other sessions from lists.
+func TestIssueStore_Claims(t *testing.T) {
+	db, cleanup := openTestDB(t)
+	defer cleanup()
+	defer db.Exec(`DELETE FROM issues WHERE target_project = 'test-claims'`)
+
+	is := NewIssueStore(db)
+	ctx := context.Background()
+
+	id, err := is.CreateIssue(ctx, &Issue{Title: "claim me", SourceProject: "test-claims", TargetProject: "test-claims"})
+	require.NoError(t, err)
+
+	claimed, err := is.ClaimIssue(ctx, id, "agent-a", "session-a", DefaultIssueLease)
+	require.NoError(t, err)
+	assert.Equal(t, "acknowledged", claimed.Status, "claiming accepts an open issue")
+	assert.Equal(t, "agent-a", claimed.AssigneeAgent)
+	assert.True(t, claimed.HasLiveClaim(time.Now()))
+
+	_, err = is.ClaimIssue(ctx, id, "agent-b", "session-b", DefaultIssueLease)
+	assert.ErrorContains(t, err, `claimed by session "session-a"`)
+	_, err = is.ClaimIssue(ctx, id, "agent-a", "session-a", time.Hour)
+	require.NoError(t, err, "the holder may claim again")
+	_, err = is.ClaimIssue(ctx, id, "agent-a", "session-a", 48*time.Hour)
+	assert.ErrorContains(t, err, "invalid lease")
+
+	renewed, err := is.RenewIssueLease(ctx, id, "session-a", 2*time.Hour)
+	require.NoError(t, err)
+	assert.WithinDuration(t, time.Now().Add(2*time.Hour), *renewed.LeaseExpiresAt, time.Minute)
+	assert.Equal(t, claimed.ClaimedAt.Unix(), renewed.ClaimedAt.Unix(), "renewal keeps claimed_at")
+	_, err = is.RenewIssueLease(ctx, id, "session-b", time.Hour)
+	assert.ErrorContains(t, err, "not claimed by")
+
+	visible, _, err := is.ListIssuesEx(ctx, IssueListParams{TargetProject: "test-claims", HideClaimedByOthers: true, Session: "session-b"})
+	require.NoError(t, err)
+	assert.Empty(t, visible, "claimed by another live session")
+	visible, _, err = is.ListIssuesEx(ctx, IssueListParams{TargetProject: "test-claims", HideClaimedByOthers: true, Session: "session-a"})
+	require.NoError(t, err)
+	assert.Len(t, visible, 1, "own claims stay visible")
+
+	// An expired lease can be taken over, and the sweeper returns the issue to open.
+	require.NoError(t, db.Exec(`UPDATE issues SET lease_expires_at = ? WHERE id = ?`, time.Now().Add(-time.Minute), id).Error)
+	_, err = is.RenewIssueLease(ctx, id, "session-a", time.Hour)
+	assert.ErrorContains(t, err, "has expired")
+	released, err := is.ExpireIssueLeases(ctx, time.Now())
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, released, int64(1))
+	issue, _, err := is.GetIssue(ctx, id)
+	require.NoError(t, err)
+	assert.Equal(t, "open", issue.Status)
+	assert.Empty(t, issue.AssigneeSession)
+	assert.Nil(t, issue.LeaseExpiresAt)
+
+	_, err = is.ClaimIssue(ctx, id, "agent-b", "session-b", DefaultIssueLease)
+	require.NoError(t, err)
+	assert.ErrorContains(t, is.ReleaseIssue(ctx, id, "session-a"), "not claimed by")
+	require.NoError(t, is.ReleaseIssue(ctx, id, "session-b"))
+	assert.ErrorContains(t, is.ReleaseIssue(ctx, 1<<40, "session-b"), "not found")
+
+	require.NoError(t, is.UpdateIssueStatus(ctx, id, "resolved"))
+	_, err = is.ClaimIssue(ctx, id, "agent-b", "session-b", DefaultIssueLease)
+	assert.ErrorContains(t, err, "only open, acknowledged or reopened")
+}
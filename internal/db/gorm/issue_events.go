@@ -0,0 +1,112 @@
+package gorm
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Issue event types recorded in issue_events.
+const (
+	IssueEventCreated       = "created"
+	IssueEventStatusChanged = "status_changed"
+	IssueEventFieldChanged  = "field_changed"
+	IssueEventClaimed       = "claimed"
+	IssueEventReleased      = "released"
+	IssueEventLeaseExpired  = "lease_expired"
+	IssueEventLinked        = "linked"
+	IssueEventUnlinked      = "unlinked"
+)
+
+// IssueActor identifies who changes an issue, for its event timeline. An
+// empty actor (background jobs) is recorded as-is.
+type IssueActor struct {
+	Project string
+	Agent   string
+	Session string
+}
+
+type issueActorKey struct{}
+
+// WithIssueActor returns a context whose IssueStore writes are attributed to
+// actor in issue_events.
+func WithIssueActor(ctx context.Context, actor IssueActor) context.Context {
+	return context.WithValue(ctx, issueActorKey{}, actor)
+}
+
+// IssueActorFrom returns the actor stored by WithIssueActor, or the zero actor.
+func IssueActorFrom(ctx context.Context) IssueActor {
+	actor, _ := ctx.Value(issueActorKey{}).(IssueActor)
+	return actor
+}
+
+// issueActor returns the context actor with project and agent replaced by the
+// explicit values a store method was called with, when set.
+func issueActor(ctx context.Context, project, agent string) IssueActor {
+	actor := IssueActorFrom(ctx)
+	if project != "" {
+		actor.Project = project
+	}
+	if agent != "" {
+		actor.Agent = agent
+	}
+	return actor
+}
+
+// event builds an issue event attributed to a.
+func (a IssueActor) event(issueID int64, eventType, field, oldValue, newValue string) IssueEvent {
+	return IssueEvent{
+		IssueID:      issueID,
+		EventType:    eventType,
+		Field:        field,
+		OldValue:     oldValue,
+		NewValue:     newValue,
+		ActorProject: a.Project,
+		ActorAgent:   a.Agent,
+		ActorSession: a.Session,
+	}
+}
+
+// statusEvent builds a status_changed event, or nil when the status is unchanged.
+func (a IssueActor) statusEvent(issueID int64, oldStatus, newStatus string) []IssueEvent {
+	if oldStatus == newStatus {
+		return nil
+	}
+	return []IssueEvent{a.event(issueID, IssueEventStatusChanged, "status", oldStatus, newStatus)}
+}
+
+// recordIssueEvents inserts events stamped with now inside tx.
+func recordIssueEvents(tx *gorm.DB, now time.Time, events ...IssueEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+	for i := range events {
+		events[i].CreatedAt = now
+	}
+	if err := tx.Create(&events).Error; err != nil {
+		return fmt.Errorf("record issue events: %w", err)
+	}
+	return nil
+}
+
+// ListIssueEvents returns an issue's activity timeline, oldest first.
+func (s *IssueStore) ListIssueEvents(ctx context.Context, issueID int64) ([]IssueEvent, error) {
+	var count int64
+	if err := s.db.WithContext(ctx).Model(&Issue{}).Where("id = ?", issueID).Count(&count).Error; err != nil {
+		return nil, fmt.Errorf("list issue events: %w", err)
+	}
+	if count == 0 {
+		return nil, fmt.Errorf("issue %d not found", issueID)
+	}
+
+	events := make([]IssueEvent, 0)
+	if err := s.db.WithContext(ctx).
+		Where("issue_id = ?", issueID).
+		Order("created_at, id").
+		Find(&events).Error; err != nil {
+		return nil, fmt.Errorf("list issue events: %w", err)
+	}
+	return events, nil
+}
@@ -0,0 +1,83 @@
+package gorm
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIssueActor(t *testing.T) {
+	ctx := WithIssueActor(context.Background(), IssueActor{Project: "engram", Agent: "claude-code", Session: "s1"})
+
+	assert.Equal(t, IssueActor{Project: "engram", Agent: "claude-code", Session: "s1"}, IssueActorFrom(ctx))
+	assert.Equal(t, IssueActor{}, IssueActorFrom(context.Background()))
+	assert.Equal(t, IssueActor{Project: "dashboard", Agent: "claude-code", Session: "s1"}, issueActor(ctx, "dashboard", ""),
+		"explicit project overrides the context actor")
+
+	assert.Empty(t, IssueActor{}.statusEvent(1, "open", "open"))
+	events := IssueActor{Project: "engram"}.statusEvent(1, "open", "resolved")
+	require.Len(t, events, 1)
+	assert.Equal(t, IssueEvent{IssueID: 1, EventType: IssueEventStatusChanged, Field: "status", OldValue: "open", NewValue: "resolved", ActorProject: "engram"}, events[0])
+}
+
+// TestIssueStore_Events walks an issue through creation, field edits, a
+// claim, links and status transitions and checks the recorded timeline.
+func TestIssueStore_Events(t *testing.T) {
+	db, cleanup := openTestDB(t)
+	defer cleanup()
+	defer db.Exec(`DELETE FROM issues WHERE target_project = 'test-events'`)
+
+	is := NewIssueStore(db)
+	ctx := context.Background()
+	agentCtx := WithIssueActor(ctx, IssueActor{Project: "test-events-src", Agent: "claude-code", Session: "s1"})
+	dashboardCtx := WithIssueActor(ctx, IssueActor{Project: "dashboard"})
+
+	id, err := is.CreateIssue(agentCtx, &Issue{Title: "flaky test", SourceProject: "test-events-src", TargetProject: "test-events"})
+	require.NoError(t, err)
+	other, err := is.CreateIssue(ctx, &Issue{Title: "root cause", SourceProject: "test-events", TargetProject: "test-events"})
+	require.NoError(t, err)
+
+	require.NoError(t, is.UpdateIssueFields(dashboardCtx, id, "flaky test", "", "high", "", []string{"ci"}))
+	_, err = is.ClaimIssue(agentCtx, id, "claude-code", "s1", time.Hour)
+	require.NoError(t, err)
+	_, err = is.ClaimIssue(agentCtx, id, "claude-code", "s1", time.Hour)
+	require.NoError(t, err, "reclaiming a live claim is not a new event")
+	_, err = is.LinkIssues(agentCtx, &IssueLink{SourceID: other, TargetID: id, LinkType: "blocks", CreatedBy: "test-events-src"})
+	require.NoError(t, err)
+	require.NoError(t, is.UnlinkIssues(dashboardCtx, id, other, "blocks"))
+	require.NoError(t, is.ReleaseIssue(agentCtx, id, "s1"))
+	require.NoError(t, is.UpdateIssueStatus(agentCtx, id, "resolved"))
+	require.NoError(t, is.ReopenIssue(ctx, id, "still flaky", "test-events-src", "codex"))
+	require.NoError(t, is.CloseIssue(dashboardCtx, id, "dashboard"))
+
+	events, err := is.ListIssueEvents(ctx, id)
+	require.NoError(t, err)
+	type change struct{ eventType, field, old, new, project string }
+	got := make([]change, len(events))
+	for i, e := range events {
+		got[i] = change{e.EventType, e.Field, e.OldValue, e.NewValue, e.ActorProject}
+	}
+	assert.Equal(t, []change{
+		{IssueEventCreated, "status", "", "open", "test-events-src"},
+		{IssueEventFieldChanged, "priority", "medium", "high", "dashboard"},
+		{IssueEventFieldChanged, "labels", "[]", `["ci"]`, "dashboard"},
+		{IssueEventClaimed, "assignee_session", "", "s1", "test-events-src"},
+		{IssueEventStatusChanged, "status", "open", "acknowledged", "test-events-src"},
+		{IssueEventLinked, "link", "", fmt.Sprintf("blocked_by #%d", other), "test-events-src"},
+		{IssueEventUnlinked, "link", fmt.Sprintf("blocked_by #%d", other), "", "dashboard"},
+		{IssueEventReleased, "assignee_session", "s1", "", "test-events-src"},
+		{IssueEventStatusChanged, "status", "acknowledged", "open", "test-events-src"},
+		{IssueEventStatusChanged, "status", "open", "resolved", "test-events-src"},
+		{IssueEventStatusChanged, "status", "resolved", "reopened", "test-events-src"},
+		{IssueEventStatusChanged, "status", "reopened", "closed", "dashboard"},
+	}, got)
+	assert.Equal(t, "codex", events[10].ActorAgent)
+	assert.Equal(t, "s1", events[3].ActorSession)
+
+	_, err = is.ListIssueEvents(ctx, 1<<40)
+	assert.ErrorContains(t, err, "not found")
+}
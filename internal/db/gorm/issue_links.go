@@ -0,0 +1,234 @@
+package gorm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// validIssueLinkTypes is the allowed set of issue link types.
+var validIssueLinkTypes = map[string]bool{"blocks": true, "duplicates": true, "relates": true, "parent": true}
+
+// UnresolvedIssueStatuses are the statuses of issues that still need work.
+// An issue blocks its targets only while it is in one of them.
+var UnresolvedIssueStatuses = []string{"open", "acknowledged", "reopened"}
+
+// issueLinkRelations names a link as seen from its source and from its target.
+var issueLinkRelations = map[string][2]string{
+	"blocks":     {"blocks", "blocked_by"},
+	"duplicates": {"duplicates", "duplicated_by"},
+	"relates":    {"relates", "relates"},
+	"parent":     {"parent_of", "child_of"},
+}
+
+// IssueLinkView is a link as seen from one of its issues: Relation reads
+// "this issue <relation> IssueID" (blocks, blocked_by, duplicates,
+// duplicated_by, relates, parent_of or child_of), and the remaining fields
+// describe the issue at the other end.
+type IssueLinkView struct {
+	CreatedAt     time.Time `json:"created_at"`
+	LinkType      string    `json:"link_type"`
+	Relation      string    `json:"relation"`
+	Title         string    `json:"title"`
+	Status        string    `json:"status"`
+	TargetProject string    `json:"target_project"`
+	CreatedBy     string    `json:"created_by"`
+	ID            int64     `json:"id"`
+	IssueID       int64     `json:"issue_id"`
+}
+
+// LinkIssues records "link.SourceID <link.LinkType> link.TargetID". Both issues
+// must exist. Between two issues there is at most one link of each type, in
+// either direction; an issue has at most one parent; and blocks and parent
+// links may not form a cycle. The link is recorded in both issues' events.
+func (s *IssueStore) LinkIssues(ctx context.Context, link *IssueLink) (*IssueLink, error) {
+	if !validIssueLinkTypes[link.LinkType] {
+		return nil, fmt.Errorf("invalid link type %q: must be one of blocks, duplicates, relates, parent", link.LinkType)
+	}
+	if link.SourceID == link.TargetID {
+		return nil, fmt.Errorf("issue %d cannot be linked to itself", link.SourceID)
+	}
+
+	created := IssueLink{
+		SourceID:  link.SourceID,
+		TargetID:  link.TargetID,
+		LinkType:  link.LinkType,
+		CreatedBy: link.CreatedBy,
+		CreatedAt: time.Now(),
+	}
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, id := range []int64{created.SourceID, created.TargetID} {
+			var count int64
+			if err := tx.Model(&Issue{}).Where("id = ?", id).Count(&count).Error; err != nil {
+				return err
+			}
+			if count == 0 {
+				return fmt.Errorf("issue %d not found", id)
+			}
+		}
+
+		var existing IssueLink
+		err := tx.Where("link_type = ? AND ((source_id = ? AND target_id = ?) OR (source_id = ? AND target_id = ?))",
+			created.LinkType, created.SourceID, created.TargetID, created.TargetID, created.SourceID).
+			First(&existing).Error
+		if err == nil {
+			return fmt.Errorf("issues %d and %d are already linked (%d %s %d)",
+				created.SourceID, created.TargetID, existing.SourceID, existing.LinkType, existing.TargetID)
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		if created.LinkType == "parent" {
+			var parent IssueLink
+			err := tx.Where("link_type = 'parent' AND target_id = ?", created.TargetID).First(&parent).Error
+			if err == nil {
+				return fmt.Errorf("issue %d already has parent %d", created.TargetID, parent.SourceID)
+			}
+			if !errors.Is(err, gorm.ErrRecordNotFound) {
+				return err
+			}
+		}
+
+		if created.LinkType == "blocks" || created.LinkType == "parent" {
+			// The new link closes a cycle if the source is already reachable
+			// from the target along links of the same type.
+			var cycle bool
+			if err := tx.Raw(`
+				WITH RECURSIVE reach(id) AS (
+					SELECT target_id FROM issue_links WHERE source_id = @target AND link_type = @type
+					UNION
+					SELECT l.target_id FROM issue_links l JOIN reach r ON l.source_id = r.id
+					WHERE l.link_type = @type
+				)
+				SELECT EXISTS (SELECT 1 FROM reach WHERE id = @source)`,
+				map[string]any{"source": created.SourceID, "target": created.TargetID, "type": created.LinkType}).
+				Scan(&cycle).Error; err != nil {
+				return err
+			}
+			if cycle {
+				return fmt.Errorf("linking issue %d %s %d would create a cycle", created.SourceID, created.LinkType, created.TargetID)
+			}
+		}
+
+		if err := tx.Create(&created).Error; err != nil {
+			return err
+		}
+		return recordIssueEvents(tx, created.CreatedAt, linkIssueEvents(issueActor(ctx, created.CreatedBy, ""), IssueEventLinked, &created)...)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("link issues: %w", err)
+	}
+	return &created, nil
+}
+
+// UnlinkIssues removes the link of linkType between two issues, whichever of
+// them is its source.
+func (s *IssueStore) UnlinkIssues(ctx context.Context, issueID, linkedID int64, linkType string) error {
+	if !validIssueLinkTypes[linkType] {
+		return fmt.Errorf("invalid link type %q: must be one of blocks, duplicates, relates, parent", linkType)
+	}
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var removed []IssueLink
+		if err := tx.Raw(`
+			DELETE FROM issue_links
+			WHERE link_type = ? AND ((source_id = ? AND target_id = ?) OR (source_id = ? AND target_id = ?))
+			RETURNING id, source_id, target_id, link_type, created_by, created_at`,
+			linkType, issueID, linkedID, linkedID, issueID).
+			Scan(&removed).Error; err != nil {
+			return fmt.Errorf("unlink issues: %w", err)
+		}
+		if len(removed) == 0 {
+			return fmt.Errorf("%s link between issues %d and %d not found", linkType, issueID, linkedID)
+		}
+		actor := issueActor(ctx, "", "")
+		var events []IssueEvent
+		for i := range removed {
+			events = append(events, linkIssueEvents(actor, IssueEventUnlinked, &removed[i])...)
+		}
+		return recordIssueEvents(tx, time.Now(), events...)
+	})
+}
+
+// linkIssueEvents describes a link change on both of its issues, each
+// naming the other end from its own point of view ("blocks #7" on the source,
+// "blocked_by #3" on the target).
+func linkIssueEvents(actor IssueActor, eventType string, link *IssueLink) []IssueEvent {
+	relations := issueLinkRelations[link.LinkType]
+	value := func(relation string, other int64) string { return fmt.Sprintf("%s #%d", relation, other) }
+	sourceValue, targetValue := value(relations[0], link.TargetID), value(relations[1], link.SourceID)
+	if eventType == IssueEventUnlinked {
+		return []IssueEvent{
+			actor.event(link.SourceID, eventType, "link", sourceValue, ""),
+			actor.event(link.TargetID, eventType, "link", targetValue, ""),
+		}
+	}
+	return []IssueEvent{
+		actor.event(link.SourceID, eventType, "link", "", sourceValue),
+		actor.event(link.TargetID, eventType, "link", "", targetValue),
+	}
+}
+
+// ListIssueLinks returns every link of an issue, in either direction, ordered
+// by link type and then oldest first.
+func (s *IssueStore) ListIssueLinks(ctx context.Context, issueID int64) ([]IssueLinkView, error) {
+	var rows []struct {
+		IssueLinkView
+		Outgoing bool
+	}
+	err := s.db.WithContext(ctx).Raw(`
+		SELECT l.id, l.link_type, l.created_by, l.created_at,
+		       l.source_id = @id AS outgoing,
+		       i.id AS issue_id, i.title, i.status, i.target_project
+		FROM issue_links l
+		JOIN issues i ON i.id = CASE WHEN l.source_id = @id THEN l.target_id ELSE l.source_id END
+		WHERE l.source_id = @id OR l.target_id = @id
+		ORDER BY l.link_type, l.created_at, l.id`,
+		map[string]any{"id": issueID}).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("list issue links: %w", err)
+	}
+
+	links := make([]IssueLinkView, len(rows))
+	for i, row := range rows {
+		links[i] = row.IssueLinkView
+		if row.Outgoing {
+			links[i].Relation = issueLinkRelations[row.LinkType][0]
+		} else {
+			links[i].Relation = issueLinkRelations[row.LinkType][1]
+		}
+	}
+	return links, nil
+}
+
+// BlockersOf returns, for each of ids that is blocked, the IDs of the
+// unresolved issues blocking it in ascending order. Blockers that are
+// resolved, closed or rejected no longer count.
+func (s *IssueStore) BlockersOf(ctx context.Context, ids []int64) (map[int64][]int64, error) {
+	blockers := make(map[int64][]int64)
+	if len(ids) == 0 {
+		return blockers, nil
+	}
+	var rows []struct {
+		TargetID int64
+		SourceID int64
+	}
+	err := s.db.WithContext(ctx).
+		Table("issue_links l").
+		Select("l.target_id, l.source_id").
+		Joins("JOIN issues b ON b.id = l.source_id").
+		Where("l.link_type = 'blocks' AND l.target_id IN ? AND b.status IN ?", ids, UnresolvedIssueStatuses).
+		Order("l.target_id, l.source_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("list issue blockers: %w", err)
+	}
+	for _, row := range rows {
+		blockers[row.TargetID] = append(blockers[row.TargetID], row.SourceID)
+	}
+	return blockers, nil
+}
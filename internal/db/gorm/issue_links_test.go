@@ -0,0 +1,71 @@
+package gorm
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestIssueStore_Links covers LinkIssues validation (self links, duplicates,
+// single parent, cycles), ListIssueLinks relations, BlockersOf and UnlinkIssues.
+func TestIssueStore_Links(t *testing.T) {
+	db, cleanup := openTestDB(t)
+	defer cleanup()
+	defer db.Exec(`DELETE FROM issues WHERE target_project IN ('test-links-a', 'test-links-b')`)
+
+	is := NewIssueStore(db)
+	ctx := context.Background()
+
+	create := func(title, project, status string) int64 {
+		id, err := is.CreateIssue(ctx, &Issue{Title: title, Status: status, SourceProject: project, TargetProject: project})
+		require.NoError(t, err)
+		return id
+	}
+	epic := create("epic", "test-links-a", "open")
+	task := create("task", "test-links-a", "open")
+	blocker := create("blocker", "test-links-b", "acknowledged")
+	done := create("done", "test-links-b", "resolved")
+
+	link := func(source, target int64, linkType string) error {
+		_, err := is.LinkIssues(ctx, &IssueLink{SourceID: source, TargetID: target, LinkType: linkType, CreatedBy: "test-links-a"})
+		return err
+	}
+	require.NoError(t, link(epic, task, "parent"))
+	require.NoError(t, link(blocker, task, "blocks"))
+	require.NoError(t, link(done, task, "blocks"))
+	require.NoError(t, link(task, done, "relates"))
+
+	assert.ErrorContains(t, link(task, task, "blocks"), "itself")
+	assert.ErrorContains(t, link(task, epic, "follows"), "invalid link type")
+	assert.ErrorContains(t, link(task, 1<<40, "relates"), "not found")
+	assert.ErrorContains(t, link(done, task, "relates"), "already linked")
+	assert.ErrorContains(t, link(blocker, task, "parent"), "already has parent")
+	assert.ErrorContains(t, link(task, blocker, "blocks"), "cycle")
+
+	links, err := is.ListIssueLinks(ctx, task)
+	require.NoError(t, err)
+	relations := make(map[int64][]string)
+	for _, l := range links {
+		relations[l.IssueID] = append(relations[l.IssueID], l.Relation)
+	}
+	assert.Equal(t, []string{"child_of"}, relations[epic])
+	assert.Equal(t, []string{"blocked_by"}, relations[blocker])
+	assert.ElementsMatch(t, []string{"blocked_by", "relates"}, relations[done])
+
+	blockers, err := is.BlockersOf(ctx, []int64{epic, task})
+	require.NoError(t, err)
+	assert.Equal(t, map[int64][]int64{task: {blocker}}, blockers, "resolved blockers no longer block")
+
+	require.NoError(t, is.UnlinkIssues(ctx, task, blocker, "blocks"), "unlink works from either end")
+	assert.ErrorContains(t, is.UnlinkIssues(ctx, task, blocker, "blocks"), "not found")
+	blockers, err = is.BlockersOf(ctx, []int64{task})
+	require.NoError(t, err)
+	assert.Empty(t, blockers)
+
+	require.NoError(t, is.DeleteIssue(ctx, epic))
+	links, err = is.ListIssueLinks(ctx, task)
+	require.NoError(t, err)
+	assert.Len(t, links, 2, "links of a deleted issue are removed")
+}
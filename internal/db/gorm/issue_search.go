@@ -0,0 +1,160 @@
+package gorm
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// issueSearchTSQuery parses a ListIssuesEx text query under both the english
+// and simple dictionaries, matching the dual-dictionary issue vectors.
+const issueSearchTSQuery = "(websearch_to_tsquery('english', ?) || websearch_to_tsquery('simple', ?))"
+
+// maxIssueSavedQueryName caps saved query names.
+const maxIssueSavedQueryName = 100
+
+// IssueFilter is the stored form of the IssueListParams filters, kept by saved
+// queries as JSONB. Empty fields do not filter.
+type IssueFilter struct {
+	CreatedAfter  *time.Time `json:"created_after,omitempty"`
+	CreatedBefore *time.Time `json:"created_before,omitempty"`
+	UpdatedAfter  *time.Time `json:"updated_after,omitempty"`
+	UpdatedBefore *time.Time `json:"updated_before,omitempty"`
+	TargetProject string     `json:"target_project,omitempty"`
+	SourceProject string     `json:"source_project,omitempty"`
+	Type          string     `json:"type,omitempty"`
+	Query         string     `json:"query,omitempty"`
+	Statuses      []string   `json:"statuses,omitempty"`
+	Labels        []string   `json:"labels,omitempty"`
+	ExcludeLabels []string   `json:"exclude_labels,omitempty"`
+	Priorities    []string   `json:"priorities,omitempty"`
+}
+
+// Scan implements sql.Scanner for IssueFilter.
+func (f *IssueFilter) Scan(src any) error {
+	var data []byte
+	switch v := src.(type) {
+	case nil:
+		*f = IssueFilter{}
+		return nil
+	case string:
+		data = []byte(v)
+	case []byte:
+		data = v
+	default:
+		return fmt.Errorf("IssueFilter: unsupported type %T", src)
+	}
+	*f = IssueFilter{}
+	if len(data) == 0 {
+		return nil
+	}
+	return json.Unmarshal(data, f)
+}
+
+// Value implements driver.Valuer for IssueFilter.
+func (f IssueFilter) Value() (driver.Value, error) {
+	return json.Marshal(f)
+}
+
+// ApplyFilter fills the filters of p that are still empty from f, so explicit
+// request parameters take precedence over a saved query.
+func (p *IssueListParams) ApplyFilter(f IssueFilter) {
+	setString := func(dst *string, v string) {
+		if *dst == "" {
+			*dst = v
+		}
+	}
+	setSlice := func(dst *[]string, v []string) {
+		if len(*dst) == 0 {
+			*dst = v
+		}
+	}
+	setTime := func(dst **time.Time, v *time.Time) {
+		if *dst == nil {
+			*dst = v
+		}
+	}
+	setString(&p.TargetProject, f.TargetProject)
+	setString(&p.SourceProject, f.SourceProject)
+	setString(&p.Type, f.Type)
+	setString(&p.Query, f.Query)
+	setSlice(&p.Statuses, f.Statuses)
+	setSlice(&p.Labels, f.Labels)
+	setSlice(&p.ExcludeLabels, f.ExcludeLabels)
+	setSlice(&p.Priorities, f.Priorities)
+	setTime(&p.CreatedAfter, f.CreatedAfter)
+	setTime(&p.CreatedBefore, f.CreatedBefore)
+	setTime(&p.UpdatedAfter, f.UpdatedAfter)
+	setTime(&p.UpdatedBefore, f.UpdatedBefore)
+}
+
+// SaveIssueQuery stores a named filter, replacing the filter of an existing
+// query with the same name.
+func (s *IssueStore) SaveIssueQuery(ctx context.Context, q *IssueSavedQuery) (*IssueSavedQuery, error) {
+	name := strings.TrimSpace(q.Name)
+	if name == "" {
+		return nil, fmt.Errorf("saved query name is required")
+	}
+	if len(name) > maxIssueSavedQueryName {
+		return nil, fmt.Errorf("saved query name is longer than %d characters", maxIssueSavedQueryName)
+	}
+
+	now := time.Now()
+	saved := IssueSavedQuery{
+		Name:      name,
+		Filter:    q.Filter,
+		CreatedBy: q.CreatedBy,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	err := s.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "name"}},
+			DoUpdates: clause.AssignmentColumns([]string{"filter", "updated_at"}),
+		}).
+		Create(&saved).Error
+	if err != nil {
+		return nil, fmt.Errorf("save issue query: %w", err)
+	}
+	return s.GetIssueQuery(ctx, name)
+}
+
+// GetIssueQuery returns the saved query with the given name.
+func (s *IssueStore) GetIssueQuery(ctx context.Context, name string) (*IssueSavedQuery, error) {
+	var q IssueSavedQuery
+	if err := s.db.WithContext(ctx).Where("name = ?", strings.TrimSpace(name)).First(&q).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("saved query %q not found", name)
+		}
+		return nil, fmt.Errorf("get issue query: %w", err)
+	}
+	return &q, nil
+}
+
+// ListIssueQueries returns all saved queries ordered by name.
+func (s *IssueStore) ListIssueQueries(ctx context.Context) ([]IssueSavedQuery, error) {
+	queries := make([]IssueSavedQuery, 0)
+	if err := s.db.WithContext(ctx).Order("name").Find(&queries).Error; err != nil {
+		return nil, fmt.Errorf("list issue queries: %w", err)
+	}
+	return queries, nil
+}
+
+// DeleteIssueQuery removes the saved query with the given name.
+func (s *IssueStore) DeleteIssueQuery(ctx context.Context, name string) error {
+	result := s.db.WithContext(ctx).Where("name = ?", strings.TrimSpace(name)).Delete(&IssueSavedQuery{})
+	if result.Error != nil {
+		return fmt.Errorf("delete issue query: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("saved query %q not found", name)
+	}
+	return nil
+}
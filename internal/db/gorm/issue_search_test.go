@@ -0,0 +1,135 @@
+package gorm
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestIssueListParams_ApplyFilter checks that a saved filter only fills the
+// parameters the request left empty.
+func TestIssueListParams_ApplyFilter(t *testing.T) {
+	since := time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC)
+	params := IssueListParams{Query: "deadlock", Labels: []string{"bug"}}
+	params.ApplyFilter(IssueFilter{
+		Query:         "timeout",
+		Labels:        []string{"feature"},
+		ExcludeLabels: []string{"wontfix"},
+		Priorities:    []string{"high"},
+		TargetProject: "engram",
+		CreatedAfter:  &since,
+	})
+
+	assert.Equal(t, "deadlock", params.Query)
+	assert.Equal(t, []string{"bug"}, params.Labels)
+	assert.Equal(t, []string{"wontfix"}, params.ExcludeLabels)
+	assert.Equal(t, []string{"high"}, params.Priorities)
+	assert.Equal(t, "engram", params.TargetProject)
+	assert.Equal(t, &since, params.CreatedAfter)
+	assert.Nil(t, params.UpdatedBefore)
+}
+
+// TestIssueFilter_ScanValue round-trips a filter through its JSONB form.
+func TestIssueFilter_ScanValue(t *testing.T) {
+	since := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	in := IssueFilter{Query: "lease", Statuses: []string{"open"}, UpdatedAfter: &since}
+
+	v, err := in.Value()
+	require.NoError(t, err)
+
+	var out IssueFilter
+	require.NoError(t, out.Scan(v))
+	assert.Equal(t, in.Query, out.Query)
+	assert.Equal(t, in.Statuses, out.Statuses)
+	require.NotNil(t, out.UpdatedAfter)
+	assert.True(t, since.Equal(*out.UpdatedAfter))
+
+	require.NoError(t, out.Scan(nil))
+	assert.Equal(t, IssueFilter{}, out)
+	assert.Error(t, out.Scan(42))
+}
+
+// TestIssueStore_Search covers full-text search over titles, bodies and
+// comments, the label, priority and date filters, and saved queries.
+func TestIssueStore_Search(t *testing.T) {
+	db, cleanup := openTestDB(t)
+	defer cleanup()
+	defer db.Exec(`DELETE FROM issues WHERE target_project = 'test-search'`)
+	defer db.Exec(`DELETE FROM issue_saved_queries WHERE name LIKE 'test-search-%'`)
+
+	is := NewIssueStore(db)
+	ctx := context.Background()
+
+	create := func(title, body, priority string, labels ...string) int64 {
+		id, err := is.CreateIssue(ctx, &Issue{
+			Title: title, Body: body, Priority: priority, Labels: labels, Status: "open",
+			SourceProject: "test-search", TargetProject: "test-search",
+		})
+		require.NoError(t, err)
+		return id
+	}
+	inTitle := create("Deadlock in scheduler", "workers stop", "high", "bug", "scheduler")
+	inBody := create("Workers stop", "a deadlock between two locks", "low", "bug")
+	inComment := create("Slow startup", "takes a minute", "medium", "perf")
+	unrelated := create("Add dark mode", "dashboard theme", "low", "feature", "wontfix")
+	_, err := is.AddComment(ctx, inComment, &IssueComment{AuthorProject: "test-search", Body: "looks like a deadlock on boot"})
+	require.NoError(t, err)
+
+	ids := func(params IssueListParams) []int64 {
+		params.TargetProject = "test-search"
+		issues, _, err := is.ListIssuesEx(ctx, params)
+		require.NoError(t, err)
+		out := make([]int64, len(issues))
+		for i, issue := range issues {
+			out[i] = issue.ID
+		}
+		return out
+	}
+
+	found := ids(IssueListParams{Query: "deadlocks"})
+	assert.ElementsMatch(t, []int64{inTitle, inBody, inComment}, found, "stemmed match in title, body and comments")
+	assert.Equal(t, inTitle, found[0], "title matches rank first")
+
+	assert.ElementsMatch(t, []int64{inTitle, inBody}, ids(IssueListParams{Labels: []string{"bug"}}))
+	assert.Equal(t, []int64{inTitle}, ids(IssueListParams{Labels: []string{"bug", "scheduler"}}))
+	assert.ElementsMatch(t, []int64{inTitle, inBody, inComment}, ids(IssueListParams{ExcludeLabels: []string{"wontfix", "nothing"}}))
+	assert.ElementsMatch(t, []int64{inBody, unrelated}, ids(IssueListParams{Priorities: []string{"low"}}))
+
+	future := time.Now().Add(time.Hour)
+	assert.Empty(t, ids(IssueListParams{CreatedAfter: &future}))
+	assert.Len(t, ids(IssueListParams{CreatedBefore: &future, UpdatedBefore: &future}), 4)
+
+	saved, err := is.SaveIssueQuery(ctx, &IssueSavedQuery{
+		Name:      "test-search-bugs",
+		CreatedBy: "operator",
+		Filter:    IssueFilter{Labels: []string{"bug"}, Priorities: []string{"high"}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"bug"}, saved.Filter.Labels)
+
+	saved, err = is.SaveIssueQuery(ctx, &IssueSavedQuery{Name: " test-search-bugs ", Filter: IssueFilter{Labels: []string{"bug"}}})
+	require.NoError(t, err, "saving an existing name replaces its filter")
+	assert.Empty(t, saved.Filter.Priorities)
+	assert.Equal(t, "operator", saved.CreatedBy)
+
+	_, err = is.SaveIssueQuery(ctx, &IssueSavedQuery{Name: "  "})
+	assert.ErrorContains(t, err, "name is required")
+
+	got, err := is.GetIssueQuery(ctx, "test-search-bugs")
+	require.NoError(t, err)
+	var params IssueListParams
+	params.ApplyFilter(got.Filter)
+	assert.ElementsMatch(t, []int64{inTitle, inBody}, ids(params))
+
+	queries, err := is.ListIssueQueries(ctx)
+	require.NoError(t, err)
+	assert.NotEmpty(t, queries)
+
+	require.NoError(t, is.DeleteIssueQuery(ctx, "test-search-bugs"))
+	assert.ErrorContains(t, is.DeleteIssueQuery(ctx, "test-search-bugs"), "not found")
+	_, err = is.GetIssueQuery(ctx, "test-search-bugs")
+	assert.ErrorContains(t, err, "not found")
+}
@@ -0,0 +1,189 @@
+package gorm
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// IssueEventEscalated records an SLA escalation in issue_events.
+const IssueEventEscalated = "escalated"
+
+// SLA breach kinds reported in IssueEscalation.Breach.
+const (
+	IssueSLABreachAcknowledge = "acknowledge"
+	IssueSLABreachResolve     = "resolve"
+)
+
+// IssueSystemProject and IssueSLAAgent author the comments and events of the
+// SLA escalation job.
+const (
+	IssueSystemProject = "system"
+	IssueSLAAgent      = "sla-escalation"
+)
+
+// IssueSLA is the service level for one priority. Acknowledge is how long an
+// issue may stay open or reopened without being acknowledged; Resolve is how
+// long it may stay unresolved. A zero duration disables that check.
+//
+// The SLA clock starts at the latest of created_at, reopened_at and
+// escalated_at, so each escalation gives the issue a fresh window at its new
+// priority.
+type IssueSLA struct {
+	Priority    string
+	Acknowledge time.Duration
+	Resolve     time.Duration
+}
+
+// IssueEscalation describes one issue raised by EscalateOverdueIssues.
+type IssueEscalation struct {
+	Issue       Issue
+	OldPriority string
+	Breach      string
+	SLA         time.Duration
+	CommentID   int64
+}
+
+// nextIssuePriority maps each priority to the one an escalation raises it to.
+// Critical issues stay critical but are still escalated (commented on and
+// notified) each time their SLA runs out again.
+var nextIssuePriority = map[string]string{
+	"low":      "medium",
+	"medium":   "high",
+	"high":     "critical",
+	"critical": "critical",
+}
+
+// issueSLAClock is the SQL start of an issue's SLA window.
+const issueSLAClock = "GREATEST(created_at, COALESCE(reopened_at, created_at), COALESCE(escalated_at, created_at))"
+
+// IsStale reports whether the SLA escalation job has escalated the issue and
+// nobody has touched it since. Escalations leave updated_at alone, so any
+// later comment, status change or edit clears the flag.
+func (i *Issue) IsStale() bool {
+	return i.EscalatedAt != nil && i.EscalatedAt.After(i.UpdatedAt)
+}
+
+// EscalateOverdueIssues raises the priority of every unresolved issue that
+// has breached its SLA as of now, posts a system comment explaining the
+// breach and records priority and escalated events. Issues locked by another
+// transaction are skipped until the next sweep.
+func (s *IssueStore) EscalateOverdueIssues(ctx context.Context, slas []IssueSLA, now time.Time) ([]IssueEscalation, error) {
+	escalations := make([]IssueEscalation, 0)
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, sla := range slas {
+			for _, check := range []struct {
+				breach   string
+				window   time.Duration
+				statuses []string
+			}{
+				{IssueSLABreachAcknowledge, sla.Acknowledge, []string{"open", "reopened"}},
+				{IssueSLABreachResolve, sla.Resolve, UnresolvedIssueStatuses},
+			} {
+				if check.window <= 0 {
+					continue
+				}
+				var overdue []Issue
+				if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+					Where("priority = ? AND status IN ? AND "+issueSLAClock+" <= ?", sla.Priority, check.statuses, now.Add(-check.window)).
+					Order("id").
+					Find(&overdue).Error; err != nil {
+					return err
+				}
+				for i := range overdue {
+					escalation, err := escalateIssue(tx, &overdue[i], check.breach, check.window, now)
+					if err != nil {
+						return err
+					}
+					escalations = append(escalations, escalation)
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("escalate overdue issues: %w", err)
+	}
+	return escalations, nil
+}
+
+// escalateIssue raises one locked issue's priority and records the breach.
+// updated_at is left alone so that staleness keeps measuring real activity.
+func escalateIssue(tx *gorm.DB, issue *Issue, breach string, window time.Duration, now time.Time) (IssueEscalation, error) {
+	oldPriority := issue.Priority
+	newPriority := nextIssuePriority[oldPriority]
+	if newPriority == "" {
+		newPriority = oldPriority
+	}
+	if err := tx.Model(&Issue{}).Where("id = ?", issue.ID).Updates(map[string]any{
+		"priority":         newPriority,
+		"escalated_at":     now,
+		"escalation_count": gorm.Expr("escalation_count + 1"),
+	}).Error; err != nil {
+		return IssueEscalation{}, err
+	}
+
+	comment := IssueComment{
+		IssueID:       issue.ID,
+		AuthorProject: IssueSystemProject,
+		AuthorAgent:   IssueSLAAgent,
+		Body:          issueEscalationComment(oldPriority, newPriority, breach, window),
+		CreatedAt:     now,
+	}
+	if err := tx.Create(&comment).Error; err != nil {
+		return IssueEscalation{}, err
+	}
+
+	actor := IssueActor{Project: IssueSystemProject, Agent: IssueSLAAgent}
+	events := []IssueEvent{actor.event(issue.ID, IssueEventEscalated, "sla", "", breach+" "+issueSLAWindow(window))}
+	if newPriority != oldPriority {
+		events = append(events, actor.event(issue.ID, IssueEventFieldChanged, "priority", oldPriority, newPriority))
+	}
+	if err := recordIssueEvents(tx, now, events...); err != nil {
+		return IssueEscalation{}, err
+	}
+
+	issue.Priority = newPriority
+	issue.EscalatedAt = &now
+	issue.EscalationCount++
+	return IssueEscalation{
+		Issue:       *issue,
+		OldPriority: oldPriority,
+		Breach:      breach,
+		SLA:         window,
+		CommentID:   comment.ID,
+	}, nil
+}
+
+// issueEscalationComment is the body of the system comment posted on escalation.
+func issueEscalationComment(oldPriority, newPriority, breach string, window time.Duration) string {
+	what := "resolved"
+	if breach == IssueSLABreachAcknowledge {
+		what = "acknowledged"
+	}
+	body := fmt.Sprintf("SLA breached: this %s issue was not %s within %s.", oldPriority, what, issueSLAWindow(window))
+	if newPriority != oldPriority {
+		return body + fmt.Sprintf(" Priority raised from %s to %s.", oldPriority, newPriority)
+	}
+	return body + " It is already critical: " + what[:len(what)-1] + " it or comment with what blocks it."
+}
+
+// issueSLAWindow formats an SLA window as whole days ("3d") when it is one,
+// else as a Go duration without zero trailing units ("90m" → "1h30m").
+func issueSLAWindow(d time.Duration) string {
+	if d >= 24*time.Hour && d%(24*time.Hour) == 0 {
+		return fmt.Sprintf("%dd", d/(24*time.Hour))
+	}
+	out := d.String()
+	if strings.HasSuffix(out, "m0s") {
+		out = strings.TrimSuffix(out, "0s")
+	}
+	if strings.HasSuffix(out, "h0m") {
+		out = strings.TrimSuffix(out, "0m")
+	}
+	return out
+}
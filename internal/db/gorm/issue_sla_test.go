@@ -0,0 +1,33 @@
+package gorm
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIssueSLAWindow(t *testing.T) {
+	assert.Equal(t, "3d", issueSLAWindow(72*time.Hour))
+	assert.Equal(t, "1h", issueSLAWindow(time.Hour))
+	assert.Equal(t, "1h30m", issueSLAWindow(90*time.Minute))
+	assert.Equal(t, "30m", issueSLAWindow(30*time.Minute))
+	assert.Equal(t, "36h", issueSLAWindow(36*time.Hour))
+}
+
+func TestIssueEscalationComment(t *testing.T) {
+	assert.Equal(t,
+		"SLA breached: this high issue was not resolved within 3d. Priority raised from high to critical.",
+		issueEscalationComment("high", "critical", IssueSLABreachResolve, 72*time.Hour))
+	assert.Equal(t,
+		"SLA breached: this critical issue was not acknowledged within 1h. It is already critical: acknowledge it or comment with what blocks it.",
+		issueEscalationComment("critical", "critical", IssueSLABreachAcknowledge, time.Hour))
+}
+
+func TestIssue_IsStale(t *testing.T) {
+	now := time.Now()
+	before, after := now.Add(-time.Minute), now.Add(time.Minute)
+	assert.False(t, (&Issue{UpdatedAt: now}).IsStale())
+	assert.True(t, (&Issue{UpdatedAt: now, EscalatedAt: &after}).IsStale())
+	assert.False(t, (&Issue{UpdatedAt: now, EscalatedAt: &before}).IsStale(), "activity after the escalation clears it")
+}
@@ -2,11 +2,14 @@ package gorm
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"regexp"
 	"time"
 
+	"github.com/lib/pq"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // projectBareName strips the "_<hash>" suffix from a canonical hashed project ID
@@ -35,9 +38,14 @@ func (s *IssueStore) ResolveProject(ctx context.Context, projectID string) strin
 }
 
 // IssueWithCount extends Issue with a computed comment count for list views.
+// SearchRank is set when the list was filtered by a text query: the better of
+// the issue's own rank and its best-matching comment's. StaleDays is the number
+// of whole days since the issue was last updated.
 type IssueWithCount struct {
 	Issue
-	CommentCount int64 `gorm:"column:comment_count" json:"comment_count"`
+	CommentCount int64   `gorm:"column:comment_count" json:"comment_count"`
+	SearchRank   float64 `gorm:"column:search_rank" json:"search_rank,omitempty"`
+	StaleDays    int     `gorm:"column:stale_days" json:"stale_days"`
 }
 
 // validIssueTypes is the allowed set of issue type values.
@@ -83,7 +91,17 @@ func (s *IssueStore) CreateIssue(ctx context.Context, issue *Issue) (int64, erro
 		return 0, fmt.Errorf("invalid type %q: must be one of bug, feature, improvement, task", created.Type)
 	}
 
-	if err := s.db.WithContext(ctx).Create(&created).Error; err != nil {
+	actor := issueActor(ctx, created.SourceProject, created.SourceAgent)
+	if actor.Session == "" {
+		actor.Session = created.CreatedBySession
+	}
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&created).Error; err != nil {
+			return err
+		}
+		return recordIssueEvents(tx, now, actor.event(created.ID, IssueEventCreated, "status", "", created.Status))
+	})
+	if err != nil {
 		return 0, fmt.Errorf("create issue: %w", err)
 	}
 	return created.ID, nil
@@ -96,8 +114,25 @@ type IssueListParams struct {
 	Statuses      []string
 	ResolvedSince *time.Time
 	Type          string
-	Limit         int
-	Offset        int
+	// Query is a websearch_to_tsquery string matched against issue titles,
+	// bodies and comments; results are then ordered by rank.
+	Query string
+	// Labels must all be present; ExcludeLabels must all be absent.
+	Labels        []string
+	ExcludeLabels []string
+	Priorities    []string
+	// Created*/Updated* bound created_at and updated_at: After is inclusive,
+	// Before exclusive.
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+	UpdatedAfter  *time.Time
+	UpdatedBefore *time.Time
+	// HideClaimedByOthers omits issues under a live claim by any session other
+	// than Session (an empty Session hides every live claim).
+	HideClaimedByOthers bool
+	Session             string
+	Limit               int
+	Offset              int
 }
 
 // ListIssues returns issues matching the filters with comment counts, stale_days, and total count.
@@ -142,6 +177,40 @@ func (s *IssueStore) ListIssuesEx(ctx context.Context, params IssueListParams) (
 	if params.Type != "" {
 		query = query.Where("type = ?", params.Type)
 	}
+	if params.Query != "" {
+		query = query.Where("(issues.search_vector @@ "+issueSearchTSQuery+
+			" OR EXISTS (SELECT 1 FROM issue_comments c WHERE c.issue_id = issues.id AND c.search_vector @@ "+issueSearchTSQuery+"))",
+			params.Query, params.Query, params.Query, params.Query)
+	}
+	if len(params.Labels) > 0 {
+		labels, err := json.Marshal(params.Labels)
+		if err != nil {
+			return nil, 0, fmt.Errorf("encode label filter: %w", err)
+		}
+		query = query.Where("labels @> CAST(? AS jsonb)", string(labels))
+	}
+	if len(params.ExcludeLabels) > 0 {
+		query = query.Where("NOT jsonb_exists_any(COALESCE(labels, '[]'::jsonb), ?)", pq.Array(params.ExcludeLabels))
+	}
+	if len(params.Priorities) > 0 {
+		query = query.Where("priority IN ?", params.Priorities)
+	}
+	if params.CreatedAfter != nil {
+		query = query.Where("created_at >= ?", *params.CreatedAfter)
+	}
+	if params.CreatedBefore != nil {
+		query = query.Where("created_at < ?", *params.CreatedBefore)
+	}
+	if params.UpdatedAfter != nil {
+		query = query.Where("updated_at >= ?", *params.UpdatedAfter)
+	}
+	if params.UpdatedBefore != nil {
+		query = query.Where("updated_at < ?", *params.UpdatedBefore)
+	}
+	if params.HideClaimedByOthers {
+		query = query.Where("NOT (assignee_session <> '' AND assignee_session <> ? AND lease_expires_at > ?)",
+			params.Session, time.Now())
+	}
 
 	var total int64
 	if err := query.Count(&total).Error; err != nil {
@@ -149,8 +218,16 @@ func (s *IssueStore) ListIssuesEx(ctx context.Context, params IssueListParams) (
 	}
 
 	var issues []IssueWithCount
+	selectSQL := "issues.*, (SELECT COUNT(*) FROM issue_comments WHERE issue_comments.issue_id = issues.id) AS comment_count, " +
+		"GREATEST(0, FLOOR(EXTRACT(EPOCH FROM (NOW() - issues.updated_at)) / 86400))::int AS stale_days"
+	if params.Query != "" {
+		selectSQL += ", GREATEST(ts_rank_cd(issues.search_vector, " + issueSearchTSQuery + "), COALESCE((" +
+			"SELECT MAX(ts_rank_cd(c.search_vector, " + issueSearchTSQuery + ")) FROM issue_comments c WHERE c.issue_id = issues.id), 0)) AS search_rank"
+		query = query.Select(selectSQL, params.Query, params.Query, params.Query, params.Query).Order("search_rank DESC")
+	} else {
+		query = query.Select(selectSQL)
+	}
 	err := query.
-		Select("issues.*, (SELECT COUNT(*) FROM issue_comments WHERE issue_comments.issue_id = issues.id) AS comment_count").
 		Order("CASE priority WHEN 'critical' THEN 1 WHEN 'high' THEN 2 WHEN 'medium' THEN 3 WHEN 'low' THEN 4 END, created_at DESC").
 		Limit(limit).
 		Offset(params.Offset).
@@ -202,12 +279,21 @@ func (s *IssueStore) UpdateIssueStatus(ctx context.Context, id int64, status str
 		updates["closed_at"] = now
 	}
 
-	result := s.db.WithContext(ctx).Model(&Issue{}).Where("id = ?", id).Updates(updates)
-	if result.Error != nil {
-		return fmt.Errorf("update issue status: %w", result.Error)
-	}
-	if result.RowsAffected == 0 {
-		return fmt.Errorf("issue %d not found", id)
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var issue Issue
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id", "status").First(&issue, id).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return fmt.Errorf("issue %d not found", id)
+			}
+			return err
+		}
+		if err := tx.Model(&Issue{}).Where("id = ?", id).Updates(updates).Error; err != nil {
+			return err
+		}
+		return recordIssueEvents(tx, now, issueActor(ctx, "", "").statusEvent(id, issue.Status, status)...)
+	})
+	if err != nil {
+		return fmt.Errorf("update issue status: %w", err)
 	}
 	return nil
 }
@@ -251,19 +337,26 @@ func (s *IssueStore) AcknowledgeIssues(ctx context.Context, ids []int64) (int64,
 	}
 
 	now := time.Now()
-	result := s.db.WithContext(ctx).
-		Model(&Issue{}).
-		Where("id IN ? AND status = ?", ids, "open").
-		Updates(map[string]interface{}{
-			"status":          "acknowledged",
-			"acknowledged_at": now,
-			"updated_at":      now,
-		})
-
-	if result.Error != nil {
-		return 0, fmt.Errorf("acknowledge issues: %w", result.Error)
+	actor := issueActor(ctx, "", "")
+	var acknowledged []int64
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Raw(`
+			UPDATE issues SET status = 'acknowledged', acknowledged_at = ?, updated_at = ?
+			WHERE id IN ? AND status = 'open'
+			RETURNING id`, now, now, ids).
+			Scan(&acknowledged).Error; err != nil {
+			return err
+		}
+		events := make([]IssueEvent, 0, len(acknowledged))
+		for _, id := range acknowledged {
+			events = append(events, actor.statusEvent(id, "open", "acknowledged")...)
+		}
+		return recordIssueEvents(tx, now, events...)
+	})
+	if err != nil {
+		return 0, fmt.Errorf("acknowledge issues: %w", err)
 	}
-	return result.RowsAffected, nil
+	return int64(len(acknowledged)), nil
 }
 
 // ReopenIssue transitions a resolved issue back to reopened state.
@@ -296,6 +389,10 @@ func (s *IssueStore) ReopenIssue(ctx context.Context, id int64, comment, authorP
 		if result.RowsAffected == 0 {
 			return fmt.Errorf("issue %d is no longer resolved (concurrent modification)", id)
 		}
+		actor := issueActor(ctx, authorProject, authorAgent)
+		if err := recordIssueEvents(tx, now, actor.statusEvent(id, "resolved", "reopened")...); err != nil {
+			return err
+		}
 
 		// Add reopen comment if provided
 		if comment != "" {
@@ -358,7 +455,7 @@ func (s *IssueStore) CloseIssue(ctx context.Context, id int64, sourceProject str
 		if result.RowsAffected == 0 {
 			return fmt.Errorf("issue %d state changed concurrently", id)
 		}
-		return nil
+		return recordIssueEvents(tx, now, issueActor(ctx, sourceProject, "").statusEvent(id, issue.Status, "closed")...)
 	})
 }
 
@@ -369,17 +466,25 @@ func (s *IssueStore) RejectIssue(ctx context.Context, id int64, comment, authorP
 		return fmt.Errorf("comment is required when rejecting an issue")
 	}
 	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var issue Issue
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id", "status").First(&issue, id).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return fmt.Errorf("issue %d not found", id)
+			}
+			return err
+		}
+
 		now := time.Now()
-		result := tx.Model(&Issue{}).Where("id = ?", id).Updates(map[string]any{
+		if err := tx.Model(&Issue{}).Where("id = ?", id).Updates(map[string]any{
 			"status":     "rejected",
 			"closed_at":  now,
 			"updated_at": now,
-		})
-		if result.Error != nil {
-			return result.Error
+		}).Error; err != nil {
+			return err
 		}
-		if result.RowsAffected == 0 {
-			return fmt.Errorf("issue %d not found", id)
+		actor := issueActor(ctx, authorProject, authorAgent)
+		if err := recordIssueEvents(tx, now, actor.statusEvent(id, issue.Status, "rejected")...); err != nil {
+			return err
 		}
 		return tx.Create(&IssueComment{
 			IssueID:       id,
@@ -409,10 +514,12 @@ func (s *IssueStore) DeleteIssue(ctx context.Context, id int64) error {
 }
 
 // UpdateIssueFields updates mutable fields (title, body, priority, labels, type) for dashboard editing.
-// Only non-zero-value fields are updated.
+// Only non-zero-value fields are updated; each one that actually changes is
+// recorded as a field_changed event.
 func (s *IssueStore) UpdateIssueFields(ctx context.Context, id int64, title, body, priority, issueType string, labels []string) error {
+	now := time.Now()
 	updates := map[string]any{
-		"updated_at": time.Now(),
+		"updated_at": now,
 	}
 	if title != "" {
 		updates["title"] = title
@@ -437,12 +544,44 @@ func (s *IssueStore) UpdateIssueFields(ctx context.Context, id int64, title, bod
 		updates["labels"] = labels
 	}
 
-	result := s.db.WithContext(ctx).Model(&Issue{}).Where("id = ?", id).Updates(updates)
-	if result.Error != nil {
-		return fmt.Errorf("update issue fields: %w", result.Error)
-	}
-	if result.RowsAffected == 0 {
-		return fmt.Errorf("issue %d not found", id)
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var issue Issue
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&issue, id).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return fmt.Errorf("issue %d not found", id)
+			}
+			return err
+		}
+		if err := tx.Model(&Issue{}).Where("id = ?", id).Updates(updates).Error; err != nil {
+			return err
+		}
+
+		actor := issueActor(ctx, "", "")
+		var events []IssueEvent
+		for _, f := range []struct{ field, old, new string }{
+			{"title", issue.Title, title},
+			{"body", issue.Body, body},
+			{"priority", issue.Priority, priority},
+			{"type", issue.Type, issueType},
+		} {
+			if f.new != "" && f.new != f.old {
+				events = append(events, actor.event(id, IssueEventFieldChanged, f.field, f.old, f.new))
+			}
+		}
+		if labels != nil {
+			oldLabels, _ := json.Marshal([]string(issue.Labels))
+			newLabels, _ := json.Marshal(labels)
+			if len(issue.Labels) == 0 {
+				oldLabels = []byte("[]")
+			}
+			if string(oldLabels) != string(newLabels) {
+				events = append(events, actor.event(id, IssueEventFieldChanged, "labels", string(oldLabels), string(newLabels)))
+			}
+		}
+		return recordIssueEvents(tx, now, events...)
+	})
+	if err != nil {
+		return fmt.Errorf("update issue fields: %w", err)
 	}
 	return nil
 }
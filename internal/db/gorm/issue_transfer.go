@@ -0,0 +1,356 @@
+package gorm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/thebtf/engram/pkg/models"
+)
+
+// Outcomes of ImportIssue.
+const (
+	IssueImportCreated   = "created"
+	IssueImportUpdated   = "updated"
+	IssueImportUnchanged = "unchanged"
+)
+
+// IssueWithComments is an issue with its comments, oldest first, as exchanged
+// by ExportIssues and ImportIssue.
+type IssueWithComments struct {
+	Issue
+	Comments []IssueComment `json:"comments"`
+}
+
+// External ID prefixes given on export to issues and comments that were
+// created here rather than imported (see issuefile.FromStore).
+const (
+	nativeIssuePrefix   = "engram:"
+	nativeCommentPrefix = "engram-comment:"
+)
+
+// IssueExportParams filters ExportIssues. Zero values do not filter.
+type IssueExportParams struct {
+	Project  string
+	Statuses []string
+}
+
+// IssueImportResult describes what ImportIssue did with one issue.
+type IssueImportResult struct {
+	ExternalID    string `json:"external_id"`
+	Action        string `json:"action"`
+	ID            int64  `json:"id"`
+	CommentsAdded int    `json:"comments_added"`
+}
+
+// ExportIssues returns the issues targeted at params.Project (all projects
+// when empty) with the given statuses, oldest first, each with its comments.
+func (s *IssueStore) ExportIssues(ctx context.Context, params IssueExportParams) ([]IssueWithComments, error) {
+	query := s.db.WithContext(ctx).Model(&Issue{})
+	if params.Project != "" {
+		query = query.Where("target_project = ?", params.Project)
+	}
+	if len(params.Statuses) > 0 {
+		query = query.Where("status IN ?", params.Statuses)
+	}
+	var issues []Issue
+	if err := query.Order("id").Find(&issues).Error; err != nil {
+		return nil, fmt.Errorf("export issues: %w", err)
+	}
+
+	exported := make([]IssueWithComments, len(issues))
+	if len(issues) == 0 {
+		return exported, nil
+	}
+	ids := make([]int64, len(issues))
+	index := make(map[int64]int, len(issues))
+	for i, issue := range issues {
+		ids[i] = issue.ID
+		index[issue.ID] = i
+		exported[i] = IssueWithComments{Issue: issue, Comments: make([]IssueComment, 0)}
+	}
+
+	var comments []IssueComment
+	if err := s.db.WithContext(ctx).
+		Where("issue_id = ANY(?)", pq.Array(ids)).
+		Order("created_at, id").
+		Find(&comments).Error; err != nil {
+		return nil, fmt.Errorf("export issue comments: %w", err)
+	}
+	for _, c := range comments {
+		i := index[c.IssueID]
+		exported[i].Comments = append(exported[i].Comments, c)
+	}
+	return exported, nil
+}
+
+// ImportIssue creates or updates the issue whose ExternalID matches in, keeping
+// its timestamps, labels, priority and status, and adds the comments whose
+// ExternalID the issue does not have yet. Importing the same issue twice
+// leaves the second import unchanged. Issue and comment ExternalIDs are
+// required; zero CreatedAt and UpdatedAt default to now.
+//
+// An "engram:<id>" issue, or "engram-comment:<id>" comment, is matched to the
+// native row with that ID, so re-importing an export on the server that made
+// it changes nothing. The issue must also have the same CreatedAt, which
+// keeps an export from another server from landing on an unrelated issue.
+func (s *IssueStore) ImportIssue(ctx context.Context, in *IssueWithComments) (*IssueImportResult, error) {
+	issue, err := normalizeImportedIssue(in)
+	if err != nil {
+		return nil, err
+	}
+	result := &IssueImportResult{ExternalID: issue.ExternalID}
+
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		actor := issueActor(ctx, "", "")
+
+		var existing Issue
+		err := gorm.ErrRecordNotFound
+		if id, ok := nativeID(issue.ExternalID, nativeIssuePrefix); ok {
+			err = tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+				Where("id = ? AND external_id = '' AND created_at = ?", id, issue.CreatedAt).
+				First(&existing).Error
+		}
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			err = tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+				Where("external_id = ?", issue.ExternalID).
+				First(&existing).Error
+		}
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			if err := tx.Create(&issue).Error; err != nil {
+				return err
+			}
+			result.ID = issue.ID
+			result.Action = IssueImportCreated
+			created := actor
+			if created.Project == "" {
+				created = IssueActor{Project: issue.SourceProject, Agent: issue.SourceAgent}
+			}
+			if err := recordIssueEvents(tx, issue.CreatedAt, created.event(issue.ID, IssueEventCreated, "status", "", issue.Status)); err != nil {
+				return err
+			}
+		case err != nil:
+			return err
+		default:
+			result.ID = existing.ID
+			result.Action = IssueImportUnchanged
+			updates, events := importedIssueChanges(actor, &existing, &issue)
+			if len(updates) > 0 {
+				if err := tx.Model(&Issue{}).Where("id = ?", existing.ID).Updates(updates).Error; err != nil {
+					return err
+				}
+				result.Action = IssueImportUpdated
+			}
+			if err := recordIssueEvents(tx, now, events...); err != nil {
+				return err
+			}
+		}
+
+		for _, c := range in.Comments {
+			if c.ExternalID == "" {
+				return fmt.Errorf("comment on issue %q has no external_id", issue.ExternalID)
+			}
+			if c.Body == "" {
+				continue
+			}
+			if id, ok := nativeID(c.ExternalID, nativeCommentPrefix); ok {
+				var n int64
+				if err := tx.Model(&IssueComment{}).
+					Where("id = ? AND issue_id = ? AND external_id = ''", id, result.ID).
+					Count(&n).Error; err != nil {
+					return err
+				}
+				if n > 0 {
+					continue
+				}
+			}
+			comment := IssueComment{
+				IssueID:       result.ID,
+				AuthorProject: c.AuthorProject,
+				AuthorAgent:   c.AuthorAgent,
+				Body:          c.Body,
+				ExternalID:    c.ExternalID,
+				CreatedAt:     importedTime(c.CreatedAt, now),
+			}
+			if comment.AuthorProject == "" {
+				comment.AuthorProject = issue.TargetProject
+			}
+			insert := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&comment)
+			if insert.Error != nil {
+				return insert.Error
+			}
+			result.CommentsAdded += int(insert.RowsAffected)
+		}
+		if result.CommentsAdded > 0 && result.Action == IssueImportUnchanged {
+			result.Action = IssueImportUpdated
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("import issue %q: %w", issue.ExternalID, err)
+	}
+	return result, nil
+}
+
+// nativeID returns the row ID in an external ID made of prefix and a number.
+func nativeID(externalID, prefix string) (int64, bool) {
+	rest, ok := strings.CutPrefix(externalID, prefix)
+	if !ok {
+		return 0, false
+	}
+	id, err := strconv.ParseInt(rest, 10, 64)
+	return id, err == nil && id > 0
+}
+
+// normalizeImportedIssue validates an imported issue and fills the same
+// defaults CreateIssue does.
+func normalizeImportedIssue(in *IssueWithComments) (Issue, error) {
+	now := time.Now()
+	issue := Issue{
+		Title:            in.Title,
+		Body:             in.Body,
+		Status:           in.Status,
+		Priority:         in.Priority,
+		Type:             in.Type,
+		SourceProject:    in.SourceProject,
+		TargetProject:    in.TargetProject,
+		SourceAgent:      in.SourceAgent,
+		CreatedBySession: in.CreatedBySession,
+		Labels:           in.Labels,
+		ExternalID:       in.ExternalID,
+		AcknowledgedAt:   importedTimePtr(in.AcknowledgedAt),
+		ResolvedAt:       importedTimePtr(in.ResolvedAt),
+		ReopenedAt:       importedTimePtr(in.ReopenedAt),
+		ClosedAt:         importedTimePtr(in.ClosedAt),
+		CreatedAt:        importedTime(in.CreatedAt, now),
+	}
+	issue.UpdatedAt = importedTime(in.UpdatedAt, issue.CreatedAt)
+	if issue.Labels == nil {
+		issue.Labels = models.JSONStringArray{}
+	}
+	if issue.SourceProject == "" {
+		issue.SourceProject = issue.TargetProject
+	}
+	if issue.Status == "" {
+		issue.Status = "open"
+	}
+	if issue.Priority == "" {
+		issue.Priority = "medium"
+	}
+	if issue.Type == "" {
+		issue.Type = "task"
+	}
+
+	switch {
+	case issue.ExternalID == "":
+		return issue, fmt.Errorf("import issue: external_id is required")
+	case issue.Title == "":
+		return issue, fmt.Errorf("import issue %q: title is required", issue.ExternalID)
+	case issue.TargetProject == "":
+		return issue, fmt.Errorf("import issue %q: target_project is required", issue.ExternalID)
+	}
+	validStatuses := map[string]bool{"open": true, "acknowledged": true, "resolved": true, "reopened": true, "closed": true, "rejected": true}
+	if !validStatuses[issue.Status] {
+		return issue, fmt.Errorf("import issue %q: invalid status %q", issue.ExternalID, issue.Status)
+	}
+	validPriorities := map[string]bool{"critical": true, "high": true, "medium": true, "low": true}
+	if !validPriorities[issue.Priority] {
+		return issue, fmt.Errorf("import issue %q: invalid priority %q", issue.ExternalID, issue.Priority)
+	}
+	if !validIssueTypes[issue.Type] {
+		return issue, fmt.Errorf("import issue %q: invalid type %q", issue.ExternalID, issue.Type)
+	}
+	return issue, nil
+}
+
+// importedIssueChanges returns the column updates that make existing match
+// imported, and the events describing them. No updates means the import is
+// a no-op.
+func importedIssueChanges(actor IssueActor, existing, imported *Issue) (map[string]any, []IssueEvent) {
+	updates := map[string]any{}
+	var events []IssueEvent
+	for _, f := range []struct{ column, old, new string }{
+		{"title", existing.Title, imported.Title},
+		{"body", existing.Body, imported.Body},
+		{"priority", existing.Priority, imported.Priority},
+		{"type", existing.Type, imported.Type},
+		{"source_project", existing.SourceProject, imported.SourceProject},
+		{"target_project", existing.TargetProject, imported.TargetProject},
+		{"source_agent", existing.SourceAgent, imported.SourceAgent},
+	} {
+		if f.old == f.new {
+			continue
+		}
+		updates[f.column] = f.new
+		switch f.column {
+		case "title", "body", "priority", "type":
+			events = append(events, actor.event(existing.ID, IssueEventFieldChanged, f.column, f.old, f.new))
+		}
+	}
+	if existing.Status != imported.Status {
+		updates["status"] = imported.Status
+		events = append(events, actor.statusEvent(existing.ID, existing.Status, imported.Status)...)
+	}
+	oldLabels, _ := json.Marshal([]string(existing.Labels))
+	newLabels, _ := json.Marshal([]string(imported.Labels))
+	if len(existing.Labels) == 0 {
+		oldLabels = []byte("[]")
+	}
+	if string(oldLabels) != string(newLabels) {
+		updates["labels"] = imported.Labels
+		events = append(events, actor.event(existing.ID, IssueEventFieldChanged, "labels", string(oldLabels), string(newLabels)))
+	}
+	for _, t := range []struct {
+		column   string
+		old, new *time.Time
+	}{
+		{"acknowledged_at", existing.AcknowledgedAt, imported.AcknowledgedAt},
+		{"resolved_at", existing.ResolvedAt, imported.ResolvedAt},
+		{"reopened_at", existing.ReopenedAt, imported.ReopenedAt},
+		{"closed_at", existing.ClosedAt, imported.ClosedAt},
+		{"created_at", &existing.CreatedAt, &imported.CreatedAt},
+		{"updated_at", &existing.UpdatedAt, &imported.UpdatedAt},
+	} {
+		if !sameImportedTime(t.old, t.new) {
+			updates[t.column] = t.new
+		}
+	}
+	return updates, events
+}
+
+// importedTime truncates t to the database's microsecond precision, or
+// returns fallback when t is zero.
+func importedTime(t, fallback time.Time) time.Time {
+	if t.IsZero() {
+		return fallback
+	}
+	return t.Truncate(time.Microsecond)
+}
+
+// importedTimePtr is importedTime for optional timestamps.
+func importedTimePtr(t *time.Time) *time.Time {
+	if t == nil || t.IsZero() {
+		return nil
+	}
+	truncated := t.Truncate(time.Microsecond)
+	return &truncated
+}
+
+// sameImportedTime reports whether two optional timestamps denote the same
+// instant at microsecond precision.
+func sameImportedTime(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return a.Truncate(time.Microsecond).Equal(b.Truncate(time.Microsecond))
+}
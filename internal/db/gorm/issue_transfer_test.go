@@ -0,0 +1,206 @@
+package gorm
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/thebtf/engram/pkg/models"
+)
+
+func TestNormalizeImportedIssue(t *testing.T) {
+	created := time.Date(2026, 3, 1, 9, 30, 0, 123456789, time.UTC)
+	issue, err := normalizeImportedIssue(&IssueWithComments{Issue: Issue{
+		ExternalID:    "github:42",
+		Title:         "flaky test",
+		TargetProject: "engram",
+		CreatedAt:     created,
+	}})
+	require.NoError(t, err)
+	assert.Equal(t, "open", issue.Status)
+	assert.Equal(t, "medium", issue.Priority)
+	assert.Equal(t, "task", issue.Type)
+	assert.Equal(t, "engram", issue.SourceProject, "source defaults to target")
+	assert.Equal(t, models.JSONStringArray{}, issue.Labels)
+	assert.Equal(t, created.Truncate(time.Microsecond), issue.CreatedAt)
+	assert.Equal(t, issue.CreatedAt, issue.UpdatedAt, "updated_at defaults to created_at")
+
+	for name, in := range map[string]Issue{
+		"external_id":    {Title: "t", TargetProject: "p"},
+		"title":          {ExternalID: "x", TargetProject: "p"},
+		"target_project": {ExternalID: "x", Title: "t"},
+		"invalid status": {ExternalID: "x", Title: "t", TargetProject: "p", Status: "done"},
+		"invalid type":   {ExternalID: "x", Title: "t", TargetProject: "p", Type: "epic"},
+	} {
+		_, err := normalizeImportedIssue(&IssueWithComments{Issue: in})
+		assert.Error(t, err, name)
+	}
+}
+
+func TestImportedIssueChanges(t *testing.T) {
+	created := time.Date(2026, 3, 1, 9, 30, 0, 0, time.UTC)
+	existing := Issue{ID: 7, Title: "flaky", Status: "open", Priority: "medium", Type: "bug", Labels: models.JSONStringArray{"ci"}, CreatedAt: created, UpdatedAt: created}
+
+	same := existing
+	same.UpdatedAt = created.Add(300 * time.Nanosecond)
+	updates, events := importedIssueChanges(IssueActor{}, &existing, &same)
+	assert.Empty(t, updates, "sub-microsecond differences are not changes")
+	assert.Empty(t, events)
+
+	resolved := created.Add(time.Hour)
+	changed := existing
+	changed.Status = "resolved"
+	changed.ResolvedAt = &resolved
+	changed.Labels = models.JSONStringArray{"ci", "flaky"}
+	changed.UpdatedAt = resolved
+	updates, events = importedIssueChanges(IssueActor{Project: "engram-import"}, &existing, &changed)
+	assert.Equal(t, "resolved", updates["status"])
+	assert.Contains(t, updates, "resolved_at")
+	assert.Contains(t, updates, "labels")
+	assert.Contains(t, updates, "updated_at")
+	require.Len(t, events, 2)
+	assert.Equal(t, IssueEventStatusChanged, events[0].EventType)
+	assert.Equal(t, `["ci","flaky"]`, events[1].NewValue)
+	assert.Equal(t, "engram-import", events[1].ActorProject)
+}
+
+// TestIssueStore_ImportExport round-trips an issue through ImportIssue and
+// ExportIssues and checks that re-importing is idempotent.
+func TestNativeID(t *testing.T) {
+	id, ok := nativeID("engram:42", nativeIssuePrefix)
+	assert.True(t, ok)
+	assert.Equal(t, int64(42), id)
+	id, ok = nativeID("engram-comment:7", nativeCommentPrefix)
+	assert.True(t, ok)
+	assert.Equal(t, int64(7), id)
+
+	for _, ext := range []string{"github:42", "engram:", "engram:x", "engram:-1", "engram-comment:7"} {
+		_, ok := nativeID(ext, nativeIssuePrefix)
+		assert.False(t, ok, ext)
+	}
+}
+
+func TestIssueStore_ImportExport(t *testing.T) {
+	db, cleanup := openTestDB(t)
+	defer cleanup()
+	defer db.Exec(`DELETE FROM issues WHERE target_project = 'test-import'`)
+
+	is := NewIssueStore(db)
+	ctx := context.Background()
+	created := time.Date(2025, 11, 2, 8, 0, 0, 0, time.UTC)
+	resolved := created.Add(48 * time.Hour)
+	in := &IssueWithComments{
+		Issue: Issue{
+			ExternalID:    "test-import:1",
+			Title:         "imported bug",
+			Body:          "steps to reproduce",
+			Status:        "resolved",
+			Priority:      "high",
+			Type:          "bug",
+			SourceProject: "test-import-src",
+			TargetProject: "test-import",
+			SourceAgent:   "codex",
+			Labels:        models.JSONStringArray{"ci", "regression"},
+			ResolvedAt:    &resolved,
+			CreatedAt:     created,
+			UpdatedAt:     resolved,
+		},
+		Comments: []IssueComment{
+			{ExternalID: "test-import:c1", AuthorProject: "test-import", AuthorAgent: "claude-code", Body: "looking", CreatedAt: created.Add(time.Hour)},
+		},
+	}
+
+	result, err := is.ImportIssue(ctx, in)
+	require.NoError(t, err)
+	assert.Equal(t, IssueImportCreated, result.Action)
+	assert.Equal(t, 1, result.CommentsAdded)
+
+	again, err := is.ImportIssue(ctx, in)
+	require.NoError(t, err)
+	assert.Equal(t, IssueImportUnchanged, again.Action, "re-importing the same issue is a no-op")
+	assert.Equal(t, result.ID, again.ID)
+	assert.Zero(t, again.CommentsAdded)
+
+	in.Comments = append(in.Comments, IssueComment{ExternalID: "test-import:c2", AuthorProject: "test-import-src", AuthorAgent: "codex", Body: "fixed", CreatedAt: resolved})
+	in.Priority = "critical"
+	updated, err := is.ImportIssue(ctx, in)
+	require.NoError(t, err)
+	assert.Equal(t, IssueImportUpdated, updated.Action)
+	assert.Equal(t, 1, updated.CommentsAdded)
+
+	exported, err := is.ExportIssues(ctx, IssueExportParams{Project: "test-import"})
+	require.NoError(t, err)
+	require.Len(t, exported, 1)
+	got := exported[0]
+	assert.Equal(t, "test-import:1", got.ExternalID)
+	assert.Equal(t, "critical", got.Priority)
+	assert.Equal(t, "resolved", got.Status)
+	assert.Equal(t, []string{"ci", "regression"}, []string(got.Labels))
+	assert.True(t, created.Equal(got.CreatedAt), "created_at is preserved")
+	require.NotNil(t, got.ResolvedAt)
+	assert.True(t, resolved.Equal(*got.ResolvedAt))
+	require.Len(t, got.Comments, 2)
+	assert.Equal(t, "claude-code", got.Comments[0].AuthorAgent)
+	assert.Equal(t, "codex", got.Comments[1].AuthorAgent)
+	assert.True(t, resolved.Equal(got.Comments[1].CreatedAt), "comment created_at is preserved")
+
+	none, err := is.ExportIssues(ctx, IssueExportParams{Project: "test-import", Statuses: []string{"open"}})
+	require.NoError(t, err)
+	assert.Empty(t, none)
+}
+
+// TestIssueStore_ReimportNativeExport verifies that exporting native issues and
+// importing them back on the same server matches the existing rows instead of
+// duplicating them.
+func TestIssueStore_ReimportNativeExport(t *testing.T) {
+	db, cleanup := openTestDB(t)
+	defer cleanup()
+	defer db.Exec(`DELETE FROM issues WHERE target_project = 'test-reimport'`)
+
+	is := NewIssueStore(db)
+	ctx := context.Background()
+	id, err := is.CreateIssue(ctx, &Issue{Title: "native bug", SourceProject: "test-reimport", TargetProject: "test-reimport"})
+	require.NoError(t, err)
+	_, err = is.AddComment(ctx, id, &IssueComment{AuthorProject: "test-reimport", Body: "looking"})
+	require.NoError(t, err)
+
+	exported, err := is.ExportIssues(ctx, IssueExportParams{Project: "test-reimport"})
+	require.NoError(t, err)
+	require.Len(t, exported, 1)
+
+	// The external IDs issuefile.FromStore gives native rows.
+	in := exported[0]
+	in.ExternalID = fmt.Sprintf("engram:%d", in.ID)
+	in.ID = 0
+	for i := range in.Comments {
+		in.Comments[i].ExternalID = fmt.Sprintf("engram-comment:%d", in.Comments[i].ID)
+	}
+
+	result, err := is.ImportIssue(ctx, &in)
+	require.NoError(t, err)
+	assert.Equal(t, IssueImportUnchanged, result.Action)
+	assert.Equal(t, id, result.ID)
+	assert.Zero(t, result.CommentsAdded)
+
+	after, err := is.ExportIssues(ctx, IssueExportParams{Project: "test-reimport"})
+	require.NoError(t, err)
+	require.Len(t, after, 1, "re-import must not duplicate the issue")
+	assert.Len(t, after[0].Comments, 1, "re-import must not duplicate comments")
+
+	// Another server's issue with the same number is not matched.
+	foreign := in
+	foreign.CreatedAt = in.CreatedAt.Add(-time.Hour)
+	foreign.Comments = nil
+	other, err := is.ImportIssue(ctx, &foreign)
+	require.NoError(t, err)
+	assert.Equal(t, IssueImportCreated, other.Action)
+	assert.NotEqual(t, id, other.ID)
+
+	again, err := is.ImportIssue(ctx, &in)
+	require.NoError(t, err)
+	assert.Equal(t, id, again.ID, "the native row still wins over an imported one with the same external_id")
+}
@@ -0,0 +1,265 @@
+package gorm
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"sort"
+
+	"github.com/lib/pq"
+
+	"github.com/thebtf/engram/internal/embedding"
+	"github.com/thebtf/engram/pkg/models"
+)
+
+// maxMemoryVectorCandidates caps how many stored vectors the in-process
+// (brute-force) search scores per query. The newest rows are kept, which is
+// what recall favours anyway.
+const maxMemoryVectorCandidates = 20000
+
+// MemoryContentHash is the fingerprint stored with a memory embedding. It equals
+// Postgres md5(content), so stale vectors can be found in SQL after an edit.
+func MemoryContentHash(content string) string {
+	sum := md5.Sum([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+// HasPgvector reports whether memory_embeddings has the pgvector column
+// (migration 107 adds it only when the extension is installed). The answer is
+// cached for the lifetime of the store.
+func (s *MemoryStore) HasPgvector(ctx context.Context) bool {
+	s.vecOnce.Do(func() {
+		var exists bool
+		err := s.db.WithContext(ctx).Raw(`
+			SELECT EXISTS (
+				SELECT 1 FROM information_schema.columns
+				WHERE table_name = 'memory_embeddings' AND column_name = 'vec'
+			)`).Scan(&exists).Error
+		s.hasVec = err == nil && exists
+	})
+	return s.hasVec
+}
+
+// UpsertEmbedding stores the vector of a memory, replacing any previous one.
+// contentHash should be MemoryContentHash of the content that was embedded.
+func (s *MemoryStore) UpsertEmbedding(ctx context.Context, memoryID int64, model string, vector []float32, contentHash string) error {
+	if model == "" {
+		return fmt.Errorf("model: must not be empty")
+	}
+	if len(vector) == 0 {
+		return fmt.Errorf("vector: must not be empty")
+	}
+	args := map[string]any{
+		"id":    memoryID,
+		"model": model,
+		"dims":  len(vector),
+		"vec":   pq.Array(vector),
+		"hash":  contentHash,
+	}
+	query := `
+		INSERT INTO memory_embeddings (memory_id, model, dims, embedding, content_hash)
+		VALUES (@id, @model, @dims, CAST(@vec AS real[]), @hash)
+		ON CONFLICT (memory_id) DO UPDATE SET
+			model = EXCLUDED.model, dims = EXCLUDED.dims, embedding = EXCLUDED.embedding,
+			content_hash = EXCLUDED.content_hash, updated_at = NOW()`
+	if s.HasPgvector(ctx) {
+		query = `
+		INSERT INTO memory_embeddings (memory_id, model, dims, embedding, vec, content_hash)
+		VALUES (@id, @model, @dims, CAST(@vec AS real[]), CAST(@vec AS real[])::vector, @hash)
+		ON CONFLICT (memory_id) DO UPDATE SET
+			model = EXCLUDED.model, dims = EXCLUDED.dims, embedding = EXCLUDED.embedding,
+			vec = EXCLUDED.vec, content_hash = EXCLUDED.content_hash, updated_at = NOW()`
+	}
+	if err := s.db.WithContext(ctx).Exec(query, args).Error; err != nil {
+		return fmt.Errorf("upsert embedding for memory %d: %w", memoryID, err)
+	}
+	return nil
+}
+
+// ListUnembedded returns active memories that have no embedding from model, or
+// whose embedding predates the last content change, oldest first.
+func (s *MemoryStore) ListUnembedded(ctx context.Context, model string, limit int) ([]*models.Memory, error) {
+	if limit <= 0 {
+		limit = 100
+	}
+	var rows []Memory
+	err := s.db.WithContext(ctx).Raw(`
+		SELECT m.id, m.project, m.content, m.tags, m.source_agent, m.version,
+		       m.edited_by, m.memory_type, m.importance, m.expires_at, m.rejected,
+		       m.created_at, m.updated_at, m.deleted_at
+		FROM memories m
+		LEFT JOIN memory_embeddings e ON e.memory_id = m.id
+		WHERE m.deleted_at IS NULL
+		  AND (e.memory_id IS NULL OR e.model <> @model OR e.content_hash <> md5(m.content))
+		ORDER BY m.id
+		LIMIT @limit`,
+		map[string]any{"model": model, "limit": limit},
+	).Scan(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("list unembedded memories: %w", err)
+	}
+	result := make([]*models.Memory, len(rows))
+	for i := range rows {
+		result[i] = memoryRowToModel(&rows[i])
+	}
+	return result, nil
+}
+
+// MemoryVectorParams holds the inputs for MemoryStore.SearchVector.
+// Vector must come from the embedder named Model; only embeddings with the same
+// model and length are compared. Scope and filters behave like MemorySearchParams
+// (empty Project = every active project). Hits below MinSimilarity are dropped.
+type MemoryVectorParams struct {
+	Project         string
+	Model           string
+	IncludeProjects []string
+	ExcludeProjects []string
+	Types           []string
+	ExcludeIDs      []int64
+	Vector          []float32
+	MinImportance   float64
+	MinSimilarity   float64
+	Limit           int
+}
+
+// MemoryVectorHit is a memory ranked by cosine similarity to the query vector.
+type MemoryVectorHit struct {
+	Memory     *models.Memory
+	Similarity float64
+}
+
+// memoryVectorRow is the scan target for SearchVector.
+type memoryVectorRow struct {
+	Memory
+	Embedding  pq.Float32Array `gorm:"column:embedding"`
+	Similarity float64         `gorm:"column:similarity"`
+}
+
+// SearchVector returns the memories most similar to params.Vector, best first.
+//
+// With pgvector installed Postgres computes cosine distance (<=>) and returns only
+// the top rows. Without it the stored REAL[] vectors of up to
+// maxMemoryVectorCandidates newest matching memories are scored in-process.
+// Both are exact scans; results are identical up to float rounding.
+func (s *MemoryStore) SearchVector(ctx context.Context, params MemoryVectorParams) ([]MemoryVectorHit, error) {
+	if params.Model == "" {
+		return nil, fmt.Errorf("model: must not be empty")
+	}
+	if len(params.Vector) == 0 {
+		return nil, fmt.Errorf("vector: must not be empty")
+	}
+	if isZeroVector(params.Vector) {
+		// Text with no features (e.g. only punctuation) is similar to nothing.
+		return []MemoryVectorHit{}, nil
+	}
+	limit := params.Limit
+	if limit <= 0 {
+		limit = 20
+	}
+	if limit > MaxPaginationLimit {
+		limit = MaxPaginationLimit
+	}
+
+	args := map[string]any{
+		"model": params.Model,
+		"dims":  len(params.Vector),
+		"q":     pq.Array(params.Vector),
+	}
+	scope, err := memoryScopeSQL(ctx, s.db, MemorySearchParams{
+		Project:         params.Project,
+		IncludeProjects: params.IncludeProjects,
+		ExcludeProjects: params.ExcludeProjects,
+		Types:           params.Types,
+		MinImportance:   params.MinImportance,
+	}, args)
+	if err != nil {
+		return nil, err
+	}
+	if len(params.ExcludeIDs) > 0 {
+		scope += " AND NOT (m.id = ANY(@exclude_ids))"
+		args["exclude_ids"] = pq.Array(params.ExcludeIDs)
+	}
+	where := `e.model = @model AND e.dims = @dims
+		  AND ` + scope + `
+		  AND m.deleted_at IS NULL
+		  AND (m.expires_at IS NULL OR m.expires_at > NOW())`
+
+	var rows []memoryVectorRow
+	if s.HasPgvector(ctx) {
+		args["min_similarity"] = params.MinSimilarity
+		args["limit"] = limit
+		err = s.db.WithContext(ctx).Raw(`
+			SELECT * FROM (
+				SELECT m.id, m.project, m.content, m.tags, m.source_agent, m.version,
+				       m.edited_by, m.memory_type, m.importance, m.expires_at, m.rejected,
+				       m.created_at, m.updated_at, m.deleted_at,
+				       (1 - (e.vec <=> CAST(@q AS real[])::vector))::float8 AS similarity
+				FROM memory_embeddings e
+				JOIN memories m ON m.id = e.memory_id
+				WHERE `+where+`
+			) ranked
+			WHERE similarity >= @min_similarity AND similarity <> 'NaN'::float8
+			ORDER BY similarity DESC, id DESC
+			LIMIT @limit`,
+			args,
+		).Scan(&rows).Error
+		if err != nil {
+			return nil, fmt.Errorf("vector search memories: %w", err)
+		}
+		hits := make([]MemoryVectorHit, len(rows))
+		for i := range rows {
+			hits[i] = MemoryVectorHit{Memory: memoryRowToModel(&rows[i].Memory), Similarity: rows[i].Similarity}
+		}
+		return hits, nil
+	}
+
+	args["candidates"] = maxMemoryVectorCandidates
+	err = s.db.WithContext(ctx).Raw(`
+		SELECT m.id, m.project, m.content, m.tags, m.source_agent, m.version,
+		       m.edited_by, m.memory_type, m.importance, m.expires_at, m.rejected,
+		       m.created_at, m.updated_at, m.deleted_at, e.embedding
+		FROM memory_embeddings e
+		JOIN memories m ON m.id = e.memory_id
+		WHERE `+where+`
+		ORDER BY m.created_at DESC, m.id DESC
+		LIMIT @candidates`,
+		args,
+	).Scan(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("vector search memories: %w", err)
+	}
+	return rankMemoryVectors(rows, params.Vector, params.MinSimilarity, limit), nil
+}
+
+// rankMemoryVectors scores rows by cosine similarity to query in-process and
+// returns the best limit hits at or above minSimilarity (ties broken by id DESC).
+func rankMemoryVectors(rows []memoryVectorRow, query []float32, minSimilarity float64, limit int) []MemoryVectorHit {
+	hits := make([]MemoryVectorHit, 0, len(rows))
+	for i := range rows {
+		sim := embedding.Cosine(query, rows[i].Embedding)
+		if sim < minSimilarity {
+			continue
+		}
+		hits = append(hits, MemoryVectorHit{Memory: memoryRowToModel(&rows[i].Memory), Similarity: sim})
+	}
+	sort.SliceStable(hits, func(i, j int) bool {
+		if hits[i].Similarity != hits[j].Similarity {
+			return hits[i].Similarity > hits[j].Similarity
+		}
+		return hits[i].Memory.ID > hits[j].Memory.ID
+	})
+	if len(hits) > limit {
+		hits = hits[:limit]
+	}
+	return hits
+}
+
+func isZeroVector(v []float32) bool {
+	for _, x := range v {
+		if x != 0 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,94 @@
+package gorm
+
+import (
+	"context"
+	"testing"
+
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/thebtf/engram/pkg/models"
+)
+
+// TestMemoryStore_Embeddings covers UpsertEmbedding, ListUnembedded and
+// SearchVector on whichever path the database supports (pgvector or in-process).
+func TestMemoryStore_Embeddings(t *testing.T) {
+	db, cleanup := openTestDB(t)
+	defer cleanup()
+	defer db.Exec(`DELETE FROM memories WHERE project = 'test-memory-embeddings'`)
+
+	ms := NewMemoryStore(&Store{DB: db})
+	ctx := context.Background()
+
+	const project = "test-memory-embeddings"
+	const model = "test-model/3"
+	near, err := ms.Create(ctx, &models.Memory{Project: project, Content: "near"})
+	require.NoError(t, err)
+	far, err := ms.Create(ctx, &models.Memory{Project: project, Content: "far"})
+	require.NoError(t, err)
+	other, err := ms.Create(ctx, &models.Memory{Project: project, Content: "other model"})
+	require.NoError(t, err)
+
+	require.NoError(t, ms.UpsertEmbedding(ctx, near.ID, model, []float32{1, 0, 0}, MemoryContentHash(near.Content)))
+	require.NoError(t, ms.UpsertEmbedding(ctx, far.ID, model, []float32{0, 1, 0}, MemoryContentHash(far.Content)))
+	require.NoError(t, ms.UpsertEmbedding(ctx, other.ID, "another-model/3", []float32{1, 0, 0}, MemoryContentHash(other.Content)))
+
+	hits, err := ms.SearchVector(ctx, MemoryVectorParams{Project: project, Model: model, Vector: []float32{0.9, 0.1, 0}, Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, hits, 2, "embeddings from another model are not compared")
+	assert.Equal(t, near.ID, hits[0].Memory.ID)
+	assert.Greater(t, hits[0].Similarity, hits[1].Similarity)
+
+	hits, err = ms.SearchVector(ctx, MemoryVectorParams{Project: project, Model: model, Vector: []float32{0.9, 0.1, 0}, MinSimilarity: 0.5})
+	require.NoError(t, err)
+	require.Len(t, hits, 1)
+	assert.Equal(t, near.ID, hits[0].Memory.ID)
+
+	stale, err := ms.ListUnembedded(ctx, model, 1000)
+	require.NoError(t, err)
+	staleIDs := make(map[int64]bool)
+	for _, mem := range stale {
+		staleIDs[mem.ID] = true
+	}
+	assert.False(t, staleIDs[near.ID])
+	assert.True(t, staleIDs[other.ID], "embedded by another model")
+
+	// Editing content invalidates the stored embedding.
+	_, err = ms.Update(ctx, &models.Memory{ID: near.ID, Project: project, Content: "near, edited"})
+	require.NoError(t, err)
+	stale, err = ms.ListUnembedded(ctx, model, 1000)
+	require.NoError(t, err)
+	found := false
+	for _, mem := range stale {
+		found = found || mem.ID == near.ID
+	}
+	assert.True(t, found, "edited memory needs a new embedding")
+}
+
+func TestRankMemoryVectors(t *testing.T) {
+	t.Parallel()
+
+	row := func(id int64, v ...float32) memoryVectorRow {
+		return memoryVectorRow{Memory: Memory{ID: id, Project: "p"}, Embedding: pq.Float32Array(v)}
+	}
+	rows := []memoryVectorRow{
+		row(1, 0, 1),
+		row(2, 1, 0),
+		row(3, 1, 1),
+		row(4, 1, 0),
+		row(5, 0, 0),
+		row(6, 1, 0, 0),
+	}
+
+	hits := rankMemoryVectors(rows, []float32{1, 0}, 0.1, 3)
+	require.Len(t, hits, 3)
+	assert.Equal(t, int64(4), hits[0].Memory.ID, "ties broken by id DESC")
+	assert.Equal(t, int64(2), hits[1].Memory.ID)
+	assert.Equal(t, int64(3), hits[2].Memory.ID)
+	assert.InDelta(t, 1.0, hits[0].Similarity, 1e-9)
+	assert.InDelta(t, 0.7071, hits[2].Similarity, 1e-4)
+
+	hits = rankMemoryVectors(rows, []float32{1, 0}, 0.9, 10)
+	assert.Len(t, hits, 2, "orthogonal, zero and mismatched vectors fall below the threshold")
+}
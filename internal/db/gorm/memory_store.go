@@ -3,14 +3,24 @@ package gorm
 
 import (
 	"context"
+	"encoding/base64"
+	"errors"
 	"fmt"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
+	"github.com/lib/pq"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 
 	"github.com/thebtf/engram/pkg/models"
 )
 
+// ErrInvalidCursor is returned by Search when the supplied cursor cannot be decoded.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
 // MemoryStore provides memory-related database operations using GORM.
 // It targets the dedicated memories table created by migration 088.
 //
@@ -18,6 +28,10 @@ import (
 // from the database row. The caller's input struct is never mutated.
 type MemoryStore struct {
 	db *gorm.DB
+
+	// vecOnce guards hasVec: whether memory_embeddings.vec (pgvector) exists.
+	vecOnce sync.Once
+	hasVec  bool
 }
 
 // NewMemoryStore creates a new MemoryStore backed by the given Store.
@@ -27,6 +41,7 @@ func NewMemoryStore(store *Store) *MemoryStore {
 
 // Create inserts a new memory row. Returns a new *models.Memory populated with the
 // database-assigned ID and timestamps. The caller's input is never mutated.
+// A zero Importance is stored as models.DefaultMemoryImportance.
 func (s *MemoryStore) Create(ctx context.Context, mem *models.Memory) (*models.Memory, error) {
 	if mem == nil {
 		return nil, fmt.Errorf("memory must not be nil")
@@ -38,6 +53,10 @@ func (s *MemoryStore) Create(ctx context.Context, mem *models.Memory) (*models.M
 		return nil, fmt.Errorf("memory.Content must not be empty")
 	}
 
+	if mem.Importance < 0 || mem.Importance > 1 {
+		return nil, fmt.Errorf("memory.Importance must be between 0 and 1")
+	}
+
 	now := time.Now().UTC()
 	row := &Memory{
 		Project:     mem.Project,
@@ -45,15 +64,28 @@ func (s *MemoryStore) Create(ctx context.Context, mem *models.Memory) (*models.M
 		Tags:        models.JSONStringArray(mem.Tags),
 		SourceAgent: mem.SourceAgent,
 		EditedBy:    mem.EditedBy,
+		MemoryType:  mem.MemoryType,
+		Importance:  mem.Importance,
+		ExpiresAt:   mem.ExpiresAt,
+		Rejected:    models.JSONStringArray(mem.Rejected),
 		Version:     1,
 		CreatedAt:   now,
 		UpdatedAt:   now,
 	}
+	if row.Importance == 0 {
+		row.Importance = models.DefaultMemoryImportance
+	}
 	if mem.Version > 0 {
 		row.Version = mem.Version
 	}
 
-	if err := s.db.WithContext(ctx).Create(row).Error; err != nil {
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(row).Error; err != nil {
+			return err
+		}
+		return insertMemoryVersion(tx, row, "create")
+	})
+	if err != nil {
 		return nil, fmt.Errorf("create memory for project %q: %w", mem.Project, err)
 	}
 	return memoryRowToModel(row), nil
@@ -75,25 +107,68 @@ func (s *MemoryStore) Get(ctx context.Context, id int64) (*models.Memory, error)
 	return memoryRowToModel(&row), nil
 }
 
-// List returns active (non-soft-deleted) memories for the given project,
+// List returns active (non-soft-deleted, unexpired) memories for the given project,
 // ordered by created_at DESC, limited to limit rows.
 // project must not be empty.
 func (s *MemoryStore) List(ctx context.Context, project string, limit int) ([]*models.Memory, error) {
-	if project == "" {
+	return s.ListEx(ctx, MemoryListParams{Project: project, Limit: limit})
+}
+
+// Memory list orderings accepted by MemoryListParams.OrderBy.
+const (
+	// MemoryOrderRecent orders by created_at DESC (the default).
+	MemoryOrderRecent = "recent"
+	// MemoryOrderImportance orders by importance DESC, then created_at DESC.
+	MemoryOrderImportance = "importance"
+)
+
+// MemoryListParams holds the filters for MemoryStore.ListEx.
+// Types matches memory_type exactly (empty = any type). MinImportance keeps rows
+// with importance >= the value. Expired memories are hidden unless IncludeExpired
+// is set; the expiry sweeper soft-deletes them shortly after expires_at anyway.
+type MemoryListParams struct {
+	Project        string
+	OrderBy        string
+	Types          []string
+	MinImportance  float64
+	Limit          int
+	IncludeExpired bool
+}
+
+// ListEx returns active memories for a project filtered by type, importance and
+// expiry. project must not be empty.
+func (s *MemoryStore) ListEx(ctx context.Context, params MemoryListParams) ([]*models.Memory, error) {
+	if params.Project == "" {
 		return nil, fmt.Errorf("project: must not be empty")
 	}
+	limit := params.Limit
 	if limit <= 0 {
 		limit = 50
 	}
 
+	q := s.db.WithContext(ctx).
+		Where("project = ? AND deleted_at IS NULL", params.Project)
+	if !params.IncludeExpired {
+		q = q.Where("(expires_at IS NULL OR expires_at > NOW())")
+	}
+	if len(params.Types) > 0 {
+		q = q.Where("memory_type = ANY(?)", pq.Array(params.Types))
+	}
+	if params.MinImportance > 0 {
+		q = q.Where("importance >= ?", params.MinImportance)
+	}
+	switch params.OrderBy {
+	case "", MemoryOrderRecent:
+		q = q.Order("created_at DESC, id DESC")
+	case MemoryOrderImportance:
+		q = q.Order("importance DESC, created_at DESC, id DESC")
+	default:
+		return nil, fmt.Errorf("order_by: unknown ordering %q", params.OrderBy)
+	}
+
 	var rows []Memory
-	err := s.db.WithContext(ctx).
-		Where("project = ? AND deleted_at IS NULL", project).
-		Order("created_at DESC").
-		Limit(limit).
-		Find(&rows).Error
-	if err != nil {
-		return nil, fmt.Errorf("list memories for project %q: %w", project, err)
+	if err := q.Limit(limit).Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("list memories for project %q: %w", params.Project, err)
 	}
 	result := make([]*models.Memory, len(rows))
 	for i := range rows {
@@ -102,9 +177,376 @@ func (s *MemoryStore) List(ctx context.Context, project string, limit int) ([]*m
 	return result, nil
 }
 
+// MemorySearchParams holds the inputs for MemoryStore.Search.
+// Query uses websearch_to_tsquery syntax: "quoted phrases", -negation and OR.
+// Cursor is the opaque NextCursor value from a previous page; empty starts at the top.
+//
+// Project scopes the search to a single project. When Project is empty the search
+// is cross-project: it spans every project that has not been removed, narrowed by
+// IncludeProjects (empty = all) and ExcludeProjects. Both lists accept canonical
+// IDs and legacy aliases; they are expanded via ExpandProjectAliases.
+//
+// Types and MinImportance filter like MemoryListParams. Expired memories never match.
+type MemorySearchParams struct {
+	Project         string
+	Query           string
+	Cursor          string
+	IncludeProjects []string
+	ExcludeProjects []string
+	Types           []string
+	MinImportance   float64
+	Limit           int
+}
+
+// MemorySearchHit is a single ranked full-text match.
+// Snippet is a ts_headline excerpt with matched terms wrapped in ** markers.
+// Similarity is the cosine similarity to the query when the hit was also
+// ranked by vector search (hybrid recall), and 0 otherwise.
+type MemorySearchHit struct {
+	Memory     *models.Memory
+	Snippet    string
+	Score      float64
+	Similarity float64
+}
+
+// MemorySearchPage is one page of Search results ordered by score DESC, id DESC.
+// NextCursor is empty when there are no further matches.
+type MemorySearchPage struct {
+	NextCursor string
+	Hits       []MemorySearchHit
+}
+
+// MemorySearchGroup collects the hits of one project within a cross-project page.
+type MemorySearchGroup struct {
+	Project   string
+	Hits      []MemorySearchHit
+	BestScore float64
+}
+
+// GroupByProject buckets the page's hits by project. Groups are ordered by their
+// best hit's score (the order in which projects first appear on the page) and
+// hits keep their ranked order inside each group.
+func (p *MemorySearchPage) GroupByProject() []MemorySearchGroup {
+	groups := make([]MemorySearchGroup, 0)
+	index := make(map[string]int)
+	for _, hit := range p.Hits {
+		i, ok := index[hit.Memory.Project]
+		if !ok {
+			i = len(groups)
+			index[hit.Memory.Project] = i
+			groups = append(groups, MemorySearchGroup{Project: hit.Memory.Project, BestScore: hit.Score})
+		}
+		groups[i].Hits = append(groups[i].Hits, hit)
+	}
+	return groups
+}
+
+// memorySearchHeadlineOptions configures ts_headline for recall snippets.
+// ** markers render as bold in the dashboard Markdown and read naturally in plain text.
+const memorySearchHeadlineOptions = "StartSel=**, StopSel=**, MaxWords=35, MinWords=15, MaxFragments=2, FragmentDelimiter=\" … \""
+
+// memorySearchRow is the scan target for the Search query.
+type memorySearchRow struct {
+	Memory
+	Snippet string  `gorm:"column:snippet"`
+	Score   float64 `gorm:"column:score"`
+}
+
+// Search runs a ranked full-text query against memories.search_vector (migration 088).
+//
+// The query is parsed with websearch_to_tsquery under both the english and simple
+// dictionaries and OR-ed together, mirroring the dual-dictionary tsvector so that
+// stemmed English terms and verbatim tokens (identifiers, non-English words) both match.
+// Rows are ranked with ts_rank_cd and paginated with a keyset cursor on (score, id),
+// so later pages stay stable while new memories are written.
+func (s *MemoryStore) Search(ctx context.Context, params MemorySearchParams) (*MemorySearchPage, error) {
+	query := strings.TrimSpace(params.Query)
+	if query == "" {
+		return nil, fmt.Errorf("query: must not be empty")
+	}
+	limit := params.Limit
+	if limit <= 0 {
+		limit = 20
+	}
+	if limit > MaxPaginationLimit {
+		limit = MaxPaginationLimit
+	}
+
+	args := map[string]any{
+		"query":    query,
+		"headline": memorySearchHeadlineOptions,
+		"limit":    limit + 1,
+	}
+
+	scope, err := memoryScopeSQL(ctx, s.db, params, args)
+	if err != nil {
+		return nil, err
+	}
+	keyset := ""
+	if params.Cursor != "" {
+		score, id, err := decodeMemorySearchCursor(params.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		keyset = "WHERE r.score < @after_score OR (r.score = @after_score AND r.id < @after_id)"
+		args["after_score"] = score
+		args["after_id"] = id
+	}
+
+	// Fetch one extra row to learn whether another page exists without a COUNT.
+	var rows []memorySearchRow
+	err = s.db.WithContext(ctx).Raw(`
+		WITH q AS (
+			SELECT websearch_to_tsquery('english', @query) || websearch_to_tsquery('simple', @query) AS tsq
+		),
+		ranked AS (
+			SELECT m.id, ts_rank_cd(m.search_vector, q.tsq)::float8 AS score
+			FROM memories m, q
+			WHERE `+scope+`
+			  AND m.deleted_at IS NULL
+			  AND (m.expires_at IS NULL OR m.expires_at > NOW())
+			  AND m.search_vector @@ q.tsq
+		)
+		SELECT m.id, m.project, m.content, m.tags, m.source_agent, m.version,
+		       m.edited_by, m.memory_type, m.importance, m.expires_at, m.rejected,
+		       m.created_at, m.updated_at, m.deleted_at, r.score,
+		       ts_headline('english', m.content, q.tsq, @headline) AS snippet
+		FROM ranked r
+		JOIN memories m ON m.id = r.id
+		CROSS JOIN q
+		`+keyset+`
+		ORDER BY r.score DESC, r.id DESC
+		LIMIT @limit`,
+		args,
+	).Scan(&rows).Error
+	if err != nil {
+		if params.Project == "" {
+			return nil, fmt.Errorf("search memories across projects: %w", err)
+		}
+		return nil, fmt.Errorf("search memories for project %q: %w", params.Project, err)
+	}
+
+	page := &MemorySearchPage{Hits: make([]MemorySearchHit, 0, min(len(rows), limit))}
+	if len(rows) > limit {
+		last := rows[limit-1]
+		page.NextCursor = encodeMemorySearchCursor(last.Score, last.ID)
+		rows = rows[:limit]
+	}
+	for i := range rows {
+		page.Hits = append(page.Hits, MemorySearchHit{
+			Memory:  memoryRowToModel(&rows[i].Memory),
+			Snippet: rows[i].Snippet,
+			Score:   rows[i].Score,
+		})
+	}
+	return page, nil
+}
+
+// memoryScopeSQL builds the WHERE fragment (over alias m) shared by the ranked
+// memory searches: a single project, or every project not removed narrowed by
+// IncludeProjects/ExcludeProjects, plus the type and importance filters.
+// Bind values are added to args.
+func memoryScopeSQL(ctx context.Context, db *gorm.DB, params MemorySearchParams, args map[string]any) (string, error) {
+	var scope string
+	if params.Project != "" {
+		scope = "m.project = @project"
+		args["project"] = params.Project
+	} else {
+		scope = `NOT EXISTS (SELECT 1 FROM projects p WHERE p.id = m.project AND p.removed_at IS NOT NULL)`
+		if len(params.IncludeProjects) > 0 {
+			include, err := ExpandProjectAliases(ctx, db, params.IncludeProjects)
+			if err != nil {
+				return "", err
+			}
+			scope += " AND m.project = ANY(@include)"
+			args["include"] = pq.Array(include)
+		}
+		if len(params.ExcludeProjects) > 0 {
+			exclude, err := ExpandProjectAliases(ctx, db, params.ExcludeProjects)
+			if err != nil {
+				return "", err
+			}
+			scope += " AND NOT (m.project = ANY(@exclude))"
+			args["exclude"] = pq.Array(exclude)
+		}
+	}
+	if len(params.Types) > 0 {
+		scope += " AND m.memory_type = ANY(@types)"
+		args["types"] = pq.Array(params.Types)
+	}
+	if params.MinImportance > 0 {
+		scope += " AND m.importance >= @min_importance"
+		args["min_importance"] = params.MinImportance
+	}
+	return scope, nil
+}
+
+// MemoryContextParams holds the task signals MemoryStore.RankForContext ranks by.
+// Query is a websearch_to_tsquery string (typically prompt keywords joined with "or").
+// Terms are lower-cased words compared with memory tags; Files are path fragments
+// (relative paths, base names) looked up verbatim in memory content.
+type MemoryContextParams struct {
+	Project string
+	Query   string
+	Terms   []string
+	Files   []string
+	Limit   int
+}
+
+// MemoryContextHit is one memory ranked by RankForContext. TextScore is the
+// ts_rank_cd of Query; MatchedTags and MatchedFiles list the Terms and Files the
+// memory overlaps with. A hit with none of the three is a fallback pick, included
+// only to fill the limit after every matching memory.
+type MemoryContextHit struct {
+	Memory       *models.Memory
+	MatchedTags  []string
+	MatchedFiles []string
+	TextScore    float64
+	Relevance    float64
+}
+
+// Matched reports whether the memory matched any task signal.
+func (h MemoryContextHit) Matched() bool {
+	return h.TextScore > 0 || len(h.MatchedTags) > 0 || len(h.MatchedFiles) > 0
+}
+
+// memoryContextRow is the scan target for the RankForContext query.
+type memoryContextRow struct {
+	Memory
+	TextScore float64 `gorm:"column:text_score"`
+	Relevance float64 `gorm:"column:relevance"`
+}
+
+// RankForContext returns the project's unexpired memories ordered by relevance to
+// the current task: full-text rank of Query plus tag overlap with Terms plus
+// mentions of Files, with importance as a tiebreaker. Memories that match nothing
+// follow in importance-then-recency order, so the result is never shorter than
+// ListEx would return. project must not be empty.
+//
+// Query is parsed with the english dictionary only: prompts are prose, and the
+// simple dictionary would keep stop words that match nearly every memory.
+// Relevance weights: text rank x10 (ts_rank_cd is usually well below 1), +1 per
+// matched tag, +2 per mentioned file, + importance.
+func (s *MemoryStore) RankForContext(ctx context.Context, params MemoryContextParams) ([]MemoryContextHit, error) {
+	if params.Project == "" {
+		return nil, fmt.Errorf("project: must not be empty")
+	}
+	limit := params.Limit
+	if limit <= 0 {
+		limit = 50
+	}
+	terms := make([]string, 0, len(params.Terms))
+	for _, term := range params.Terms {
+		if term = strings.ToLower(strings.TrimSpace(term)); term != "" {
+			terms = append(terms, term)
+		}
+	}
+	files := make([]string, 0, len(params.Files))
+	for _, file := range params.Files {
+		if file = strings.TrimSpace(file); file != "" {
+			files = append(files, file)
+		}
+	}
+
+	var rows []memoryContextRow
+	err := s.db.WithContext(ctx).Raw(`
+		WITH q AS (
+			SELECT CASE WHEN @query = '' THEN NULL::tsquery
+			            ELSE websearch_to_tsquery('english', @query)
+			       END AS tsq
+		),
+		scored AS (
+			SELECT m.id,
+			       COALESCE(ts_rank_cd(m.search_vector, q.tsq), 0)::float8 AS text_score,
+			       (SELECT count(*) FROM jsonb_array_elements_text(m.tags) t
+			         WHERE lower(t) = ANY(CAST(@terms AS text[])))::float8 AS tag_hits,
+			       (SELECT count(*) FROM unnest(CAST(@files AS text[])) f
+			         WHERE strpos(m.content, f) > 0)::float8 AS file_hits
+			FROM memories m, q
+			WHERE m.project = @project
+			  AND m.deleted_at IS NULL
+			  AND (m.expires_at IS NULL OR m.expires_at > NOW())
+		)
+		SELECT m.id, m.project, m.content, m.tags, m.source_agent, m.version,
+		       m.edited_by, m.memory_type, m.importance, m.expires_at, m.rejected,
+		       m.created_at, m.updated_at, m.deleted_at, s.text_score,
+		       CASE WHEN s.text_score > 0 OR s.tag_hits > 0 OR s.file_hits > 0
+		            THEN s.text_score * 10 + s.tag_hits + s.file_hits * 2 + m.importance
+		            ELSE 0
+		       END AS relevance
+		FROM scored s
+		JOIN memories m ON m.id = s.id
+		ORDER BY relevance DESC, m.importance DESC, m.created_at DESC, m.id DESC
+		LIMIT @limit`,
+		map[string]any{
+			"project": params.Project,
+			"query":   strings.TrimSpace(params.Query),
+			"terms":   pq.Array(terms),
+			"files":   pq.Array(files),
+			"limit":   limit,
+		},
+	).Scan(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("rank memories for project %q: %w", params.Project, err)
+	}
+
+	termSet := make(map[string]struct{}, len(terms))
+	for _, term := range terms {
+		termSet[term] = struct{}{}
+	}
+	hits := make([]MemoryContextHit, 0, len(rows))
+	for i := range rows {
+		hit := MemoryContextHit{
+			Memory:    memoryRowToModel(&rows[i].Memory),
+			TextScore: rows[i].TextScore,
+			Relevance: rows[i].Relevance,
+		}
+		for _, tag := range rows[i].Tags {
+			if _, ok := termSet[strings.ToLower(tag)]; ok {
+				hit.MatchedTags = append(hit.MatchedTags, tag)
+			}
+		}
+		for _, file := range files {
+			if strings.Contains(rows[i].Content, file) {
+				hit.MatchedFiles = append(hit.MatchedFiles, file)
+			}
+		}
+		hits = append(hits, hit)
+	}
+	return hits, nil
+}
+
+// encodeMemorySearchCursor packs the (score, id) keyset position of the last hit
+// on a page into an opaque URL-safe token.
+func encodeMemorySearchCursor(score float64, id int64) string {
+	raw := strconv.FormatFloat(score, 'g', -1, 64) + ":" + strconv.FormatInt(id, 10)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// decodeMemorySearchCursor reverses encodeMemorySearchCursor.
+func decodeMemorySearchCursor(cursor string) (float64, int64, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, 0, fmt.Errorf("%w: %v", ErrInvalidCursor, err)
+	}
+	scoreStr, idStr, ok := strings.Cut(string(raw), ":")
+	if !ok {
+		return 0, 0, fmt.Errorf("%w: malformed position", ErrInvalidCursor)
+	}
+	score, err := strconv.ParseFloat(scoreStr, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("%w: score: %v", ErrInvalidCursor, err)
+	}
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil || id <= 0 {
+		return 0, 0, fmt.Errorf("%w: id %q", ErrInvalidCursor, idStr)
+	}
+	return score, id, nil
+}
+
 // Update updates an existing memory row by ID.
-// Bumps version and sets updated_at. Returns a NEW populated model.
-// The caller's input struct is never mutated.
+// Bumps version, sets updated_at and records the new revision in memory_versions.
+// Returns a NEW populated model. The caller's input struct is never mutated.
 func (s *MemoryStore) Update(ctx context.Context, mem *models.Memory) (*models.Memory, error) {
 	if mem == nil {
 		return nil, fmt.Errorf("memory must not be nil")
@@ -116,31 +558,209 @@ func (s *MemoryStore) Update(ctx context.Context, mem *models.Memory) (*models.M
 		return nil, fmt.Errorf("memory.Content must not be empty")
 	}
 
-	now := time.Now().UTC()
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		current, err := lockActiveMemory(tx, mem.ID)
+		if err != nil {
+			return err
+		}
+		return updateMemoryRevision(tx, current, mem.Content, mem.Tags, mem.SourceAgent, mem.EditedBy, "edit")
+	})
+	if err != nil {
+		return nil, fmt.Errorf("update memory id=%d: %w", mem.ID, err)
+	}
 
-	// Perform the update using a map to avoid GORM zero-value omission issues.
-	updates := map[string]any{
-		"content":      mem.Content,
-		"tags":         models.JSONStringArray(mem.Tags),
-		"source_agent": mem.SourceAgent,
-		"edited_by":    mem.EditedBy,
-		"updated_at":   now,
-		"version":      gorm.Expr("version + 1"),
+	// Re-fetch to return the fully-populated model.
+	return s.Get(ctx, mem.ID)
+}
+
+// ListVersions returns every recorded revision of a memory, newest first.
+// Soft-deleted memories keep their history and can still be listed.
+func (s *MemoryStore) ListVersions(ctx context.Context, memoryID int64) ([]*models.MemoryVersion, error) {
+	if memoryID == 0 {
+		return nil, fmt.Errorf("memory id must be non-zero")
 	}
+	var rows []MemoryVersion
+	err := s.db.WithContext(ctx).
+		Where("memory_id = ?", memoryID).
+		Order("version DESC").
+		Find(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("list versions for memory id=%d: %w", memoryID, err)
+	}
+	result := make([]*models.MemoryVersion, len(rows))
+	for i := range rows {
+		result[i] = memoryVersionRowToModel(&rows[i])
+	}
+	return result, nil
+}
 
-	result := s.db.WithContext(ctx).
-		Model(&Memory{}).
-		Where("id = ? AND deleted_at IS NULL", mem.ID).
-		Updates(updates)
-	if result.Error != nil {
-		return nil, fmt.Errorf("update memory id=%d: %w", mem.ID, result.Error)
+// GetVersion returns a single revision of a memory.
+// Returns a wrapped gorm.ErrRecordNotFound if that revision was never recorded.
+func (s *MemoryStore) GetVersion(ctx context.Context, memoryID int64, version int) (*models.MemoryVersion, error) {
+	if memoryID == 0 {
+		return nil, fmt.Errorf("memory id must be non-zero")
 	}
-	if result.RowsAffected == 0 {
-		return nil, fmt.Errorf("update memory id=%d: %w", mem.ID, gorm.ErrRecordNotFound)
+	var row MemoryVersion
+	err := s.db.WithContext(ctx).
+		Where("memory_id = ? AND version = ?", memoryID, version).
+		First(&row).Error
+	if err != nil {
+		return nil, fmt.Errorf("get memory id=%d version=%d: %w", memoryID, version, err)
 	}
+	return memoryVersionRowToModel(&row), nil
+}
 
-	// Re-fetch to return the fully-populated model.
-	return s.Get(ctx, mem.ID)
+// Restore makes the content and tags of an older revision current again.
+// Restoring does not rewrite history: it records a new revision whose note
+// names the restored version.
+func (s *MemoryStore) Restore(ctx context.Context, memoryID int64, version int, editedBy string) (*models.Memory, error) {
+	if memoryID == 0 {
+		return nil, fmt.Errorf("memory id must be non-zero")
+	}
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		current, err := lockActiveMemory(tx, memoryID)
+		if err != nil {
+			return err
+		}
+		var old MemoryVersion
+		if err := tx.Where("memory_id = ? AND version = ?", memoryID, version).First(&old).Error; err != nil {
+			return fmt.Errorf("version %d: %w", version, err)
+		}
+		return updateMemoryRevision(tx, current, old.Content, old.Tags, current.SourceAgent, editedBy,
+			fmt.Sprintf("restore v%d", version))
+	})
+	if err != nil {
+		return nil, fmt.Errorf("restore memory id=%d: %w", memoryID, err)
+	}
+	return s.Get(ctx, memoryID)
+}
+
+// Merge folds the source memory into the target and soft-deletes the source.
+// The target gains the union of both tag sets (target order first) and, unless
+// content is non-empty, the source content appended after a blank line. Both
+// memories must be active and belong to the same project.
+func (s *MemoryStore) Merge(ctx context.Context, sourceID, targetID int64, content, editedBy string) (*models.Memory, error) {
+	if sourceID == 0 || targetID == 0 {
+		return nil, fmt.Errorf("source and target ids must be non-zero")
+	}
+	if sourceID == targetID {
+		return nil, fmt.Errorf("cannot merge memory id=%d into itself", sourceID)
+	}
+
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Lock in id order so concurrent merges of the same pair cannot deadlock.
+		first, second := sourceID, targetID
+		if first > second {
+			first, second = second, first
+		}
+		a, err := lockActiveMemory(tx, first)
+		if err != nil {
+			return err
+		}
+		b, err := lockActiveMemory(tx, second)
+		if err != nil {
+			return err
+		}
+		source, target := a, b
+		if source.ID != sourceID {
+			source, target = b, a
+		}
+		if source.Project != target.Project {
+			return fmt.Errorf("source project %q differs from target project %q", source.Project, target.Project)
+		}
+
+		merged := content
+		if merged == "" {
+			merged = target.Content
+			if !strings.Contains(target.Content, source.Content) {
+				merged = target.Content + "\n\n" + source.Content
+			}
+		}
+		tags := unionTags(target.Tags, source.Tags)
+
+		if err := updateMemoryRevision(tx, target, merged, tags, target.SourceAgent, editedBy,
+			fmt.Sprintf("merge from #%d", source.ID)); err != nil {
+			return err
+		}
+		now := time.Now().UTC()
+		return tx.Model(&Memory{}).
+			Where("id = ?", source.ID).
+			Updates(map[string]any{"deleted_at": now, "updated_at": now}).Error
+	})
+	if err != nil {
+		return nil, fmt.Errorf("merge memory id=%d into id=%d: %w", sourceID, targetID, err)
+	}
+	return s.Get(ctx, targetID)
+}
+
+// lockActiveMemory loads an active memory row with SELECT ... FOR UPDATE.
+func lockActiveMemory(tx *gorm.DB, id int64) (*Memory, error) {
+	var row Memory
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ? AND deleted_at IS NULL", id).
+		First(&row).Error
+	if err != nil {
+		return nil, fmt.Errorf("memory id=%d: %w", id, err)
+	}
+	return &row, nil
+}
+
+// updateMemoryRevision writes a new revision of a locked memory row and records it
+// in memory_versions. Must be called inside a transaction after lockActiveMemory.
+func updateMemoryRevision(tx *gorm.DB, current *Memory, content string, tags []string, sourceAgent, editedBy, note string) error {
+	now := time.Now().UTC()
+	next := *current
+	next.Content = content
+	next.Tags = models.JSONStringArray(tags)
+	next.SourceAgent = sourceAgent
+	next.EditedBy = editedBy
+	next.Version = current.Version + 1
+	next.UpdatedAt = now
+
+	// Perform the update using a map to avoid GORM zero-value omission issues.
+	err := tx.Model(&Memory{}).
+		Where("id = ?", current.ID).
+		Updates(map[string]any{
+			"content":      next.Content,
+			"tags":         next.Tags,
+			"source_agent": next.SourceAgent,
+			"edited_by":    next.EditedBy,
+			"updated_at":   next.UpdatedAt,
+			"version":      next.Version,
+		}).Error
+	if err != nil {
+		return err
+	}
+	return insertMemoryVersion(tx, &next, note)
+}
+
+// insertMemoryVersion records row's current revision in memory_versions.
+func insertMemoryVersion(tx *gorm.DB, row *Memory, note string) error {
+	return tx.Create(&MemoryVersion{
+		MemoryID:  row.ID,
+		Version:   row.Version,
+		Content:   row.Content,
+		Tags:      row.Tags,
+		EditedBy:  row.EditedBy,
+		Note:      note,
+		CreatedAt: row.UpdatedAt,
+	}).Error
+}
+
+// unionTags returns a followed by the elements of b not already in a.
+func unionTags(a, b []string) []string {
+	seen := make(map[string]struct{}, len(a)+len(b))
+	out := make([]string, 0, len(a)+len(b))
+	for _, list := range [][]string{a, b} {
+		for _, tag := range list {
+			if _, ok := seen[tag]; ok {
+				continue
+			}
+			seen[tag] = struct{}{}
+			out = append(out, tag)
+		}
+	}
+	return out
 }
 
 // Delete soft-deletes the memory by setting deleted_at = NOW().
@@ -166,6 +786,37 @@ func (s *MemoryStore) Delete(ctx context.Context, id int64) error {
 	return nil
 }
 
+// SoftDeleteExpired soft-deletes every active memory whose expires_at is at or
+// before now and returns the number of rows affected. The memories stay
+// restorable through their version history like any other deleted memory.
+func (s *MemoryStore) SoftDeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	result := s.db.WithContext(ctx).
+		Model(&Memory{}).
+		Where("deleted_at IS NULL AND expires_at IS NOT NULL AND expires_at <= ?", now).
+		Updates(map[string]any{
+			"deleted_at": now,
+			"updated_at": now,
+		})
+	if result.Error != nil {
+		return 0, fmt.Errorf("soft-delete expired memories: %w", result.Error)
+	}
+	return result.RowsAffected, nil
+}
+
+// memoryVersionRowToModel converts an internal GORM MemoryVersion row to the pkg/models type.
+func memoryVersionRowToModel(row *MemoryVersion) *models.MemoryVersion {
+	return &models.MemoryVersion{
+		ID:        row.ID,
+		MemoryID:  row.MemoryID,
+		Version:   row.Version,
+		Content:   row.Content,
+		Tags:      []string(row.Tags),
+		EditedBy:  row.EditedBy,
+		Note:      row.Note,
+		CreatedAt: row.CreatedAt,
+	}
+}
+
 // memoryRowToModel converts an internal GORM Memory row to the pkg/models.Memory type.
 func memoryRowToModel(row *Memory) *models.Memory {
 	return &models.Memory{
@@ -175,6 +826,10 @@ func memoryRowToModel(row *Memory) *models.Memory {
 		Tags:        []string(row.Tags),
 		SourceAgent: row.SourceAgent,
 		EditedBy:    row.EditedBy,
+		MemoryType:  row.MemoryType,
+		Importance:  row.Importance,
+		ExpiresAt:   row.ExpiresAt,
+		Rejected:    []string(row.Rejected),
 		Version:     row.Version,
 		CreatedAt:   row.CreatedAt,
 		UpdatedAt:   row.UpdatedAt,
@@ -2,7 +2,9 @@ package gorm
 
 import (
 	"context"
+	"strconv"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -166,3 +168,321 @@ func TestMemoryStore_List_FiltersByProject(t *testing.T) {
 	assert.Equal(t, proj2, list2[0].Project)
 	assert.Equal(t, "proj2 memory A", list2[0].Content)
 }
+
+// TestMemoryStore_ListEx_MetadataFilters verifies that type, importance and expiry
+// round-trip through Create and drive ListEx filtering and ordering, and that
+// SoftDeleteExpired only touches memories whose TTL has elapsed.
+func TestMemoryStore_ListEx_MetadataFilters(t *testing.T) {
+	db, cleanup := openTestDB(t)
+	defer cleanup()
+	defer db.Exec(`DELETE FROM memories WHERE project = 'test-memory-metadata'`)
+
+	ms := NewMemoryStore(&Store{DB: db})
+	ctx := context.Background()
+
+	const project = "test-memory-metadata"
+	past := time.Now().UTC().Add(-time.Hour)
+	future := time.Now().UTC().Add(24 * time.Hour)
+
+	pitfall, err := ms.Create(ctx, &models.Memory{
+		Project:    project,
+		Content:    "pitfall: migrations must be idempotent",
+		MemoryType: "pitfall",
+		Importance: 0.9,
+		ExpiresAt:  &future,
+		Rejected:   []string{"manual DDL"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "pitfall", pitfall.MemoryType)
+	assert.InDelta(t, 0.9, pitfall.Importance, 1e-9)
+	require.NotNil(t, pitfall.ExpiresAt)
+	assert.Equal(t, []string{"manual DDL"}, pitfall.Rejected)
+
+	decision, err := ms.Create(ctx, &models.Memory{Project: project, Content: "decision: use pgx", MemoryType: "decision"})
+	require.NoError(t, err)
+	assert.InDelta(t, models.DefaultMemoryImportance, decision.Importance, 1e-9, "zero importance stores the default")
+
+	expired, err := ms.Create(ctx, &models.Memory{Project: project, Content: "expired note", MemoryType: "decision", ExpiresAt: &past})
+	require.NoError(t, err)
+
+	// Default ordering is newest first and hides the expired memory.
+	all, err := ms.ListEx(ctx, MemoryListParams{Project: project})
+	require.NoError(t, err)
+	require.Len(t, all, 2)
+	assert.Equal(t, decision.ID, all[0].ID)
+
+	byImportance, err := ms.ListEx(ctx, MemoryListParams{Project: project, OrderBy: MemoryOrderImportance})
+	require.NoError(t, err)
+	require.Len(t, byImportance, 2)
+	assert.Equal(t, pitfall.ID, byImportance[0].ID)
+
+	decisions, err := ms.ListEx(ctx, MemoryListParams{Project: project, Types: []string{"decision"}, IncludeExpired: true})
+	require.NoError(t, err)
+	assert.Len(t, decisions, 2)
+
+	important, err := ms.ListEx(ctx, MemoryListParams{Project: project, MinImportance: 0.8})
+	require.NoError(t, err)
+	require.Len(t, important, 1)
+	assert.Equal(t, pitfall.ID, important[0].ID)
+
+	_, err = ms.ListEx(ctx, MemoryListParams{Project: project, OrderBy: "bogus"})
+	assert.Error(t, err)
+
+	swept, err := ms.SoftDeleteExpired(ctx, time.Now().UTC())
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, swept, int64(1))
+	_, err = ms.Get(ctx, expired.ID)
+	assert.Error(t, err, "expired memory must be soft-deleted by the sweep")
+	_, err = ms.Get(ctx, pitfall.ID)
+	assert.NoError(t, err, "unexpired memory must survive the sweep")
+}
+
+// TestMemoryStore_RankForContext verifies that prompt, tag and file signals lift
+// older memories above newer unrelated ones, and that unmatched memories still
+// fill the result after the matches.
+func TestMemoryStore_RankForContext(t *testing.T) {
+	db, cleanup := openTestDB(t)
+	defer cleanup()
+	defer db.Exec(`DELETE FROM memories WHERE project = 'test-memory-rank-context'`)
+
+	ms := NewMemoryStore(&Store{DB: db})
+	ctx := context.Background()
+
+	const project = "test-memory-rank-context"
+	authNote, err := ms.Create(ctx, &models.Memory{Project: project, Content: "Token refresh races when two requests expire together", Tags: []string{"auth"}})
+	require.NoError(t, err)
+	fileNote, err := ms.Create(ctx, &models.Memory{Project: project, Content: "internal/auth/token.go caches the signing key"})
+	require.NoError(t, err)
+	old := time.Now().UTC().Add(-time.Hour)
+	require.NoError(t, db.Exec(`UPDATE memories SET created_at = ? WHERE id IN (?, ?)`, old, authNote.ID, fileNote.ID).Error)
+	unrelated, err := ms.Create(ctx, &models.Memory{Project: project, Content: "Dashboard colours follow the brand palette"})
+	require.NoError(t, err)
+
+	hits, err := ms.RankForContext(ctx, MemoryContextParams{
+		Project: project,
+		Query:   "token or refresh",
+		Terms:   []string{"auth"},
+		Files:   []string{"internal/auth/token.go"},
+		Limit:   10,
+	})
+	require.NoError(t, err)
+	require.Len(t, hits, 3)
+
+	assert.Equal(t, authNote.ID, hits[0].Memory.ID, "prompt match plus tag overlap ranks first")
+	assert.Positive(t, hits[0].TextScore)
+	assert.Equal(t, []string{"auth"}, hits[0].MatchedTags)
+
+	assert.Equal(t, fileNote.ID, hits[1].Memory.ID)
+	assert.Equal(t, []string{"internal/auth/token.go"}, hits[1].MatchedFiles)
+
+	assert.Equal(t, unrelated.ID, hits[2].Memory.ID)
+	assert.False(t, hits[2].Matched(), "unrelated memory is a fallback pick")
+}
+
+// TestMemoryStore_Search_RanksAndPaginates verifies websearch syntax (phrase and
+// negation), ts_headline snippets and keyset cursor pagination.
+func TestMemoryStore_Search_RanksAndPaginates(t *testing.T) {
+	db, cleanup := openTestDB(t)
+	defer cleanup()
+	defer db.Exec(`DELETE FROM memories WHERE project = 'test-memory-search'`)
+
+	store := &Store{DB: db}
+	ms := NewMemoryStore(store)
+	ctx := context.Background()
+
+	const project = "test-memory-search"
+	contents := []string{
+		"connection pool exhaustion under load: raise max_conns and add a pool timeout",
+		"pool timeout was the root cause of the nightly import failures",
+		"the worker pool is sized from GOMAXPROCS",
+		"unrelated note about dashboard colours",
+	}
+	for _, c := range contents {
+		_, err := ms.Create(ctx, &models.Memory{Project: project, Content: c})
+		require.NoError(t, err)
+	}
+
+	// Plain term: three matches, highest score first, snippets highlight the term.
+	page, err := ms.Search(ctx, MemorySearchParams{Project: project, Query: "pool"})
+	require.NoError(t, err)
+	require.Len(t, page.Hits, 3)
+	assert.Empty(t, page.NextCursor)
+	for i := 1; i < len(page.Hits); i++ {
+		assert.GreaterOrEqual(t, page.Hits[i-1].Score, page.Hits[i].Score, "hits must be ordered by score DESC")
+	}
+	assert.Contains(t, page.Hits[0].Snippet, "**pool**")
+
+	// Phrase: only rows containing the exact phrase.
+	page, err = ms.Search(ctx, MemorySearchParams{Project: project, Query: `"pool timeout"`})
+	require.NoError(t, err)
+	assert.Len(t, page.Hits, 2)
+
+	// Negation: exclude rows mentioning timeout.
+	page, err = ms.Search(ctx, MemorySearchParams{Project: project, Query: "pool -timeout"})
+	require.NoError(t, err)
+	require.Len(t, page.Hits, 1)
+	assert.Contains(t, page.Hits[0].Memory.Content, "GOMAXPROCS")
+
+	// Pagination: walk one hit at a time and collect every match exactly once.
+	seen := map[int64]bool{}
+	cursor := ""
+	for range 5 {
+		page, err = ms.Search(ctx, MemorySearchParams{Project: project, Query: "pool", Cursor: cursor, Limit: 1})
+		require.NoError(t, err)
+		for _, h := range page.Hits {
+			assert.False(t, seen[h.Memory.ID], "memory %d returned on two pages", h.Memory.ID)
+			seen[h.Memory.ID] = true
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		cursor = page.NextCursor
+	}
+	assert.Len(t, seen, 3)
+
+	_, err = ms.Search(ctx, MemorySearchParams{Project: project, Query: "pool", Cursor: "not-a-cursor"})
+	require.Error(t, err)
+}
+
+// TestMemorySearchCursor_RoundTrip verifies the opaque cursor encodes the exact
+// (score, id) keyset position so the next page resumes without gaps.
+func TestMemorySearchCursor_RoundTrip(t *testing.T) {
+	score, id, err := decodeMemorySearchCursor(encodeMemorySearchCursor(0.0333333358168602, 42))
+	require.NoError(t, err)
+	assert.Equal(t, 0.0333333358168602, score)
+	assert.Equal(t, int64(42), id)
+
+	for _, bad := range []string{"!!", "MTIz", "YWJjOjE"} {
+		_, _, err := decodeMemorySearchCursor(bad)
+		assert.ErrorIs(t, err, ErrInvalidCursor, "cursor %q must be rejected", bad)
+	}
+}
+
+// TestMemoryStore_Search_CrossProject verifies that an empty Project searches every
+// project, that include/exclude lists narrow the scope, and that GroupByProject
+// buckets hits in ranked order.
+func TestMemoryStore_Search_CrossProject(t *testing.T) {
+	db, cleanup := openTestDB(t)
+	defer cleanup()
+	defer db.Exec(`DELETE FROM memories WHERE project IN ('test-memory-xproj-a','test-memory-xproj-b','test-memory-xproj-c')`)
+
+	store := &Store{DB: db}
+	ms := NewMemoryStore(store)
+	ctx := context.Background()
+
+	seed := map[string][]string{
+		"test-memory-xproj-a": {"flaky zanzibarquux test fixed by pinning the clock", "zanzibarquux retries disabled in CI"},
+		"test-memory-xproj-b": {"zanzibarquux timeouts traced to DNS"},
+		"test-memory-xproj-c": {"zanzibarquux is not used here"},
+	}
+	for project, contents := range seed {
+		for _, c := range contents {
+			_, err := ms.Create(ctx, &models.Memory{Project: project, Content: c})
+			require.NoError(t, err)
+		}
+	}
+
+	page, err := ms.Search(ctx, MemorySearchParams{
+		Query:           "zanzibarquux",
+		IncludeProjects: []string{"test-memory-xproj-a", "test-memory-xproj-b", "test-memory-xproj-c"},
+	})
+	require.NoError(t, err)
+	assert.Len(t, page.Hits, 4)
+
+	page, err = ms.Search(ctx, MemorySearchParams{
+		Query:           "zanzibarquux",
+		IncludeProjects: []string{"test-memory-xproj-a", "test-memory-xproj-b", "test-memory-xproj-c"},
+		ExcludeProjects: []string{"test-memory-xproj-c"},
+	})
+	require.NoError(t, err)
+	require.Len(t, page.Hits, 3)
+
+	groups := page.GroupByProject()
+	require.Len(t, groups, 2)
+	counts := map[string]int{}
+	for _, g := range groups {
+		counts[g.Project] = len(g.Hits)
+		assert.Equal(t, g.Hits[0].Score, g.BestScore, "BestScore must be the first (highest) hit in the group")
+	}
+	assert.Equal(t, map[string]int{"test-memory-xproj-a": 2, "test-memory-xproj-b": 1}, counts)
+}
+
+// TestMemoryStore_VersionHistory verifies that create, update and restore each record
+// a revision with its author, and that restoring never rewrites history.
+func TestMemoryStore_VersionHistory(t *testing.T) {
+	db, cleanup := openTestDB(t)
+	defer cleanup()
+	defer db.Exec(`DELETE FROM memories WHERE project = 'test-memory-versions'`)
+
+	store := &Store{DB: db}
+	ms := NewMemoryStore(store)
+	ctx := context.Background()
+
+	created, err := ms.Create(ctx, &models.Memory{
+		Project:  "test-memory-versions",
+		Content:  "v1 content",
+		Tags:     []string{"a"},
+		EditedBy: "alice",
+	})
+	require.NoError(t, err)
+
+	_, err = ms.Update(ctx, &models.Memory{ID: created.ID, Content: "v2 content", Tags: []string{"a", "b"}, EditedBy: "bob"})
+	require.NoError(t, err)
+
+	restored, err := ms.Restore(ctx, created.ID, 1, "carol")
+	require.NoError(t, err)
+	assert.Equal(t, 3, restored.Version)
+	assert.Equal(t, "v1 content", restored.Content)
+	assert.Equal(t, []string{"a"}, restored.Tags)
+	assert.Equal(t, "carol", restored.EditedBy)
+
+	versions, err := ms.ListVersions(ctx, created.ID)
+	require.NoError(t, err)
+	require.Len(t, versions, 3)
+	assert.Equal(t, []int{3, 2, 1}, []int{versions[0].Version, versions[1].Version, versions[2].Version})
+	assert.Equal(t, []string{"carol", "bob", "alice"}, []string{versions[0].EditedBy, versions[1].EditedBy, versions[2].EditedBy})
+	assert.Equal(t, "restore v1", versions[0].Note)
+	assert.Equal(t, "v2 content", versions[1].Content)
+
+	v2, err := ms.GetVersion(ctx, created.ID, 2)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b"}, v2.Tags)
+
+	_, err = ms.Restore(ctx, created.ID, 99, "carol")
+	require.Error(t, err)
+}
+
+// TestMemoryStore_Merge verifies tag union, content append and source soft-delete.
+func TestMemoryStore_Merge(t *testing.T) {
+	db, cleanup := openTestDB(t)
+	defer cleanup()
+	defer db.Exec(`DELETE FROM memories WHERE project IN ('test-memory-merge','test-memory-merge-other')`)
+
+	store := &Store{DB: db}
+	ms := NewMemoryStore(store)
+	ctx := context.Background()
+
+	target, err := ms.Create(ctx, &models.Memory{Project: "test-memory-merge", Content: "use pgx pool", Tags: []string{"db", "pgx"}})
+	require.NoError(t, err)
+	source, err := ms.Create(ctx, &models.Memory{Project: "test-memory-merge", Content: "cap pool at 20 conns", Tags: []string{"pgx", "limits"}})
+	require.NoError(t, err)
+
+	merged, err := ms.Merge(ctx, source.ID, target.ID, "", "merger")
+	require.NoError(t, err)
+	assert.Equal(t, "use pgx pool\n\ncap pool at 20 conns", merged.Content)
+	assert.Equal(t, []string{"db", "pgx", "limits"}, merged.Tags)
+	assert.Equal(t, 2, merged.Version)
+
+	_, err = ms.Get(ctx, source.ID)
+	require.Error(t, err, "merged source must be soft-deleted")
+
+	versions, err := ms.ListVersions(ctx, target.ID)
+	require.NoError(t, err)
+	require.NotEmpty(t, versions)
+	assert.Equal(t, "merge from #"+strconv.FormatInt(source.ID, 10), versions[0].Note)
+
+	other, err := ms.Create(ctx, &models.Memory{Project: "test-memory-merge-other", Content: "elsewhere"})
+	require.NoError(t, err)
+	_, err = ms.Merge(ctx, other.ID, target.ID, "", "merger")
+	require.Error(t, err, "cross-project merge must be rejected")
+}
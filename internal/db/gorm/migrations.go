@@ -3268,6 +3268,737 @@ WHERE utility_propagated_at IS NOT NULL`).Error
 				return fmt.Errorf("104_drop_sdk_sessions: IRREVERSIBLE — pg_restore required (C3)")
 			},
 		},
+
+		// Migration 105: memory_versions — revision history for memories.
+		// Every create/edit/merge/restore writes the resulting revision here, so
+		// MemoryStore can list, diff and restore versions. Existing memories are
+		// backfilled with their current revision; earlier revisions were never kept.
+		{
+			ID: "105_memory_versions",
+			Migrate: func(tx *gorm.DB) error {
+				sqls := []string{
+					`CREATE TABLE IF NOT EXISTS memory_versions (
+						id         BIGSERIAL PRIMARY KEY,
+						memory_id  BIGINT NOT NULL REFERENCES memories(id) ON DELETE CASCADE,
+						version    INTEGER NOT NULL,
+						content    TEXT NOT NULL,
+						tags       JSONB NOT NULL DEFAULT '[]',
+						edited_by  TEXT,
+						note       TEXT,
+						created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+						UNIQUE(memory_id, version)
+					)`,
+					`INSERT INTO memory_versions (memory_id, version, content, tags, edited_by, note, created_at)
+						SELECT id, version, content, tags, edited_by, 'backfill', updated_at
+						FROM memories
+						ON CONFLICT (memory_id, version) DO NOTHING`,
+				}
+				for _, s := range sqls {
+					if err := tx.Exec(s).Error; err != nil {
+						return fmt.Errorf("migration 105_memory_versions: %w", err)
+					}
+				}
+				return nil
+			},
+			Rollback: func(tx *gorm.DB) error {
+				if err := tx.Exec(`DROP TABLE IF EXISTS memory_versions`).Error; err != nil {
+					return fmt.Errorf("migration 105_memory_versions rollback: %w", err)
+				}
+				return nil
+			},
+		},
+		{
+			// Structured metadata on memories. Before this migration type and TTL only
+			// survived as "type:X" / "ttl:N" tags and importance/rejected were dropped.
+			// The backfill lifts the first tag of each kind into the new columns.
+			ID: "106_memory_metadata",
+			Migrate: func(tx *gorm.DB) error {
+				sqls := []string{
+					`ALTER TABLE memories ADD COLUMN IF NOT EXISTS memory_type TEXT NOT NULL DEFAULT ''`,
+					`ALTER TABLE memories ADD COLUMN IF NOT EXISTS importance DOUBLE PRECISION NOT NULL DEFAULT 0.5`,
+					`ALTER TABLE memories ADD COLUMN IF NOT EXISTS expires_at TIMESTAMPTZ`,
+					`ALTER TABLE memories ADD COLUMN IF NOT EXISTS rejected JSONB NOT NULL DEFAULT '[]'`,
+					`UPDATE memories m SET memory_type = t.value
+						FROM (
+							SELECT DISTINCT ON (id) id, substr(tag, 6) AS value
+							FROM memories, jsonb_array_elements_text(tags) AS tag
+							WHERE tag LIKE 'type:%'
+							ORDER BY id
+						) t
+						WHERE m.id = t.id AND m.memory_type = ''`,
+					`UPDATE memories m SET expires_at = m.created_at + make_interval(days => t.days)
+						FROM (
+							SELECT DISTINCT ON (id) id, substr(tag, 5)::int AS days
+							FROM memories, jsonb_array_elements_text(tags) AS tag
+							WHERE tag ~ '^ttl:[0-9]{1,5}$'
+							ORDER BY id
+						) t
+						WHERE m.id = t.id AND m.expires_at IS NULL AND t.days > 0`,
+					`CREATE INDEX IF NOT EXISTS idx_memories_project_importance
+						ON memories (project, importance DESC, created_at DESC)
+						WHERE deleted_at IS NULL`,
+					`CREATE INDEX IF NOT EXISTS idx_memories_project_type
+						ON memories (project, memory_type)
+						WHERE deleted_at IS NULL`,
+					`CREATE INDEX IF NOT EXISTS idx_memories_expires_at
+						ON memories (expires_at)
+						WHERE deleted_at IS NULL AND expires_at IS NOT NULL`,
+				}
+				for _, s := range sqls {
+					if err := tx.Exec(s).Error; err != nil {
+						return fmt.Errorf("migration 106_memory_metadata: %w", err)
+					}
+				}
+				return nil
+			},
+			Rollback: func(tx *gorm.DB) error {
+				sqls := []string{
+					`DROP INDEX IF EXISTS idx_memories_expires_at`,
+					`DROP INDEX IF EXISTS idx_memories_project_type`,
+					`DROP INDEX IF EXISTS idx_memories_project_importance`,
+					`ALTER TABLE memories DROP COLUMN IF EXISTS rejected`,
+					`ALTER TABLE memories DROP COLUMN IF EXISTS expires_at`,
+					`ALTER TABLE memories DROP COLUMN IF EXISTS importance`,
+					`ALTER TABLE memories DROP COLUMN IF EXISTS memory_type`,
+				}
+				for _, s := range sqls {
+					if err := tx.Exec(s).Error; err != nil {
+						return fmt.Errorf("migration 106_memory_metadata rollback: %w", err)
+					}
+				}
+				return nil
+			},
+		},
+		{
+			// Memory embeddings for semantic recall. One row per memory, tagged with
+			// the embedder that produced it (model) so a backend switch re-embeds
+			// instead of comparing incompatible vectors. embedding (REAL[]) is always
+			// present and is what the in-process brute-force search reads; vec is the
+			// same vector as pgvector's type, added only when the extension is
+			// installed, and lets Postgres rank candidates itself. The column is left
+			// untyped so any dimension fits; memories are project-scoped and small,
+			// so an exact scan is used rather than an approximate index that would
+			// lose recall under the project filter.
+			ID: "107_memory_embeddings",
+			Migrate: func(tx *gorm.DB) error {
+				sqls := []string{
+					`CREATE TABLE IF NOT EXISTS memory_embeddings (
+						memory_id    BIGINT PRIMARY KEY REFERENCES memories(id) ON DELETE CASCADE,
+						model        TEXT NOT NULL,
+						dims         INTEGER NOT NULL,
+						embedding    REAL[] NOT NULL,
+						content_hash TEXT NOT NULL,
+						created_at   TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+						updated_at   TIMESTAMPTZ NOT NULL DEFAULT NOW()
+					)`,
+					`CREATE INDEX IF NOT EXISTS idx_memory_embeddings_model ON memory_embeddings(model, dims)`,
+					`DO $$
+					BEGIN
+						IF EXISTS (SELECT 1 FROM pg_extension WHERE extname = 'vector') THEN
+							ALTER TABLE memory_embeddings ADD COLUMN IF NOT EXISTS vec vector;
+						END IF;
+					END $$`,
+				}
+				for _, s := range sqls {
+					if err := tx.Exec(s).Error; err != nil {
+						return fmt.Errorf("migration 107_memory_embeddings: %w", err)
+					}
+				}
+				return nil
+			},
+			Rollback: func(tx *gorm.DB) error {
+				if err := tx.Exec(`DROP TABLE IF EXISTS memory_embeddings`).Error; err != nil {
+					return fmt.Errorf("migration 107_memory_embeddings rollback: %w", err)
+				}
+				return nil
+			},
+		},
+		{
+			// Document chunks for collection full-text search. content_chunks returns
+			// (dropped with the embedding pipeline in 085) keyed the same way, by
+			// content hash and sequence, so documents sharing a body share its chunks
+			// and a re-ingest of unchanged content skips chunking. name is the
+			// markdown heading or Go symbol the chunk belongs to; it is weighted above
+			// the body so a query naming a section ranks that section first. The
+			// dual-dictionary vector follows memories (088).
+			ID: "108_content_chunks_fts",
+			Migrate: func(tx *gorm.DB) error {
+				sqls := []string{
+					`CREATE TABLE IF NOT EXISTS content_chunks (
+						hash          TEXT NOT NULL REFERENCES content(hash) ON DELETE CASCADE,
+						seq           INTEGER NOT NULL,
+						kind          TEXT NOT NULL DEFAULT '',
+						name          TEXT NOT NULL DEFAULT '',
+						parent_name   TEXT NOT NULL DEFAULT '',
+						language      TEXT NOT NULL DEFAULT '',
+						start_line    INTEGER NOT NULL,
+						end_line      INTEGER NOT NULL,
+						text          TEXT NOT NULL,
+						created_at    TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+						search_vector tsvector GENERATED ALWAYS AS (
+							setweight(to_tsvector('english', COALESCE(name, '')), 'A') ||
+							setweight(to_tsvector('simple',  COALESCE(name, '')), 'A') ||
+							to_tsvector('english', COALESCE(text, '')) ||
+							to_tsvector('simple',  COALESCE(text, ''))
+						) STORED,
+						PRIMARY KEY (hash, seq)
+					)`,
+					`CREATE INDEX IF NOT EXISTS idx_content_chunks_fts
+						ON content_chunks USING GIN (search_vector)`,
+				}
+				for _, s := range sqls {
+					if err := tx.Exec(s).Error; err != nil {
+						return fmt.Errorf("migration 108_content_chunks_fts: %w", err)
+					}
+				}
+				return nil
+			},
+			Rollback: func(tx *gorm.DB) error {
+				if err := tx.Exec(`DROP TABLE IF EXISTS content_chunks`).Error; err != nil {
+					return fmt.Errorf("migration 108_content_chunks_fts rollback: %w", err)
+				}
+				return nil
+			},
+		},
+		{
+			// Typed links between issues, possibly across projects: "source blocks
+			// target", "source duplicates target", "source relates to target" and
+			// "source is the parent of target". An issue has at most one parent,
+			// enforced by the partial unique index; cycles in blocks and parent
+			// links are rejected by IssueStore.LinkIssues before insert.
+			ID: "109_issue_links",
+			Migrate: func(tx *gorm.DB) error {
+				sqls := []string{
+					`CREATE TABLE IF NOT EXISTS issue_links (
+						id         BIGSERIAL PRIMARY KEY,
+						source_id  BIGINT NOT NULL REFERENCES issues(id) ON DELETE CASCADE,
+						target_id  BIGINT NOT NULL REFERENCES issues(id) ON DELETE CASCADE,
+						link_type  TEXT NOT NULL CHECK (link_type IN ('blocks','duplicates','relates','parent')),
+						created_by TEXT NOT NULL DEFAULT '',
+						created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+						CHECK (source_id <> target_id),
+						UNIQUE (source_id, target_id, link_type)
+					)`,
+					`CREATE INDEX IF NOT EXISTS idx_issue_links_target
+						ON issue_links (target_id, link_type)`,
+					`CREATE UNIQUE INDEX IF NOT EXISTS idx_issue_links_single_parent
+						ON issue_links (target_id) WHERE link_type = 'parent'`,
+				}
+				for _, s := range sqls {
+					if err := tx.Exec(s).Error; err != nil {
+						return fmt.Errorf("migration 109_issue_links: %w", err)
+					}
+				}
+				return nil
+			},
+			Rollback: func(tx *gorm.DB) error {
+				if err := tx.Exec(`DROP TABLE IF EXISTS issue_links`).Error; err != nil {
+					return fmt.Errorf("migration 109_issue_links rollback: %w", err)
+				}
+				return nil
+			},
+		},
+		{
+			// Issue claims: which agent session is working on an issue, until when.
+			// A claim is a lease — the claiming session renews it while it works and
+			// the issue lease sweeper clears it once lease_expires_at passes, so a
+			// crashed session does not hold an issue forever. The partial index
+			// serves the sweeper and the session-start "claimed by another session"
+			// filter.
+			ID: "110_issue_claims",
+			Migrate: func(tx *gorm.DB) error {
+				sqls := []string{
+					`ALTER TABLE issues ADD COLUMN IF NOT EXISTS assignee_agent TEXT NOT NULL DEFAULT ''`,
+					`ALTER TABLE issues ADD COLUMN IF NOT EXISTS assignee_session TEXT NOT NULL DEFAULT ''`,
+					`ALTER TABLE issues ADD COLUMN IF NOT EXISTS claimed_at TIMESTAMPTZ`,
+					`ALTER TABLE issues ADD COLUMN IF NOT EXISTS lease_expires_at TIMESTAMPTZ`,
+					`CREATE INDEX IF NOT EXISTS idx_issues_lease_expires
+						ON issues (lease_expires_at) WHERE assignee_session <> ''`,
+				}
+				for _, s := range sqls {
+					if err := tx.Exec(s).Error; err != nil {
+						return fmt.Errorf("migration 110_issue_claims: %w", err)
+					}
+				}
+				return nil
+			},
+			Rollback: func(tx *gorm.DB) error {
+				sqls := []string{
+					`DROP INDEX IF EXISTS idx_issues_lease_expires`,
+					`ALTER TABLE issues DROP COLUMN IF EXISTS lease_expires_at`,
+					`ALTER TABLE issues DROP COLUMN IF EXISTS claimed_at`,
+					`ALTER TABLE issues DROP COLUMN IF EXISTS assignee_session`,
+					`ALTER TABLE issues DROP COLUMN IF EXISTS assignee_agent`,
+				}
+				for _, s := range sqls {
+					if err := tx.Exec(s).Error; err != nil {
+						return fmt.Errorf("migration 110_issue_claims rollback: %w", err)
+					}
+				}
+				return nil
+			},
+		},
+		{
+			// Issue search: generated tsvectors over issue title (weighted) and body
+			// and over comment bodies, a GIN index on labels for include/exclude
+			// filters, and named saved filters for the dashboard. The
+			// dual-dictionary vectors follow memories (088).
+			ID: "111_issue_search",
+			Migrate: func(tx *gorm.DB) error {
+				sqls := []string{
+					`ALTER TABLE issues ADD COLUMN IF NOT EXISTS search_vector tsvector GENERATED ALWAYS AS (
+						setweight(to_tsvector('english', COALESCE(title, '')), 'A') ||
+						setweight(to_tsvector('simple',  COALESCE(title, '')), 'A') ||
+						to_tsvector('english', COALESCE(body, '')) ||
+						to_tsvector('simple',  COALESCE(body, ''))
+					) STORED`,
+					`CREATE INDEX IF NOT EXISTS idx_issues_fts ON issues USING GIN (search_vector)`,
+					`ALTER TABLE issue_comments ADD COLUMN IF NOT EXISTS search_vector tsvector GENERATED ALWAYS AS (
+						to_tsvector('english', COALESCE(body, '')) ||
+						to_tsvector('simple',  COALESCE(body, ''))
+					) STORED`,
+					`CREATE INDEX IF NOT EXISTS idx_issue_comments_fts ON issue_comments USING GIN (search_vector)`,
+					`CREATE INDEX IF NOT EXISTS idx_issues_labels ON issues USING GIN (labels)`,
+					`CREATE TABLE IF NOT EXISTS issue_saved_queries (
+						id         BIGSERIAL PRIMARY KEY,
+						name       TEXT NOT NULL UNIQUE,
+						filter     JSONB NOT NULL DEFAULT '{}',
+						created_by TEXT NOT NULL DEFAULT '',
+						created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+						updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+					)`,
+				}
+				for _, s := range sqls {
+					if err := tx.Exec(s).Error; err != nil {
+						return fmt.Errorf("migration 111_issue_search: %w", err)
+					}
+				}
+				return nil
+			},
+			Rollback: func(tx *gorm.DB) error {
+				sqls := []string{
+					`DROP TABLE IF EXISTS issue_saved_queries`,
+					`DROP INDEX IF EXISTS idx_issues_labels`,
+					`DROP INDEX IF EXISTS idx_issue_comments_fts`,
+					`ALTER TABLE issue_comments DROP COLUMN IF EXISTS search_vector`,
+					`DROP INDEX IF EXISTS idx_issues_fts`,
+					`ALTER TABLE issues DROP COLUMN IF EXISTS search_vector`,
+				}
+				for _, s := range sqls {
+					if err := tx.Exec(s).Error; err != nil {
+						return fmt.Errorf("migration 111_issue_search rollback: %w", err)
+					}
+				}
+				return nil
+			},
+		},
+		{
+			// Issue activity timeline: one row per status transition, field edit,
+			// claim change and link change, with the acting project, agent and
+			// session and the old and new values. Existing issues get a "created"
+			// event backfilled from their own columns so every timeline starts
+			// at creation.
+			ID: "112_issue_events",
+			Migrate: func(tx *gorm.DB) error {
+				sqls := []string{
+					`CREATE TABLE IF NOT EXISTS issue_events (
+						id            BIGSERIAL PRIMARY KEY,
+						issue_id      BIGINT NOT NULL REFERENCES issues(id) ON DELETE CASCADE,
+						event_type    TEXT NOT NULL,
+						field         TEXT NOT NULL DEFAULT '',
+						old_value     TEXT NOT NULL DEFAULT '',
+						new_value     TEXT NOT NULL DEFAULT '',
+						actor_project TEXT NOT NULL DEFAULT '',
+						actor_agent   TEXT NOT NULL DEFAULT '',
+						actor_session TEXT NOT NULL DEFAULT '',
+						created_at    TIMESTAMPTZ NOT NULL DEFAULT NOW()
+					)`,
+					`CREATE INDEX IF NOT EXISTS idx_issue_events_issue_created
+						ON issue_events (issue_id, created_at)`,
+					`INSERT INTO issue_events (issue_id, event_type, field, new_value, actor_project, actor_agent, actor_session, created_at)
+						SELECT i.id, 'created', 'status', i.status, i.source_project, COALESCE(i.source_agent, ''), COALESCE(i.created_by_session, ''), i.created_at
+						FROM issues i
+						WHERE NOT EXISTS (SELECT 1 FROM issue_events e WHERE e.issue_id = i.id)`,
+				}
+				for _, s := range sqls {
+					if err := tx.Exec(s).Error; err != nil {
+						return fmt.Errorf("migration 112_issue_events: %w", err)
+					}
+				}
+				return nil
+			},
+			Rollback: func(tx *gorm.DB) error {
+				if err := tx.Exec(`DROP TABLE IF EXISTS issue_events`).Error; err != nil {
+					return fmt.Errorf("migration 112_issue_events rollback: %w", err)
+				}
+				return nil
+			},
+		},
+		{
+			// Outbound webhooks: registered endpoints with an event filter and an
+			// optional project filter, and a persistent delivery queue. Each event
+			// becomes one delivery row per matching endpoint; the webhook deliverer
+			// posts pending rows whose next_attempt_at has passed and reschedules
+			// failures with exponential backoff. Delivered and failed rows stay as
+			// the delivery log.
+			ID: "113_webhooks",
+			Migrate: func(tx *gorm.DB) error {
+				sqls := []string{
+					`CREATE TABLE IF NOT EXISTS webhooks (
+						id         BIGSERIAL PRIMARY KEY,
+						name       TEXT NOT NULL UNIQUE,
+						url        TEXT NOT NULL,
+						secret     TEXT NOT NULL,
+						events     JSONB NOT NULL DEFAULT '[]',
+						project    TEXT NOT NULL DEFAULT '',
+						enabled    BOOLEAN NOT NULL DEFAULT TRUE,
+						created_by TEXT NOT NULL DEFAULT '',
+						created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+						updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+					)`,
+					`CREATE TABLE IF NOT EXISTS webhook_deliveries (
+						id              BIGSERIAL PRIMARY KEY,
+						webhook_id      BIGINT NOT NULL REFERENCES webhooks(id) ON DELETE CASCADE,
+						event_id        TEXT NOT NULL,
+						event_type      TEXT NOT NULL,
+						payload         JSONB NOT NULL,
+						status          TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending','delivered','failed')),
+						attempts        INTEGER NOT NULL DEFAULT 0,
+						next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+						last_attempt_at TIMESTAMPTZ,
+						response_status INTEGER NOT NULL DEFAULT 0,
+						last_error      TEXT NOT NULL DEFAULT '',
+						delivered_at    TIMESTAMPTZ,
+						created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW()
+					)`,
+					`CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_due
+						ON webhook_deliveries (next_attempt_at) WHERE status = 'pending'`,
+					`CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_webhook
+						ON webhook_deliveries (webhook_id, created_at DESC)`,
+				}
+				for _, s := range sqls {
+					if err := tx.Exec(s).Error; err != nil {
+						return fmt.Errorf("migration 113_webhooks: %w", err)
+					}
+				}
+				return nil
+			},
+			Rollback: func(tx *gorm.DB) error {
+				sqls := []string{
+					`DROP TABLE IF EXISTS webhook_deliveries`,
+					`DROP TABLE IF EXISTS webhooks`,
+				}
+				for _, s := range sqls {
+					if err := tx.Exec(s).Error; err != nil {
+						return fmt.Errorf("migration 113_webhooks rollback: %w", err)
+					}
+				}
+				return nil
+			},
+		},
+		{
+			// Issue import/export: external_id identifies an issue or comment in
+			// the tracker it was imported from (or the engram instance it was
+			// exported from), so re-importing the same file updates rows instead
+			// of duplicating them. Empty means the row originated here.
+			ID: "114_issue_external_ids",
+			Migrate: func(tx *gorm.DB) error {
+				sqls := []string{
+					`ALTER TABLE issues ADD COLUMN IF NOT EXISTS external_id TEXT NOT NULL DEFAULT ''`,
+					`ALTER TABLE issue_comments ADD COLUMN IF NOT EXISTS external_id TEXT NOT NULL DEFAULT ''`,
+					`CREATE UNIQUE INDEX IF NOT EXISTS idx_issues_external_id
+						ON issues (external_id) WHERE external_id <> ''`,
+					`CREATE UNIQUE INDEX IF NOT EXISTS idx_issue_comments_external_id
+						ON issue_comments (issue_id, external_id) WHERE external_id <> ''`,
+				}
+				for _, s := range sqls {
+					if err := tx.Exec(s).Error; err != nil {
+						return fmt.Errorf("migration 114_issue_external_ids: %w", err)
+					}
+				}
+				return nil
+			},
+			Rollback: func(tx *gorm.DB) error {
+				sqls := []string{
+					`DROP INDEX IF EXISTS idx_issue_comments_external_id`,
+					`DROP INDEX IF EXISTS idx_issues_external_id`,
+					`ALTER TABLE issue_comments DROP COLUMN IF EXISTS external_id`,
+					`ALTER TABLE issues DROP COLUMN IF EXISTS external_id`,
+				}
+				for _, s := range sqls {
+					if err := tx.Exec(s).Error; err != nil {
+						return fmt.Errorf("migration 114_issue_external_ids rollback: %w", err)
+					}
+				}
+				return nil
+			},
+		},
+		{
+			// Issue SLA escalation: escalated_at restarts the SLA clock each time
+			// the escalation job raises an issue's priority, and escalation_count
+			// records how often it did. The partial index covers the job's scan
+			// of unresolved issues by priority.
+			ID: "115_issue_sla_escalation",
+			Migrate: func(tx *gorm.DB) error {
+				sqls := []string{
+					`ALTER TABLE issues ADD COLUMN IF NOT EXISTS escalated_at TIMESTAMPTZ`,
+					`ALTER TABLE issues ADD COLUMN IF NOT EXISTS escalation_count INTEGER NOT NULL DEFAULT 0`,
+					`CREATE INDEX IF NOT EXISTS idx_issues_unresolved_priority
+						ON issues (priority, status) WHERE status IN ('open', 'acknowledged', 'reopened')`,
+				}
+				for _, s := range sqls {
+					if err := tx.Exec(s).Error; err != nil {
+						return fmt.Errorf("migration 115_issue_sla_escalation: %w", err)
+					}
+				}
+				return nil
+			},
+			Rollback: func(tx *gorm.DB) error {
+				sqls := []string{
+					`DROP INDEX IF EXISTS idx_issues_unresolved_priority`,
+					`ALTER TABLE issues DROP COLUMN IF EXISTS escalation_count`,
+					`ALTER TABLE issues DROP COLUMN IF EXISTS escalated_at`,
+				}
+				for _, s := range sqls {
+					if err := tx.Exec(s).Error; err != nil {
+						return fmt.Errorf("migration 115_issue_sla_escalation rollback: %w", err)
+					}
+				}
+				return nil
+			},
+		},
+		{
+			// Per-credential access policies and the vault read audit log.
+			// allowed_projects / allowed_keycards restrict who may decrypt a
+			// credential (an empty project list means the credential's own
+			// project, an empty keycard list any keycard); access='read-only' hides
+			// the value from everyone but admins. credential_access_log records
+			// every decrypt attempt, allowed or denied. It keeps the credential's
+			// project and key so entries outlive the credential itself.
+			ID: "116_credential_acls",
+			Migrate: func(tx *gorm.DB) error {
+				sqls := []string{
+					`ALTER TABLE credentials ADD COLUMN IF NOT EXISTS allowed_projects JSONB NOT NULL DEFAULT '[]'`,
+					`ALTER TABLE credentials ADD COLUMN IF NOT EXISTS allowed_keycards JSONB NOT NULL DEFAULT '[]'`,
+					`ALTER TABLE credentials ADD COLUMN IF NOT EXISTS access TEXT NOT NULL DEFAULT 'reveal'`,
+					`CREATE TABLE IF NOT EXISTS credential_access_log (
+						id                 BIGSERIAL PRIMARY KEY,
+						credential_id      BIGINT NOT NULL,
+						credential_project TEXT NOT NULL,
+						credential_key     TEXT NOT NULL,
+						keycard_id         TEXT NOT NULL DEFAULT '',
+						role               TEXT NOT NULL DEFAULT '',
+						caller_project     TEXT NOT NULL DEFAULT '',
+						session_id         TEXT NOT NULL DEFAULT '',
+						source             TEXT NOT NULL DEFAULT '',
+						allowed            BOOLEAN NOT NULL,
+						reason             TEXT NOT NULL DEFAULT '',
+						created_at         TIMESTAMPTZ NOT NULL DEFAULT NOW()
+					)`,
+					`CREATE INDEX IF NOT EXISTS idx_credential_access_log_created
+						ON credential_access_log (created_at DESC)`,
+					`CREATE INDEX IF NOT EXISTS idx_credential_access_log_credential
+						ON credential_access_log (credential_project, credential_key, created_at DESC)`,
+					`CREATE INDEX IF NOT EXISTS idx_credential_access_log_keycard
+						ON credential_access_log (keycard_id, created_at DESC) WHERE keycard_id <> ''`,
+				}
+				for _, s := range sqls {
+					if err := tx.Exec(s).Error; err != nil {
+						return fmt.Errorf("migration 116_credential_acls: %w", err)
+					}
+				}
+				return nil
+			},
+			Rollback: func(tx *gorm.DB) error {
+				sqls := []string{
+					`DROP TABLE IF EXISTS credential_access_log`,
+					`ALTER TABLE credentials DROP COLUMN IF EXISTS access`,
+					`ALTER TABLE credentials DROP COLUMN IF EXISTS allowed_keycards`,
+					`ALTER TABLE credentials DROP COLUMN IF EXISTS allowed_projects`,
+				}
+				for _, s := range sqls {
+					if err := tx.Exec(s).Error; err != nil {
+						return fmt.Errorf("migration 116_credential_acls rollback: %w", err)
+					}
+				}
+				return nil
+			},
+		},
+		{
+			// 117: envelope encryption for the vault. Each project gets a random
+			// data key that encrypts its credentials; credential_data_keys keeps
+			// it only wrapped by the key-encryption key named in kek_id.
+			// Credentials encrypted directly with the master key before this
+			// migration stay readable and are moved under their project's data
+			// key by the next master-key rotation.
+			ID: "117_credential_data_keys",
+			Migrate: func(tx *gorm.DB) error {
+				sqls := []string{
+					`CREATE TABLE IF NOT EXISTS credential_data_keys (
+						id          BIGSERIAL PRIMARY KEY,
+						project     TEXT NOT NULL,
+						wrapped_key BYTEA NOT NULL,
+						kek_id      TEXT NOT NULL,
+						fingerprint TEXT NOT NULL,
+						created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+						rotated_at  TIMESTAMPTZ NOT NULL DEFAULT NOW()
+					)`,
+					`CREATE UNIQUE INDEX IF NOT EXISTS idx_credential_data_keys_project
+						ON credential_data_keys (project)`,
+					`CREATE INDEX IF NOT EXISTS idx_credential_data_keys_kek
+						ON credential_data_keys (kek_id)`,
+				}
+				for _, s := range sqls {
+					if err := tx.Exec(s).Error; err != nil {
+						return fmt.Errorf("migration 117_credential_data_keys: %w", err)
+					}
+				}
+				return nil
+			},
+			Rollback: func(tx *gorm.DB) error {
+				if err := tx.Exec(`DROP TABLE IF EXISTS credential_data_keys`).Error; err != nil {
+					return fmt.Errorf("migration 117_credential_data_keys rollback: %w", err)
+				}
+				return nil
+			},
+		},
+		{
+			// 118: project-scoped keycards. allowed_projects lists the project
+			// IDs and glob patterns a worker keycard may reach; empty keeps
+			// every existing keycard unrestricted.
+			ID: "118_api_token_allowed_projects",
+			Migrate: func(tx *gorm.DB) error {
+				if err := tx.Exec(`ALTER TABLE api_tokens ADD COLUMN IF NOT EXISTS allowed_projects JSONB NOT NULL DEFAULT '[]'`).Error; err != nil {
+					return fmt.Errorf("migration 118_api_token_allowed_projects: %w", err)
+				}
+				return nil
+			},
+			Rollback: func(tx *gorm.DB) error {
+				if err := tx.Exec(`ALTER TABLE api_tokens DROP COLUMN IF EXISTS allowed_projects`).Error; err != nil {
+					return fmt.Errorf("migration 118_api_token_allowed_projects rollback: %w", err)
+				}
+				return nil
+			},
+		},
+		{
+			// 119: keycard expiry, rotation and usage alerts. expires_at is
+			// enforced by the validator; successor_id links a rotated token to
+			// its replacement. The usage_* columns hold the keycard watch job's
+			// last check and the token's usual hourly rate, and
+			// api_token_alerts the anomalies it raised.
+			ID: "119_api_token_expiry_alerts",
+			Migrate: func(tx *gorm.DB) error {
+				sqls := []string{
+					`ALTER TABLE api_tokens ADD COLUMN IF NOT EXISTS expires_at TIMESTAMPTZ`,
+					`ALTER TABLE api_tokens ADD COLUMN IF NOT EXISTS successor_id UUID`,
+					`ALTER TABLE api_tokens ADD COLUMN IF NOT EXISTS usage_checked_at TIMESTAMPTZ`,
+					`ALTER TABLE api_tokens ADD COLUMN IF NOT EXISTS usage_checked_count BIGINT NOT NULL DEFAULT 0`,
+					`ALTER TABLE api_tokens ADD COLUMN IF NOT EXISTS usage_baseline DOUBLE PRECISION NOT NULL DEFAULT 0`,
+					`CREATE TABLE IF NOT EXISTS api_token_alerts (
+						id          BIGSERIAL PRIMARY KEY,
+						token_id    UUID NOT NULL,
+						token_name  TEXT NOT NULL,
+						kind        TEXT NOT NULL,
+						message     TEXT NOT NULL,
+						rate        DOUBLE PRECISION NOT NULL DEFAULT 0,
+						baseline    DOUBLE PRECISION NOT NULL DEFAULT 0,
+						created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+						resolved_at TIMESTAMPTZ
+					)`,
+					`CREATE INDEX IF NOT EXISTS idx_api_token_alerts_token ON api_token_alerts (token_id)`,
+					`CREATE UNIQUE INDEX IF NOT EXISTS idx_api_token_alerts_open
+						ON api_token_alerts (token_id, kind) WHERE resolved_at IS NULL`,
+				}
+				for _, s := range sqls {
+					if err := tx.Exec(s).Error; err != nil {
+						return fmt.Errorf("migration 119_api_token_expiry_alerts: %w", err)
+					}
+				}
+				return nil
+			},
+			Rollback: func(tx *gorm.DB) error {
+				sqls := []string{
+					`DROP TABLE IF EXISTS api_token_alerts`,
+					`ALTER TABLE api_tokens DROP COLUMN IF EXISTS usage_baseline`,
+					`ALTER TABLE api_tokens DROP COLUMN IF EXISTS usage_checked_count`,
+					`ALTER TABLE api_tokens DROP COLUMN IF EXISTS usage_checked_at`,
+					`ALTER TABLE api_tokens DROP COLUMN IF EXISTS successor_id`,
+					`ALTER TABLE api_tokens DROP COLUMN IF EXISTS expires_at`,
+				}
+				for _, s := range sqls {
+					if err := tx.Exec(s).Error; err != nil {
+						return fmt.Errorf("migration 119_api_token_expiry_alerts rollback: %w", err)
+					}
+				}
+				return nil
+			},
+		},
+		{
+			// Custom roles: named permission bundles assignable to users and
+			// keycards. users.role widens to hold custom role names.
+			ID: "120_roles",
+			Migrate: func(tx *gorm.DB) error {
+				sqls := []string{
+					`CREATE TABLE IF NOT EXISTS roles (
+						name        VARCHAR(64) PRIMARY KEY,
+						description TEXT NOT NULL DEFAULT '',
+						permissions JSONB NOT NULL DEFAULT '[]',
+						created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+						updated_at  TIMESTAMPTZ NOT NULL DEFAULT NOW()
+					)`,
+					`ALTER TABLE users ALTER COLUMN role TYPE VARCHAR(64)`,
+				}
+				for _, s := range sqls {
+					if err := tx.Exec(s).Error; err != nil {
+						return fmt.Errorf("migration 120_roles: %w", err)
+					}
+				}
+				return nil
+			},
+			Rollback: func(tx *gorm.DB) error {
+				sqls := []string{
+					`UPDATE users SET role = 'operator' WHERE role NOT IN ('admin', 'operator')`,
+					`UPDATE api_tokens SET scope = 'read-only' WHERE scope NOT IN ('read-write', 'read-only')`,
+					`ALTER TABLE users ALTER COLUMN role TYPE VARCHAR(20)`,
+					`DROP TABLE IF EXISTS roles`,
+				}
+				for _, s := range sqls {
+					if err := tx.Exec(s).Error; err != nil {
+						return fmt.Errorf("migration 120_roles rollback: %w", err)
+					}
+				}
+				return nil
+			},
+		},
+		{
+			// Results of tool calls that carried an idempotency key (daemon
+			// outbox replays), so a replayed call runs once.
+			ID: "121_tool_call_results",
+			Migrate: func(tx *gorm.DB) error {
+				sqls := []string{
+					`CREATE TABLE IF NOT EXISTS tool_call_results (
+						keycard_id      TEXT NOT NULL DEFAULT '',
+						idempotency_key VARCHAR(128) NOT NULL,
+						tool_name       TEXT NOT NULL,
+						status          VARCHAR(16) NOT NULL DEFAULT 'pending',
+						is_error        BOOLEAN NOT NULL DEFAULT FALSE,
+						content_json    BYTEA,
+						created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+						PRIMARY KEY (keycard_id, idempotency_key)
+					)`,
+					`CREATE INDEX IF NOT EXISTS idx_tool_call_results_created ON tool_call_results (created_at)`,
+				}
+				for _, s := range sqls {
+					if err := tx.Exec(s).Error; err != nil {
+						return fmt.Errorf("migration 121_tool_call_results: %w", err)
+					}
+				}
+				return nil
+			},
+			Rollback: func(tx *gorm.DB) error {
+				return tx.Exec(`DROP TABLE IF EXISTS tool_call_results`).Error
+			},
+		},
 	})
 	if err := m.Migrate(); err != nil {
 		return fmt.Errorf("run gormigrate migrations: %w", err)
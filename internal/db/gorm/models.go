@@ -3,6 +3,7 @@ package gorm
 
 import (
 	"database/sql"
+	"encoding/json"
 	"time"
 
 	"github.com/lib/pq"
@@ -153,16 +154,21 @@ type Document struct {
 // TableName returns the table name for Document.
 func (Document) TableName() string { return "documents" }
 
-// ContentChunk held per-chunk embeddings for a content hash.
-// Removed in v5: content_chunks table dropped (migration 085).
-// This type is retained as a shell so document_store.go compiles without changes.
+// ContentChunk is one searchable chunk of a content body (migration 108).
+// Name is the markdown heading or code symbol the chunk belongs to and
+// ParentName the receiver of a method; StartLine and EndLine are 1-based and
+// inclusive. search_vector is generated by the database and not mapped.
 type ContentChunk struct {
-	Hash      string    `gorm:"type:text;not null;primaryKey" json:"hash"`
-	Seq       int       `gorm:"primaryKey" json:"seq"`
-	Text      string    `gorm:"type:text;not null;default:''" json:"text"`
-	Pos       int       `gorm:"not null" json:"pos"`
-	Model     string    `gorm:"type:text;not null" json:"model"`
-	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+	Hash       string    `gorm:"type:text;not null;primaryKey" json:"hash"`
+	Seq        int       `gorm:"primaryKey" json:"seq"`
+	Kind       string    `gorm:"type:text;not null;default:''" json:"kind"`
+	Name       string    `gorm:"type:text;not null;default:''" json:"name"`
+	ParentName string    `gorm:"type:text;not null;default:''" json:"parent_name"`
+	Language   string    `gorm:"type:text;not null;default:''" json:"language"`
+	StartLine  int       `gorm:"not null" json:"start_line"`
+	EndLine    int       `gorm:"not null" json:"end_line"`
+	Text       string    `gorm:"type:text;not null" json:"text"`
+	CreatedAt  time.Time `gorm:"autoCreateTime" json:"created_at"`
 }
 
 // TableName returns the table name for ContentChunk.
@@ -211,10 +217,41 @@ type APIToken struct {
 	ErrorCount   int64      `gorm:"not null;default:0"`
 	Revoked      bool       `gorm:"not null;default:false"`
 	RevokedAt    *time.Time `gorm:"column:revoked_at"`
+	// AllowedProjects restricts the token to these project IDs and glob
+	// patterns. Empty means every project.
+	AllowedProjects models.JSONStringArray `gorm:"type:jsonb;not null;default:'[]'"`
+	// ExpiresAt is when the validator stops accepting the token; nil never
+	// expires. Rotation sets it to the end of the old token's grace window.
+	ExpiresAt *time.Time `gorm:"column:expires_at"`
+	// SuccessorID is the token that replaced this one on rotation.
+	SuccessorID *string `gorm:"column:successor_id;type:uuid"`
+	// UsageCheckedAt and UsageCheckedCount are the time and request_count of
+	// the last usage check; UsageBaseline is the token's usual request rate
+	// per hour. The keycard watch job keeps them to spot usage spikes.
+	UsageCheckedAt    *time.Time `gorm:"column:usage_checked_at"`
+	UsageCheckedCount int64      `gorm:"not null;default:0"`
+	UsageBaseline     float64    `gorm:"not null;default:0"`
 }
 
 func (APIToken) TableName() string { return "api_tokens" }
 
+// APITokenAlert is a usage anomaly the keycard watch job raised for a token:
+// unused for too long, or used far above its usual rate. An alert stays open
+// until it is dismissed or, for an unused token, the token is used again.
+type APITokenAlert struct {
+	CreatedAt  time.Time  `gorm:"not null;default:now()" json:"created_at"`
+	ResolvedAt *time.Time `gorm:"column:resolved_at" json:"resolved_at,omitempty"`
+	TokenID    string     `gorm:"type:uuid;not null;index" json:"token_id"`
+	TokenName  string     `gorm:"type:text;not null" json:"token_name"`
+	Kind       string     `gorm:"type:text;not null" json:"kind"`
+	Message    string     `gorm:"type:text;not null" json:"message"`
+	Rate       float64    `gorm:"not null;default:0" json:"rate"`
+	Baseline   float64    `gorm:"not null;default:0" json:"baseline"`
+	ID         int64      `gorm:"primaryKey;autoIncrement" json:"id"`
+}
+
+func (APITokenAlert) TableName() string { return "api_token_alerts" }
+
 // ReasoningTrace stores an agent's reasoning chain (System 2 memory).
 // Each trace captures the multi-step reasoning process an agent used
 // to arrive at a decision, enabling future agents to learn from
@@ -245,6 +282,10 @@ func (r *ReasoningTrace) BeforeCreate(tx *gorm.DB) error {
 
 // Issue represents a cross-project issue filed by an agent.
 // Lifecycle: open → acknowledged → resolved ⟲ reopened
+// AssigneeAgent and AssigneeSession name the session holding a claim on the
+// issue until LeaseExpiresAt (migration 110). ExternalID ties an imported
+// issue to its origin so re-imports update it (migration 114). EscalatedAt
+// and EscalationCount are kept by the SLA escalation job (migration 115).
 type Issue struct {
 	ID               int64                  `gorm:"primaryKey;autoIncrement" json:"id"`
 	Title            string                 `gorm:"type:text;not null" json:"title"`
@@ -257,10 +298,17 @@ type Issue struct {
 	SourceAgent      string                 `gorm:"type:text" json:"source_agent"`
 	CreatedBySession string                 `gorm:"type:text" json:"created_by_session"`
 	Labels           models.JSONStringArray `gorm:"type:jsonb;default:'[]'" json:"labels"`
+	ExternalID       string                 `gorm:"type:text;not null;default:''" json:"external_id,omitempty"`
+	AssigneeAgent    string                 `gorm:"type:text;not null;default:''" json:"assignee_agent"`
+	AssigneeSession  string                 `gorm:"type:text;not null;default:''" json:"assignee_session"`
+	ClaimedAt        *time.Time             `gorm:"type:timestamptz" json:"claimed_at"`
+	LeaseExpiresAt   *time.Time             `gorm:"type:timestamptz" json:"lease_expires_at"`
 	AcknowledgedAt   *time.Time             `gorm:"type:timestamptz" json:"acknowledged_at"`
 	ResolvedAt       *time.Time             `gorm:"type:timestamptz" json:"resolved_at"`
 	ReopenedAt       *time.Time             `gorm:"type:timestamptz" json:"reopened_at"`
 	ClosedAt         *time.Time             `gorm:"type:timestamptz" json:"closed_at"`
+	EscalatedAt      *time.Time             `gorm:"type:timestamptz" json:"escalated_at,omitempty"`
+	EscalationCount  int                    `gorm:"not null;default:0" json:"escalation_count"`
 	CreatedAt        time.Time              `gorm:"type:timestamptz;not null;default:now()" json:"created_at"`
 	UpdatedAt        time.Time              `gorm:"type:timestamptz;not null;default:now()" json:"updated_at"`
 }
@@ -274,31 +322,155 @@ type IssueComment struct {
 	AuthorProject string    `gorm:"type:text;not null" json:"author_project"`
 	AuthorAgent   string    `gorm:"type:text" json:"author_agent"`
 	Body          string    `gorm:"type:text;not null" json:"body"`
+	ExternalID    string    `gorm:"type:text;not null;default:''" json:"external_id,omitempty"`
 	CreatedAt     time.Time `gorm:"type:timestamptz;not null;default:now();index:idx_issue_comments_issue_created,priority:2" json:"created_at"`
 }
 
 func (IssueComment) TableName() string { return "issue_comments" }
 
+// IssueLink is a typed, directed link between two issues (migration 109).
+// LinkType is one of blocks, duplicates, relates or parent and reads
+// "SourceID <type> TargetID"; for parent, SourceID is the parent issue.
+type IssueLink struct {
+	ID        int64     `gorm:"primaryKey;autoIncrement" json:"id"`
+	SourceID  int64     `gorm:"not null" json:"source_id"`
+	TargetID  int64     `gorm:"not null;index:idx_issue_links_target,priority:1" json:"target_id"`
+	LinkType  string    `gorm:"type:text;not null;index:idx_issue_links_target,priority:2" json:"link_type"`
+	CreatedBy string    `gorm:"type:text;not null;default:''" json:"created_by"`
+	CreatedAt time.Time `gorm:"type:timestamptz;not null;default:now()" json:"created_at"`
+}
+
+func (IssueLink) TableName() string { return "issue_links" }
+
+// IssueSavedQuery is a named issue filter saved from the dashboard
+// (migration 111). Names are unique; saving an existing name replaces its filter.
+type IssueSavedQuery struct {
+	ID        int64       `gorm:"primaryKey;autoIncrement" json:"id"`
+	Name      string      `gorm:"type:text;not null;uniqueIndex" json:"name"`
+	Filter    IssueFilter `gorm:"type:jsonb;not null;default:'{}'" json:"filter"`
+	CreatedBy string      `gorm:"type:text;not null;default:''" json:"created_by"`
+	CreatedAt time.Time   `gorm:"type:timestamptz;not null;default:now()" json:"created_at"`
+	UpdatedAt time.Time   `gorm:"type:timestamptz;not null;default:now()" json:"updated_at"`
+}
+
+func (IssueSavedQuery) TableName() string { return "issue_saved_queries" }
+
+// IssueEvent is one entry of an issue's activity timeline (migration 112):
+// a status transition, field edit, claim change or link change, with the
+// actor that made it. Field names the changed column, if any.
+type IssueEvent struct {
+	ID           int64     `gorm:"primaryKey;autoIncrement" json:"id"`
+	IssueID      int64     `gorm:"not null;index:idx_issue_events_issue_created,priority:1" json:"issue_id"`
+	EventType    string    `gorm:"type:text;not null" json:"event_type"`
+	Field        string    `gorm:"type:text;not null;default:''" json:"field,omitempty"`
+	OldValue     string    `gorm:"type:text;not null;default:''" json:"old_value,omitempty"`
+	NewValue     string    `gorm:"type:text;not null;default:''" json:"new_value,omitempty"`
+	ActorProject string    `gorm:"type:text;not null;default:''" json:"actor_project"`
+	ActorAgent   string    `gorm:"type:text;not null;default:''" json:"actor_agent"`
+	ActorSession string    `gorm:"type:text;not null;default:''" json:"actor_session"`
+	CreatedAt    time.Time `gorm:"type:timestamptz;not null;default:now();index:idx_issue_events_issue_created,priority:2" json:"created_at"`
+}
+
+func (IssueEvent) TableName() string { return "issue_events" }
+
+// Webhook is a registered outbound webhook endpoint (migration 113). Events
+// lists the event types it receives ("*" for all); a non-empty Project limits
+// it to events of that project. Secret signs deliveries and is never returned
+// by the API after creation.
+type Webhook struct {
+	ID        int64                  `gorm:"primaryKey;autoIncrement" json:"id"`
+	Name      string                 `gorm:"type:text;not null;uniqueIndex" json:"name"`
+	URL       string                 `gorm:"column:url;type:text;not null" json:"url"`
+	Secret    string                 `gorm:"type:text;not null" json:"-"`
+	Events    models.JSONStringArray `gorm:"type:jsonb;not null;default:'[]'" json:"events"`
+	Project   string                 `gorm:"type:text;not null;default:''" json:"project"`
+	Enabled   bool                   `gorm:"not null;default:true" json:"enabled"`
+	CreatedBy string                 `gorm:"type:text;not null;default:''" json:"created_by"`
+	CreatedAt time.Time              `gorm:"type:timestamptz;not null;default:now()" json:"created_at"`
+	UpdatedAt time.Time              `gorm:"type:timestamptz;not null;default:now()" json:"updated_at"`
+}
+
+func (Webhook) TableName() string { return "webhooks" }
+
+// WebhookDelivery is one event queued for one webhook (migration 113). Status
+// is pending until a 2xx response (delivered) or until the attempts run out
+// (failed); NextAttemptAt schedules the next try of a pending delivery.
+type WebhookDelivery struct {
+	ID             int64           `gorm:"primaryKey;autoIncrement" json:"id"`
+	WebhookID      int64           `gorm:"not null;index:idx_webhook_deliveries_webhook,priority:1" json:"webhook_id"`
+	EventID        string          `gorm:"type:text;not null" json:"event_id"`
+	EventType      string          `gorm:"type:text;not null" json:"event_type"`
+	Payload        json.RawMessage `gorm:"type:jsonb;not null" json:"payload"`
+	Status         string          `gorm:"type:text;not null;default:'pending'" json:"status"`
+	Attempts       int             `gorm:"not null;default:0" json:"attempts"`
+	NextAttemptAt  time.Time       `gorm:"type:timestamptz;not null;default:now()" json:"next_attempt_at"`
+	LastAttemptAt  *time.Time      `gorm:"type:timestamptz" json:"last_attempt_at,omitempty"`
+	ResponseStatus int             `gorm:"not null;default:0" json:"response_status"`
+	LastError      string          `gorm:"type:text;not null;default:''" json:"last_error,omitempty"`
+	DeliveredAt    *time.Time      `gorm:"type:timestamptz" json:"delivered_at,omitempty"`
+	CreatedAt      time.Time       `gorm:"type:timestamptz;not null;default:now();index:idx_webhook_deliveries_webhook,priority:2,sort:desc" json:"created_at"`
+}
+
+func (WebhookDelivery) TableName() string { return "webhook_deliveries" }
+
 // Credential represents a vault-stored encrypted credential.
 // Created by migration 087 as a dedicated static-entity table.
 // Pre-v5: credentials lived as rows in observations (type='credential').
 // Post-v5 (US3): credentials are migrated here and observations is dropped.
 type Credential struct {
-	Project                  string     `gorm:"type:text;not null;index:idx_credentials_project,where:deleted_at IS NULL;uniqueIndex:idx_credentials_project_key,priority:1" json:"project"`
-	Key                      string     `gorm:"type:text;not null;uniqueIndex:idx_credentials_project_key,priority:2" json:"key"`
-	EncryptedSecret          []byte     `gorm:"type:bytea;not null" json:"encrypted_secret"`
-	EncryptionKeyFingerprint string     `gorm:"type:text;not null;index:idx_credentials_fingerprint,where:deleted_at IS NULL" json:"encryption_key_fingerprint"`
-	Scope                    string     `gorm:"type:text" json:"scope,omitempty"`
-	EditedBy                 string     `gorm:"type:text" json:"edited_by,omitempty"`
-	CreatedAt                time.Time  `gorm:"type:timestamptz;not null;default:now()" json:"created_at"`
-	UpdatedAt                time.Time  `gorm:"type:timestamptz;not null;default:now()" json:"updated_at"`
-	DeletedAt                *time.Time `gorm:"type:timestamptz" json:"deleted_at,omitempty"`
-	ID                       int64      `gorm:"primaryKey;autoIncrement" json:"id"`
-	Version                  int        `gorm:"not null;default:1" json:"version"`
+	Project                  string                 `gorm:"type:text;not null;index:idx_credentials_project,where:deleted_at IS NULL;uniqueIndex:idx_credentials_project_key,priority:1" json:"project"`
+	Key                      string                 `gorm:"type:text;not null;uniqueIndex:idx_credentials_project_key,priority:2" json:"key"`
+	EncryptedSecret          []byte                 `gorm:"type:bytea;not null" json:"encrypted_secret"`
+	EncryptionKeyFingerprint string                 `gorm:"type:text;not null;index:idx_credentials_fingerprint,where:deleted_at IS NULL" json:"encryption_key_fingerprint"`
+	Scope                    string                 `gorm:"type:text" json:"scope,omitempty"`
+	EditedBy                 string                 `gorm:"type:text" json:"edited_by,omitempty"`
+	Access                   string                 `gorm:"type:text;not null;default:'reveal'" json:"access"`
+	AllowedProjects          models.JSONStringArray `gorm:"type:jsonb;not null;default:'[]'" json:"allowed_projects"`
+	AllowedKeycards          models.JSONStringArray `gorm:"type:jsonb;not null;default:'[]'" json:"allowed_keycards"`
+	CreatedAt                time.Time              `gorm:"type:timestamptz;not null;default:now()" json:"created_at"`
+	UpdatedAt                time.Time              `gorm:"type:timestamptz;not null;default:now()" json:"updated_at"`
+	DeletedAt                *time.Time             `gorm:"type:timestamptz" json:"deleted_at,omitempty"`
+	ID                       int64                  `gorm:"primaryKey;autoIncrement" json:"id"`
+	Version                  int                    `gorm:"not null;default:1" json:"version"`
 }
 
 func (Credential) TableName() string { return "credentials" }
 
+// CredentialAccessLog records one attempt to decrypt a vault credential
+// (migration 116). Denied attempts are logged too, with the reason. The
+// credential's project and key are copied so entries survive its deletion.
+type CredentialAccessLog struct {
+	CreatedAt         time.Time `gorm:"type:timestamptz;not null;default:now()" json:"created_at"`
+	CredentialProject string    `gorm:"type:text;not null" json:"credential_project"`
+	CredentialKey     string    `gorm:"type:text;not null" json:"credential_key"`
+	KeycardID         string    `gorm:"type:text;not null;default:''" json:"keycard_id,omitempty"`
+	Role              string    `gorm:"type:text;not null;default:''" json:"role,omitempty"`
+	CallerProject     string    `gorm:"type:text;not null;default:''" json:"caller_project,omitempty"`
+	SessionID         string    `gorm:"type:text;not null;default:''" json:"session_id,omitempty"`
+	Source            string    `gorm:"type:text;not null;default:''" json:"source"`
+	Reason            string    `gorm:"type:text;not null;default:''" json:"reason,omitempty"`
+	ID                int64     `gorm:"primaryKey;autoIncrement" json:"id"`
+	CredentialID      int64     `gorm:"not null" json:"credential_id"`
+	Allowed           bool      `gorm:"not null" json:"allowed"`
+}
+
+func (CredentialAccessLog) TableName() string { return "credential_access_log" }
+
+// CredentialDataKey is a project's credential data key wrapped by a KEK
+// (migration 117). Credentials of the project are encrypted with the data
+// key; Fingerprint matches their encryption_key_fingerprint.
+type CredentialDataKey struct {
+	CreatedAt   time.Time `gorm:"type:timestamptz;not null;default:now()" json:"created_at"`
+	RotatedAt   time.Time `gorm:"type:timestamptz;not null;default:now()" json:"rotated_at"`
+	Project     string    `gorm:"type:text;not null;uniqueIndex:idx_credential_data_keys_project" json:"project"`
+	KEKID       string    `gorm:"column:kek_id;type:text;not null;index:idx_credential_data_keys_kek" json:"kek_id"`
+	Fingerprint string    `gorm:"type:text;not null" json:"fingerprint"`
+	WrappedKey  []byte    `gorm:"type:bytea;not null" json:"-"`
+	ID          int64     `gorm:"primaryKey;autoIncrement" json:"id"`
+}
+
+func (CredentialDataKey) TableName() string { return "credential_data_keys" }
+
 // Memory is the GORM row struct for the memories table (migration 088).
 // Tags are stored as JSONB using models.JSONStringArray.
 // search_vector is a GENERATED ALWAYS AS STORED column — it must NOT appear in INSERT/UPDATE
@@ -310,15 +482,34 @@ type Memory struct {
 	Tags        models.JSONStringArray `gorm:"type:jsonb;not null;default:'[]'" json:"tags"`
 	SourceAgent string                 `gorm:"type:text" json:"source_agent,omitempty"`
 	EditedBy    string                 `gorm:"type:text" json:"edited_by,omitempty"`
+	MemoryType  string                 `gorm:"column:memory_type;type:text;not null" json:"type,omitempty"`
+	Rejected    models.JSONStringArray `gorm:"type:jsonb;not null;default:'[]'" json:"rejected,omitempty"`
 	CreatedAt   time.Time              `gorm:"type:timestamptz;not null;default:now();index:idx_memories_project_created,priority:2,sort:desc" json:"created_at"`
 	UpdatedAt   time.Time              `gorm:"type:timestamptz;not null;default:now()" json:"updated_at"`
 	DeletedAt   *time.Time             `gorm:"type:timestamptz" json:"deleted_at,omitempty"`
+	ExpiresAt   *time.Time             `gorm:"type:timestamptz" json:"expires_at,omitempty"`
 	ID          int64                  `gorm:"primaryKey;autoIncrement" json:"id"`
+	Importance  float64                `gorm:"type:double precision;not null" json:"importance"`
 	Version     int                    `gorm:"not null;default:1" json:"version"`
 }
 
 func (Memory) TableName() string { return "memories" }
 
+// MemoryVersion is the GORM row struct for the memory_versions table (migration 105).
+// One row per revision of a memory; (memory_id, version) is unique.
+type MemoryVersion struct {
+	Content   string                 `gorm:"type:text;not null" json:"content"`
+	Tags      models.JSONStringArray `gorm:"type:jsonb;not null;default:'[]'" json:"tags"`
+	EditedBy  string                 `gorm:"type:text" json:"edited_by,omitempty"`
+	Note      string                 `gorm:"type:text" json:"note,omitempty"`
+	CreatedAt time.Time              `gorm:"type:timestamptz;not null;default:now()" json:"created_at"`
+	ID        int64                  `gorm:"primaryKey;autoIncrement" json:"id"`
+	MemoryID  int64                  `gorm:"not null;uniqueIndex:idx_memory_versions_memory_version,priority:1" json:"memory_id"`
+	Version   int                    `gorm:"not null;uniqueIndex:idx_memory_versions_memory_version,priority:2" json:"version"`
+}
+
+func (MemoryVersion) TableName() string { return "memory_versions" }
+
 // BehavioralRule is the GORM row struct for the behavioral_rules table (migration 089).
 // Project is a pointer because the column is NULLable: NULL = global rule.
 type BehavioralRule struct {
@@ -6,6 +6,7 @@ import (
 	"database/sql"
 	"fmt"
 
+	"github.com/lib/pq"
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
 )
@@ -64,3 +65,40 @@ func ResolveProjectID(ctx context.Context, db *gorm.DB, projectID string) string
 	}
 	return canonicalID
 }
+
+// ExpandProjectAliases returns every identifier that refers to the same projects as
+// projectIDs: each input itself, plus the canonical ID and all legacy_ids of any
+// active project that matches an input by ID or by legacy alias. Rows keyed by
+// either a pre-hash slug or the canonical ID are therefore matched by one filter.
+// Order is not significant; duplicates are removed.
+func ExpandProjectAliases(ctx context.Context, db *gorm.DB, projectIDs []string) ([]string, error) {
+	if len(projectIDs) == 0 {
+		return nil, nil
+	}
+	var aliases []string
+	err := db.WithContext(ctx).Raw(`
+		SELECT DISTINCT alias FROM (
+			SELECT unnest(ARRAY[id] || COALESCE(legacy_ids, ARRAY[]::TEXT[])) AS alias
+			FROM projects
+			WHERE removed_at IS NULL
+			  AND (id = ANY(?) OR COALESCE(legacy_ids, ARRAY[]::TEXT[]) && ?::TEXT[])
+		) a`, pq.Array(projectIDs), pq.Array(projectIDs)).
+		Scan(&aliases).Error
+	if err != nil {
+		return nil, fmt.Errorf("expand project aliases: %w", err)
+	}
+
+	seen := make(map[string]struct{}, len(aliases)+len(projectIDs))
+	result := make([]string, 0, len(aliases)+len(projectIDs))
+	for _, id := range append(projectIDs, aliases...) {
+		if id == "" {
+			continue
+		}
+		if _, ok := seen[id]; ok {
+			continue
+		}
+		seen[id] = struct{}{}
+		result = append(result, id)
+	}
+	return result, nil
+}
@@ -0,0 +1,103 @@
+package gorm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrRoleInUse is returned by DeleteRole while users or live keycards are
+// assigned the role.
+var ErrRoleInUse = errors.New("role is assigned to users or keycards")
+
+// RoleStore manages custom roles. It does not validate role names or
+// permissions; that is the caller's job (see auth.BuiltinRoles and
+// auth.ValidPermission).
+type RoleStore struct {
+	db *gorm.DB
+}
+
+// NewRoleStore creates a new RoleStore.
+func NewRoleStore(db *gorm.DB) *RoleStore {
+	return &RoleStore{db: db}
+}
+
+// ListRoles returns every custom role ordered by name.
+func (s *RoleStore) ListRoles(ctx context.Context) ([]Role, error) {
+	roles := make([]Role, 0)
+	if err := s.db.WithContext(ctx).Order("name").Find(&roles).Error; err != nil {
+		return nil, fmt.Errorf("list roles: %w", err)
+	}
+	return roles, nil
+}
+
+// GetRole returns the custom role name, or gorm.ErrRecordNotFound.
+func (s *RoleStore) GetRole(ctx context.Context, name string) (*Role, error) {
+	var role Role
+	if err := s.db.WithContext(ctx).Where("name = ?", name).First(&role).Error; err != nil {
+		return nil, err
+	}
+	return &role, nil
+}
+
+// SaveRole creates role, or replaces the description and permissions of the
+// role with its name.
+func (s *RoleStore) SaveRole(ctx context.Context, role Role) (*Role, error) {
+	now := time.Now()
+	role.CreatedAt = now
+	role.UpdatedAt = now
+	if role.Permissions == nil {
+		role.Permissions = []string{}
+	}
+	err := s.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "name"}},
+		DoUpdates: clause.AssignmentColumns([]string{"description", "permissions", "updated_at"}),
+	}).Create(&role).Error
+	if err != nil {
+		return nil, fmt.Errorf("save role: %w", err)
+	}
+	return s.GetRole(ctx, role.Name)
+}
+
+// DeleteRole removes the custom role name. It returns ErrRoleInUse while a
+// user or a non-revoked keycard has the role, and gorm.ErrRecordNotFound
+// when there is no such role.
+func (s *RoleStore) DeleteRole(ctx context.Context, name string) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var users, tokens int64
+		if err := tx.Model(&User{}).Where("role = ?", name).Count(&users).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&APIToken{}).Where("scope = ? AND NOT revoked", name).Count(&tokens).Error; err != nil {
+			return err
+		}
+		if users > 0 || tokens > 0 {
+			return fmt.Errorf("%w: %d users, %d keycards", ErrRoleInUse, users, tokens)
+		}
+		result := tx.Where("name = ?", name).Delete(&Role{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return nil
+	})
+}
+
+// RolePermissions returns the permissions of the custom role name, and false
+// when there is no such role. It implements auth.RoleSource.
+func (s *RoleStore) RolePermissions(ctx context.Context, name string) ([]string, bool, error) {
+	role, err := s.GetRole(ctx, name)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return role.Permissions, true, nil
+}
@@ -0,0 +1,218 @@
+package gorm
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Token alert kinds reported in APITokenAlert.Kind.
+const (
+	TokenAlertUnused = "unused"
+	TokenAlertSpike  = "spike"
+)
+
+// tokenBaselineWeight is the weight of the latest check's rate in a token's
+// usage baseline (an exponentially weighted moving average).
+const tokenBaselineWeight = 0.2
+
+// minTokenUsageWindow is the shortest interval between two usage checks that
+// updates a token's rate. Shorter windows are too noisy to compare.
+const minTokenUsageWindow = time.Minute
+
+// TokenUsagePolicy configures CheckTokenUsage.
+type TokenUsagePolicy struct {
+	// UnusedAfter flags tokens not used for this long (or, never used, not
+	// since they were created). Zero disables the check.
+	UnusedAfter time.Duration
+	// SpikeFactor flags tokens whose request rate since the last check is at
+	// least this many times their baseline. Zero disables the check.
+	SpikeFactor float64
+	// SpikeMinRequests is the fewest requests since the last check that can
+	// count as a spike, so a quiet token's first few calls do not.
+	SpikeMinRequests int64
+}
+
+// CheckTokenUsage compares every live token's usage with policy as of now and
+// returns the alerts it raised. Live tokens are those not revoked, expired or
+// rotated. A token has at most one open alert of each kind: an unused alert
+// resolves itself once the token is used again, and a spike alert once its
+// rate falls back under the threshold. Each check also records the token's
+// request count and folds the rate since the previous check into its
+// baseline. Tokens locked by another transaction wait for the next check.
+func (s *TokenStore) CheckTokenUsage(ctx context.Context, policy TokenUsagePolicy, now time.Time) ([]APITokenAlert, error) {
+	raised := make([]APITokenAlert, 0)
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var tokens []APIToken
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("NOT revoked AND successor_id IS NULL AND (expires_at IS NULL OR expires_at > ?)", now).
+			Order("created_at").
+			Find(&tokens).Error; err != nil {
+			return err
+		}
+
+		var open []APITokenAlert
+		if err := tx.Where("resolved_at IS NULL").Find(&open).Error; err != nil {
+			return err
+		}
+		openAlerts := make(map[string]int64, len(open))
+		for _, a := range open {
+			openAlerts[a.TokenID+"/"+a.Kind] = a.ID
+		}
+
+		for i := range tokens {
+			t := &tokens[i]
+			if policy.UnusedAfter > 0 {
+				lastUsed := t.CreatedAt
+				if t.LastUsedAt != nil {
+					lastUsed = *t.LastUsedAt
+				}
+				idle := now.Sub(lastUsed)
+				alert := APITokenAlert{
+					TokenID:   t.ID,
+					TokenName: t.Name,
+					Kind:      TokenAlertUnused,
+					Message:   fmt.Sprintf("keycard %q has not been used for %d days", t.Name, int(idle/(24*time.Hour))),
+				}
+				if t.LastUsedAt == nil {
+					alert.Message = fmt.Sprintf("keycard %q has not been used since it was created %d days ago", t.Name, int(idle/(24*time.Hour)))
+				}
+				// A dismissed unused alert stays dismissed until the token
+				// is used again.
+				if err := applyTokenAlert(tx, openAlerts, idle >= policy.UnusedAfter, alert, &lastUsed, now, &raised); err != nil {
+					return err
+				}
+			}
+
+			updates := map[string]any{"usage_checked_at": now, "usage_checked_count": t.RequestCount}
+			if t.UsageCheckedAt == nil {
+				if err := tx.Model(&APIToken{}).Where("id = ?", t.ID).Updates(updates).Error; err != nil {
+					return err
+				}
+				continue
+			}
+			window := now.Sub(*t.UsageCheckedAt)
+			if window < minTokenUsageWindow {
+				continue
+			}
+			delta := t.RequestCount - t.UsageCheckedCount
+			rate := float64(max(delta, 0)) / window.Hours()
+			spike := isTokenUsageSpike(policy, delta, rate, t.UsageBaseline)
+			if policy.SpikeFactor > 0 {
+				alert := APITokenAlert{
+					TokenID:   t.ID,
+					TokenName: t.Name,
+					Kind:      TokenAlertSpike,
+					Rate:      rate,
+					Baseline:  t.UsageBaseline,
+				}
+				if spike {
+					alert.Message = fmt.Sprintf("keycard %q made %d requests in %s (%.1f/h), %.0fx its usual %.1f/h",
+						t.Name, delta, window.Round(time.Minute), rate, rate/t.UsageBaseline, t.UsageBaseline)
+				}
+				if err := applyTokenAlert(tx, openAlerts, spike, alert, nil, now, &raised); err != nil {
+					return err
+				}
+			}
+			// A spike stays out of the baseline, so a sustained burst keeps
+			// being measured against the token's usual rate.
+			if !spike {
+				updates["usage_baseline"] = nextTokenBaseline(t.UsageBaseline, rate)
+			}
+			if err := tx.Model(&APIToken{}).Where("id = ?", t.ID).Updates(updates).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("check token usage: %w", err)
+	}
+	return raised, nil
+}
+
+// applyTokenAlert opens alert when firing and no alert of its kind is open
+// for the token, and resolves the open one when not firing. With quietSince,
+// it is not reopened if one of its kind was already raised since then.
+func applyTokenAlert(tx *gorm.DB, openAlerts map[string]int64, firing bool, alert APITokenAlert, quietSince *time.Time, now time.Time, raised *[]APITokenAlert) error {
+	openID, isOpen := openAlerts[alert.TokenID+"/"+alert.Kind]
+	switch {
+	case firing && !isOpen:
+		if quietSince != nil {
+			var earlier int64
+			if err := tx.Model(&APITokenAlert{}).
+				Where("token_id = ? AND kind = ? AND created_at >= ?", alert.TokenID, alert.Kind, *quietSince).
+				Count(&earlier).Error; err != nil {
+				return err
+			}
+			if earlier > 0 {
+				return nil
+			}
+		}
+		alert.CreatedAt = now
+		if err := tx.Create(&alert).Error; err != nil {
+			return err
+		}
+		*raised = append(*raised, alert)
+	case !firing && isOpen:
+		return tx.Model(&APITokenAlert{}).Where("id = ?", openID).Update("resolved_at", now).Error
+	}
+	return nil
+}
+
+// isTokenUsageSpike reports whether delta requests at rate per hour are a
+// spike against a token's baseline. A token without a baseline yet has no
+// usual rate to exceed.
+func isTokenUsageSpike(policy TokenUsagePolicy, delta int64, rate, baseline float64) bool {
+	return policy.SpikeFactor > 0 &&
+		baseline > 0 &&
+		delta >= policy.SpikeMinRequests &&
+		rate >= policy.SpikeFactor*baseline
+}
+
+// nextTokenBaseline folds rate into a token's baseline. The first rate seeds
+// it.
+func nextTokenBaseline(baseline, rate float64) float64 {
+	if baseline <= 0 {
+		return rate
+	}
+	next := baseline + tokenBaselineWeight*(rate-baseline)
+	if math.IsNaN(next) || math.IsInf(next, 0) {
+		return baseline
+	}
+	return next
+}
+
+// ListTokenAlerts returns token alerts, newest first: only open ones unless
+// includeResolved, and at most limit.
+func (s *TokenStore) ListTokenAlerts(ctx context.Context, includeResolved bool, limit int) ([]APITokenAlert, error) {
+	q := s.db.WithContext(ctx).Order("created_at DESC, id DESC").Limit(limit)
+	if !includeResolved {
+		q = q.Where("resolved_at IS NULL")
+	}
+	alerts := make([]APITokenAlert, 0)
+	if err := q.Find(&alerts).Error; err != nil {
+		return nil, err
+	}
+	return alerts, nil
+}
+
+// ResolveTokenAlert marks an open alert resolved, e.g. when an admin
+// dismisses it. It returns gorm.ErrRecordNotFound when no open alert has id.
+func (s *TokenStore) ResolveTokenAlert(ctx context.Context, id int64) error {
+	result := s.db.WithContext(ctx).
+		Model(&APITokenAlert{}).
+		Where("id = ? AND resolved_at IS NULL", id).
+		Update("resolved_at", time.Now())
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
@@ -0,0 +1,22 @@
+package gorm
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsTokenUsageSpike(t *testing.T) {
+	policy := TokenUsagePolicy{SpikeFactor: 10, SpikeMinRequests: 50}
+	assert.True(t, isTokenUsageSpike(policy, 600, 600, 50))
+	assert.False(t, isTokenUsageSpike(policy, 400, 400, 50), "under the factor")
+	assert.False(t, isTokenUsageSpike(policy, 40, 40, 2), "too few requests")
+	assert.False(t, isTokenUsageSpike(policy, 600, 600, 0), "no baseline yet")
+	assert.False(t, isTokenUsageSpike(TokenUsagePolicy{}, 600, 600, 50), "spike check disabled")
+}
+
+func TestNextTokenBaseline(t *testing.T) {
+	assert.Equal(t, 30.0, nextTokenBaseline(0, 30), "the first rate seeds the baseline")
+	assert.InDelta(t, 12.0, nextTokenBaseline(10, 20), 1e-9)
+	assert.InDelta(t, 8.0, nextTokenBaseline(10, 0), 1e-9)
+}
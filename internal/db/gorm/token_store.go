@@ -3,9 +3,14 @@ package gorm
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/thebtf/engram/pkg/models"
 )
 
 // TokenStore provides API token database operations using GORM.
@@ -18,13 +23,21 @@ func NewTokenStore(store *Store) *TokenStore {
 	return &TokenStore{db: store.DB}
 }
 
-// Create stores a new API token record.
-func (s *TokenStore) Create(ctx context.Context, name, tokenHash, tokenPrefix, scope string) (*APIToken, error) {
+// ErrTokenNotRotatable is returned by Rotate for a token that has expired or
+// already been rotated.
+var ErrTokenNotRotatable = errors.New("token is expired or already rotated")
+
+// Create stores a new API token record. allowedProjects restricts the token
+// to those project IDs and glob patterns; nil leaves it unrestricted.
+// expiresAt, when set, is when the validator stops accepting the token.
+func (s *TokenStore) Create(ctx context.Context, name, tokenHash, tokenPrefix, scope string, allowedProjects []string, expiresAt *time.Time) (*APIToken, error) {
 	token := &APIToken{
-		Name:        name,
-		TokenHash:   tokenHash,
-		TokenPrefix: tokenPrefix,
-		Scope:       scope,
+		Name:            name,
+		TokenHash:       tokenHash,
+		TokenPrefix:     tokenPrefix,
+		Scope:           scope,
+		AllowedProjects: allowedProjects,
+		ExpiresAt:       expiresAt,
 	}
 
 	if err := s.db.WithContext(ctx).Create(token).Error; err != nil {
@@ -76,6 +89,92 @@ func (s *TokenStore) Revoke(ctx context.Context, id string) error {
 	return result.Error
 }
 
+// SetAllowedProjects replaces a token's project allowlist. An empty list
+// makes the token unrestricted. The change applies to the token's next
+// request: the validator reads the allowlist on every lookup.
+func (s *TokenStore) SetAllowedProjects(ctx context.Context, id string, projects []string) error {
+	result := s.db.WithContext(ctx).
+		Model(&APIToken{}).
+		Where("id = ?", id).
+		Update("allowed_projects", models.JSONStringArray(projects))
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// SetScope changes a token's role. Like the allowlist, it applies to the
+// token's next request.
+func (s *TokenStore) SetScope(ctx context.Context, id, scope string) error {
+	result := s.db.WithContext(ctx).
+		Model(&APIToken{}).
+		Where("id = ? AND NOT revoked", id).
+		Update("scope", scope)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// Rotate replaces token id with a successor that has the same name, scope and
+// project allowlist and the given hash, prefix and expiry. The old token
+// keeps working for grace and then expires; it is renamed with its prefix
+// ("ci (rotated engram_1a2b3c4d)") so the successor can take its name. It
+// returns the successor and the old token as updated. A revoked token is not
+// found; an expired or already rotated one yields ErrTokenNotRotatable.
+func (s *TokenStore) Rotate(ctx context.Context, id, tokenHash, tokenPrefix string, grace time.Duration, expiresAt *time.Time) (*APIToken, *APIToken, error) {
+	var successor, old APIToken
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND NOT revoked", id).
+			First(&old).Error; err != nil {
+			return err
+		}
+		now := time.Now()
+		if old.SuccessorID != nil || (old.ExpiresAt != nil && !now.Before(*old.ExpiresAt)) {
+			return ErrTokenNotRotatable
+		}
+
+		graceEnd := now.Add(grace)
+		if old.ExpiresAt != nil && old.ExpiresAt.Before(graceEnd) {
+			graceEnd = *old.ExpiresAt
+		}
+		name := old.Name
+		if err := tx.Model(&APIToken{}).Where("id = ?", old.ID).Updates(map[string]any{
+			"name":       fmt.Sprintf("%s (rotated engram_%s)", name, old.TokenPrefix),
+			"expires_at": graceEnd,
+		}).Error; err != nil {
+			return err
+		}
+
+		successor = APIToken{
+			Name:            name,
+			TokenHash:       tokenHash,
+			TokenPrefix:     tokenPrefix,
+			Scope:           old.Scope,
+			AllowedProjects: old.AllowedProjects,
+			ExpiresAt:       expiresAt,
+		}
+		if err := tx.Create(&successor).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&APIToken{}).Where("id = ?", old.ID).Update("successor_id", successor.ID).Error; err != nil {
+			return err
+		}
+		return tx.First(&old, "id = ?", old.ID).Error
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return &successor, &old, nil
+}
+
 // IncrementStats increments request_count and updates last_used_at for a token.
 func (s *TokenStore) IncrementStats(ctx context.Context, id string) error {
 	return s.db.WithContext(ctx).
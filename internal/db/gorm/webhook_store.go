@@ -0,0 +1,344 @@
+package gorm
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/thebtf/engram/pkg/models"
+)
+
+// WebhookEventTypes are the event types a webhook can subscribe to; "*"
+// subscribes to all of them.
+var WebhookEventTypes = []string{"issue.created", "issue.resolved", "issue.escalated", "memory.created", "rule.updated", "project.removed"}
+
+// Webhook delivery statuses.
+const (
+	WebhookDeliveryPending   = "pending"
+	WebhookDeliveryDelivered = "delivered"
+	WebhookDeliveryFailed    = "failed"
+)
+
+// WebhookStore manages webhook endpoints and their delivery queue.
+type WebhookStore struct {
+	db *gorm.DB
+}
+
+// NewWebhookStore creates a new WebhookStore.
+func NewWebhookStore(db *gorm.DB) *WebhookStore {
+	return &WebhookStore{db: db}
+}
+
+// WebhookUpdate holds the mutable fields of a webhook; nil fields are left
+// unchanged.
+type WebhookUpdate struct {
+	URL     *string
+	Project *string
+	Enabled *bool
+	Events  []string
+}
+
+// WebhookDeliveryJob is a claimed delivery with the endpoint to post it to.
+type WebhookDeliveryJob struct {
+	WebhookDelivery
+	URL    string `gorm:"column:url"`
+	Secret string `gorm:"column:secret"`
+}
+
+// WebhookAttempt is the outcome of one delivery attempt. A failed attempt
+// with a nil NextAttemptAt gives the delivery up.
+type WebhookAttempt struct {
+	At             time.Time
+	NextAttemptAt  *time.Time
+	Error          string
+	ResponseStatus int
+	Delivered      bool
+}
+
+// WebhookDeliveryListParams filters ListWebhookDeliveries. Zero values do not
+// filter.
+type WebhookDeliveryListParams struct {
+	Status    string
+	WebhookID int64
+	Limit     int
+}
+
+// validateWebhookURL accepts absolute http and https URLs.
+func validateWebhookURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid webhook url %q: must be an absolute http or https URL", raw)
+	}
+	return nil
+}
+
+// validateWebhookEvents requires at least one known event type or "*".
+func validateWebhookEvents(events []string) error {
+	if len(events) == 0 {
+		return fmt.Errorf("webhook events are required (one or more of %s, or *)", strings.Join(WebhookEventTypes, ", "))
+	}
+	for _, event := range events {
+		if event == "*" {
+			continue
+		}
+		known := false
+		for _, t := range WebhookEventTypes {
+			if event == t {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return fmt.Errorf("invalid webhook event %q: must be one of %s, or *", event, strings.Join(WebhookEventTypes, ", "))
+		}
+	}
+	return nil
+}
+
+// generateWebhookSecret returns a random signing secret.
+func generateWebhookSecret() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("generate webhook secret: %w", err)
+	}
+	return "whsec_" + hex.EncodeToString(buf), nil
+}
+
+// CreateWebhook registers an endpoint. When w.Secret is empty a random secret
+// is generated; the returned webhook carries it so the caller can show it once.
+func (s *WebhookStore) CreateWebhook(ctx context.Context, w *Webhook) (*Webhook, error) {
+	name := strings.TrimSpace(w.Name)
+	if name == "" {
+		return nil, fmt.Errorf("webhook name is required")
+	}
+	if err := validateWebhookURL(w.URL); err != nil {
+		return nil, err
+	}
+	if err := validateWebhookEvents(w.Events); err != nil {
+		return nil, err
+	}
+	secret := w.Secret
+	if secret == "" {
+		var err error
+		if secret, err = generateWebhookSecret(); err != nil {
+			return nil, err
+		}
+	}
+
+	now := time.Now()
+	created := Webhook{
+		Name:      name,
+		URL:       w.URL,
+		Secret:    secret,
+		Events:    w.Events,
+		Project:   w.Project,
+		Enabled:   true,
+		CreatedBy: w.CreatedBy,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := s.db.WithContext(ctx).Create(&created).Error; err != nil {
+		if strings.Contains(err.Error(), "duplicate key") {
+			return nil, fmt.Errorf("webhook %q already exists", name)
+		}
+		return nil, fmt.Errorf("create webhook: %w", err)
+	}
+	return &created, nil
+}
+
+// ListWebhooks returns all webhooks ordered by name.
+func (s *WebhookStore) ListWebhooks(ctx context.Context) ([]Webhook, error) {
+	webhooks := make([]Webhook, 0)
+	if err := s.db.WithContext(ctx).Order("name").Find(&webhooks).Error; err != nil {
+		return nil, fmt.Errorf("list webhooks: %w", err)
+	}
+	return webhooks, nil
+}
+
+// GetWebhook returns the webhook with the given ID.
+func (s *WebhookStore) GetWebhook(ctx context.Context, id int64) (*Webhook, error) {
+	var w Webhook
+	if err := s.db.WithContext(ctx).First(&w, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("webhook %d not found", id)
+		}
+		return nil, fmt.Errorf("get webhook: %w", err)
+	}
+	return &w, nil
+}
+
+// UpdateWebhook changes the URL, event filter, project filter or enabled flag
+// of a webhook and returns the result.
+func (s *WebhookStore) UpdateWebhook(ctx context.Context, id int64, update WebhookUpdate) (*Webhook, error) {
+	updates := map[string]any{"updated_at": time.Now()}
+	if update.URL != nil {
+		if err := validateWebhookURL(*update.URL); err != nil {
+			return nil, err
+		}
+		updates["url"] = *update.URL
+	}
+	if update.Events != nil {
+		if err := validateWebhookEvents(update.Events); err != nil {
+			return nil, err
+		}
+		updates["events"] = models.JSONStringArray(update.Events)
+	}
+	if update.Project != nil {
+		updates["project"] = *update.Project
+	}
+	if update.Enabled != nil {
+		updates["enabled"] = *update.Enabled
+	}
+
+	result := s.db.WithContext(ctx).Model(&Webhook{}).Where("id = ?", id).Updates(updates)
+	if result.Error != nil {
+		return nil, fmt.Errorf("update webhook: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return nil, fmt.Errorf("webhook %d not found", id)
+	}
+	return s.GetWebhook(ctx, id)
+}
+
+// DeleteWebhook removes a webhook and its delivery log.
+func (s *WebhookStore) DeleteWebhook(ctx context.Context, id int64) error {
+	result := s.db.WithContext(ctx).Delete(&Webhook{}, id)
+	if result.Error != nil {
+		return fmt.Errorf("delete webhook: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("webhook %d not found", id)
+	}
+	return nil
+}
+
+// EnqueueWebhookEvent queues payload for every enabled webhook subscribed to
+// eventType whose project filter is empty or equals project, and returns the
+// number of deliveries queued.
+func (s *WebhookStore) EnqueueWebhookEvent(ctx context.Context, eventType, project, eventID string, payload []byte) (int64, error) {
+	now := time.Now()
+	result := s.db.WithContext(ctx).Exec(`
+		INSERT INTO webhook_deliveries (webhook_id, event_id, event_type, payload, status, next_attempt_at, created_at)
+		SELECT id, ?, ?, CAST(? AS jsonb), 'pending', ?, ?
+		FROM webhooks
+		WHERE enabled
+		  AND (jsonb_exists(events, ?) OR jsonb_exists(events, '*'))
+		  AND (project = '' OR project = ?)`,
+		eventID, eventType, string(payload), now, now, eventType, project)
+	if result.Error != nil {
+		return 0, fmt.Errorf("enqueue webhook event: %w", result.Error)
+	}
+	return result.RowsAffected, nil
+}
+
+// ClaimDueWebhookDeliveries returns up to limit pending deliveries that are
+// due at now and pushes their next attempt lease into the future, so a
+// concurrent deliverer skips them and a crash mid-delivery retries them once
+// the lease passes.
+func (s *WebhookStore) ClaimDueWebhookDeliveries(ctx context.Context, now time.Time, limit int, lease time.Duration) ([]WebhookDeliveryJob, error) {
+	jobs := make([]WebhookDeliveryJob, 0)
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Raw(`
+			SELECT d.*, w.url, w.secret
+			FROM webhook_deliveries d
+			JOIN webhooks w ON w.id = d.webhook_id
+			WHERE d.status = 'pending' AND d.next_attempt_at <= ?
+			ORDER BY d.next_attempt_at, d.id
+			LIMIT ?
+			FOR UPDATE OF d SKIP LOCKED`, now, limit).
+			Scan(&jobs).Error; err != nil {
+			return err
+		}
+		if len(jobs) == 0 {
+			return nil
+		}
+		ids := make([]int64, len(jobs))
+		for i, job := range jobs {
+			ids[i] = job.ID
+		}
+		return tx.Model(&WebhookDelivery{}).Where("id IN ?", ids).Update("next_attempt_at", now.Add(lease)).Error
+	})
+	if err != nil {
+		return nil, fmt.Errorf("claim webhook deliveries: %w", err)
+	}
+	return jobs, nil
+}
+
+// RecordWebhookAttempt stores the outcome of a delivery attempt.
+func (s *WebhookStore) RecordWebhookAttempt(ctx context.Context, id int64, attempt WebhookAttempt) error {
+	updates := map[string]any{
+		"attempts":        gorm.Expr("attempts + 1"),
+		"last_attempt_at": attempt.At,
+		"response_status": attempt.ResponseStatus,
+		"last_error":      attempt.Error,
+	}
+	switch {
+	case attempt.Delivered:
+		updates["status"] = WebhookDeliveryDelivered
+		updates["delivered_at"] = attempt.At
+	case attempt.NextAttemptAt == nil:
+		updates["status"] = WebhookDeliveryFailed
+	default:
+		updates["next_attempt_at"] = *attempt.NextAttemptAt
+	}
+
+	result := s.db.WithContext(ctx).Model(&WebhookDelivery{}).Where("id = ?", id).Updates(updates)
+	if result.Error != nil {
+		return fmt.Errorf("record webhook attempt: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("webhook delivery %d not found", id)
+	}
+	return nil
+}
+
+// ListWebhookDeliveries returns the delivery log, newest first.
+func (s *WebhookStore) ListWebhookDeliveries(ctx context.Context, params WebhookDeliveryListParams) ([]WebhookDelivery, error) {
+	query := s.db.WithContext(ctx).Model(&WebhookDelivery{})
+	if params.WebhookID > 0 {
+		query = query.Where("webhook_id = ?", params.WebhookID)
+	}
+	if params.Status != "" {
+		query = query.Where("status = ?", params.Status)
+	}
+	limit := params.Limit
+	if limit <= 0 || limit > 500 {
+		limit = 100
+	}
+
+	deliveries := make([]WebhookDelivery, 0)
+	if err := query.Order("created_at DESC, id DESC").Limit(limit).Find(&deliveries).Error; err != nil {
+		return nil, fmt.Errorf("list webhook deliveries: %w", err)
+	}
+	return deliveries, nil
+}
+
+// RetryWebhookDelivery puts a failed delivery back in the queue for an
+// immediate attempt with a fresh attempt budget.
+func (s *WebhookStore) RetryWebhookDelivery(ctx context.Context, id int64) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var delivery WebhookDelivery
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id", "status").First(&delivery, id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("webhook delivery %d not found", id)
+			}
+			return fmt.Errorf("retry webhook delivery: %w", err)
+		}
+		if delivery.Status != WebhookDeliveryFailed {
+			return fmt.Errorf("webhook delivery %d is %s — only failed deliveries can be retried", id, delivery.Status)
+		}
+		return tx.Model(&WebhookDelivery{}).Where("id = ?", id).Updates(map[string]any{
+			"status":          WebhookDeliveryPending,
+			"attempts":        0,
+			"next_attempt_at": time.Now(),
+		}).Error
+	})
+}
@@ -0,0 +1,113 @@
+// Package embedding turns text into dense vectors for semantic memory search.
+//
+// The default backend is Local, a deterministic hashed n-gram embedder that runs
+// in-process with no model download, GPU or network access. An OpenAI-compatible
+// HTTP backend (OpenAI, Ollama, LM Studio, vLLM, ...) can be selected instead via
+// ENGRAM_EMBEDDING_PROVIDER=openai. Vectors from different backends are not
+// comparable, so every stored vector records the Name of the embedder that made it.
+package embedding
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/thebtf/engram/internal/config"
+)
+
+// Provider names accepted by New (config.EmbeddingProvider).
+const (
+	ProviderLocal  = "local"
+	ProviderOpenAI = "openai"
+	ProviderNone   = "none"
+)
+
+// Embedder converts texts to vectors.
+//
+// Implementations must be safe for concurrent use and return one vector per
+// input text, in input order. Vectors are L2-normalised so that the dot product
+// of two vectors equals their cosine similarity; an empty text may map to the
+// zero vector.
+type Embedder interface {
+	// Name identifies the model and its parameters, e.g. "local-ngram-v1/384".
+	// Vectors produced under different names must never be compared.
+	Name() string
+	// Dimensions is the length of every returned vector.
+	Dimensions() int
+	// Embed returns the vectors for texts.
+	Embed(ctx context.Context, texts []string) ([][]float32, error)
+}
+
+// New builds the embedder selected by cfg. It returns (nil, nil) when
+// embeddings are disabled (provider "none"), in which case callers fall back
+// to full-text search only.
+func New(cfg *config.Config) (Embedder, error) {
+	switch provider := strings.ToLower(strings.TrimSpace(cfg.EmbeddingProvider)); provider {
+	case "", ProviderLocal:
+		return NewLocal(cfg.EmbeddingDimensions), nil
+	case ProviderOpenAI:
+		e, err := NewOpenAI(OpenAIConfig{
+			BaseURL:    cfg.EmbeddingBaseURL,
+			APIKey:     cfg.EmbeddingAPIKey,
+			Model:      cfg.EmbeddingModel,
+			Dimensions: cfg.EmbeddingDimensions,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return e, nil
+	case ProviderNone, "off", "disabled":
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("unknown embedding provider %q (valid: %s, %s, %s)",
+			provider, ProviderLocal, ProviderOpenAI, ProviderNone)
+	}
+}
+
+// EmbedOne embeds a single text.
+func EmbedOne(ctx context.Context, e Embedder, text string) ([]float32, error) {
+	vectors, err := e.Embed(ctx, []string{text})
+	if err != nil {
+		return nil, err
+	}
+	if len(vectors) != 1 {
+		return nil, fmt.Errorf("embed: expected 1 vector, got %d", len(vectors))
+	}
+	return vectors[0], nil
+}
+
+// Cosine returns the cosine similarity of a and b, or 0 when the lengths differ
+// or either vector is zero. It matches pgvector's 1 - (a <=> b), so in-process
+// ranking agrees with the database's.
+func Cosine(a, b []float32) float64 {
+	if len(a) != len(b) || len(a) == 0 {
+		return 0
+	}
+	var dot, na, nb float64
+	for i := range a {
+		x, y := float64(a[i]), float64(b[i])
+		dot += x * y
+		na += x * x
+		nb += y * y
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(na) * math.Sqrt(nb))
+}
+
+// normalize scales v to unit length in place. The zero vector is left as is.
+func normalize(v []float32) {
+	var sum float64
+	for _, x := range v {
+		sum += float64(x) * float64(x)
+	}
+	if sum == 0 {
+		return
+	}
+	inv := 1 / math.Sqrt(sum)
+	for i := range v {
+		v[i] = float32(float64(v[i]) * inv)
+	}
+}
@@ -0,0 +1,132 @@
+package embedding
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"strings"
+	"unicode"
+)
+
+// DefaultLocalDimensions is the vector length of the local embedder when none is
+// configured. 384 matches the historical vectors table and keeps a vector at 1.5 KiB.
+const DefaultLocalDimensions = 384
+
+// localModelVersion is bumped whenever feature extraction changes, so vectors
+// from an older version are re-embedded instead of compared.
+const localModelVersion = "local-ngram-v1"
+
+// Feature weights. Whole words carry most of the signal; word bigrams reward
+// shared phrasing and character trigrams catch inflections and identifiers
+// split differently ("rate-limit" vs "ratelimiter").
+const (
+	localWordWeight    = 1.0
+	localBigramWeight  = 0.5
+	localTrigramWeight = 0.35
+)
+
+// localStopWords carry no topical signal and are skipped as unigrams. This is
+// the local stand-in for IDF: a corpus-fitted IDF would change every stored
+// vector as memories are added, while a fixed list keeps vectors reproducible.
+var localStopWords = map[string]struct{}{
+	"a": {}, "an": {}, "and": {}, "are": {}, "as": {}, "at": {}, "be": {}, "but": {},
+	"by": {}, "for": {}, "from": {}, "has": {}, "have": {}, "if": {}, "in": {}, "into": {},
+	"is": {}, "it": {}, "its": {}, "not": {}, "of": {}, "on": {}, "or": {}, "so": {},
+	"that": {}, "the": {}, "then": {}, "there": {}, "this": {}, "to": {}, "was": {},
+	"we": {}, "were": {}, "when": {}, "which": {}, "will": {}, "with": {}, "you": {},
+}
+
+// Local is a deterministic hashed n-gram embedder.
+//
+// Each text is split into lower-cased words; words, adjacent word pairs and the
+// character trigrams of each word are hashed (FNV-1a) into Dimensions buckets
+// with a hash-derived sign, weighted by sublinear term frequency (1 + ln tf),
+// and the result is L2-normalised. The same text always yields the same vector,
+// on every machine, so vectors can be stored and compared across restarts.
+type Local struct {
+	dims int
+}
+
+// NewLocal creates a Local embedder. dims <= 0 selects DefaultLocalDimensions.
+func NewLocal(dims int) *Local {
+	if dims <= 0 {
+		dims = DefaultLocalDimensions
+	}
+	return &Local{dims: dims}
+}
+
+// Name implements Embedder.
+func (l *Local) Name() string {
+	return fmt.Sprintf("%s/%d", localModelVersion, l.dims)
+}
+
+// Dimensions implements Embedder.
+func (l *Local) Dimensions() int {
+	return l.dims
+}
+
+// Embed implements Embedder. It never fails except on context cancellation.
+func (l *Local) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	vectors := make([][]float32, len(texts))
+	for i, text := range texts {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		vectors[i] = l.embed(text)
+	}
+	return vectors, nil
+}
+
+func (l *Local) embed(text string) []float32 {
+	type feature struct {
+		key    string
+		weight float64
+	}
+	// Features are kept in first-seen order so the floating-point sums below
+	// are accumulated identically on every run.
+	tf := make(map[feature]int)
+	var order []feature
+	add := func(f feature) {
+		if tf[f] == 0 {
+			order = append(order, f)
+		}
+		tf[f]++
+	}
+	words := localWords(text)
+	for i, word := range words {
+		if _, stop := localStopWords[word]; !stop {
+			add(feature{"w:" + word, localWordWeight})
+		}
+		if i > 0 {
+			add(feature{"b:" + words[i-1] + " " + word, localBigramWeight})
+		}
+		padded := []rune("^" + word + "$")
+		for j := 0; j+3 <= len(padded); j++ {
+			add(feature{"c:" + string(padded[j:j+3]), localTrigramWeight})
+		}
+	}
+
+	v := make([]float32, l.dims)
+	for _, f := range order {
+		n := tf[f]
+		h := fnv.New64a()
+		_, _ = h.Write([]byte(f.key))
+		sum := h.Sum64()
+		value := f.weight * (1 + math.Log(float64(n)))
+		if sum>>63 == 1 {
+			value = -value
+		}
+		v[sum%uint64(l.dims)] += float32(value)
+	}
+	normalize(v)
+	return v
+}
+
+// localWords splits text into lower-cased words of letters and digits.
+// Underscores split too, so snake_case identifiers share words with prose.
+func localWords(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
@@ -0,0 +1,109 @@
+package embedding
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/thebtf/engram/internal/config"
+)
+
+func TestLocal_DeterministicAndNormalised(t *testing.T) {
+	t.Parallel()
+
+	e := NewLocal(0)
+	assert.Equal(t, DefaultLocalDimensions, e.Dimensions())
+	assert.Equal(t, "local-ngram-v1/384", e.Name())
+
+	ctx := context.Background()
+	text := "Postgres advisory locks serialise the migration runner"
+	a, err := EmbedOne(ctx, e, text)
+	require.NoError(t, err)
+	b, err := EmbedOne(ctx, NewLocal(384), text)
+	require.NoError(t, err)
+
+	require.Len(t, a, DefaultLocalDimensions)
+	assert.Equal(t, a, b, "same text must produce the same vector")
+
+	var norm float64
+	for _, x := range a {
+		norm += float64(x) * float64(x)
+	}
+	assert.InDelta(t, 1.0, math.Sqrt(norm), 1e-5)
+}
+
+func TestLocal_RanksRelatedTextHigher(t *testing.T) {
+	t.Parallel()
+
+	e := NewLocal(512)
+	vectors, err := e.Embed(context.Background(), []string{
+		"rate limiter drops requests when the token bucket is empty",
+		"the ratelimiter rejects requests once its token bucket runs empty",
+		"dashboard colours follow the brand palette",
+	})
+	require.NoError(t, err)
+	require.Len(t, vectors, 3)
+
+	related := Cosine(vectors[0], vectors[1])
+	unrelated := Cosine(vectors[0], vectors[2])
+	assert.Greater(t, related, 0.4)
+	assert.Less(t, unrelated, 0.2)
+	assert.Greater(t, related, unrelated)
+}
+
+func TestLocal_EmptyTextIsZeroVector(t *testing.T) {
+	t.Parallel()
+
+	v, err := EmbedOne(context.Background(), NewLocal(16), "  ... ")
+	require.NoError(t, err)
+	assert.Equal(t, make([]float32, 16), v)
+	assert.Zero(t, Cosine(v, v))
+}
+
+func TestLocal_CancelledContext(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := NewLocal(0).Embed(ctx, []string{"text"})
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestCosine(t *testing.T) {
+	t.Parallel()
+
+	assert.InDelta(t, 1.0, Cosine([]float32{1, 2, 3}, []float32{2, 4, 6}), 1e-9)
+	assert.InDelta(t, 0.0, Cosine([]float32{1, 0}, []float32{0, 1}), 1e-9)
+	assert.InDelta(t, -1.0, Cosine([]float32{1, 0}, []float32{-1, 0}), 1e-9)
+	assert.Zero(t, Cosine([]float32{1}, []float32{1, 2}), "length mismatch")
+	assert.Zero(t, Cosine(nil, nil))
+}
+
+func TestNew_Providers(t *testing.T) {
+	t.Parallel()
+
+	e, err := New(&config.Config{})
+	require.NoError(t, err)
+	assert.IsType(t, &Local{}, e, "empty provider defaults to local")
+
+	e, err = New(&config.Config{EmbeddingProvider: "LOCAL", EmbeddingDimensions: 128})
+	require.NoError(t, err)
+	assert.Equal(t, 128, e.Dimensions())
+
+	e, err = New(&config.Config{EmbeddingProvider: "openai", EmbeddingBaseURL: "http://localhost:11434/v1", EmbeddingModel: "nomic-embed-text"})
+	require.NoError(t, err)
+	assert.Equal(t, "openai:nomic-embed-text", e.Name())
+
+	e, err = New(&config.Config{EmbeddingProvider: "none"})
+	require.NoError(t, err)
+	assert.Nil(t, e)
+
+	_, err = New(&config.Config{EmbeddingProvider: "word2vec"})
+	assert.ErrorContains(t, err, "unknown embedding provider")
+
+	_, err = New(&config.Config{EmbeddingProvider: "openai", EmbeddingBaseURL: "localhost:11434"})
+	assert.ErrorContains(t, err, "must start with http")
+}
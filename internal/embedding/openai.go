@@ -0,0 +1,163 @@
+package embedding
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// Defaults for the OpenAI-compatible backend.
+const (
+	DefaultOpenAIBaseURL = "https://api.openai.com/v1"
+	DefaultOpenAIModel   = "text-embedding-3-small"
+
+	openAIRequestTimeout = 30 * time.Second
+	// openAIMaxErrorBody caps how much of an error response is quoted back.
+	openAIMaxErrorBody = 512
+)
+
+// OpenAIConfig configures an OpenAI-compatible /embeddings endpoint.
+// Dimensions is sent as the "dimensions" request field when > 0 (models that do
+// not support shortening ignore or reject it); when 0 it is learned from the
+// first response.
+type OpenAIConfig struct {
+	HTTPClient *http.Client
+	BaseURL    string
+	APIKey     string
+	Model      string
+	Dimensions int
+}
+
+// OpenAI embeds texts through an OpenAI-compatible HTTP API
+// (POST {BaseURL}/embeddings). Works with OpenAI, Azure-style proxies, Ollama,
+// LM Studio and vLLM.
+type OpenAI struct {
+	client    *http.Client
+	endpoint  string
+	apiKey    string
+	model     string
+	requested int
+	dims      atomic.Int64
+}
+
+// NewOpenAI validates cfg and creates an OpenAI embedder. Missing BaseURL and
+// Model fall back to DefaultOpenAIBaseURL and DefaultOpenAIModel; the API key is
+// optional because local servers usually do not need one.
+func NewOpenAI(cfg OpenAIConfig) (*OpenAI, error) {
+	baseURL := strings.TrimRight(strings.TrimSpace(cfg.BaseURL), "/")
+	if baseURL == "" {
+		baseURL = DefaultOpenAIBaseURL
+	}
+	if !strings.HasPrefix(baseURL, "http://") && !strings.HasPrefix(baseURL, "https://") {
+		return nil, fmt.Errorf("embedding base URL %q: must start with http:// or https://", baseURL)
+	}
+	if cfg.Dimensions < 0 {
+		return nil, fmt.Errorf("embedding dimensions must not be negative")
+	}
+	model := strings.TrimSpace(cfg.Model)
+	if model == "" {
+		model = DefaultOpenAIModel
+	}
+	client := cfg.HTTPClient
+	if client == nil {
+		client = &http.Client{Timeout: openAIRequestTimeout}
+	}
+	e := &OpenAI{
+		client:    client,
+		endpoint:  baseURL + "/embeddings",
+		apiKey:    cfg.APIKey,
+		model:     model,
+		requested: cfg.Dimensions,
+	}
+	e.dims.Store(int64(cfg.Dimensions))
+	return e, nil
+}
+
+// Name implements Embedder.
+func (e *OpenAI) Name() string {
+	if e.requested > 0 {
+		return fmt.Sprintf("openai:%s/%d", e.model, e.requested)
+	}
+	return "openai:" + e.model
+}
+
+// Dimensions implements Embedder. It is 0 until the first successful call when
+// no dimensions were configured.
+func (e *OpenAI) Dimensions() int {
+	return int(e.dims.Load())
+}
+
+type openAIEmbeddingRequest struct {
+	Model      string   `json:"model"`
+	Input      []string `json:"input"`
+	Dimensions int      `json:"dimensions,omitempty"`
+}
+
+type openAIEmbeddingResponse struct {
+	Data []struct {
+		Embedding []float32 `json:"embedding"`
+		Index     int       `json:"index"`
+	} `json:"data"`
+}
+
+// Embed implements Embedder. All texts are sent in one request; results are
+// placed by their "index" field and normalised.
+func (e *OpenAI) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	if len(texts) == 0 {
+		return [][]float32{}, nil
+	}
+	body, err := json.Marshal(openAIEmbeddingRequest{Model: e.model, Input: texts, Dimensions: e.requested})
+	if err != nil {
+		return nil, fmt.Errorf("marshal embedding request: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("create embedding request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if e.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+e.apiKey)
+	}
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("embedding request: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, openAIMaxErrorBody))
+		return nil, fmt.Errorf("embedding request: %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+
+	var decoded openAIEmbeddingResponse
+	if err := json.NewDecoder(resp.Body).Decode(&decoded); err != nil {
+		return nil, fmt.Errorf("decode embedding response: %w", err)
+	}
+	if len(decoded.Data) != len(texts) {
+		return nil, fmt.Errorf("embedding response: expected %d vectors, got %d", len(texts), len(decoded.Data))
+	}
+
+	dims := e.Dimensions()
+	vectors := make([][]float32, len(texts))
+	for _, item := range decoded.Data {
+		if item.Index < 0 || item.Index >= len(texts) || vectors[item.Index] != nil {
+			return nil, fmt.Errorf("embedding response: invalid index %d", item.Index)
+		}
+		if dims == 0 {
+			dims = len(item.Embedding)
+		}
+		if len(item.Embedding) != dims || dims == 0 {
+			return nil, fmt.Errorf("embedding response: expected %d dimensions, got %d", dims, len(item.Embedding))
+		}
+		normalize(item.Embedding)
+		vectors[item.Index] = item.Embedding
+	}
+	e.dims.CompareAndSwap(0, int64(dims))
+	return vectors, nil
+}
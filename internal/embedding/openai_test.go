@@ -0,0 +1,95 @@
+package embedding
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpenAI_Embed(t *testing.T) {
+	t.Parallel()
+
+	var got openAIEmbeddingRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/embeddings", r.URL.Path)
+		assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+		// Out of order on purpose: results must be placed by index.
+		_, _ = w.Write([]byte(`{"data":[
+			{"index":1,"embedding":[0,3,4]},
+			{"index":0,"embedding":[2,0,0]}
+		]}`))
+	}))
+	defer srv.Close()
+
+	e, err := NewOpenAI(OpenAIConfig{BaseURL: srv.URL + "/v1/", APIKey: "secret", Model: "tiny"})
+	require.NoError(t, err)
+	assert.Zero(t, e.Dimensions(), "dimensions are learned from the first response")
+
+	vectors, err := e.Embed(context.Background(), []string{"first", "second"})
+	require.NoError(t, err)
+
+	assert.Equal(t, "tiny", got.Model)
+	assert.Equal(t, []string{"first", "second"}, got.Input)
+	assert.Zero(t, got.Dimensions, "dimensions are omitted when not configured")
+	assert.Equal(t, [][]float32{{1, 0, 0}, {0, 0.6, 0.8}}, vectors, "vectors are normalised")
+	assert.Equal(t, 3, e.Dimensions())
+	assert.Equal(t, "openai:tiny", e.Name())
+}
+
+func TestOpenAI_Errors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		status      int
+		body        string
+		dims        int
+		errContains string
+	}{
+		{name: "http error", status: http.StatusUnauthorized, body: `{"error":"bad key"}`, errContains: "401"},
+		{name: "missing vectors", status: http.StatusOK, body: `{"data":[]}`, errContains: "expected 1 vectors, got 0"},
+		{name: "bad index", status: http.StatusOK, body: `{"data":[{"index":4,"embedding":[1]}]}`, errContains: "invalid index 4"},
+		{name: "dimension mismatch", status: http.StatusOK, body: `{"data":[{"index":0,"embedding":[1,2]}]}`, dims: 3, errContains: "expected 3 dimensions, got 2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer srv.Close()
+
+			e, err := NewOpenAI(OpenAIConfig{BaseURL: srv.URL, Dimensions: tt.dims})
+			require.NoError(t, err)
+			_, err = e.Embed(context.Background(), []string{"text"})
+			assert.ErrorContains(t, err, tt.errContains)
+		})
+	}
+}
+
+func TestOpenAI_SendsConfiguredDimensions(t *testing.T) {
+	t.Parallel()
+
+	var got openAIEmbeddingRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+		_, _ = w.Write([]byte(`{"data":[{"index":0,"embedding":[1,0]}]}`))
+	}))
+	defer srv.Close()
+
+	e, err := NewOpenAI(OpenAIConfig{BaseURL: srv.URL, Dimensions: 2})
+	require.NoError(t, err)
+	_, err = e.Embed(context.Background(), []string{"text"})
+	require.NoError(t, err)
+
+	assert.Equal(t, 2, got.Dimensions)
+	assert.Equal(t, DefaultOpenAIModel, got.Model)
+	assert.Equal(t, "openai:"+DefaultOpenAIModel+"/2", e.Name())
+}
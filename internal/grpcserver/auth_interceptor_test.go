@@ -243,3 +243,61 @@ func TestStreamAuthInterceptor_RevokedKeycardAtOpen(t *testing.T) {
 	assert.Equal(t, "invalid token", st.Message(),
 		"revoked-equivalent path collapses to invalid token at the wire to avoid leaking revocation state")
 }
+
+func TestAuthInterceptor_ProjectScopedKeycard(t *testing.T) {
+	t.Parallel()
+	raw := "engram_cccc333300000000000000000000beef"
+	row := makeKeycardRow(t, "uuid-p", raw, "read-write")
+	row.AllowedProjects = []string{"acme-*"}
+	srv := &Server{
+		validator: auth.NewValidator("master-secret", &stubReader{
+			rows: map[string][]gormdb.APIToken{"cccc3333": {row}},
+		}),
+	}
+	info := &grpc.UnaryServerInfo{FullMethod: "/engram.v1.EngramService/CallTool"}
+
+	_, err := srv.authInterceptor(bearerCtx(t, raw), &pb.CallToolRequest{Project: "acme-api"}, info, echoUnaryHandler)
+	require.NoError(t, err)
+
+	_, err = srv.authInterceptor(bearerCtx(t, raw), &pb.CallToolRequest{Project: "globex"}, info, echoUnaryHandler)
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	info = &grpc.UnaryServerInfo{FullMethod: "/engram.v1.EngramService/GetSessionStartContext"}
+	_, err = srv.authInterceptor(bearerCtx(t, raw), &pb.GetSessionStartContextRequest{Project: "globex"}, info, echoUnaryHandler)
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+}
+
+// stubRoles is a minimal auth.RoleSource for interceptor tests.
+type stubRoles map[string][]string
+
+func (s stubRoles) RolePermissions(_ context.Context, name string) ([]string, bool, error) {
+	perms, ok := s[name]
+	return perms, ok, nil
+}
+
+func TestAuthInterceptor_MethodPermission(t *testing.T) {
+	t.Parallel()
+	raw := "engram_dddd444400000000000000000000c1c1"
+	v := auth.NewValidator("master-secret", &stubReader{
+		rows: map[string][]gormdb.APIToken{"dddd4444": {makeKeycardRow(t, "uuid-ci", raw, "ci")}},
+	})
+	v.SetRoleSource(stubRoles{"ci": {"issues:read", "issues:write"}})
+	srv := &Server{validator: v}
+
+	// CallTool is checked per tool by the MCP server, not here.
+	info := &grpc.UnaryServerInfo{FullMethod: pb.EngramService_CallTool_FullMethodName}
+	_, err := srv.authInterceptor(bearerCtx(t, raw), &pb.CallToolRequest{}, info, echoUnaryHandler)
+	require.NoError(t, err)
+
+	info = &grpc.UnaryServerInfo{FullMethod: pb.EngramService_GetSessionStartContext_FullMethodName}
+	_, err = srv.authInterceptor(bearerCtx(t, raw), &pb.GetSessionStartContextRequest{Project: "acme"}, info, echoUnaryHandler)
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	streamHandler := func(_ any, _ grpc.ServerStream) error {
+		t.Fatal("handler must not run without memory:read")
+		return nil
+	}
+	streamInfo := &grpc.StreamServerInfo{FullMethod: pb.EngramService_ProjectEvents_FullMethodName}
+	err = srv.streamAuthInterceptor(nil, &stubStream{ctx: bearerCtx(t, raw)}, streamInfo, streamHandler)
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+}
@@ -0,0 +1,144 @@
+package grpcserver
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/thebtf/engram/proto/engram/v1"
+)
+
+// IdempotencyKeyHeader is the gRPC metadata key under which a CallTool
+// request may carry an idempotency key. Calls with the same key from the same
+// keycard run at most once; repeats get the first call's response. The
+// daemon's offline outbox sets it on every call it may replay.
+const IdempotencyKeyHeader = "x-engram-idempotency-key"
+
+const (
+	maxIdempotencyKeyLen = 128
+
+	// idempotencyClaimTimeout is how long a claimed call may stay unfinished
+	// before a retry takes it over (the server died mid-call).
+	idempotencyClaimTimeout = 10 * time.Minute
+
+	// idempotencyRetention is how long results are kept for repeats. The
+	// daemon outbox gives up long before.
+	idempotencyRetention = 7 * 24 * time.Hour
+
+	// idempotencySweepInterval spaces out the deletion of expired results.
+	idempotencySweepInterval = time.Hour
+)
+
+// idempotencyKey returns the request's idempotency key, or "" when it has
+// none.
+func idempotencyKey(ctx context.Context) (string, error) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return "", nil
+	}
+	values := md.Get(IdempotencyKeyHeader)
+	if len(values) == 0 || values[0] == "" {
+		return "", nil
+	}
+	if len(values[0]) > maxIdempotencyKeyLen {
+		return "", status.Errorf(codes.InvalidArgument, "%s longer than %d characters", IdempotencyKeyHeader, maxIdempotencyKeyLen)
+	}
+	return values[0], nil
+}
+
+// toolCallResult is a row of tool_call_results.
+type toolCallResult struct {
+	ToolName    string    `gorm:"column:tool_name"`
+	Status      string    `gorm:"column:status"`
+	IsError     bool      `gorm:"column:is_error"`
+	ContentJSON []byte    `gorm:"column:content_json"`
+	CreatedAt   time.Time `gorm:"column:created_at"`
+}
+
+// claimToolCall records that the call keyed (keycardID, key) is running. It
+// returns the stored response when the call already completed, and
+// codes.Aborted while another attempt is still running. (nil, nil) means
+// the caller owns the call and must report its outcome to finishToolCall.
+func (s *Server) claimToolCall(ctx context.Context, keycardID, key, tool string) (*pb.CallToolResponse, error) {
+	db := s.db.WithContext(ctx)
+	s.sweepToolCallResults(ctx)
+
+	res := db.Exec(
+		`INSERT INTO tool_call_results (keycard_id, idempotency_key, tool_name) VALUES (?, ?, ?) ON CONFLICT DO NOTHING`,
+		keycardID, key, tool,
+	)
+	if res.Error != nil {
+		return nil, status.Errorf(codes.Unavailable, "idempotency store: %v", res.Error)
+	}
+	if res.RowsAffected == 1 {
+		return nil, nil
+	}
+
+	var row toolCallResult
+	if err := db.Raw(
+		`SELECT tool_name, status, is_error, content_json, created_at FROM tool_call_results WHERE keycard_id = ? AND idempotency_key = ?`,
+		keycardID, key,
+	).Scan(&row).Error; err != nil {
+		return nil, status.Errorf(codes.Unavailable, "idempotency store: %v", err)
+	}
+	if row.ToolName != "" && row.ToolName != tool {
+		return nil, status.Errorf(codes.InvalidArgument, "idempotency key already used for tool %q", row.ToolName)
+	}
+	if row.Status == "done" {
+		return &pb.CallToolResponse{IsError: row.IsError, ContentJson: row.ContentJSON}, nil
+	}
+
+	res = db.Exec(
+		`UPDATE tool_call_results SET created_at = NOW() WHERE keycard_id = ? AND idempotency_key = ? AND status = 'pending' AND created_at < ?`,
+		keycardID, key, time.Now().Add(-idempotencyClaimTimeout),
+	)
+	if res.Error != nil {
+		return nil, status.Errorf(codes.Unavailable, "idempotency store: %v", res.Error)
+	}
+	if res.RowsAffected == 1 {
+		return nil, nil
+	}
+	return nil, status.Error(codes.Aborted, "a call with this idempotency key is still running")
+}
+
+// finishToolCall stores the outcome of a call claimed by claimToolCall. A
+// failed call is forgotten so that a retry runs it again. It runs even when
+// the client has gone away: the call itself completed.
+func (s *Server) finishToolCall(ctx context.Context, keycardID, key string, resp *pb.CallToolResponse, callErr error) {
+	db := s.db.WithContext(context.WithoutCancel(ctx))
+	var err error
+	if callErr != nil {
+		err = db.Exec(
+			`DELETE FROM tool_call_results WHERE keycard_id = ? AND idempotency_key = ? AND status = 'pending'`,
+			keycardID, key,
+		).Error
+	} else {
+		err = db.Exec(
+			`UPDATE tool_call_results SET status = 'done', is_error = ?, content_json = ? WHERE keycard_id = ? AND idempotency_key = ?`,
+			resp.IsError, resp.ContentJson, keycardID, key,
+		).Error
+	}
+	if err != nil {
+		// The retry will either run the call again or wait out the claim.
+		log.Warn().Err(err).Str("idempotency_key", key).Msg("grpc: failed to record tool call result")
+	}
+}
+
+// sweepToolCallResults deletes results past idempotencyRetention, at most
+// once per idempotencySweepInterval.
+func (s *Server) sweepToolCallResults(ctx context.Context) {
+	now := time.Now()
+	last := s.lastResultSweep.Load()
+	if now.UnixNano()-last < int64(idempotencySweepInterval) || !s.lastResultSweep.CompareAndSwap(last, now.UnixNano()) {
+		return
+	}
+	if err := s.db.WithContext(ctx).Exec(
+		`DELETE FROM tool_call_results WHERE created_at < ?`, now.Add(-idempotencyRetention),
+	).Error; err != nil {
+		log.Warn().Err(err).Msg("grpc: failed to sweep tool call results")
+	}
+}
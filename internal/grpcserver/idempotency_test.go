@@ -0,0 +1,143 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	pb "github.com/thebtf/engram/proto/engram/v1"
+)
+
+// countingHandler is an MCPHandler that counts tool calls.
+type countingHandler struct {
+	calls atomic.Int32
+	err   error
+}
+
+func (h *countingHandler) HandleToolCall(_ context.Context, toolName string, _ []byte) ([]byte, bool, error) {
+	n := h.calls.Add(1)
+	if h.err != nil {
+		return nil, false, h.err
+	}
+	return []byte(fmt.Sprintf("%s call %d", toolName, n)), false, nil
+}
+
+func (h *countingHandler) ToolDefinitions() []ToolDef { return nil }
+
+func (h *countingHandler) ServerInfo() (string, string) { return "test", "v0" }
+
+func keyedCtx(key string) context.Context {
+	return metadata.NewIncomingContext(context.Background(), metadata.Pairs(IdempotencyKeyHeader, key))
+}
+
+func TestIdempotencyKey(t *testing.T) {
+	t.Parallel()
+
+	key, err := idempotencyKey(context.Background())
+	require.NoError(t, err)
+	assert.Empty(t, key)
+
+	key, err = idempotencyKey(keyedCtx("abc123"))
+	require.NoError(t, err)
+	assert.Equal(t, "abc123", key)
+
+	_, err = idempotencyKey(keyedCtx(strings.Repeat("k", maxIdempotencyKeyLen+1)))
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestCallTool_IdempotencyKeyWithoutDB(t *testing.T) {
+	t.Parallel()
+
+	h := &countingHandler{}
+	srv := &Server{handler: h}
+	_, err := srv.CallTool(keyedCtx("k1"), &pb.CallToolRequest{ToolName: "store_memory"})
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+	assert.Zero(t, h.calls.Load())
+}
+
+// testIdempotencyDB opens a real postgres test DB with the tool_call_results
+// table. Tests skip when DATABASE_DSN is not set.
+func testIdempotencyDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	dsn := os.Getenv("DATABASE_DSN")
+	if dsn == "" {
+		t.Skip("DATABASE_DSN not set, skipping gRPC idempotency integration test")
+	}
+
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+
+	ddl := `CREATE TABLE IF NOT EXISTS tool_call_results (
+		keycard_id      TEXT NOT NULL DEFAULT '',
+		idempotency_key VARCHAR(128) NOT NULL,
+		tool_name       TEXT NOT NULL,
+		status          VARCHAR(16) NOT NULL DEFAULT 'pending',
+		is_error        BOOLEAN NOT NULL DEFAULT FALSE,
+		content_json    BYTEA,
+		created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+		PRIMARY KEY (keycard_id, idempotency_key)
+	)`
+	require.NoError(t, db.Exec(ddl).Error)
+
+	sqlDB, _ := db.DB()
+	t.Cleanup(func() { sqlDB.Close() })
+	return db
+}
+
+func TestCallTool_IdempotencyKey_RunsOnce(t *testing.T) {
+	t.Parallel()
+
+	db := testIdempotencyDB(t)
+	key := "test-runs-once-" + t.Name()
+	t.Cleanup(func() { db.Exec("DELETE FROM tool_call_results WHERE idempotency_key = ?", key) })
+
+	h := &countingHandler{}
+	srv := &Server{handler: h, db: db}
+	req := &pb.CallToolRequest{ToolName: "store_memory"}
+
+	first, err := srv.CallTool(keyedCtx(key), req)
+	require.NoError(t, err)
+	second, err := srv.CallTool(keyedCtx(key), req)
+	require.NoError(t, err)
+
+	assert.Equal(t, int32(1), h.calls.Load())
+	assert.Equal(t, first.ContentJson, second.ContentJson)
+
+	_, err = srv.CallTool(keyedCtx(key), &pb.CallToolRequest{ToolName: "issues"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestCallTool_IdempotencyKey_FailedCallRetries(t *testing.T) {
+	t.Parallel()
+
+	db := testIdempotencyDB(t)
+	key := "test-failed-retries-" + t.Name()
+	t.Cleanup(func() { db.Exec("DELETE FROM tool_call_results WHERE idempotency_key = ?", key) })
+
+	h := &countingHandler{err: errors.New("boom")}
+	srv := &Server{handler: h, db: db}
+	req := &pb.CallToolRequest{ToolName: "store_memory"}
+
+	_, err := srv.CallTool(keyedCtx(key), req)
+	assert.Equal(t, codes.Internal, status.Code(err))
+
+	h.err = nil
+	_, err = srv.CallTool(keyedCtx(key), req)
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), h.calls.Load())
+}
@@ -9,6 +9,7 @@ import (
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 
+	"github.com/thebtf/engram/internal/auth"
 	"github.com/thebtf/engram/internal/worker/projectevents"
 	pb "github.com/thebtf/engram/proto/engram/v1"
 )
@@ -41,6 +42,8 @@ func (s *Server) ProjectEvents(req *pb.ProjectEventsRequest, stream grpc.ServerS
 	// Channel-based bridge between the synchronous Bus.Subscribe callback and the
 	// blocking grpc.ServerStream.Send. Buffer of 64 events to absorb bursts without
 	// blocking the emitter goroutine.
+	// A project-scoped keycard only hears about its own projects.
+	id, _ := auth.IdentityFrom(stream.Context())
 	evCh := make(chan projectevents.Event, 64)
 
 	unsub := s.bus.Subscribe(func(ev projectevents.Event) {
@@ -60,6 +63,9 @@ func (s *Server) ProjectEvents(req *pb.ProjectEventsRequest, stream grpc.ServerS
 			return nil
 
 		case ev := <-evCh:
+			if !id.CanAccessProject(ev.ProjectID) {
+				continue
+			}
 			pbEv, err := busEventToProto(ev)
 			if err != nil {
 				// Should not happen with well-formed events.
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"strings"
 	"sync"
+	"sync/atomic"
 
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
@@ -50,6 +51,8 @@ type Server struct {
 	validator *auth.Validator    // nil = auth disabled; read under mu.RLock
 	db        *gorm.DB           // injected by worker after DB is ready
 	bus       *projectevents.Bus // in-process project lifecycle event bus
+
+	lastResultSweep atomic.Int64 // unix nanos of the last tool_call_results sweep
 }
 
 // New creates a new gRPC server. The returned *grpc.Server has EngramService
@@ -152,6 +155,29 @@ func (s *Server) CallTool(ctx context.Context, req *pb.CallToolRequest) (*pb.Cal
 		ctx = mcp.ContextWithProject(ctx, req.Project)
 	}
 
+	key, err := idempotencyKey(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if key == "" {
+		return s.callTool(ctx, req)
+	}
+
+	// Keyed calls (outbox replays) run at most once per keycard and key.
+	if s.db == nil {
+		return nil, status.Error(codes.Unavailable, "database not ready")
+	}
+	id, _ := auth.IdentityFrom(ctx)
+	if resp, err := s.claimToolCall(ctx, id.KeycardID, key, req.ToolName); resp != nil || err != nil {
+		return resp, err
+	}
+	resp, err := s.callTool(ctx, req)
+	s.finishToolCall(ctx, id.KeycardID, key, resp, err)
+	return resp, err
+}
+
+// callTool runs req through the MCP handler.
+func (s *Server) callTool(ctx context.Context, req *pb.CallToolRequest) (*pb.CallToolResponse, error) {
 	resultJSON, isError, err := s.handler.HandleToolCall(ctx, req.ToolName, req.ArgumentsJson)
 	if err != nil {
 		return nil, status.Errorf(codes.Internal, "tool call failed: %v", err)
@@ -218,6 +244,8 @@ func (s *Server) validateBearer(ctx context.Context) (auth.Identity, error) {
 		// a different TokenStoreReader implementation surfaces revoked
 		// rows for audit logging.
 		return auth.Identity{}, status.Error(codes.Unauthenticated, "token revoked")
+	case errors.Is(err, auth.ErrExpired):
+		return auth.Identity{}, status.Error(codes.Unauthenticated, "token expired")
 	default:
 		// DB error or unexpected bcrypt failure. Surface as Internal so
 		// monitoring distinguishes auth-rejected (Unauthenticated) from
@@ -252,10 +280,55 @@ func (s *Server) authInterceptor(
 		return nil, err
 	}
 
+	if err := checkMethodPermission(id, info.FullMethod); err != nil {
+		return nil, err
+	}
+	if err := checkRequestProject(id, req); err != nil {
+		return nil, err
+	}
+
 	ctx = auth.WithIdentity(ctx, id)
 	return handler(ctx, req)
 }
 
+// methodPermissions maps RPCs to the permission they need. RPCs not listed
+// need none: Initialize and NegotiateVersion are handshakes, and CallTool is
+// checked per tool and action by the MCP server.
+var methodPermissions = map[string]auth.Permission{
+	pb.EngramService_GetSessionStartContext_FullMethodName: auth.PermMemoryRead,
+	pb.EngramService_SyncProjectState_FullMethodName:       auth.PermMemoryRead,
+	pb.EngramService_ProjectEvents_FullMethodName:          auth.PermMemoryRead,
+}
+
+// checkMethodPermission enforces the caller's role on method (see
+// methodPermissions).
+func checkMethodPermission(id auth.Identity, method string) error {
+	perm, ok := methodPermissions[method]
+	if !ok {
+		return nil
+	}
+	if err := id.Require(perm); err != nil {
+		return status.Error(codes.PermissionDenied, err.Error())
+	}
+	return nil
+}
+
+// checkRequestProject enforces a project-scoped keycard's allowlist on
+// requests that carry a project (CallTool, GetSessionStartContext,
+// Initialize). An empty project is left to the handler:
+// GetSessionStartContext rejects it, and CallTool's MCP server checks the
+// projects named in the tool arguments.
+func checkRequestProject(id auth.Identity, req any) error {
+	r, ok := req.(interface{ GetProject() string })
+	if !ok || r.GetProject() == "" {
+		return nil
+	}
+	if err := id.CheckProjects(r.GetProject()); err != nil {
+		return status.Error(codes.PermissionDenied, err.Error())
+	}
+	return nil
+}
+
 // streamAuthInterceptor is the streaming gRPC server interceptor. Ping is not
 // streaming; SyncProjectState is unary; ProjectEvents is the only streaming
 // method on the engram surface. The interceptor validates the bearer at stream
@@ -276,6 +349,9 @@ func (s *Server) streamAuthInterceptor(
 	if err != nil {
 		return err
 	}
+	if err := checkMethodPermission(id, info.FullMethod); err != nil {
+		return err
+	}
 
 	wrapped := &authedStream{ServerStream: ss, ctx: auth.WithIdentity(ss.Context(), id)}
 	return handler(srv, wrapped)
@@ -2,8 +2,10 @@ package grpcserver
 
 import (
 	"context"
+	"strings"
 	"time"
 
+	"github.com/thebtf/engram/internal/config"
 	dbgorm "github.com/thebtf/engram/internal/db/gorm"
 	"github.com/thebtf/engram/pkg/models"
 	pb "github.com/thebtf/engram/proto/engram/v1"
@@ -22,8 +24,16 @@ const (
 )
 
 // GetSessionStartContext returns static session-start entities for a project.
-// The payload is SQL-backed only: active issues, behavioral rules, recent memories,
-// plus the timestamp when the response was generated.
+// The payload is SQL-backed only: active issues, behavioral rules, unexpired memories
+// (most important first, then newest), plus the timestamp when the response was generated.
+//
+// The entities are packed into a token budget (req.token_budget, else the
+// context_max_tokens setting): rules first, then issues by priority, then memories.
+// What the budget truncated or dropped is reported in the truncation section.
+//
+// When the request carries a prompt, cwd or recent_files, memories are ranked by
+// relevance to that task first (see MemoryStore.RankForContext). Every returned
+// entity carries a reason explaining its inclusion.
 func (s *Server) GetSessionStartContext(ctx context.Context, req *pb.GetSessionStartContextRequest) (*pb.GetSessionStartContextResponse, error) {
 	project := req.GetProject()
 	if project == "" {
@@ -41,6 +51,9 @@ func (s *Server) GetSessionStartContext(ctx context.Context, req *pb.GetSessionS
 	if req.GetIssuesLimit() > maxSessionStartIssuesLimit {
 		return nil, status.Errorf(codes.InvalidArgument, "issues_limit must be <= %d", maxSessionStartIssuesLimit)
 	}
+	if req.GetTokenBudget() < 0 {
+		return nil, status.Error(codes.InvalidArgument, "token_budget must be >= 0")
+	}
 	if s.db == nil {
 		return nil, status.Error(codes.Unavailable, "database not ready")
 	}
@@ -57,18 +70,48 @@ func (s *Server) GetSessionStartContext(ctx context.Context, req *pb.GetSessionS
 
 	issueStore := dbgorm.NewIssueStore(s.db)
 	issueRows, _, err := issueStore.ListIssuesEx(ctx, dbgorm.IssueListParams{
-		TargetProject: project,
-		Statuses:      []string{"open", "acknowledged", "reopened"},
-		Limit:         issuesLimit,
+		TargetProject:       project,
+		Statuses:            dbgorm.UnresolvedIssueStatuses,
+		HideClaimedByOthers: true, // another live session is already working on these
+		Session:             strings.TrimSpace(req.GetSessionId()),
+		Limit:               issuesLimit,
 	})
 	if err != nil {
 		return nil, status.Error(codes.Internal, "failed to list session-start issues")
 	}
+	issueIDs := make([]int64, len(issueRows))
+	for i, row := range issueRows {
+		issueIDs[i] = row.ID
+	}
+	blockers, err := issueStore.BlockersOf(ctx, issueIDs)
+	if err != nil {
+		return nil, status.Error(codes.Internal, "failed to list session-start issue blockers")
+	}
 
+	var memories []*pb.SessionStartMemory
 	memoryStore := dbgorm.NewMemoryStore(&dbgorm.Store{DB: s.db})
-	memoryRows, err := memoryStore.List(ctx, project, memoriesLimit)
-	if err != nil {
-		return nil, status.Error(codes.Internal, "failed to list session-start memories")
+	if signals := sessionStartSignalsFrom(req); !signals.empty() {
+		hits, err := memoryStore.RankForContext(ctx, dbgorm.MemoryContextParams{
+			Project: project,
+			Query:   signals.query,
+			Terms:   signals.terms,
+			Files:   signals.files,
+			Limit:   memoriesLimit,
+		})
+		if err != nil {
+			return nil, status.Error(codes.Internal, "failed to rank session-start memories")
+		}
+		memories = mapSessionStartMemoryHits(hits)
+	} else {
+		memoryRows, err := memoryStore.ListEx(ctx, dbgorm.MemoryListParams{
+			Project: project,
+			OrderBy: dbgorm.MemoryOrderImportance,
+			Limit:   memoriesLimit,
+		})
+		if err != nil {
+			return nil, status.Error(codes.Internal, "failed to list session-start memories")
+		}
+		memories = mapSessionStartMemories(memoryRows)
 	}
 
 	var ruleRows []dbgorm.BehavioralRule
@@ -81,37 +124,62 @@ func (s *Server) GetSessionStartContext(ctx context.Context, req *pb.GetSessionS
 		return nil, status.Error(codes.Internal, "failed to list session-start rules")
 	}
 
-	generatedAt := timestamppb.Now()
-	return &pb.GetSessionStartContextResponse{
-		Issues:      mapSessionStartIssues(issueRows),
+	resp := &pb.GetSessionStartContextResponse{
+		Issues:      mapSessionStartIssues(issueRows, blockers),
 		Rules:       mapSessionStartRules(ruleRows),
-		Memories:    mapSessionStartMemories(memoryRows),
-		GeneratedAt: generatedAt,
-	}, nil
+		Memories:    memories,
+		GeneratedAt: timestamppb.Now(),
+	}
+
+	tokenBudget := int(req.GetTokenBudget())
+	if tokenBudget == 0 {
+		tokenBudget = config.Get().ContextMaxTokens
+	}
+	if tokenBudget > 0 {
+		packer := newSessionStartPacker(tokenBudget)
+		resp.Rules = packer.packRules(resp.Rules)
+		resp.Issues = packer.packIssues(resp.Issues)
+		resp.Memories = packer.packMemories(resp.Memories)
+		resp.Truncation = packer.trunc
+	}
+	return resp, nil
 }
 
-func mapSessionStartIssues(rows []dbgorm.IssueWithCount) []*pb.SessionStartIssue {
+func mapSessionStartIssues(rows []dbgorm.IssueWithCount, blockers map[int64][]int64) []*pb.SessionStartIssue {
+	now := time.Now()
 	issues := make([]*pb.SessionStartIssue, 0, len(rows))
 	for _, row := range rows {
-		issues = append(issues, &pb.SessionStartIssue{
-			Id:             row.ID,
-			Title:          row.Title,
-			Body:           row.Body,
-			Status:         row.Status,
-			Priority:       row.Priority,
-			Type:           row.Type,
-			SourceProject:  row.SourceProject,
-			TargetProject:  row.TargetProject,
-			SourceAgent:    row.SourceAgent,
-			Labels:         append([]string(nil), row.Labels...),
-			CommentCount:   row.CommentCount,
-			AcknowledgedAt: timestampProto(row.AcknowledgedAt),
-			ResolvedAt:     timestampProto(row.ResolvedAt),
-			ReopenedAt:     timestampProto(row.ReopenedAt),
-			ClosedAt:       timestampProto(row.ClosedAt),
-			CreatedAt:      timestamppb.New(row.CreatedAt),
-			UpdatedAt:      timestamppb.New(row.UpdatedAt),
-		})
+		issue := &pb.SessionStartIssue{
+			Id:              row.ID,
+			Title:           row.Title,
+			Body:            row.Body,
+			Status:          row.Status,
+			Priority:        row.Priority,
+			Type:            row.Type,
+			SourceProject:   row.SourceProject,
+			TargetProject:   row.TargetProject,
+			SourceAgent:     row.SourceAgent,
+			Labels:          append([]string(nil), row.Labels...),
+			CommentCount:    row.CommentCount,
+			AcknowledgedAt:  timestampProto(row.AcknowledgedAt),
+			ResolvedAt:      timestampProto(row.ResolvedAt),
+			ReopenedAt:      timestampProto(row.ReopenedAt),
+			ClosedAt:        timestampProto(row.ClosedAt),
+			CreatedAt:       timestamppb.New(row.CreatedAt),
+			UpdatedAt:       timestamppb.New(row.UpdatedAt),
+			Reason:          sessionStartIssueReason(row, blockers[row.ID]),
+			BlockedBy:       blockers[row.ID],
+			Stale:           row.IsStale(),
+			StaleDays:       int32(row.StaleDays),
+			EscalationCount: int32(row.EscalationCount),
+			EscalatedAt:     timestampProto(row.EscalatedAt),
+		}
+		if row.HasLiveClaim(now) {
+			issue.AssigneeAgent = row.AssigneeAgent
+			issue.AssigneeSession = row.AssigneeSession
+			issue.LeaseExpiresAt = timestampProto(row.LeaseExpiresAt)
+		}
+		issues = append(issues, issue)
 	}
 	return issues
 }
@@ -132,6 +200,7 @@ func mapSessionStartRules(rows []dbgorm.BehavioralRule) []*pb.SessionStartRule {
 			Version:   int32(row.Version),
 			CreatedAt: timestamppb.New(row.CreatedAt),
 			UpdatedAt: timestamppb.New(row.UpdatedAt),
+			Reason:    sessionStartRuleReason(row),
 		})
 	}
 	return rules
@@ -143,21 +212,37 @@ func mapSessionStartMemories(rows []*models.Memory) []*pb.SessionStartMemory {
 		if row == nil {
 			continue
 		}
-		memories = append(memories, &pb.SessionStartMemory{
-			Id:          row.ID,
-			Project:     row.Project,
-			Content:     row.Content,
-			Tags:        append([]string(nil), row.Tags...),
-			SourceAgent: row.SourceAgent,
-			EditedBy:    row.EditedBy,
-			Version:     int32(row.Version),
-			CreatedAt:   timestamppb.New(row.CreatedAt),
-			UpdatedAt:   timestamppb.New(row.UpdatedAt),
-		})
+		memories = append(memories, mapSessionStartMemory(row, sessionStartMemoryReason(row)))
+	}
+	return memories
+}
+
+func mapSessionStartMemoryHits(hits []dbgorm.MemoryContextHit) []*pb.SessionStartMemory {
+	memories := make([]*pb.SessionStartMemory, 0, len(hits))
+	for _, hit := range hits {
+		memories = append(memories, mapSessionStartMemory(hit.Memory, sessionStartMemoryHitReason(hit)))
 	}
 	return memories
 }
 
+func mapSessionStartMemory(row *models.Memory, reason string) *pb.SessionStartMemory {
+	return &pb.SessionStartMemory{
+		Id:          row.ID,
+		Project:     row.Project,
+		Content:     row.Content,
+		Tags:        append([]string(nil), row.Tags...),
+		SourceAgent: row.SourceAgent,
+		EditedBy:    row.EditedBy,
+		Version:     int32(row.Version),
+		CreatedAt:   timestamppb.New(row.CreatedAt),
+		UpdatedAt:   timestamppb.New(row.UpdatedAt),
+		Type:        row.MemoryType,
+		Importance:  row.Importance,
+		ExpiresAt:   timestampProto(row.ExpiresAt),
+		Reason:      reason,
+	}
+}
+
 func timestampProto(ts *time.Time) *timestamppb.Timestamp {
 	if ts == nil || ts.IsZero() {
 		return nil
@@ -0,0 +1,123 @@
+package grpcserver
+
+import (
+	"github.com/thebtf/engram/pkg/strutil"
+	pb "github.com/thebtf/engram/proto/engram/v1"
+)
+
+// Fixed per-entity overheads (in characters) for the metadata that accompanies
+// each body when the hook renders session-start context: ids, status/priority
+// tags, labels and bullet formatting. Mirrors the ~50 char overhead the
+// observation estimator in the worker uses.
+const (
+	sessionStartRuleOverheadChars   = 20
+	sessionStartIssueOverheadChars  = 60
+	sessionStartMemoryOverheadChars = 40
+
+	// sessionStartMinBodyTokens is the smallest truncated body worth returning.
+	// An entity that cannot keep at least this much of its body is dropped.
+	sessionStartMinBodyTokens = 24
+)
+
+// sessionStartPacker fills a token budget greedily in the order entities are
+// offered. Each entity is kept whole when it fits, kept with a truncated body
+// when at least sessionStartMinBodyTokens of it fit, and dropped otherwise.
+type sessionStartPacker struct {
+	trunc     *pb.SessionStartTruncation
+	remaining int
+}
+
+func newSessionStartPacker(budget int) *sessionStartPacker {
+	return &sessionStartPacker{
+		trunc:     &pb.SessionStartTruncation{TokenBudget: int32(budget)},
+		remaining: budget,
+	}
+}
+
+// fit decides how much of an entity with the given fixed overhead and body can
+// be kept. It returns the (possibly truncated) body, whether the body was
+// truncated, and false when the entity must be dropped.
+func (p *sessionStartPacker) fit(overheadChars int, body string) (string, bool, bool) {
+	overhead := strutil.EstimateTokens(overheadChars)
+	cost := strutil.EstimateTokens(overheadChars + len(body))
+	if cost <= p.remaining {
+		p.spend(cost)
+		return body, false, true
+	}
+	bodyBudget := p.remaining - overhead
+	if bodyBudget < sessionStartMinBodyTokens {
+		return "", false, false
+	}
+	short := strutil.TruncateToTokens(body, bodyBudget)
+	p.spend(overhead + strutil.EstimateTokens(len(short)))
+	return short, true, true
+}
+
+func (p *sessionStartPacker) spend(tokens int) {
+	p.remaining -= tokens
+	p.trunc.TokensUsed += int32(tokens)
+}
+
+// packRules keeps rules in priority order, truncating content to fit.
+func (p *sessionStartPacker) packRules(rules []*pb.SessionStartRule) []*pb.SessionStartRule {
+	kept := make([]*pb.SessionStartRule, 0, len(rules))
+	for _, rule := range rules {
+		content, truncated, ok := p.fit(sessionStartRuleOverheadChars, rule.GetContent())
+		if !ok {
+			p.trunc.DroppedRuleIds = append(p.trunc.DroppedRuleIds, rule.GetId())
+			continue
+		}
+		if truncated {
+			rule.Content = content
+			p.trunc.RulesTruncated++
+		}
+		kept = append(kept, rule)
+	}
+	return kept
+}
+
+// packIssues keeps issues in priority order. The title and labels count as
+// overhead; only the body is truncated.
+func (p *sessionStartPacker) packIssues(issues []*pb.SessionStartIssue) []*pb.SessionStartIssue {
+	kept := make([]*pb.SessionStartIssue, 0, len(issues))
+	for _, issue := range issues {
+		overhead := sessionStartIssueOverheadChars + len(issue.GetTitle())
+		for _, label := range issue.GetLabels() {
+			overhead += len(label) + 2
+		}
+		body, truncated, ok := p.fit(overhead, issue.GetBody())
+		if !ok {
+			p.trunc.DroppedIssueIds = append(p.trunc.DroppedIssueIds, issue.GetId())
+			continue
+		}
+		if truncated {
+			issue.Body = body
+			p.trunc.IssuesTruncated++
+		}
+		kept = append(kept, issue)
+	}
+	return kept
+}
+
+// packMemories keeps memories in the order given (importance, then recency).
+// Tags count as overhead; only the content is truncated.
+func (p *sessionStartPacker) packMemories(memories []*pb.SessionStartMemory) []*pb.SessionStartMemory {
+	kept := make([]*pb.SessionStartMemory, 0, len(memories))
+	for _, memory := range memories {
+		overhead := sessionStartMemoryOverheadChars
+		for _, tag := range memory.GetTags() {
+			overhead += len(tag) + 2
+		}
+		content, truncated, ok := p.fit(overhead, memory.GetContent())
+		if !ok {
+			p.trunc.DroppedMemoryIds = append(p.trunc.DroppedMemoryIds, memory.GetId())
+			continue
+		}
+		if truncated {
+			memory.Content = content
+			p.trunc.MemoriesTruncated++
+		}
+		kept = append(kept, memory)
+	}
+	return kept
+}
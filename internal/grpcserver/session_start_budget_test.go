@@ -0,0 +1,66 @@
+package grpcserver
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	pb "github.com/thebtf/engram/proto/engram/v1"
+)
+
+func TestSessionStartPacker_PacksRulesThenIssuesThenMemories(t *testing.T) {
+	t.Parallel()
+
+	longBody := strings.Repeat("long body ", 200)
+	rules := []*pb.SessionStartRule{
+		{Id: 1, Content: "always run the tests"},
+	}
+	issues := []*pb.SessionStartIssue{
+		{Id: 10, Title: "critical", Body: longBody},
+		{Id: 11, Title: "high", Body: "short"},
+	}
+	memories := []*pb.SessionStartMemory{
+		{Id: 20, Content: "fits nowhere"},
+	}
+
+	packer := newSessionStartPacker(120)
+	keptRules := packer.packRules(rules)
+	keptIssues := packer.packIssues(issues)
+	keptMemories := packer.packMemories(memories)
+
+	require.Len(t, keptRules, 1)
+	assert.Equal(t, "always run the tests", keptRules[0].Content)
+
+	require.Len(t, keptIssues, 1, "the truncated critical issue exhausts the budget")
+	assert.Equal(t, int64(10), keptIssues[0].Id)
+	assert.True(t, strings.HasSuffix(keptIssues[0].Body, "…"))
+	assert.Less(t, len(keptIssues[0].Body), len(longBody))
+
+	assert.Empty(t, keptMemories)
+
+	trunc := packer.trunc
+	assert.Equal(t, int32(120), trunc.TokenBudget)
+	assert.LessOrEqual(t, trunc.TokensUsed, int32(120))
+	assert.Equal(t, int32(1), trunc.IssuesTruncated)
+	assert.Equal(t, []int64{11}, trunc.DroppedIssueIds)
+	assert.Equal(t, []int64{20}, trunc.DroppedMemoryIds)
+	assert.Empty(t, trunc.DroppedRuleIds)
+}
+
+func TestSessionStartPacker_EverythingFits(t *testing.T) {
+	t.Parallel()
+
+	packer := newSessionStartPacker(10000)
+	rules := packer.packRules([]*pb.SessionStartRule{{Id: 1, Content: "rule"}})
+	issues := packer.packIssues([]*pb.SessionStartIssue{{Id: 2, Title: "t", Body: "b", Labels: []string{"bug"}}})
+	memories := packer.packMemories([]*pb.SessionStartMemory{{Id: 3, Content: "m", Tags: []string{"x"}}})
+
+	assert.Len(t, rules, 1)
+	assert.Len(t, issues, 1)
+	assert.Len(t, memories, 1)
+	assert.Zero(t, packer.trunc.RulesTruncated+packer.trunc.IssuesTruncated+packer.trunc.MemoriesTruncated)
+	assert.Empty(t, packer.trunc.DroppedIssueIds)
+	assert.Positive(t, packer.trunc.TokensUsed)
+}
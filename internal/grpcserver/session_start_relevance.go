@@ -0,0 +1,189 @@
+package grpcserver
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+	"unicode"
+
+	dbgorm "github.com/thebtf/engram/internal/db/gorm"
+	"github.com/thebtf/engram/pkg/models"
+	pb "github.com/thebtf/engram/proto/engram/v1"
+)
+
+const (
+	// maxSessionStartPromptKeywords caps how many prompt words feed the full-text query.
+	maxSessionStartPromptKeywords = 32
+	// maxSessionStartRecentFiles caps how many recent files are matched against memories.
+	maxSessionStartRecentFiles = 50
+	// minSessionStartTermLen drops short words and path segments ("go", "db") that
+	// match too many tags to be a useful signal.
+	minSessionStartTermLen = 3
+)
+
+// sessionStartGenericSegments are path segments too common to say anything about
+// the task (they appear in most repositories' layouts).
+var sessionStartGenericSegments = map[string]struct{}{
+	"internal": {}, "pkg": {}, "cmd": {}, "src": {}, "lib": {}, "app": {},
+	"test": {}, "tests": {}, "testdata": {}, "vendor": {}, "node_modules": {},
+	"main": {}, "index": {}, "home": {}, "users": {}, "root": {}, "tmp": {},
+}
+
+// sessionStartSignals is the task context derived from a request's prompt, cwd
+// and recent_files, in the shape MemoryStore.RankForContext expects.
+type sessionStartSignals struct {
+	query string
+	terms []string
+	files []string
+}
+
+// empty reports whether the request carried no usable task context.
+func (s sessionStartSignals) empty() bool {
+	return s.query == "" && len(s.terms) == 0 && len(s.files) == 0
+}
+
+// sessionStartSignalsFrom extracts ranking signals from the request.
+// Prompt keywords become an OR-ed websearch query and tag terms; recent files
+// contribute their cwd-relative path and base name as file fragments and their
+// directory and file-name segments as tag terms. The cwd's base name is a term too.
+func sessionStartSignalsFrom(req *pb.GetSessionStartContextRequest) sessionStartSignals {
+	var sig sessionStartSignals
+	seenTerms := make(map[string]struct{})
+	addTerm := func(term string) {
+		term = strings.ToLower(term)
+		if len(term) < minSessionStartTermLen {
+			return
+		}
+		if _, generic := sessionStartGenericSegments[term]; generic {
+			return
+		}
+		if _, ok := seenTerms[term]; ok {
+			return
+		}
+		seenTerms[term] = struct{}{}
+		sig.terms = append(sig.terms, term)
+	}
+
+	keywords := promptKeywords(req.GetPrompt())
+	for _, kw := range keywords {
+		addTerm(kw)
+	}
+	sig.query = strings.Join(keywords, " or ")
+
+	cwd := filepath.ToSlash(strings.TrimSpace(req.GetCwd()))
+	if cwd != "" {
+		addTerm(path.Base(cwd))
+	}
+
+	seenFiles := make(map[string]struct{})
+	addFile := func(fragment string) {
+		if len(fragment) < minSessionStartTermLen {
+			return
+		}
+		if _, ok := seenFiles[fragment]; ok {
+			return
+		}
+		seenFiles[fragment] = struct{}{}
+		sig.files = append(sig.files, fragment)
+	}
+	files := req.GetRecentFiles()
+	if len(files) > maxSessionStartRecentFiles {
+		files = files[:maxSessionStartRecentFiles]
+	}
+	for _, file := range files {
+		file = filepath.ToSlash(strings.TrimSpace(file))
+		if file == "" {
+			continue
+		}
+		if cwd != "" {
+			file = strings.TrimPrefix(strings.TrimPrefix(file, strings.TrimSuffix(cwd, "/")), "/")
+		}
+		addFile(file)
+		base := path.Base(file)
+		addFile(base)
+		for _, segment := range strings.Split(path.Dir(file), "/") {
+			addTerm(segment)
+		}
+		addTerm(strings.TrimSuffix(base, path.Ext(base)))
+	}
+	return sig
+}
+
+// promptKeywords returns the distinct words of a prompt in order of first
+// appearance, keeping identifiers (letters, digits, '_' and '-') of at least
+// minSessionStartTermLen characters. Stop words are left to the tsquery parser.
+func promptKeywords(prompt string) []string {
+	words := strings.FieldsFunc(prompt, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_' && r != '-'
+	})
+	seen := make(map[string]struct{}, len(words))
+	keywords := make([]string, 0, min(len(words), maxSessionStartPromptKeywords))
+	for _, word := range words {
+		word = strings.ToLower(strings.Trim(word, "-"))
+		if len([]rune(word)) < minSessionStartTermLen {
+			continue
+		}
+		if _, ok := seen[word]; ok {
+			continue
+		}
+		seen[word] = struct{}{}
+		keywords = append(keywords, word)
+		if len(keywords) == maxSessionStartPromptKeywords {
+			break
+		}
+	}
+	return keywords
+}
+
+// sessionStartMemoryReason explains why a memory was included in the default
+// (unranked) ordering.
+func sessionStartMemoryReason(mem *models.Memory) string {
+	if mem.Importance > models.DefaultMemoryImportance {
+		return fmt.Sprintf("high importance (%.2f)", mem.Importance)
+	}
+	return "recent project memory"
+}
+
+// sessionStartMemoryHitReason explains a memory picked by RankForContext.
+func sessionStartMemoryHitReason(hit dbgorm.MemoryContextHit) string {
+	if !hit.Matched() {
+		return sessionStartMemoryReason(hit.Memory) + "; no task match"
+	}
+	parts := make([]string, 0, 3)
+	if hit.TextScore > 0 {
+		parts = append(parts, fmt.Sprintf("matches prompt (rank %.3f)", hit.TextScore))
+	}
+	if len(hit.MatchedTags) > 0 {
+		parts = append(parts, "tags: "+strings.Join(hit.MatchedTags, ", "))
+	}
+	if len(hit.MatchedFiles) > 0 {
+		parts = append(parts, "mentions "+strings.Join(hit.MatchedFiles, ", "))
+	}
+	return strings.Join(parts, "; ")
+}
+
+// sessionStartRuleReason explains why a behavioral rule was included.
+func sessionStartRuleReason(row dbgorm.BehavioralRule) string {
+	if row.Project == nil {
+		return fmt.Sprintf("global rule (priority %d)", row.Priority)
+	}
+	return fmt.Sprintf("project rule (priority %d)", row.Priority)
+}
+
+// sessionStartIssueReason explains why an issue was included and, when
+// unresolved issues block it, which ones.
+func sessionStartIssueReason(row dbgorm.IssueWithCount, blockedBy []int64) string {
+	reason := fmt.Sprintf("%s issue for this project (priority %s)", row.Status, row.Priority)
+	if len(blockedBy) > 0 {
+		ids := make([]string, len(blockedBy))
+		for i, id := range blockedBy {
+			ids[i] = fmt.Sprintf("#%d", id)
+		}
+		reason += "; blocked by " + strings.Join(ids, ", ")
+	}
+	if row.IsStale() {
+		reason += fmt.Sprintf("; stale: escalated by SLA, no activity for %d days", row.StaleDays)
+	}
+	return reason
+}
@@ -0,0 +1,73 @@
+package grpcserver
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	dbgorm "github.com/thebtf/engram/internal/db/gorm"
+	"github.com/thebtf/engram/pkg/models"
+	pb "github.com/thebtf/engram/proto/engram/v1"
+)
+
+func TestSessionStartSignalsFrom(t *testing.T) {
+	t.Parallel()
+
+	sig := sessionStartSignalsFrom(&pb.GetSessionStartContextRequest{
+		Prompt:      "Fix the auth token refresh, then fix it again in the gRPC client",
+		Cwd:         "/home/dev/engram",
+		RecentFiles: []string{"/home/dev/engram/internal/auth/token.go", "internal/auth/token.go", "web/ui.ts"},
+	})
+
+	assert.Equal(t, "fix or the or auth or token or refresh or then or again or grpc or client", sig.query)
+	assert.Contains(t, sig.terms, "auth")
+	assert.Contains(t, sig.terms, "engram")
+	assert.Contains(t, sig.terms, "web")
+	assert.NotContains(t, sig.terms, "internal", "generic path segments are not terms")
+	assert.NotContains(t, sig.terms, "it", "short words are not terms")
+	assert.Equal(t, []string{"internal/auth/token.go", "token.go", "web/ui.ts", "ui.ts"}, sig.files)
+	assert.False(t, sig.empty())
+
+	assert.True(t, sessionStartSignalsFrom(&pb.GetSessionStartContextRequest{Project: "p"}).empty())
+}
+
+func TestPromptKeywords_Caps(t *testing.T) {
+	t.Parallel()
+
+	prompt := ""
+	for i := 0; i < maxSessionStartPromptKeywords+10; i++ {
+		prompt += " word" + string(rune('a'+i%26)) + string(rune('a'+i/26))
+	}
+	assert.Len(t, promptKeywords(prompt), maxSessionStartPromptKeywords)
+	assert.Empty(t, promptKeywords("a an of"))
+}
+
+func TestSessionStartMemoryHitReason(t *testing.T) {
+	t.Parallel()
+
+	mem := &models.Memory{ID: 1, Importance: models.DefaultMemoryImportance}
+	assert.Equal(t, "recent project memory; no task match", sessionStartMemoryHitReason(dbgorm.MemoryContextHit{Memory: mem}))
+
+	reason := sessionStartMemoryHitReason(dbgorm.MemoryContextHit{
+		Memory:       mem,
+		TextScore:    0.25,
+		MatchedTags:  []string{"auth"},
+		MatchedFiles: []string{"token.go"},
+	})
+	assert.Equal(t, "matches prompt (rank 0.250); tags: auth; mentions token.go", reason)
+
+	assert.Equal(t, "high importance (0.90)", sessionStartMemoryReason(&models.Memory{Importance: 0.9}))
+}
+
+func TestSessionStartIssueReason(t *testing.T) {
+	t.Parallel()
+
+	row := dbgorm.IssueWithCount{Issue: dbgorm.Issue{Status: "open", Priority: "high"}}
+	assert.Equal(t, "open issue for this project (priority high)", sessionStartIssueReason(row, nil))
+	assert.Equal(t, "open issue for this project (priority high); blocked by #42, #7", sessionStartIssueReason(row, []int64{42, 7}))
+
+	escalated := row.UpdatedAt.Add(time.Hour)
+	row.EscalatedAt, row.StaleDays = &escalated, 4
+	assert.Equal(t, "open issue for this project (priority high); stale: escalated by SLA, no activity for 4 days", sessionStartIssueReason(row, nil))
+}
@@ -170,7 +170,7 @@ func TestGetSessionStartContext_HappyPath(t *testing.T) {
 		Labels:        []string{"task"},
 	})
 	require.NoError(t, err)
-	_, err = issueStore.CreateIssue(ctx, &localgorm.Issue{
+	resolvedIssueID, err := issueStore.CreateIssue(ctx, &localgorm.Issue{
 		Title:         "resolved issue",
 		Body:          "ignore me",
 		Status:        "resolved",
@@ -181,7 +181,7 @@ func TestGetSessionStartContext_HappyPath(t *testing.T) {
 		SourceAgent:   "agent-c",
 	})
 	require.NoError(t, err)
-	_, err = issueStore.CreateIssue(ctx, &localgorm.Issue{
+	otherIssueID, err := issueStore.CreateIssue(ctx, &localgorm.Issue{
 		Title:         "other project issue",
 		Body:          "ignore me too",
 		Status:        "open",
@@ -193,12 +193,32 @@ func TestGetSessionStartContext_HappyPath(t *testing.T) {
 	})
 	require.NoError(t, err)
 	require.NoError(t, db.Exec(`INSERT INTO issue_comments (issue_id, author_project, author_agent, body) VALUES (?, ?, ?, ?)`, criticalIssueID, project, "agent-b", "first comment").Error)
+	// A critical issue claimed by another live session is hidden; one claimed by
+	// this session stays visible.
+	claimedIssueID, err := issueStore.CreateIssue(ctx, &localgorm.Issue{
+		Title:         "claimed elsewhere",
+		Status:        "open",
+		Priority:      "critical",
+		SourceProject: "source-e",
+		TargetProject: project,
+	})
+	require.NoError(t, err)
+	_, err = issueStore.ClaimIssue(ctx, claimedIssueID, "agent-e", "other-session", localgorm.DefaultIssueLease)
+	require.NoError(t, err)
+	_, err = issueStore.ClaimIssue(ctx, highIssueID, "agent-a", "this-session", localgorm.DefaultIssueLease)
+	require.NoError(t, err)
+	// An open issue in another project blocks the high issue; a resolved one no longer blocks.
+	_, err = issueStore.LinkIssues(ctx, &localgorm.IssueLink{SourceID: otherIssueID, TargetID: highIssueID, LinkType: "blocks"})
+	require.NoError(t, err)
+	_, err = issueStore.LinkIssues(ctx, &localgorm.IssueLink{SourceID: resolvedIssueID, TargetID: criticalIssueID, LinkType: "blocks"})
+	require.NoError(t, err)
 
 	srv := &Server{db: db}
 	resp, err := srv.GetSessionStartContext(ctx, &pb.GetSessionStartContextRequest{
 		Project:       project,
 		MemoriesLimit: 1,
 		IssuesLimit:   2,
+		SessionId:     "this-session",
 	})
 	require.NoError(t, err)
 	require.NotNil(t, resp.GeneratedAt)
@@ -213,8 +233,13 @@ func TestGetSessionStartContext_HappyPath(t *testing.T) {
 	assert.Equal(t, criticalIssueID, resp.Issues[0].Id)
 	assert.Equal(t, "critical", resp.Issues[0].Priority)
 	assert.Equal(t, int64(1), resp.Issues[0].CommentCount)
+	assert.Empty(t, resp.Issues[0].BlockedBy)
 	assert.Equal(t, highIssueID, resp.Issues[1].Id)
 	assert.Equal(t, "high", resp.Issues[1].Priority)
+	assert.Equal(t, []int64{otherIssueID}, resp.Issues[1].BlockedBy)
+	assert.Equal(t, "this-session", resp.Issues[1].AssigneeSession)
+	assert.NotNil(t, resp.Issues[1].LeaseExpiresAt)
+	assert.Contains(t, resp.Issues[1].Reason, fmt.Sprintf("blocked by #%d", otherIssueID))
 
 	require.Len(t, resp.Rules, 2)
 	assert.Equal(t, globalRule.ID, resp.Rules[0].Id)
@@ -263,3 +288,50 @@ func TestGetSessionStartContext_DefaultLimits(t *testing.T) {
 	assert.Len(t, resp.Memories, 3)
 	assert.Len(t, resp.Issues, 3)
 }
+
+func TestGetSessionStartContext_MemoriesByImportanceSkipExpired(t *testing.T) {
+	db, cleanup := openSessionStartTestDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	project := fmt.Sprintf("grpc-session-start-importance-%d", time.Now().UnixNano())
+	defer db.Exec(`DELETE FROM memories WHERE project = ?`, project)
+
+	memoryStore := localgorm.NewMemoryStore(&localgorm.Store{DB: db})
+
+	important, err := memoryStore.Create(ctx, &models.Memory{
+		Project:    project,
+		Content:    "important but old",
+		MemoryType: "pitfall",
+		Importance: 0.9,
+	})
+	require.NoError(t, err)
+	oldCreatedAt := time.Now().UTC().Add(-time.Hour)
+	require.NoError(t, db.Exec(`UPDATE memories SET created_at = ? WHERE id = ?`, oldCreatedAt, important.ID).Error)
+
+	recent, err := memoryStore.Create(ctx, &models.Memory{
+		Project: project,
+		Content: "recent default importance",
+	})
+	require.NoError(t, err)
+
+	expiredAt := time.Now().UTC().Add(-time.Minute)
+	_, err = memoryStore.Create(ctx, &models.Memory{
+		Project:    project,
+		Content:    "expired",
+		Importance: 1,
+		ExpiresAt:  &expiredAt,
+	})
+	require.NoError(t, err)
+
+	srv := &Server{db: db}
+	resp, err := srv.GetSessionStartContext(ctx, &pb.GetSessionStartContextRequest{Project: project})
+	require.NoError(t, err)
+
+	require.Len(t, resp.Memories, 2)
+	assert.Equal(t, important.ID, resp.Memories[0].Id)
+	assert.Equal(t, "pitfall", resp.Memories[0].Type)
+	assert.InDelta(t, 0.9, resp.Memories[0].Importance, 1e-9)
+	assert.Equal(t, recent.ID, resp.Memories[1].Id)
+	assert.InDelta(t, models.DefaultMemoryImportance, resp.Memories[1].Importance, 1e-9)
+}
@@ -2,6 +2,7 @@ package grpcserver
 
 import (
 	"context"
+	"slices"
 	"time"
 
 	"github.com/lib/pq"
@@ -10,6 +11,7 @@ import (
 
 	"gorm.io/gorm"
 
+	"github.com/thebtf/engram/internal/auth"
 	pb "github.com/thebtf/engram/proto/engram/v1"
 )
 
@@ -47,7 +49,12 @@ func (s *Server) SyncProjectState(ctx context.Context, req *pb.SyncProjectStateR
 		return nil, status.Error(codes.Unavailable, "database not ready")
 	}
 
-	localIDs := req.GetLocalProjectIds()
+	// A project-scoped keycard can neither heartbeat nor learn about
+	// projects outside its allowlist.
+	id, _ := auth.IdentityFrom(ctx)
+	localIDs := slices.DeleteFunc(slices.Clone(req.GetLocalProjectIds()), func(p string) bool {
+		return !id.CanAccessProject(p)
+	})
 	now := time.Now().UTC()
 
 	var removed, unknown []string
@@ -106,7 +113,9 @@ func (s *Server) SyncProjectState(ctx context.Context, req *pb.SyncProjectStateR
 		// Non-fatal — unknown list is informational.
 		staleIDs = nil
 	}
-	unknown = staleIDs
+	unknown = slices.DeleteFunc(staleIDs, func(p string) bool {
+		return !id.CanAccessProject(p)
+	})
 
 	return &pb.SyncProjectStateResponse{
 		Removed:          removed,
@@ -60,7 +60,7 @@ func (s *mockEngramServer) CallTool(_ context.Context, _ *pb.CallToolRequest) (*
 
 // startMockGRPC starts a mock gRPC server on an ephemeral port and returns the
 // listener address ("host:port"). The server is registered for cleanup via t.Cleanup.
-func startMockGRPC(t *testing.T, srv *mockEngramServer) string {
+func startMockGRPC(t *testing.T, srv pb.EngramServiceServer) string {
 	t.Helper()
 	lis, err := net.Listen("tcp", "127.0.0.1:0")
 	if err != nil {
@@ -19,6 +19,7 @@ package engramcore
 import (
 	"context"
 	"fmt"
+	"sync"
 
 	"github.com/thebtf/engram/internal/module"
 	muxcore "github.com/thebtf/mcp-mux/muxcore"
@@ -45,6 +46,14 @@ type Module struct {
 	pool  *grpcPool
 	cache *slugCache
 	deps  module.ModuleDeps
+
+	// Offline outbox (see outbox.go). outbox is nil when disabled.
+	outbox      *outbox
+	tokens      sync.Map // token hash → token, for replay
+	replayKick  chan struct{}
+	stopReplay  context.CancelFunc
+	replayDone  chan struct{}
+	outboxClose sync.Once
 }
 
 // NewModule constructs an unstarted engramcore module. Call Init before
@@ -52,8 +61,9 @@ type Module struct {
 // wiring in cmd/engram/main.go.
 func NewModule() *Module {
 	return &Module{
-		pool:  &grpcPool{},
-		cache: &slugCache{},
+		pool:       &grpcPool{},
+		cache:      &slugCache{},
+		replayKick: make(chan struct{}, 1),
 	}
 }
 
@@ -64,26 +74,32 @@ func NewModule() *Module {
 // Name returns the stable module identifier. Implements module.EngramModule.
 func (m *Module) Name() string { return moduleName }
 
-// Init captures ModuleDeps for later use. The module has no blocking
-// initialisation — gRPC connections are dialled lazily on first use.
-// Implements module.EngramModule.
-func (m *Module) Init(_ context.Context, deps module.ModuleDeps) error {
+// Init captures ModuleDeps for later use and opens the offline outbox in
+// StorageDir. gRPC connections are dialled lazily on first use. An outbox
+// that cannot be opened is logged and left disabled rather than failing
+// daemon startup. Implements module.EngramModule.
+func (m *Module) Init(ctx context.Context, deps module.ModuleDeps) error {
 	m.deps = deps
 	if deps.Logger != nil {
+		m.startOutbox(ctx, deps)
 		deps.Logger.Info("engramcore module initialised",
 			"storage_dir", deps.StorageDir,
 			"config_bytes", len(deps.Config),
+			"outbox", m.outbox != nil,
 		)
 	}
 	return nil
 }
 
-// Shutdown closes all pooled gRPC connections. Implements module.EngramModule.
+// Shutdown stops the outbox replayer and closes the outbox and all pooled
+// gRPC connections. Queued calls stay on disk for the next daemon run.
+// Implements module.EngramModule.
 //
 // Per design.md §4.1 shutdown proceeds in reverse registration order; this
 // module is typically registered first so it drains last. Closing gRPC
 // connections is idempotent so concurrent Shutdown calls are safe.
 func (m *Module) Shutdown(_ context.Context) error {
+	m.stopOutbox()
 	m.pool.closeAll()
 	if m.deps.Logger != nil {
 		m.deps.Logger.Info("engramcore module shut down")
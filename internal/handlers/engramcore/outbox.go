@@ -0,0 +1,375 @@
+package engramcore
+
+// outbox.go — durable journal of mutating tool calls made while the engram
+// server was unreachable. ProxyHandleTool writes to it instead of failing the
+// call; the replayer drains it in order once the server answers Ping again.
+//
+// Every journaled call carries an idempotency key (sent as gRPC metadata) so
+// a call the server did run before the connection dropped is not run twice on
+// replay.
+//
+// Raw keycard tokens are never written to disk. An entry stores the token
+// hash; the token itself is remembered in memory from the sessions that use
+// it, and entries whose token has not been presented since the daemon started
+// wait until it is.
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	// Register the pure-Go SQLite driver so sql.Open("sqlite", ...) works.
+	_ "modernc.org/sqlite"
+)
+
+// idempotencyKeyHeader is the gRPC metadata key the engram server reads
+// idempotency keys from (grpcserver.IdempotencyKeyHeader). Duplicated rather
+// than imported to keep server packages out of the daemon binary.
+const idempotencyKeyHeader = "x-engram-idempotency-key"
+
+const (
+	// outboxFile is the outbox database, relative to the module StorageDir.
+	outboxFile = "outbox.db"
+
+	// maxOutboxEntries bounds the pending entries. Calls past it fail as they
+	// did before the outbox existed.
+	maxOutboxEntries = 10000
+
+	// outboxFailedRetention is how long failed entries are kept after they
+	// were queued.
+	outboxFailedRetention = 7 * 24 * time.Hour
+
+	// outboxReplayInterval is how often the replayer checks whether the
+	// server is back.
+	outboxReplayInterval = 15 * time.Second
+
+	// outboxPingTimeout and outboxCallTimeout bound the replayer's RPCs.
+	outboxPingTimeout = 5 * time.Second
+	outboxCallTimeout = 30 * time.Second
+)
+
+// Outbox entry statuses. Failed entries were rejected by the server and are
+// kept for inspection for outboxFailedRetention, not retried.
+const (
+	outboxPending = "pending"
+	outboxFailed  = "failed"
+)
+
+// errOutboxFull is returned by enqueue when the journal holds maxOutboxEntries
+// pending entries.
+var errOutboxFull = errors.New("outbox is full")
+
+// queueableActions lists, for the tools that take an "action" argument, the
+// actions the outbox may journal and the default action. Mirrors the server's
+// tool handlers; lease actions (issues claim/renew/release) are left out
+// because replaying them late is meaningless.
+var queueableActions = map[string]struct {
+	defaultAction string
+	actions       map[string]bool
+}{
+	"store": {"create", map[string]bool{
+		"create": true, "edit": true, "merge": true, "restore": true, "import": true,
+	}},
+	"issues": {"list", map[string]bool{
+		"create": true, "update": true, "comment": true, "reopen": true,
+		"close": true, "link": true, "unlink": true,
+	}},
+	"feedback": {"", map[string]bool{"rate": true, "suppress": true}},
+	"docs":     {"", map[string]bool{"create": true, "comment": true, "remove": true, "ingest": true}},
+}
+
+// queueableTools lists the legacy single-purpose tools the outbox may
+// journal. Vault and credential tools are deliberately absent from both
+// tables: their arguments carry secrets that must not reach the disk.
+var queueableTools = map[string]bool{
+	"store_memory":     true,
+	"rate_memory":      true,
+	"suppress_memory":  true,
+	"store_rule":       true,
+	"import_instincts": true,
+	"doc_create":       true,
+	"doc_update":       true,
+	"doc_comment":      true,
+}
+
+// toolIsQueueable reports whether a call of tool name with args changes
+// server state and may be journaled when the server is unreachable.
+func toolIsQueueable(name string, args json.RawMessage) bool {
+	if queueableTools[name] {
+		return true
+	}
+	qa, ok := queueableActions[name]
+	if !ok {
+		return false
+	}
+	var a struct {
+		Action *string `json:"action"`
+	}
+	if len(args) > 0 {
+		if err := json.Unmarshal(args, &a); err != nil {
+			return false
+		}
+	}
+	action := qa.defaultAction
+	if a.Action != nil && *a.Action != "" {
+		action = *a.Action
+	}
+	return qa.actions[action]
+}
+
+// isTransportError reports whether a gRPC error means the call may not have
+// reached the server, so it is worth journaling or retrying.
+func isTransportError(err error) bool {
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded, codes.Aborted:
+		return true
+	default:
+		return false
+	}
+}
+
+// newIdempotencyKey returns a random idempotency key.
+func newIdempotencyKey() (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b[:]), nil
+}
+
+// withIdempotencyKey attaches key to the outgoing metadata of ctx.
+func withIdempotencyKey(ctx context.Context, key string) context.Context {
+	return metadata.AppendToOutgoingContext(ctx, idempotencyKeyHeader, key)
+}
+
+// outboxTarget is a server and keycard pair. Entries are replayed in order
+// within a target; targets are independent of each other.
+type outboxTarget struct {
+	serverURL string
+	tokenHash string
+}
+
+// outboxEntry is one journaled tool call.
+type outboxEntry struct {
+	seq            int64
+	idempotencyKey string
+	target         outboxTarget
+	project        string
+	tool           string
+	args           json.RawMessage
+	queuedAt       time.Time
+	attempts       int
+}
+
+// OutboxStatus summarises the offline outbox for the control socket.
+type OutboxStatus struct {
+	Enabled bool `json:"enabled"`
+	Pending int  `json:"pending"`
+	Failed  int  `json:"failed"`
+	// OldestQueuedAt is when the oldest pending call was queued.
+	OldestQueuedAt *time.Time `json:"oldest_queued_at,omitempty"`
+}
+
+// outbox is the SQLite-backed journal. All methods are safe for concurrent
+// use; ordering within a target follows seq.
+type outbox struct {
+	db *sql.DB
+}
+
+// openOutbox opens (creating if needed) the outbox database in dir.
+func openOutbox(ctx context.Context, dir string) (*outbox, error) {
+	db, err := sql.Open("sqlite", filepath.Join(dir, outboxFile))
+	if err != nil {
+		return nil, fmt.Errorf("open %s: %w", outboxFile, err)
+	}
+	stmts := []string{
+		"PRAGMA journal_mode=WAL",
+		"PRAGMA synchronous=NORMAL",
+		"PRAGMA busy_timeout=5000",
+		`CREATE TABLE IF NOT EXISTS outbox (
+			seq             INTEGER PRIMARY KEY AUTOINCREMENT,
+			idempotency_key TEXT NOT NULL UNIQUE,
+			server_url      TEXT NOT NULL,
+			token_hash      TEXT NOT NULL,
+			project         TEXT NOT NULL,
+			tool            TEXT NOT NULL,
+			args            BLOB,
+			queued_at       INTEGER NOT NULL,
+			attempts        INTEGER NOT NULL DEFAULT 0,
+			status          TEXT NOT NULL DEFAULT 'pending',
+			last_error      TEXT NOT NULL DEFAULT ''
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_outbox_target ON outbox (status, server_url, token_hash, seq)`,
+	}
+	for _, s := range stmts {
+		if _, err := db.ExecContext(ctx, s); err != nil {
+			_ = db.Close()
+			return nil, fmt.Errorf("outbox schema: %w", err)
+		}
+	}
+	return &outbox{db: db}, nil
+}
+
+// close closes the database.
+func (o *outbox) close() error {
+	return o.db.Close()
+}
+
+// enqueue journals e and returns its position among the pending entries of
+// its target, counting from 1. Failed entries past outboxFailedRetention are
+// dropped on the way.
+func (o *outbox) enqueue(ctx context.Context, e outboxEntry) (int, error) {
+	if _, err := o.db.ExecContext(ctx,
+		`DELETE FROM outbox WHERE status = 'failed' AND queued_at < ?`,
+		time.Now().Add(-outboxFailedRetention).UnixMilli(),
+	); err != nil {
+		return 0, fmt.Errorf("expire outbox entries: %w", err)
+	}
+
+	var n int
+	if err := o.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM outbox WHERE status = 'pending'`).Scan(&n); err != nil {
+		return 0, fmt.Errorf("count outbox: %w", err)
+	}
+	if n >= maxOutboxEntries {
+		return 0, errOutboxFull
+	}
+
+	res, err := o.db.ExecContext(ctx,
+		`INSERT INTO outbox (idempotency_key, server_url, token_hash, project, tool, args, queued_at) VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		e.idempotencyKey, e.target.serverURL, e.target.tokenHash, e.project, e.tool, []byte(e.args), e.queuedAt.UnixMilli(),
+	)
+	if err != nil {
+		return 0, fmt.Errorf("insert outbox entry: %w", err)
+	}
+	seq, err := res.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("insert outbox entry: %w", err)
+	}
+
+	var pos int
+	if err := o.db.QueryRowContext(ctx,
+		`SELECT COUNT(*) FROM outbox WHERE status = 'pending' AND server_url = ? AND token_hash = ? AND seq <= ?`,
+		e.target.serverURL, e.target.tokenHash, seq,
+	).Scan(&pos); err != nil {
+		return 0, fmt.Errorf("count outbox: %w", err)
+	}
+	return pos, nil
+}
+
+// hasPending reports whether t has pending entries. New calls for such a
+// target are queued behind them to keep the order.
+func (o *outbox) hasPending(ctx context.Context, t outboxTarget) (bool, error) {
+	var one int
+	err := o.db.QueryRowContext(ctx,
+		`SELECT 1 FROM outbox WHERE status = 'pending' AND server_url = ? AND token_hash = ? LIMIT 1`,
+		t.serverURL, t.tokenHash,
+	).Scan(&one)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("query outbox: %w", err)
+	}
+	return true, nil
+}
+
+// targets returns every target with pending entries.
+func (o *outbox) targets(ctx context.Context) ([]outboxTarget, error) {
+	rows, err := o.db.QueryContext(ctx,
+		`SELECT DISTINCT server_url, token_hash FROM outbox WHERE status = 'pending'`,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("query outbox: %w", err)
+	}
+	defer rows.Close()
+
+	var targets []outboxTarget
+	for rows.Next() {
+		var t outboxTarget
+		if err := rows.Scan(&t.serverURL, &t.tokenHash); err != nil {
+			return nil, fmt.Errorf("scan outbox: %w", err)
+		}
+		targets = append(targets, t)
+	}
+	return targets, rows.Err()
+}
+
+// next returns the oldest pending entry of t, or nil when there is none.
+func (o *outbox) next(ctx context.Context, t outboxTarget) (*outboxEntry, error) {
+	e := outboxEntry{target: t}
+	var args []byte
+	var queuedAt int64
+	err := o.db.QueryRowContext(ctx,
+		`SELECT seq, idempotency_key, project, tool, args, queued_at, attempts FROM outbox
+		 WHERE status = 'pending' AND server_url = ? AND token_hash = ? ORDER BY seq LIMIT 1`,
+		t.serverURL, t.tokenHash,
+	).Scan(&e.seq, &e.idempotencyKey, &e.project, &e.tool, &args, &queuedAt, &e.attempts)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("query outbox: %w", err)
+	}
+	e.args = args
+	e.queuedAt = time.UnixMilli(queuedAt)
+	return &e, nil
+}
+
+// remove deletes the entry seq.
+func (o *outbox) remove(ctx context.Context, seq int64) error {
+	if _, err := o.db.ExecContext(ctx, `DELETE FROM outbox WHERE seq = ?`, seq); err != nil {
+		return fmt.Errorf("delete outbox entry: %w", err)
+	}
+	return nil
+}
+
+// recordAttempt counts a replay attempt of seq that hit a transport error.
+func (o *outbox) recordAttempt(ctx context.Context, seq int64, cause error) error {
+	if _, err := o.db.ExecContext(ctx,
+		`UPDATE outbox SET attempts = attempts + 1, last_error = ? WHERE seq = ?`, cause.Error(), seq,
+	); err != nil {
+		return fmt.Errorf("update outbox entry: %w", err)
+	}
+	return nil
+}
+
+// markFailed records that the server rejected seq; it is not retried.
+func (o *outbox) markFailed(ctx context.Context, seq int64, cause error) error {
+	if _, err := o.db.ExecContext(ctx,
+		`UPDATE outbox SET attempts = attempts + 1, status = 'failed', last_error = ? WHERE seq = ?`, cause.Error(), seq,
+	); err != nil {
+		return fmt.Errorf("update outbox entry: %w", err)
+	}
+	return nil
+}
+
+// status counts the entries by status.
+func (o *outbox) status(ctx context.Context) (OutboxStatus, error) {
+	st := OutboxStatus{Enabled: true}
+	var oldest sql.NullInt64
+	err := o.db.QueryRowContext(ctx,
+		`SELECT
+			COALESCE(SUM(CASE WHEN status = 'pending' THEN 1 ELSE 0 END), 0),
+			COALESCE(SUM(CASE WHEN status = 'failed' THEN 1 ELSE 0 END), 0),
+			MIN(CASE WHEN status = 'pending' THEN queued_at END)
+		 FROM outbox`,
+	).Scan(&st.Pending, &st.Failed, &oldest)
+	if err != nil {
+		return OutboxStatus{}, fmt.Errorf("query outbox: %w", err)
+	}
+	if oldest.Valid {
+		t := time.UnixMilli(oldest.Int64)
+		st.OldestQueuedAt = &t
+	}
+	return st, nil
+}
@@ -0,0 +1,262 @@
+package engramcore
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/thebtf/engram/internal/config"
+	"github.com/thebtf/engram/internal/module"
+	"github.com/thebtf/engram/internal/module/obs"
+	pb "github.com/thebtf/engram/proto/engram/v1"
+)
+
+// startOutbox opens the outbox in the module StorageDir and starts the
+// replayer on DaemonCtx. The outbox is optional: without a StorageDir, or
+// when the database cannot be opened, tool calls fail while the server is
+// down exactly as they did before.
+func (m *Module) startOutbox(ctx context.Context, deps module.ModuleDeps) {
+	if deps.StorageDir == "" || deps.DaemonCtx == nil {
+		return
+	}
+	ob, err := openOutbox(ctx, deps.StorageDir)
+	if err != nil {
+		deps.Logger.Warn("engramcore: offline outbox unavailable", "error", err)
+		return
+	}
+	m.outbox = ob
+
+	// Entries queued by a previous daemon run can be replayed as soon as the
+	// host-wide token is known; session tokens arrive with the sessions.
+	m.rememberToken(os.Getenv(config.EnvWorkstationToken))
+
+	replayCtx, cancel := context.WithCancel(deps.DaemonCtx)
+	m.stopReplay = cancel
+	m.replayDone = make(chan struct{})
+	go func() {
+		defer close(m.replayDone)
+		m.runReplayer(replayCtx)
+	}()
+	m.reportOutboxDepth(ctx)
+}
+
+// stopOutbox stops the replayer and closes the outbox. Safe to call more
+// than once.
+func (m *Module) stopOutbox() {
+	m.outboxClose.Do(func() {
+		if m.outbox == nil {
+			return
+		}
+		m.stopReplay()
+		<-m.replayDone
+		if err := m.outbox.close(); err != nil && m.deps.Logger != nil {
+			m.deps.Logger.Warn("engramcore: close outbox", "error", err)
+		}
+	})
+}
+
+// rememberToken keeps token in memory under its hash so the replayer can
+// authenticate entries journaled for it.
+func (m *Module) rememberToken(token string) {
+	if token != "" {
+		m.tokens.Store(hashToken(token), token)
+	}
+}
+
+// tokenFor returns the token with hash h, and false while no session has
+// presented it.
+func (m *Module) tokenFor(h string) (string, bool) {
+	if h == "" {
+		return "", true
+	}
+	v, ok := m.tokens.Load(h)
+	if !ok {
+		return "", false
+	}
+	return v.(string), true
+}
+
+// queueToolCall journals e and returns the acknowledgement the agent sees in
+// place of the tool result. cause is the error that made the call go to the
+// outbox, or nil when it was queued behind earlier entries; it is returned
+// as the call's error if the outbox cannot take the entry.
+func (m *Module) queueToolCall(ctx context.Context, e outboxEntry, cause error) (json.RawMessage, error) {
+	// The call's own context may already be past its deadline.
+	ctx = context.WithoutCancel(ctx)
+	pos, err := m.outbox.enqueue(ctx, e)
+	if err != nil {
+		m.deps.Logger.Warn("engramcore: could not queue tool call",
+			"tool", e.tool,
+			"error", err,
+		)
+		if cause != nil {
+			return nil, cause
+		}
+		return nil, fmt.Errorf("queue tool call: %w", err)
+	}
+	m.deps.Logger.Info("engramcore: tool call queued in outbox",
+		"tool", e.tool,
+		"idempotency_key", e.idempotencyKey,
+		"position", pos,
+		"cause", cause,
+	)
+	m.reportOutboxDepth(ctx)
+	if cause == nil {
+		m.kickReplayer()
+	}
+
+	ack, err := json.Marshal(map[string]any{
+		"queued":          true,
+		"idempotency_key": e.idempotencyKey,
+		"position":        pos,
+		"message":         "engram server unreachable; the call was queued and will be replayed when the server is back",
+	})
+	if err != nil {
+		return nil, err
+	}
+	return buildInnerBlock(ack)
+}
+
+// kickReplayer makes the replayer run now instead of at its next tick.
+func (m *Module) kickReplayer() {
+	select {
+	case m.replayKick <- struct{}{}:
+	default:
+	}
+}
+
+// runReplayer drains the outbox every outboxReplayInterval, and when kicked,
+// until ctx is done.
+func (m *Module) runReplayer(ctx context.Context) {
+	ticker := time.NewTicker(outboxReplayInterval)
+	defer ticker.Stop()
+	for {
+		m.replayOutbox(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-m.replayKick:
+		}
+	}
+}
+
+// replayOutbox replays the pending entries of every target whose server
+// answers Ping.
+func (m *Module) replayOutbox(ctx context.Context) {
+	targets, err := m.outbox.targets(ctx)
+	if err != nil {
+		if ctx.Err() == nil {
+			m.deps.Logger.Warn("engramcore: outbox replay", "error", err)
+		}
+		return
+	}
+	for _, t := range targets {
+		token, ok := m.tokenFor(t.tokenHash)
+		if !ok {
+			continue
+		}
+		conn, err := m.pool.getOrDialGRPC(t.serverURL, token)
+		if err != nil {
+			continue
+		}
+		client := pb.NewEngramServiceClient(conn)
+
+		pingCtx, cancel := context.WithTimeout(ctx, outboxPingTimeout)
+		_, err = client.Ping(pingCtx, &pb.PingRequest{})
+		cancel()
+		if err != nil {
+			continue
+		}
+		m.replayTarget(ctx, client, t)
+	}
+	m.reportOutboxDepth(ctx)
+}
+
+// replayTarget replays the pending entries of t in order. It stops at the
+// first transport error, leaving that entry and the ones after it for the
+// next round.
+func (m *Module) replayTarget(ctx context.Context, client pb.EngramServiceClient, t outboxTarget) {
+	for ctx.Err() == nil {
+		e, err := m.outbox.next(ctx, t)
+		if err != nil || e == nil {
+			return
+		}
+
+		callCtx, cancel := context.WithTimeout(withIdempotencyKey(ctx, e.idempotencyKey), outboxCallTimeout)
+		resp, err := client.CallTool(callCtx, &pb.CallToolRequest{
+			ToolName:      e.tool,
+			ArgumentsJson: e.args,
+			Project:       e.project,
+		})
+		cancel()
+
+		switch {
+		case err != nil && isTransportError(err):
+			if ctx.Err() == nil {
+				_ = m.outbox.recordAttempt(ctx, e.seq, err)
+			}
+			return
+		case err != nil:
+			m.deps.Logger.Warn("engramcore: server rejected queued tool call",
+				"tool", e.tool,
+				"idempotency_key", e.idempotencyKey,
+				"error", err,
+			)
+			if err := m.outbox.markFailed(ctx, e.seq, err); err != nil {
+				return
+			}
+			obs.RecordOutboxReplay(ctx, moduleName, "rejected")
+		default:
+			result := "ok"
+			if resp.IsError {
+				result = "tool_error"
+				m.deps.Logger.Warn("engramcore: queued tool call returned an error",
+					"tool", e.tool,
+					"idempotency_key", e.idempotencyKey,
+					"content", string(resp.ContentJson),
+				)
+			}
+			if err := m.outbox.remove(ctx, e.seq); err != nil {
+				return
+			}
+			obs.RecordOutboxReplay(ctx, moduleName, result)
+			m.deps.Logger.Info("engramcore: replayed queued tool call",
+				"tool", e.tool,
+				"idempotency_key", e.idempotencyKey,
+				"queued_for", time.Since(e.queuedAt).Round(time.Second),
+			)
+		}
+	}
+}
+
+// reportOutboxDepth publishes the outbox depth to the obs metrics.
+func (m *Module) reportOutboxDepth(ctx context.Context) {
+	st, err := m.outbox.status(ctx)
+	if err != nil {
+		return
+	}
+	obs.RecordOutboxDepth(ctx, moduleName, outboxPending, int64(st.Pending))
+	obs.RecordOutboxDepth(ctx, moduleName, outboxFailed, int64(st.Failed))
+}
+
+// OutboxStatus reports the offline outbox for the daemon control socket.
+func (m *Module) OutboxStatus(ctx context.Context) (OutboxStatus, error) {
+	if m.outbox == nil {
+		return OutboxStatus{}, nil
+	}
+	return m.outbox.status(ctx)
+}
+
+// FlushOutbox asks the replayer to try the server now. It returns an error
+// when the outbox is disabled.
+func (m *Module) FlushOutbox() error {
+	if m.outbox == nil {
+		return errors.New("offline outbox disabled")
+	}
+	m.kickReplayer()
+	return nil
+}
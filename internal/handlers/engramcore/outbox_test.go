@@ -0,0 +1,302 @@
+package engramcore
+
+// outbox_test.go — unit tests for the offline outbox: tool classification,
+// the SQLite journal, and queue-then-replay through ProxyHandleTool against a
+// mock gRPC server.
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/thebtf/engram/internal/module"
+	pb "github.com/thebtf/engram/proto/engram/v1"
+	muxcore "github.com/thebtf/mcp-mux/muxcore"
+)
+
+func TestToolIsQueueable(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name string
+		args string
+		want bool
+	}{
+		{"store_memory", `{"content":"x"}`, true},
+		{"store", `{}`, true},
+		{"store", `{"action":"edit"}`, true},
+		{"store", `{"action":"history"}`, false},
+		{"issues", `{}`, false},
+		{"issues", `{"action":"create"}`, true},
+		{"issues", `{"action":"claim"}`, false},
+		{"feedback", `{"action":"rate"}`, true},
+		{"docs", `{"action":"read"}`, false},
+		{"recall", `{}`, false},
+		{"vault", `{"action":"store"}`, false},
+		{"store_credential", `{"name":"k","value":"v"}`, false},
+		{"store", `not json`, false},
+	}
+	for _, tc := range cases {
+		if got := toolIsQueueable(tc.name, json.RawMessage(tc.args)); got != tc.want {
+			t.Errorf("toolIsQueueable(%q, %s) = %v, want %v", tc.name, tc.args, got, tc.want)
+		}
+	}
+}
+
+// TestOutbox_OrderAndPersistence verifies that entries come back in order per
+// target and survive closing and reopening the database.
+func TestOutbox_OrderAndPersistence(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	dir := t.TempDir()
+	ob, err := openOutbox(ctx, dir)
+	if err != nil {
+		t.Fatalf("openOutbox: %v", err)
+	}
+
+	a := outboxTarget{serverURL: "http://a:37777", tokenHash: "aaaa"}
+	b := outboxTarget{serverURL: "http://b:37777", tokenHash: "bbbb"}
+	for i, e := range []outboxEntry{
+		{idempotencyKey: "k1", target: a, tool: "store_memory"},
+		{idempotencyKey: "k2", target: b, tool: "store_memory"},
+		{idempotencyKey: "k3", target: a, tool: "issues"},
+	} {
+		e.queuedAt = time.Now()
+		pos, err := ob.enqueue(ctx, e)
+		if err != nil {
+			t.Fatalf("enqueue %s: %v", e.idempotencyKey, err)
+		}
+		if want := []int{1, 1, 2}[i]; pos != want {
+			t.Errorf("enqueue %s: position = %d, want %d", e.idempotencyKey, pos, want)
+		}
+	}
+	if err := ob.close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+
+	ob, err = openOutbox(ctx, dir)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	defer ob.close()
+
+	st, err := ob.status(ctx)
+	if err != nil {
+		t.Fatalf("status: %v", err)
+	}
+	if st.Pending != 3 || st.OldestQueuedAt == nil {
+		t.Fatalf("status after reopen = %+v, want 3 pending", st)
+	}
+
+	e, err := ob.next(ctx, a)
+	if err != nil || e == nil || e.idempotencyKey != "k1" {
+		t.Fatalf("next(a) = %+v, %v; want k1", e, err)
+	}
+	if err := ob.remove(ctx, e.seq); err != nil {
+		t.Fatalf("remove: %v", err)
+	}
+	e, err = ob.next(ctx, a)
+	if err != nil || e == nil || e.idempotencyKey != "k3" {
+		t.Fatalf("next(a) = %+v, %v; want k3", e, err)
+	}
+	if err := ob.markFailed(ctx, e.seq, status.Error(codes.PermissionDenied, "no")); err != nil {
+		t.Fatalf("markFailed: %v", err)
+	}
+	if e, _ := ob.next(ctx, a); e != nil {
+		t.Errorf("next(a) after markFailed = %+v, want nil", e)
+	}
+
+	st, _ = ob.status(ctx)
+	if st.Pending != 1 || st.Failed != 1 {
+		t.Errorf("status = %+v, want 1 pending and 1 failed", st)
+	}
+}
+
+// TestOutbox_FailedEntriesDoNotFillIt verifies that only pending entries
+// count against maxOutboxEntries and that old failed entries are dropped.
+func TestOutbox_FailedEntriesDoNotFillIt(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	ob, err := openOutbox(ctx, t.TempDir())
+	if err != nil {
+		t.Fatalf("openOutbox: %v", err)
+	}
+	defer ob.close()
+
+	// maxOutboxEntries failed entries, the first one past the retention.
+	expired := time.Now().Add(-outboxFailedRetention - time.Hour).UnixMilli()
+	if _, err := ob.db.ExecContext(ctx, `
+		WITH RECURSIVE n(i) AS (SELECT 1 UNION ALL SELECT i + 1 FROM n WHERE i < ?)
+		INSERT INTO outbox (idempotency_key, server_url, token_hash, project, tool, queued_at, status)
+		SELECT 'failed-' || i, 'http://a:37777', 'aaaa', 'p', 'store_memory',
+			CASE WHEN i = 1 THEN ? ELSE ? END, 'failed'
+		FROM n`,
+		maxOutboxEntries, expired, time.Now().UnixMilli(),
+	); err != nil {
+		t.Fatalf("seed failed entries: %v", err)
+	}
+
+	a := outboxTarget{serverURL: "http://a:37777", tokenHash: "aaaa"}
+	pos, err := ob.enqueue(ctx, outboxEntry{idempotencyKey: "k1", target: a, tool: "store_memory", queuedAt: time.Now()})
+	if err != nil {
+		t.Fatalf("enqueue with a full set of failed entries: %v", err)
+	}
+	if pos != 1 {
+		t.Errorf("position = %d, want 1", pos)
+	}
+
+	st, err := ob.status(ctx)
+	if err != nil {
+		t.Fatalf("status: %v", err)
+	}
+	if st.Pending != 1 || st.Failed != maxOutboxEntries-1 {
+		t.Errorf("status = %+v, want 1 pending and %d failed", st, maxOutboxEntries-1)
+	}
+
+	// Pending entries still count.
+	if _, err := ob.db.ExecContext(ctx, `UPDATE outbox SET status = 'pending'`); err != nil {
+		t.Fatalf("mark pending: %v", err)
+	}
+	if _, err := ob.enqueue(ctx, outboxEntry{idempotencyKey: "k2", target: a, tool: "store_memory", queuedAt: time.Now()}); err != errOutboxFull {
+		t.Errorf("enqueue with a full outbox: err = %v, want errOutboxFull", err)
+	}
+}
+
+// flakyEngramServer is a mock EngramServiceServer that can be taken down and
+// records the tool calls it ran with their idempotency keys.
+type flakyEngramServer struct {
+	pb.UnimplementedEngramServiceServer
+
+	mu    sync.Mutex
+	down  bool
+	calls []string // "tool key"
+}
+
+func (s *flakyEngramServer) setDown(down bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.down = down
+}
+
+func (s *flakyEngramServer) recorded() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.calls...)
+}
+
+func (s *flakyEngramServer) Ping(_ context.Context, _ *pb.PingRequest) (*pb.PingResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.down {
+		return nil, status.Error(codes.Unavailable, "down")
+	}
+	return &pb.PingResponse{}, nil
+}
+
+func (s *flakyEngramServer) CallTool(ctx context.Context, req *pb.CallToolRequest) (*pb.CallToolResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.down {
+		return nil, status.Error(codes.Unavailable, "down")
+	}
+	md, _ := metadata.FromIncomingContext(ctx)
+	key := ""
+	if v := md.Get(idempotencyKeyHeader); len(v) > 0 {
+		key = v[0]
+	}
+	s.calls = append(s.calls, req.ToolName+" "+key)
+	return &pb.CallToolResponse{ContentJson: []byte(`{"ok":true}`)}, nil
+}
+
+// TestProxyHandleTool_QueuesAndReplays verifies that mutating calls made
+// while the server is down are acknowledged as queued and replayed in order,
+// with their idempotency keys, once the server is back; and that reads still
+// fail.
+func TestProxyHandleTool_QueuesAndReplays(t *testing.T) {
+	t.Parallel()
+
+	srv := &flakyEngramServer{}
+	srv.setDown(true)
+	addr := startMockGRPC(t, srv)
+
+	mod := NewModule()
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	if err := mod.Init(ctx, module.ModuleDeps{
+		Logger:     slog.Default(),
+		DaemonCtx:  ctx,
+		StorageDir: t.TempDir(),
+	}); err != nil {
+		t.Fatalf("Init: %v", err)
+	}
+	t.Cleanup(func() { _ = mod.Shutdown(context.Background()) })
+
+	p := muxcore.ProjectContext{
+		ID:  "outbox-test-project",
+		Cwd: t.TempDir(),
+		Env: map[string]string{"ENGRAM_URL": "http://" + addr},
+	}
+	mod.cache.ForceCacheEntry(p.ID, p.ID)
+
+	var keys []string
+	for _, tool := range []string{"store_memory", "store_rule"} {
+		block, err := mod.ProxyHandleTool(ctx, p, tool, json.RawMessage(`{}`))
+		if err != nil {
+			t.Fatalf("ProxyHandleTool(%s) while down: %v", tool, err)
+		}
+		var inner struct {
+			Text string `json:"text"`
+		}
+		var ack struct {
+			Queued         bool   `json:"queued"`
+			IdempotencyKey string `json:"idempotency_key"`
+		}
+		if err := json.Unmarshal(block, &inner); err != nil {
+			t.Fatalf("unmarshal block: %v", err)
+		}
+		if err := json.Unmarshal([]byte(inner.Text), &ack); err != nil || !ack.Queued {
+			t.Fatalf("ProxyHandleTool(%s) = %s, want a queued acknowledgement", tool, block)
+		}
+		keys = append(keys, ack.IdempotencyKey)
+	}
+
+	if _, err := mod.ProxyHandleTool(ctx, p, "recall", json.RawMessage(`{}`)); err == nil {
+		t.Error("read tool call while down: want error, got nil")
+	}
+
+	st, err := mod.OutboxStatus(ctx)
+	if err != nil || st.Pending != 2 {
+		t.Fatalf("OutboxStatus = %+v, %v; want 2 pending", st, err)
+	}
+
+	srv.setDown(false)
+	if err := mod.FlushOutbox(); err != nil {
+		t.Fatalf("FlushOutbox: %v", err)
+	}
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		st, err = mod.OutboxStatus(ctx)
+		if err == nil && st.Pending == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("outbox not drained: %+v, %v", st, err)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	got := srv.recorded()
+	want := []string{"store_memory " + keys[0], "store_rule " + keys[1]}
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("replayed calls = %v, want %v", got, want)
+	}
+}
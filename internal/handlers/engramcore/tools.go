@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"time"
 
 	"github.com/thebtf/engram/internal/config"
 	"github.com/thebtf/engram/internal/module"
@@ -28,6 +29,7 @@ func (m *Module) ProxyTools(ctx context.Context, p muxcore.ProjectContext) ([]mo
 	}
 	token := m.envFor(p, config.EnvWorkstationToken)
 	project := m.cache.Resolve(p)
+	m.rememberToken(token)
 
 	conn, err := m.pool.getOrDialGRPC(serverURL, token)
 	if err != nil {
@@ -75,6 +77,13 @@ func (m *Module) ProxyTools(ctx context.Context, p muxcore.ProjectContext) ([]mo
 //	    sentinel carrying the SAME inner block; the dispatcher detects the
 //	    sentinel and wraps with isError:true. End result is byte-identical
 //	    to v4.2.0 both in content and in the isError boolean.
+//
+// Offline outbox: a mutating call (toolIsQueueable) is sent with an
+// idempotency key. When the server cannot be reached, or the call may not
+// have reached it, the call is journaled and the agent gets a "queued"
+// acknowledgement instead of an error. While a server and keycard have
+// queued calls, new mutating calls for them queue behind, so the server sees
+// them in the order the agent made them.
 func (m *Module) ProxyHandleTool(ctx context.Context, p muxcore.ProjectContext, name string, args json.RawMessage) (json.RawMessage, error) {
 	serverURL, err := m.requireServerURL(p)
 	if err != nil {
@@ -82,10 +91,33 @@ func (m *Module) ProxyHandleTool(ctx context.Context, p muxcore.ProjectContext,
 	}
 	token := m.envFor(p, config.EnvWorkstationToken)
 	project := m.cache.Resolve(p)
+	m.rememberToken(token)
+
+	var entry *outboxEntry
+	if m.outbox != nil && toolIsQueueable(name, args) {
+		if key, err := newIdempotencyKey(); err == nil {
+			entry = &outboxEntry{
+				idempotencyKey: key,
+				target:         outboxTarget{serverURL: serverURL, tokenHash: hashToken(token)},
+				project:        project,
+				tool:           name,
+				args:           args,
+				queuedAt:       time.Now(),
+			}
+			if queued, err := m.outbox.hasPending(ctx, entry.target); err == nil && queued {
+				return m.queueToolCall(ctx, *entry, nil)
+			}
+			ctx = withIdempotencyKey(ctx, key)
+		}
+	}
 
 	conn, err := m.pool.getOrDialGRPC(serverURL, token)
 	if err != nil {
-		return nil, fmt.Errorf("gRPC connect: %w", err)
+		err = fmt.Errorf("gRPC connect: %w", err)
+		if entry != nil {
+			return m.queueToolCall(ctx, *entry, err)
+		}
+		return nil, err
 	}
 	client := pb.NewEngramServiceClient(conn)
 
@@ -95,6 +127,9 @@ func (m *Module) ProxyHandleTool(ctx context.Context, p muxcore.ProjectContext,
 		Project:       project,
 	})
 	if err != nil {
+		if entry != nil && isTransportError(err) {
+			return m.queueToolCall(ctx, *entry, fmt.Errorf("gRPC CallTool: %w", err))
+		}
 		return nil, fmt.Errorf("gRPC CallTool: %w", err)
 	}
 
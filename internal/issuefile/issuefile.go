@@ -0,0 +1,329 @@
+// Package issuefile converts engram issues to and from the interchange formats
+// of engram-import issues-export and issues-import.
+//
+// The JSON format is a Document whose issues use GitHub's REST field names
+// (number, title, body, state, state_reason, labels, user, created_at,
+// updated_at, closed_at) plus an "engram" object carrying what GitHub has no
+// field for: the engram status, priority, type, projects and lifecycle
+// timestamps. A bare JSON array of GitHub issues is accepted as well, and
+// "comments" may be an array of comments or GitHub's comment count.
+//
+// The Markdown format is a directory with one file per issue: YAML frontmatter
+// with the issue fields, the issue body, then each comment introduced by an
+// HTML comment line carrying its metadata:
+//
+//	<!-- engram:comment {"external_id":"engram-comment:9","author_project":"engram","author_agent":"codex","created_at":"2026-03-01T09:30:00Z"} -->
+//
+// Every issue and comment has an external ID. Imports match on it, so
+// re-importing a file updates the issues it created instead of duplicating
+// them.
+package issuefile
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	dbgorm "github.com/thebtf/engram/internal/db/gorm"
+	"github.com/thebtf/engram/pkg/models"
+)
+
+// Version is the Document format version written by this package.
+const Version = 1
+
+// Document is the JSON export of a set of issues.
+type Document struct {
+	ExportedAt time.Time `json:"exported_at"`
+	Issues     []Issue   `json:"issues"`
+	Version    int       `json:"version"`
+}
+
+// Issue is one exported issue.
+type Issue struct {
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+	ClosedAt    *time.Time `json:"closed_at"`
+	User        *User      `json:"user,omitempty"`
+	Engram      *Engram    `json:"engram,omitempty"`
+	ExternalID  string     `json:"external_id,omitempty"`
+	HTMLURL     string     `json:"html_url,omitempty"`
+	Title       string     `json:"title"`
+	Body        string     `json:"body"`
+	State       string     `json:"state"`
+	StateReason string     `json:"state_reason,omitempty"`
+	Labels      Labels     `json:"labels"`
+	Comments    Comments   `json:"comments"`
+	Number      int64      `json:"number,omitempty"`
+}
+
+// User is a GitHub-style author reference.
+type User struct {
+	Login string `json:"login"`
+}
+
+// Engram holds the issue fields GitHub has no equivalent for.
+type Engram struct {
+	Status         string     `json:"status" yaml:"status"`
+	Priority       string     `json:"priority" yaml:"priority"`
+	Type           string     `json:"type" yaml:"type"`
+	SourceProject  string     `json:"source_project" yaml:"source_project"`
+	TargetProject  string     `json:"target_project" yaml:"target_project"`
+	SourceAgent    string     `json:"source_agent,omitempty" yaml:"source_agent,omitempty"`
+	AcknowledgedAt *time.Time `json:"acknowledged_at,omitempty" yaml:"acknowledged_at,omitempty"`
+	ResolvedAt     *time.Time `json:"resolved_at,omitempty" yaml:"resolved_at,omitempty"`
+	ReopenedAt     *time.Time `json:"reopened_at,omitempty" yaml:"reopened_at,omitempty"`
+}
+
+// Comment is one issue comment. AuthorProject and AuthorAgent are engram's
+// authorship; User is the GitHub-style author.
+type Comment struct {
+	CreatedAt     time.Time `json:"created_at"`
+	User          *User     `json:"user,omitempty"`
+	ExternalID    string    `json:"external_id,omitempty"`
+	AuthorProject string    `json:"author_project,omitempty"`
+	AuthorAgent   string    `json:"author_agent,omitempty"`
+	Body          string    `json:"body,omitempty"`
+	ID            int64     `json:"id,omitempty"`
+}
+
+// Labels marshals as GitHub label objects and unmarshals from either label
+// objects or plain strings.
+type Labels []string
+
+// MarshalJSON implements json.Marshaler.
+func (l Labels) MarshalJSON() ([]byte, error) {
+	objects := make([]struct {
+		Name string `json:"name"`
+	}, len(l))
+	for i, name := range l {
+		objects[i].Name = name
+	}
+	return json.Marshal(objects)
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (l *Labels) UnmarshalJSON(data []byte) error {
+	var raw []json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return fmt.Errorf("labels: %w", err)
+	}
+	labels := make(Labels, 0, len(raw))
+	for _, item := range raw {
+		var name string
+		if err := json.Unmarshal(item, &name); err != nil {
+			var object struct {
+				Name string `json:"name"`
+			}
+			if err := json.Unmarshal(item, &object); err != nil {
+				return fmt.Errorf("labels: %w", err)
+			}
+			name = object.Name
+		}
+		if name != "" {
+			labels = append(labels, name)
+		}
+	}
+	*l = labels
+	return nil
+}
+
+// Comments unmarshals from a comment array or from GitHub's comment count,
+// which carries no comments.
+type Comments []Comment
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (c *Comments) UnmarshalJSON(data []byte) error {
+	trimmed := strings.TrimSpace(string(data))
+	if trimmed == "null" || (trimmed != "" && trimmed[0] != '[') {
+		*c = nil
+		return nil
+	}
+	var comments []Comment
+	if err := json.Unmarshal(data, &comments); err != nil {
+		return fmt.Errorf("comments: %w", err)
+	}
+	*c = comments
+	return nil
+}
+
+// UnmarshalDocument parses a Document, or a bare JSON array of issues such as
+// a GitHub issue list.
+func UnmarshalDocument(data []byte) (*Document, error) {
+	trimmed := strings.TrimSpace(string(data))
+	if strings.HasPrefix(trimmed, "[") {
+		var issues []Issue
+		if err := json.Unmarshal(data, &issues); err != nil {
+			return nil, fmt.Errorf("parse issues: %w", err)
+		}
+		return &Document{Version: Version, Issues: issues}, nil
+	}
+	var doc Document
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("parse issues: %w", err)
+	}
+	if doc.Version > Version {
+		return nil, fmt.Errorf("unsupported issue file version %d (max %d)", doc.Version, Version)
+	}
+	return &doc, nil
+}
+
+// FromStore converts a stored issue for export. Issues and comments that were
+// not imported get engram:<id> and engram-comment:<id> external IDs.
+func FromStore(in dbgorm.IssueWithComments) Issue {
+	out := Issue{
+		ExternalID: in.ExternalID,
+		Number:     in.ID,
+		Title:      in.Title,
+		Body:       in.Body,
+		Labels:     Labels(append([]string{}, in.Labels...)),
+		CreatedAt:  in.CreatedAt,
+		UpdatedAt:  in.UpdatedAt,
+		Engram: &Engram{
+			Status:         in.Status,
+			Priority:       in.Priority,
+			Type:           in.Type,
+			SourceProject:  in.SourceProject,
+			TargetProject:  in.TargetProject,
+			SourceAgent:    in.SourceAgent,
+			AcknowledgedAt: in.AcknowledgedAt,
+			ResolvedAt:     in.ResolvedAt,
+			ReopenedAt:     in.ReopenedAt,
+		},
+		Comments: make(Comments, len(in.Comments)),
+	}
+	if out.ExternalID == "" {
+		out.ExternalID = fmt.Sprintf("engram:%d", in.ID)
+	}
+	if login := firstNonEmpty(in.SourceAgent, in.SourceProject); login != "" {
+		out.User = &User{Login: login}
+	}
+
+	out.State = "open"
+	switch in.Status {
+	case "resolved":
+		out.State, out.StateReason = "closed", "completed"
+		out.ClosedAt = in.ResolvedAt
+	case "closed":
+		out.State, out.StateReason = "closed", "completed"
+		out.ClosedAt = in.ClosedAt
+	case "rejected":
+		out.State, out.StateReason = "closed", "not_planned"
+		out.ClosedAt = in.ClosedAt
+	case "reopened":
+		out.StateReason = "reopened"
+	}
+
+	for i, c := range in.Comments {
+		comment := Comment{
+			ID:            c.ID,
+			ExternalID:    c.ExternalID,
+			AuthorProject: c.AuthorProject,
+			AuthorAgent:   c.AuthorAgent,
+			Body:          c.Body,
+			CreatedAt:     c.CreatedAt,
+		}
+		if comment.ExternalID == "" {
+			comment.ExternalID = fmt.Sprintf("engram-comment:%d", c.ID)
+		}
+		if login := firstNonEmpty(c.AuthorAgent, c.AuthorProject); login != "" {
+			comment.User = &User{Login: login}
+		}
+		out.Comments[i] = comment
+	}
+	return out
+}
+
+// ToStore converts an imported issue for IssueStore.ImportIssue.
+// defaultProject is the target (and source) project of issues without an
+// engram block naming one. Issues without an engram block take their status
+// from state and state_reason and their priority from a "priority:<level>"
+// label.
+func (i Issue) ToStore(defaultProject string) (dbgorm.IssueWithComments, error) {
+	externalID := firstNonEmpty(i.ExternalID, i.HTMLURL)
+	if externalID == "" {
+		return dbgorm.IssueWithComments{}, fmt.Errorf("issue #%d %q has no external_id or html_url", i.Number, i.Title)
+	}
+
+	out := dbgorm.IssueWithComments{Issue: dbgorm.Issue{
+		ExternalID: externalID,
+		Title:      i.Title,
+		Body:       i.Body,
+		Labels:     models.JSONStringArray(append([]string{}, i.Labels...)),
+		CreatedAt:  i.CreatedAt,
+		UpdatedAt:  i.UpdatedAt,
+		ClosedAt:   i.ClosedAt,
+	}}
+	if i.User != nil {
+		out.SourceAgent = i.User.Login
+	}
+
+	if e := i.Engram; e != nil {
+		out.Status = e.Status
+		out.Priority = e.Priority
+		out.Type = e.Type
+		out.SourceProject = e.SourceProject
+		out.TargetProject = e.TargetProject
+		out.SourceAgent = firstNonEmpty(e.SourceAgent, out.SourceAgent)
+		out.AcknowledgedAt = e.AcknowledgedAt
+		out.ResolvedAt = e.ResolvedAt
+		out.ReopenedAt = e.ReopenedAt
+		if out.Status != "closed" && out.Status != "rejected" {
+			out.ClosedAt = nil
+		}
+	} else {
+		out.Status = "open"
+		switch {
+		case i.State == "closed" && i.StateReason == "not_planned":
+			out.Status = "rejected"
+		case i.State == "closed":
+			out.Status = "resolved"
+			out.ResolvedAt, out.ClosedAt = i.ClosedAt, nil
+		case i.StateReason == "reopened":
+			out.Status = "reopened"
+		}
+		for _, label := range i.Labels {
+			if level, ok := strings.CutPrefix(label, "priority:"); ok {
+				out.Priority = level
+			}
+		}
+	}
+	if out.TargetProject == "" {
+		out.TargetProject = defaultProject
+	}
+	if out.SourceProject == "" {
+		out.SourceProject = out.TargetProject
+	}
+
+	out.Comments = make([]dbgorm.IssueComment, 0, len(i.Comments))
+	for _, c := range i.Comments {
+		comment := dbgorm.IssueComment{
+			ExternalID:    c.ExternalID,
+			AuthorProject: firstNonEmpty(c.AuthorProject, out.TargetProject),
+			AuthorAgent:   c.AuthorAgent,
+			Body:          c.Body,
+			CreatedAt:     c.CreatedAt,
+		}
+		if comment.AuthorAgent == "" && c.User != nil {
+			comment.AuthorAgent = c.User.Login
+		}
+		if comment.ExternalID == "" {
+			if c.ID != 0 {
+				comment.ExternalID = fmt.Sprintf("%s#comment-%d", externalID, c.ID)
+			} else {
+				comment.ExternalID = fmt.Sprintf("%s#comment-%s", externalID, c.CreatedAt.UTC().Format(time.RFC3339Nano))
+			}
+		}
+		out.Comments = append(out.Comments, comment)
+	}
+	return out, nil
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
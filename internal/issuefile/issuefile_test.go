@@ -0,0 +1,166 @@
+package issuefile
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	dbgorm "github.com/thebtf/engram/internal/db/gorm"
+	"github.com/thebtf/engram/pkg/models"
+)
+
+func storedIssue() dbgorm.IssueWithComments {
+	created := time.Date(2026, 3, 1, 9, 30, 0, 0, time.UTC)
+	resolved := created.Add(26 * time.Hour)
+	return dbgorm.IssueWithComments{
+		Issue: dbgorm.Issue{
+			ID:            42,
+			Title:         "Flaky test: TestDeliverer",
+			Body:          "Fails on CI about once a day.\n\n---\n\nSee the logs.",
+			Status:        "resolved",
+			Priority:      "high",
+			Type:          "bug",
+			SourceProject: "dashboard",
+			TargetProject: "engram",
+			SourceAgent:   "claude-code",
+			Labels:        models.JSONStringArray{"ci", "flaky"},
+			ResolvedAt:    &resolved,
+			CreatedAt:     created,
+			UpdatedAt:     resolved,
+		},
+		Comments: []dbgorm.IssueComment{
+			{ID: 9, AuthorProject: "engram", AuthorAgent: "codex", Body: "Looking into it.", CreatedAt: created.Add(time.Hour)},
+			{ID: 10, AuthorProject: "dashboard", Body: "Fixed by retrying.\n\nThanks!", ExternalID: "github:7#comment-3", CreatedAt: resolved},
+		},
+	}
+}
+
+func TestFromStore(t *testing.T) {
+	in := storedIssue()
+	out := FromStore(in)
+
+	assert.Equal(t, "engram:42", out.ExternalID)
+	assert.Equal(t, int64(42), out.Number)
+	assert.Equal(t, "closed", out.State)
+	assert.Equal(t, "completed", out.StateReason)
+	assert.Equal(t, in.ResolvedAt, out.ClosedAt)
+	assert.Equal(t, &User{Login: "claude-code"}, out.User)
+	require.Len(t, out.Comments, 2)
+	assert.Equal(t, "engram-comment:9", out.Comments[0].ExternalID)
+	assert.Equal(t, "github:7#comment-3", out.Comments[1].ExternalID, "imported comments keep their origin ID")
+
+	data, err := json.Marshal(out)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"labels":[{"name":"ci"},{"name":"flaky"}]`)
+}
+
+func TestRoundTrip(t *testing.T) {
+	in := storedIssue()
+	want, err := FromStore(in).ToStore("")
+	require.NoError(t, err)
+	assert.Equal(t, "engram:42", want.ExternalID)
+	assert.Equal(t, "resolved", want.Status)
+	assert.Nil(t, want.ClosedAt, "resolved issues have no closed_at")
+	assert.Equal(t, in.ResolvedAt, want.ResolvedAt)
+	assert.Equal(t, "dashboard", want.SourceProject)
+	assert.Equal(t, "engram", want.TargetProject)
+	assert.Equal(t, "codex", want.Comments[0].AuthorAgent)
+	assert.Equal(t, "dashboard", want.Comments[1].AuthorProject)
+
+	t.Run("json", func(t *testing.T) {
+		data, err := json.Marshal(Document{Version: Version, Issues: []Issue{FromStore(in)}})
+		require.NoError(t, err)
+		doc, err := UnmarshalDocument(data)
+		require.NoError(t, err)
+		require.Len(t, doc.Issues, 1)
+		got, err := doc.Issues[0].ToStore("")
+		require.NoError(t, err)
+		assert.Equal(t, want, got)
+	})
+
+	t.Run("markdown", func(t *testing.T) {
+		data, err := MarshalMarkdown(FromStore(in))
+		require.NoError(t, err)
+		assert.Contains(t, string(data), "\nFails on CI about once a day.\n")
+		assert.Contains(t, string(data), `<!-- engram:comment {"created_at":"2026-03-01T10:30:00Z"`)
+
+		issue, err := UnmarshalMarkdown(data)
+		require.NoError(t, err)
+		got, err := issue.ToStore("")
+		require.NoError(t, err)
+		assert.Equal(t, in.Body, got.Body, "a --- line in the body is not frontmatter")
+		assert.Equal(t, "Fixed by retrying.\n\nThanks!", got.Comments[1].Body)
+		assert.True(t, want.CreatedAt.Equal(got.CreatedAt))
+		assert.True(t, want.ResolvedAt.Equal(*got.ResolvedAt))
+		got.CreatedAt, got.UpdatedAt, got.ResolvedAt = want.CreatedAt, want.UpdatedAt, want.ResolvedAt
+		for i := range got.Comments {
+			assert.True(t, want.Comments[i].CreatedAt.Equal(got.Comments[i].CreatedAt))
+			got.Comments[i].CreatedAt = want.Comments[i].CreatedAt
+		}
+		assert.Equal(t, want, got)
+	})
+}
+
+func TestToStore_GitHubIssue(t *testing.T) {
+	doc, err := UnmarshalDocument([]byte(`[{
+		"number": 7,
+		"html_url": "https://github.com/acme/app/issues/7",
+		"title": "Crash on start",
+		"body": "Stack trace attached.",
+		"state": "closed",
+		"state_reason": "not_planned",
+		"labels": [{"name": "bug"}, "priority:critical"],
+		"user": {"login": "octocat"},
+		"comments": 3,
+		"created_at": "2025-12-01T10:00:00Z",
+		"updated_at": "2025-12-02T10:00:00Z",
+		"closed_at": "2025-12-02T10:00:00Z"
+	}]`))
+	require.NoError(t, err)
+	require.Len(t, doc.Issues, 1)
+	assert.Empty(t, doc.Issues[0].Comments, "a GitHub comment count carries no comments")
+
+	got, err := doc.Issues[0].ToStore("acme-app")
+	require.NoError(t, err)
+	assert.Equal(t, "https://github.com/acme/app/issues/7", got.ExternalID)
+	assert.Equal(t, "rejected", got.Status)
+	assert.Equal(t, "critical", got.Priority)
+	assert.Equal(t, []string{"bug", "priority:critical"}, []string(got.Labels))
+	assert.Equal(t, "acme-app", got.TargetProject)
+	assert.Equal(t, "acme-app", got.SourceProject)
+	assert.Equal(t, "octocat", got.SourceAgent)
+	require.NotNil(t, got.ClosedAt)
+
+	_, err = Issue{Number: 8, Title: "no id"}.ToStore("acme-app")
+	assert.ErrorContains(t, err, "no external_id or html_url")
+}
+
+func TestUnmarshalDocument_Version(t *testing.T) {
+	_, err := UnmarshalDocument([]byte(`{"version": 99, "issues": []}`))
+	assert.ErrorContains(t, err, "unsupported issue file version")
+}
+
+func TestWriteReadDir(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "issues")
+	paths, err := WriteDir(dir, []Issue{FromStore(storedIssue())})
+	require.NoError(t, err)
+	require.Len(t, paths, 1)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "notes.md"), []byte("no frontmatter"), 0o644))
+
+	issues, errs := ReadDir(dir)
+	require.Len(t, issues, 1)
+	assert.Equal(t, "engram:42", issues[0].ExternalID)
+	assert.Len(t, issues[0].Comments, 2)
+	require.Len(t, errs, 1)
+	assert.ErrorContains(t, errs[0], "notes.md")
+}
+
+func TestFileName(t *testing.T) {
+	assert.Equal(t, "0042-flaky-test-testdeliverer.md", FileName(Issue{Number: 42, Title: "Flaky test: TestDeliverer"}))
+	assert.Equal(t, "github-acme-app-7.md", FileName(Issue{ExternalID: "github:acme/app#7"}))
+}
@@ -0,0 +1,233 @@
+package issuefile
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"gopkg.in/yaml.v3"
+)
+
+// commentMarkerPrefix and commentMarkerSuffix delimit the metadata line that
+// introduces a comment in the Markdown format.
+const (
+	commentMarkerPrefix = "<!-- engram:comment "
+	commentMarkerSuffix = " -->"
+)
+
+// frontmatter is the YAML header of a Markdown issue file, in reading order.
+type frontmatter struct {
+	ExternalID  string     `yaml:"external_id"`
+	Number      int64      `yaml:"number,omitempty"`
+	HTMLURL     string     `yaml:"html_url,omitempty"`
+	Title       string     `yaml:"title"`
+	State       string     `yaml:"state"`
+	StateReason string     `yaml:"state_reason,omitempty"`
+	Labels      []string   `yaml:"labels,flow"`
+	Author      string     `yaml:"author,omitempty"`
+	CreatedAt   time.Time  `yaml:"created_at"`
+	UpdatedAt   time.Time  `yaml:"updated_at"`
+	ClosedAt    *time.Time `yaml:"closed_at,omitempty"`
+	Engram      *Engram    `yaml:"engram,omitempty"`
+}
+
+// MarshalMarkdown renders an issue as YAML frontmatter, the issue body and
+// its comments.
+func MarshalMarkdown(issue Issue) ([]byte, error) {
+	header := frontmatter{
+		ExternalID:  issue.ExternalID,
+		Number:      issue.Number,
+		HTMLURL:     issue.HTMLURL,
+		Title:       issue.Title,
+		State:       issue.State,
+		StateReason: issue.StateReason,
+		Labels:      issue.Labels,
+		CreatedAt:   issue.CreatedAt,
+		UpdatedAt:   issue.UpdatedAt,
+		ClosedAt:    issue.ClosedAt,
+		Engram:      issue.Engram,
+	}
+	if header.Labels == nil {
+		header.Labels = []string{}
+	}
+	if issue.User != nil {
+		header.Author = issue.User.Login
+	}
+	front, err := yaml.Marshal(header)
+	if err != nil {
+		return nil, fmt.Errorf("marshal frontmatter: %w", err)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("---\n")
+	buf.Write(front)
+	buf.WriteString("---\n\n")
+	if body := strings.TrimSpace(issue.Body); body != "" {
+		buf.WriteString(body)
+		buf.WriteString("\n")
+	}
+	for _, c := range issue.Comments {
+		body := strings.TrimSpace(c.Body)
+		c.Body = ""
+		meta, err := json.Marshal(c)
+		if err != nil {
+			return nil, fmt.Errorf("marshal comment: %w", err)
+		}
+		buf.WriteString("\n" + commentMarkerPrefix)
+		buf.Write(meta)
+		buf.WriteString(commentMarkerSuffix + "\n")
+		buf.WriteString(body)
+		buf.WriteString("\n")
+	}
+	return buf.Bytes(), nil
+}
+
+// UnmarshalMarkdown parses a file written by MarshalMarkdown.
+func UnmarshalMarkdown(data []byte) (Issue, error) {
+	content := strings.ReplaceAll(string(data), "\r\n", "\n")
+	if !strings.HasPrefix(content, "---\n") {
+		return Issue{}, fmt.Errorf("missing YAML frontmatter")
+	}
+	front, rest, ok := strings.Cut(content[len("---\n"):], "\n---\n")
+	if !ok {
+		return Issue{}, fmt.Errorf("malformed YAML frontmatter")
+	}
+
+	var header frontmatter
+	if err := yaml.Unmarshal([]byte(front), &header); err != nil {
+		return Issue{}, fmt.Errorf("parse YAML frontmatter: %w", err)
+	}
+	issue := Issue{
+		ExternalID:  header.ExternalID,
+		Number:      header.Number,
+		HTMLURL:     header.HTMLURL,
+		Title:       header.Title,
+		State:       header.State,
+		StateReason: header.StateReason,
+		Labels:      header.Labels,
+		CreatedAt:   header.CreatedAt,
+		UpdatedAt:   header.UpdatedAt,
+		ClosedAt:    header.ClosedAt,
+		Engram:      header.Engram,
+	}
+	if header.Author != "" {
+		issue.User = &User{Login: header.Author}
+	}
+
+	var body strings.Builder
+	var current *Comment
+	flush := func() {
+		text := strings.TrimSpace(body.String())
+		body.Reset()
+		if current == nil {
+			issue.Body = text
+			return
+		}
+		current.Body = text
+		issue.Comments = append(issue.Comments, *current)
+	}
+	for _, line := range strings.Split(rest, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, commentMarkerPrefix) && strings.HasSuffix(trimmed, commentMarkerSuffix) {
+			flush()
+			meta := strings.TrimSuffix(strings.TrimPrefix(trimmed, commentMarkerPrefix), commentMarkerSuffix)
+			var c Comment
+			if err := json.Unmarshal([]byte(meta), &c); err != nil {
+				return Issue{}, fmt.Errorf("parse comment metadata: %w", err)
+			}
+			current = &c
+			continue
+		}
+		body.WriteString(line)
+		body.WriteString("\n")
+	}
+	flush()
+	return issue, nil
+}
+
+// FileName returns the Markdown file name for an issue: its number (or
+// external ID) and a slug of its title.
+func FileName(issue Issue) string {
+	prefix := slug(issue.ExternalID, 40)
+	if issue.Number > 0 {
+		prefix = fmt.Sprintf("%04d", issue.Number)
+	}
+	if title := slug(issue.Title, 60); title != "" {
+		return prefix + "-" + title + ".md"
+	}
+	return prefix + ".md"
+}
+
+// slug lowercases s and joins its letter and digit runs with dashes, capped
+// at max bytes.
+func slug(s string, max int) string {
+	fields := strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	out := strings.Join(fields, "-")
+	if len(out) > max {
+		out = strings.TrimRight(out[:max], "-")
+	}
+	return out
+}
+
+// WriteDir writes one Markdown file per issue into dir, creating it if
+// needed, and returns the paths written.
+func WriteDir(dir string, issues []Issue) ([]string, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create %s: %w", dir, err)
+	}
+	paths := make([]string, 0, len(issues))
+	for _, issue := range issues {
+		data, err := MarshalMarkdown(issue)
+		if err != nil {
+			return paths, fmt.Errorf("issue %s: %w", issue.ExternalID, err)
+		}
+		path := filepath.Join(dir, FileName(issue))
+		if err := os.WriteFile(path, data, 0o644); err != nil {
+			return paths, fmt.Errorf("write %s: %w", path, err)
+		}
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
+
+// ReadDir parses every .md file in dir (non-recursive), in file name order.
+// Files that fail to parse are reported in the returned errors and skipped.
+func ReadDir(dir string) ([]Issue, []error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, []error{fmt.Errorf("read %s: %w", dir, err)}
+	}
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".md") {
+			names = append(names, entry.Name())
+		}
+	}
+	sort.Strings(names)
+
+	var issues []Issue
+	var errs []error
+	for _, name := range names {
+		path := filepath.Join(dir, name)
+		data, err := os.ReadFile(path)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("read %s: %w", path, err))
+			continue
+		}
+		issue, err := UnmarshalMarkdown(data)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", path, err))
+			continue
+		}
+		issues = append(issues, issue)
+	}
+	return issues, errs
+}
@@ -0,0 +1,199 @@
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	gormlib "gorm.io/gorm"
+
+	"github.com/thebtf/engram/internal/auth"
+)
+
+// keycardDeniedTools are tools a project-scoped keycard may not call at all:
+// they administer or bulk-load data across every project.
+var keycardDeniedTools = map[string]bool{
+	"admin":            true,
+	"import_instincts": true,
+}
+
+// toolProjectArgs are the tool arguments through which a call names a project.
+var toolProjectArgs = []string{"project", "source_project", "target_project"}
+
+// toolProjectListArgs are the tool arguments through which a call names
+// several projects (recall's cross-project search).
+var toolProjectListArgs = []string{"include_projects", "exclude_projects"}
+
+// scopeToolCall enforces a project-scoped keycard's allowlist on a tool call
+// and returns the arguments to run it with. Every project the call names —
+// in its arguments or as the call's project (X-Engram-Project, or the gRPC
+// CallTool project) — must be allowed, and one of them must be named. A call
+// that names none in its arguments is pinned to the call's project, so tools
+// that default to "every project" only see that one. Cross-project searches
+// (scope=all, or an include_projects list) are refused outright. Calls from
+// other identities pass through unchanged.
+func scopeToolCall(ctx context.Context, name string, args json.RawMessage) (json.RawMessage, error) {
+	id, ok := auth.IdentityFrom(ctx)
+	if !ok || !id.IsProjectScoped() {
+		return args, nil
+	}
+	if keycardDeniedTools[name] {
+		return nil, fmt.Errorf("%w: tool %q is not available to project-scoped keycards", auth.ErrProjectForbidden, name)
+	}
+
+	// UseNumber keeps large integer IDs intact if the arguments are
+	// re-encoded with a pinned project.
+	m := map[string]any{}
+	if len(args) > 0 {
+		dec := json.NewDecoder(bytes.NewReader(args))
+		dec.UseNumber()
+		if err := dec.Decode(&m); err != nil {
+			return nil, fmt.Errorf("invalid arguments: %w", err)
+		}
+	}
+	if scope := coerceString(m["scope"], ""); scope == "global" || scope == "all" {
+		return nil, fmt.Errorf("%w: scope %q spans every project", auth.ErrProjectForbidden, scope)
+	}
+
+	if len(coerceStringSlice(m["include_projects"])) > 0 {
+		return nil, fmt.Errorf("%w: include_projects searches across projects", auth.ErrProjectForbidden)
+	}
+
+	var named []string
+	for _, key := range toolProjectArgs {
+		if p := coerceString(m[key], ""); p != "" {
+			named = append(named, p)
+		}
+	}
+	for _, key := range toolProjectListArgs {
+		for _, p := range coerceStringSlice(m[key]) {
+			if p != "" {
+				named = append(named, p)
+			}
+		}
+	}
+	pinned := projectFromContext(ctx)
+	if pinned != "" {
+		named = append(named, pinned)
+	}
+	if err := id.CheckProjects(named...); err != nil {
+		return nil, err
+	}
+
+	if coerceString(m["project"], "") != "" || pinned == "" {
+		return args, nil
+	}
+	m["project"] = pinned
+	pinnedArgs, err := json.Marshal(m)
+	if err != nil {
+		return nil, fmt.Errorf("pin project: %w", err)
+	}
+	return pinnedArgs, nil
+}
+
+// recordOwnersFunc returns the projects the record id belongs to, or none
+// when there is no such record.
+type recordOwnersFunc func(ctx context.Context, id int64) ([]string, error)
+
+// toolRecordIDs returns the memories and issues a call of tool name with
+// arguments m addresses by ID. Zero IDs are left to the handler to reject.
+func toolRecordIDs(name string, m map[string]any) (memories, issues []int64) {
+	add := func(ids []int64, keys ...string) []int64 {
+		for _, key := range keys {
+			if id := coerceInt64(m[key], 0); id != 0 {
+				ids = append(ids, id)
+			}
+		}
+		return ids
+	}
+	switch name {
+	case "store":
+		switch coerceString(m["action"], "create") {
+		case "edit", "history", "diff", "restore":
+			memories = add(memories, "id")
+		case "merge":
+			memories = add(memories, "source_id", "target_id")
+		}
+	case "feedback", "rate_memory", "suppress_memory":
+		memories = add(memories, "id")
+	case "issues":
+		switch coerceString(m["action"], "list") {
+		case "create", "list":
+		case "link", "unlink":
+			issues = add(issues, "id", "linked_id")
+		default:
+			issues = add(issues, "id")
+		}
+	}
+	return memories, issues
+}
+
+// checkToolRecords enforces a project-scoped keycard's allowlist on the
+// memories and issues a tool call addresses by ID: the keycard must be
+// allowed the memory's project, and the source or target project of each
+// issue, as on the REST routes. A record that does not exist has no owners,
+// so the keycard is refused without learning whether it exists. Calls from
+// other identities pass through.
+func checkToolRecords(ctx context.Context, name string, args json.RawMessage, memoryOwners, issueOwners recordOwnersFunc) error {
+	id, ok := auth.IdentityFrom(ctx)
+	if !ok || !id.IsProjectScoped() {
+		return nil
+	}
+	m, err := parseArgs(args)
+	if err != nil {
+		return nil // the handler reports malformed arguments
+	}
+
+	memories, issues := toolRecordIDs(name, m)
+	check := func(kind string, ids []int64, owners recordOwnersFunc) error {
+		for _, rid := range ids {
+			projects, err := owners(ctx, rid)
+			if err != nil {
+				return fmt.Errorf("look up %s %d: %w", kind, rid, err)
+			}
+			if !slices.ContainsFunc(projects, id.CanAccessProject) {
+				return fmt.Errorf("%w: %s %d", auth.ErrProjectForbidden, kind, rid)
+			}
+		}
+		return nil
+	}
+	if err := check("memory", memories, memoryOwners); err != nil {
+		return err
+	}
+	return check("issue", issues, issueOwners)
+}
+
+// memoryOwners returns the project of memory id. Implements recordOwnersFunc.
+func (s *Server) memoryOwners(ctx context.Context, id int64) ([]string, error) {
+	if s.memoryStore == nil {
+		return nil, nil
+	}
+	memory, err := s.memoryStore.Get(ctx, id)
+	if errors.Is(err, gormlib.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return []string{memory.Project}, nil
+}
+
+// issueOwners returns the source and target project of issue id. Implements
+// recordOwnersFunc.
+func (s *Server) issueOwners(ctx context.Context, id int64) ([]string, error) {
+	if s.issueStore == nil {
+		return nil, nil
+	}
+	issue, _, err := s.issueStore.GetIssue(ctx, id)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return []string{issue.SourceProject, issue.TargetProject}, nil
+}
@@ -0,0 +1,112 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/thebtf/engram/internal/auth"
+)
+
+func TestScopeToolCall(t *testing.T) {
+	scoped := auth.WithIdentity(context.Background(), auth.Client("read-write", "uuid-1", "acme"))
+
+	// Unscoped callers pass through untouched.
+	args := json.RawMessage(`{"action":"search"}`)
+	got, err := scopeToolCall(context.Background(), "recall", args)
+	if err != nil || string(got) != string(args) {
+		t.Fatalf("unscoped call changed: %s, %v", got, err)
+	}
+
+	// A call without a project in its arguments is pinned to the call's project.
+	got, err = scopeToolCall(contextWithProject(scoped, "acme"), "recall", json.RawMessage(`{"id":9007199254740993}`))
+	if err != nil {
+		t.Fatalf("pinned call: %v", err)
+	}
+	var m map[string]json.RawMessage
+	if err := json.Unmarshal(got, &m); err != nil {
+		t.Fatal(err)
+	}
+	if string(m["project"]) != `"acme"` || string(m["id"]) != "9007199254740993" {
+		t.Errorf("pinned args = %s", got)
+	}
+
+	denied := []struct {
+		name string
+		ctx  context.Context
+		tool string
+		args string
+	}{
+		{"other project in args", scoped, "recall", `{"project":"globex"}`},
+		{"other call project", contextWithProject(scoped, "globex"), "recall", `{}`},
+		{"args and call disagree", contextWithProject(scoped, "acme"), "issues", `{"target_project":"globex"}`},
+		{"no project at all", scoped, "recall", `{}`},
+		{"global scope", contextWithProject(scoped, "acme"), "vault", `{"scope":"global"}`},
+		{"admin tool", contextWithProject(scoped, "acme"), "admin", `{}`},
+		{"include other project", contextWithProject(scoped, "acme"), "recall", `{"action":"search","query":"x","include_projects":["globex"]}`},
+		{"include own project", contextWithProject(scoped, "acme"), "recall", `{"action":"search","query":"x","include_projects":["acme"]}`},
+		{"exclude other project", contextWithProject(scoped, "acme"), "recall", `{"action":"search","exclude_projects":["globex"]}`},
+	}
+	for _, c := range denied {
+		if _, err := scopeToolCall(c.ctx, c.tool, json.RawMessage(c.args)); !errors.Is(err, auth.ErrProjectForbidden) {
+			t.Errorf("%s: err = %v, want ErrProjectForbidden", c.name, err)
+		}
+	}
+}
+
+func TestCheckToolRecords(t *testing.T) {
+	scoped := auth.WithIdentity(context.Background(), auth.Client("read-write", "uuid-1", "acme"))
+
+	// Memory 1 and issue 10 belong to acme; memory 2 and issue 20 to globex;
+	// issue 30 was filed by globex against acme.
+	memoryOwners := func(_ context.Context, id int64) ([]string, error) {
+		return map[int64][]string{1: {"acme"}, 2: {"globex"}}[id], nil
+	}
+	issueOwners := func(_ context.Context, id int64) ([]string, error) {
+		return map[int64][]string{10: {"acme", "acme"}, 20: {"globex", "globex"}, 30: {"globex", "acme"}}[id], nil
+	}
+
+	allowed := []struct{ tool, args string }{
+		{"store", `{"action":"edit","id":1,"content":"x"}`},
+		{"store", `{"action":"merge","source_id":1,"target_id":1}`},
+		{"issues", `{"action":"get","id":10}`},
+		{"issues", `{"action":"comment","id":30,"body":"x"}`},
+		{"issues", `{"action":"link","id":10,"linked_id":30,"link_type":"blocks"}`},
+		{"issues", `{"action":"list"}`},
+		{"store", `{"content":"new memory"}`},
+	}
+	for _, c := range allowed {
+		if err := checkToolRecords(scoped, c.tool, json.RawMessage(c.args), memoryOwners, issueOwners); err != nil {
+			t.Errorf("%s %s: %v", c.tool, c.args, err)
+		}
+	}
+
+	denied := []struct{ tool, args string }{
+		{"store", `{"action":"edit","id":2,"content":"x"}`},
+		{"store", `{"action":"history","id":2}`},
+		{"store", `{"action":"diff","id":2,"from":1,"to":2}`},
+		{"store", `{"action":"restore","id":2,"version":1}`},
+		{"store", `{"action":"merge","source_id":2,"target_id":1}`},
+		{"feedback", `{"action":"suppress","id":2}`},
+		{"suppress_memory", `{"id":2}`},
+		{"store", `{"action":"edit","id":99}`},
+		{"issues", `{"action":"get","id":20}`},
+		{"issues", `{"action":"update","id":20,"status":"resolved"}`},
+		{"issues", `{"action":"close","id":20}`},
+		{"issues", `{"action":"claim","id":20}`},
+		{"issues", `{"action":"release","id":20}`},
+		{"issues", `{"action":"link","id":10,"linked_id":20,"link_type":"blocks"}`},
+		{"issues", `{"action":"unlink","id":20,"linked_id":10}`},
+	}
+	for _, c := range denied {
+		if err := checkToolRecords(scoped, c.tool, json.RawMessage(c.args), memoryOwners, issueOwners); !errors.Is(err, auth.ErrProjectForbidden) {
+			t.Errorf("%s %s: err = %v, want ErrProjectForbidden", c.tool, c.args, err)
+		}
+	}
+
+	// Unscoped callers are not checked.
+	if err := checkToolRecords(context.Background(), "store", json.RawMessage(`{"action":"edit","id":2}`), memoryOwners, issueOwners); err != nil {
+		t.Errorf("unscoped: %v", err)
+	}
+}
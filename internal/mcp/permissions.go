@@ -0,0 +1,128 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/thebtf/engram/internal/auth"
+)
+
+// actionPermissions classifies the actions of a consolidated tool. An action
+// not listed in actions, or a call whose arguments do not parse, needs
+// otherwise: the stricter permission of the tool, so that a malformed call
+// is never let through on the weaker one.
+type actionPermissions struct {
+	defaultAction string
+	actions       map[string]auth.Permission
+	otherwise     auth.Permission
+}
+
+// consolidatedToolPermissions covers the tools that take an "action"
+// argument. Their default actions mirror the tool handlers.
+var consolidatedToolPermissions = map[string]actionPermissions{
+	"recall": {defaultAction: "search", otherwise: auth.PermMemoryRead},
+	"store": {
+		defaultAction: "create",
+		actions: map[string]auth.Permission{
+			"history": auth.PermMemoryRead,
+			"diff":    auth.PermMemoryRead,
+			"import":  auth.PermRulesWrite,
+		},
+		otherwise: auth.PermMemoryWrite,
+	},
+	"feedback": {otherwise: auth.PermMemoryWrite},
+	"vault": {
+		actions: map[string]auth.Permission{
+			"get":    auth.PermVaultRead,
+			"list":   auth.PermVaultRead,
+			"status": auth.PermVaultRead,
+		},
+		otherwise: auth.PermVaultWrite,
+	},
+	"docs": {
+		actions: map[string]auth.Permission{
+			"read":        auth.PermDocsRead,
+			"list":        auth.PermDocsRead,
+			"history":     auth.PermDocsRead,
+			"collections": auth.PermDocsRead,
+			"documents":   auth.PermDocsRead,
+			"get_doc":     auth.PermDocsRead,
+			"search_docs": auth.PermDocsRead,
+		},
+		otherwise: auth.PermDocsWrite,
+	},
+	"admin": {otherwise: auth.PermMemoryRead},
+	"issues": {
+		defaultAction: "list",
+		actions: map[string]auth.Permission{
+			"list": auth.PermIssuesRead,
+			"get":  auth.PermIssuesRead,
+		},
+		otherwise: auth.PermIssuesWrite,
+	},
+}
+
+// toolPermissions covers the legacy single-purpose tools. Tools in neither
+// table need memory:read; callTool rejects the ones it does not know.
+var toolPermissions = map[string]auth.Permission{
+	"store_rule":           auth.PermRulesWrite,
+	"import_instincts":     auth.PermRulesWrite,
+	"store_memory":         auth.PermMemoryWrite,
+	"rate_memory":          auth.PermMemoryWrite,
+	"suppress_memory":      auth.PermMemoryWrite,
+	"store_credential":     auth.PermVaultWrite,
+	"vault_store":          auth.PermVaultWrite,
+	"delete_credential":    auth.PermVaultWrite,
+	"vault_delete":         auth.PermVaultWrite,
+	"get_credential":       auth.PermVaultRead,
+	"vault_get":            auth.PermVaultRead,
+	"list_credentials":     auth.PermVaultRead,
+	"vault_list":           auth.PermVaultRead,
+	"vault_status":         auth.PermVaultRead,
+	"ingest_document":      auth.PermDocsWrite,
+	"remove_document":      auth.PermDocsWrite,
+	"doc_ingest":           auth.PermDocsWrite,
+	"doc_remove":           auth.PermDocsWrite,
+	"doc_create":           auth.PermDocsWrite,
+	"doc_update":           auth.PermDocsWrite,
+	"doc_comment":          auth.PermDocsWrite,
+	"list_collections":     auth.PermDocsRead,
+	"list_documents":       auth.PermDocsRead,
+	"get_document":         auth.PermDocsRead,
+	"search_collection":    auth.PermDocsRead,
+	"doc_list_collections": auth.PermDocsRead,
+	"doc_list_documents":   auth.PermDocsRead,
+	"doc_get":              auth.PermDocsRead,
+	"doc_search":           auth.PermDocsRead,
+	"doc_read":             auth.PermDocsRead,
+	"doc_list":             auth.PermDocsRead,
+	"doc_history":          auth.PermDocsRead,
+}
+
+// toolPermission returns the permission a call of tool name with args needs.
+func toolPermission(name string, args json.RawMessage) auth.Permission {
+	if ap, ok := consolidatedToolPermissions[name]; ok {
+		m, err := parseArgs(args)
+		if err != nil {
+			return ap.otherwise
+		}
+		if p, ok := ap.actions[coerceString(m["action"], ap.defaultAction)]; ok {
+			return p
+		}
+		return ap.otherwise
+	}
+	if p, ok := toolPermissions[name]; ok {
+		return p
+	}
+	return auth.PermMemoryRead
+}
+
+// authorizeToolCall runs the auth policy on a tool call. Like every other
+// check in this package it passes when ctx carries no identity.
+func authorizeToolCall(ctx context.Context, name string, args json.RawMessage) error {
+	if err := auth.Authorize(ctx, toolPermission(name, args)); err != nil {
+		return fmt.Errorf("tool %q: %w", name, err)
+	}
+	return nil
+}
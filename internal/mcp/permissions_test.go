@@ -0,0 +1,60 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/thebtf/engram/internal/auth"
+)
+
+func TestToolPermission(t *testing.T) {
+	cases := []struct {
+		tool string
+		args string
+		want auth.Permission
+	}{
+		{"recall", `{}`, auth.PermMemoryRead},
+		{"store", `{}`, auth.PermMemoryWrite},
+		{"store", `{"action":"history"}`, auth.PermMemoryRead},
+		{"store", `{"action":"import"}`, auth.PermRulesWrite},
+		{"vault", `{"action":"get"}`, auth.PermVaultRead},
+		{"vault", `{"action":"delete"}`, auth.PermVaultWrite},
+		{"vault", `not json`, auth.PermVaultWrite},
+		{"docs", `{"action":"search_docs"}`, auth.PermDocsRead},
+		{"docs", `{"action":"ingest"}`, auth.PermDocsWrite},
+		{"issues", `{}`, auth.PermIssuesRead},
+		{"issues", `{"action":"create"}`, auth.PermIssuesWrite},
+		{"store_rule", `{}`, auth.PermRulesWrite},
+		{"get_credential", `{}`, auth.PermVaultRead},
+		{"find_by_file", `{}`, auth.PermMemoryRead},
+	}
+	for _, c := range cases {
+		if got := toolPermission(c.tool, json.RawMessage(c.args)); got != c.want {
+			t.Errorf("toolPermission(%s, %s) = %s, want %s", c.tool, c.args, got, c.want)
+		}
+	}
+}
+
+func TestAuthorizeToolCall(t *testing.T) {
+	// A CI keycard can file issues but never read the vault.
+	ci := auth.WithIdentity(context.Background(), auth.Identity{
+		Role:        "ci",
+		Source:      auth.SourceClient,
+		Permissions: []auth.Permission{auth.PermIssuesRead, auth.PermIssuesWrite},
+	})
+	if err := authorizeToolCall(ci, "issues", json.RawMessage(`{"action":"create"}`)); err != nil {
+		t.Errorf("ci issues create: %v", err)
+	}
+	for _, tool := range []string{"vault", "get_credential", "vault_list"} {
+		err := authorizeToolCall(ci, tool, json.RawMessage(`{"action":"get"}`))
+		if !errors.Is(err, auth.ErrPermissionDenied) {
+			t.Errorf("ci %s: err = %v, want ErrPermissionDenied", tool, err)
+		}
+	}
+
+	if err := authorizeToolCall(context.Background(), "vault", json.RawMessage(`{"action":"delete"}`)); err != nil {
+		t.Errorf("no identity: %v", err)
+	}
+}
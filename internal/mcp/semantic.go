@@ -0,0 +1,122 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/thebtf/engram/internal/db/gorm"
+	"github.com/thebtf/engram/internal/embedding"
+	"github.com/thebtf/engram/pkg/models"
+)
+
+// Recall search modes.
+const (
+	recallModeFTS      = "fts"
+	recallModeSemantic = "semantic"
+	recallModeHybrid   = "hybrid"
+)
+
+const (
+	// rrfK is the reciprocal rank fusion constant: a hit at rank r in a list
+	// contributes 1/(rrfK+r). 60 is the value from the original RRF paper and
+	// keeps one list's top hit from drowning out agreement between both lists.
+	rrfK = 60
+	// defaultRecallMinSimilarity drops vector hits that share little more than
+	// common n-grams with the query.
+	defaultRecallMinSimilarity = 0.2
+)
+
+// embedMemory stores the embedding of a freshly written memory so semantic
+// recall sees it immediately. Failures are logged rather than returned: the
+// memory itself is stored and the background embedder retries it.
+func (s *Server) embedMemory(ctx context.Context, mem *models.Memory) {
+	if s.embedder == nil || s.memoryStore == nil {
+		return
+	}
+	vec, err := embedding.EmbedOne(ctx, s.embedder, mem.Content)
+	if err != nil {
+		log.Warn().Err(err).Int64("memory_id", mem.ID).Msg("embed memory failed; background embedder will retry")
+		return
+	}
+	if err := s.memoryStore.UpsertEmbedding(ctx, mem.ID, s.embedder.Name(), vec, gorm.MemoryContentHash(mem.Content)); err != nil {
+		log.Warn().Err(err).Int64("memory_id", mem.ID).Msg("store memory embedding failed; background embedder will retry")
+	}
+}
+
+// searchMemoriesVector embeds query and returns the most similar memories in the
+// scope of params (Query and Cursor are ignored).
+func (s *Server) searchMemoriesVector(ctx context.Context, query string, params gorm.MemorySearchParams, minSimilarity float64) ([]gorm.MemoryVectorHit, error) {
+	vec, err := embedding.EmbedOne(ctx, s.embedder, query)
+	if err != nil {
+		return nil, fmt.Errorf("embed query: %w", err)
+	}
+	return s.memoryStore.SearchVector(ctx, gorm.MemoryVectorParams{
+		Project:         params.Project,
+		Model:           s.embedder.Name(),
+		IncludeProjects: params.IncludeProjects,
+		ExcludeProjects: params.ExcludeProjects,
+		Types:           params.Types,
+		Vector:          vec,
+		MinImportance:   params.MinImportance,
+		MinSimilarity:   minSimilarity,
+		Limit:           params.Limit,
+	})
+}
+
+// searchMemories runs a recall query in the given mode. fts pages through
+// MemoryStore.Search; semantic ranks by vector similarity alone; hybrid fuses
+// both rankings. Semantic and hybrid results are a single page (no cursor).
+func (s *Server) searchMemories(ctx context.Context, params gorm.MemorySearchParams, mode string, minSimilarity float64) (*gorm.MemorySearchPage, error) {
+	if mode == recallModeFTS {
+		return s.memoryStore.Search(ctx, params)
+	}
+
+	vectorHits, err := s.searchMemoriesVector(ctx, params.Query, params, minSimilarity)
+	if err != nil {
+		return nil, err
+	}
+	if mode == recallModeSemantic {
+		page := &gorm.MemorySearchPage{Hits: make([]gorm.MemorySearchHit, 0, len(vectorHits))}
+		for _, hit := range vectorHits {
+			page.Hits = append(page.Hits, gorm.MemorySearchHit{Memory: hit.Memory, Score: hit.Similarity, Similarity: hit.Similarity})
+		}
+		return page, nil
+	}
+
+	ftsPage, err := s.memoryStore.Search(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+	return &gorm.MemorySearchPage{Hits: fuseMemoryHits(ftsPage.Hits, vectorHits, params.Limit)}, nil
+}
+
+// fuseMemoryHits merges a full-text and a vector ranking with reciprocal rank
+// fusion. A memory found by both lists keeps its snippet and similarity and
+// scores the sum of its two contributions. Ties keep full-text order first.
+func fuseMemoryHits(fts []gorm.MemorySearchHit, vector []gorm.MemoryVectorHit, limit int) []gorm.MemorySearchHit {
+	fused := make([]gorm.MemorySearchHit, 0, len(fts)+len(vector))
+	index := make(map[int64]int, len(fts)+len(vector))
+	for rank, hit := range fts {
+		index[hit.Memory.ID] = len(fused)
+		hit.Score = 1 / float64(rrfK+rank+1)
+		fused = append(fused, hit)
+	}
+	for rank, hit := range vector {
+		contribution := 1 / float64(rrfK+rank+1)
+		if i, ok := index[hit.Memory.ID]; ok {
+			fused[i].Score += contribution
+			fused[i].Similarity = hit.Similarity
+			continue
+		}
+		index[hit.Memory.ID] = len(fused)
+		fused = append(fused, gorm.MemorySearchHit{Memory: hit.Memory, Score: contribution, Similarity: hit.Similarity})
+	}
+	sort.SliceStable(fused, func(i, j int) bool { return fused[i].Score > fused[j].Score })
+	if limit > 0 && len(fused) > limit {
+		fused = fused[:limit]
+	}
+	return fused
+}
@@ -0,0 +1,64 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/thebtf/engram/internal/db/gorm"
+	"github.com/thebtf/engram/internal/embedding"
+	"github.com/thebtf/engram/pkg/models"
+)
+
+func TestFuseMemoryHits(t *testing.T) {
+	t.Parallel()
+
+	mem := func(id int64) *models.Memory { return &models.Memory{ID: id} }
+	fts := []gorm.MemorySearchHit{
+		{Memory: mem(1), Snippet: "**one**", Score: 0.9},
+		{Memory: mem(2), Snippet: "**two**", Score: 0.5},
+	}
+	vector := []gorm.MemoryVectorHit{
+		{Memory: mem(2), Similarity: 0.8},
+		{Memory: mem(3), Similarity: 0.6},
+	}
+
+	fused := fuseMemoryHits(fts, vector, 10)
+	require.Len(t, fused, 3)
+
+	assert.Equal(t, int64(2), fused[0].Memory.ID, "found by both rankings")
+	assert.Equal(t, "**two**", fused[0].Snippet)
+	assert.InDelta(t, 0.8, fused[0].Similarity, 1e-9)
+	assert.InDelta(t, 1.0/62+1.0/61, fused[0].Score, 1e-12)
+
+	assert.Equal(t, int64(1), fused[1].Memory.ID, "full-text order wins ties")
+	assert.Zero(t, fused[1].Similarity)
+	assert.Equal(t, int64(3), fused[2].Memory.ID)
+	assert.Empty(t, fused[2].Snippet)
+
+	assert.Len(t, fuseMemoryHits(fts, vector, 2), 2)
+}
+
+func TestHandleRecallSearch_ModeValidation(t *testing.T) {
+	t.Parallel()
+
+	server := NewServer(ServerOptions{Version: "1.0.0"})
+	server.SetMemoryStore(&gorm.MemoryStore{})
+	ctx := context.Background()
+
+	_, err := server.handleRecall(ctx, json.RawMessage(`{"project":"p","query":"q","mode":"semantic"}`))
+	assert.ErrorContains(t, err, "embeddings disabled")
+
+	_, err = server.handleRecall(ctx, json.RawMessage(`{"project":"p","query":"q","mode":"fuzzy"}`))
+	assert.ErrorContains(t, err, "invalid mode")
+
+	server.SetEmbedder(embedding.NewLocal(0))
+	_, err = server.handleRecall(ctx, json.RawMessage(`{"project":"p","query":"q","mode":"hybrid","cursor":"abc"}`))
+	assert.ErrorContains(t, err, "cursor is only supported")
+
+	_, err = server.handleRecall(ctx, json.RawMessage(`{"project":"p","query":"q","min_similarity":2}`))
+	assert.ErrorContains(t, err, "min_similarity")
+}
@@ -19,8 +19,10 @@ import (
 	"github.com/thebtf/engram/internal/config"
 	"github.com/thebtf/engram/internal/crypto"
 	gorm "github.com/thebtf/engram/internal/db/gorm"
+	"github.com/thebtf/engram/internal/embedding"
 	"github.com/thebtf/engram/internal/privacy"
 	"github.com/thebtf/engram/internal/sessions"
+	"github.com/thebtf/engram/internal/webhooks"
 )
 
 // Server is the MCP server that exposes engram tools.
@@ -40,9 +42,16 @@ type Server struct {
 	issueStore             *gorm.IssueStore
 	memoryStore            *gorm.MemoryStore
 	behavioralRulesStore   *gorm.BehavioralRulesStore
+	webhookPublisher       *webhooks.Publisher
+	embedder               embedding.Embedder
 	vault                  *crypto.Vault
 	vaultInitErr           error
 	vaultOnce              sync.Once
+	vaultFunc              func() (*crypto.Vault, error)
+	keyring                *crypto.Keyring
+	keyringErr             error
+	keyringOnce            sync.Once
+	keyringFunc            func() (*crypto.Keyring, error)
 	backfillStatusFunc     func() (any, error)
 	version                string
 }
@@ -73,6 +82,18 @@ func NewServer(opts ServerOptions) *Server {
 	}
 }
 
+// SetVaultFunc makes the server use the host's vault instead of loading its
+// own, so both see the same key after a rotation.
+func (s *Server) SetVaultFunc(fn func() (*crypto.Vault, error)) {
+	s.vaultFunc = fn
+}
+
+// SetKeyringFunc makes the server use the host's envelope-encryption keyring,
+// so both share cached data keys and see the same KEK after a rotation.
+func (s *Server) SetKeyringFunc(fn func() (*crypto.Keyring, error)) {
+	s.keyringFunc = fn
+}
+
 // SetInjectionStore sets the injection store for learning MCP tools.
 func (s *Server) SetInjectionStore(is *gorm.InjectionStore) {
 	s.injectionStore = is
@@ -98,6 +119,12 @@ func (s *Server) SetIssueStore(is *gorm.IssueStore) {
 	s.issueStore = is
 }
 
+// SetWebhookPublisher sets the publisher that queues webhook deliveries for
+// issue, memory and rule changes made through MCP tools.
+func (s *Server) SetWebhookPublisher(p *webhooks.Publisher) {
+	s.webhookPublisher = p
+}
+
 // SetMemoryStore sets the memory store for the memories table (US3 Commit C).
 func (s *Server) SetMemoryStore(ms *gorm.MemoryStore) {
 	s.memoryStore = ms
@@ -108,6 +135,12 @@ func (s *Server) SetBehavioralRulesStore(brs *gorm.BehavioralRulesStore) {
 	s.behavioralRulesStore = brs
 }
 
+// SetEmbedder sets the embedder for semantic memory recall. A nil embedder
+// keeps recall on full-text search only.
+func (s *Server) SetEmbedder(e embedding.Embedder) {
+	s.embedder = e
+}
+
 // HandleRequest dispatches a JSON-RPC request and returns the response.
 // This is the public wrapper for the private handleRequest method,
 // enabling the gRPC adapter to invoke tool calls without duplicating dispatch logic.
@@ -480,38 +513,55 @@ func (s *Server) primaryTools() []Tool {
 	return []Tool{
 		{
 			Name:        "recall",
-			Description: "Search and retrieve memories. Actions: search (default, trivial SQL filter over memories), by_file, related, reasoning.",
+			Description: "Search and retrieve memories. Actions: search (default, hybrid full-text + semantic search over memories), similar (semantic only), by_file, related, reasoning.",
 			tier:        tierCore,
 			InputSchema: map[string]any{
 				"type": "object",
 				"properties": map[string]any{
-					"action":         map[string]any{"type": "string", "enum": []string{"search", "by_file", "related", "reasoning"}, "default": "search", "description": "Action to perform"},
-					"query":          map[string]any{"type": "string", "description": "Search query / substring filter (for search)"},
-					"files":          map[string]any{"type": "string", "description": "File paths (for action=by_file)"},
-					"id":             map[string]any{"type": "number", "description": "Observation ID (for action=related)"},
-					"project":        map[string]any{"type": "string", "description": "Project name filter"},
-					"limit":          map[string]any{"type": "number", "description": "Max results"},
-					"min_confidence": map[string]any{"type": "number", "description": "Min confidence 0-1 (for action=related)"},
+					"action":           map[string]any{"type": "string", "enum": []string{"search", "similar", "by_file", "related", "reasoning"}, "default": "search", "description": "Action to perform"},
+					"query":            map[string]any{"type": "string", "description": "Full-text query (for search). Supports \"exact phrase\", -exclude and OR"},
+					"cursor":           map[string]any{"type": "string", "description": "next_cursor from a previous search page (for search)"},
+					"files":            map[string]any{"type": "string", "description": "File paths (for action=by_file)"},
+					"id":               map[string]any{"type": "number", "description": "Observation ID (for action=related)"},
+					"project":          map[string]any{"type": "string", "description": "Project name filter"},
+					"scope":            map[string]any{"type": "string", "enum": []string{"project", "all"}, "default": "project", "description": "all = search every project, results grouped per project (for search)"},
+					"include_projects": map[string]any{"type": "array", "items": map[string]any{"type": "string"}, "description": "Projects to search; implies scope=all (for search)"},
+					"exclude_projects": map[string]any{"type": "array", "items": map[string]any{"type": "string"}, "description": "Projects to skip when scope=all (for search)"},
+					"types":            map[string]any{"type": "array", "items": map[string]any{"type": "string"}, "description": "Only memories of these types, e.g. decision, pitfall (for search)"},
+					"min_importance":   map[string]any{"type": "number", "minimum": 0, "maximum": 1, "description": "Only memories with importance >= this value (for search)"},
+					"order_by":         map[string]any{"type": "string", "enum": []string{"recent", "importance"}, "default": "recent", "description": "Ordering when no query is given (for search)"},
+					"mode":             map[string]any{"type": "string", "enum": []string{"fts", "semantic", "hybrid"}, "description": "Query ranking: full-text, vector similarity, or both fused (for search). Default hybrid when embeddings are enabled; a cursor requires fts"},
+					"min_similarity":   map[string]any{"type": "number", "minimum": 0, "maximum": 1, "description": "Ignore vector hits below this cosine similarity (for search: default 0.2; for similar: default 0.7)"},
+					"limit":            map[string]any{"type": "number", "description": "Max results"},
+					"min_confidence":   map[string]any{"type": "number", "description": "Min confidence 0-1 (for action=related)"},
 				},
 			},
 		},
 		{
 			Name:        "store",
-			Description: "Store, edit, merge, or import memories. Actions: create (default), edit, merge, import.",
+			Description: "Store, edit, merge, or import memories and browse their version history. Actions: create (default), edit, merge, history, diff, restore, import.",
 			tier:        tierCore,
 			InputSchema: map[string]any{
 				"type": "object",
 				"properties": map[string]any{
-					"action":        map[string]any{"type": "string", "enum": []string{"create", "edit", "merge", "import"}, "default": "create", "description": "Action to perform"},
-					"content":       map[string]any{"type": "string", "description": "Observation content (for create)"},
+					"action":        map[string]any{"type": "string", "enum": []string{"create", "edit", "merge", "history", "diff", "restore", "import"}, "default": "create", "description": "Action to perform"},
+					"content":       map[string]any{"type": "string", "description": "Memory content (for create, edit; for merge overrides the merged text)"},
 					"title":         map[string]any{"type": "string", "description": "Title (for create, edit)"},
-					"id":            map[string]any{"type": "number", "description": "Observation ID (for edit)"},
-					"source_id":     map[string]any{"type": "number", "description": "Source observation ID (for merge)"},
-					"target_id":     map[string]any{"type": "number", "description": "Target observation ID (for merge)"},
+					"id":            map[string]any{"type": "number", "description": "Memory ID (for edit, history, diff, restore)"},
+					"source_id":     map[string]any{"type": "number", "description": "Memory merged and soft-deleted (for merge)"},
+					"target_id":     map[string]any{"type": "number", "description": "Memory that receives the merge (for merge)"},
+					"version":       map[string]any{"type": "number", "description": "Version to restore (for restore)"},
+					"from":          map[string]any{"type": "number", "description": "Older version (for diff, default to-1)"},
+					"to":            map[string]any{"type": "number", "description": "Newer version (for diff, default latest)"},
+					"edited_by":     map[string]any{"type": "string", "description": "Author recorded on the new revision (for edit, merge, restore)"},
 					"type":          map[string]any{"type": "string", "enum": []string{"decision", "bugfix", "feature", "refactor", "discovery", "change", "guidance", "credential", "entity", "wiki", "pitfall", "operational", "timeline"}, "description": "Observation type (for create). Must be an observation type, not a memory_type value like insight/context/pattern."},
-					"tags":          map[string]any{"type": "string", "description": "Comma-separated tags (for create)"},
+					"tags":          map[string]any{"type": "string", "description": "Comma-separated tags (for create, edit)"},
 					"scope":         map[string]any{"type": "string", "description": "Scope: project/global/agent (for create)"},
 					"always_inject": map[string]any{"type": "boolean", "description": "Always inject in context (for create, edit)"},
+					"importance":    map[string]any{"type": "number", "minimum": 0, "maximum": 1, "description": "Importance 0-1, default 0.5; session-start and recall rank by it (for create)"},
+					"ttl_days":      map[string]any{"type": "integer", "minimum": 1, "description": "Days until the memory expires and is swept (for create)"},
+					"rejected":      map[string]any{"type": "array", "items": map[string]any{"type": "string"}, "description": "Alternatives considered and dismissed (for create)"},
+					"force":         map[string]any{"type": "boolean", "description": "Store even when a near-duplicate exists; otherwise create returns duplicate_of candidates instead (for create)"},
 					"narrative":     map[string]any{"type": "string", "description": "Narrative text (for edit)"},
 					"path":          map[string]any{"type": "string", "description": "File path (for import)"},
 					"project":       map[string]any{"type": "string", "description": "Project name"},
@@ -543,11 +593,12 @@ func (s *Server) primaryTools() []Tool {
 				"type":     "object",
 				"required": []string{"action"},
 				"properties": map[string]any{
-					"action":  map[string]any{"type": "string", "enum": []string{"store", "get", "list", "delete", "status"}, "description": "Action to perform (required)"},
-					"name":    map[string]any{"type": "string", "description": "Credential name (for store, get, delete)"},
-					"value":   map[string]any{"type": "string", "description": "Credential value (for store)"},
-					"scope":   map[string]any{"type": "string", "description": "Scope: project/global (for store)"},
-					"project": map[string]any{"type": "string", "description": "Project name (for store)"},
+					"action":     map[string]any{"type": "string", "enum": []string{"store", "get", "list", "delete", "status"}, "description": "Action to perform (required)"},
+					"name":       map[string]any{"type": "string", "description": "Credential name (for store, get, delete)"},
+					"value":      map[string]any{"type": "string", "description": "Credential value (for store)"},
+					"scope":      map[string]any{"type": "string", "description": "Scope: project/global (for store)"},
+					"project":    map[string]any{"type": "string", "description": "Credential project (for store, get, list, delete)"},
+					"session_id": map[string]any{"type": "string", "description": "Caller session ID, recorded in the credential access log (for get)"},
 				},
 			},
 		},
@@ -565,6 +616,8 @@ func (s *Server) primaryTools() []Tool {
 					"content":    map[string]any{"type": "string", "description": "Document content (for create, ingest)"},
 					"collection": map[string]any{"type": "string", "description": "Collection name (for documents, get_doc, remove, ingest, search_docs)"},
 					"query":      map[string]any{"type": "string", "description": "Search query (for search_docs)"},
+					"title":      map[string]any{"type": "string", "description": "Document title (for ingest)"},
+					"limit":      map[string]any{"type": "number", "default": 10, "minimum": 1, "maximum": 50, "description": "Max chunks to return (for search_docs)"},
 					"version":    map[string]any{"type": "number", "description": "Version number (for read)"},
 					"comment":    map[string]any{"type": "string", "description": "Comment text (for comment)"},
 					"doc_type":   map[string]any{"type": "string", "description": "Document type (for create, list)"},
@@ -580,9 +633,11 @@ func (s *Server) primaryTools() []Tool {
 				"type":     "object",
 				"required": []string{"action"},
 				"properties": map[string]any{
-					"action":  map[string]any{"type": "string", "description": "Action to perform (required). See tool description for valid actions."},
-					"project": map[string]any{"type": "string", "description": "Project name (for stats, search_analytics)"},
-					"days":    map[string]any{"type": "number", "description": "Days to analyze (for search_analytics)"},
+					"action":    map[string]any{"type": "string", "description": "Action to perform (required). See tool description for valid actions."},
+					"project":   map[string]any{"type": "string", "description": "Project name (for stats, search_analytics, duplicates)"},
+					"days":      map[string]any{"type": "number", "description": "Days to analyze (for search_analytics)"},
+					"threshold": map[string]any{"type": "number", "minimum": 0, "maximum": 1, "description": "Similarity at which memories count as duplicates (for duplicates, default store_memory_dedup_threshold)"},
+					"limit":     map[string]any{"type": "number", "minimum": 1, "maximum": 5000, "description": "Newest memories to scan (for duplicates, default 1000)"},
 				},
 			},
 		},
@@ -592,7 +647,11 @@ func (s *Server) primaryTools() []Tool {
 				"`project` = YOUR current working project slug (identifies who is acting — audit trail).\n" +
 				"`target_project` = project the issue is FOR (where it will be injected).\n" +
 				"Lifecycle: open → acknowledged (auto) → resolved → closed ⟲ reopened.\n" +
-				"Target agent resolves, source agent closes. Only source or dashboard operator can close.\n\n" +
+				"Target agent resolves, source agent closes. Only source or dashboard operator can close.\n" +
+				"Links: link(id, linked_id, link_type) records \"#id <link_type> #linked_id\" — blocks, duplicates, relates, or parent (id is the parent). Use links instead of pasting issue IDs into comments; blocked issues are marked at session start.\n" +
+				"Claims: claim(id, session_id) before working on an issue so parallel sessions skip it; renew while working, release when done. Unrenewed claims expire (lease_minutes, default 30).\n" +
+				"Search: list(query=...) ranks issues by title, body and comments; filter with labels, exclude_labels, priorities, created_*/updated_* (epoch ms) or a dashboard saved_query.\n" +
+				"History: get(id, include_events=true) shows who changed status, fields, claims and links, and when.\n\n" +
 				"FORMATTING (body and comments support Markdown — rendered in dashboard):\n" +
 				"- Wrap code in fenced blocks: ```go\\nfunc main(){}\\n``` (with language tag)\n" +
 				"- Wrap terminal output: ```bash\\n$ command\\noutput\\n```\n" +
@@ -651,7 +710,7 @@ func (s *Server) handleToolsList(req *Request) *Response {
 		},
 		{
 			Name:        "find_similar_observations",
-			Description: "Find observations semantically similar to a query or observation. Uses vector similarity search to find related content. Useful for detecting duplicates before creating new observations.",
+			Description: "Find memories semantically similar to a query. Uses vector similarity search (local embeddings by default) to find related content. Useful for detecting duplicates before storing new memories.",
 			tier:        tierUseful,
 			InputSchema: map[string]any{
 				"type":     "object",
@@ -796,6 +855,7 @@ func (s *Server) handleToolsList(req *Request) *Response {
 						"ttl_days":      map[string]any{"type": "integer", "minimum": 1, "description": "TTL in days for verified facts. Auto-computed from tags if not provided. Only applies to observations with 'verified' tag."},
 						"always_inject": map[string]any{"type": "boolean", "description": "If true, this memory will be injected into every agent context regardless of query relevance. Use for behavioral rules that must always be present."},
 						"agent_source":  map[string]any{"type": "string", "enum": []string{"claude-code", "codex", "gemini", "other", "unknown"}, "description": "Which AI tool created this observation"},
+						"force":         map[string]any{"type": "boolean", "description": "Store even when a near-duplicate of an existing memory exists (otherwise duplicate_of candidates are returned and nothing is stored)"},
 					},
 				},
 			},
@@ -980,12 +1040,12 @@ func (s *Server) handleToolsList(req *Request) *Response {
 		)
 	}
 
-	// Document ingest/search tools are document-store gated (embedding removed in v5).
+	// Document ingest/search tools are document-store gated.
 	if s.documentStore != nil {
 		tools = append(tools,
 			Tool{
 				Name:        "ingest_document",
-				Description: "[Documents] Ingest a document into a collection. Chunks the content, generates embeddings, and stores for semantic search. Skips re-embedding if content hash unchanged.",
+				Description: "[Documents] Ingest a document into a collection. Splits the content into heading/symbol-aware chunks and indexes them for full-text search. Skips re-chunking if content hash unchanged.",
 				tier:        tierAdmin,
 				InputSchema: map[string]any{
 					"type":     "object",
@@ -1000,13 +1060,13 @@ func (s *Server) handleToolsList(req *Request) *Response {
 			},
 			Tool{
 				Name:        "search_collection",
-				Description: "[Documents] Semantic search across document chunks in a collection. Returns ranked results with chunk text.",
+				Description: "[Documents] Full-text search across document chunks in a collection. Returns ranked chunks with file path, line range, heading or symbol, and a highlighted snippet.",
 				tier:        tierAdmin,
 				InputSchema: map[string]any{
 					"type":     "object",
 					"required": []string{"query"},
 					"properties": map[string]any{
-						"query":      map[string]any{"type": "string", "description": "Natural language search query"},
+						"query":      map[string]any{"type": "string", "description": "Search query (web search syntax: \"quoted phrases\", -exclusion, OR)"},
 						"collection": map[string]any{"type": "string", "description": "Collection to search (omit to search all collections)"},
 						"limit":      map[string]any{"type": "number", "default": 10, "minimum": 1, "maximum": 50},
 					},
@@ -1160,7 +1220,18 @@ func (s *Server) handleToolsCall(ctx context.Context, req *Request) *Response {
 		}
 	}
 
-	result, err := s.callTool(ctx, params.Name, params.Arguments)
+	err := authorizeToolCall(ctx, params.Name, params.Arguments)
+	var args json.RawMessage
+	if err == nil {
+		args, err = scopeToolCall(ctx, params.Name, params.Arguments)
+	}
+	if err == nil {
+		err = checkToolRecords(ctx, params.Name, args, s.memoryOwners, s.issueOwners)
+	}
+	var result string
+	if err == nil {
+		result, err = s.callTool(ctx, params.Name, args)
+	}
 	if err != nil {
 		// Truncated args for debugging (first 200 chars)
 		argsStr := string(params.Arguments)
@@ -1441,12 +1512,40 @@ func (s *Server) handleFindSimilarObservations(ctx context.Context, args json.Ra
 		params.Limit = 50
 	}
 
-	// Vector search removed in v5 (content_chunks table dropped). Return empty result set.
+	if s.memoryStore == nil || s.embedder == nil {
+		response := map[string]any{
+			"observations":   []any{},
+			"count":          0,
+			"min_similarity": params.MinSimilarity,
+			"note":           "Semantic search not configured (no memory store or ENGRAM_EMBEDDING_PROVIDER=none); use recall(action=\"search\") for FTS-based retrieval",
+		}
+		output, err := json.Marshal(response)
+		if err != nil {
+			return "", fmt.Errorf("marshal response: %w", err)
+		}
+		return string(output), nil
+	}
+
+	// Observations are stored as memories since v5; an empty project searches
+	// every active project.
+	hits, err := s.searchMemoriesVector(ctx, params.Query, gorm.MemorySearchParams{
+		Project: params.Project,
+		Limit:   params.Limit,
+	}, params.MinSimilarity)
+	if err != nil {
+		return "", fmt.Errorf("find similar: %w", err)
+	}
+	results := make([]recallMemoryResult, 0, len(hits))
+	for _, hit := range hits {
+		result := recallResultFromMemory(hit.Memory)
+		result.Similarity = hit.Similarity
+		results = append(results, result)
+	}
 	response := map[string]any{
-		"observations":   []any{},
-		"count":          0,
+		"observations":   results,
+		"count":          len(results),
 		"min_similarity": params.MinSimilarity,
-		"note":           "Vector similarity search removed in v5; use recall(action=\"search\") for FTS-based retrieval",
+		"model":          s.embedder.Name(),
 	}
 
 	output, err := json.Marshal(response)
@@ -1675,5 +1774,3 @@ func (s *Server) handleSearchSessions(_ context.Context, _ json.RawMessage) (str
 func (s *Server) handleListSessions(_ context.Context, _ json.RawMessage) (string, error) {
 	return "", fmt.Errorf("list_sessions removed in v5 (US3) — indexed_sessions table dropped")
 }
-
-
@@ -1690,14 +1690,14 @@ func TestCallTool_AllSpecialTools(t *testing.T) {
 // =============================================================================
 
 // TestHandleFindSimilarObservations_ReturnsEmptyInV5 verifies that find_similar_observations
-// returns an empty result set now that vector storage is removed in v5.
+// returns an empty result set when no memory store or embedder is configured.
 func TestHandleFindSimilarObservations_ReturnsEmptyInV5(t *testing.T) {
 	t.Parallel()
 
 	server := NewServer(ServerOptions{Version: "1.0.0"})
 	ctx := context.Background()
 
-	// Vector search removed in v5: should return empty observations without error.
+	// Semantic search not configured: should return empty observations without error.
 	result, err := server.handleFindSimilarObservations(ctx, json.RawMessage(`{"query": "test query"}`))
 	require.NoError(t, err)
 
@@ -11,7 +11,7 @@ import (
 // It is referenced by handleAdmin for validation messages and by the tool
 // registration in server.go for the tool description.
 var adminActions = []string{
-	"stats", "search_analytics", "backfill_status",
+	"stats", "search_analytics", "backfill_status", "duplicates",
 }
 
 func (s *Server) handleAdmin(ctx context.Context, args json.RawMessage) (string, error) {
@@ -31,6 +31,8 @@ func (s *Server) handleAdmin(ctx context.Context, args json.RawMessage) (string,
 		return s.handleAnalyzeSearchPatterns(ctx, args)
 	case "backfill_status":
 		return s.handleBackfillStatus()
+	case "duplicates":
+		return s.handleAdminDuplicates(ctx, m)
 	default:
 		return "", fmt.Errorf("unknown admin action: %q (valid: %s)", action, strings.Join(adminActions, ", "))
 	}
@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/rs/zerolog/log"
+	"github.com/thebtf/engram/internal/auth"
 	"github.com/thebtf/engram/internal/config"
 	"github.com/thebtf/engram/internal/crypto"
 	gormstore "github.com/thebtf/engram/internal/db/gorm"
@@ -18,7 +19,11 @@ import (
 // getVault returns the Server's Vault, initializing it lazily on first call.
 // The vault is a singleton within the server instance; initialization errors
 // are permanent — restart the server after fixing key configuration to retry.
+// When the host installed a vault provider with SetVaultFunc, that is used instead.
 func (s *Server) getVault() (*crypto.Vault, error) {
+	if s.vaultFunc != nil {
+		return s.vaultFunc()
+	}
 	s.vaultOnce.Do(func() {
 		cfg := config.Get()
 		s.vault, s.vaultInitErr = crypto.NewVault(cfg)
@@ -29,6 +34,40 @@ func (s *Server) getVault() (*crypto.Vault, error) {
 	return s.vault, s.vaultInitErr
 }
 
+// getKeyring returns the envelope-encryption keyring that holds the
+// per-project data keys. When the host installed one with SetKeyringFunc,
+// that is used; otherwise one is built once from the server's vault, the KEK
+// configured by ENGRAM_VAULT_KEK and the configured database.
+func (s *Server) getKeyring() (*crypto.Keyring, error) {
+	if s.keyringFunc != nil {
+		return s.keyringFunc()
+	}
+	s.keyringOnce.Do(func() {
+		v, err := s.getVault()
+		if err != nil {
+			s.keyringErr = err
+			return
+		}
+		dsn := config.GetDatabaseDSN()
+		if dsn == "" {
+			s.keyringErr = fmt.Errorf("data key store not available: database DSN not configured")
+			return
+		}
+		store, err := gormstore.NewStore(gormstore.Config{DSN: dsn})
+		if err != nil {
+			s.keyringErr = fmt.Errorf("data key store not available: %w", err)
+			return
+		}
+		kek, err := crypto.NewKEK(config.Get(), v)
+		if err != nil {
+			s.keyringErr = fmt.Errorf("vault KEK: %w", err)
+			return
+		}
+		s.keyring = crypto.NewKeyring(kek, gormstore.NewCredentialDataKeyStore(store), v)
+	})
+	return s.keyring, s.keyringErr
+}
+
 // credentialStore derives a dedicated CredentialStore from the server's configured
 // database DSN using only public constructors. This removes the prior reflect/unsafe
 // field access while keeping the change local to MCP wiring.
@@ -91,10 +130,14 @@ func (s *Server) handleStoreCredential(ctx context.Context, args json.RawMessage
 		return "", fmt.Errorf("project is required for project-scoped credentials")
 	}
 
-	v, err := s.getVault()
+	kr, err := s.getKeyring()
 	if err != nil {
 		return "", fmt.Errorf("vault not available: %w", err)
 	}
+	v, err := kr.ForProject(ctx, params.Project)
+	if err != nil {
+		return "", fmt.Errorf("project data key: %w", err)
+	}
 
 	ciphertext, err := v.Encrypt(params.Value)
 	if err != nil {
@@ -126,7 +169,29 @@ func (s *Server) handleStoreCredential(ctx context.Context, args json.RawMessage
 	return string(out), nil
 }
 
-// handleGetCredential retrieves and decrypts a credential by name.
+// credentialAccessor describes the MCP caller for credential policy checks and
+// the access log. The caller's project is the one the client connected with,
+// not the credential project named in the arguments. Without an identity on
+// the context authentication is disabled and the caller is treated as an admin.
+func credentialAccessor(ctx context.Context, sessionID string) gormstore.CredentialAccessor {
+	a := gormstore.CredentialAccessor{
+		Project:   projectFromContext(ctx),
+		SessionID: sessionID,
+		Source:    gormstore.CredentialAccessSourceMCP,
+	}
+	id, ok := auth.IdentityFrom(ctx)
+	if !ok {
+		a.Admin = true
+		return a
+	}
+	a.KeycardID = id.KeycardID
+	a.Role = string(id.Role)
+	a.Admin = id.Can(auth.PermVaultAdmin)
+	return a
+}
+
+// handleGetCredential retrieves and decrypts a credential by name after
+// checking the credential's access policy. Every attempt is audited.
 func (s *Server) handleGetCredential(ctx context.Context, args json.RawMessage) (string, error) {
 	store, err := s.credentialStore()
 	if err != nil {
@@ -139,11 +204,13 @@ func (s *Server) handleGetCredential(ctx context.Context, args json.RawMessage)
 	}
 
 	var params struct {
-		Name    string
-		Project string
+		Name      string
+		Project   string
+		SessionID string
 	}
 	params.Name = coerceString(m["name"], "")
 	params.Project = coerceString(m["project"], "")
+	params.SessionID = coerceString(m["session_id"], "")
 	if params.Name == "" {
 		return "", fmt.Errorf("name is required")
 	}
@@ -151,7 +218,7 @@ func (s *Server) handleGetCredential(ctx context.Context, args json.RawMessage)
 		return "", fmt.Errorf("project is required")
 	}
 
-	v, err := s.getVault()
+	kr, err := s.getKeyring()
 	if err != nil {
 		return "", fmt.Errorf("vault not available — configure ENGRAM_ENCRYPTION_KEY or ENGRAM_ENCRYPTION_KEY_FILE: %w", err)
 	}
@@ -164,6 +231,17 @@ func (s *Server) handleGetCredential(ctx context.Context, args json.RawMessage)
 		return "", fmt.Errorf("get credential: %w", err)
 	}
 
+	if err := store.AuthorizeReveal(ctx, cred, credentialAccessor(ctx, params.SessionID)); err != nil {
+		if errors.Is(err, gormstore.ErrCredentialAccessDenied) {
+			return "", fmt.Errorf("credential %q: %w", params.Name, err)
+		}
+		return "", fmt.Errorf("audit credential read: %w", err)
+	}
+
+	v, err := kr.ForProject(ctx, cred.Project)
+	if err != nil {
+		return "", fmt.Errorf("project data key: %w", err)
+	}
 	if cred.EncryptionKeyFingerprint != "" && !v.MatchesFingerprint(cred.EncryptionKeyFingerprint) {
 		return "", fmt.Errorf(
 			"encryption key mismatch: credential %q was encrypted with key fingerprint %q, current key has fingerprint %q — restore the original key to decrypt",
@@ -216,15 +294,17 @@ func (s *Server) handleListCredentials(ctx context.Context, args json.RawMessage
 	type credItem struct {
 		Name      string `json:"name"`
 		Scope     string `json:"scope"`
+		Access    string `json:"access"`
 		CreatedAt string `json:"created_at,omitempty"`
 		ID        int64  `json:"id"`
 	}
 	items := make([]credItem, 0, len(creds))
 	for _, c := range creds {
 		item := credItem{
-			ID:    c.ID,
-			Name:  c.Key,
-			Scope: c.Scope,
+			ID:     c.ID,
+			Name:   c.Key,
+			Scope:  c.Scope,
+			Access: c.Access,
 		}
 		if !c.CreatedAt.IsZero() {
 			item.CreatedAt = c.CreatedAt.UTC().Format(time.RFC3339)
@@ -0,0 +1,209 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/thebtf/engram/internal/config"
+	"github.com/thebtf/engram/internal/db/gorm"
+	"github.com/thebtf/engram/pkg/models"
+	"github.com/thebtf/engram/pkg/similarity"
+)
+
+const (
+	// maxDuplicateCandidates caps how many of a project's newest memories a new
+	// memory is compared with on store.
+	maxDuplicateCandidates = 1000
+	// maxDuplicateMatches caps the duplicate_of list returned by store.
+	maxDuplicateMatches = 5
+	// defaultDuplicateScanLimit and maxDuplicateScanLimit bound the admin
+	// duplicates scan, which compares every pair of scanned memories.
+	defaultDuplicateScanLimit = 1000
+	maxDuplicateScanLimit     = 5000
+)
+
+// duplicateMatch is one existing memory that a new memory nearly repeats.
+type duplicateMatch struct {
+	CreatedAt  time.Time `json:"created_at"`
+	Title      string    `json:"title"`
+	ID         int64     `json:"id"`
+	Similarity float64   `json:"similarity"`
+	Importance float64   `json:"importance"`
+}
+
+// dedupThreshold returns the configured near-duplicate threshold, or 0 when
+// duplicate detection is disabled.
+func dedupThreshold() float64 {
+	threshold := config.Get().StoreMemoryDedupThreshold
+	if threshold <= 0 || threshold > 1 {
+		return 0
+	}
+	return threshold
+}
+
+// findDuplicateMemories compares content with the project's newest active
+// memories by character-trigram similarity and returns those at or above
+// threshold, most similar first.
+func (s *Server) findDuplicateMemories(ctx context.Context, project, content string, threshold float64) ([]duplicateMatch, error) {
+	existing, err := s.memoryStore.ListEx(ctx, gorm.MemoryListParams{
+		Project: project,
+		Limit:   maxDuplicateCandidates,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("load memories for duplicate check: %w", err)
+	}
+
+	profile := similarity.NewShingleProfile(content)
+	matches := make([]duplicateMatch, 0)
+	for _, mem := range existing {
+		sim := profile.Similarity(similarity.NewShingleProfile(mem.Content))
+		if sim < threshold {
+			continue
+		}
+		matches = append(matches, duplicateMatch{
+			ID:         mem.ID,
+			Title:      truncateTitle(mem.Content, 80),
+			Similarity: sim,
+			Importance: mem.Importance,
+			CreatedAt:  mem.CreatedAt,
+		})
+	}
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].Similarity > matches[j].Similarity })
+	if len(matches) > maxDuplicateMatches {
+		matches = matches[:maxDuplicateMatches]
+	}
+	return matches, nil
+}
+
+// duplicateRejection is the store result when a new memory is not written
+// because it nearly repeats existing ones.
+func duplicateRejection(matches []duplicateMatch, threshold float64) (string, error) {
+	best := matches[0]
+	result := map[string]any{
+		"stored":       false,
+		"duplicate_of": matches,
+		"threshold":    threshold,
+		"suggestion": map[string]any{
+			"action": "edit",
+			"id":     best.ID,
+		},
+		"message": fmt.Sprintf(
+			"Not stored: near-duplicate of memory %d (similarity %.2f). Update it with store(action=\"edit\", id=%d) if the new content adds something, or pass force=true to store anyway.",
+			best.ID, best.Similarity, best.ID),
+	}
+	out, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("marshal result: %w", err)
+	}
+	return string(out), nil
+}
+
+// duplicateClusterMember is one memory of a duplicate cluster in admin output.
+type duplicateClusterMember struct {
+	CreatedAt  time.Time `json:"created_at"`
+	Title      string    `json:"title"`
+	ID         int64     `json:"id"`
+	Importance float64   `json:"importance"`
+}
+
+// duplicateCluster is a group of near-identical memories. KeepID is the memory
+// suggested as merge target (highest importance, then newest); Merges lists
+// the store(action="merge") calls that would fold the others into it.
+type duplicateCluster struct {
+	Members       []duplicateClusterMember `json:"members"`
+	Merges        []map[string]any         `json:"merges"`
+	KeepID        int64                    `json:"keep_id"`
+	MaxSimilarity float64                  `json:"max_similarity"`
+}
+
+// handleAdminDuplicates scans a project's newest memories and reports clusters
+// of near-duplicates with merge suggestions. It changes nothing.
+func (s *Server) handleAdminDuplicates(ctx context.Context, m map[string]any) (string, error) {
+	if s.memoryStore == nil {
+		return "", fmt.Errorf("duplicates: memory store not configured")
+	}
+	project := coerceString(m["project"], "")
+	if project == "" {
+		return "", fmt.Errorf("duplicates: project is required")
+	}
+	threshold := coerceFloat64(m["threshold"], 0)
+	if threshold == 0 {
+		threshold = dedupThreshold()
+	}
+	if threshold <= 0 || threshold > 1 {
+		return "", fmt.Errorf("duplicates: threshold must be between 0 and 1 (duplicate detection is disabled by config; pass threshold explicitly)")
+	}
+	limit := coerceInt(m["limit"], defaultDuplicateScanLimit)
+	if limit <= 0 {
+		limit = defaultDuplicateScanLimit
+	} else if limit > maxDuplicateScanLimit {
+		limit = maxDuplicateScanLimit
+	}
+
+	memories, err := s.memoryStore.ListEx(ctx, gorm.MemoryListParams{Project: project, Limit: limit})
+	if err != nil {
+		return "", fmt.Errorf("duplicates: %w", err)
+	}
+	profiles := make([]similarity.ShingleProfile, len(memories))
+	for i, mem := range memories {
+		profiles[i] = similarity.NewShingleProfile(mem.Content)
+	}
+
+	clusters := make([]duplicateCluster, 0)
+	duplicates := 0
+	for _, indexes := range similarity.ClusterBySimilarity(profiles, threshold) {
+		clusters = append(clusters, buildDuplicateCluster(memories, profiles, indexes))
+		duplicates += len(indexes) - 1
+	}
+
+	out, err := json.MarshalIndent(map[string]any{
+		"project":    project,
+		"threshold":  threshold,
+		"scanned":    len(memories),
+		"clusters":   clusters,
+		"count":      len(clusters),
+		"duplicates": duplicates,
+	}, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("marshal result: %w", err)
+	}
+	return string(out), nil
+}
+
+// buildDuplicateCluster describes the memories at indexes and picks the one to keep.
+func buildDuplicateCluster(memories []*models.Memory, profiles []similarity.ShingleProfile, indexes []int) duplicateCluster {
+	keep := indexes[0]
+	for _, i := range indexes[1:] {
+		a, b := memories[i], memories[keep]
+		if a.Importance > b.Importance || (a.Importance == b.Importance && a.CreatedAt.After(b.CreatedAt)) {
+			keep = i
+		}
+	}
+
+	cluster := duplicateCluster{KeepID: memories[keep].ID}
+	for n, i := range indexes {
+		mem := memories[i]
+		cluster.Members = append(cluster.Members, duplicateClusterMember{
+			ID:         mem.ID,
+			Title:      truncateTitle(mem.Content, 80),
+			Importance: mem.Importance,
+			CreatedAt:  mem.CreatedAt,
+		})
+		for _, j := range indexes[n+1:] {
+			if sim := profiles[i].Similarity(profiles[j]); sim > cluster.MaxSimilarity {
+				cluster.MaxSimilarity = sim
+			}
+		}
+		if i != keep {
+			cluster.Merges = append(cluster.Merges, map[string]any{
+				"action":    "merge",
+				"source_id": mem.ID,
+				"target_id": cluster.KeepID,
+			})
+		}
+	}
+	return cluster
+}
@@ -0,0 +1,81 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/thebtf/engram/internal/db/gorm"
+	"github.com/thebtf/engram/pkg/models"
+	"github.com/thebtf/engram/pkg/similarity"
+)
+
+func TestDuplicateRejection(t *testing.T) {
+	t.Parallel()
+
+	out, err := duplicateRejection([]duplicateMatch{
+		{ID: 7, Title: "Always run migrations in a transaction", Similarity: 0.97},
+		{ID: 3, Title: "Run migrations in a transaction", Similarity: 0.93},
+	}, 0.92)
+	require.NoError(t, err)
+
+	var payload struct {
+		Stored      bool             `json:"stored"`
+		DuplicateOf []duplicateMatch `json:"duplicate_of"`
+		Threshold   float64          `json:"threshold"`
+		Suggestion  map[string]any   `json:"suggestion"`
+		Message     string           `json:"message"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(out), &payload))
+	assert.False(t, payload.Stored)
+	require.Len(t, payload.DuplicateOf, 2)
+	assert.Equal(t, int64(7), payload.DuplicateOf[0].ID)
+	assert.InDelta(t, 0.92, payload.Threshold, 1e-9)
+	assert.Equal(t, "edit", payload.Suggestion["action"])
+	assert.EqualValues(t, 7, payload.Suggestion["id"])
+	assert.Contains(t, payload.Message, "force=true")
+}
+
+func TestBuildDuplicateCluster_KeepsMostImportantThenNewest(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	memories := []*models.Memory{
+		{ID: 1, Content: "Always run migrations inside a transaction", Importance: 0.5, CreatedAt: now.Add(-2 * time.Hour)},
+		{ID: 2, Content: "Always run the migrations inside a transaction", Importance: 0.8, CreatedAt: now.Add(-3 * time.Hour)},
+		{ID: 3, Content: "always run the migrations inside one transaction", Importance: 0.8, CreatedAt: now.Add(-time.Hour)},
+	}
+	profiles := make([]similarity.ShingleProfile, len(memories))
+	for i, mem := range memories {
+		profiles[i] = similarity.NewShingleProfile(mem.Content)
+	}
+
+	cluster := buildDuplicateCluster(memories, profiles, []int{0, 1, 2})
+	assert.Equal(t, int64(3), cluster.KeepID, "highest importance, newest on ties")
+	require.Len(t, cluster.Members, 3)
+	require.Len(t, cluster.Merges, 2)
+	assert.Equal(t, map[string]any{"action": "merge", "source_id": int64(1), "target_id": int64(3)}, cluster.Merges[0])
+	assert.Equal(t, map[string]any{"action": "merge", "source_id": int64(2), "target_id": int64(3)}, cluster.Merges[1])
+	assert.Greater(t, cluster.MaxSimilarity, 0.85)
+}
+
+func TestHandleAdminDuplicates_Validation(t *testing.T) {
+	t.Parallel()
+
+	server := NewServer(ServerOptions{Version: "1.0.0"})
+	ctx := context.Background()
+
+	_, err := server.handleAdmin(ctx, json.RawMessage(`{"action":"duplicates","project":"p"}`))
+	assert.ErrorContains(t, err, "memory store not configured")
+
+	server.SetMemoryStore(&gorm.MemoryStore{})
+	_, err = server.handleAdmin(ctx, json.RawMessage(`{"action":"duplicates"}`))
+	assert.ErrorContains(t, err, "project is required")
+
+	_, err = server.handleAdmin(ctx, json.RawMessage(`{"action":"duplicates","project":"p","threshold":1.5}`))
+	assert.ErrorContains(t, err, "threshold must be between 0 and 1")
+}
@@ -3,12 +3,13 @@ package mcp
 
 import (
 	"context"
-	"crypto/sha256"
-	"encoding/hex"
 	"encoding/json"
 	"fmt"
 
 	"github.com/rs/zerolog/log"
+	"github.com/thebtf/engram/internal/chunking"
+	mdchunking "github.com/thebtf/engram/internal/chunking/markdown"
+	"github.com/thebtf/engram/internal/db/gorm"
 )
 
 // handleListCollections returns all configured collections with document counts.
@@ -189,9 +190,10 @@ func (s *Server) handleRemoveDocument(ctx context.Context, args json.RawMessage)
 	return fmt.Sprintf("Document %s/%s deactivated.", params.Collection, params.Path), nil
 }
 
-// handleIngestDocument ingests a document into a collection.
-// Embedding/chunk storage removed in v5 (content_chunks table dropped).
-// In v5, only document metadata is upserted; chunk-level search is unavailable.
+// handleIngestDocument ingests a document into a collection. The body is
+// stored content-addressed, split by the chunk manager and indexed for
+// search_docs. Chunking is skipped when chunks for the body already exist, so
+// re-ingesting an unchanged document only refreshes its metadata.
 func (s *Server) handleIngestDocument(ctx context.Context, args json.RawMessage) (string, error) {
 	if s.documentStore == nil {
 		return "", fmt.Errorf("document store not available")
@@ -216,20 +218,79 @@ func (s *Server) handleIngestDocument(ctx context.Context, args json.RawMessage)
 		return "", fmt.Errorf("collection, path, and content are required")
 	}
 
-	// Upsert document metadata only (embedding pipeline removed in v5).
-	_, err = s.documentStore.UpsertDocument(ctx, params.Collection, params.Path, params.Title, params.Content)
+	doc, err := s.documentStore.UpsertDocument(ctx, params.Collection, params.Path, params.Title, params.Content)
 	if err != nil {
 		return "", fmt.Errorf("upsert document: %w", err)
 	}
+	hash := doc.Hash.String
 
-	hashBytes := sha256.Sum256([]byte(params.Content))
-	newHash := hex.EncodeToString(hashBytes[:])
-	return fmt.Sprintf("Document %s/%s ingested (metadata only, hash %s; chunk embeddings removed in v5).", params.Collection, params.Path, newHash[:12]), nil
+	exists, err := s.documentStore.ChunksExist(ctx, hash)
+	if err != nil {
+		return "", fmt.Errorf("check chunks: %w", err)
+	}
+	if exists {
+		return fmt.Sprintf("Document %s/%s ingested (hash %s; content unchanged, chunks already indexed).", params.Collection, params.Path, hash[:12]), nil
+	}
+
+	chunks := s.documentChunks(ctx, params.Path, params.Content)
+	if err := s.documentStore.UpsertChunks(ctx, hash, chunks); err != nil {
+		return "", fmt.Errorf("store chunks: %w", err)
+	}
+	return fmt.Sprintf("Document %s/%s ingested (hash %s, %d chunks indexed).", params.Collection, params.Path, hash[:12], len(chunks)), nil
 }
 
-// handleSearchCollection searches document chunks in a collection.
-// Chunk-level vector search removed in v5 (content_chunks table dropped).
-func (s *Server) handleSearchCollection(_ context.Context, args json.RawMessage) (string, error) {
+// documentChunks splits a document body for indexing. The chunker is chosen by
+// the path's extension; content that fails to parse (say, a Go snippet that is
+// not a complete file) is indexed as plain text rather than rejected.
+func (s *Server) documentChunks(ctx context.Context, path, content string) []gorm.ContentChunk {
+	plain := chunking.NewManager(nil, chunking.DefaultChunkOptions())
+	manager := s.chunkManager
+	if manager == nil {
+		manager = plain
+	}
+	chunks, err := manager.ChunkContent(ctx, path, content)
+	if err != nil {
+		log.Warn().Err(err).Str("path", path).Msg("Chunking failed, indexing document as plain text")
+		chunks, _ = plain.ChunkContent(ctx, path, content)
+	}
+
+	result := make([]gorm.ContentChunk, len(chunks))
+	for i, chunk := range chunks {
+		result[i] = gorm.ContentChunk{
+			Kind:       string(chunk.Type),
+			Name:       chunk.Name,
+			ParentName: chunk.ParentName,
+			Language:   string(chunk.Language),
+			StartLine:  chunk.StartLine,
+			EndLine:    chunk.EndLine,
+			Text:       chunk.Content,
+		}
+	}
+	return result
+}
+
+// documentSearchResult is one search_docs hit. Heading is set for markdown
+// sections and Symbol for code chunks; plain-text chunks carry neither.
+type documentSearchResult struct {
+	Collection string  `json:"collection"`
+	Path       string  `json:"path"`
+	Title      string  `json:"title,omitempty"`
+	LineRange  string  `json:"line_range"`
+	Heading    string  `json:"heading,omitempty"`
+	Symbol     string  `json:"symbol,omitempty"`
+	Kind       string  `json:"kind"`
+	Language   string  `json:"language,omitempty"`
+	Snippet    string  `json:"snippet"`
+	Score      float64 `json:"score"`
+}
+
+// handleSearchCollection runs a ranked full-text search over the chunks of
+// active documents, in one collection or all of them.
+func (s *Server) handleSearchCollection(ctx context.Context, args json.RawMessage) (string, error) {
+	if s.documentStore == nil {
+		return "", fmt.Errorf("document store not available")
+	}
+
 	m, err := parseArgs(args)
 	if err != nil {
 		return "", err
@@ -238,17 +299,69 @@ func (s *Server) handleSearchCollection(_ context.Context, args json.RawMessage)
 	var params struct {
 		Query      string
 		Collection string
+		Limit      int
 	}
 	params.Query = coerceString(m["query"], "")
 	params.Collection = coerceString(m["collection"], "")
+	params.Limit = coerceInt(m["limit"], 10)
 	if params.Query == "" {
 		return "", fmt.Errorf("query is required")
 	}
+	if params.Limit <= 0 {
+		params.Limit = 10
+	} else if params.Limit > 50 {
+		params.Limit = 50
+	}
+
+	hits, err := s.documentStore.SearchChunks(ctx, gorm.ChunkSearchParams{
+		Query:      params.Query,
+		Collection: params.Collection,
+		Limit:      params.Limit,
+	})
+	if err != nil {
+		return "", fmt.Errorf("search documents: %w", err)
+	}
+
+	results := make([]documentSearchResult, len(hits))
+	for i, hit := range hits {
+		chunk := chunking.Chunk{
+			Name:       hit.Chunk.Name,
+			ParentName: hit.Chunk.ParentName,
+			StartLine:  hit.Chunk.StartLine,
+			EndLine:    hit.Chunk.EndLine,
+		}
+		result := documentSearchResult{
+			Collection: hit.Collection,
+			Path:       hit.Path,
+			Title:      hit.Title,
+			LineRange:  chunk.LineRange(),
+			Kind:       hit.Chunk.Kind,
+			Language:   hit.Chunk.Language,
+			Snippet:    hit.Snippet,
+			Score:      hit.Score,
+		}
+		switch chunking.ChunkType(hit.Chunk.Kind) {
+		case chunking.ChunkTypeText:
+			// Plain text has neither a heading nor a symbol.
+		case mdchunking.ChunkTypeSection:
+			result.Heading = chunk.Name
+		default:
+			result.Symbol = chunk.Identifier()
+		}
+		results[i] = result
+	}
 
-	// Vector chunk search removed in v5 (content_chunks table dropped).
-	msg := "Document chunk search removed in v5; use find_relevant_memories for observation-level FTS retrieval"
+	out := map[string]any{
+		"query":   params.Query,
+		"results": results,
+		"count":   len(results),
+	}
 	if params.Collection != "" {
-		msg += fmt.Sprintf(" (collection %q)", params.Collection)
+		out["collection"] = params.Collection
+	}
+	b, err := json.MarshalIndent(out, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("marshal results: %w", err)
 	}
-	return msg + ".", nil
+	return string(b), nil
 }
@@ -0,0 +1,66 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/thebtf/engram/internal/chunking"
+	gochunking "github.com/thebtf/engram/internal/chunking/golang"
+	mdchunking "github.com/thebtf/engram/internal/chunking/markdown"
+)
+
+func newDocumentChunkServer() *Server {
+	opts := chunking.DefaultChunkOptions()
+	return NewServer(ServerOptions{
+		Version: "1.0.0",
+		ChunkManager: chunking.NewManager([]chunking.Chunker{
+			mdchunking.NewChunker(opts),
+			gochunking.NewChunker(opts),
+		}, opts),
+	})
+}
+
+func TestDocumentChunks(t *testing.T) {
+	t.Parallel()
+
+	s := newDocumentChunkServer()
+	ctx := context.Background()
+
+	chunks := s.documentChunks(ctx, "docs/arch.md", "# Architecture\n\nThe worker owns the outbox.\n")
+	require.Len(t, chunks, 1)
+	assert.Equal(t, "section", chunks[0].Kind)
+	assert.Equal(t, "Architecture", chunks[0].Name)
+	assert.Equal(t, "markdown", chunks[0].Language)
+	assert.Equal(t, 1, chunks[0].StartLine)
+
+	chunks = s.documentChunks(ctx, "store.go", "package store\n\nfunc (s *Store) Get() {}\n")
+	require.Len(t, chunks, 1)
+	assert.Equal(t, "method", chunks[0].Kind)
+	assert.Equal(t, "Get", chunks[0].Name)
+	assert.Equal(t, "Store", chunks[0].ParentName)
+	assert.Equal(t, 3, chunks[0].StartLine)
+
+	// A Go snippet that does not parse is indexed as plain text.
+	chunks = s.documentChunks(ctx, "snippet.go", "if err != nil {\n\treturn err\n}")
+	require.Len(t, chunks, 1)
+	assert.Equal(t, "text", chunks[0].Kind)
+	assert.Equal(t, 3, chunks[0].EndLine)
+
+	// Without a chunk manager everything is plain text.
+	bare := NewServer(ServerOptions{Version: "1.0.0"})
+	chunks = bare.documentChunks(ctx, "docs/arch.md", "# Architecture")
+	require.Len(t, chunks, 1)
+	assert.Equal(t, "text", chunks[0].Kind)
+}
+
+func TestHandleSearchCollection_Validation(t *testing.T) {
+	t.Parallel()
+
+	s := NewServer(ServerOptions{Version: "1.0.0"})
+	_, err := s.handleSearchCollection(context.Background(), json.RawMessage(`{"query":"outbox"}`))
+	assert.ErrorContains(t, err, "document store not available")
+}
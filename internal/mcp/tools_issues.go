@@ -9,6 +9,7 @@ import (
 
 	gormdb "github.com/thebtf/engram/internal/db/gorm"
 	"github.com/thebtf/engram/internal/config"
+	"github.com/thebtf/engram/internal/webhooks"
 )
 
 // issuesToolSchema returns the flat JSON Schema for the issues tool.
@@ -23,12 +24,12 @@ func issuesToolSchema() map[string]any {
 		"properties": map[string]any{
 			"action": map[string]any{
 				"type":        "string",
-				"enum":        []string{"create", "list", "get", "update", "comment", "reopen", "close"},
+				"enum":        []string{"create", "list", "get", "update", "comment", "reopen", "close", "link", "unlink", "claim", "renew", "release"},
 				"description": "Action to perform.",
 			},
 			"project": map[string]any{
 				"type":        "string",
-				"description": "REQUIRED_FOR: create|update|comment|reopen|close. YOUR current project slug (identifies who is acting — audit trail). For list: optional filter by target_project.",
+				"description": "REQUIRED_FOR: create|update|comment|reopen|close|link|unlink|claim|renew|release. YOUR current project slug (identifies who is acting — audit trail). For list: optional filter by target_project.",
 			},
 			"title": map[string]any{
 				"type":        "string",
@@ -40,7 +41,16 @@ func issuesToolSchema() map[string]any {
 			},
 			"id": map[string]any{
 				"type":        "integer",
-				"description": "REQUIRED_FOR: get|update|comment|reopen|close. Issue ID returned by create or list.",
+				"description": "REQUIRED_FOR: get|update|comment|reopen|close|link|unlink|claim|renew|release. Issue ID returned by create or list.",
+			},
+			"linked_id": map[string]any{
+				"type":        "integer",
+				"description": "REQUIRED_FOR: link|unlink. The other issue; may belong to another project.",
+			},
+			"link_type": map[string]any{
+				"type":        "string",
+				"enum":        []string{"blocks", "duplicates", "relates", "parent"},
+				"description": "REQUIRED_FOR: link|unlink. Reads \"#id <link_type> #linked_id\": blocks (linked_id cannot proceed until id is resolved), duplicates, relates, parent (id is the parent of linked_id).",
 			},
 			"body": map[string]any{
 				"type":        "string",
@@ -60,7 +70,25 @@ func issuesToolSchema() map[string]any {
 			"labels": map[string]any{
 				"type":        "array",
 				"items":       map[string]any{"type": "string"},
-				"description": "OPTIONAL_FOR: create. Tags like 'bug', 'feature', 'reliability'.",
+				"description": "OPTIONAL_FOR: create|list. Tags like 'bug', 'feature', 'reliability'. For list: only issues carrying ALL of these labels.",
+			},
+			"exclude_labels": map[string]any{
+				"type":        "array",
+				"items":       map[string]any{"type": "string"},
+				"description": "OPTIONAL_FOR: list. Hide issues carrying ANY of these labels.",
+			},
+			"priorities": map[string]any{
+				"type":        "array",
+				"items":       map[string]any{"type": "string", "enum": []string{"critical", "high", "medium", "low"}},
+				"description": "OPTIONAL_FOR: list. Only issues with one of these priorities.",
+			},
+			"query": map[string]any{
+				"type":        "string",
+				"description": "OPTIONAL_FOR: list. Full-text search over title, body and comments (web search syntax: \"quoted phrase\", -exclude, OR). Results are ranked by relevance.",
+			},
+			"saved_query": map[string]any{
+				"type":        "string",
+				"description": "OPTIONAL_FOR: list. Name of a saved query (created from the dashboard); explicit filters override its fields.",
 			},
 			"type": map[string]any{
 				"type":        "string",
@@ -80,10 +108,38 @@ func issuesToolSchema() map[string]any {
 				"type":        "integer",
 				"description": "OPTIONAL_FOR: list. Filter to issues resolved after this epoch-ms timestamp.",
 			},
+			"created_after": map[string]any{
+				"type":        "integer",
+				"description": "OPTIONAL_FOR: list. Only issues created at or after this epoch-ms timestamp.",
+			},
+			"created_before": map[string]any{
+				"type":        "integer",
+				"description": "OPTIONAL_FOR: list. Only issues created before this epoch-ms timestamp.",
+			},
+			"updated_after": map[string]any{
+				"type":        "integer",
+				"description": "OPTIONAL_FOR: list. Only issues updated at or after this epoch-ms timestamp.",
+			},
+			"updated_before": map[string]any{
+				"type":        "integer",
+				"description": "OPTIONAL_FOR: list. Only issues updated before this epoch-ms timestamp.",
+			},
 			"limit": map[string]any{
 				"type":        "integer",
 				"description": "OPTIONAL_FOR: list. Max results (default 20).",
 			},
+			"include_events": map[string]any{
+				"type":        "boolean",
+				"description": "OPTIONAL_FOR: get. Append the activity timeline: who changed status, fields, claims and links, and when.",
+			},
+			"session_id": map[string]any{
+				"type":        "string",
+				"description": "REQUIRED_FOR: claim|renew|release. YOUR session ID; the claim belongs to this session. For other changes: optional, recorded in the issue's activity timeline.",
+			},
+			"lease_minutes": map[string]any{
+				"type":        "integer",
+				"description": "OPTIONAL_FOR: claim|renew. How long the claim lasts without renewal (default 30, max 1440). An expired claim is released automatically.",
+			},
 		},
 	}
 }
@@ -95,12 +151,17 @@ var actionRequirements = map[string]struct {
 	full     string
 }{
 	"create":  {required: []string{"project", "title", "target_project"}, full: "action, project, title, target_project"},
-	"list":    {required: []string{}, full: "action  [optional: project, source_project, status, resolved_since, limit]"},
-	"get":     {required: []string{"id"}, full: "action, id"},
+	"list":    {required: []string{}, full: "action  [optional: project, source_project, status, query, labels, exclude_labels, priorities, created_after, created_before, updated_after, updated_before, resolved_since, saved_query, limit]"},
+	"get":     {required: []string{"id"}, full: "action, id  [optional: include_events]"},
 	"update":  {required: []string{"project", "id", "status"}, full: "action, project, id, status=resolved"},
 	"comment": {required: []string{"project", "id", "body"}, full: "action, project, id, body"},
 	"reopen":  {required: []string{"project", "id"}, full: "action, project, id"},
 	"close":   {required: []string{"project", "id"}, full: "action, project, id"},
+	"link":    {required: []string{"project", "id", "linked_id", "link_type"}, full: "action, project, id, linked_id, link_type"},
+	"unlink":  {required: []string{"project", "id", "linked_id", "link_type"}, full: "action, project, id, linked_id, link_type"},
+	"claim":   {required: []string{"project", "id", "session_id"}, full: "action, project, id, session_id  [optional: lease_minutes]"},
+	"renew":   {required: []string{"project", "id", "session_id"}, full: "action, project, id, session_id  [optional: lease_minutes]"},
+	"release": {required: []string{"project", "id", "session_id"}, full: "action, project, id, session_id"},
 }
 
 // validateIssueActionParams checks that all required params for the given action are present.
@@ -109,15 +170,15 @@ var actionRequirements = map[string]struct {
 func validateIssueActionParams(action string, m map[string]any) error {
 	spec, ok := actionRequirements[action]
 	if !ok {
-		return fmt.Errorf("unknown issues action: %q (valid: create, list, get, update, comment, reopen, close)", action)
+		return fmt.Errorf("unknown issues action: %q (valid: create, list, get, update, comment, reopen, close, link, unlink, claim, renew, release)", action)
 	}
 
 	var missing []string
 	for _, param := range spec.required {
 		switch param {
-		case "id":
-			if int64(coerceInt(m["id"], 0)) <= 0 {
-				missing = append(missing, "id (integer)")
+		case "id", "linked_id":
+			if int64(coerceInt(m[param], 0)) <= 0 {
+				missing = append(missing, param+" (integer)")
 			}
 		case "status":
 			if coerceString(m["status"], "") == "" {
@@ -139,7 +200,8 @@ func validateIssueActionParams(action string, m map[string]any) error {
 	return nil
 }
 
-// handleIssues dispatches issue actions: create, list, get, update, comment, reopen, close.
+// handleIssues dispatches issue actions: create, list, get, update, comment, reopen, close, link, unlink,
+// claim, renew, release.
 func (s *Server) handleIssues(ctx context.Context, args json.RawMessage) (string, error) {
 	if s.issueStore == nil {
 		return "", fmt.Errorf("issue store not available")
@@ -158,6 +220,15 @@ func (s *Server) handleIssues(ctx context.Context, args json.RawMessage) (string
 		return "", err
 	}
 
+	// Attribute every change this call makes in the issue's event timeline.
+	if action != "list" && action != "get" {
+		ctx = gormdb.WithIssueActor(ctx, gormdb.IssueActor{
+			Project: s.resolveSourceProject(ctx, m),
+			Agent:   coerceString(m["agent_source"], "claude-code"),
+			Session: coerceString(m["session_id"], ""),
+		})
+	}
+
 	switch action {
 	case "create":
 		return s.handleIssueCreate(ctx, m)
@@ -173,8 +244,18 @@ func (s *Server) handleIssues(ctx context.Context, args json.RawMessage) (string
 		return s.handleIssueReopen(ctx, m)
 	case "close":
 		return s.handleIssueClose(ctx, m)
+	case "link":
+		return s.handleIssueLink(ctx, m)
+	case "unlink":
+		return s.handleIssueUnlink(ctx, m)
+	case "claim":
+		return s.handleIssueClaim(ctx, m)
+	case "renew":
+		return s.handleIssueRenew(ctx, m)
+	case "release":
+		return s.handleIssueRelease(ctx, m)
 	default:
-		return "", fmt.Errorf("unknown issues action: %q (valid: create, list, get, update, comment, reopen, close)", action)
+		return "", fmt.Errorf("unknown issues action: %q (valid: create, list, get, update, comment, reopen, close, link, unlink, claim, renew, release)", action)
 	}
 }
 
@@ -243,6 +324,7 @@ func (s *Server) handleIssueCreate(ctx context.Context, m map[string]any) (strin
 	if err != nil {
 		return "", fmt.Errorf("create issue: %w", err)
 	}
+	s.webhookPublisher.PublishIssue(ctx, webhooks.EventIssueCreated, issue)
 
 	return fmt.Sprintf("Issue #%d created: %s\nTarget: %s | Priority: %s | From: %s", id, title, targetProject, priority, sourceProject), nil
 }
@@ -256,9 +338,13 @@ func (s *Server) handleIssueList(ctx context.Context, m map[string]any) (string,
 	if sourceProject != "" {
 		sourceProject = s.issueStore.ResolveProject(ctx,sourceProject)
 	}
-	statusParam := coerceString(m["status"], "open,reopened")
+	statusParam := coerceString(m["status"], "")
 	limit := coerceInt(m["limit"], 20)
 	resolvedSinceMs := int64(coerceInt(m["resolved_since"], 0))
+	savedQuery := coerceString(m["saved_query"], "")
+	if statusParam == "" && savedQuery == "" {
+		statusParam = "open,reopened"
+	}
 
 	var statuses []string
 	for _, s := range strings.Split(statusParam, ",") {
@@ -272,12 +358,39 @@ func (s *Server) handleIssueList(ctx context.Context, m map[string]any) (string,
 		TargetProject: project,
 		SourceProject: sourceProject,
 		Statuses:      statuses,
+		Query:         strings.TrimSpace(coerceString(m["query"], "")),
+		Labels:        coerceStringSlice(m["labels"]),
+		ExcludeLabels: coerceStringSlice(m["exclude_labels"]),
+		Priorities:    coerceStringSlice(m["priorities"]),
 		Limit:         limit,
 	}
 	if resolvedSinceMs > 0 {
 		t := time.Unix(0, resolvedSinceMs*int64(time.Millisecond))
 		params.ResolvedSince = &t
 	}
+	for name, dst := range map[string]**time.Time{
+		"created_after":  &params.CreatedAfter,
+		"created_before": &params.CreatedBefore,
+		"updated_after":  &params.UpdatedAfter,
+		"updated_before": &params.UpdatedBefore,
+	} {
+		if ms := int64(coerceInt(m[name], 0)); ms > 0 {
+			t := time.UnixMilli(ms)
+			*dst = &t
+		}
+	}
+	if savedQuery != "" {
+		saved, err := s.issueStore.GetIssueQuery(ctx, savedQuery)
+		if err != nil {
+			return "", fmt.Errorf("list issues: %w", err)
+		}
+		params.ApplyFilter(saved.Filter)
+		if len(params.Statuses) == 0 {
+			statusParam = "any"
+		} else {
+			statusParam = strings.Join(params.Statuses, ",")
+		}
+	}
 
 	issues, total, err := s.issueStore.ListIssuesEx(ctx, params)
 	if err != nil {
@@ -291,8 +404,22 @@ func (s *Server) handleIssueList(ctx context.Context, m map[string]any) (string,
 		return fmt.Sprintf("No issues found with status %s.", statusParam), nil
 	}
 
+	ids := make([]int64, len(issues))
+	for i, issue := range issues {
+		ids[i] = issue.ID
+	}
+	blockers, err := s.issueStore.BlockersOf(ctx, ids)
+	if err != nil {
+		return "", fmt.Errorf("list issues: %w", err)
+	}
+
+	now := time.Now()
 	var sb strings.Builder
-	sb.WriteString(fmt.Sprintf("Issues (%d of %d):\n\n", len(issues), total))
+	if params.Query != "" {
+		sb.WriteString(fmt.Sprintf("Issues matching %q, most relevant first (%d of %d):\n\n", params.Query, len(issues), total))
+	} else {
+		sb.WriteString(fmt.Sprintf("Issues (%d of %d):\n\n", len(issues), total))
+	}
 
 	for _, issue := range issues {
 		status := issue.Status
@@ -300,8 +427,15 @@ func (s *Server) handleIssueList(ctx context.Context, m map[string]any) (string,
 		if issue.CommentCount > 0 {
 			comments = fmt.Sprintf(" · %d comments", issue.CommentCount)
 		}
-		sb.WriteString(fmt.Sprintf("#%d [%s] [%s] %s\n  %s → %s%s\n\n",
-			issue.ID, strings.ToUpper(issue.Priority), status,
+		blocked := ""
+		if blockedBy := blockers[issue.ID]; len(blockedBy) > 0 {
+			blocked = " [blocked by " + formatIssueRefs(blockedBy) + "]"
+		}
+		if issue.HasLiveClaim(now) {
+			blocked += fmt.Sprintf(" [claimed by %s]", issue.AssigneeSession)
+		}
+		sb.WriteString(fmt.Sprintf("#%d [%s] [%s]%s %s\n  %s → %s%s\n\n",
+			issue.ID, strings.ToUpper(issue.Priority), status, blocked,
 			issue.Title, issue.SourceProject, issue.TargetProject, comments))
 	}
 
@@ -318,11 +452,19 @@ func (s *Server) handleIssueGet(ctx context.Context, m map[string]any) (string,
 	if err != nil {
 		return "", err
 	}
+	links, err := s.issueStore.ListIssueLinks(ctx, id)
+	if err != nil {
+		return "", err
+	}
 
 	var sb strings.Builder
 	sb.WriteString(fmt.Sprintf("# Issue #%d: %s\n", issue.ID, issue.Title))
 	sb.WriteString(fmt.Sprintf("Status: %s | Priority: %s\n", issue.Status, issue.Priority))
 	sb.WriteString(fmt.Sprintf("From: %s → %s\n", issue.SourceProject, issue.TargetProject))
+	if issue.HasLiveClaim(time.Now()) {
+		sb.WriteString(fmt.Sprintf("Claimed by: %s (session %s) until %s\n",
+			issue.AssigneeAgent, issue.AssigneeSession, issue.LeaseExpiresAt.UTC().Format("2006-01-02 15:04 UTC")))
+	}
 	sb.WriteString(fmt.Sprintf("Created: %s\n\n", issue.CreatedAt.Format("2006-01-02 15:04")))
 
 	if issue.Body != "" {
@@ -330,6 +472,15 @@ func (s *Server) handleIssueGet(ctx context.Context, m map[string]any) (string,
 		sb.WriteString("\n\n")
 	}
 
+	if len(links) > 0 {
+		sb.WriteString(fmt.Sprintf("--- Links (%d) ---\n", len(links)))
+		for _, l := range links {
+			sb.WriteString(fmt.Sprintf("%s #%d [%s] %s (%s)\n",
+				l.Relation, l.IssueID, l.Status, l.Title, l.TargetProject))
+		}
+		sb.WriteString("\n")
+	}
+
 	if len(comments) > 0 {
 		sb.WriteString(fmt.Sprintf("--- Comments (%d) ---\n\n", len(comments)))
 		for _, c := range comments {
@@ -338,6 +489,18 @@ func (s *Server) handleIssueGet(ctx context.Context, m map[string]any) (string,
 		}
 	}
 
+	if coerceBool(m["include_events"], false) {
+		events, err := s.issueStore.ListIssueEvents(ctx, id)
+		if err != nil {
+			return "", err
+		}
+		sb.WriteString(fmt.Sprintf("--- Activity (%d) ---\n", len(events)))
+		for _, e := range events {
+			sb.WriteString(fmt.Sprintf("[%s] %s by %s\n",
+				e.CreatedAt.Format("2006-01-02 15:04"), formatIssueEventChange(e), formatIssueEventActor(e)))
+		}
+	}
+
 	return sb.String(), nil
 }
 
@@ -357,6 +520,9 @@ func (s *Server) handleIssueUpdate(ctx context.Context, m map[string]any) (strin
 		if err := s.issueStore.UpdateIssueStatus(ctx, id, status); err != nil {
 			return "", err
 		}
+		if issue, _, err := s.issueStore.GetIssue(ctx, id); err == nil {
+			s.webhookPublisher.PublishIssue(ctx, webhooks.EventIssueResolved, issue)
+		}
 	}
 
 	if comment != "" {
@@ -436,3 +602,138 @@ func (s *Server) handleIssueClose(ctx context.Context, m map[string]any) (string
 
 	return fmt.Sprintf("Issue #%d closed. The issue will no longer appear in any session injection.", id), nil
 }
+
+func (s *Server) handleIssueLink(ctx context.Context, m map[string]any) (string, error) {
+	id := int64(coerceInt(m["id"], 0))
+	linkedID := int64(coerceInt(m["linked_id"], 0))
+	linkType := coerceString(m["link_type"], "")
+
+	link, err := s.issueStore.LinkIssues(ctx, &gormdb.IssueLink{
+		SourceID:  id,
+		TargetID:  linkedID,
+		LinkType:  linkType,
+		CreatedBy: s.resolveSourceProject(ctx, m),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("Linked: issue #%d %s #%d (link id: %d).", link.SourceID, link.LinkType, link.TargetID, link.ID), nil
+}
+
+func (s *Server) handleIssueUnlink(ctx context.Context, m map[string]any) (string, error) {
+	id := int64(coerceInt(m["id"], 0))
+	linkedID := int64(coerceInt(m["linked_id"], 0))
+	linkType := coerceString(m["link_type"], "")
+
+	if err := s.issueStore.UnlinkIssues(ctx, id, linkedID, linkType); err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("Removed %s link between issues #%d and #%d.", linkType, id, linkedID), nil
+}
+
+func (s *Server) handleIssueClaim(ctx context.Context, m map[string]any) (string, error) {
+	id := int64(coerceInt(m["id"], 0))
+	session := coerceString(m["session_id"], "")
+	agent := coerceString(m["agent_source"], "claude-code")
+
+	issue, err := s.issueStore.ClaimIssue(ctx, id, agent, session, issueLease(m))
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("Issue #%d claimed by session %s until %s. Renew with issues(action=\"renew\") while you work; release when done or the claim lapses automatically.",
+		issue.ID, issue.AssigneeSession, issue.LeaseExpiresAt.UTC().Format("2006-01-02 15:04 UTC")), nil
+}
+
+func (s *Server) handleIssueRenew(ctx context.Context, m map[string]any) (string, error) {
+	id := int64(coerceInt(m["id"], 0))
+	session := coerceString(m["session_id"], "")
+
+	issue, err := s.issueStore.RenewIssueLease(ctx, id, session, issueLease(m))
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("Claim on issue #%d renewed until %s.",
+		issue.ID, issue.LeaseExpiresAt.UTC().Format("2006-01-02 15:04 UTC")), nil
+}
+
+func (s *Server) handleIssueRelease(ctx context.Context, m map[string]any) (string, error) {
+	id := int64(coerceInt(m["id"], 0))
+	session := coerceString(m["session_id"], "")
+
+	if err := s.issueStore.ReleaseIssue(ctx, id, session); err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("Claim on issue #%d released.", id), nil
+}
+
+// issueLease returns the lease requested by lease_minutes, or the default.
+func issueLease(m map[string]any) time.Duration {
+	minutes := coerceInt(m["lease_minutes"], 0)
+	if minutes <= 0 {
+		return gormdb.DefaultIssueLease
+	}
+	return time.Duration(minutes) * time.Minute
+}
+
+// formatIssueRefs renders issue IDs as "#1, #2".
+func formatIssueRefs(ids []int64) string {
+	refs := make([]string, len(ids))
+	for i, id := range ids {
+		refs[i] = fmt.Sprintf("#%d", id)
+	}
+	return strings.Join(refs, ", ")
+}
+
+// formatIssueEventChange renders what an issue event changed, e.g.
+// "status: resolved → reopened" or "linked: blocks #7".
+func formatIssueEventChange(e gormdb.IssueEvent) string {
+	switch e.EventType {
+	case gormdb.IssueEventCreated:
+		return "created as " + e.NewValue
+	case gormdb.IssueEventStatusChanged:
+		return fmt.Sprintf("status: %s → %s", e.OldValue, e.NewValue)
+	case gormdb.IssueEventFieldChanged:
+		if e.Field == "body" {
+			return "body edited"
+		}
+		return fmt.Sprintf("%s: %s → %s", e.Field, e.OldValue, e.NewValue)
+	case gormdb.IssueEventClaimed:
+		return "claimed by session " + e.NewValue
+	case gormdb.IssueEventReleased, gormdb.IssueEventLeaseExpired:
+		return fmt.Sprintf("%s (session %s)", strings.ReplaceAll(e.EventType, "_", " "), e.OldValue)
+	case gormdb.IssueEventLinked:
+		return "linked: " + e.NewValue
+	case gormdb.IssueEventUnlinked:
+		return "unlinked: " + e.OldValue
+	default:
+		return e.EventType
+	}
+}
+
+// formatIssueEventActor renders who made an issue event: "project (agent,
+// session s1)", or "engram" for background jobs.
+func formatIssueEventActor(e gormdb.IssueEvent) string {
+	var details []string
+	if e.ActorAgent != "" {
+		details = append(details, e.ActorAgent)
+	}
+	if e.ActorSession != "" {
+		details = append(details, "session "+e.ActorSession)
+	}
+	actor := e.ActorProject
+	if actor == "" {
+		if len(details) == 0 {
+			return "engram"
+		}
+		actor = "unknown project"
+	}
+	if len(details) > 0 {
+		actor += " (" + strings.Join(details, ", ") + ")"
+	}
+	return actor
+}
@@ -0,0 +1,38 @@
+package mcp
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	gormdb "github.com/thebtf/engram/internal/db/gorm"
+)
+
+func TestFormatIssueEvent(t *testing.T) {
+	tests := []struct {
+		event      gormdb.IssueEvent
+		wantChange string
+		wantActor  string
+	}{
+		{
+			gormdb.IssueEvent{EventType: gormdb.IssueEventStatusChanged, Field: "status", OldValue: "resolved", NewValue: "reopened", ActorProject: "engram", ActorAgent: "claude-code", ActorSession: "s1"},
+			"status: resolved → reopened", "engram (claude-code, session s1)",
+		},
+		{
+			gormdb.IssueEvent{EventType: gormdb.IssueEventFieldChanged, Field: "body", OldValue: "a", NewValue: "b", ActorProject: "dashboard"},
+			"body edited", "dashboard",
+		},
+		{
+			gormdb.IssueEvent{EventType: gormdb.IssueEventLeaseExpired, Field: "assignee_session", OldValue: "s2"},
+			"lease expired (session s2)", "engram",
+		},
+		{
+			gormdb.IssueEvent{EventType: gormdb.IssueEventLinked, Field: "link", NewValue: "blocks #7", ActorAgent: "codex"},
+			"linked: blocks #7", "unknown project (codex)",
+		},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.wantChange, formatIssueEventChange(tt.event))
+		assert.Equal(t, tt.wantActor, formatIssueEventActor(tt.event))
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"strings"
+	"time"
 	"unicode/utf8"
 
 	gormlib "gorm.io/gorm"
@@ -13,7 +14,9 @@ import (
 	"github.com/rs/zerolog/log"
 	"github.com/thebtf/engram/internal/config"
 	"github.com/thebtf/engram/internal/privacy"
+	"github.com/thebtf/engram/internal/webhooks"
 	"github.com/thebtf/engram/pkg/models"
+	"github.com/thebtf/engram/pkg/strutil"
 )
 
 func isValidStoreObservationType(obsType models.ObservationType) bool {
@@ -60,6 +63,7 @@ func (s *Server) handleStoreMemory(ctx context.Context, args json.RawMessage) (s
 		Importance   *float64
 		TtlDays      *int
 		AlwaysInject bool
+		Force        bool
 	}
 	params.Tags = coerceStringSlice(m["tags"])
 	params.Rejected = coerceStringSlice(m["rejected"])
@@ -77,6 +81,7 @@ func (s *Server) handleStoreMemory(ctx context.Context, args json.RawMessage) (s
 		params.Project = coerceString(m["project"], "")
 	}
 	params.AlwaysInject = coerceBool(m["always_inject"], false)
+	params.Force = coerceBool(m["force"], false)
 	if v, ok := m["importance"]; ok && v != nil {
 		f := coerceFloat64(v, 0)
 		params.Importance = &f
@@ -204,6 +209,7 @@ func (s *Server) handleStoreMemory(ctx context.Context, args json.RawMessage) (s
 		if err != nil {
 			return "", fmt.Errorf("store behavioral rule: %w", err)
 		}
+		s.webhookPublisher.PublishRule(ctx, created)
 
 		result := map[string]any{
 			"id":            created.ID,
@@ -230,33 +236,55 @@ func (s *Server) handleStoreMemory(ctx context.Context, args json.RawMessage) (s
 		}
 	}
 
+	// Near-duplicate guard: the same lesson tends to be stored again by every
+	// session that learns it. force=true skips the check.
+	if threshold := dedupThreshold(); threshold > 0 && !params.Force {
+		matches, err := s.findDuplicateMemories(ctx, params.Project, params.Content, threshold)
+		if err != nil {
+			return "", fmt.Errorf("store memory: %w", err)
+		}
+		if len(matches) > 0 {
+			return duplicateRejection(matches, threshold)
+		}
+	}
+
 	memory := &models.Memory{
 		Project:     params.Project,
 		Content:     params.Content,
 		Tags:        tags,
 		SourceAgent: agentSource,
+		MemoryType:  obsTypeStr,
+		Rejected:    params.Rejected,
+	}
+	if params.Importance != nil {
+		memory.Importance = *params.Importance
+	}
+	if ttlApplied {
+		expiresAt := time.Now().UTC().AddDate(0, 0, ttlDays)
+		memory.ExpiresAt = &expiresAt
 	}
 	created, err := s.memoryStore.Create(ctx, memory)
 	if err != nil {
 		return "", fmt.Errorf("store memory: %w", err)
 	}
+	s.embedMemory(ctx, created)
+	s.webhookPublisher.Publish(ctx, webhooks.EventMemoryCreated, created.Project, created)
 
 	result := map[string]any{
-		"id":      created.ID,
-		"title":   truncateTitle(created.Content, 80),
-		"type":    obsTypeStr,
-		"scope":   resolvedScope,
-		"storage": "memories",
-		"message": "Memory stored successfully",
+		"id":         created.ID,
+		"title":      truncateTitle(created.Content, 80),
+		"type":       created.MemoryType,
+		"scope":      resolvedScope,
+		"importance": created.Importance,
+		"storage":    "memories",
+		"message":    "Memory stored successfully",
 	}
 	if ttlApplied {
 		result["ttl_days"] = ttlDays
+		result["expires_at"] = created.ExpiresAt
 	}
-	if params.Importance != nil {
-		result["importance_note"] = "importance metadata is not stored in v5 memories schema"
-	}
-	if len(params.Rejected) > 0 {
-		result["rejected_note"] = "rejected alternatives are not stored in v5 memories schema"
+	if len(created.Rejected) > 0 {
+		result["rejected"] = created.Rejected
 	}
 	out, err := json.MarshalIndent(result, "", "  ")
 	if err != nil {
@@ -552,3 +580,199 @@ func (s *Server) handleSuppressMemory(ctx context.Context, args json.RawMessage)
 
 	return fmt.Sprintf("Memory %d suppressed", id), nil
 }
+
+// memoryEditor resolves who is recorded as edited_by for a memory revision made
+// through MCP: an explicit edited_by, else agent_source, else "mcp".
+func memoryEditor(m map[string]any) string {
+	if v := strings.TrimSpace(coerceString(m["edited_by"], "")); v != "" {
+		return v
+	}
+	if v := strings.TrimSpace(coerceString(m["agent_source"], "")); v != "" {
+		return v
+	}
+	return "mcp"
+}
+
+// marshalMemoryRevision renders the result of an edit, merge or restore.
+func marshalMemoryRevision(mem *models.Memory, message string) (string, error) {
+	out, err := json.MarshalIndent(map[string]any{
+		"id":        mem.ID,
+		"project":   mem.Project,
+		"title":     truncateTitle(mem.Content, 80),
+		"tags":      mem.Tags,
+		"version":   mem.Version,
+		"edited_by": mem.EditedBy,
+		"message":   message,
+	}, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("marshal result: %w", err)
+	}
+	return string(out), nil
+}
+
+// handleEditMemory rewrites a memory's content and/or tags, recording a new revision.
+// Omitted fields keep their current value; narrative is accepted as an alias for content.
+func (s *Server) handleEditMemory(ctx context.Context, m map[string]any) (string, error) {
+	if s.memoryStore == nil {
+		return "", fmt.Errorf("memory store not available")
+	}
+	id := coerceInt64(m["id"], 0)
+	if id == 0 {
+		return "", fmt.Errorf("id is required for store(action=\"edit\")")
+	}
+
+	current, err := s.memoryStore.Get(ctx, id)
+	if err != nil {
+		if errors.Is(err, gormlib.ErrRecordNotFound) {
+			return "", fmt.Errorf("edit: memory %d not found", id)
+		}
+		return "", fmt.Errorf("edit: %w", err)
+	}
+
+	content := coerceString(m["content"], "")
+	if content == "" {
+		content = coerceString(m["narrative"], "")
+	}
+	tags := current.Tags
+	_, tagsGiven := m["tags"]
+	if tagsGiven {
+		tags = coerceStringSlice(m["tags"])
+	}
+	if content == "" && !tagsGiven {
+		return "", fmt.Errorf("edit: content or tags is required")
+	}
+	if content == "" {
+		content = current.Content
+	}
+	if privacy.ContainsSecrets(content) {
+		log.Warn().Int64("id", id).Msg("edit memory: content contains secrets — redacting before storage")
+		content = privacy.RedactSecrets(content)
+	}
+
+	updated, err := s.memoryStore.Update(ctx, &models.Memory{
+		ID:          id,
+		Content:     content,
+		Tags:        tags,
+		SourceAgent: current.SourceAgent,
+		EditedBy:    memoryEditor(m),
+	})
+	if err != nil {
+		return "", fmt.Errorf("edit: %w", err)
+	}
+	return marshalMemoryRevision(updated, "Memory updated")
+}
+
+// handleMergeMemories folds source_id into target_id: tags are unioned, the source
+// content is appended (unless content overrides the merged text) and the source is
+// soft-deleted.
+func (s *Server) handleMergeMemories(ctx context.Context, m map[string]any) (string, error) {
+	if s.memoryStore == nil {
+		return "", fmt.Errorf("memory store not available")
+	}
+	sourceID := coerceInt64(m["source_id"], 0)
+	targetID := coerceInt64(m["target_id"], 0)
+	if sourceID == 0 || targetID == 0 {
+		return "", fmt.Errorf("source_id and target_id are required for store(action=\"merge\")")
+	}
+
+	merged, err := s.memoryStore.Merge(ctx, sourceID, targetID, coerceString(m["content"], ""), memoryEditor(m))
+	if err != nil {
+		if errors.Is(err, gormlib.ErrRecordNotFound) {
+			return "", fmt.Errorf("merge: memory not found (source_id=%d, target_id=%d)", sourceID, targetID)
+		}
+		return "", fmt.Errorf("merge: %w", err)
+	}
+	return marshalMemoryRevision(merged, fmt.Sprintf("Memory %d merged into %d", sourceID, targetID))
+}
+
+// handleMemoryHistory lists the recorded revisions of a memory, newest first.
+func (s *Server) handleMemoryHistory(ctx context.Context, m map[string]any) (string, error) {
+	if s.memoryStore == nil {
+		return "", fmt.Errorf("memory store not available")
+	}
+	id := coerceInt64(m["id"], 0)
+	if id == 0 {
+		return "", fmt.Errorf("id is required for store(action=\"history\")")
+	}
+	versions, err := s.memoryStore.ListVersions(ctx, id)
+	if err != nil {
+		return "", fmt.Errorf("history: %w", err)
+	}
+	out, err := json.MarshalIndent(map[string]any{
+		"id":       id,
+		"versions": versions,
+		"count":    len(versions),
+	}, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("marshal result: %w", err)
+	}
+	return string(out), nil
+}
+
+// handleDiffMemoryVersions returns a line diff between two revisions of a memory.
+// from defaults to the revision before to; to defaults to the latest revision.
+func (s *Server) handleDiffMemoryVersions(ctx context.Context, m map[string]any) (string, error) {
+	if s.memoryStore == nil {
+		return "", fmt.Errorf("memory store not available")
+	}
+	id := coerceInt64(m["id"], 0)
+	if id == 0 {
+		return "", fmt.Errorf("id is required for store(action=\"diff\")")
+	}
+	to := coerceInt(m["to"], 0)
+	if to <= 0 {
+		current, err := s.memoryStore.Get(ctx, id)
+		if err != nil {
+			return "", fmt.Errorf("diff: %w", err)
+		}
+		to = current.Version
+	}
+	from := coerceInt(m["from"], to-1)
+	if from <= 0 {
+		return "", fmt.Errorf("diff: memory %d has no revision before v%d", id, to)
+	}
+
+	a, err := s.memoryStore.GetVersion(ctx, id, from)
+	if err != nil {
+		return "", fmt.Errorf("diff: %w", err)
+	}
+	b, err := s.memoryStore.GetVersion(ctx, id, to)
+	if err != nil {
+		return "", fmt.Errorf("diff: %w", err)
+	}
+
+	out, err := json.MarshalIndent(map[string]any{
+		"id":           id,
+		"from":         from,
+		"to":           to,
+		"content_diff": strutil.LineDiff(a.Content, b.Content),
+		"tags_added":   strutil.Difference(b.Tags, a.Tags),
+		"tags_removed": strutil.Difference(a.Tags, b.Tags),
+		"edited_by":    b.EditedBy,
+		"note":         b.Note,
+	}, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("marshal result: %w", err)
+	}
+	return string(out), nil
+}
+
+// handleRestoreMemoryVersion makes an older revision current by writing it as a new revision.
+func (s *Server) handleRestoreMemoryVersion(ctx context.Context, m map[string]any) (string, error) {
+	if s.memoryStore == nil {
+		return "", fmt.Errorf("memory store not available")
+	}
+	id := coerceInt64(m["id"], 0)
+	version := coerceInt(m["version"], 0)
+	if id == 0 || version <= 0 {
+		return "", fmt.Errorf("id and version are required for store(action=\"restore\")")
+	}
+	restored, err := s.memoryStore.Restore(ctx, id, version, memoryEditor(m))
+	if err != nil {
+		if errors.Is(err, gormlib.ErrRecordNotFound) {
+			return "", fmt.Errorf("restore: memory %d version %d not found", id, version)
+		}
+		return "", fmt.Errorf("restore: %w", err)
+	}
+	return marshalMemoryRevision(restored, fmt.Sprintf("Memory %d restored to v%d", id, version))
+}
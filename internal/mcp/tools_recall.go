@@ -2,10 +2,11 @@
 // to existing handler functions on *Server. This is the single entry point
 // for all memory retrieval operations, dispatching by action parameter.
 //
-// v5 (US9): dropped actions search (was hybrid/fusion), preset, by_concept,
-// by_type, similar, timeline, explain. The "search" action now runs a trivial
-// SQL filter over the memories store. Dropped handler symbols have been
-// removed from server.go.
+// v5 (US9): dropped actions preset, by_concept, by_type, timeline, explain.
+// The "search" action runs ranked Postgres full-text search over the memories
+// store, fused with vector similarity when an embedder is configured
+// (mode=hybrid); "similar" is pure vector search. Dropped handler symbols have
+// been removed from server.go.
 package mcp
 
 import (
@@ -13,6 +14,10 @@ import (
 	"encoding/json"
 	"fmt"
 	"strings"
+	"time"
+
+	"github.com/thebtf/engram/internal/db/gorm"
+	"github.com/thebtf/engram/pkg/models"
 )
 
 // handleRecall is the consolidated recall tool handler. It parses the "action"
@@ -45,8 +50,7 @@ func (s *Server) handleRecall(ctx context.Context, args json.RawMessage) (string
 		return "", fmt.Errorf("recall: action %q not supported in v5 (type-lane search removed — use recall(action=\"search\") instead)", action)
 
 	case "similar":
-		// Dropped in v5 (US9): vector similarity search removed (content_chunks dropped).
-		return "", fmt.Errorf("recall: action %q not supported in v5 (vector similarity removed)", action)
+		return s.handleFindSimilarObservations(ctx, args)
 
 	case "timeline":
 		// Dropped in v5 (US9): timeline backed by search.Manager.
@@ -71,19 +75,66 @@ func (s *Server) handleRecall(ctx context.Context, args json.RawMessage) (string
 
 	default:
 		return "", fmt.Errorf(
-			"unknown recall action: %q (valid: search, by_file, related, reasoning)",
+			"unknown recall action: %q (valid: search, similar, by_file, related, reasoning)",
 			action,
 		)
 	}
 }
 
-// handleRecallSearch performs trivial SQL-based memory retrieval.
-// It filters the memories table by project (required when non-empty) and
-// optionally applies a case-insensitive substring match on content when a
-// query string is provided. Results are ordered by created_at DESC.
+// recallMemoryResult is the JSON shape of a single memory in recall search output.
+// Snippet and Score are only populated for ranked queries; Similarity only for
+// hits ranked by vector search.
+type recallMemoryResult struct {
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+	Tags        []string   `json:"tags,omitempty"`
+	Content     string     `json:"content"`
+	Snippet     string     `json:"snippet,omitempty"`
+	SourceAgent string     `json:"source_agent,omitempty"`
+	Project     string     `json:"project"`
+	Type        string     `json:"type,omitempty"`
+	ID          int64      `json:"id"`
+	Score       float64    `json:"score,omitempty"`
+	Similarity  float64    `json:"similarity,omitempty"`
+	Importance  float64    `json:"importance"`
+	Version     int        `json:"version"`
+}
+
+// recallMemoryGroup is the JSON shape of one project's hits in cross-project output.
+type recallMemoryGroup struct {
+	Project   string               `json:"project"`
+	Memories  []recallMemoryResult `json:"memories"`
+	Count     int                  `json:"count"`
+	BestScore float64              `json:"best_score"`
+}
+
+// handleRecallSearch retrieves memories for a project.
+// With a query it runs ranked full-text search over memories.search_vector
+// (websearch syntax: "exact phrase", -exclude, a OR b) and returns highlighted
+// snippets, relevance scores and a next_cursor for pagination. Without a query it
+// returns the newest memories first, or the most important ones with order_by=importance.
+//
+// types and min_importance narrow both modes; expired memories are never returned.
+//
+// mode selects how a query is ranked: fts (full-text only, paginated), semantic
+// (vector similarity only) or hybrid (both, merged by reciprocal rank fusion).
+// It defaults to hybrid when an embedder is configured and no cursor is given.
+// Vector hits below min_similarity (default 0.2) are ignored.
+//
+// scope="all" (or a non-empty include_projects list) switches to cross-project
+// search: every active project is searched, narrowed by include_projects and
+// exclude_projects, and hits are grouped per project.
 func (s *Server) handleRecallSearch(ctx context.Context, m map[string]any) (string, error) {
 	project := coerceString(m["project"], "")
-	query := coerceString(m["query"], "")
+	query := strings.TrimSpace(coerceString(m["query"], ""))
+	cursor := strings.TrimSpace(coerceString(m["cursor"], ""))
+	scope := coerceString(m["scope"], "project")
+	includeProjects := coerceStringSlice(m["include_projects"])
+	excludeProjects := coerceStringSlice(m["exclude_projects"])
+	types := coerceStringSlice(m["types"])
+	minImportance := coerceFloat64(m["min_importance"], 0)
+	orderBy := coerceString(m["order_by"], gorm.MemoryOrderRecent)
+	mode := coerceString(m["mode"], "")
+	minSimilarity := coerceFloat64(m["min_similarity"], defaultRecallMinSimilarity)
 	limit := coerceInt(m["limit"], 20)
 	if limit <= 0 {
 		limit = 20
@@ -95,74 +146,139 @@ func (s *Server) handleRecallSearch(ctx context.Context, m map[string]any) (stri
 		return "", fmt.Errorf("recall: memory store not configured")
 	}
 
-	// List returns created_at DESC, project-filtered results.
+	if scope != "project" && scope != "all" {
+		return "", fmt.Errorf("recall: invalid scope %q (valid: project, all)", scope)
+	}
+	if orderBy != gorm.MemoryOrderRecent && orderBy != gorm.MemoryOrderImportance {
+		return "", fmt.Errorf("recall: invalid order_by %q (valid: recent, importance)", orderBy)
+	}
+	if minImportance < 0 || minImportance > 1 {
+		return "", fmt.Errorf("recall: min_importance must be between 0 and 1")
+	}
+	if minSimilarity < 0 || minSimilarity > 1 {
+		return "", fmt.Errorf("recall: min_similarity must be between 0 and 1")
+	}
+	switch mode {
+	case "":
+		mode = recallModeFTS
+		if s.embedder != nil && cursor == "" {
+			mode = recallModeHybrid
+		}
+	case recallModeFTS:
+	case recallModeSemantic, recallModeHybrid:
+		if s.embedder == nil {
+			return "", fmt.Errorf("recall: mode %q unavailable (embeddings disabled, ENGRAM_EMBEDDING_PROVIDER=none)", mode)
+		}
+		if cursor != "" {
+			return "", fmt.Errorf("recall: cursor is only supported with mode=%q", recallModeFTS)
+		}
+	default:
+		return "", fmt.Errorf("recall: invalid mode %q (valid: fts, semantic, hybrid)", mode)
+	}
+	if scope == "all" || len(includeProjects) > 0 {
+		return s.handleRecallSearchAcrossProjects(ctx, mode, minSimilarity, gorm.MemorySearchParams{
+			Query:           query,
+			Cursor:          cursor,
+			IncludeProjects: includeProjects,
+			ExcludeProjects: excludeProjects,
+			Types:           types,
+			MinImportance:   minImportance,
+			Limit:           limit,
+		})
+	}
+
 	if project == "" {
 		// No project scope: return a helpful message rather than silently
 		// returning zero rows (the project param is required by List).
-		return `{"memories":[],"count":0,"note":"project parameter required for memory search in v5"}`, nil
+		return `{"memories":[],"count":0,"note":"project parameter required for memory search in v5 (use scope=\"all\" to search every project)"}`, nil
 	}
 
-	// When a query filter is provided, fetch a wider candidate pool so that
-	// older matching memories are not excluded by the limit before filtering.
-	// The final result is capped at `limit` after filtering.
-	fetchLimit := limit
-	query = strings.TrimSpace(query)
+	results := make([]recallMemoryResult, 0, limit)
+	out := map[string]any{}
+
 	if query != "" {
-		const candidateMultiplier = 10
-		const minCandidatePool = 1000
-		fetchLimit = limit * candidateMultiplier
-		if fetchLimit < minCandidatePool {
-			fetchLimit = minCandidatePool
+		page, err := s.searchMemories(ctx, gorm.MemorySearchParams{
+			Project:       project,
+			Query:         query,
+			Cursor:        cursor,
+			Types:         types,
+			MinImportance: minImportance,
+			Limit:         limit,
+		}, mode, minSimilarity)
+		if err != nil {
+			return "", fmt.Errorf("recall search: %w", err)
+		}
+		for _, hit := range page.Hits {
+			results = append(results, recallHitResult(hit))
 		}
+		out["query"] = query
+		out["mode"] = mode
+		if page.NextCursor != "" {
+			out["next_cursor"] = page.NextCursor
+		}
+	} else {
+		memories, err := s.memoryStore.ListEx(ctx, gorm.MemoryListParams{
+			Project:       project,
+			Types:         types,
+			MinImportance: minImportance,
+			OrderBy:       orderBy,
+			Limit:         limit,
+		})
+		if err != nil {
+			return "", fmt.Errorf("recall search: %w", err)
+		}
+		for _, mem := range memories {
+			results = append(results, recallResultFromMemory(mem))
+		}
+	}
+
+	out["memories"] = results
+	out["count"] = len(results)
+
+	output, err := json.Marshal(out)
+	if err != nil {
+		return "", fmt.Errorf("recall search marshal: %w", err)
+	}
+	return string(output), nil
+}
+
+// handleRecallSearchAcrossProjects runs a ranked search over every project the
+// caller may see and groups the hits per project. The cursor paginates the
+// underlying ranked list, so a project can reappear in the groups of a later page.
+func (s *Server) handleRecallSearchAcrossProjects(ctx context.Context, mode string, minSimilarity float64, params gorm.MemorySearchParams) (string, error) {
+	if params.Query == "" {
+		return "", fmt.Errorf("recall: query is required for cross-project search")
 	}
 
-	memories, err := s.memoryStore.List(ctx, project, fetchLimit)
+	page, err := s.searchMemories(ctx, params, mode, minSimilarity)
 	if err != nil {
 		return "", fmt.Errorf("recall search: %w", err)
 	}
 
-	// Apply optional query filter in-memory (case-insensitive substring),
-	// then cap at the originally requested limit.
-	if query != "" {
-		queryLower := strings.ToLower(query)
-		filtered := memories[:0:0] // same element type, zero length, zero cap (avoids models import)
-		for _, mem := range memories {
-			if strings.Contains(strings.ToLower(mem.Content), queryLower) {
-				filtered = append(filtered, mem)
-				if len(filtered) == limit {
-					break
-				}
-			}
+	groups := make([]recallMemoryGroup, 0)
+	for _, g := range page.GroupByProject() {
+		group := recallMemoryGroup{
+			Project:   g.Project,
+			BestScore: g.BestScore,
+			Memories:  make([]recallMemoryResult, 0, len(g.Hits)),
 		}
-		memories = filtered
-	}
-
-	type memoryResult struct {
-		Tags        []string `json:"tags,omitempty"`
-		Content     string   `json:"content"`
-		SourceAgent string   `json:"source_agent,omitempty"`
-		Project     string   `json:"project"`
-		ID          int64    `json:"id"`
-		Version     int      `json:"version"`
-	}
-	results := make([]memoryResult, 0, len(memories))
-	for _, mem := range memories {
-		results = append(results, memoryResult{
-			ID:          mem.ID,
-			Project:     mem.Project,
-			Content:     mem.Content,
-			Tags:        mem.Tags,
-			SourceAgent: mem.SourceAgent,
-			Version:     mem.Version,
-		})
+		for _, hit := range g.Hits {
+			group.Memories = append(group.Memories, recallHitResult(hit))
+		}
+		group.Count = len(group.Memories)
+		groups = append(groups, group)
 	}
 
 	out := map[string]any{
-		"memories": results,
-		"count":    len(results),
+		"scope":    "all",
+		"query":    params.Query,
+		"mode":     mode,
+		"groups":   groups,
+		"projects": len(groups),
+		"count":    len(page.Hits),
 	}
-	if query != "" {
-		out["query"] = query
+	if page.NextCursor != "" {
+		out["next_cursor"] = page.NextCursor
 	}
 
 	output, err := json.Marshal(out)
@@ -172,6 +288,29 @@ func (s *Server) handleRecallSearch(ctx context.Context, m map[string]any) (stri
 	return string(output), nil
 }
 
+// recallHitResult converts a ranked search hit to its recall JSON shape.
+func recallHitResult(hit gorm.MemorySearchHit) recallMemoryResult {
+	result := recallResultFromMemory(hit.Memory)
+	result.Snippet = hit.Snippet
+	result.Score = hit.Score
+	result.Similarity = hit.Similarity
+	return result
+}
+
+// recallResultFromMemory converts a memory to its recall JSON shape.
+func recallResultFromMemory(mem *models.Memory) recallMemoryResult {
+	return recallMemoryResult{
+		ID:          mem.ID,
+		Project:     mem.Project,
+		Content:     mem.Content,
+		Tags:        mem.Tags,
+		SourceAgent: mem.SourceAgent,
+		Type:        mem.MemoryType,
+		Importance:  mem.Importance,
+		ExpiresAt:   mem.ExpiresAt,
+		Version:     mem.Version,
+	}
+}
 
 // handleReasoningSearch retrieves reasoning traces by project.
 func (s *Server) handleReasoningSearch(ctx context.Context, args json.RawMessage) (string, error) {
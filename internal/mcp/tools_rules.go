@@ -46,6 +46,7 @@ func (s *Server) handleStoreRule(ctx context.Context, args json.RawMessage) (str
 	if err != nil {
 		return "", fmt.Errorf("store_rule: %w", err)
 	}
+	s.webhookPublisher.PublishRule(ctx, created)
 
 	type response struct {
 		CreatedAt any    `json:"created_at"`
@@ -19,13 +19,19 @@ func (s *Server) handleStoreConsolidated(ctx context.Context, args json.RawMessa
 	case "create":
 		return s.handleStoreMemory(ctx, args)
 	case "edit":
-		return "", fmt.Errorf("store action 'edit' (observation editing) removed in v5")
+		return s.handleEditMemory(ctx, m)
 	case "merge":
-		return "", fmt.Errorf("store action 'merge' (observation merging) removed in v5")
+		return s.handleMergeMemories(ctx, m)
+	case "history":
+		return s.handleMemoryHistory(ctx, m)
+	case "diff":
+		return s.handleDiffMemoryVersions(ctx, m)
+	case "restore":
+		return s.handleRestoreMemoryVersion(ctx, m)
 	case "import":
 		return s.handleImportInstincts(ctx, args)
 	default:
-		return "", fmt.Errorf("unknown store action: %q (valid: create, edit, merge, import)", action)
+		return "", fmt.Errorf("unknown store action: %q (valid: create, edit, merge, history, diff, restore, import)", action)
 	}
 }
 
@@ -60,7 +60,7 @@ func Init() error {
 // instruments — lazily-initialised metric instruments
 // ---------------------------------------------------------------------------
 
-// instruments holds all four metric instruments used by the engram framework.
+// instruments holds all metric instruments used by the engram framework.
 // Each instrument is created at most once via its own sync.Once. If creation
 // fails (should never happen with the OTel no-op provider), the error is
 // logged once and the instrument pointer remains nil; subsequent Record calls
@@ -88,6 +88,16 @@ type instruments struct {
 	// No labels — avoiding cardinality explosion per design.md §6.
 	activeSessionsOnce sync.Once
 	activeSessions     metric.Int64UpDownCounter
+
+	// outboxDepth tracks the number of tool calls in a module's offline
+	// outbox. Labels: module, status.
+	outboxDepthOnce sync.Once
+	outboxDepth     metric.Int64Gauge
+
+	// outboxReplayedTotal counts outbox entries the replayer finished with.
+	// Labels: module, result.
+	outboxReplayedTotalOnce sync.Once
+	outboxReplayedTotal     metric.Int64Counter
 }
 
 // global is the process-wide instrument set. It is intentionally unexported
@@ -254,6 +264,74 @@ func DecrementActiveSessions(ctx context.Context) {
 	global.activeSessions.Add(ctx, -1)
 }
 
+// ---------------------------------------------------------------------------
+// RecordOutboxDepth — engram_outbox_depth
+// ---------------------------------------------------------------------------
+
+// RecordOutboxDepth records the number of tool calls in a module's offline
+// outbox.
+//
+// Parameters:
+//   - module: owning module name.
+//   - status: "pending" (waiting for replay) or "failed" (rejected by the
+//     server, kept for inspection).
+//   - depth: current number of entries with that status.
+func RecordOutboxDepth(ctx context.Context, module, status string, depth int64) {
+	global.outboxDepthOnce.Do(func() {
+		g, err := meter().Int64Gauge(
+			"engram_outbox_depth",
+			metric.WithDescription("Tool calls in the offline outbox, labelled by module and status"),
+		)
+		if err != nil {
+			slog.Warn("obs: failed to create engram_outbox_depth gauge", "error", err)
+			return
+		}
+		global.outboxDepth = g
+	})
+	if global.outboxDepth == nil {
+		return
+	}
+	global.outboxDepth.Record(ctx, depth,
+		metric.WithAttributes(
+			attribute.String("module", module),
+			attribute.String("status", status),
+		),
+	)
+}
+
+// ---------------------------------------------------------------------------
+// RecordOutboxReplay — engram_outbox_replayed_total
+// ---------------------------------------------------------------------------
+
+// RecordOutboxReplay counts an outbox entry the replayer finished with.
+//
+// Parameters:
+//   - module: owning module name.
+//   - result: "ok", "tool_error" (the server ran the call and the tool
+//     reported an error) or "rejected" (the server refused the call).
+func RecordOutboxReplay(ctx context.Context, module, result string) {
+	global.outboxReplayedTotalOnce.Do(func() {
+		c, err := meter().Int64Counter(
+			"engram_outbox_replayed_total",
+			metric.WithDescription("Total offline outbox entries replayed, labelled by module and result"),
+		)
+		if err != nil {
+			slog.Warn("obs: failed to create engram_outbox_replayed_total counter", "error", err)
+			return
+		}
+		global.outboxReplayedTotal = c
+	})
+	if global.outboxReplayedTotal == nil {
+		return
+	}
+	global.outboxReplayedTotal.Add(ctx, 1,
+		metric.WithAttributes(
+			attribute.String("module", module),
+			attribute.String("result", result),
+		),
+	)
+}
+
 // ---------------------------------------------------------------------------
 // ResetInstrumentsForTesting — test helper ONLY
 // ---------------------------------------------------------------------------
@@ -0,0 +1,216 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+
+	dbgorm "github.com/thebtf/engram/internal/db/gorm"
+)
+
+const (
+	// defaultDeliveryInterval is how often the deliverer polls the queue when
+	// ENGRAM_WEBHOOK_DELIVERY_INTERVAL is unset or invalid.
+	defaultDeliveryInterval = 10 * time.Second
+	// deliveryBatchSize caps the deliveries claimed per poll.
+	deliveryBatchSize = 20
+	// deliveryTimeout bounds a single HTTP attempt.
+	deliveryTimeout = 10 * time.Second
+	// deliveryLease is how long a claimed batch is hidden from other pollers;
+	// it must cover a whole batch of timed-out attempts.
+	deliveryLease = 5 * time.Minute
+	// maxErrorBody caps the response body kept in last_error.
+	maxErrorBody = 512
+
+	// MaxAttempts is how many times a delivery is tried before it is marked
+	// failed.
+	MaxAttempts = 8
+	// baseBackoff is the delay after the first failed attempt; each further
+	// failure doubles it, up to maxBackoff.
+	baseBackoff = 30 * time.Second
+	maxBackoff  = 6 * time.Hour
+)
+
+// Backoff returns the delay before the next attempt after attempts failed
+// attempts: 30s, 1m, 2m, 4m, ... capped at 6h.
+func Backoff(attempts int) time.Duration {
+	if attempts < 1 {
+		attempts = 1
+	}
+	d := baseBackoff
+	for i := 1; i < attempts; i++ {
+		d *= 2
+		if d >= maxBackoff {
+			return maxBackoff
+		}
+	}
+	return d
+}
+
+// Deliverer periodically posts due webhook deliveries.
+type Deliverer struct {
+	store  *dbgorm.WebhookStore
+	client *http.Client
+	stop   chan struct{}
+	done   chan struct{}
+}
+
+// New creates a Deliverer backed by the given database connection.
+func New(db *gorm.DB) *Deliverer {
+	var store *dbgorm.WebhookStore
+	if db != nil {
+		store = dbgorm.NewWebhookStore(db)
+	}
+	return &Deliverer{
+		store:  store,
+		client: &http.Client{Timeout: deliveryTimeout},
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+}
+
+// Start launches the delivery loop in a background goroutine. It respects ctx
+// for graceful shutdown and also responds to Stop(). Returns immediately.
+func (d *Deliverer) Start(ctx context.Context) {
+	interval := deliveryInterval()
+	log.Info().
+		Dur("interval", interval).
+		Msg("webhook deliverer started")
+
+	go func() {
+		defer close(d.done)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				log.Info().Msg("webhook deliverer stopped (context cancelled)")
+				return
+			case <-d.stop:
+				log.Info().Msg("webhook deliverer stopped")
+				return
+			case <-ticker.C:
+				if _, err := d.deliver(ctx); err != nil {
+					log.Error().Err(err).Msg("webhook deliverer: delivery run failed")
+				}
+			}
+		}
+	}()
+}
+
+// Stop signals the deliverer to cease and waits for the goroutine to exit.
+func (d *Deliverer) Stop() {
+	select {
+	case <-d.stop:
+		// Already closed — idempotent.
+	default:
+		close(d.stop)
+	}
+	<-d.done
+}
+
+// deliver posts every due delivery, in batches, and records each outcome.
+// It returns the number of deliveries attempted.
+func (d *Deliverer) deliver(ctx context.Context) (int, error) {
+	if d.store == nil {
+		return 0, nil
+	}
+
+	attempted := 0
+	for {
+		now := time.Now()
+		jobs, err := d.store.ClaimDueWebhookDeliveries(ctx, now, deliveryBatchSize, deliveryLease)
+		if err != nil {
+			return attempted, err
+		}
+		for _, job := range jobs {
+			attempt := d.attempt(ctx, job)
+			if err := d.store.RecordWebhookAttempt(ctx, job.ID, attempt); err != nil {
+				return attempted, err
+			}
+			attempted++
+			if !attempt.Delivered {
+				log.Warn().
+					Int64("delivery_id", job.ID).
+					Int64("webhook_id", job.WebhookID).
+					Str("event", job.EventType).
+					Int("attempt", job.Attempts+1).
+					Str("error", attempt.Error).
+					Bool("gave_up", attempt.NextAttemptAt == nil).
+					Msg("webhook deliverer: delivery failed")
+			}
+		}
+		if len(jobs) < deliveryBatchSize || ctx.Err() != nil {
+			return attempted, nil
+		}
+	}
+}
+
+// attempt posts one delivery and describes the outcome. Any 2xx response
+// counts as delivered; anything else is retried after Backoff until
+// MaxAttempts is reached.
+func (d *Deliverer) attempt(ctx context.Context, job dbgorm.WebhookDeliveryJob) dbgorm.WebhookAttempt {
+	now := time.Now()
+	result := dbgorm.WebhookAttempt{At: now}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, job.URL, bytes.NewReader(job.Payload))
+	if err == nil {
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("User-Agent", "engram-webhooks")
+		req.Header.Set(HeaderEvent, job.EventType)
+		req.Header.Set(HeaderDelivery, strconv.FormatInt(job.ID, 10))
+		req.Header.Set(HeaderTimestamp, strconv.FormatInt(now.Unix(), 10))
+		req.Header.Set(HeaderSignature, Sign(job.Secret, now.Unix(), job.Payload))
+
+		var resp *http.Response
+		if resp, err = d.client.Do(req); err == nil {
+			body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+			_ = resp.Body.Close()
+			result.ResponseStatus = resp.StatusCode
+			if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+				result.Delivered = true
+				return result
+			}
+			err = fmt.Errorf("HTTP %d: %s", resp.StatusCode, bytes.TrimSpace(body))
+		}
+	}
+
+	result.Error = err.Error()
+	if attempts := job.Attempts + 1; attempts < MaxAttempts {
+		next := now.Add(Backoff(attempts))
+		result.NextAttemptAt = &next
+	}
+	return result
+}
+
+// deliveryInterval returns the configured polling interval.
+// Reads ENGRAM_WEBHOOK_DELIVERY_INTERVAL (a Go duration such as "30s");
+// falls back to defaultDeliveryInterval.
+func deliveryInterval() time.Duration {
+	if v := os.Getenv("ENGRAM_WEBHOOK_DELIVERY_INTERVAL"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d >= time.Second {
+			return d
+		}
+	}
+	return defaultDeliveryInterval
+}
+
+// DeliverOnce runs a single delivery pass synchronously and returns the number
+// of deliveries attempted. Useful for integration testing where time-based
+// scheduling is not practical.
+func (d *Deliverer) DeliverOnce(ctx context.Context) (int, error) {
+	if d.store == nil {
+		return 0, fmt.Errorf("webhook deliverer: db is nil")
+	}
+	return d.deliver(ctx)
+}
@@ -0,0 +1,174 @@
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	dbgorm "github.com/thebtf/engram/internal/db/gorm"
+)
+
+// receiver is a local webhook endpoint that verifies signatures and answers
+// with status.
+type receiver struct {
+	mu       sync.Mutex
+	secret   string
+	status   int
+	received []Event
+	headers  []http.Header
+}
+
+func (rc *receiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	body, _ := io.ReadAll(r.Body)
+	if !Verify(rc.secret, r.Header.Get(HeaderTimestamp), r.Header.Get(HeaderSignature), body, time.Minute, time.Now()) {
+		http.Error(w, "bad signature", http.StatusUnauthorized)
+		return
+	}
+	var ev Event
+	_ = json.Unmarshal(body, &ev)
+	rc.received = append(rc.received, ev)
+	rc.headers = append(rc.headers, r.Header.Clone())
+	w.WriteHeader(rc.status)
+}
+
+func TestDeliverer_Attempt(t *testing.T) {
+	rc := &receiver{secret: "whsec_test", status: http.StatusNoContent}
+	srv := httptest.NewServer(rc)
+	defer srv.Close()
+
+	d := New(nil)
+	job := dbgorm.WebhookDeliveryJob{
+		WebhookDelivery: dbgorm.WebhookDelivery{
+			ID:        42,
+			EventType: EventIssueCreated,
+			Payload:   json.RawMessage(`{"id":"e1","type":"issue.created","data":{"id":7}}`),
+		},
+		URL:    srv.URL,
+		Secret: "whsec_test",
+	}
+
+	attempt := d.attempt(context.Background(), job)
+	if !attempt.Delivered || attempt.ResponseStatus != http.StatusNoContent || attempt.Error != "" {
+		t.Fatalf("attempt = %+v, want delivered", attempt)
+	}
+	if len(rc.received) != 1 || rc.received[0].ID != "e1" {
+		t.Fatalf("received = %+v", rc.received)
+	}
+	if got := rc.headers[0].Get(HeaderEvent); got != EventIssueCreated {
+		t.Errorf("%s = %q", HeaderEvent, got)
+	}
+	if got := rc.headers[0].Get(HeaderDelivery); got != "42" {
+		t.Errorf("%s = %q", HeaderDelivery, got)
+	}
+
+	rc.status = http.StatusInternalServerError
+	job.Attempts = 2
+	attempt = d.attempt(context.Background(), job)
+	if attempt.Delivered || attempt.ResponseStatus != http.StatusInternalServerError || attempt.NextAttemptAt == nil {
+		t.Fatalf("attempt = %+v, want a retry", attempt)
+	}
+	if wait := attempt.NextAttemptAt.Sub(attempt.At); wait != Backoff(3) {
+		t.Errorf("retry after %s, want %s", wait, Backoff(3))
+	}
+
+	job.Attempts = MaxAttempts - 1
+	if attempt = d.attempt(context.Background(), job); attempt.NextAttemptAt != nil {
+		t.Errorf("last attempt should give up, got retry at %s", attempt.NextAttemptAt)
+	}
+
+	job.Secret = "whsec_wrong"
+	job.Attempts = 0
+	if attempt = d.attempt(context.Background(), job); attempt.ResponseStatus != http.StatusUnauthorized {
+		t.Errorf("receiver accepted a bad signature: %+v", attempt)
+	}
+
+	srv.Close()
+	if attempt = d.attempt(context.Background(), job); attempt.Delivered || attempt.Error == "" || attempt.NextAttemptAt == nil {
+		t.Errorf("unreachable endpoint: %+v", attempt)
+	}
+}
+
+// testDelivererDB opens a migrated postgres test DB.
+// Tests skip when DATABASE_DSN is not set.
+func testDelivererDB(t *testing.T) (*gorm.DB, func()) {
+	t.Helper()
+	dsn := os.Getenv("DATABASE_DSN")
+	if dsn == "" {
+		t.Skip("DATABASE_DSN not set, skipping webhook deliverer integration test")
+	}
+
+	store, err := dbgorm.NewStore(dbgorm.Config{DSN: dsn, MaxConns: 2, LogLevel: logger.Silent})
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	return store.DB, func() { _ = store.Close() }
+}
+
+func TestDeliverer_DeliversQueuedEvents(t *testing.T) {
+	db, cleanup := testDelivererDB(t)
+	defer cleanup()
+
+	rc := &receiver{status: http.StatusOK}
+	srv := httptest.NewServer(rc)
+	defer srv.Close()
+
+	ctx := context.Background()
+	ws := dbgorm.NewWebhookStore(db)
+	name := fmt.Sprintf("webhook-deliverer-%d", time.Now().UnixNano())
+	hook, err := ws.CreateWebhook(ctx, &dbgorm.Webhook{Name: name, URL: srv.URL, Events: []string{EventIssueCreated}, Project: "engram"})
+	if err != nil {
+		t.Fatalf("CreateWebhook: %v", err)
+	}
+	defer func() { _ = ws.DeleteWebhook(ctx, hook.ID) }()
+	rc.secret = hook.Secret
+
+	p := NewPublisher(db)
+	p.Publish(ctx, EventIssueCreated, "engram", map[string]any{"id": 7})
+	p.Publish(ctx, EventIssueCreated, "other-project", map[string]any{"id": 8})
+	p.Publish(ctx, EventMemoryCreated, "engram", map[string]any{"id": 9})
+
+	if _, err := New(db).DeliverOnce(ctx); err != nil {
+		t.Fatalf("DeliverOnce: %v", err)
+	}
+	if len(rc.received) != 1 || rc.received[0].Type != EventIssueCreated || rc.received[0].Project != "engram" {
+		t.Fatalf("received = %+v, want the engram issue.created event only", rc.received)
+	}
+
+	deliveries, err := ws.ListWebhookDeliveries(ctx, dbgorm.WebhookDeliveryListParams{WebhookID: hook.ID})
+	if err != nil {
+		t.Fatalf("ListWebhookDeliveries: %v", err)
+	}
+	if len(deliveries) != 1 || deliveries[0].Status != dbgorm.WebhookDeliveryDelivered || deliveries[0].Attempts != 1 {
+		t.Fatalf("deliveries = %+v", deliveries)
+	}
+	if got := rc.headers[0].Get(HeaderDelivery); got != strconv.FormatInt(deliveries[0].ID, 10) {
+		t.Errorf("%s = %q, want %d", HeaderDelivery, got, deliveries[0].ID)
+	}
+
+	// A failing endpoint is retried later, not immediately.
+	rc.status = http.StatusBadGateway
+	p.Publish(ctx, EventIssueCreated, "engram", map[string]any{"id": 10})
+	if _, err := New(db).DeliverOnce(ctx); err != nil {
+		t.Fatalf("DeliverOnce: %v", err)
+	}
+	pending, err := ws.ListWebhookDeliveries(ctx, dbgorm.WebhookDeliveryListParams{WebhookID: hook.ID, Status: dbgorm.WebhookDeliveryPending})
+	if err != nil {
+		t.Fatalf("ListWebhookDeliveries: %v", err)
+	}
+	if len(pending) != 1 || pending[0].Attempts != 1 || pending[0].ResponseStatus != http.StatusBadGateway || !pending[0].NextAttemptAt.After(time.Now()) {
+		t.Fatalf("pending = %+v, want one delivery scheduled for retry", pending)
+	}
+}
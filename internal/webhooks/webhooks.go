@@ -0,0 +1,156 @@
+// Package webhooks delivers engram events to registered HTTP endpoints.
+//
+// Publishers queue an event for every matching webhook in the
+// webhook_deliveries table (migration 113); the Deliverer posts queued rows in
+// the background, signs each request with the webhook's secret, and retries
+// failures with exponential backoff until MaxAttempts. Because the queue is a
+// table, deliveries survive restarts and the rows double as the delivery log
+// shown in the dashboard.
+//
+// Each request carries:
+//
+//	X-Engram-Event:     the event type, e.g. issue.created
+//	X-Engram-Delivery:  the delivery ID (stable across retries)
+//	X-Engram-Timestamp: Unix seconds when the attempt was signed
+//	X-Engram-Signature: sha256=<hex HMAC-SHA256 of "<timestamp>.<body>">
+//
+// Receivers recompute the signature with the shared secret and compare it in
+// constant time; see Verify.
+package webhooks
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+
+	dbgorm "github.com/thebtf/engram/internal/db/gorm"
+	"github.com/thebtf/engram/pkg/models"
+)
+
+// Event types a webhook can subscribe to (see dbgorm.WebhookEventTypes).
+const (
+	EventIssueCreated   = "issue.created"
+	EventIssueResolved  = "issue.resolved"
+	EventIssueEscalated = "issue.escalated"
+	EventMemoryCreated  = "memory.created"
+	EventRuleUpdated    = "rule.updated"
+	EventProjectRemoved = "project.removed"
+)
+
+// Request headers set on every delivery.
+const (
+	HeaderEvent     = "X-Engram-Event"
+	HeaderDelivery  = "X-Engram-Delivery"
+	HeaderTimestamp = "X-Engram-Timestamp"
+	HeaderSignature = "X-Engram-Signature"
+)
+
+// Event is the JSON body of a delivery.
+type Event struct {
+	CreatedAt time.Time `json:"created_at"`
+	Data      any       `json:"data"`
+	ID        string    `json:"id"`
+	Type      string    `json:"type"`
+	Project   string    `json:"project,omitempty"`
+}
+
+// Publisher queues events for delivery. A nil Publisher, or one without a
+// database, drops events, so callers need not check whether webhooks are
+// configured.
+type Publisher struct {
+	store *dbgorm.WebhookStore
+}
+
+// NewPublisher creates a Publisher backed by the given database connection.
+func NewPublisher(db *gorm.DB) *Publisher {
+	var store *dbgorm.WebhookStore
+	if db != nil {
+		store = dbgorm.NewWebhookStore(db)
+	}
+	return &Publisher{store: store}
+}
+
+// Publish queues an event of eventType about project for every subscribed
+// webhook. Failures are logged, never returned: a webhook outage must not fail
+// the change that triggered it.
+func (p *Publisher) Publish(ctx context.Context, eventType, project string, data any) {
+	if p == nil || p.store == nil {
+		return
+	}
+	event := Event{
+		ID:        uuid.NewString(),
+		Type:      eventType,
+		Project:   project,
+		CreatedAt: time.Now().UTC(),
+		Data:      data,
+	}
+	payload, err := json.Marshal(event)
+	if err != nil {
+		log.Error().Err(err).Str("event", eventType).Msg("webhooks: marshal event failed")
+		return
+	}
+	queued, err := p.store.EnqueueWebhookEvent(ctx, eventType, project, event.ID, payload)
+	if err != nil {
+		log.Error().Err(err).Str("event", eventType).Str("project", project).Msg("webhooks: enqueue event failed")
+		return
+	}
+	if queued > 0 {
+		log.Debug().Str("event", eventType).Str("project", project).Int64("deliveries", queued).Msg("webhooks: event queued")
+	}
+}
+
+// PublishIssue queues an issue event addressed to the issue's target project.
+func (p *Publisher) PublishIssue(ctx context.Context, eventType string, issue *dbgorm.Issue) {
+	if issue == nil {
+		return
+	}
+	p.Publish(ctx, eventType, issue.TargetProject, issue)
+}
+
+// PublishRule queues a rule.updated event for a created or changed behavioral
+// rule. Global rules (no project) reach only webhooks without a project filter.
+func (p *Publisher) PublishRule(ctx context.Context, rule *models.BehavioralRule) {
+	if rule == nil {
+		return
+	}
+	project := ""
+	if rule.Project != nil {
+		project = *rule.Project
+	}
+	p.Publish(ctx, EventRuleUpdated, project, rule)
+}
+
+// Sign returns the X-Engram-Signature value for body signed at timestamp.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify reports whether signature is a valid X-Engram-Signature for body and
+// the X-Engram-Timestamp value timestamp, and the timestamp is within
+// tolerance of now. A zero tolerance skips the age check.
+func Verify(secret, timestamp, signature string, body []byte, tolerance time.Duration, now time.Time) bool {
+	ts, err := strconv.ParseInt(strings.TrimSpace(timestamp), 10, 64)
+	if err != nil {
+		return false
+	}
+	if tolerance > 0 {
+		age := now.Sub(time.Unix(ts, 0))
+		if age > tolerance || age < -tolerance {
+			return false
+		}
+	}
+	return hmac.Equal([]byte(Sign(secret, ts, body)), []byte(signature))
+}
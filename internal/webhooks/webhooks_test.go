@@ -0,0 +1,54 @@
+package webhooks
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestSignVerify(t *testing.T) {
+	body := []byte(`{"type":"issue.created"}`)
+	now := time.Unix(1_800_000_000, 0)
+	sig := Sign("whsec_test", now.Unix(), body)
+
+	if sig != Sign("whsec_test", now.Unix(), body) {
+		t.Fatal("Sign is not deterministic")
+	}
+	if !Verify("whsec_test", "1800000000", sig, body, 5*time.Minute, now) {
+		t.Fatal("valid signature rejected")
+	}
+	for name, ok := range map[string]bool{
+		"wrong secret":    Verify("whsec_other", "1800000000", sig, body, 5*time.Minute, now),
+		"tampered body":   Verify("whsec_test", "1800000000", sig, []byte(`{}`), 5*time.Minute, now),
+		"other timestamp": Verify("whsec_test", "1800000001", sig, body, 5*time.Minute, now),
+		"too old":         Verify("whsec_test", "1800000000", sig, body, 5*time.Minute, now.Add(time.Hour)),
+		"bad timestamp":   Verify("whsec_test", "soon", sig, body, 0, now),
+	} {
+		if ok {
+			t.Errorf("%s: signature accepted", name)
+		}
+	}
+	if !Verify("whsec_test", "1800000000", sig, body, 0, now.Add(time.Hour)) {
+		t.Error("zero tolerance should skip the age check")
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	for attempts, want := range map[int]time.Duration{
+		0:  30 * time.Second,
+		1:  30 * time.Second,
+		2:  time.Minute,
+		4:  4 * time.Minute,
+		20: 6 * time.Hour,
+	} {
+		if got := Backoff(attempts); got != want {
+			t.Errorf("Backoff(%d) = %s, want %s", attempts, got, want)
+		}
+	}
+}
+
+func TestPublisher_NilSafe(t *testing.T) {
+	var p *Publisher
+	p.Publish(context.Background(), EventIssueCreated, "engram", nil)
+	NewPublisher(nil).Publish(context.Background(), EventIssueCreated, "engram", nil)
+}
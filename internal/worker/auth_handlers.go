@@ -10,6 +10,7 @@ import (
 
 	"github.com/go-chi/chi/v5"
 	"github.com/rs/zerolog/log"
+	authpkg "github.com/thebtf/engram/internal/auth"
 	gormdb "github.com/thebtf/engram/internal/db/gorm"
 	"golang.org/x/crypto/bcrypt"
 )
@@ -27,6 +28,12 @@ type AuthHandlers struct {
 	invitations *gormdb.InvitationStore
 	sessions    *gormdb.AuthSessionStore
 
+	// roles is set by SetRoleStore; nil allows only the built-in user roles.
+	roles *gormdb.RoleStore
+
+	// oidc is set by EnableOIDC; nil when OIDC login is not configured.
+	oidc *oidcLogin
+
 	// Rate limiting: IP -> mutex + []time.Time (last N attempts)
 	loginAttempts sync.Map
 }
@@ -40,6 +47,38 @@ func NewAuthHandlers(users *gormdb.UserStore, invitations *gormdb.InvitationStor
 	}
 }
 
+// SetRoleStore lets users be assigned custom roles.
+func (h *AuthHandlers) SetRoleStore(roles *gormdb.RoleStore) {
+	h.roles = roles
+}
+
+// requireUserAdmin rejects callers that lack admin:users or do not act from
+// a browser session: like role management (requireRoleAdmin), user and
+// invitation management can hand out admin, so a keycard with a custom role
+// may not reach it. Returns true when the caller was rejected and the
+// response written.
+func requireUserAdmin(w http.ResponseWriter, r *http.Request) bool {
+	id, ok := authpkg.IdentityFrom(r.Context())
+	if !ok || !id.Can(authpkg.PermAdminUsers) || id.Source != authpkg.SourceSession {
+		http.Error(w, `{"error":"admin access required"}`, http.StatusForbidden)
+		return true
+	}
+	return false
+}
+
+// validUserRole reports whether users may be assigned role: admin, operator
+// or a custom role.
+func (h *AuthHandlers) validUserRole(r *http.Request, role string) (bool, error) {
+	if role == string(authpkg.RoleAdmin) || role == string(authpkg.RoleOperator) {
+		return true, nil
+	}
+	if h.roles == nil || authpkg.IsBuiltinRole(role) {
+		return false, nil
+	}
+	_, ok, err := h.roles.RolePermissions(r.Context(), role)
+	return ok, err
+}
+
 // handleSetupNeeded returns {"needed": true} when no users exist yet.
 func (h *AuthHandlers) handleSetupNeeded(w http.ResponseWriter, r *http.Request) {
 	count, err := h.users.CountUsers()
@@ -190,9 +229,7 @@ func (h *AuthHandlers) handleLogout(w http.ResponseWriter, r *http.Request) {
 
 // handleCreateInvitation generates a new invitation code (admin only).
 func (h *AuthHandlers) handleCreateInvitation(w http.ResponseWriter, r *http.Request) {
-	role, _ := r.Context().Value(authRoleKey{}).(string)
-	if role != "admin" {
-		http.Error(w, `{"error":"admin access required"}`, http.StatusForbidden)
+	if requireUserAdmin(w, r) {
 		return
 	}
 
@@ -231,9 +268,7 @@ func (h *AuthHandlers) handleCreateInvitation(w http.ResponseWriter, r *http.Req
 
 // handleListInvitations returns all invitation codes (admin only).
 func (h *AuthHandlers) handleListInvitations(w http.ResponseWriter, r *http.Request) {
-	role, _ := r.Context().Value(authRoleKey{}).(string)
-	if role != "admin" {
-		http.Error(w, `{"error":"admin access required"}`, http.StatusForbidden)
+	if requireUserAdmin(w, r) {
 		return
 	}
 
@@ -339,9 +374,7 @@ func (h *AuthHandlers) checkRateLimit(ip string) bool {
 
 // handleListUsers returns all users (admin only, no password hashes).
 func (h *AuthHandlers) handleListUsers(w http.ResponseWriter, r *http.Request) {
-	role, _ := r.Context().Value(authRoleKey{}).(string)
-	if role != "admin" {
-		http.Error(w, `{"error":"admin access required"}`, http.StatusForbidden)
+	if requireUserAdmin(w, r) {
 		return
 	}
 
@@ -374,9 +407,7 @@ func (h *AuthHandlers) handleListUsers(w http.ResponseWriter, r *http.Request) {
 
 // handleUpdateUser updates user disabled/role (admin only).
 func (h *AuthHandlers) handleUpdateUser(w http.ResponseWriter, r *http.Request) {
-	role, _ := r.Context().Value(authRoleKey{}).(string)
-	if role != "admin" {
-		http.Error(w, `{"error":"admin access required"}`, http.StatusForbidden)
+	if requireUserAdmin(w, r) {
 		return
 	}
 
@@ -425,8 +456,14 @@ func (h *AuthHandlers) handleUpdateUser(w http.ResponseWriter, r *http.Request)
 		}
 	}
 	if req.Role != nil {
-		if *req.Role != "admin" && *req.Role != "operator" {
-			http.Error(w, `{"error":"role must be admin or operator"}`, http.StatusBadRequest)
+		valid, err := h.validUserRole(r, *req.Role)
+		if err != nil {
+			log.Error().Err(err).Str("role", *req.Role).Msg("auth: failed to look up role")
+			http.Error(w, `{"error":"internal error"}`, http.StatusInternalServerError)
+			return
+		}
+		if !valid {
+			http.Error(w, `{"error":"role must be admin, operator or a custom role"}`, http.StatusBadRequest)
 			return
 		}
 		if *req.Role != "admin" {
@@ -569,3 +606,36 @@ func (s *Service) handleAdminUpdateUser(w http.ResponseWriter, r *http.Request)
 	}
 	h.handleUpdateUser(w, r)
 }
+
+func (s *Service) handleOIDCConfig(w http.ResponseWriter, r *http.Request) {
+	s.initMu.RLock()
+	h := s.authHandlers
+	s.initMu.RUnlock()
+	if h == nil {
+		http.Error(w, `{"error":"not ready"}`, http.StatusServiceUnavailable)
+		return
+	}
+	h.handleOIDCConfig(w, r)
+}
+
+func (s *Service) handleOIDCLogin(w http.ResponseWriter, r *http.Request) {
+	s.initMu.RLock()
+	h := s.authHandlers
+	s.initMu.RUnlock()
+	if h == nil {
+		http.Error(w, `{"error":"not ready"}`, http.StatusServiceUnavailable)
+		return
+	}
+	h.handleOIDCLogin(w, r)
+}
+
+func (s *Service) handleOIDCCallback(w http.ResponseWriter, r *http.Request) {
+	s.initMu.RLock()
+	h := s.authHandlers
+	s.initMu.RUnlock()
+	if h == nil {
+		http.Error(w, `{"error":"not ready"}`, http.StatusServiceUnavailable)
+		return
+	}
+	h.handleOIDCCallback(w, r)
+}
@@ -0,0 +1,33 @@
+package worker
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	authpkg "github.com/thebtf/engram/internal/auth"
+)
+
+func TestRequireUserAdmin(t *testing.T) {
+	userAdmin := authpkg.Client("user-admins", "uuid-1")
+	userAdmin.Permissions = []authpkg.Permission{authpkg.PermAdminUsers}
+
+	cases := map[string]struct {
+		id     authpkg.Identity
+		reject bool
+	}{
+		"session admin":            {id: authpkg.Session("admin"), reject: false},
+		"session operator":         {id: authpkg.Session("operator"), reject: true},
+		"master token":             {id: authpkg.Admin(), reject: true},
+		"keycard with admin:users": {id: userAdmin, reject: true},
+		"read-write keycard":       {id: authpkg.Client("read-write", "uuid-2"), reject: true},
+	}
+	for name, c := range cases {
+		req := httptest.NewRequest(http.MethodGet, "/api/auth/users", nil)
+		req = req.WithContext(authpkg.WithIdentity(req.Context(), c.id))
+		rr := httptest.NewRecorder()
+		assert.Equal(t, c.reject, requireUserAdmin(rr, req), name)
+	}
+}
@@ -0,0 +1,293 @@
+package worker
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	gormlib "gorm.io/gorm"
+
+	authpkg "github.com/thebtf/engram/internal/auth"
+	"github.com/thebtf/engram/internal/auth/oidc"
+)
+
+const (
+	// oidcLoginTTL bounds how long a user may spend at the provider between
+	// /api/auth/oidc/login and the callback.
+	oidcLoginTTL        = 10 * time.Minute
+	oidcStateCookieName = "engram_oidc_state"
+	oidcCookiePath      = "/api/auth/oidc"
+
+	// maxOIDCPending caps the logins in flight. /api/auth/oidc/login is
+	// unauthenticated, so without a cap a flood of login starts would grow
+	// the map for oidcLoginTTL at a time.
+	maxOIDCPending = 1000
+)
+
+// oidcLogin is the OIDC configuration of AuthHandlers plus the logins in
+// flight, keyed by their state parameter.
+type oidcLogin struct {
+	provider      *oidc.Provider
+	roles         oidc.RoleMapping
+	name          string
+	autoProvision bool
+
+	mu      sync.Mutex
+	pending map[string]oidcPending
+}
+
+// oidcPending is what the callback needs to finish a login started by
+// handleOIDCLogin.
+type oidcPending struct {
+	verifier string
+	nonce    string
+	returnTo string
+	expires  time.Time
+}
+
+// EnableOIDC turns on OIDC login against provider. name labels the login
+// button; autoProvision creates users on their first login with the role
+// roles maps them to.
+func (h *AuthHandlers) EnableOIDC(provider *oidc.Provider, roles oidc.RoleMapping, name string, autoProvision bool) {
+	h.oidc = &oidcLogin{
+		provider:      provider,
+		roles:         roles,
+		name:          name,
+		autoProvision: autoProvision,
+		pending:       make(map[string]oidcPending),
+	}
+}
+
+// start records a login in flight, dropping expired ones. It returns false,
+// recording nothing, when maxOIDCPending logins are already in flight.
+func (o *oidcLogin) start(state string, p oidcPending) bool {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	now := time.Now()
+	for k, v := range o.pending {
+		if now.After(v.expires) {
+			delete(o.pending, k)
+		}
+	}
+	if len(o.pending) >= maxOIDCPending {
+		return false
+	}
+	o.pending[state] = p
+	return true
+}
+
+// finish removes and returns the login in flight for state. Each state can
+// be redeemed once.
+func (o *oidcLogin) finish(state string) (oidcPending, bool) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	p, ok := o.pending[state]
+	delete(o.pending, state)
+	if !ok || time.Now().After(p.expires) {
+		return oidcPending{}, false
+	}
+	return p, true
+}
+
+// reapplyRole reports whether a login mapped to role should replace the
+// user's current role: only when the mapping is authoritative, and never
+// over a custom role, which an admin assigned by hand and the mapping cannot
+// produce.
+func (o *oidcLogin) reapplyRole(current, role string) bool {
+	return o.roles.Authoritative() && current != role && authpkg.IsBuiltinRole(current)
+}
+
+// handleOIDCConfig tells the login page whether to offer OIDC login.
+func (h *AuthHandlers) handleOIDCConfig(w http.ResponseWriter, r *http.Request) {
+	resp := map[string]any{"enabled": false}
+	if h.oidc != nil {
+		resp = map[string]any{"enabled": true, "name": h.oidc.name}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		log.Error().Err(err).Msg("auth: failed to encode oidc config response")
+	}
+}
+
+// handleOIDCLogin starts an authorization-code login: it redirects the
+// browser to the provider with a fresh state, nonce and PKCE challenge, and
+// binds the state to the browser with a short-lived cookie.
+// The optional return_to query parameter is the dashboard path to land on.
+func (h *AuthHandlers) handleOIDCLogin(w http.ResponseWriter, r *http.Request) {
+	if h.oidc == nil {
+		http.Error(w, `{"error":"oidc login not configured"}`, http.StatusNotFound)
+		return
+	}
+	var state, nonce, verifier string
+	var err error
+	for _, v := range []*string{&state, &nonce, &verifier} {
+		if *v, err = oidc.NewVerifier(); err != nil {
+			log.Error().Err(err).Msg("auth: oidc login")
+			http.Error(w, `{"error":"internal error"}`, http.StatusInternalServerError)
+			return
+		}
+	}
+	target, err := h.oidc.provider.AuthCodeURL(r.Context(), state, nonce, verifier)
+	if err != nil {
+		log.Error().Err(err).Str("issuer", h.oidc.provider.Issuer()).Msg("auth: oidc provider unavailable")
+		redirectOIDCError(w, r, "identity provider unavailable")
+		return
+	}
+
+	if !h.oidc.start(state, oidcPending{
+		verifier: verifier,
+		nonce:    nonce,
+		returnTo: safeReturnTo(r.URL.Query().Get("return_to")),
+		expires:  time.Now().Add(oidcLoginTTL),
+	}) {
+		log.Warn().Int("pending", maxOIDCPending).Msg("auth: too many oidc logins in flight")
+		redirectOIDCError(w, r, "too many logins in progress, please try again later")
+		return
+	}
+	// Lax, not Strict: the callback is a top-level navigation from the
+	// provider's site.
+	http.SetCookie(w, &http.Cookie{
+		Name:     oidcStateCookieName,
+		Value:    state,
+		Path:     oidcCookiePath,
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteLaxMode,
+		MaxAge:   int(oidcLoginTTL.Seconds()),
+	})
+	http.Redirect(w, r, target, http.StatusFound)
+}
+
+// handleOIDCCallback finishes a login: it checks the state against the
+// browser's cookie, redeems the code, maps the ID token's claims to a user
+// (provisioning one if enabled) and opens a dashboard session, stored in
+// AuthSession like an email/password login. Failures land on the login page
+// with an oidc_error message.
+func (h *AuthHandlers) handleOIDCCallback(w http.ResponseWriter, r *http.Request) {
+	if h.oidc == nil {
+		http.Error(w, `{"error":"oidc login not configured"}`, http.StatusNotFound)
+		return
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     oidcStateCookieName,
+		Value:    "",
+		Path:     oidcCookiePath,
+		HttpOnly: true,
+		MaxAge:   -1,
+	})
+
+	q := r.URL.Query()
+	state := q.Get("state")
+	cookie, err := r.Cookie(oidcStateCookieName)
+	if state == "" || err != nil || subtle.ConstantTimeCompare([]byte(cookie.Value), []byte(state)) != 1 {
+		redirectOIDCError(w, r, "login expired or was started in another browser, please try again")
+		return
+	}
+	pending, ok := h.oidc.finish(state)
+	if !ok {
+		redirectOIDCError(w, r, "login expired, please try again")
+		return
+	}
+	if e := q.Get("error"); e != "" {
+		log.Warn().Str("error", e).Str("description", q.Get("error_description")).Msg("auth: oidc provider refused login")
+		redirectOIDCError(w, r, "identity provider refused the login")
+		return
+	}
+
+	claims, err := h.oidc.provider.Exchange(r.Context(), q.Get("code"), pending.verifier, pending.nonce)
+	if err != nil {
+		log.Warn().Err(err).Msg("auth: oidc code exchange failed")
+		redirectOIDCError(w, r, "could not verify the login with the identity provider")
+		return
+	}
+	email := strings.ToLower(strings.TrimSpace(claims.Email))
+	if email == "" {
+		redirectOIDCError(w, r, "identity provider did not share an email address")
+		return
+	}
+	// Accounts are matched by email, admins included, so an address the
+	// provider has not vouched for must not sign anyone in. A missing
+	// email_verified claim counts as unverified.
+	if claims.EmailVerified == nil || !*claims.EmailVerified {
+		redirectOIDCError(w, r, "email address is not verified")
+		return
+	}
+	role := h.oidc.roles.Role(claims)
+	if role == "" {
+		log.Warn().Str("email", email).Str("subject", claims.Subject).Msg("auth: oidc login refused by role mapping")
+		redirectOIDCError(w, r, "your account is not allowed to use engram")
+		return
+	}
+
+	user, err := h.users.GetUserByEmail(email)
+	switch {
+	case errors.Is(err, gormlib.ErrRecordNotFound) && h.oidc.autoProvision:
+		// No password: the user signs in through the provider only.
+		user, err = h.users.CreateUser(email, "", role)
+		if err != nil {
+			log.Error().Err(err).Str("email", email).Msg("auth: oidc auto-provision failed")
+			redirectOIDCError(w, r, "internal error")
+			return
+		}
+		log.Info().Str("email", email).Str("role", role).Msg("auth: oidc user provisioned")
+	case errors.Is(err, gormlib.ErrRecordNotFound):
+		redirectOIDCError(w, r, "no engram account for "+email)
+		return
+	case err != nil:
+		log.Error().Err(err).Str("email", email).Msg("auth: oidc user lookup failed")
+		redirectOIDCError(w, r, "internal error")
+		return
+	}
+	if user.Disabled {
+		redirectOIDCError(w, r, "account disabled")
+		return
+	}
+
+	now := time.Now()
+	updates := map[string]any{"last_login_at": now}
+	if h.oidc.reapplyRole(user.Role, role) {
+		log.Info().Str("email", email).Str("from", user.Role).Str("to", role).Msg("auth: oidc role changed")
+		updates["role"] = role
+	}
+
+	sess, err := h.sessions.CreateSession(user.ID, sessionDuration)
+	if err != nil {
+		log.Error().Err(err).Int64("user_id", user.ID).Msg("auth: failed to create session")
+		redirectOIDCError(w, r, "internal error")
+		return
+	}
+	if err := h.users.UpdateUser(user.ID, updates); err != nil {
+		log.Warn().Err(err).Int64("user_id", user.ID).Msg("auth: failed to update user after oidc login")
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     authSessionCookieName,
+		Value:    sess.ID,
+		Path:     "/",
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+		MaxAge:   int(sessionDuration.Seconds()),
+	})
+	http.Redirect(w, r, pending.returnTo, http.StatusFound)
+}
+
+// redirectOIDCError sends the browser back to the login page with msg.
+func redirectOIDCError(w http.ResponseWriter, r *http.Request, msg string) {
+	http.Redirect(w, r, "/login?"+url.Values{"oidc_error": {msg}}.Encode(), http.StatusFound)
+}
+
+// safeReturnTo returns p if it is a path on this origin, else "/". Anything
+// that a browser could resolve to another host ("//evil", "/\evil",
+// "https://...") is rejected.
+func safeReturnTo(p string) string {
+	if !strings.HasPrefix(p, "/") || strings.HasPrefix(p, "//") || strings.ContainsAny(p, "\\\r\n") {
+		return "/"
+	}
+	return p
+}
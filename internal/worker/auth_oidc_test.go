@@ -0,0 +1,210 @@
+package worker
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/thebtf/engram/internal/auth/oidc"
+	"github.com/thebtf/engram/internal/auth/oidc/oidctest"
+)
+
+// oidcTestHandlers returns AuthHandlers logging in against iss. The user and
+// session stores are nil: these tests stop before a user is looked up.
+func oidcTestHandlers(t *testing.T, iss *oidctest.Issuer, roles oidc.RoleMapping) *AuthHandlers {
+	t.Helper()
+	p, err := oidc.New(oidc.Config{
+		IssuerURL:    iss.URL,
+		ClientID:     iss.ClientID,
+		ClientSecret: iss.ClientSecret,
+		RedirectURL:  "http://engram.test/api/auth/oidc/callback",
+	})
+	require.NoError(t, err)
+	h := NewAuthHandlers(nil, nil, nil)
+	h.EnableOIDC(p, roles, "Example SSO", false)
+	return h
+}
+
+// startOIDCLogin runs /api/auth/oidc/login and the stub's authorization
+// step, and returns the callback request the provider sends the browser to.
+func startOIDCLogin(t *testing.T, h *AuthHandlers) *http.Request {
+	t.Helper()
+	rr := httptest.NewRecorder()
+	h.handleOIDCLogin(rr, httptest.NewRequest(http.MethodGet, "/api/auth/oidc/login?return_to=/memories", nil))
+	require.Equal(t, http.StatusFound, rr.Code)
+	authURL, err := url.Parse(rr.Header().Get("Location"))
+	require.NoError(t, err)
+	assert.Equal(t, "S256", authURL.Query().Get("code_challenge_method"))
+	assert.NotEmpty(t, authURL.Query().Get("code_challenge"))
+
+	cookies := rr.Result().Cookies()
+	require.Len(t, cookies, 1)
+	assert.Equal(t, oidcStateCookieName, cookies[0].Name)
+	assert.Equal(t, authURL.Query().Get("state"), cookies[0].Value)
+	assert.True(t, cookies[0].HttpOnly)
+
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+	resp, err := client.Get(authURL.String())
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusFound, resp.StatusCode)
+
+	callback := httptest.NewRequest(http.MethodGet, resp.Header.Get("Location"), nil)
+	callback.AddCookie(cookies[0])
+	return callback
+}
+
+// oidcError returns the oidc_error a callback redirected to the login page
+// with, failing if it redirected anywhere else.
+func oidcError(t *testing.T, rr *httptest.ResponseRecorder) string {
+	t.Helper()
+	require.Equal(t, http.StatusFound, rr.Code)
+	loc, err := url.Parse(rr.Header().Get("Location"))
+	require.NoError(t, err)
+	require.Equal(t, "/login", loc.Path)
+	return loc.Query().Get("oidc_error")
+}
+
+func TestOIDCConfig(t *testing.T) {
+	rr := httptest.NewRecorder()
+	NewAuthHandlers(nil, nil, nil).handleOIDCConfig(rr, httptest.NewRequest(http.MethodGet, "/api/auth/oidc/config", nil))
+	assert.JSONEq(t, `{"enabled":false}`, rr.Body.String())
+
+	iss := oidctest.New("engram", "s3cret")
+	defer iss.Close()
+	rr = httptest.NewRecorder()
+	oidcTestHandlers(t, iss, oidc.RoleMapping{}).handleOIDCConfig(rr, httptest.NewRequest(http.MethodGet, "/api/auth/oidc/config", nil))
+	assert.JSONEq(t, `{"enabled":true,"name":"Example SSO"}`, rr.Body.String())
+}
+
+func TestOIDCLogin_NotConfigured(t *testing.T) {
+	h := NewAuthHandlers(nil, nil, nil)
+	for _, handle := range []http.HandlerFunc{h.handleOIDCLogin, h.handleOIDCCallback} {
+		rr := httptest.NewRecorder()
+		handle(rr, httptest.NewRequest(http.MethodGet, "/api/auth/oidc/login", nil))
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	}
+}
+
+func TestOIDCCallback_StateMustMatchCookie(t *testing.T) {
+	iss := oidctest.New("engram", "s3cret")
+	defer iss.Close()
+	h := oidcTestHandlers(t, iss, oidc.RoleMapping{})
+
+	callback := startOIDCLogin(t, h)
+	forged := httptest.NewRequest(http.MethodGet, callback.URL.String(), nil)
+	forged.AddCookie(&http.Cookie{Name: oidcStateCookieName, Value: "someone-elses-state"})
+	rr := httptest.NewRecorder()
+	h.handleOIDCCallback(rr, forged)
+	assert.Contains(t, oidcError(t, rr), "another browser")
+
+	noCookie := httptest.NewRequest(http.MethodGet, callback.URL.String(), nil)
+	rr = httptest.NewRecorder()
+	h.handleOIDCCallback(rr, noCookie)
+	assert.Contains(t, oidcError(t, rr), "another browser")
+}
+
+func TestOIDCCallback_StateIsSingleUse(t *testing.T) {
+	iss := oidctest.New("engram", "s3cret")
+	defer iss.Close()
+	iss.Claims(map[string]any{"sub": "u-1", "email": "ada@example.com", "email_verified": false})
+	h := oidcTestHandlers(t, iss, oidc.RoleMapping{})
+
+	callback := startOIDCLogin(t, h)
+	rr := httptest.NewRecorder()
+	h.handleOIDCCallback(rr, callback)
+	assert.Equal(t, "email address is not verified", oidcError(t, rr))
+
+	rr = httptest.NewRecorder()
+	h.handleOIDCCallback(rr, callback)
+	assert.Equal(t, "login expired, please try again", oidcError(t, rr))
+}
+
+// TestOIDCCallback_EmailVerifiedMissing verifies that an ID token without an
+// email_verified claim is refused before its email is matched to an account.
+func TestOIDCCallback_EmailVerifiedMissing(t *testing.T) {
+	iss := oidctest.New("engram", "s3cret")
+	defer iss.Close()
+	iss.Claims(map[string]any{"sub": "u-3", "email": "admin@example.com"})
+	h := oidcTestHandlers(t, iss, oidc.RoleMapping{})
+
+	rr := httptest.NewRecorder()
+	h.handleOIDCCallback(rr, startOIDCLogin(t, h))
+	assert.Equal(t, "email address is not verified", oidcError(t, rr))
+}
+
+func TestOIDCCallback_RefusedByRoleMapping(t *testing.T) {
+	iss := oidctest.New("engram", "")
+	defer iss.Close()
+	iss.Claims(map[string]any{"sub": "u-2", "email": "bob@example.com", "email_verified": true, "groups": []string{"guests"}})
+	h := oidcTestHandlers(t, iss, oidc.RoleMapping{Claim: "groups", AdminValues: []string{"admins"}, OperatorValues: []string{"staff"}})
+
+	rr := httptest.NewRecorder()
+	h.handleOIDCCallback(rr, startOIDCLogin(t, h))
+	assert.Equal(t, "your account is not allowed to use engram", oidcError(t, rr))
+}
+
+func TestOIDCCallback_ProviderError(t *testing.T) {
+	iss := oidctest.New("engram", "s3cret")
+	defer iss.Close()
+	h := oidcTestHandlers(t, iss, oidc.RoleMapping{})
+
+	callback := startOIDCLogin(t, h)
+	q := callback.URL.Query()
+	q.Del("code")
+	q.Set("error", "access_denied")
+	denied := httptest.NewRequest(http.MethodGet, "/api/auth/oidc/callback?"+q.Encode(), nil)
+	denied.AddCookie(&http.Cookie{Name: oidcStateCookieName, Value: q.Get("state")})
+	rr := httptest.NewRecorder()
+	h.handleOIDCCallback(rr, denied)
+	assert.Equal(t, "identity provider refused the login", oidcError(t, rr))
+}
+
+func TestOIDCLogin_PendingIsCapped(t *testing.T) {
+	o := &oidcLogin{pending: make(map[string]oidcPending)}
+	live := oidcPending{expires: time.Now().Add(oidcLoginTTL)}
+	for i := 0; i < maxOIDCPending; i++ {
+		require.True(t, o.start(fmt.Sprintf("state-%d", i), live))
+	}
+	assert.False(t, o.start("one-too-many", live), "a full map refuses new logins")
+	assert.Len(t, o.pending, maxOIDCPending)
+
+	_, ok := o.finish("state-0")
+	require.True(t, ok)
+	assert.True(t, o.start("after-finish", live), "a redeemed login frees its slot")
+
+	o.pending["state-1"] = oidcPending{expires: time.Now().Add(-time.Second)}
+	assert.True(t, o.start("after-expiry", live), "expired logins free their slots")
+}
+
+func TestOIDCLogin_ReapplyRole(t *testing.T) {
+	authoritative := &oidcLogin{roles: oidc.RoleMapping{Claim: "groups", AdminValues: []string{"admins"}}}
+	assert.True(t, authoritative.reapplyRole("operator", "admin"))
+	assert.True(t, authoritative.reapplyRole("admin", "operator"))
+	assert.False(t, authoritative.reapplyRole("admin", "admin"))
+	assert.False(t, authoritative.reapplyRole("auditors", "operator"), "a custom role is kept")
+	assert.False(t, authoritative.reapplyRole("auditors", "admin"), "a custom role is kept")
+
+	advisory := &oidcLogin{roles: oidc.RoleMapping{}}
+	assert.False(t, advisory.reapplyRole("operator", "admin"))
+}
+
+func TestSafeReturnTo(t *testing.T) {
+	cases := map[string]string{
+		"":                     "/",
+		"/memories?tab=all":    "/memories?tab=all",
+		"//evil.example":       "/",
+		"/\\evil.example":      "/",
+		"https://evil.example": "/",
+		"memories":             "/",
+	}
+	for in, want := range cases {
+		assert.Equal(t, want, safeReturnTo(in), in)
+	}
+}
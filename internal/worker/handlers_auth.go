@@ -10,8 +10,10 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"os"
+	"strconv"
 	"strings"
 	"time"
 
@@ -21,6 +23,7 @@ import (
 	"golang.org/x/crypto/bcrypt"
 
 	authpkg "github.com/thebtf/engram/internal/auth"
+	gormdb "github.com/thebtf/engram/internal/db/gorm"
 )
 
 // isAuthDisabled returns true when ENGRAM_AUTH_DISABLED env var is "true" or "1".
@@ -57,10 +60,97 @@ type loginRequest struct {
 
 // tokenCreateRequest is the JSON body for POST /api/auth/tokens.
 type tokenCreateRequest struct {
-	Name  string `json:"name"`
+	Name            string   `json:"name"`
+	Scope           string   `json:"scope"`
+	AllowedProjects []string `json:"allowed_projects,omitempty"`
+	// ExpiresIn ("720h", "90d") or ExpiresAt sets when the token expires.
+	// Neither means it never does.
+	ExpiresIn string     `json:"expires_in,omitempty"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// tokenRotateRequest is the JSON body for POST /api/auth/tokens/{id}/rotate.
+type tokenRotateRequest struct {
+	// Grace is how long the old token keeps working ("24h", "7d"). Defaults
+	// to defaultTokenRotationGrace; "0" ends it immediately.
+	Grace string `json:"grace,omitempty"`
+	// ExpiresIn or ExpiresAt sets the successor's expiry. Neither gives it
+	// the old token's lifetime, counted from now.
+	ExpiresIn string     `json:"expires_in,omitempty"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// defaultTokenRotationGrace and maxTokenRotationGrace bound how long a
+// rotated token keeps working alongside its successor.
+const (
+	defaultTokenRotationGrace = 24 * time.Hour
+	maxTokenRotationGrace     = 30 * 24 * time.Hour
+)
+
+// tokenProjectsRequest is the JSON body for PATCH /api/auth/tokens/{id}/projects.
+type tokenProjectsRequest struct {
+	AllowedProjects []string `json:"allowed_projects"`
+}
+
+// tokenScopeRequest is the JSON body for PATCH /api/auth/tokens/{id}/scope.
+type tokenScopeRequest struct {
 	Scope string `json:"scope"`
 }
 
+// keycardScopePermissions returns the permissions of scope and whether
+// keycards may be issued with it: read-write, read-only or a custom role.
+// admin and operator are not keycard roles.
+func (s *Service) keycardScopePermissions(r *http.Request, scope string) ([]authpkg.Permission, bool, error) {
+	if scope == string(authpkg.RoleReadWrite) || scope == string(authpkg.RoleReadOnly) {
+		return authpkg.BuiltinRoles[authpkg.Role(scope)], true, nil
+	}
+	s.initMu.RLock()
+	roleStore := s.roleStore
+	s.initMu.RUnlock()
+
+	if roleStore == nil || authpkg.IsBuiltinRole(scope) {
+		return nil, false, nil
+	}
+	names, ok, err := roleStore.RolePermissions(r.Context(), scope)
+	if !ok || err != nil {
+		return nil, ok, err
+	}
+	perms := make([]authpkg.Permission, len(names))
+	for i, name := range names {
+		perms[i] = authpkg.Permission(name)
+	}
+	return perms, true, nil
+}
+
+// checkKeycardScope writes the response and returns true when keycards may
+// not be given scope by the caller: the scope must be a keycard role, and
+// its permissions a subset of the caller's, so admin:tokens alone cannot
+// mint a keycard that reaches the vault or manages users.
+func (s *Service) checkKeycardScope(w http.ResponseWriter, r *http.Request, scope string) bool {
+	perms, valid, err := s.keycardScopePermissions(r, scope)
+	if err != nil {
+		log.Error().Err(err).Str("scope", scope).Msg("auth: failed to look up role")
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return true
+	}
+	if !valid {
+		http.Error(w, "scope must be 'read-write', 'read-only' or a custom role", http.StatusBadRequest)
+		return true
+	}
+	caller, _ := authpkg.IdentityFrom(r.Context())
+	var missing []string
+	for _, p := range perms {
+		if !caller.Can(p) {
+			missing = append(missing, string(p))
+		}
+	}
+	if len(missing) > 0 {
+		http.Error(w, fmt.Sprintf("forbidden: scope %q grants permissions you do not hold: %s", scope, strings.Join(missing, ", ")), http.StatusForbidden)
+		return true
+	}
+	return false
+}
+
 // handleAuthLogin godoc
 // @Summary Login with master token
 // @Description Validates the master admin token and returns an HMAC-signed session cookie.
@@ -164,6 +254,7 @@ func (s *Service) handleAuthMe(w http.ResponseWriter, r *http.Request) {
 		writeJSON(w, map[string]any{
 			"authenticated": true,
 			"role":          role,
+			"permissions":   s.rolePermissions(r, role),
 			"auth_disabled": authDisabled,
 		})
 		return
@@ -185,6 +276,7 @@ func (s *Service) handleAuthMe(w http.ResponseWriter, r *http.Request) {
 					writeJSON(w, map[string]any{
 						"authenticated": true,
 						"role":          "admin",
+						"permissions":   authpkg.AllPermissions,
 						"auth_disabled": authDisabled,
 					})
 					return
@@ -201,6 +293,7 @@ func (s *Service) handleAuthMe(w http.ResponseWriter, r *http.Request) {
 					writeJSON(w, map[string]any{
 						"authenticated": true,
 						"role":          user.Role,
+						"permissions":   s.rolePermissions(r, user.Role),
 						"auth_disabled": authDisabled,
 						"user":          map[string]any{"id": user.ID, "email": user.Email, "role": user.Role},
 					})
@@ -260,21 +353,31 @@ func (s *Service) handleListTokens(w http.ResponseWriter, r *http.Request) {
 		ErrorCount   int64      `json:"error_count"`
 		Revoked      bool       `json:"revoked"`
 		RevokedAt    *time.Time `json:"revoked_at,omitempty"`
+		// AllowedProjects is never null so the dashboard can treat [] as
+		// "all projects" without a nil check.
+		AllowedProjects []string   `json:"allowed_projects"`
+		ExpiresAt       *time.Time `json:"expires_at,omitempty"`
+		SuccessorID     *string    `json:"successor_id,omitempty"`
+		UsageBaseline   float64    `json:"usage_baseline"`
 	}
 
 	resp := make([]tokenResponse, len(tokens))
 	for i, t := range tokens {
 		resp[i] = tokenResponse{
-			ID:           t.ID,
-			Name:         t.Name,
-			TokenPrefix:  t.TokenPrefix,
-			Scope:        t.Scope,
-			CreatedAt:    t.CreatedAt,
-			LastUsedAt:   t.LastUsedAt,
-			RequestCount: t.RequestCount,
-			ErrorCount:   t.ErrorCount,
-			Revoked:      t.Revoked,
-			RevokedAt:    t.RevokedAt,
+			ID:              t.ID,
+			Name:            t.Name,
+			TokenPrefix:     t.TokenPrefix,
+			Scope:           t.Scope,
+			CreatedAt:       t.CreatedAt,
+			LastUsedAt:      t.LastUsedAt,
+			RequestCount:    t.RequestCount,
+			ErrorCount:      t.ErrorCount,
+			Revoked:         t.Revoked,
+			RevokedAt:       t.RevokedAt,
+			AllowedProjects: append([]string{}, t.AllowedProjects...),
+			ExpiresAt:       t.ExpiresAt,
+			SuccessorID:     t.SuccessorID,
+			UsageBaseline:   t.UsageBaseline,
 		}
 	}
 
@@ -353,8 +456,18 @@ func (s *Service) handleCreateToken(w http.ResponseWriter, r *http.Request) {
 	if scope == "" {
 		scope = "read-write"
 	}
-	if scope != "read-write" && scope != "read-only" {
-		http.Error(w, "scope must be 'read-write' or 'read-only'", http.StatusBadRequest)
+	if s.checkKeycardScope(w, r, scope) {
+		return
+	}
+
+	allowedProjects, err := authpkg.NormalizeProjectPatterns(req.AllowedProjects)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	expiresAt, err := tokenExpiry(req.ExpiresIn, req.ExpiresAt, time.Now())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
@@ -374,7 +487,7 @@ func (s *Service) handleCreateToken(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	token, err := tokenStore.Create(r.Context(), req.Name, string(hash), prefix, scope)
+	token, err := tokenStore.Create(r.Context(), req.Name, string(hash), prefix, scope, allowedProjects, expiresAt)
 	if err != nil {
 		// Check for unique constraint violation (duplicate name)
 		if isDuplicateKeyError(err) {
@@ -387,10 +500,389 @@ func (s *Service) handleCreateToken(w http.ResponseWriter, r *http.Request) {
 	}
 
 	writeJSON(w, map[string]any{
-		"id":    token.ID,
-		"name":  token.Name,
-		"token": rawToken,
-		"scope": token.Scope,
+		"id":               token.ID,
+		"name":             token.Name,
+		"token":            rawToken,
+		"scope":            token.Scope,
+		"allowed_projects": allowedProjects,
+		"expires_at":       token.ExpiresAt,
+	})
+}
+
+// handleRotateToken godoc
+// @Summary Rotate an API token
+// @Description Issues a successor with the same name, scope and project allowlist. The old token keeps working for the grace window, then expires. The successor's raw token is returned only once.
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Token ID (UUID)"
+// @Param body body tokenRotateRequest false "Grace window and successor expiry"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {string} string "bad request"
+// @Failure 403 {string} string "forbidden — requires browser session"
+// @Failure 404 {string} string "not found"
+// @Failure 409 {string} string "token is expired or already rotated"
+// @Failure 500 {string} string "internal error"
+// @Router /api/auth/tokens/{id}/rotate [post]
+func (s *Service) handleRotateToken(w http.ResponseWriter, r *http.Request) {
+	if s.requireSessionAdmin(w, r) {
+		return
+	}
+
+	s.initMu.RLock()
+	tokenStore := s.tokenStore
+	s.initMu.RUnlock()
+
+	if tokenStore == nil {
+		http.Error(w, "not ready", http.StatusServiceUnavailable)
+		return
+	}
+
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		http.Error(w, "token id required", http.StatusBadRequest)
+		return
+	}
+
+	var req tokenRotateRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "invalid request body", http.StatusBadRequest)
+			return
+		}
+	}
+	grace := defaultTokenRotationGrace
+	if req.Grace != "" {
+		d, err := parseTokenDuration(req.Grace)
+		if err != nil || d > maxTokenRotationGrace {
+			http.Error(w, "invalid grace: want a duration such as 24h or 7d, at most 30d", http.StatusBadRequest)
+			return
+		}
+		grace = d
+	}
+
+	now := time.Now()
+	expiresAt, err := tokenExpiry(req.ExpiresIn, req.ExpiresAt, now)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if expiresAt == nil {
+		old, err := tokenStore.GetByID(r.Context(), id)
+		if err != nil {
+			log.Error().Err(err).Str("token_id", id).Msg("auth: failed to load token for rotation")
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+		if old == nil {
+			http.Error(w, "not found", http.StatusNotFound)
+			return
+		}
+		if old.ExpiresAt != nil {
+			inherited := now.Add(old.ExpiresAt.Sub(old.CreatedAt))
+			expiresAt = &inherited
+		}
+	}
+
+	randomBytes := make([]byte, 16)
+	if _, err := rand.Read(randomBytes); err != nil {
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	rawToken := tokenRawPrefix + hex.EncodeToString(randomBytes)
+	prefix := rawToken[len(tokenRawPrefix) : len(tokenRawPrefix)+tokenPrefixLen]
+	hash, err := bcrypt.GenerateFromPassword([]byte(rawToken), bcrypt.DefaultCost)
+	if err != nil {
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+
+	successor, old, err := tokenStore.Rotate(r.Context(), id, string(hash), prefix, grace, expiresAt)
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			http.Error(w, "not found", http.StatusNotFound)
+		case errors.Is(err, gormdb.ErrTokenNotRotatable):
+			http.Error(w, err.Error(), http.StatusConflict)
+		default:
+			log.Error().Err(err).Str("token_id", id).Msg("auth: failed to rotate token")
+			http.Error(w, "internal error", http.StatusInternalServerError)
+		}
+		return
+	}
+	log.Info().
+		Str("token_id", old.ID).
+		Str("successor_id", successor.ID).
+		Time("grace_ends", *old.ExpiresAt).
+		Msg("auth: token rotated")
+
+	writeJSON(w, map[string]any{
+		"id":               successor.ID,
+		"name":             successor.Name,
+		"token":            rawToken,
+		"scope":            successor.Scope,
+		"allowed_projects": append([]string{}, successor.AllowedProjects...),
+		"expires_at":       successor.ExpiresAt,
+		"rotated": map[string]any{
+			"id":         old.ID,
+			"name":       old.Name,
+			"expires_at": old.ExpiresAt,
+		},
+	})
+}
+
+// handleListTokenAlerts godoc
+// @Summary List API token usage alerts
+// @Description Returns the unused-token and usage-spike alerts raised by the keycard watch job, newest first. Only open alerts unless all=true.
+// @Tags Auth
+// @Produce json
+// @Security ApiKeyAuth
+// @Param all query bool false "Include resolved alerts"
+// @Param limit query int false "Max alerts (default 100)"
+// @Success 200 {object} map[string]interface{}
+// @Failure 403 {string} string "forbidden — requires browser session"
+// @Failure 500 {string} string "internal error"
+// @Router /api/auth/tokens/alerts [get]
+func (s *Service) handleListTokenAlerts(w http.ResponseWriter, r *http.Request) {
+	if s.requireSessionAdmin(w, r) {
+		return
+	}
+
+	s.initMu.RLock()
+	tokenStore := s.tokenStore
+	s.initMu.RUnlock()
+
+	if tokenStore == nil {
+		http.Error(w, "not ready", http.StatusServiceUnavailable)
+		return
+	}
+
+	limit := 100
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 || n > 1000 {
+			http.Error(w, "invalid limit", http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+	alerts, err := tokenStore.ListTokenAlerts(r.Context(), r.URL.Query().Get("all") == "true", limit)
+	if err != nil {
+		log.Error().Err(err).Msg("auth: failed to list token alerts")
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, map[string]any{
+		"alerts": alerts,
+	})
+}
+
+// handleDismissTokenAlert godoc
+// @Summary Dismiss an API token usage alert
+// @Description Resolves an open alert. A dismissed unused-token alert is not raised again until the token has been used.
+// @Tags Auth
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "Alert ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 403 {string} string "forbidden — requires browser session"
+// @Failure 404 {string} string "not found"
+// @Failure 500 {string} string "internal error"
+// @Router /api/auth/tokens/alerts/{id}/dismiss [post]
+func (s *Service) handleDismissTokenAlert(w http.ResponseWriter, r *http.Request) {
+	if s.requireSessionAdmin(w, r) {
+		return
+	}
+
+	s.initMu.RLock()
+	tokenStore := s.tokenStore
+	s.initMu.RUnlock()
+
+	if tokenStore == nil {
+		http.Error(w, "not ready", http.StatusServiceUnavailable)
+		return
+	}
+
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "invalid alert id", http.StatusBadRequest)
+		return
+	}
+	if err := tokenStore.ResolveTokenAlert(r.Context(), id); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			http.Error(w, "not found", http.StatusNotFound)
+		} else {
+			log.Error().Err(err).Int64("alert_id", id).Msg("auth: failed to dismiss token alert")
+			http.Error(w, "internal error", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	writeJSON(w, map[string]any{
+		"dismissed": true,
+	})
+}
+
+// tokenExpiry resolves a request's expires_in / expires_at into an expiry
+// time; nil when neither is set. Setting both, or an expiry not in the
+// future, is an error.
+func tokenExpiry(expiresIn string, expiresAt *time.Time, now time.Time) (*time.Time, error) {
+	switch {
+	case expiresIn != "" && expiresAt != nil:
+		return nil, errors.New("set expires_in or expires_at, not both")
+	case expiresIn != "":
+		d, err := parseTokenDuration(expiresIn)
+		if err != nil || d <= 0 {
+			return nil, errors.New("invalid expires_in: want a duration such as 720h or 90d")
+		}
+		t := now.Add(d)
+		return &t, nil
+	case expiresAt != nil:
+		if !expiresAt.After(now) {
+			return nil, errors.New("expires_at must be in the future")
+		}
+		return expiresAt, nil
+	}
+	return nil, nil
+}
+
+// parseTokenDuration parses a Go duration or a whole number of days ("90d").
+func parseTokenDuration(s string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid duration %q", s)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid duration %q", s)
+	}
+	return d, nil
+}
+
+// handleSetTokenProjects godoc
+// @Summary Set an API token's project allowlist
+// @Description Replaces the project IDs and glob patterns the token may reach. An empty list makes the token unrestricted. Takes effect on the token's next request.
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Token ID (UUID)"
+// @Param body body tokenProjectsRequest true "Project allowlist"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {string} string "bad request"
+// @Failure 403 {string} string "forbidden — requires browser session"
+// @Failure 404 {string} string "not found"
+// @Failure 500 {string} string "internal error"
+// @Router /api/auth/tokens/{id}/projects [patch]
+func (s *Service) handleSetTokenProjects(w http.ResponseWriter, r *http.Request) {
+	if s.requireSessionAdmin(w, r) {
+		return
+	}
+
+	s.initMu.RLock()
+	tokenStore := s.tokenStore
+	s.initMu.RUnlock()
+
+	if tokenStore == nil {
+		http.Error(w, "not ready", http.StatusServiceUnavailable)
+		return
+	}
+
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		http.Error(w, "token id required", http.StatusBadRequest)
+		return
+	}
+
+	var req tokenProjectsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	allowedProjects, err := authpkg.NormalizeProjectPatterns(req.AllowedProjects)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := tokenStore.SetAllowedProjects(r.Context(), id, allowedProjects); err != nil {
+		log.Error().Err(err).Str("token_id", id).Msg("auth: failed to set token projects")
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			http.Error(w, "not found", http.StatusNotFound)
+		} else {
+			http.Error(w, "internal error", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	writeJSON(w, map[string]any{
+		"id":               id,
+		"allowed_projects": allowedProjects,
+	})
+}
+
+// handleSetTokenScope godoc
+// @Summary Set an API token's role
+// @Description Changes the role (scope) of a live token: read-write, read-only or a custom role. Takes effect on the token's next request.
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Token ID (UUID)"
+// @Param body body tokenScopeRequest true "Role"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {string} string "bad request"
+// @Failure 403 {string} string "forbidden — requires browser session"
+// @Failure 404 {string} string "not found"
+// @Failure 500 {string} string "internal error"
+// @Router /api/auth/tokens/{id}/scope [patch]
+func (s *Service) handleSetTokenScope(w http.ResponseWriter, r *http.Request) {
+	if s.requireSessionAdmin(w, r) {
+		return
+	}
+
+	s.initMu.RLock()
+	tokenStore := s.tokenStore
+	s.initMu.RUnlock()
+
+	if tokenStore == nil {
+		http.Error(w, "not ready", http.StatusServiceUnavailable)
+		return
+	}
+
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		http.Error(w, "token id required", http.StatusBadRequest)
+		return
+	}
+
+	var req tokenScopeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	if s.checkKeycardScope(w, r, req.Scope) {
+		return
+	}
+
+	if err := tokenStore.SetScope(r.Context(), id, req.Scope); err != nil {
+		log.Error().Err(err).Str("token_id", id).Msg("auth: failed to set token scope")
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			http.Error(w, "not found", http.StatusNotFound)
+		} else {
+			http.Error(w, "internal error", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	writeJSON(w, map[string]any{
+		"id":    id,
+		"scope": req.Scope,
 	})
 }
 
@@ -493,6 +985,31 @@ type authRoleKey struct{}
 
 // getAuthRole extracts the auth role from the request context.
 // Returns "admin" for master token or session cookie auth, or the scope for client tokens.
+// rolePermissions returns the permissions of role for /api/auth/me: those of
+// the request's identity when it has one, else those role resolves to.
+// Unknown roles and lookup failures yield none.
+func (s *Service) rolePermissions(r *http.Request, role string) []authpkg.Permission {
+	if id, ok := authpkg.IdentityFrom(r.Context()); ok {
+		return id.Permissions
+	}
+	s.initMu.RLock()
+	roleStore := s.roleStore
+	s.initMu.RUnlock()
+
+	var src authpkg.RoleSource
+	if roleStore != nil {
+		src = roleStore
+	}
+	perms, _, err := authpkg.ResolveRole(r.Context(), src, role)
+	if err != nil {
+		log.Warn().Err(err).Str("role", role).Msg("auth: role lookup failed")
+	}
+	if perms == nil {
+		perms = []authpkg.Permission{}
+	}
+	return perms
+}
+
 func getAuthRole(r *http.Request) string {
 	if role, ok := r.Context().Value(authRoleKey{}).(string); ok {
 		return role
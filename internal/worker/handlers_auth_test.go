@@ -0,0 +1,91 @@
+package worker
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	authpkg "github.com/thebtf/engram/internal/auth"
+	gormdb "github.com/thebtf/engram/internal/db/gorm"
+)
+
+func TestTokenExpiry(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	future := now.Add(time.Hour)
+	past := now.Add(-time.Hour)
+
+	got, err := tokenExpiry("", nil, now)
+	require.NoError(t, err)
+	assert.Nil(t, got, "no expiry requested")
+
+	got, err = tokenExpiry("90d", nil, now)
+	require.NoError(t, err)
+	assert.Equal(t, now.Add(90*24*time.Hour), *got)
+
+	got, err = tokenExpiry("12h", nil, now)
+	require.NoError(t, err)
+	assert.Equal(t, now.Add(12*time.Hour), *got)
+
+	got, err = tokenExpiry("", &future, now)
+	require.NoError(t, err)
+	assert.Equal(t, future, *got)
+
+	for name, c := range map[string]struct {
+		in string
+		at *time.Time
+	}{
+		"both":          {in: "1h", at: &future},
+		"past":          {at: &past},
+		"zero duration": {in: "0d"},
+		"garbage":       {in: "soon"},
+	} {
+		_, err := tokenExpiry(c.in, c.at, now)
+		assert.Error(t, err, name)
+	}
+}
+
+// TestCheckKeycardScope verifies that a caller cannot issue or re-scope a
+// keycard to a role holding permissions the caller lacks.
+func TestCheckKeycardScope(t *testing.T) {
+	s := &Service{}
+	tokenAdmin := authpkg.Session("token-admins")
+	tokenAdmin.Permissions = []authpkg.Permission{authpkg.PermAdminTokens}
+
+	cases := []struct {
+		name   string
+		caller authpkg.Identity
+		scope  string
+		want   int
+	}{
+		{name: "admin issues read-write", caller: authpkg.Session("admin"), scope: "read-write", want: http.StatusOK},
+		{name: "admin issues read-only", caller: authpkg.Session("admin"), scope: "read-only", want: http.StatusOK},
+		{name: "admin:tokens alone issues read-write", caller: tokenAdmin, scope: "read-write", want: http.StatusForbidden},
+		{name: "admin:tokens alone issues read-only", caller: tokenAdmin, scope: "read-only", want: http.StatusForbidden},
+		{name: "operator is not a keycard role", caller: authpkg.Session("admin"), scope: "operator", want: http.StatusBadRequest},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/api/auth/tokens", nil)
+			req = req.WithContext(authpkg.WithIdentity(req.Context(), c.caller))
+			rr := httptest.NewRecorder()
+			rejected := s.checkKeycardScope(rr, req, c.scope)
+			assert.Equal(t, c.want != http.StatusOK, rejected)
+			if rejected {
+				assert.Equal(t, c.want, rr.Code, rr.Body.String())
+			}
+		})
+	}
+
+	// The issuance handler refuses the escalation before touching the store.
+	s.tokenStore = &gormdb.TokenStore{}
+	req := httptest.NewRequest(http.MethodPost, "/api/auth/tokens", strings.NewReader(`{"name":"ci","scope":"read-write"}`))
+	req = req.WithContext(authpkg.WithIdentity(req.Context(), tokenAdmin))
+	rr := httptest.NewRecorder()
+	s.handleCreateToken(rr, req)
+	assert.Equal(t, http.StatusForbidden, rr.Code, rr.Body.String())
+}
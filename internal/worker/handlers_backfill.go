@@ -186,7 +186,7 @@ func (s *Service) handleBackfillSession(w http.ResponseWriter, r *http.Request)
 	})
 }
 
-// vaultStoreDetectedSecrets extracts secrets from text, encrypts each with the vault,
+// vaultStoreDetectedSecrets extracts secrets from text, encrypts each with the project's data key,
 // and stores them as credential observations. All errors are non-fatal — secrets are
 // still redacted from the transcript even if vault storage fails.
 func vaultStoreDetectedSecrets(ctx context.Context, s *Service, text, project string) {
@@ -195,11 +195,16 @@ func vaultStoreDetectedSecrets(ctx context.Context, s *Service, text, project st
 		return
 	}
 
-	vault, err := s.getVault()
+	kr, err := s.getKeyring()
 	if err != nil {
 		log.Warn().Err(err).Msg("backfill: vault not available, skipping secret storage")
 		return
 	}
+	vault, err := kr.ForProject(ctx, project)
+	if err != nil {
+		log.Warn().Err(err).Str("project", project).Msg("backfill: project data key not available, skipping secret storage")
+		return
+	}
 
 	s.initMu.RLock()
 	credentialStore := s.credentialStore
@@ -6,6 +6,7 @@ import (
 	"database/sql"
 	"encoding/json"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
@@ -16,6 +17,7 @@ import (
 	pb "github.com/thebtf/engram/proto/engram/v1"
 	"github.com/thebtf/engram/internal/worker/sdk"
 	"github.com/thebtf/engram/pkg/models"
+	"github.com/thebtf/engram/pkg/strutil"
 )
 
 type sessionStartContextProvider interface {
@@ -327,13 +329,13 @@ func estimateObsTokens(obs *models.Observation) int {
 	}
 	// Add overhead for type tag, formatting, bullet points (~50 chars)
 	chars += 50
-	return (chars + 3) / 4 // ceil(chars/4)
+	return strutil.EstimateTokens(chars)
 }
 
 // estimateObsTokensCondensed estimates tokens for condensed format (title + subtitle only).
 func estimateObsTokensCondensed(obs *models.Observation) int {
 	chars := len(obs.Title.String) + len(obs.Subtitle.String) + 30 // type tag + formatting
-	return (chars + 3) / 4
+	return strutil.EstimateTokens(chars)
 }
 
 // estimateTokens estimates total tokens for a slice of observations.
@@ -476,10 +478,11 @@ func applyActiveVersions(ctx context.Context, vs *gorm.VersionStore, observation
 }
 
 type sessionStartCompatibilityResponse struct {
-	Issues      []map[string]any `json:"issues"`
-	Rules       []map[string]any `json:"rules"`
-	Memories    []map[string]any `json:"memories"`
-	GeneratedAt string           `json:"generated_at"`
+	Issues      []map[string]any           `json:"issues"`
+	Rules       []map[string]any           `json:"rules"`
+	Memories    []map[string]any           `json:"memories"`
+	Truncation  *pb.SessionStartTruncation `json:"truncation,omitempty"`
+	GeneratedAt string                     `json:"generated_at"`
 }
 
 func sessionStartIssuesToMaps(issues []*pb.SessionStartIssue) []map[string]any {
@@ -500,6 +503,27 @@ func sessionStartIssuesToMaps(issues []*pb.SessionStartIssue) []map[string]any {
 			"source_agent":   issue.GetSourceAgent(),
 			"labels":         append([]string(nil), issue.GetLabels()...),
 			"comment_count":  issue.GetCommentCount(),
+			"reason":         issue.GetReason(),
+			"stale_days":     issue.GetStaleDays(),
+		}
+		if issue.GetStale() {
+			entry["stale"] = true
+		}
+		if count := issue.GetEscalationCount(); count > 0 {
+			entry["escalation_count"] = count
+		}
+		if ts := issue.GetEscalatedAt(); ts != nil {
+			entry["escalated_at"] = ts.AsTime().UTC().Format(time.RFC3339)
+		}
+		if blockedBy := issue.GetBlockedBy(); len(blockedBy) > 0 {
+			entry["blocked_by"] = append([]int64(nil), blockedBy...)
+		}
+		if session := issue.GetAssigneeSession(); session != "" {
+			entry["assignee_agent"] = issue.GetAssigneeAgent()
+			entry["assignee_session"] = session
+		}
+		if ts := issue.GetLeaseExpiresAt(); ts != nil {
+			entry["lease_expires_at"] = ts.AsTime().UTC().Format(time.RFC3339)
 		}
 		if ts := issue.GetAcknowledgedAt(); ts != nil {
 			entry["acknowledged_at"] = ts.AsTime().UTC().Format(time.RFC3339)
@@ -540,6 +564,7 @@ func sessionStartRulesToMaps(rules []*pb.SessionStartRule) []map[string]any {
 			"narrative":  rule.GetContent(),
 			"title":      rule.GetContent(),
 			"facts":      []string{},
+			"reason":     rule.GetReason(),
 		}
 		if ts := rule.GetCreatedAt(); ts != nil {
 			entry["created_at"] = ts.AsTime().UTC().Format(time.RFC3339)
@@ -566,6 +591,12 @@ func sessionStartMemoriesToMaps(memories []*pb.SessionStartMemory) []map[string]
 			"source_agent": memory.GetSourceAgent(),
 			"edited_by":    memory.GetEditedBy(),
 			"version":      memory.GetVersion(),
+			"type":         memory.GetType(),
+			"importance":   memory.GetImportance(),
+			"reason":       memory.GetReason(),
+		}
+		if ts := memory.GetExpiresAt(); ts != nil {
+			entry["expires_at"] = ts.AsTime().UTC().Format(time.RFC3339)
 		}
 		if ts := memory.GetCreatedAt(); ts != nil {
 			entry["created_at"] = ts.AsTime().UTC().Format(time.RFC3339)
@@ -581,11 +612,15 @@ func sessionStartMemoriesToMaps(memories []*pb.SessionStartMemory) []map[string]
 // handleSessionStartContextStatic godoc
 // @Summary Get static session-start context
 // @Description Returns static session-start context sourced from the server gRPC implementation: active issues, behavioral rules, recent memories, and generated_at.
+// @Description The payload is packed into token_budget (default: context_max_tokens); truncation reports what was cut.
+// @Description prompt, cwd and recent_files rank memories by relevance to the task; every item carries a reason.
 // @Tags Context
 // @Produce json
 // @Security ApiKeyAuth
 // @Param project query string false "Project slug (required)"
-// @Param body body object false "POST body: {project, memories_limit, issues_limit}"
+// @Param token_budget query int false "Token budget for the payload (0 = server default)"
+// @Param cwd query string false "Working directory of the session"
+// @Param body body object false "POST body: {project, memories_limit, issues_limit, token_budget, prompt, cwd, recent_files}"
 // @Success 200 {object} sessionStartCompatibilityResponse
 // @Failure 400 {string} string "project required"
 // @Failure 500 {string} string "internal error"
@@ -595,12 +630,30 @@ func (s *Service) handleSessionStartContextStatic(w http.ResponseWriter, r *http
 	project := strings.TrimSpace(r.URL.Query().Get("project"))
 	memoriesLimit := int32(0)
 	issuesLimit := int32(0)
+	tokenBudget := int32(0)
+	cwd := strings.TrimSpace(r.URL.Query().Get("cwd"))
+	sessionID := strings.TrimSpace(r.URL.Query().Get("session_id"))
+	var prompt string
+	var recentFiles []string
+	if raw := r.URL.Query().Get("token_budget"); raw != "" {
+		n, err := strconv.ParseInt(raw, 10, 32)
+		if err != nil || n < 0 {
+			http.Error(w, "token_budget must be a non-negative integer", http.StatusBadRequest)
+			return
+		}
+		tokenBudget = int32(n)
+	}
 
 	if r.Method == http.MethodPost && r.Body != nil {
 		var body struct {
 			Project       string `json:"project"`
 			MemoriesLimit int32  `json:"memories_limit"`
 			IssuesLimit   int32  `json:"issues_limit"`
+			TokenBudget   int32    `json:"token_budget"`
+			Prompt        string   `json:"prompt"`
+			Cwd           string   `json:"cwd"`
+			RecentFiles   []string `json:"recent_files"`
+			SessionID     string   `json:"session_id"`
 		}
 		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
 			http.Error(w, "Invalid JSON: "+err.Error(), http.StatusBadRequest)
@@ -611,6 +664,17 @@ func (s *Service) handleSessionStartContextStatic(w http.ResponseWriter, r *http
 		}
 		memoriesLimit = body.MemoriesLimit
 		issuesLimit = body.IssuesLimit
+		if body.TokenBudget != 0 {
+			tokenBudget = body.TokenBudget
+		}
+		if strings.TrimSpace(body.Cwd) != "" {
+			cwd = strings.TrimSpace(body.Cwd)
+		}
+		prompt = body.Prompt
+		recentFiles = body.RecentFiles
+		if strings.TrimSpace(body.SessionID) != "" {
+			sessionID = strings.TrimSpace(body.SessionID)
+		}
 	}
 
 	if project == "" {
@@ -634,6 +698,11 @@ func (s *Service) handleSessionStartContextStatic(w http.ResponseWriter, r *http
 		Project:       project,
 		MemoriesLimit: memoriesLimit,
 		IssuesLimit:   issuesLimit,
+		TokenBudget:   tokenBudget,
+		Prompt:        prompt,
+		Cwd:           cwd,
+		RecentFiles:   recentFiles,
+		SessionId:     sessionID,
 	})
 	if err != nil {
 		if st, ok := grpcstatus.FromError(err); ok {
@@ -653,6 +722,7 @@ func (s *Service) handleSessionStartContextStatic(w http.ResponseWriter, r *http
 		Issues:      sessionStartIssuesToMaps(resp.GetIssues()),
 		Rules:       sessionStartRulesToMaps(resp.GetRules()),
 		Memories:    sessionStartMemoriesToMaps(resp.GetMemories()),
+		Truncation:  resp.GetTruncation(),
 		GeneratedAt: generatedAt,
 	})
 }
@@ -83,6 +83,39 @@ func TestHandleSessionStartContextStatic_HappyPath(t *testing.T) {
 	assert.Equal(t, "Memory content", body.Memories[0]["content"])
 }
 
+func TestHandleSessionStartContextStatic_TokenBudget(t *testing.T) {
+	t.Parallel()
+
+	server := &stubSessionStartContextServer{
+		resp: &pb.GetSessionStartContextResponse{
+			Truncation: &pb.SessionStartTruncation{
+				TokenBudget:      500,
+				TokensUsed:       480,
+				DroppedMemoryIds: []int64{7},
+			},
+		},
+	}
+	service := &Service{grpcInternalServer: server}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/context/session-start?project=engram&token_budget=500", nil)
+	w := httptest.NewRecorder()
+
+	service.handleSessionStartContextStatic(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, int32(500), server.req.GetTokenBudget())
+
+	var body sessionStartCompatibilityResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	require.NotNil(t, body.Truncation)
+	assert.Equal(t, int32(480), body.Truncation.TokensUsed)
+	assert.Equal(t, []int64{7}, body.Truncation.DroppedMemoryIds)
+
+	bad := httptest.NewRecorder()
+	service.handleSessionStartContextStatic(bad, httptest.NewRequest(http.MethodGet, "/api/context/session-start?project=engram&token_budget=-1", nil))
+	assert.Equal(t, http.StatusBadRequest, bad.Code)
+}
+
 func TestHandleSessionStartContextStatic_MapsGrpcErrors(t *testing.T) {
 	t.Parallel()
 
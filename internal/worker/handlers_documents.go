@@ -0,0 +1,209 @@
+// Package worker provides document collection REST handlers used by bulk ingestion.
+package worker
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rs/zerolog/log"
+
+	"github.com/thebtf/engram/internal/db/gorm"
+)
+
+// documentChunk is one pre-chunked span of a document in an upsert request.
+// Lines are 1-based and inclusive, as produced by chunking.Manager.
+type documentChunk struct {
+	Kind       string `json:"kind,omitempty"`
+	Name       string `json:"name,omitempty"`
+	ParentName string `json:"parent_name,omitempty"`
+	Language   string `json:"language,omitempty"`
+	Text       string `json:"text"`
+	StartLine  int    `json:"start_line"`
+	EndLine    int    `json:"end_line"`
+}
+
+// upsertDocumentRequest is the JSON body for PUT /api/collections/{collection}/documents.
+// Chunks are computed by the client so the server never needs the file tree.
+type upsertDocumentRequest struct {
+	Path    string          `json:"path"`
+	Title   string          `json:"title,omitempty"`
+	Content string          `json:"content"`
+	Chunks  []documentChunk `json:"chunks"`
+}
+
+// collectionDocument is a document as listed by GET /api/collections/{collection}/documents.
+// Hash is the full SHA-256 of the content, for clients to skip unchanged files.
+type collectionDocument struct {
+	Path      string `json:"path"`
+	Title     string `json:"title,omitempty"`
+	Hash      string `json:"hash"`
+	UpdatedAt string `json:"updated_at"`
+}
+
+// upsertDocumentResponse reports the result of an upsert. Unchanged is true
+// when the content's chunks were already stored and the request's were ignored.
+type upsertDocumentResponse struct {
+	Document  collectionDocument `json:"document"`
+	Chunks    int                `json:"chunks"`
+	Unchanged bool               `json:"unchanged"`
+}
+
+func toCollectionDocument(d *gorm.Document) collectionDocument {
+	return collectionDocument{
+		Path:      d.Path,
+		Title:     d.Title.String,
+		Hash:      d.Hash.String,
+		UpdatedAt: d.UpdatedAt.UTC().Format("2006-01-02T15:04:05Z"),
+	}
+}
+
+// handleListCollectionDocuments godoc
+// @Summary List active documents in a collection
+// @Description Returns every active document of the collection with its full content hash.
+// @Tags Documents
+// @Produce json
+// @Security ApiKeyAuth
+// @Param collection path string true "Collection name"
+// @Success 200 {array} collectionDocument
+// @Failure 503 {string} string "service unavailable"
+// @Failure 500 {string} string "internal error"
+// @Router /api/collections/{collection}/documents [get]
+func (s *Service) handleListCollectionDocuments(w http.ResponseWriter, r *http.Request) {
+	if s.documentStore == nil {
+		http.Error(w, "document store not available", http.StatusServiceUnavailable)
+		return
+	}
+	collection := chi.URLParam(r, "collection")
+
+	docs, err := s.documentStore.ListDocuments(r.Context(), collection, true)
+	if err != nil {
+		log.Error().Err(err).Str("collection", collection).Msg("list collection documents failed")
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+	result := make([]collectionDocument, len(docs))
+	for i := range docs {
+		result[i] = toCollectionDocument(&docs[i])
+	}
+	writeJSON(w, result)
+}
+
+// handleUpsertCollectionDocument godoc
+// @Summary Ingest a pre-chunked document
+// @Description Stores the document body and metadata and indexes the given chunks for search_docs. Chunks are only written when the content changed.
+// @Tags Documents
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param collection path string true "Collection name"
+// @Param body body upsertDocumentRequest true "Document and its chunks"
+// @Success 200 {object} upsertDocumentResponse
+// @Failure 400 {string} string "bad request"
+// @Failure 503 {string} string "service unavailable"
+// @Failure 500 {string} string "internal error"
+// @Router /api/collections/{collection}/documents [put]
+func (s *Service) handleUpsertCollectionDocument(w http.ResponseWriter, r *http.Request) {
+	if s.documentStore == nil {
+		http.Error(w, "document store not available", http.StatusServiceUnavailable)
+		return
+	}
+	collection := chi.URLParam(r, "collection")
+
+	var req upsertDocumentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid JSON body: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.Path == "" {
+		http.Error(w, "path is required", http.StatusBadRequest)
+		return
+	}
+	if req.Content == "" {
+		http.Error(w, "content is required", http.StatusBadRequest)
+		return
+	}
+	if len(req.Chunks) == 0 {
+		http.Error(w, "chunks are required", http.StatusBadRequest)
+		return
+	}
+	for _, c := range req.Chunks {
+		if c.StartLine < 1 || c.EndLine < c.StartLine {
+			http.Error(w, "chunk line range is invalid", http.StatusBadRequest)
+			return
+		}
+	}
+
+	ctx := r.Context()
+	doc, err := s.documentStore.UpsertDocument(ctx, collection, req.Path, req.Title, req.Content)
+	if err != nil {
+		log.Error().Err(err).Str("collection", collection).Str("path", req.Path).Msg("upsert document failed")
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+	hash := doc.Hash.String
+
+	exists, err := s.documentStore.ChunksExist(ctx, hash)
+	if err != nil {
+		log.Error().Err(err).Str("hash", hash).Msg("check document chunks failed")
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+	if !exists {
+		chunks := make([]gorm.ContentChunk, len(req.Chunks))
+		for i, c := range req.Chunks {
+			chunks[i] = gorm.ContentChunk{
+				Kind:       c.Kind,
+				Name:       c.Name,
+				ParentName: c.ParentName,
+				Language:   c.Language,
+				StartLine:  c.StartLine,
+				EndLine:    c.EndLine,
+				Text:       c.Text,
+			}
+		}
+		if err := s.documentStore.UpsertChunks(ctx, hash, chunks); err != nil {
+			log.Error().Err(err).Str("hash", hash).Msg("store document chunks failed")
+			http.Error(w, "internal server error", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	writeJSON(w, upsertDocumentResponse{
+		Document:  toCollectionDocument(doc),
+		Chunks:    len(req.Chunks),
+		Unchanged: exists,
+	})
+}
+
+// handleDeactivateCollectionDocument godoc
+// @Summary Deactivate a document
+// @Description Marks the document at ?path= inactive so it no longer appears in listings or search. Its content is kept.
+// @Tags Documents
+// @Security ApiKeyAuth
+// @Param collection path string true "Collection name"
+// @Param path query string true "Document path"
+// @Success 204
+// @Failure 400 {string} string "bad request"
+// @Failure 503 {string} string "service unavailable"
+// @Failure 500 {string} string "internal error"
+// @Router /api/collections/{collection}/documents [delete]
+func (s *Service) handleDeactivateCollectionDocument(w http.ResponseWriter, r *http.Request) {
+	if s.documentStore == nil {
+		http.Error(w, "document store not available", http.StatusServiceUnavailable)
+		return
+	}
+	collection := chi.URLParam(r, "collection")
+	path := r.URL.Query().Get("path")
+	if path == "" {
+		http.Error(w, "path is required", http.StatusBadRequest)
+		return
+	}
+
+	if err := s.documentStore.DeactivateDocument(r.Context(), collection, path); err != nil {
+		log.Error().Err(err).Str("collection", collection).Str("path", path).Msg("deactivate document failed")
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,120 @@
+package worker
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	dbgorm "github.com/thebtf/engram/internal/db/gorm"
+)
+
+// documentTestRouter routes the collection document endpoints to service.
+func documentTestRouter(service *Service) *chi.Mux {
+	r := chi.NewRouter()
+	r.Get("/api/collections/{collection}/documents", service.handleListCollectionDocuments)
+	r.Put("/api/collections/{collection}/documents", service.handleUpsertCollectionDocument)
+	r.Delete("/api/collections/{collection}/documents", service.handleDeactivateCollectionDocument)
+	return r
+}
+
+func putDocument(t *testing.T, router http.Handler, collection string, req upsertDocumentRequest) *httptest.ResponseRecorder {
+	t.Helper()
+	body, err := json.Marshal(req)
+	require.NoError(t, err)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPut, "/api/collections/"+collection+"/documents", bytes.NewReader(body)))
+	return w
+}
+
+func TestHandleUpsertCollectionDocument_Validation(t *testing.T) {
+	t.Parallel()
+
+	// A zero-value store is enough: validation fails before it is used.
+	router := documentTestRouter(&Service{documentStore: &dbgorm.DocumentStore{}})
+	chunk := documentChunk{Text: "x", StartLine: 1, EndLine: 1}
+	tests := []struct {
+		name string
+		req  upsertDocumentRequest
+		want string
+	}{
+		{"missing path", upsertDocumentRequest{Content: "x", Chunks: []documentChunk{chunk}}, "path is required"},
+		{"missing content", upsertDocumentRequest{Path: "a.md", Chunks: []documentChunk{chunk}}, "content is required"},
+		{"missing chunks", upsertDocumentRequest{Path: "a.md", Content: "x"}, "chunks are required"},
+		{"bad range", upsertDocumentRequest{Path: "a.md", Content: "x", Chunks: []documentChunk{{Text: "x", StartLine: 3, EndLine: 2}}}, "line range"},
+	}
+	for _, tt := range tests {
+		w := putDocument(t, router, "arch", tt.req)
+		assert.Equal(t, http.StatusBadRequest, w.Code, tt.name)
+		assert.Contains(t, w.Body.String(), tt.want, tt.name)
+	}
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/api/collections/arch/documents", nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = httptest.NewRecorder()
+	documentTestRouter(&Service{}).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/collections/arch/documents", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+}
+
+func TestCollectionDocuments_RoundTrip(t *testing.T) {
+	dsn := os.Getenv("DATABASE_DSN")
+	if dsn == "" {
+		t.Skip("DATABASE_DSN not set, skipping integration test")
+	}
+	store, err := dbgorm.NewStore(dbgorm.Config{DSN: dsn, MaxConns: 2})
+	require.NoError(t, err)
+	collection := "test-collection-" + uuid.NewString()
+	t.Cleanup(func() {
+		require.NoError(t, store.DB.WithContext(context.Background()).Exec("DELETE FROM documents WHERE collection = ?", collection).Error)
+		require.NoError(t, store.Close())
+	})
+	router := documentTestRouter(&Service{documentStore: dbgorm.NewDocumentStore(store)})
+
+	req := upsertDocumentRequest{
+		Path:    "docs/adr/0001.md",
+		Title:   "Use an outbox",
+		Content: "# Use an outbox\n\nWrites go through the outbox table.",
+		Chunks:  []documentChunk{{Kind: "section", Name: "Use an outbox", Language: "markdown", Text: "# Use an outbox\n\nWrites go through the outbox table.", StartLine: 1, EndLine: 3}},
+	}
+	w := putDocument(t, router, collection, req)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var first upsertDocumentResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &first))
+	assert.False(t, first.Unchanged)
+	assert.Equal(t, 1, first.Chunks)
+	assert.Len(t, first.Document.Hash, 64)
+
+	w = putDocument(t, router, collection, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	var second upsertDocumentResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &second))
+	assert.True(t, second.Unchanged, "same content keeps its chunks")
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/collections/"+collection+"/documents", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	var docs []collectionDocument
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &docs))
+	require.Len(t, docs, 1)
+	assert.Equal(t, first.Document.Hash, docs[0].Hash)
+	assert.Equal(t, "Use an outbox", docs[0].Title)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/api/collections/"+collection+"/documents?path=docs/adr/0001.md", nil))
+	require.Equal(t, http.StatusNoContent, w.Code)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/collections/"+collection+"/documents", nil))
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &docs))
+	assert.Empty(t, docs)
+}
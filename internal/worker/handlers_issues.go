@@ -1,8 +1,10 @@
 package worker
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 	"strconv"
 	"strings"
@@ -12,6 +14,8 @@ import (
 	"github.com/rs/zerolog/log"
 
 	gormdb "github.com/thebtf/engram/internal/db/gorm"
+	"github.com/thebtf/engram/internal/issuefile"
+	"github.com/thebtf/engram/internal/webhooks"
 )
 
 // handleListIssues handles GET /api/issues with optional filters.
@@ -47,6 +51,10 @@ func (s *Service) handleListIssues(w http.ResponseWriter, r *http.Request) {
 		SourceProject: sourceProject,
 		Statuses:      statuses,
 		Type:          typeParam,
+		Query:         strings.TrimSpace(r.URL.Query().Get("q")),
+		Labels:        splitCommaList(r.URL.Query().Get("labels")),
+		ExcludeLabels: splitCommaList(r.URL.Query().Get("exclude_labels")),
+		Priorities:    splitCommaList(strings.ToLower(r.URL.Query().Get("priority"))),
 		Limit:         limit,
 		Offset:        offset,
 	}
@@ -56,6 +64,36 @@ func (s *Service) handleListIssues(w http.ResponseWriter, r *http.Request) {
 			params.ResolvedSince = &t
 		}
 	}
+	for name, dst := range map[string]**time.Time{
+		"created_after":  &params.CreatedAfter,
+		"created_before": &params.CreatedBefore,
+		"updated_after":  &params.UpdatedAfter,
+		"updated_before": &params.UpdatedBefore,
+	} {
+		raw := r.URL.Query().Get(name)
+		if raw == "" {
+			continue
+		}
+		ms, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			http.Error(w, fmt.Sprintf(`{"error": "%s must be epoch milliseconds"}`, name), http.StatusBadRequest)
+			return
+		}
+		t := time.UnixMilli(ms)
+		*dst = &t
+	}
+	if name := r.URL.Query().Get("saved_query"); name != "" {
+		saved, err := s.issueStore.GetIssueQuery(r.Context(), name)
+		if err != nil {
+			if strings.Contains(err.Error(), "not found") {
+				http.Error(w, `{"error": "saved query not found"}`, http.StatusNotFound)
+				return
+			}
+			http.Error(w, fmt.Sprintf(`{"error": %q}`, err.Error()), http.StatusInternalServerError)
+			return
+		}
+		params.ApplyFilter(saved.Filter)
+	}
 
 	issues, total, err := s.issueStore.ListIssuesEx(r.Context(), params)
 	if err != nil {
@@ -104,12 +142,18 @@ func (s *Service) handleGetIssue(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, fmt.Sprintf(`{"error": %q}`, err.Error()), http.StatusInternalServerError)
 		return
 	}
+	links, err := s.issueStore.ListIssueLinks(r.Context(), id)
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error": %q}`, err.Error()), http.StatusInternalServerError)
+		return
+	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
 		"issue":                        issue,
 		"comments":                     comments,
 		"comment_count":                len(comments),
+		"links":                        links,
 		"source_project_display_name":  s.getProjectDisplayName(r.Context(), issue.SourceProject),
 		"target_project_display_name":  s.getProjectDisplayName(r.Context(), issue.TargetProject),
 	})
@@ -171,6 +215,7 @@ func (s *Service) handleCreateIssue(w http.ResponseWriter, r *http.Request) {
 		Str("source", req.SourceProject).
 		Str("target", req.TargetProject).
 		Msg("Issue created")
+	s.webhookPublisher.PublishIssue(r.Context(), webhooks.EventIssueCreated, issue)
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
@@ -194,6 +239,7 @@ func (s *Service) handleUpdateIssue(w http.ResponseWriter, r *http.Request) {
 		Comment       string   `json:"comment"`
 		SourceProject string   `json:"source_project"`
 		SourceAgent   string   `json:"source_agent"`
+		SessionID     string   `json:"session_id"`
 		Title         string   `json:"title"`
 		Body          string   `json:"body"`
 		Priority      string   `json:"priority"`
@@ -205,12 +251,18 @@ func (s *Service) handleUpdateIssue(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	ctx := gormdb.WithIssueActor(r.Context(), gormdb.IssueActor{
+		Project: req.SourceProject,
+		Agent:   req.SourceAgent,
+		Session: req.SessionID,
+	})
+
 	// Normalize type before validation and storage.
 	req.Type = strings.ToLower(strings.TrimSpace(req.Type))
 
 	// Field edits (dashboard inline editing)
 	if req.Title != "" || req.Body != "" || req.Priority != "" || req.Type != "" || req.Labels != nil {
-		if err := s.issueStore.UpdateIssueFields(r.Context(), id, req.Title, req.Body, req.Priority, req.Type, req.Labels); err != nil {
+		if err := s.issueStore.UpdateIssueFields(ctx, id, req.Title, req.Body, req.Priority, req.Type, req.Labels); err != nil {
 			if strings.Contains(err.Error(), "not found") {
 				http.Error(w, `{"error": "issue not found"}`, http.StatusNotFound)
 				return
@@ -225,18 +277,18 @@ func (s *Service) handleUpdateIssue(w http.ResponseWriter, r *http.Request) {
 		var statusErr error
 		switch req.Status {
 		case "resolved":
-			statusErr = s.issueStore.UpdateIssueStatus(r.Context(), id, req.Status)
+			statusErr = s.issueStore.UpdateIssueStatus(ctx, id, req.Status)
 		case "reopened":
-			statusErr = s.issueStore.ReopenIssue(r.Context(), id, req.Comment, req.SourceProject, req.SourceAgent)
+			statusErr = s.issueStore.ReopenIssue(ctx, id, req.Comment, req.SourceProject, req.SourceAgent)
 			req.Comment = "" // ReopenIssue already adds comment
 		case "closed":
-			statusErr = s.issueStore.CloseIssue(r.Context(), id, req.SourceProject)
+			statusErr = s.issueStore.CloseIssue(ctx, id, req.SourceProject)
 		case "rejected":
-			statusErr = s.issueStore.RejectIssue(r.Context(), id, req.Comment, req.SourceProject, req.SourceAgent)
+			statusErr = s.issueStore.RejectIssue(ctx, id, req.Comment, req.SourceProject, req.SourceAgent)
 			req.Comment = "" // RejectIssue already adds comment
 		case "open", "acknowledged":
 			// Force status (operator override) — no lifecycle validation
-			statusErr = s.issueStore.UpdateIssueStatus(r.Context(), id, req.Status)
+			statusErr = s.issueStore.UpdateIssueStatus(ctx, id, req.Status)
 		default:
 			http.Error(w, `{"error": "invalid status"}`, http.StatusBadRequest)
 			return
@@ -249,10 +301,15 @@ func (s *Service) handleUpdateIssue(w http.ResponseWriter, r *http.Request) {
 			http.Error(w, fmt.Sprintf(`{"error": %q}`, statusErr.Error()), http.StatusBadRequest)
 			return
 		}
+		if req.Status == "resolved" {
+			if issue, _, err := s.issueStore.GetIssue(ctx, id); err == nil {
+				s.webhookPublisher.PublishIssue(ctx, webhooks.EventIssueResolved, issue)
+			}
+		}
 	}
 
 	if req.Comment != "" {
-		_, err := s.issueStore.AddComment(r.Context(), id, &gormdb.IssueComment{
+		_, err := s.issueStore.AddComment(ctx, id, &gormdb.IssueComment{
 			AuthorProject: req.SourceProject,
 			AuthorAgent:   req.SourceAgent,
 			Body:          req.Comment,
@@ -272,14 +329,22 @@ func (s *Service) handleUpdateIssue(w http.ResponseWriter, r *http.Request) {
 // handleAcknowledgeIssues handles POST /api/issues/acknowledge.
 func (s *Service) handleAcknowledgeIssues(w http.ResponseWriter, r *http.Request) {
 	var req struct {
-		IDs []int64 `json:"ids"`
+		Project     string  `json:"project"`
+		AgentSource string  `json:"agent_source"`
+		SessionID   string  `json:"session_id"`
+		IDs         []int64 `json:"ids"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		http.Error(w, `{"error": "invalid JSON body"}`, http.StatusBadRequest)
 		return
 	}
 
-	acknowledged, err := s.issueStore.AcknowledgeIssues(r.Context(), req.IDs)
+	ctx := gormdb.WithIssueActor(r.Context(), gormdb.IssueActor{
+		Project: req.Project,
+		Agent:   req.AgentSource,
+		Session: req.SessionID,
+	})
+	acknowledged, err := s.issueStore.AcknowledgeIssues(ctx, req.IDs)
 	if err != nil {
 		http.Error(w, fmt.Sprintf(`{"error": %q}`, err.Error()), http.StatusInternalServerError)
 		return
@@ -332,3 +397,412 @@ func (s *Service) handleDeleteIssue(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusNoContent)
 }
 
+
+// handleListIssueLinks handles GET /api/issues/{id}/links.
+func (s *Service) handleListIssueLinks(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, `{"error": "invalid issue id"}`, http.StatusBadRequest)
+		return
+	}
+
+	links, err := s.issueStore.ListIssueLinks(r.Context(), id)
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error": %q}`, err.Error()), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"links": links,
+		"count": len(links),
+	})
+}
+
+// handleCreateIssueLink handles POST /api/issues/{id}/links. The body names the
+// other issue and the link type, read as "{id} <link_type> {linked_id}".
+func (s *Service) handleCreateIssueLink(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, `{"error": "invalid issue id"}`, http.StatusBadRequest)
+		return
+	}
+
+	var req struct {
+		LinkedID      int64  `json:"linked_id"`
+		LinkType      string `json:"link_type"`
+		SourceProject string `json:"source_project"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, `{"error": "invalid JSON body"}`, http.StatusBadRequest)
+		return
+	}
+	if req.LinkedID <= 0 {
+		http.Error(w, `{"error": "linked_id is required"}`, http.StatusBadRequest)
+		return
+	}
+
+	link, err := s.issueStore.LinkIssues(r.Context(), &gormdb.IssueLink{
+		SourceID:  id,
+		TargetID:  req.LinkedID,
+		LinkType:  strings.ToLower(strings.TrimSpace(req.LinkType)),
+		CreatedBy: req.SourceProject,
+	})
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			http.Error(w, `{"error": "issue not found"}`, http.StatusNotFound)
+			return
+		}
+		http.Error(w, fmt.Sprintf(`{"error": %q}`, err.Error()), http.StatusBadRequest)
+		return
+	}
+
+	log.Info().
+		Int64("source_id", link.SourceID).
+		Int64("target_id", link.TargetID).
+		Str("link_type", link.LinkType).
+		Msg("Issue link created")
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(link)
+}
+
+// handleDeleteIssueLink handles DELETE /api/issues/{id}/links?linked_id=N&link_type=T.
+// An optional source_project names who removed the link in the issue events.
+func (s *Service) handleDeleteIssueLink(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, `{"error": "invalid issue id"}`, http.StatusBadRequest)
+		return
+	}
+	linkedID, err := strconv.ParseInt(r.URL.Query().Get("linked_id"), 10, 64)
+	if err != nil || linkedID <= 0 {
+		http.Error(w, `{"error": "linked_id is required"}`, http.StatusBadRequest)
+		return
+	}
+	linkType := strings.ToLower(strings.TrimSpace(r.URL.Query().Get("link_type")))
+
+	ctx := gormdb.WithIssueActor(r.Context(), gormdb.IssueActor{Project: r.URL.Query().Get("source_project")})
+	if err := s.issueStore.UnlinkIssues(ctx, id, linkedID, linkType); err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			http.Error(w, `{"error": "link not found"}`, http.StatusNotFound)
+			return
+		}
+		http.Error(w, fmt.Sprintf(`{"error": %q}`, err.Error()), http.StatusBadRequest)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// issueClaimRequest is the body of the claim, renew and release endpoints.
+type issueClaimRequest struct {
+	Project      string `json:"project"`
+	Agent        string `json:"agent"`
+	SessionID    string `json:"session_id"`
+	LeaseMinutes int    `json:"lease_minutes"`
+}
+
+// actorContext attributes the claim change to the requesting session.
+func (req issueClaimRequest) actorContext(r *http.Request) context.Context {
+	return gormdb.WithIssueActor(r.Context(), gormdb.IssueActor{
+		Project: req.Project,
+		Agent:   req.Agent,
+		Session: req.SessionID,
+	})
+}
+
+// lease returns the requested lease, or the default when none was given.
+func (req issueClaimRequest) lease() time.Duration {
+	if req.LeaseMinutes <= 0 {
+		return gormdb.DefaultIssueLease
+	}
+	return time.Duration(req.LeaseMinutes) * time.Minute
+}
+
+// decodeIssueClaimRequest parses the issue ID and claim body shared by the
+// claim endpoints, writing a 400 and returning false when either is invalid.
+func decodeIssueClaimRequest(w http.ResponseWriter, r *http.Request) (int64, issueClaimRequest, bool) {
+	var req issueClaimRequest
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, `{"error": "invalid issue id"}`, http.StatusBadRequest)
+		return 0, req, false
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, `{"error": "invalid JSON body"}`, http.StatusBadRequest)
+		return 0, req, false
+	}
+	req.SessionID = strings.TrimSpace(req.SessionID)
+	if req.SessionID == "" {
+		http.Error(w, `{"error": "session_id is required"}`, http.StatusBadRequest)
+		return 0, req, false
+	}
+	return id, req, true
+}
+
+// writeIssueClaimError maps claim errors to HTTP statuses: unknown issue 404,
+// a claim held by another session 409, anything else 400.
+func writeIssueClaimError(w http.ResponseWriter, err error) {
+	msg := err.Error()
+	switch {
+	case strings.Contains(msg, "not found"):
+		http.Error(w, `{"error": "issue not found"}`, http.StatusNotFound)
+	case strings.Contains(msg, "is claimed by"), strings.Contains(msg, "is not claimed by"), strings.Contains(msg, "has expired"):
+		http.Error(w, fmt.Sprintf(`{"error": %q}`, msg), http.StatusConflict)
+	default:
+		http.Error(w, fmt.Sprintf(`{"error": %q}`, msg), http.StatusBadRequest)
+	}
+}
+
+// handleClaimIssue handles POST /api/issues/{id}/claim.
+func (s *Service) handleClaimIssue(w http.ResponseWriter, r *http.Request) {
+	id, req, ok := decodeIssueClaimRequest(w, r)
+	if !ok {
+		return
+	}
+
+	issue, err := s.issueStore.ClaimIssue(req.actorContext(r), id, req.Agent, req.SessionID, req.lease())
+	if err != nil {
+		writeIssueClaimError(w, err)
+		return
+	}
+
+	log.Info().
+		Int64("issue_id", id).
+		Str("session", req.SessionID).
+		Time("lease_expires_at", *issue.LeaseExpiresAt).
+		Msg("Issue claimed")
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]any{"issue": issue})
+}
+
+// handleRenewIssueLease handles POST /api/issues/{id}/renew.
+func (s *Service) handleRenewIssueLease(w http.ResponseWriter, r *http.Request) {
+	id, req, ok := decodeIssueClaimRequest(w, r)
+	if !ok {
+		return
+	}
+
+	issue, err := s.issueStore.RenewIssueLease(r.Context(), id, req.SessionID, req.lease())
+	if err != nil {
+		writeIssueClaimError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]any{"issue": issue})
+}
+
+// handleReleaseIssue handles POST /api/issues/{id}/release.
+func (s *Service) handleReleaseIssue(w http.ResponseWriter, r *http.Request) {
+	id, req, ok := decodeIssueClaimRequest(w, r)
+	if !ok {
+		return
+	}
+
+	if err := s.issueStore.ReleaseIssue(req.actorContext(r), id, req.SessionID); err != nil {
+		writeIssueClaimError(w, err)
+		return
+	}
+
+	log.Info().Int64("issue_id", id).Str("session", req.SessionID).Msg("Issue claim released")
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleListIssueQueries handles GET /api/issues/saved-queries.
+func (s *Service) handleListIssueQueries(w http.ResponseWriter, r *http.Request) {
+	queries, err := s.issueStore.ListIssueQueries(r.Context())
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error": %q}`, err.Error()), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"queries": queries,
+		"count":   len(queries),
+	})
+}
+
+// handleSaveIssueQuery handles POST /api/issues/saved-queries. Saving an
+// existing name replaces its filter. Apply a saved query with
+// GET /api/issues?saved_query={name}.
+func (s *Service) handleSaveIssueQuery(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Name      string             `json:"name"`
+		CreatedBy string             `json:"created_by"`
+		Filter    gormdb.IssueFilter `json:"filter"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, `{"error": "invalid JSON body"}`, http.StatusBadRequest)
+		return
+	}
+
+	saved, err := s.issueStore.SaveIssueQuery(r.Context(), &gormdb.IssueSavedQuery{
+		Name:      req.Name,
+		CreatedBy: req.CreatedBy,
+		Filter:    req.Filter,
+	})
+	if err != nil {
+		if strings.Contains(err.Error(), "saved query name") {
+			http.Error(w, fmt.Sprintf(`{"error": %q}`, err.Error()), http.StatusBadRequest)
+			return
+		}
+		http.Error(w, fmt.Sprintf(`{"error": %q}`, err.Error()), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(saved)
+}
+
+// handleDeleteIssueQuery handles DELETE /api/issues/saved-queries/{name}.
+func (s *Service) handleDeleteIssueQuery(w http.ResponseWriter, r *http.Request) {
+	if err := s.issueStore.DeleteIssueQuery(r.Context(), chi.URLParam(r, "name")); err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			http.Error(w, `{"error": "saved query not found"}`, http.StatusNotFound)
+			return
+		}
+		http.Error(w, fmt.Sprintf(`{"error": %q}`, err.Error()), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleListIssueEvents handles GET /api/issues/{id}/events: the issue's
+// activity timeline, oldest first.
+func (s *Service) handleListIssueEvents(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, `{"error": "invalid issue id"}`, http.StatusBadRequest)
+		return
+	}
+
+	events, err := s.issueStore.ListIssueEvents(r.Context(), id)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			http.Error(w, `{"error": "issue not found"}`, http.StatusNotFound)
+			return
+		}
+		http.Error(w, fmt.Sprintf(`{"error": %q}`, err.Error()), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"events": events,
+		"count":  len(events),
+	})
+}
+
+// maxIssueImportBytes caps the request body of POST /api/issues/import.
+const maxIssueImportBytes = 32 << 20
+
+// handleExportIssues handles GET /api/issues/export: issues with their
+// comments in the issuefile JSON format. Optional filters: project (target
+// project) and status (comma-separated).
+func (s *Service) handleExportIssues(w http.ResponseWriter, r *http.Request) {
+	params := gormdb.IssueExportParams{Project: r.URL.Query().Get("project")}
+	if params.Project != "" {
+		params.Project = s.issueStore.ResolveProject(r.Context(), params.Project)
+	}
+	for _, status := range strings.Split(r.URL.Query().Get("status"), ",") {
+		if status = strings.TrimSpace(status); status != "" {
+			params.Statuses = append(params.Statuses, status)
+		}
+	}
+
+	stored, err := s.issueStore.ExportIssues(r.Context(), params)
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error": %q}`, err.Error()), http.StatusInternalServerError)
+		return
+	}
+	doc := issuefile.Document{
+		Version:    issuefile.Version,
+		ExportedAt: time.Now().UTC(),
+		Issues:     make([]issuefile.Issue, len(stored)),
+	}
+	for i, issue := range stored {
+		doc.Issues[i] = issuefile.FromStore(issue)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(doc)
+}
+
+// issueImportError reports an issue POST /api/issues/import could not import.
+type issueImportError struct {
+	ExternalID string `json:"external_id"`
+	Title      string `json:"title"`
+	Error      string `json:"error"`
+}
+
+// handleImportIssues handles POST /api/issues/import. The body is an issuefile
+// Document or a bare array of GitHub issues; issues without an engram block
+// are filed under the project query parameter. Each issue is imported
+// independently, so one bad issue does not stop the rest; failures are listed
+// in errors. Re-importing the same issues is a no-op.
+func (s *Service) handleImportIssues(w http.ResponseWriter, r *http.Request) {
+	data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxIssueImportBytes))
+	if err != nil {
+		http.Error(w, `{"error": "request body too large or unreadable"}`, http.StatusBadRequest)
+		return
+	}
+	doc, err := issuefile.UnmarshalDocument(data)
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error": %q}`, err.Error()), http.StatusBadRequest)
+		return
+	}
+
+	q := r.URL.Query()
+	project := q.Get("project")
+	if project != "" {
+		project = s.issueStore.ResolveProject(r.Context(), project)
+	}
+	ctx := gormdb.WithIssueActor(r.Context(), gormdb.IssueActor{
+		Project: q.Get("source_project"),
+		Agent:   q.Get("source_agent"),
+	})
+
+	results := make([]*gormdb.IssueImportResult, 0, len(doc.Issues))
+	failures := make([]issueImportError, 0)
+	counts := map[string]int{}
+	commentsAdded := 0
+	for _, issue := range doc.Issues {
+		stored, err := issue.ToStore(project)
+		if err == nil {
+			var result *gormdb.IssueImportResult
+			if result, err = s.issueStore.ImportIssue(ctx, &stored); err == nil {
+				results = append(results, result)
+				counts[result.Action]++
+				commentsAdded += result.CommentsAdded
+				continue
+			}
+		}
+		failures = append(failures, issueImportError{
+			ExternalID: issue.ExternalID,
+			Title:      issue.Title,
+			Error:      err.Error(),
+		})
+	}
+
+	log.Info().
+		Int("created", counts[gormdb.IssueImportCreated]).
+		Int("updated", counts[gormdb.IssueImportUpdated]).
+		Int("unchanged", counts[gormdb.IssueImportUnchanged]).
+		Int("errors", len(failures)).
+		Msg("Issues imported")
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"created":        counts[gormdb.IssueImportCreated],
+		"updated":        counts[gormdb.IssueImportUpdated],
+		"unchanged":      counts[gormdb.IssueImportUnchanged],
+		"comments_added": commentsAdded,
+		"results":        results,
+		"errors":         failures,
+	})
+}
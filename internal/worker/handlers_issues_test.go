@@ -0,0 +1,98 @@
+package worker
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIssueClaimEndpoints_Validation(t *testing.T) {
+	t.Parallel()
+
+	// Validation fails before the issue store is used.
+	service := &Service{}
+	r := chi.NewRouter()
+	r.Post("/api/issues/{id}/claim", service.handleClaimIssue)
+	r.Post("/api/issues/{id}/renew", service.handleRenewIssueLease)
+	r.Post("/api/issues/{id}/release", service.handleReleaseIssue)
+
+	tests := []struct {
+		name string
+		path string
+		body string
+		want string
+	}{
+		{"invalid id", "/api/issues/abc/claim", `{"session_id":"s"}`, "invalid issue id"},
+		{"invalid body", "/api/issues/1/renew", `{`, "invalid JSON body"},
+		{"missing session", "/api/issues/1/release", `{"session_id":"  "}`, "session_id is required"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, tt.path, strings.NewReader(tt.body)))
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+			assert.Contains(t, w.Body.String(), tt.want)
+		})
+	}
+}
+
+func TestWriteIssueClaimError(t *testing.T) {
+	t.Parallel()
+
+	for msg, want := range map[string]int{
+		"claim issue: issue 4 not found":                            http.StatusNotFound,
+		`claim issue: issue 4 is claimed by session "s2" (agent a)`: http.StatusConflict,
+		`release issue: issue 4 is not claimed by session "s1"`:     http.StatusConflict,
+		"renew issue lease: lease on issue 4 has expired":           http.StatusConflict,
+		"invalid lease 48h0m0s: must be between 1m and 24h0m0s":     http.StatusBadRequest,
+	} {
+		w := httptest.NewRecorder()
+		writeIssueClaimError(w, errors.New(msg))
+		assert.Equal(t, want, w.Code, msg)
+	}
+}
+
+func TestListIssues_InvalidDateFilter(t *testing.T) {
+	t.Parallel()
+
+	// Date filters are parsed before the issue store is used.
+	service := &Service{}
+	w := httptest.NewRecorder()
+	service.handleListIssues(w, httptest.NewRequest(http.MethodGet, "/api/issues?created_after=yesterday", nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "created_after must be epoch milliseconds")
+}
+
+func TestListIssueEvents_InvalidID(t *testing.T) {
+	t.Parallel()
+
+	service := &Service{}
+	r := chi.NewRouter()
+	r.Get("/api/issues/{id}/events", service.handleListIssueEvents)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/issues/abc/events", nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "invalid issue id")
+}
+
+func TestImportIssues_InvalidBody(t *testing.T) {
+	t.Parallel()
+
+	// The document is parsed before the issue store is used.
+	service := &Service{}
+	for body, want := range map[string]string{
+		`{`:                            "parse issues",
+		`{"version": 2, "issues": []}`: "unsupported issue file version",
+	} {
+		w := httptest.NewRecorder()
+		service.handleImportIssues(w, httptest.NewRequest(http.MethodPost, "/api/issues/import", strings.NewReader(body)))
+		assert.Equal(t, http.StatusBadRequest, w.Code, body)
+		assert.Contains(t, w.Body.String(), want, body)
+	}
+}
@@ -7,12 +7,16 @@ import (
 	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/rs/zerolog/log"
 	gormlib "gorm.io/gorm"
 
+	"github.com/thebtf/engram/internal/db/gorm"
+	"github.com/thebtf/engram/internal/webhooks"
 	"github.com/thebtf/engram/pkg/models"
+	"github.com/thebtf/engram/pkg/strutil"
 )
 
 // storeMemoryRequest is the JSON body for POST /api/memories.
@@ -70,20 +74,56 @@ func (s *Service) handleStoreMemoryExplicit(w http.ResponseWriter, r *http.Reque
 		http.Error(w, "internal server error", http.StatusInternalServerError)
 		return
 	}
+	s.webhookPublisher.Publish(r.Context(), webhooks.EventMemoryCreated, created.Project, created)
 
 	w.WriteHeader(http.StatusCreated)
 	writeJSON(w, created)
 }
 
+// memorySearchHit is one ranked hit in a GET /api/memories search response.
+type memorySearchHit struct {
+	*models.Memory
+	Snippet string  `json:"snippet"`
+	Score   float64 `json:"score"`
+}
+
+// memorySearchGroup is one project's hits in a cross-project search response.
+type memorySearchGroup struct {
+	Project   string            `json:"project"`
+	Hits      []memorySearchHit `json:"hits"`
+	BestScore float64           `json:"best_score"`
+}
+
+// memorySearchResponse is the GET /api/memories body when q is set.
+// Groups is only populated for scope=all.
+type memorySearchResponse struct {
+	NextCursor string              `json:"next_cursor,omitempty"`
+	Hits       []memorySearchHit   `json:"hits"`
+	Groups     []memorySearchGroup `json:"groups,omitempty"`
+}
+
 // handleListMemories godoc
-// @Summary List memory notes for a project
-// @Description Returns stored memories for the given project, newest first.
+// @Summary List or search memory notes
+// @Description Without q, returns stored memories for the given project, newest first.
+// @Description With q, runs ranked full-text search (websearch syntax) and returns hits with
+// @Description snippets, scores and a next_cursor. scope=all searches every project (narrowed by
+// @Description include_projects / exclude_projects, comma-separated) and groups hits per project.
+// @Description types and min_importance filter both modes; expired memories are never returned.
 // @Tags Memories
 // @Produce json
 // @Security ApiKeyAuth
-// @Param project query string true "Project identifier"
+// @Param project query string false "Project identifier (required unless scope=all)"
+// @Param q query string false "Full-text query"
+// @Param cursor query string false "next_cursor from a previous search page"
+// @Param scope query string false "project (default) or all"
+// @Param include_projects query string false "Comma-separated projects to search (scope=all)"
+// @Param exclude_projects query string false "Comma-separated projects to skip (scope=all)"
+// @Param types query string false "Comma-separated memory types to keep"
+// @Param min_importance query number false "Minimum importance 0-1"
+// @Param order_by query string false "recent (default) or importance; list mode only"
 // @Param limit query int false "Maximum number of results (default 50)"
 // @Success 200 {array} models.Memory
+// @Success 200 {object} memorySearchResponse
 // @Failure 400 {string} string "project is required"
 // @Failure 503 {string} string "service unavailable"
 // @Failure 500 {string} string "internal error"
@@ -94,15 +134,29 @@ func (s *Service) handleListMemories(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	project := r.URL.Query().Get("project")
-	if project == "" {
+	q := r.URL.Query()
+	project := q.Get("project")
+	query := strings.TrimSpace(q.Get("q"))
+	scope := q.Get("scope")
+	if scope == "" {
+		scope = "project"
+	}
+	if scope != "project" && scope != "all" {
+		http.Error(w, "scope must be project or all", http.StatusBadRequest)
+		return
+	}
+	if scope == "project" && project == "" {
 		http.Error(w, "project is required", http.StatusBadRequest)
 		return
 	}
+	if scope == "all" && query == "" {
+		http.Error(w, "q is required when scope=all", http.StatusBadRequest)
+		return
+	}
 
 	const maxLimit = 500
 	limit := 50
-	if raw := r.URL.Query().Get("limit"); raw != "" {
+	if raw := q.Get("limit"); raw != "" {
 		n, err := strconv.Atoi(raw)
 		if err != nil || n <= 0 {
 			http.Error(w, "limit must be a positive integer", http.StatusBadRequest)
@@ -115,7 +169,41 @@ func (s *Service) handleListMemories(w http.ResponseWriter, r *http.Request) {
 		limit = n
 	}
 
-	mems, err := s.memoryStore.List(r.Context(), project, limit)
+	var minImportance float64
+	if raw := q.Get("min_importance"); raw != "" {
+		f, err := strconv.ParseFloat(raw, 64)
+		if err != nil || f < 0 || f > 1 {
+			http.Error(w, "min_importance must be a number between 0 and 1", http.StatusBadRequest)
+			return
+		}
+		minImportance = f
+	}
+	types := splitCommaList(q.Get("types"))
+
+	if query != "" {
+		s.searchMemories(w, r, gorm.MemorySearchParams{
+			Query:         query,
+			Cursor:        q.Get("cursor"),
+			Types:         types,
+			MinImportance: minImportance,
+			Limit:         limit,
+		}, scope, project)
+		return
+	}
+
+	orderBy := q.Get("order_by")
+	if orderBy != "" && orderBy != gorm.MemoryOrderRecent && orderBy != gorm.MemoryOrderImportance {
+		http.Error(w, "order_by must be recent or importance", http.StatusBadRequest)
+		return
+	}
+
+	mems, err := s.memoryStore.ListEx(r.Context(), gorm.MemoryListParams{
+		Project:       project,
+		Types:         types,
+		MinImportance: minImportance,
+		OrderBy:       orderBy,
+		Limit:         limit,
+	})
 	if err != nil {
 		log.Error().Err(err).Str("project", project).Msg("list memories failed")
 		http.Error(w, "internal server error", http.StatusInternalServerError)
@@ -130,6 +218,58 @@ func (s *Service) handleListMemories(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, mems)
 }
 
+// searchMemories serves the q branch of GET /api/memories.
+func (s *Service) searchMemories(w http.ResponseWriter, r *http.Request, params gorm.MemorySearchParams, scope, project string) {
+	if scope == "all" {
+		params.IncludeProjects = splitCommaList(r.URL.Query().Get("include_projects"))
+		params.ExcludeProjects = splitCommaList(r.URL.Query().Get("exclude_projects"))
+	} else {
+		params.Project = project
+	}
+
+	page, err := s.memoryStore.Search(r.Context(), params)
+	if err != nil {
+		if errors.Is(err, gorm.ErrInvalidCursor) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		log.Error().Err(err).Str("project", project).Str("scope", scope).Msg("search memories failed")
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	resp := memorySearchResponse{
+		NextCursor: page.NextCursor,
+		Hits:       make([]memorySearchHit, 0, len(page.Hits)),
+	}
+	for _, hit := range page.Hits {
+		resp.Hits = append(resp.Hits, memorySearchHit{Memory: hit.Memory, Snippet: hit.Snippet, Score: hit.Score})
+	}
+	if scope == "all" {
+		resp.Groups = make([]memorySearchGroup, 0)
+		for _, g := range page.GroupByProject() {
+			group := memorySearchGroup{Project: g.Project, BestScore: g.BestScore}
+			for _, hit := range g.Hits {
+				group.Hits = append(group.Hits, memorySearchHit{Memory: hit.Memory, Snippet: hit.Snippet, Score: hit.Score})
+			}
+			resp.Groups = append(resp.Groups, group)
+		}
+	}
+
+	writeJSON(w, resp)
+}
+
+// splitCommaList splits a comma-separated query parameter, dropping blanks.
+func splitCommaList(raw string) []string {
+	var out []string
+	for _, part := range strings.Split(raw, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
+
 // handleDeleteMemoryByID godoc
 // @Summary Delete a memory note by ID
 // @Description Soft-deletes a memory entry by its numeric ID.
@@ -168,3 +308,318 @@ func (s *Service) handleDeleteMemoryByID(w http.ResponseWriter, r *http.Request)
 
 	writeJSON(w, map[string]string{"status": "ok"})
 }
+
+// editMemoryRequest is the JSON body for PUT /api/memories/{id}.
+// Omitted fields keep their current value.
+type editMemoryRequest struct {
+	Content  *string  `json:"content,omitempty"`
+	Tags     []string `json:"tags,omitempty"`
+	EditedBy string   `json:"edited_by,omitempty"`
+}
+
+// mergeMemoryRequest is the JSON body for POST /api/memories/{id}/merge.
+type mergeMemoryRequest struct {
+	Content  string `json:"content,omitempty"`
+	EditedBy string `json:"edited_by,omitempty"`
+	SourceID int64  `json:"source_id"`
+}
+
+// memoryVersionDiff is the response body for GET /api/memories/{id}/versions/diff.
+type memoryVersionDiff struct {
+	ContentDiff string   `json:"content_diff"`
+	EditedBy    string   `json:"edited_by,omitempty"`
+	Note        string   `json:"note,omitempty"`
+	TagsAdded   []string `json:"tags_added"`
+	TagsRemoved []string `json:"tags_removed"`
+	ID          int64    `json:"id"`
+	From        int      `json:"from"`
+	To          int      `json:"to"`
+}
+
+// parseMemoryID reads the {id} URL parameter. Writes a 400 and returns false when invalid.
+func parseMemoryID(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil || id <= 0 {
+		http.Error(w, "invalid memory id", http.StatusBadRequest)
+		return 0, false
+	}
+	return id, true
+}
+
+// writeMemoryStoreError maps a MemoryStore error to an HTTP status.
+func writeMemoryStoreError(w http.ResponseWriter, err error, op string, id int64) {
+	if errors.Is(err, gormlib.ErrRecordNotFound) {
+		http.Error(w, "memory not found", http.StatusNotFound)
+		return
+	}
+	log.Error().Err(err).Int64("id", id).Msg(op + " failed")
+	http.Error(w, "internal server error", http.StatusInternalServerError)
+}
+
+// handleEditMemory godoc
+// @Summary Edit a memory note
+// @Description Replaces content and/or tags of a memory and records the new revision in its version history.
+// @Tags Memories
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "Memory ID"
+// @Param body body editMemoryRequest true "Fields to change"
+// @Success 200 {object} models.Memory
+// @Failure 400 {string} string "bad request"
+// @Failure 404 {string} string "not found"
+// @Failure 503 {string} string "service unavailable"
+// @Router /api/memories/{id} [put]
+func (s *Service) handleEditMemory(w http.ResponseWriter, r *http.Request) {
+	if s.memoryStore == nil {
+		http.Error(w, "memory store not available", http.StatusServiceUnavailable)
+		return
+	}
+	id, ok := parseMemoryID(w, r)
+	if !ok {
+		return
+	}
+
+	var req editMemoryRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid JSON body: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.Content == nil && req.Tags == nil {
+		http.Error(w, "content or tags is required", http.StatusBadRequest)
+		return
+	}
+	if req.Content != nil && *req.Content == "" {
+		http.Error(w, "content must not be empty", http.StatusBadRequest)
+		return
+	}
+
+	current, err := s.memoryStore.Get(r.Context(), id)
+	if err != nil {
+		writeMemoryStoreError(w, err, "edit memory", id)
+		return
+	}
+	next := &models.Memory{
+		ID:          id,
+		Content:     current.Content,
+		Tags:        current.Tags,
+		SourceAgent: current.SourceAgent,
+		EditedBy:    req.EditedBy,
+	}
+	if req.Content != nil {
+		next.Content = *req.Content
+	}
+	if req.Tags != nil {
+		next.Tags = req.Tags
+	}
+	if next.EditedBy == "" {
+		next.EditedBy = "api"
+	}
+
+	updated, err := s.memoryStore.Update(r.Context(), next)
+	if err != nil {
+		writeMemoryStoreError(w, err, "edit memory", id)
+		return
+	}
+	writeJSON(w, updated)
+}
+
+// handleMergeMemory godoc
+// @Summary Merge another memory into this one
+// @Description Unions the tags of source_id into {id}, appends its content (unless content is given) and soft-deletes the source.
+// @Tags Memories
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "Target memory ID"
+// @Param body body mergeMemoryRequest true "Merge source"
+// @Success 200 {object} models.Memory
+// @Failure 400 {string} string "bad request"
+// @Failure 404 {string} string "not found"
+// @Failure 503 {string} string "service unavailable"
+// @Router /api/memories/{id}/merge [post]
+func (s *Service) handleMergeMemory(w http.ResponseWriter, r *http.Request) {
+	if s.memoryStore == nil {
+		http.Error(w, "memory store not available", http.StatusServiceUnavailable)
+		return
+	}
+	targetID, ok := parseMemoryID(w, r)
+	if !ok {
+		return
+	}
+
+	var req mergeMemoryRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid JSON body: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.SourceID <= 0 || req.SourceID == targetID {
+		http.Error(w, "source_id must be a different memory id", http.StatusBadRequest)
+		return
+	}
+	editedBy := req.EditedBy
+	if editedBy == "" {
+		editedBy = "api"
+	}
+
+	merged, err := s.memoryStore.Merge(r.Context(), req.SourceID, targetID, req.Content, editedBy)
+	if err != nil {
+		writeMemoryStoreError(w, err, "merge memory", targetID)
+		return
+	}
+	writeJSON(w, merged)
+}
+
+// handleListMemoryVersions godoc
+// @Summary List the version history of a memory
+// @Description Returns every recorded revision, newest first, with its author (edited_by) and note.
+// @Tags Memories
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "Memory ID"
+// @Success 200 {array} models.MemoryVersion
+// @Failure 400 {string} string "invalid id"
+// @Failure 503 {string} string "service unavailable"
+// @Router /api/memories/{id}/versions [get]
+func (s *Service) handleListMemoryVersions(w http.ResponseWriter, r *http.Request) {
+	if s.memoryStore == nil {
+		http.Error(w, "memory store not available", http.StatusServiceUnavailable)
+		return
+	}
+	id, ok := parseMemoryID(w, r)
+	if !ok {
+		return
+	}
+	versions, err := s.memoryStore.ListVersions(r.Context(), id)
+	if err != nil {
+		writeMemoryStoreError(w, err, "list memory versions", id)
+		return
+	}
+	if versions == nil {
+		versions = []*models.MemoryVersion{}
+	}
+	writeJSON(w, versions)
+}
+
+// handleDiffMemoryVersions godoc
+// @Summary Diff two versions of a memory
+// @Description Line diff of content plus added/removed tags between versions from and to.
+// @Description to defaults to the latest version, from to the one before it.
+// @Tags Memories
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "Memory ID"
+// @Param from query int false "Older version"
+// @Param to query int false "Newer version"
+// @Success 200 {object} memoryVersionDiff
+// @Failure 400 {string} string "bad request"
+// @Failure 404 {string} string "not found"
+// @Router /api/memories/{id}/versions/diff [get]
+func (s *Service) handleDiffMemoryVersions(w http.ResponseWriter, r *http.Request) {
+	if s.memoryStore == nil {
+		http.Error(w, "memory store not available", http.StatusServiceUnavailable)
+		return
+	}
+	id, ok := parseMemoryID(w, r)
+	if !ok {
+		return
+	}
+
+	to, from := 0, 0
+	if raw := r.URL.Query().Get("to"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n <= 0 {
+			http.Error(w, "to must be a positive integer", http.StatusBadRequest)
+			return
+		}
+		to = n
+	}
+	if raw := r.URL.Query().Get("from"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n <= 0 {
+			http.Error(w, "from must be a positive integer", http.StatusBadRequest)
+			return
+		}
+		from = n
+	}
+	if to == 0 {
+		versions, err := s.memoryStore.ListVersions(r.Context(), id)
+		if err != nil {
+			writeMemoryStoreError(w, err, "diff memory versions", id)
+			return
+		}
+		if len(versions) == 0 {
+			http.Error(w, "memory not found", http.StatusNotFound)
+			return
+		}
+		to = versions[0].Version
+	}
+	if from == 0 {
+		from = to - 1
+	}
+	if from <= 0 {
+		http.Error(w, "no earlier version to diff against", http.StatusBadRequest)
+		return
+	}
+
+	a, err := s.memoryStore.GetVersion(r.Context(), id, from)
+	if err != nil {
+		writeMemoryStoreError(w, err, "diff memory versions", id)
+		return
+	}
+	b, err := s.memoryStore.GetVersion(r.Context(), id, to)
+	if err != nil {
+		writeMemoryStoreError(w, err, "diff memory versions", id)
+		return
+	}
+
+	writeJSON(w, memoryVersionDiff{
+		ID:          id,
+		From:        from,
+		To:          to,
+		ContentDiff: strutil.LineDiff(a.Content, b.Content),
+		TagsAdded:   strutil.Difference(b.Tags, a.Tags),
+		TagsRemoved: strutil.Difference(a.Tags, b.Tags),
+		EditedBy:    b.EditedBy,
+		Note:        b.Note,
+	})
+}
+
+// handleRestoreMemoryVersion godoc
+// @Summary Restore an older version of a memory
+// @Description Writes the content and tags of {version} as a new revision. History is never rewritten.
+// @Tags Memories
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "Memory ID"
+// @Param version path int true "Version to restore"
+// @Success 200 {object} models.Memory
+// @Failure 400 {string} string "bad request"
+// @Failure 404 {string} string "not found"
+// @Router /api/memories/{id}/versions/{version}/restore [post]
+func (s *Service) handleRestoreMemoryVersion(w http.ResponseWriter, r *http.Request) {
+	if s.memoryStore == nil {
+		http.Error(w, "memory store not available", http.StatusServiceUnavailable)
+		return
+	}
+	id, ok := parseMemoryID(w, r)
+	if !ok {
+		return
+	}
+	version, err := strconv.Atoi(chi.URLParam(r, "version"))
+	if err != nil || version <= 0 {
+		http.Error(w, "invalid version", http.StatusBadRequest)
+		return
+	}
+	editedBy := r.URL.Query().Get("edited_by")
+	if editedBy == "" {
+		editedBy = "api"
+	}
+
+	restored, err := s.memoryStore.Restore(r.Context(), id, version, editedBy)
+	if err != nil {
+		writeMemoryStoreError(w, err, "restore memory version", id)
+		return
+	}
+	writeJSON(w, restored)
+}
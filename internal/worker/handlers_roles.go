@@ -0,0 +1,233 @@
+package worker
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"regexp"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+
+	authpkg "github.com/thebtf/engram/internal/auth"
+	gormdb "github.com/thebtf/engram/internal/db/gorm"
+)
+
+// roleNamePattern restricts custom role names to what fits users.role and
+// api_tokens.scope and reads well in logs.
+var roleNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9._-]{0,63}$`)
+
+// builtinRoleOrder lists the built-in roles for GET /api/auth/roles, with
+// their descriptions.
+var builtinRoleOrder = []struct {
+	role        authpkg.Role
+	description string
+}{
+	{authpkg.RoleAdmin, "Dashboard administrator: every permission"},
+	{authpkg.RoleOperator, "Dashboard user: everything but administration"},
+	{authpkg.RoleReadWrite, "Keycard: everything but administration"},
+	{authpkg.RoleReadOnly, "Keycard: reads memories, issues, documents and the vault"},
+}
+
+// roleResponse is one role in GET /api/auth/roles.
+type roleResponse struct {
+	Name        string               `json:"name"`
+	Description string               `json:"description"`
+	Permissions []authpkg.Permission `json:"permissions"`
+	Builtin     bool                 `json:"builtin"`
+	UpdatedAt   *time.Time           `json:"updated_at,omitempty"`
+}
+
+// roleRequest is the JSON body for PUT /api/auth/roles/{name}.
+type roleRequest struct {
+	Description string   `json:"description"`
+	Permissions []string `json:"permissions"`
+}
+
+// requireRoleAdmin gates role changes: the caller must hold admin:users and,
+// like keycard issuance, act from a browser session, since a role can grant
+// any permission. Returns true when the caller was rejected and the response
+// written.
+func requireRoleAdmin(w http.ResponseWriter, r *http.Request) bool {
+	id, ok := authpkg.IdentityFrom(r.Context())
+	if !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return true
+	}
+	if !id.Can(authpkg.PermAdminUsers) || id.Source != authpkg.SourceSession {
+		http.Error(w, "forbidden: role management requires admin:users from a browser session", http.StatusForbidden)
+		return true
+	}
+	return false
+}
+
+// roleStoreOrUnavailable returns the role store, or writes 503 and nil
+// before the database is ready.
+func (s *Service) roleStoreOrUnavailable(w http.ResponseWriter) *gormdb.RoleStore {
+	s.initMu.RLock()
+	roleStore := s.roleStore
+	s.initMu.RUnlock()
+
+	if roleStore == nil {
+		http.Error(w, "not ready", http.StatusServiceUnavailable)
+	}
+	return roleStore
+}
+
+// handleListRoles godoc
+// @Summary List roles and permissions
+// @Description Returns the built-in and custom roles with their permissions, and every permission a role can grant.
+// @Tags Auth
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} map[string]interface{}
+// @Failure 500 {string} string "internal error"
+// @Router /api/auth/roles [get]
+func (s *Service) handleListRoles(w http.ResponseWriter, r *http.Request) {
+	roleStore := s.roleStoreOrUnavailable(w)
+	if roleStore == nil {
+		return
+	}
+
+	custom, err := roleStore.ListRoles(r.Context())
+	if err != nil {
+		log.Error().Err(err).Msg("auth: failed to list roles")
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+
+	roles := make([]roleResponse, 0, len(builtinRoleOrder)+len(custom))
+	for _, b := range builtinRoleOrder {
+		roles = append(roles, roleResponse{
+			Name:        string(b.role),
+			Description: b.description,
+			Permissions: authpkg.BuiltinRoles[b.role],
+			Builtin:     true,
+		})
+	}
+	for _, c := range custom {
+		perms := make([]authpkg.Permission, 0, len(c.Permissions))
+		for _, p := range c.Permissions {
+			perms = append(perms, authpkg.Permission(p))
+		}
+		roles = append(roles, roleResponse{
+			Name:        c.Name,
+			Description: c.Description,
+			Permissions: perms,
+			UpdatedAt:   &c.UpdatedAt,
+		})
+	}
+
+	writeJSON(w, map[string]any{
+		"roles":       roles,
+		"permissions": authpkg.AllPermissions,
+	})
+}
+
+// handleSaveRole godoc
+// @Summary Create or update a custom role
+// @Description Sets the description and permissions of a custom role. Built-in role names are reserved. Keycards and users with the role pick up the change on their next request.
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param name path string true "Role name"
+// @Param body body roleRequest true "Role"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {string} string "bad request"
+// @Failure 403 {string} string "forbidden — requires browser session"
+// @Failure 500 {string} string "internal error"
+// @Router /api/auth/roles/{name} [put]
+func (s *Service) handleSaveRole(w http.ResponseWriter, r *http.Request) {
+	if requireRoleAdmin(w, r) {
+		return
+	}
+	roleStore := s.roleStoreOrUnavailable(w)
+	if roleStore == nil {
+		return
+	}
+
+	name := chi.URLParam(r, "name")
+	if !roleNamePattern.MatchString(name) {
+		http.Error(w, "role name must be 1-64 lowercase letters, digits, '.', '_' or '-'", http.StatusBadRequest)
+		return
+	}
+	if authpkg.IsBuiltinRole(name) {
+		http.Error(w, "built-in roles cannot be changed", http.StatusBadRequest)
+		return
+	}
+
+	var req roleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	for _, p := range req.Permissions {
+		if !authpkg.ValidPermission(authpkg.Permission(p)) {
+			http.Error(w, "unknown permission "+p, http.StatusBadRequest)
+			return
+		}
+	}
+
+	role, err := roleStore.SaveRole(r.Context(), gormdb.Role{
+		Name:        name,
+		Description: req.Description,
+		Permissions: req.Permissions,
+	})
+	if err != nil {
+		log.Error().Err(err).Str("role", name).Msg("auth: failed to save role")
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	log.Info().Str("role", name).Strs("permissions", role.Permissions).Msg("auth: role saved")
+
+	writeJSON(w, map[string]any{
+		"name":        role.Name,
+		"description": role.Description,
+		"permissions": role.Permissions,
+		"updated_at":  role.UpdatedAt,
+	})
+}
+
+// handleDeleteRole godoc
+// @Summary Delete a custom role
+// @Description Deletes a custom role that no user or live keycard is assigned.
+// @Tags Auth
+// @Produce json
+// @Security ApiKeyAuth
+// @Param name path string true "Role name"
+// @Success 200 {object} map[string]interface{}
+// @Failure 403 {string} string "forbidden — requires browser session"
+// @Failure 404 {string} string "not found"
+// @Failure 409 {string} string "role is still assigned"
+// @Failure 500 {string} string "internal error"
+// @Router /api/auth/roles/{name} [delete]
+func (s *Service) handleDeleteRole(w http.ResponseWriter, r *http.Request) {
+	if requireRoleAdmin(w, r) {
+		return
+	}
+	roleStore := s.roleStoreOrUnavailable(w)
+	if roleStore == nil {
+		return
+	}
+
+	name := chi.URLParam(r, "name")
+	if err := roleStore.DeleteRole(r.Context(), name); err != nil {
+		switch {
+		case errors.Is(err, gormdb.ErrRoleInUse):
+			http.Error(w, err.Error(), http.StatusConflict)
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			http.Error(w, "not found", http.StatusNotFound)
+		default:
+			log.Error().Err(err).Str("role", name).Msg("auth: failed to delete role")
+			http.Error(w, "internal error", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	writeJSON(w, map[string]any{
+		"deleted": true,
+	})
+}
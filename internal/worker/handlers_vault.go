@@ -2,28 +2,67 @@
 package worker
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/rs/zerolog/log"
 	gormlib "gorm.io/gorm"
 
+	authpkg "github.com/thebtf/engram/internal/auth"
 	"github.com/thebtf/engram/internal/config"
 	"github.com/thebtf/engram/internal/crypto"
+	gormdb "github.com/thebtf/engram/internal/db/gorm"
 	"github.com/thebtf/engram/pkg/models"
 )
 
 // storeCredentialRequest is the JSON body for POST /api/vault/credentials.
 type storeCredentialRequest struct {
-	Name    string   `json:"name"`
-	Value   string   `json:"value"`
-	Scope   string   `json:"scope"`
-	Project string   `json:"project"`
-	Tags    []string `json:"tags,omitempty"`
+	Name            string   `json:"name"`
+	Value           string   `json:"value"`
+	Scope           string   `json:"scope"`
+	Project         string   `json:"project"`
+	Access          string   `json:"access,omitempty"`
+	Tags            []string `json:"tags,omitempty"`
+	AllowedProjects []string `json:"allowed_projects,omitempty"`
+	AllowedKeycards []string `json:"allowed_keycards,omitempty"`
+}
+
+// credentialAccessor describes the caller of a vault request for policy checks
+// and the access log. The caller's project comes from the X-Engram-Project
+// header, not from ?project=, which names the credential's project. Without an
+// identity on the context authentication is disabled and the caller is
+// treated as an admin.
+func credentialAccessor(r *http.Request) gormdb.CredentialAccessor {
+	a := gormdb.CredentialAccessor{
+		Project:   r.Header.Get("X-Engram-Project"),
+		SessionID: r.URL.Query().Get("session_id"),
+		Source:    gormdb.CredentialAccessSourceREST,
+	}
+	id, ok := authpkg.IdentityFrom(r.Context())
+	if !ok {
+		a.Admin = true
+		return a
+	}
+	a.KeycardID = id.KeycardID
+	a.Role = string(id.Role)
+	a.Admin = id.Can(authpkg.PermVaultAdmin)
+	return a
+}
+
+// requireVaultAdmin rejects non-admin callers of the vault policy and audit
+// endpoints. Returns true when the caller was rejected and the response written.
+func requireVaultAdmin(w http.ResponseWriter, r *http.Request) bool {
+	if !credentialAccessor(r).Admin {
+		http.Error(w, "forbidden: vault administration requires vault:admin", http.StatusForbidden)
+		return true
+	}
+	return false
 }
 
 // handleListCredentials godoc
@@ -45,11 +84,26 @@ func (s *Service) handleListCredentials(w http.ResponseWriter, r *http.Request)
 	project := r.URL.Query().Get("project")
 
 	type credItem struct {
-		Name      string `json:"name"`
-		Scope     string `json:"scope"`
-		Project   string `json:"project,omitempty"`
-		CreatedAt string `json:"created_at,omitempty"`
-		ID        int64  `json:"id"`
+		Name            string   `json:"name"`
+		Scope           string   `json:"scope"`
+		Project         string   `json:"project,omitempty"`
+		CreatedAt       string   `json:"created_at,omitempty"`
+		Access          string   `json:"access"`
+		AllowedProjects []string `json:"allowed_projects"`
+		AllowedKeycards []string `json:"allowed_keycards"`
+		ID              int64    `json:"id"`
+	}
+	toItem := func(c *models.Credential) credItem {
+		return credItem{
+			ID:              c.ID,
+			Name:            c.Key,
+			Scope:           c.Scope,
+			Project:         c.Project,
+			CreatedAt:       c.CreatedAt.Format(time.RFC3339),
+			Access:          c.Access,
+			AllowedProjects: append([]string{}, c.AllowedProjects...),
+			AllowedKeycards: append([]string{}, c.AllowedKeycards...),
+		}
 	}
 
 	// When no project specified, list ALL credentials (dashboard admin view).
@@ -62,13 +116,7 @@ func (s *Service) handleListCredentials(w http.ResponseWriter, r *http.Request)
 		}
 		items := make([]credItem, 0, len(allCreds))
 		for _, c := range allCreds {
-			items = append(items, credItem{
-				Name:      c.Key,
-				Scope:     c.Scope,
-				Project:   c.Project,
-				ID:        c.ID,
-				CreatedAt: c.CreatedAt.Format(time.RFC3339),
-			})
+			items = append(items, toItem(c))
 		}
 		writeJSON(w, items)
 		return
@@ -83,13 +131,7 @@ func (s *Service) handleListCredentials(w http.ResponseWriter, r *http.Request)
 
 	items := make([]credItem, 0, len(creds))
 	for _, c := range creds {
-		items = append(items, credItem{
-			ID:        c.ID,
-			Name:      c.Key,
-			Scope:     c.Scope,
-			Project:   c.Project,
-			CreatedAt: c.CreatedAt.Format(time.RFC3339),
-		})
+		items = append(items, toItem(c))
 	}
 
 	writeJSON(w, items)
@@ -98,13 +140,17 @@ func (s *Service) handleListCredentials(w http.ResponseWriter, r *http.Request)
 // handleGetCredential godoc
 // @Summary Get credential with decrypted value
 // @Description Retrieves and decrypts a credential by name. Verifies key fingerprint before decryption.
+// @Description The credential's access policy is checked against the caller (keycard, X-Engram-Project
+// @Description header) and every attempt, allowed or denied, is recorded in the credential access log.
 // @Tags Vault
 // @Produce json
 // @Security ApiKeyAuth
 // @Param name path string true "Credential name"
-// @Param project query string false "Project scope"
+// @Param project query string false "Project scope (required for non-admin callers)"
+// @Param session_id query string false "Caller session ID recorded in the access log"
 // @Success 200 {object} object
 // @Failure 400 {string} string "bad request"
+// @Failure 403 {string} string "access denied by credential policy"
 // @Failure 404 {string} string "not found"
 // @Failure 500 {string} string "internal error"
 // @Router /api/vault/credentials/{name} [get]
@@ -120,8 +166,13 @@ func (s *Service) handleGetCredential(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	project := r.URL.Query().Get("project")
+	accessor := credentialAccessor(r)
+	if project == "" && !accessor.Admin {
+		http.Error(w, "project is required", http.StatusBadRequest)
+		return
+	}
 
-	v, err := s.getVault()
+	kr, err := s.getKeyring()
 	if err != nil {
 		log.Error().Err(err).Msg("vault not available")
 		http.Error(w, "vault not available", http.StatusInternalServerError)
@@ -145,6 +196,28 @@ func (s *Service) handleGetCredential(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if err := s.credentialStore.AuthorizeReveal(r.Context(), cred, accessor); err != nil {
+		if errors.Is(err, gormdb.ErrCredentialAccessDenied) {
+			log.Warn().Str("project", cred.Project).Str("name", name).Str("keycard_id", accessor.KeycardID).Err(err).Msg("credential read denied")
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
+		log.Error().Err(err).Str("name", name).Msg("credential access log failed")
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	v, err := kr.ForProject(r.Context(), cred.Project)
+	if err != nil {
+		if errors.Is(err, crypto.ErrDataKeyKEKMismatch) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		log.Error().Err(err).Str("project", cred.Project).Msg("load project data key failed")
+		http.Error(w, "project data key not available", http.StatusInternalServerError)
+		return
+	}
+
 	// Verify key fingerprint before decryption to detect key mismatch early.
 	if cred.EncryptionKeyFingerprint != "" {
 		if !v.MatchesFingerprint(cred.EncryptionKeyFingerprint) {
@@ -223,13 +296,28 @@ func (s *Service) handleStoreCredential(w http.ResponseWriter, r *http.Request)
 		http.Error(w, "project is required for project-scoped credentials", http.StatusBadRequest)
 		return
 	}
+	policy := gormdb.CredentialPolicy{Access: req.Access, AllowedProjects: req.AllowedProjects, AllowedKeycards: req.AllowedKeycards}
+	if err := policy.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 
-	v, err := s.getVault()
+	kr, err := s.getKeyring()
 	if err != nil {
 		log.Error().Err(err).Msg("vault not available")
 		http.Error(w, "vault not available", http.StatusInternalServerError)
 		return
 	}
+	v, err := kr.ForProject(r.Context(), req.Project)
+	if err != nil {
+		if errors.Is(err, crypto.ErrDataKeyKEKMismatch) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		log.Error().Err(err).Str("project", req.Project).Msg("load project data key failed")
+		http.Error(w, "project data key not available", http.StatusInternalServerError)
+		return
+	}
 
 	ciphertext, err := v.Encrypt(req.Value)
 	if err != nil {
@@ -245,6 +333,9 @@ func (s *Service) handleStoreCredential(w http.ResponseWriter, r *http.Request)
 		EncryptionKeyFingerprint: v.Fingerprint(),
 		Scope:                    req.Scope,
 		EditedBy:                 "api",
+		Access:                   policy.Access,
+		AllowedProjects:          policy.AllowedProjects,
+		AllowedKeycards:          policy.AllowedKeycards,
 	}
 	created, err := s.credentialStore.Create(r.Context(), cred)
 	if err != nil {
@@ -262,6 +353,397 @@ func (s *Service) handleStoreCredential(w http.ResponseWriter, r *http.Request)
 	})
 }
 
+// handleSetCredentialPolicy godoc
+// @Summary Set a credential's access policy
+// @Description Replaces the access policy of a credential: access ("reveal" or "read-only"), allowed_projects
+// @Description and allowed_keycards. Empty lists leave that dimension unrestricted. Admin only.
+// @Tags Vault
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param name path string true "Credential name"
+// @Param project query string true "Credential project"
+// @Param body body gormdb.CredentialPolicy true "Access policy"
+// @Success 200 {object} object
+// @Failure 400 {string} string "bad request"
+// @Failure 403 {string} string "forbidden"
+// @Failure 404 {string} string "not found"
+// @Failure 500 {string} string "internal error"
+// @Router /api/vault/credentials/{name}/policy [patch]
+func (s *Service) handleSetCredentialPolicy(w http.ResponseWriter, r *http.Request) {
+	if s.credentialStore == nil {
+		http.Error(w, "credential store not available", http.StatusServiceUnavailable)
+		return
+	}
+	if requireVaultAdmin(w, r) {
+		return
+	}
+
+	name := chi.URLParam(r, "name")
+	project := r.URL.Query().Get("project")
+	if name == "" || project == "" {
+		http.Error(w, "credential name and project are required", http.StatusBadRequest)
+		return
+	}
+
+	var policy gormdb.CredentialPolicy
+	if err := json.NewDecoder(r.Body).Decode(&policy); err != nil {
+		http.Error(w, "invalid JSON body: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := policy.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	cred, err := s.credentialStore.SetPolicy(r.Context(), project, name, policy)
+	if err != nil {
+		if errors.Is(err, gormlib.ErrRecordNotFound) {
+			http.Error(w, "credential not found", http.StatusNotFound)
+			return
+		}
+		log.Error().Err(err).Str("name", name).Msg("set credential policy failed")
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, map[string]any{
+		"name":             cred.Key,
+		"project":          cred.Project,
+		"access":           cred.Access,
+		"allowed_projects": cred.AllowedProjects,
+		"allowed_keycards": cred.AllowedKeycards,
+	})
+}
+
+// handleCredentialAccessLog godoc
+// @Summary List credential access log
+// @Description Returns vault decrypt attempts, newest first: who (keycard, role, caller project, session)
+// @Description read which credential, through which transport, and whether the policy allowed it. Admin only.
+// @Tags Vault
+// @Produce json
+// @Security ApiKeyAuth
+// @Param project query string false "Credential project"
+// @Param name query string false "Credential name"
+// @Param keycard_id query string false "Keycard ID"
+// @Param since query string false "RFC 3339 timestamp or Go duration such as 168h"
+// @Param denied query bool false "Only denied attempts"
+// @Param limit query int false "Max entries (default 100, max 1000)"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {string} string "bad request"
+// @Failure 403 {string} string "forbidden"
+// @Failure 500 {string} string "internal error"
+// @Router /api/vault/access-log [get]
+func (s *Service) handleCredentialAccessLog(w http.ResponseWriter, r *http.Request) {
+	if s.credentialStore == nil {
+		http.Error(w, "credential store not available", http.StatusServiceUnavailable)
+		return
+	}
+	if requireVaultAdmin(w, r) {
+		return
+	}
+
+	q := r.URL.Query()
+	filter := gormdb.CredentialAccessFilter{
+		Project:    q.Get("project"),
+		Key:        q.Get("name"),
+		KeycardID:  q.Get("keycard_id"),
+		DeniedOnly: q.Get("denied") == "true",
+	}
+	if v := q.Get("since"); v != "" {
+		if t, err := time.Parse(time.RFC3339, v); err == nil {
+			filter.Since = t
+		} else if d, err := time.ParseDuration(v); err == nil && d > 0 {
+			filter.Since = time.Now().Add(-d)
+		} else {
+			http.Error(w, "invalid since: want an RFC 3339 timestamp or a duration such as 168h", http.StatusBadRequest)
+			return
+		}
+	}
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			http.Error(w, "invalid limit", http.StatusBadRequest)
+			return
+		}
+		filter.Limit = n
+	}
+
+	entries, err := s.credentialStore.ListAccessLog(r.Context(), filter)
+	if err != nil {
+		log.Error().Err(err).Msg("list credential access log failed")
+		http.Error(w, "failed to list credential access log", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, map[string]any{"entries": entries})
+}
+
+// rotateVaultKeyRequest is the JSON body for POST /api/vault/rotate.
+type rotateVaultKeyRequest struct {
+	NewKey    string `json:"new_key"`
+	OldKey    string `json:"old_key,omitempty"`
+	BatchSize int    `json:"batch_size,omitempty"`
+	DryRun    bool   `json:"dry_run,omitempty"`
+}
+
+// handleRotateVaultKey godoc
+// @Summary Rotate the vault master key
+// @Description Replaces the master key (default old key: the server's current one) with new_key. When the master
+// @Description key is the KEK (ENGRAM_VAULT_KEK=master, the default), every project data key it wraps is rewrapped
+// @Description with new_key; credentials under data keys are not touched. Credentials still encrypted directly
+// @Description with the old master key, from before envelope encryption, are re-encrypted with their project's
+// @Description data key, batch_size rows per transaction. Everything is first verified to open with the old key;
+// @Description with dry_run only that check runs. An interrupted rotation is resumed by sending the same request
+// @Description again. An auto-generated key file is replaced (the old one is kept as a .bak); a key from
+// @Description ENGRAM_ENCRYPTION_KEY or ENGRAM_ENCRYPTION_KEY_FILE must be updated before restarting. Admin only.
+// @Tags Vault
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param body body rotateVaultKeyRequest true "Keys as 64 hex chars"
+// @Success 200 {object} object "rotation (gormdb.CredentialRotation) and data_keys (crypto.DataKeyRewrap)"
+// @Failure 400 {string} string "bad request"
+// @Failure 403 {string} string "forbidden"
+// @Failure 409 {string} string "rotation already running"
+// @Failure 422 {object} object "credentials or data keys failed verification"
+// @Failure 500 {string} string "internal error"
+// @Router /api/vault/rotate [post]
+func (s *Service) handleRotateVaultKey(w http.ResponseWriter, r *http.Request) {
+	if s.credentialStore == nil || s.credentialDataKeys == nil {
+		http.Error(w, "credential store not available", http.StatusServiceUnavailable)
+		return
+	}
+	if requireVaultAdmin(w, r) {
+		return
+	}
+
+	var req rotateVaultKeyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid JSON body: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	newKey, err := crypto.ParseKey(req.NewKey)
+	if err != nil {
+		http.Error(w, "invalid new_key: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	next, err := crypto.NewVaultFromKey(newKey, "rotated")
+	if err != nil {
+		http.Error(w, "invalid new_key: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	current, err := s.getVault()
+	if err != nil {
+		log.Error().Err(err).Msg("vault not available")
+		http.Error(w, "vault not available", http.StatusInternalServerError)
+		return
+	}
+	kr, err := s.getKeyring()
+	if err != nil {
+		log.Error().Err(err).Msg("vault keyring not available")
+		http.Error(w, "vault not available", http.StatusInternalServerError)
+		return
+	}
+	old := current
+	if req.OldKey != "" {
+		oldKey, err := crypto.ParseKey(req.OldKey)
+		if err != nil {
+			http.Error(w, "invalid old_key: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		if old, err = crypto.NewVaultFromKey(oldKey, "rotation"); err != nil {
+			http.Error(w, "invalid old_key: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	if old.Fingerprint() == next.Fingerprint() {
+		http.Error(w, fmt.Sprintf("old and new keys are the same (fingerprint %s)", next.Fingerprint()), http.StatusBadRequest)
+		return
+	}
+
+	if !s.vaultRotateMu.TryLock() {
+		http.Error(w, "a vault key rotation is already running", http.StatusConflict)
+		return
+	}
+	defer s.vaultRotateMu.Unlock()
+
+	// When the master key is the KEK, the data keys it wraps move to the new
+	// key. The transitional keyring wraps new data keys with the new key and
+	// still unwraps those under the old one, so readers are served throughout.
+	masterIsKEK := kr.KEK().ID() == crypto.NewMasterKEK(current).ID()
+	oldKEK, nextKEK := crypto.NewMasterKEK(old), crypto.NewMasterKEK(next)
+	transitional := current.WithDecryptKeys(old, next)
+	ring := crypto.NewKeyring(kr.KEK(), s.credentialDataKeys, transitional)
+	if masterIsKEK {
+		ring = crypto.NewKeyring(nextKEK, s.credentialDataKeys, transitional, oldKEK, kr.KEK())
+	}
+	if !req.DryRun {
+		s.setVault(transitional, ring)
+	}
+
+	resp := map[string]any{}
+	writeFailure := func(err error, status int) {
+		log.Error().Err(err).Str("from", old.Fingerprint()).Str("to", next.Fingerprint()).Msg("vault key rotation failed")
+		resp["error"] = err.Error()
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		_ = json.NewEncoder(w).Encode(resp)
+	}
+
+	if masterIsKEK {
+		rewrap, err := ring.Rewrap(r.Context(), oldKEK, req.DryRun)
+		if rewrap != nil {
+			resp["data_keys"] = rewrap
+		}
+		if err != nil {
+			status := http.StatusInternalServerError
+			if errors.Is(err, crypto.ErrRewrapVerifyFailed) {
+				status = http.StatusUnprocessableEntity
+			}
+			writeFailure(err, status)
+			return
+		}
+	}
+
+	dataKeys := func(ctx context.Context, project string) (gormdb.CredentialCipher, error) {
+		v, err := ring.ForProject(ctx, project)
+		if err != nil {
+			return nil, err
+		}
+		return v, nil
+	}
+	result, err := s.credentialStore.MoveToDataKeys(r.Context(), old, dataKeys, req.BatchSize, req.DryRun)
+	if result != nil {
+		resp["rotation"] = result
+	}
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, gormdb.ErrRotationVerifyFailed) {
+			status = http.StatusUnprocessableEntity
+		}
+		writeFailure(err, status)
+		return
+	}
+	if req.DryRun {
+		writeJSON(w, resp)
+		return
+	}
+
+	// The keyring is rebuilt from the new vault and the configured KEK.
+	s.setVault(next.WithDecryptKeys(current, old), nil)
+	resp["active_fingerprint"] = next.Fingerprint()
+	log.Info().
+		Str("from", old.Fingerprint()).
+		Str("to", next.Fingerprint()).
+		Int("credentials_moved", result.Rotated).
+		Bool("master_is_kek", masterIsKEK).
+		Msg("vault key rotated")
+
+	if current.KeySource() == "auto_generated" && current.KeyFile() != "" && current.Fingerprint() == old.Fingerprint() {
+		backup, err := crypto.ReplaceKeyFile(current.KeyFile(), next)
+		if err != nil {
+			log.Error().Err(err).Str("file", current.KeyFile()).Msg("vault key rotation: replace key file failed")
+			resp["action_required"] = fmt.Sprintf("could not write the new key to %s (%v): write it there yourself before restarting", current.KeyFile(), err)
+		} else {
+			resp["key_file"] = current.KeyFile()
+			resp["key_file_backup"] = backup
+		}
+	} else {
+		resp["action_required"] = "set ENGRAM_ENCRYPTION_KEY (or the file named by ENGRAM_ENCRYPTION_KEY_FILE) to the new key before restarting; until then this server decrypts with both keys"
+	}
+	writeJSON(w, resp)
+}
+
+// rewrapDataKeysRequest is the JSON body for POST /api/vault/data-keys/rewrap.
+type rewrapDataKeysRequest struct {
+	Previous crypto.KEKSpec `json:"previous"`
+	DryRun   bool           `json:"dry_run,omitempty"`
+}
+
+// handleRewrapDataKeys godoc
+// @Summary Rewrap project data keys under the configured KEK
+// @Description Unwraps every project data key wrapped by the previous KEK and wraps it with the KEK the server is
+// @Description configured with (ENGRAM_VAULT_KEK). Use it after switching KEK provider or KEK material and
+// @Description restarting: until then credentials of projects whose data key is under the previous KEK cannot be
+// @Description read. Credentials are not touched. Every key is first verified to unwrap; with dry_run only that
+// @Description check runs. Repeating the request finishes an interrupted rewrap. Admin only.
+// @Tags Vault
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param body body rewrapDataKeysRequest true "The previous KEK"
+// @Success 200 {object} crypto.DataKeyRewrap
+// @Failure 400 {string} string "bad request"
+// @Failure 403 {string} string "forbidden"
+// @Failure 409 {string} string "rotation already running"
+// @Failure 422 {object} crypto.DataKeyRewrap "data keys failed verification"
+// @Failure 500 {string} string "internal error"
+// @Router /api/vault/data-keys/rewrap [post]
+func (s *Service) handleRewrapDataKeys(w http.ResponseWriter, r *http.Request) {
+	if s.credentialDataKeys == nil {
+		http.Error(w, "credential store not available", http.StatusServiceUnavailable)
+		return
+	}
+	if requireVaultAdmin(w, r) {
+		return
+	}
+
+	var req rewrapDataKeysRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid JSON body: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	current, err := s.getVault()
+	if err != nil {
+		log.Error().Err(err).Msg("vault not available")
+		http.Error(w, "vault not available", http.StatusInternalServerError)
+		return
+	}
+	kr, err := s.getKeyring()
+	if err != nil {
+		log.Error().Err(err).Msg("vault keyring not available")
+		http.Error(w, "vault not available", http.StatusInternalServerError)
+		return
+	}
+	previous, err := crypto.OpenKEK(req.Previous, current)
+	if err != nil {
+		http.Error(w, "invalid previous KEK: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if previous.ID() == kr.KEK().ID() {
+		http.Error(w, fmt.Sprintf("previous KEK is the configured one (%s)", previous.ID()), http.StatusBadRequest)
+		return
+	}
+
+	if !s.vaultRotateMu.TryLock() {
+		http.Error(w, "a vault key rotation is already running", http.StatusConflict)
+		return
+	}
+	defer s.vaultRotateMu.Unlock()
+
+	result, err := kr.Rewrap(r.Context(), previous, req.DryRun)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, crypto.ErrRewrapVerifyFailed) {
+			status = http.StatusUnprocessableEntity
+		}
+		log.Error().Err(err).Str("from", previous.ID()).Str("to", kr.KEK().ID()).Msg("data key rewrap failed")
+		resp := map[string]any{"error": err.Error()}
+		if result != nil {
+			resp["data_keys"] = result
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		_ = json.NewEncoder(w).Encode(resp)
+		return
+	}
+	if !req.DryRun {
+		log.Info().Str("from", result.FromKEK).Str("to", result.ToKEK).Int("rewrapped", result.Rewrapped).Msg("data keys rewrapped")
+	}
+	writeJSON(w, result)
+}
+
 // handleDeleteCredential godoc
 // @Summary Delete a credential
 // @Description Removes a credential by name and optional project/scope filter.
@@ -315,7 +797,7 @@ func (s *Service) handleDeleteCredential(w http.ResponseWriter, r *http.Request)
 
 // handleDeleteOrphanedCredentials godoc
 // @Summary Delete orphaned credentials
-// @Description Removes credentials encrypted with a key that no longer matches the current vault key fingerprint.
+// @Description Removes credentials encrypted with a key that is neither the current vault key, one of its decrypt-only keys, nor a project data key.
 // @Tags Vault
 // @Produce json
 // @Security ApiKeyAuth
@@ -341,7 +823,20 @@ func (s *Service) handleDeleteOrphanedCredentials(w http.ResponseWriter, r *http
 		return
 	}
 
-	deleted, err := s.credentialStore.DeleteOrphanedByFingerprint(r.Context(), fingerprint)
+	// Credentials under a project data key are not orphaned, nor are those
+	// under a decrypt-only key.
+	kr, err := s.getKeyring()
+	if err != nil {
+		http.Error(w, "vault not configured", http.StatusServiceUnavailable)
+		return
+	}
+	known, err := kr.Fingerprints(r.Context())
+	if err != nil {
+		http.Error(w, "failed to list vault keys", http.StatusInternalServerError)
+		return
+	}
+
+	deleted, err := s.credentialStore.DeleteOrphanedByFingerprint(r.Context(), fingerprint, known...)
 	if err != nil {
 		http.Error(w, "failed to delete orphaned credentials", http.StatusInternalServerError)
 		return
@@ -385,11 +880,18 @@ func (s *Service) handleVaultStatus(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	// Check for fingerprint mismatch: credentials encrypted with a different key.
+	// Check for fingerprint mismatch: credentials encrypted with a key that is
+	// neither the master key nor a project data key.
 	mismatchCount := 0
-	if fingerprint != "" && s.credentialStore != nil {
-		if n, err := s.credentialStore.CountWithDifferentFingerprint(r.Context(), fingerprint); err == nil {
-			mismatchCount = int(n)
+	var kr *crypto.Keyring
+	if fingerprint != "" {
+		kr, _ = s.getKeyring()
+	}
+	if kr != nil && s.credentialStore != nil {
+		if known, err := kr.Fingerprints(r.Context()); err == nil {
+			if n, err := s.credentialStore.CountWithDifferentFingerprint(r.Context(), fingerprint, known...); err == nil {
+				mismatchCount = int(n)
+			}
 		}
 	}
 
@@ -400,6 +902,29 @@ func (s *Service) handleVaultStatus(w http.ResponseWriter, r *http.Request) {
 		"credential_count": count,
 		"backup_reminder":  "Back up vault.key (or set ENGRAM_VAULT_KEY) — losing this key makes stored credentials unrecoverable",
 	}
+	if fingerprint != "" {
+		if v, err := s.getVault(); err == nil && v != nil {
+			if fps := v.DecryptOnlyFingerprints(); len(fps) > 0 {
+				resp["decrypt_only_fingerprints"] = fps
+			}
+		}
+	}
+	if kr != nil {
+		resp["kek_id"] = kr.KEK().ID()
+		if keys, err := kr.DataKeys(r.Context()); err == nil {
+			otherKEK := 0
+			for _, k := range keys {
+				if k.KEKID != kr.KEK().ID() {
+					otherKEK++
+				}
+			}
+			resp["data_key_count"] = len(keys)
+			if otherKEK > 0 {
+				resp["data_keys_other_kek"] = otherKEK
+				resp["data_key_warning"] = fmt.Sprintf("%d project data key(s) are wrapped by another KEK — rewrap them with POST /api/vault/data-keys/rewrap", otherKEK)
+			}
+		}
+	}
 	if mismatchCount > 0 {
 		resp["mismatch_warning"] = fmt.Sprintf("%d credential(s) encrypted with a different key — they cannot be decrypted with the current key", mismatchCount)
 		resp["mismatch_count"] = mismatchCount
@@ -0,0 +1,227 @@
+package worker
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rs/zerolog/log"
+
+	gormdb "github.com/thebtf/engram/internal/db/gorm"
+)
+
+// webhookErrorStatus maps a WebhookStore error to an HTTP status.
+func webhookErrorStatus(err error) int {
+	switch msg := err.Error(); {
+	case strings.Contains(msg, "not found"):
+		return http.StatusNotFound
+	case strings.Contains(msg, "already exists"):
+		return http.StatusConflict
+	default:
+		return http.StatusBadRequest
+	}
+}
+
+// handleListWebhooks handles GET /api/webhooks.
+func (s *Service) handleListWebhooks(w http.ResponseWriter, r *http.Request) {
+	if s.webhookStore == nil {
+		http.Error(w, `{"error": "webhooks not available"}`, http.StatusServiceUnavailable)
+		return
+	}
+
+	webhooks, err := s.webhookStore.ListWebhooks(r.Context())
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error": %q}`, err.Error()), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"webhooks":    webhooks,
+		"event_types": gormdb.WebhookEventTypes,
+	})
+}
+
+// handleCreateWebhook handles POST /api/webhooks. The response carries the
+// signing secret; it is not returned by any other endpoint.
+func (s *Service) handleCreateWebhook(w http.ResponseWriter, r *http.Request) {
+	if s.webhookStore == nil {
+		http.Error(w, `{"error": "webhooks not available"}`, http.StatusServiceUnavailable)
+		return
+	}
+
+	var req struct {
+		Name      string   `json:"name"`
+		URL       string   `json:"url"`
+		Secret    string   `json:"secret"`
+		Project   string   `json:"project"`
+		CreatedBy string   `json:"created_by"`
+		Events    []string `json:"events"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, `{"error": "invalid JSON body"}`, http.StatusBadRequest)
+		return
+	}
+
+	created, err := s.webhookStore.CreateWebhook(r.Context(), &gormdb.Webhook{
+		Name:      req.Name,
+		URL:       strings.TrimSpace(req.URL),
+		Secret:    req.Secret,
+		Project:   strings.TrimSpace(req.Project),
+		CreatedBy: req.CreatedBy,
+		Events:    req.Events,
+	})
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error": %q}`, err.Error()), webhookErrorStatus(err))
+		return
+	}
+
+	log.Info().
+		Int64("webhook_id", created.ID).
+		Str("name", created.Name).
+		Strs("events", created.Events).
+		Msg("Webhook created")
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]any{
+		"webhook": created,
+		"secret":  created.Secret,
+	})
+}
+
+// handleUpdateWebhook handles PATCH /api/webhooks/{id}.
+func (s *Service) handleUpdateWebhook(w http.ResponseWriter, r *http.Request) {
+	if s.webhookStore == nil {
+		http.Error(w, `{"error": "webhooks not available"}`, http.StatusServiceUnavailable)
+		return
+	}
+
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, `{"error": "invalid webhook id"}`, http.StatusBadRequest)
+		return
+	}
+
+	var req struct {
+		URL     *string  `json:"url"`
+		Project *string  `json:"project"`
+		Enabled *bool    `json:"enabled"`
+		Events  []string `json:"events"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, `{"error": "invalid JSON body"}`, http.StatusBadRequest)
+		return
+	}
+
+	updated, err := s.webhookStore.UpdateWebhook(r.Context(), id, gormdb.WebhookUpdate{
+		URL:     req.URL,
+		Project: req.Project,
+		Enabled: req.Enabled,
+		Events:  req.Events,
+	})
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error": %q}`, err.Error()), webhookErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(updated)
+}
+
+// handleDeleteWebhook handles DELETE /api/webhooks/{id}.
+func (s *Service) handleDeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	if s.webhookStore == nil {
+		http.Error(w, `{"error": "webhooks not available"}`, http.StatusServiceUnavailable)
+		return
+	}
+
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, `{"error": "invalid webhook id"}`, http.StatusBadRequest)
+		return
+	}
+
+	if err := s.webhookStore.DeleteWebhook(r.Context(), id); err != nil {
+		http.Error(w, fmt.Sprintf(`{"error": %q}`, err.Error()), webhookErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"message": "webhook deleted",
+	})
+}
+
+// handleListWebhookDeliveries handles GET /api/webhooks/deliveries with
+// optional webhook_id, status and limit filters.
+func (s *Service) handleListWebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	if s.webhookStore == nil {
+		http.Error(w, `{"error": "webhooks not available"}`, http.StatusServiceUnavailable)
+		return
+	}
+
+	q := r.URL.Query()
+	params := gormdb.WebhookDeliveryListParams{Status: q.Get("status")}
+	switch params.Status {
+	case "", gormdb.WebhookDeliveryPending, gormdb.WebhookDeliveryDelivered, gormdb.WebhookDeliveryFailed:
+	default:
+		http.Error(w, `{"error": "status must be pending, delivered or failed"}`, http.StatusBadRequest)
+		return
+	}
+	if v := q.Get("webhook_id"); v != "" {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			http.Error(w, `{"error": "invalid webhook_id"}`, http.StatusBadRequest)
+			return
+		}
+		params.WebhookID = id
+	}
+	if v := q.Get("limit"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			params.Limit = n
+		}
+	}
+
+	deliveries, err := s.webhookStore.ListWebhookDeliveries(r.Context(), params)
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error": %q}`, err.Error()), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"deliveries": deliveries,
+	})
+}
+
+// handleRetryWebhookDelivery handles POST /api/webhooks/deliveries/{id}/retry.
+func (s *Service) handleRetryWebhookDelivery(w http.ResponseWriter, r *http.Request) {
+	if s.webhookStore == nil {
+		http.Error(w, `{"error": "webhooks not available"}`, http.StatusServiceUnavailable)
+		return
+	}
+
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, `{"error": "invalid delivery id"}`, http.StatusBadRequest)
+		return
+	}
+
+	if err := s.webhookStore.RetryWebhookDelivery(r.Context(), id); err != nil {
+		status := webhookErrorStatus(err)
+		if status == http.StatusBadRequest {
+			status = http.StatusConflict
+		}
+		http.Error(w, fmt.Sprintf(`{"error": %q}`, err.Error()), status)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"message": "delivery queued for retry",
+	})
+}
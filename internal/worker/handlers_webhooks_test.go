@@ -0,0 +1,67 @@
+package worker
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+
+	gormdb "github.com/thebtf/engram/internal/db/gorm"
+)
+
+func TestWebhookEndpoints_Validation(t *testing.T) {
+	t.Parallel()
+
+	// Validation fails before the database is used.
+	service := &Service{webhookStore: gormdb.NewWebhookStore(nil)}
+	r := chi.NewRouter()
+	r.Post("/api/webhooks", service.handleCreateWebhook)
+	r.Get("/api/webhooks/deliveries", service.handleListWebhookDeliveries)
+	r.Post("/api/webhooks/deliveries/{id}/retry", service.handleRetryWebhookDelivery)
+	r.Patch("/api/webhooks/{id}", service.handleUpdateWebhook)
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		want   string
+	}{
+		{"invalid body", http.MethodPost, "/api/webhooks", `{`, "invalid JSON body"},
+		{"missing name", http.MethodPost, "/api/webhooks", `{"url":"https://example.com","events":["issue.created"]}`, "name is required"},
+		{"bad url", http.MethodPost, "/api/webhooks", `{"name":"ci","url":"ftp://example.com","events":["issue.created"]}`, "http"},
+		{"unknown event", http.MethodPost, "/api/webhooks", `{"name":"ci","url":"https://example.com","events":["issue.deleted"]}`, "issue.deleted"},
+		{"invalid webhook id", http.MethodPatch, "/api/webhooks/abc", `{}`, "invalid webhook id"},
+		{"invalid status filter", http.MethodGet, "/api/webhooks/deliveries?status=lost", ``, "status must be"},
+		{"invalid webhook_id filter", http.MethodGet, "/api/webhooks/deliveries?webhook_id=x", ``, "invalid webhook_id"},
+		{"invalid delivery id", http.MethodPost, "/api/webhooks/deliveries/abc/retry", ``, "invalid delivery id"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body)))
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+			assert.Contains(t, w.Body.String(), tt.want)
+		})
+	}
+}
+
+func TestWebhookErrorStatus(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, http.StatusNotFound, webhookErrorStatus(errors.New("webhook 7 not found")))
+	assert.Equal(t, http.StatusConflict, webhookErrorStatus(errors.New(`webhook "ci" already exists`)))
+	assert.Equal(t, http.StatusBadRequest, webhookErrorStatus(errors.New("webhook name is required")))
+}
+
+func TestWebhookEndpoints_Unavailable(t *testing.T) {
+	t.Parallel()
+
+	w := httptest.NewRecorder()
+	(&Service{}).handleListWebhooks(w, httptest.NewRequest(http.MethodGet, "/api/webhooks", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+}
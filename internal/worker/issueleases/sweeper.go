@@ -0,0 +1,130 @@
+// Package issueleases provides a periodic job that releases issue claims whose
+// lease has run out (lease_expires_at <= now, migration 110).
+//
+// Claim checks already treat an expired lease as no claim, so a new session
+// can take the issue over at any time. The sweeper makes expiry visible: it
+// clears the assignee and returns acknowledged issues to open, so the issue is
+// injected and acknowledged again at the next session start in its project.
+package issueleases
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+
+	dbgorm "github.com/thebtf/engram/internal/db/gorm"
+)
+
+// defaultSweepInterval is how often the sweeper runs when
+// ENGRAM_ISSUE_LEASE_SWEEP_INTERVAL is unset or invalid.
+const defaultSweepInterval = time.Minute
+
+// Sweeper periodically releases expired issue claims.
+type Sweeper struct {
+	store *dbgorm.IssueStore
+	stop  chan struct{}
+	done  chan struct{}
+}
+
+// New creates a Sweeper backed by the given database connection.
+func New(db *gorm.DB) *Sweeper {
+	var store *dbgorm.IssueStore
+	if db != nil {
+		store = dbgorm.NewIssueStore(db)
+	}
+	return &Sweeper{
+		store: store,
+		stop:  make(chan struct{}),
+		done:  make(chan struct{}),
+	}
+}
+
+// Start launches the sweeper loop in a background goroutine. It respects ctx for
+// graceful shutdown and also responds to Stop(). Returns immediately.
+func (s *Sweeper) Start(ctx context.Context) {
+	interval := sweepInterval()
+	log.Info().
+		Dur("interval", interval).
+		Msg("issue lease sweeper started")
+
+	go func() {
+		defer close(s.done)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				log.Info().Msg("issue lease sweeper stopped (context cancelled)")
+				return
+			case <-s.stop:
+				log.Info().Msg("issue lease sweeper stopped")
+				return
+			case <-ticker.C:
+				if _, err := s.sweep(ctx); err != nil {
+					log.Error().Err(err).Msg("issue lease sweeper: sweep failed")
+				}
+			}
+		}
+	}()
+}
+
+// Stop signals the sweeper to cease and waits for the goroutine to exit.
+func (s *Sweeper) Stop() {
+	select {
+	case <-s.stop:
+		// Already closed — idempotent.
+	default:
+		close(s.stop)
+	}
+	<-s.done
+}
+
+// sweep releases claims whose lease ended before now.
+// It is idempotent and safe to call concurrently.
+func (s *Sweeper) sweep(ctx context.Context) (int64, error) {
+	if s.store == nil {
+		return 0, nil
+	}
+
+	now := time.Now().UTC()
+	released, err := s.store.ExpireIssueLeases(ctx, now)
+	if err != nil {
+		return 0, err
+	}
+
+	if released > 0 {
+		log.Info().
+			Int64("released", released).
+			Time("now", now).
+			Msg("issue lease sweeper: released expired claims")
+	}
+	return released, nil
+}
+
+// SweepOnce runs a single sweep synchronously and returns the number of
+// claims released. Useful for integration testing where time-based
+// scheduling is not practical.
+func (s *Sweeper) SweepOnce(ctx context.Context) (int64, error) {
+	if s.store == nil {
+		return 0, fmt.Errorf("issue lease sweeper: db is nil")
+	}
+	return s.sweep(ctx)
+}
+
+// sweepInterval returns the configured sweep interval.
+// Reads ENGRAM_ISSUE_LEASE_SWEEP_INTERVAL (a Go duration such as "30s");
+// falls back to defaultSweepInterval.
+func sweepInterval() time.Duration {
+	if v := os.Getenv("ENGRAM_ISSUE_LEASE_SWEEP_INTERVAL"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d >= 10*time.Second {
+			return d
+		}
+	}
+	return defaultSweepInterval
+}
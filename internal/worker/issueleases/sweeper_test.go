@@ -0,0 +1,102 @@
+package issueleases
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	dbgorm "github.com/thebtf/engram/internal/db/gorm"
+)
+
+// testSweeperDB opens a migrated postgres test DB.
+// Tests skip when DATABASE_DSN is not set.
+func testSweeperDB(t *testing.T) (*gorm.DB, func()) {
+	t.Helper()
+	dsn := os.Getenv("DATABASE_DSN")
+	if dsn == "" {
+		t.Skip("DATABASE_DSN not set, skipping issue lease sweeper integration test")
+	}
+
+	store, err := dbgorm.NewStore(dbgorm.Config{DSN: dsn, MaxConns: 2, LogLevel: logger.Silent})
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	return store.DB, func() { _ = store.Close() }
+}
+
+func TestSweeper_ReleasesExpiredClaims(t *testing.T) {
+	db, cleanup := testSweeperDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	project := fmt.Sprintf("issue-lease-sweeper-%d", time.Now().UnixNano())
+	defer db.Exec(`DELETE FROM issues WHERE target_project = ?`, project)
+
+	is := dbgorm.NewIssueStore(db)
+	create := func(title string) int64 {
+		id, err := is.CreateIssue(ctx, &dbgorm.Issue{Title: title, SourceProject: project, TargetProject: project})
+		if err != nil {
+			t.Fatalf("create %s: %v", title, err)
+		}
+		if _, err := is.ClaimIssue(ctx, id, "agent", "session-"+title, dbgorm.DefaultIssueLease); err != nil {
+			t.Fatalf("claim %s: %v", title, err)
+		}
+		return id
+	}
+	expired := create("expired")
+	live := create("live")
+	if err := db.Exec(`UPDATE issues SET lease_expires_at = ? WHERE id = ?`, time.Now().Add(-time.Minute), expired).Error; err != nil {
+		t.Fatalf("age lease: %v", err)
+	}
+
+	released, err := New(db).SweepOnce(ctx)
+	if err != nil {
+		t.Fatalf("SweepOnce: %v", err)
+	}
+	if released < 1 {
+		t.Fatalf("expected at least one expired claim released, got %d", released)
+	}
+
+	issue, _, err := is.GetIssue(ctx, expired)
+	if err != nil {
+		t.Fatalf("get expired: %v", err)
+	}
+	if issue.AssigneeSession != "" || issue.Status != "open" {
+		t.Errorf("expired claim not released: session=%q status=%s", issue.AssigneeSession, issue.Status)
+	}
+	issue, _, err = is.GetIssue(ctx, live)
+	if err != nil {
+		t.Fatalf("get live: %v", err)
+	}
+	if issue.AssigneeSession != "session-live" || issue.Status != "acknowledged" {
+		t.Errorf("live claim was released: session=%q status=%s", issue.AssigneeSession, issue.Status)
+	}
+}
+
+func TestSweeper_NilDB(t *testing.T) {
+	t.Parallel()
+
+	if _, err := New(nil).SweepOnce(context.Background()); err == nil {
+		t.Fatal("expected error for nil db")
+	}
+}
+
+func TestSweepInterval(t *testing.T) {
+	t.Setenv("ENGRAM_ISSUE_LEASE_SWEEP_INTERVAL", "")
+	if got := sweepInterval(); got != defaultSweepInterval {
+		t.Errorf("default interval = %v, want %v", got, defaultSweepInterval)
+	}
+	t.Setenv("ENGRAM_ISSUE_LEASE_SWEEP_INTERVAL", "30s")
+	if got := sweepInterval(); got != 30*time.Second {
+		t.Errorf("interval = %v, want 30s", got)
+	}
+	t.Setenv("ENGRAM_ISSUE_LEASE_SWEEP_INTERVAL", "1s")
+	if got := sweepInterval(); got != defaultSweepInterval {
+		t.Errorf("sub-10s interval = %v, want default", got)
+	}
+}
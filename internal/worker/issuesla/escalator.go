@@ -0,0 +1,270 @@
+// Package issuesla provides a periodic job that escalates issues which have
+// breached their per-priority SLA (see dbgorm.IssueSLA).
+//
+// An escalated issue moves up one priority (critical stays critical), gets a
+// system comment explaining the breach and an escalated event in its timeline,
+// and is reported to the OnEscalated callback, which the worker service uses
+// to notify the dashboard, the event bus and webhooks. Escalation restarts the
+// SLA clock, so an issue nobody picks up keeps climbing one level per window.
+//
+// SLAs come from ENGRAM_ISSUE_SLA, a comma-separated list of
+// <priority>.<acknowledge|resolve>=<duration> entries, for example
+//
+//	critical.acknowledge=1h,critical.resolve=1d,high.resolve=3d
+//
+// Durations are Go durations or whole days ("3d"). Setting the variable
+// replaces DefaultSLAs entirely; "off" disables the job.
+package issuesla
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+
+	dbgorm "github.com/thebtf/engram/internal/db/gorm"
+)
+
+// defaultInterval is how often the escalator runs when
+// ENGRAM_ISSUE_SLA_INTERVAL is unset or invalid.
+const defaultInterval = 5 * time.Minute
+
+// validPriorities is the set of issue priorities an SLA can name.
+var validPriorities = map[string]bool{"critical": true, "high": true, "medium": true, "low": true}
+
+// DefaultSLAs apply when ENGRAM_ISSUE_SLA is unset.
+var DefaultSLAs = []dbgorm.IssueSLA{
+	{Priority: "critical", Acknowledge: time.Hour, Resolve: 24 * time.Hour},
+	{Priority: "high", Resolve: 3 * 24 * time.Hour},
+	{Priority: "medium", Resolve: 14 * 24 * time.Hour},
+}
+
+// Escalator periodically escalates issues that breached their SLA.
+type Escalator struct {
+	store *dbgorm.IssueStore
+	slas  []dbgorm.IssueSLA
+	stop  chan struct{}
+	done  chan struct{}
+
+	mu          sync.RWMutex
+	onEscalated func(dbgorm.IssueEscalation)
+}
+
+// New creates an Escalator backed by the given database connection, with
+// SLAs read from ENGRAM_ISSUE_SLA. An invalid setting is logged and the
+// defaults are used instead.
+func New(db *gorm.DB) *Escalator {
+	var store *dbgorm.IssueStore
+	if db != nil {
+		store = dbgorm.NewIssueStore(db)
+	}
+	slas, err := ParseSLAs(os.Getenv("ENGRAM_ISSUE_SLA"))
+	if err != nil {
+		log.Warn().Err(err).Msg("issue SLA escalator: invalid ENGRAM_ISSUE_SLA, using defaults")
+		slas = DefaultSLAs
+	}
+	return &Escalator{
+		store: store,
+		slas:  slas,
+		stop:  make(chan struct{}),
+		done:  make(chan struct{}),
+	}
+}
+
+// SetOnEscalated sets the callback invoked after each escalation is committed.
+func (e *Escalator) SetOnEscalated(fn func(dbgorm.IssueEscalation)) {
+	e.mu.Lock()
+	e.onEscalated = fn
+	e.mu.Unlock()
+}
+
+// Start launches the escalator loop in a background goroutine. It respects ctx
+// for graceful shutdown and also responds to Stop(). Returns immediately.
+func (e *Escalator) Start(ctx context.Context) {
+	if len(e.slas) == 0 {
+		log.Info().Msg("issue SLA escalator disabled")
+		close(e.done)
+		return
+	}
+	interval := escalationInterval()
+	log.Info().
+		Dur("interval", interval).
+		Str("slas", FormatSLAs(e.slas)).
+		Msg("issue SLA escalator started")
+
+	go func() {
+		defer close(e.done)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				log.Info().Msg("issue SLA escalator stopped (context cancelled)")
+				return
+			case <-e.stop:
+				log.Info().Msg("issue SLA escalator stopped")
+				return
+			case <-ticker.C:
+				if _, err := e.escalate(ctx); err != nil {
+					log.Error().Err(err).Msg("issue SLA escalator: sweep failed")
+				}
+			}
+		}
+	}()
+}
+
+// Stop signals the escalator to cease and waits for the goroutine to exit.
+func (e *Escalator) Stop() {
+	select {
+	case <-e.stop:
+		// Already closed — idempotent.
+	default:
+		close(e.stop)
+	}
+	<-e.done
+}
+
+// escalate escalates every issue past its SLA and notifies the callback.
+func (e *Escalator) escalate(ctx context.Context) ([]dbgorm.IssueEscalation, error) {
+	if e.store == nil || len(e.slas) == 0 {
+		return nil, nil
+	}
+
+	escalations, err := e.store.EscalateOverdueIssues(ctx, e.slas, time.Now().UTC())
+	if err != nil {
+		return nil, err
+	}
+
+	e.mu.RLock()
+	onEscalated := e.onEscalated
+	e.mu.RUnlock()
+	for _, esc := range escalations {
+		log.Info().
+			Int64("issue_id", esc.Issue.ID).
+			Str("project", esc.Issue.TargetProject).
+			Str("breach", esc.Breach).
+			Str("old_priority", esc.OldPriority).
+			Str("priority", esc.Issue.Priority).
+			Msg("issue SLA escalator: escalated issue")
+		if onEscalated != nil {
+			onEscalated(esc)
+		}
+	}
+	return escalations, nil
+}
+
+// EscalateOnce runs a single sweep synchronously and returns the escalations.
+// Useful for integration testing where time-based scheduling is not practical.
+func (e *Escalator) EscalateOnce(ctx context.Context) ([]dbgorm.IssueEscalation, error) {
+	if e.store == nil {
+		return nil, fmt.Errorf("issue SLA escalator: db is nil")
+	}
+	return e.escalate(ctx)
+}
+
+// ParseSLAs parses an ENGRAM_ISSUE_SLA value. An empty value yields
+// DefaultSLAs and "off" yields none.
+func ParseSLAs(value string) ([]dbgorm.IssueSLA, error) {
+	value = strings.TrimSpace(value)
+	switch value {
+	case "":
+		return DefaultSLAs, nil
+	case "off":
+		return nil, nil
+	}
+
+	index := make(map[string]int)
+	var slas []dbgorm.IssueSLA
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		key, raw, ok := strings.Cut(entry, "=")
+		priority, kind, ok2 := strings.Cut(strings.TrimSpace(key), ".")
+		if !ok || !ok2 {
+			return nil, fmt.Errorf("invalid SLA %q: want <priority>.<acknowledge|resolve>=<duration>", entry)
+		}
+		if !validPriorities[priority] {
+			return nil, fmt.Errorf("invalid SLA %q: unknown priority %q", entry, priority)
+		}
+		window, err := parseWindow(strings.TrimSpace(raw))
+		if err != nil {
+			return nil, fmt.Errorf("invalid SLA %q: %w", entry, err)
+		}
+
+		i, seen := index[priority]
+		if !seen {
+			i = len(slas)
+			index[priority] = i
+			slas = append(slas, dbgorm.IssueSLA{Priority: priority})
+		}
+		switch kind {
+		case "acknowledge":
+			slas[i].Acknowledge = window
+		case "resolve":
+			slas[i].Resolve = window
+		default:
+			return nil, fmt.Errorf("invalid SLA %q: unknown check %q", entry, kind)
+		}
+	}
+	return slas, nil
+}
+
+// FormatSLAs renders SLAs in the ENGRAM_ISSUE_SLA syntax.
+func FormatSLAs(slas []dbgorm.IssueSLA) string {
+	var parts []string
+	for _, sla := range slas {
+		if sla.Acknowledge > 0 {
+			parts = append(parts, sla.Priority+".acknowledge="+formatWindow(sla.Acknowledge))
+		}
+		if sla.Resolve > 0 {
+			parts = append(parts, sla.Priority+".resolve="+formatWindow(sla.Resolve))
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+// parseWindow parses a Go duration or a whole number of days ("3d").
+func parseWindow(s string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n <= 0 {
+			return 0, fmt.Errorf("invalid duration %q", s)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d < time.Minute {
+		return 0, fmt.Errorf("invalid duration %q: must be at least 1m", s)
+	}
+	return d, nil
+}
+
+// formatWindow is the inverse of parseWindow.
+func formatWindow(d time.Duration) string {
+	if d%(24*time.Hour) == 0 {
+		return fmt.Sprintf("%dd", d/(24*time.Hour))
+	}
+	return d.String()
+}
+
+// escalationInterval returns the configured sweep interval.
+// Reads ENGRAM_ISSUE_SLA_INTERVAL (a Go duration such as "1m");
+// falls back to defaultInterval.
+func escalationInterval() time.Duration {
+	if v := os.Getenv("ENGRAM_ISSUE_SLA_INTERVAL"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d >= 10*time.Second {
+			return d
+		}
+	}
+	return defaultInterval
+}
@@ -0,0 +1,147 @@
+package issuesla
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	dbgorm "github.com/thebtf/engram/internal/db/gorm"
+)
+
+// testEscalatorDB opens a migrated postgres test DB.
+// Tests skip when DATABASE_DSN is not set.
+func testEscalatorDB(t *testing.T) (*gorm.DB, func()) {
+	t.Helper()
+	dsn := os.Getenv("DATABASE_DSN")
+	if dsn == "" {
+		t.Skip("DATABASE_DSN not set, skipping issue SLA escalator integration test")
+	}
+
+	store, err := dbgorm.NewStore(dbgorm.Config{DSN: dsn, MaxConns: 2, LogLevel: logger.Silent})
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	return store.DB, func() { _ = store.Close() }
+}
+
+func TestEscalator_EscalatesOverdueIssues(t *testing.T) {
+	db, cleanup := testEscalatorDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	project := fmt.Sprintf("issue-sla-%d", time.Now().UnixNano())
+	defer db.Exec(`DELETE FROM issues WHERE target_project = ?`, project)
+
+	is := dbgorm.NewIssueStore(db)
+	create := func(title, priority string, age time.Duration) int64 {
+		id, err := is.CreateIssue(ctx, &dbgorm.Issue{Title: title, Priority: priority, SourceProject: project, TargetProject: project})
+		if err != nil {
+			t.Fatalf("create %s: %v", title, err)
+		}
+		old := time.Now().Add(-age)
+		if err := db.Exec(`UPDATE issues SET created_at = ?, updated_at = ? WHERE id = ?`, old, old, id).Error; err != nil {
+			t.Fatalf("age %s: %v", title, err)
+		}
+		return id
+	}
+	overdue := create("overdue", "high", 4*24*time.Hour)
+	fresh := create("fresh", "high", time.Hour)
+
+	e := New(db)
+	e.slas = []dbgorm.IssueSLA{{Priority: "high", Resolve: 3 * 24 * time.Hour}}
+	var notified []int64
+	e.SetOnEscalated(func(esc dbgorm.IssueEscalation) {
+		if esc.Issue.TargetProject == project {
+			notified = append(notified, esc.Issue.ID)
+		}
+	})
+
+	if _, err := e.EscalateOnce(ctx); err != nil {
+		t.Fatalf("EscalateOnce: %v", err)
+	}
+	if !reflect.DeepEqual(notified, []int64{overdue}) {
+		t.Fatalf("notified = %v, want [%d]", notified, overdue)
+	}
+
+	issue, comments, err := is.GetIssue(ctx, overdue)
+	if err != nil {
+		t.Fatalf("get overdue: %v", err)
+	}
+	if issue.Priority != "critical" || issue.EscalationCount != 1 || !issue.IsStale() {
+		t.Errorf("overdue issue: priority=%s count=%d stale=%v", issue.Priority, issue.EscalationCount, issue.IsStale())
+	}
+	if len(comments) != 1 || comments[0].AuthorAgent != dbgorm.IssueSLAAgent || !strings.Contains(comments[0].Body, "not resolved within 3d") {
+		t.Errorf("escalation comment = %+v", comments)
+	}
+	if issue, _, _ := is.GetIssue(ctx, fresh); issue.Priority != "high" {
+		t.Errorf("fresh issue escalated to %s", issue.Priority)
+	}
+
+	// The escalation restarted the clock: a second sweep does nothing.
+	notified = nil
+	if _, err := e.EscalateOnce(ctx); err != nil {
+		t.Fatalf("second EscalateOnce: %v", err)
+	}
+	if len(notified) != 0 {
+		t.Errorf("second sweep escalated %v again", notified)
+	}
+}
+
+func TestEscalator_NilDB(t *testing.T) {
+	t.Parallel()
+
+	if _, err := New(nil).EscalateOnce(context.Background()); err == nil {
+		t.Fatal("expected error for nil db")
+	}
+}
+
+func TestParseSLAs(t *testing.T) {
+	t.Parallel()
+
+	slas, err := ParseSLAs("")
+	if err != nil || !reflect.DeepEqual(slas, DefaultSLAs) {
+		t.Errorf("empty value = %v, %v; want defaults", slas, err)
+	}
+	if slas, err := ParseSLAs("off"); err != nil || len(slas) != 0 {
+		t.Errorf("off = %v, %v; want none", slas, err)
+	}
+
+	slas, err = ParseSLAs("critical.acknowledge=30m, critical.resolve=1d,low.resolve=90m")
+	if err != nil {
+		t.Fatalf("ParseSLAs: %v", err)
+	}
+	want := []dbgorm.IssueSLA{
+		{Priority: "critical", Acknowledge: 30 * time.Minute, Resolve: 24 * time.Hour},
+		{Priority: "low", Resolve: 90 * time.Minute},
+	}
+	if !reflect.DeepEqual(slas, want) {
+		t.Errorf("ParseSLAs = %+v, want %+v", slas, want)
+	}
+	if got := FormatSLAs(slas); got != "critical.acknowledge=30m0s,critical.resolve=1d,low.resolve=1h30m0s" {
+		t.Errorf("FormatSLAs = %q", got)
+	}
+
+	for _, bad := range []string{"critical=1h", "urgent.resolve=1h", "high.respond=1h", "high.resolve=soon", "high.resolve=10s", "high.resolve=0d"} {
+		if _, err := ParseSLAs(bad); err == nil {
+			t.Errorf("ParseSLAs(%q): expected error", bad)
+		}
+	}
+}
+
+func TestEscalationInterval(t *testing.T) {
+	t.Setenv("ENGRAM_ISSUE_SLA_INTERVAL", "")
+	if got := escalationInterval(); got != defaultInterval {
+		t.Errorf("default interval = %v, want %v", got, defaultInterval)
+	}
+	t.Setenv("ENGRAM_ISSUE_SLA_INTERVAL", "1m")
+	if got := escalationInterval(); got != time.Minute {
+		t.Errorf("interval = %v, want 1m", got)
+	}
+}
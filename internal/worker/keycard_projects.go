@@ -0,0 +1,302 @@
+package worker
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rs/zerolog/log"
+	gormlib "gorm.io/gorm"
+
+	authpkg "github.com/thebtf/engram/internal/auth"
+)
+
+// keycardRoute describes how a project-scoped keycard may call a route.
+//
+// By default the request must name its project(s) — in the query, the
+// X-Engram-Project header or the JSON body — and every one of them must be
+// in the keycard's allowlist; the handler then scopes its reads and writes to
+// the named project. anyProject marks routes that touch no project data.
+// owners loads the record an ID-addressed route acts on and returns the
+// projects it belongs to; the keycard must be allowed at least one of them.
+type keycardRoute struct {
+	owners     func(s *Service, r *http.Request) ([]string, error)
+	anyProject bool
+}
+
+// keycardRoutes lists, by chi route pattern, the routes a project-scoped
+// keycard may call. Every other route behind requireKeycardProject answers
+// 403 to such a keycard: anything not listed either spans all projects
+// (dashboards, stats, webhooks, imports) or is administrative.
+var keycardRoutes = map[string]keycardRoute{
+	"/api/types":   {anyProject: true},
+	"/api/models":  {anyProject: true},
+	"/api/auth/me": {anyProject: true},
+
+	"/api/backfill/session":                         {},
+	"/api/sessions/init":                            {},
+	"/api/sessions/list":                            {},
+	"/api/sessions":                                 {owners: (*Service).sessionOwnersByClaudeID},
+	"/api/sessions/{id}/init":                       {owners: (*Service).sessionOwners},
+	"/api/sessions/{id}/summarize":                  {owners: (*Service).sessionOwners},
+	"/api/sessions/subagent-complete":               {owners: (*Service).subagentSessionOwners},
+	"/api/events/ingest":                            {},
+	"/api/observations":                             {},
+	"/api/context/count":                            {},
+	"/api/context/inject":                           {},
+	"/api/context/session-start":                    {},
+	"/api/context/search":                           {},
+	"/api/context/files":                            {},
+	"/api/context/by-file":                          {},
+	"/api/memory/triggers":                          {},
+	"/api/decisions/search":                         {},
+	"/api/issues":                                   {},
+	"/api/issues/acknowledge":                       {},
+	"/api/issues/export":                            {},
+	"/api/issues/{id}":                              {owners: (*Service).issueOwners},
+	"/api/issues/{id}/links":                        {owners: (*Service).issueOwners},
+	"/api/issues/{id}/events":                       {owners: (*Service).issueOwners},
+	"/api/issues/{id}/claim":                        {owners: (*Service).issueOwners},
+	"/api/issues/{id}/renew":                        {owners: (*Service).issueOwners},
+	"/api/issues/{id}/release":                      {owners: (*Service).issueOwners},
+	"/api/vault/credentials":                        {},
+	"/api/vault/credentials/{name}":                 {},
+	"/api/memories":                                 {},
+	"/api/memories/{id}":                            {owners: (*Service).memoryOwners},
+	"/api/memories/{id}/merge":                      {owners: (*Service).memoryOwners},
+	"/api/memories/{id}/versions":                   {owners: (*Service).memoryOwners},
+	"/api/memories/{id}/versions/diff":              {owners: (*Service).memoryOwners},
+	"/api/memories/{id}/versions/{version}/restore": {owners: (*Service).memoryOwners},
+}
+
+// requestProjectParams are the query parameters and top-level JSON body
+// fields through which a request names a project.
+var requestProjectParams = []string{"project", "source_project", "target_project", "legacy_project"}
+
+// requireKeycardProject enforces keycard project allowlists on the routes it
+// wraps. Callers other than project-scoped keycards pass straight through.
+// It runs after routing so it can classify the request by route pattern (see
+// keycardRoutes).
+func (s *Service) requireKeycardProject(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, ok := authpkg.IdentityFrom(r.Context())
+		if !ok || !id.IsProjectScoped() {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		pattern := chi.RouteContext(r.Context()).RoutePattern()
+		route, listed := keycardRoutes[pattern]
+		if !listed {
+			http.Error(w, "forbidden: not available to project-scoped keycards", http.StatusForbidden)
+			return
+		}
+
+		named, err := requestProjects(r)
+		if err != nil {
+			http.Error(w, "invalid request body", http.StatusBadRequest)
+			return
+		}
+		switch {
+		case route.owners != nil:
+			owners, lookupErr := route.owners(s, r)
+			if lookupErr != nil {
+				log.Error().Err(lookupErr).Str("path", r.URL.Path).Msg("auth: keycard project lookup failed")
+				http.Error(w, "internal error", http.StatusInternalServerError)
+				return
+			}
+			if !slices.ContainsFunc(owners, id.CanAccessProject) {
+				err = authpkg.ErrProjectForbidden
+			} else if len(named) > 0 {
+				err = id.CheckProjects(named...)
+			}
+		case route.anyProject:
+			if len(named) > 0 {
+				err = id.CheckProjects(named...)
+			}
+		default:
+			err = id.CheckProjects(named...)
+			// ?scope=all lists memories of every project, whatever the
+			// project parameter says.
+			if err == nil && pattern == "/api/memories" && r.URL.Query().Get("scope") == "all" {
+				err = authpkg.ErrProjectForbidden
+			}
+		}
+		if err != nil {
+			log.Warn().
+				Str("path", r.URL.Path).
+				Str("keycard_id", id.KeycardID).
+				Err(err).
+				Msg("auth: keycard project denied")
+			http.Error(w, "forbidden: "+strings.TrimPrefix(err.Error(), "auth: "), http.StatusForbidden)
+			return
+		}
+		pinHeaderProject(r)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// pinHeaderProject copies the X-Engram-Project header into a missing
+// ?project= parameter. List and export handlers read only the query, and
+// without it they answer for every project, although the header is what the
+// keycard was checked against.
+func pinHeaderProject(r *http.Request) {
+	p := strings.TrimSpace(r.Header.Get("X-Engram-Project"))
+	q := r.URL.Query()
+	if p == "" || q.Get("project") != "" {
+		return
+	}
+	q.Set("project", p)
+	r.URL.RawQuery = q.Encode()
+}
+
+// requestProjects returns every project r names: the requestProjectParams
+// query parameters, include_projects, the X-Engram-Project header, and the
+// requestProjectParams and allowed_projects fields of a JSON body.
+func requestProjects(r *http.Request) ([]string, error) {
+	var projects []string
+	add := func(p string) {
+		if p = strings.TrimSpace(p); p != "" && !slices.Contains(projects, p) {
+			projects = append(projects, p)
+		}
+	}
+
+	q := r.URL.Query()
+	for _, name := range requestProjectParams {
+		for _, v := range q[name] {
+			add(v)
+		}
+	}
+	for _, v := range strings.Split(q.Get("include_projects"), ",") {
+		add(v)
+	}
+	add(r.Header.Get("X-Engram-Project"))
+
+	body, err := peekJSONBody(r)
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range requestProjectParams {
+		var v string
+		if raw, ok := body[name]; ok && json.Unmarshal(raw, &v) == nil {
+			add(v)
+		}
+	}
+	var allowed []string
+	if raw, ok := body["allowed_projects"]; ok && json.Unmarshal(raw, &allowed) == nil {
+		for _, v := range allowed {
+			add(v)
+		}
+	}
+	return projects, nil
+}
+
+// peekJSONBody decodes a JSON object body without consuming it: r.Body is
+// replaced with a reader over the same bytes for the handler. Requests
+// without a body, or whose body is not a JSON object, yield nil; the handler
+// reports malformed bodies itself.
+func peekJSONBody(r *http.Request) (map[string]json.RawMessage, error) {
+	if r.Body == nil || r.Body == http.NoBody || !isMutatingMethod(r.Method) {
+		return nil, nil
+	}
+	data, err := io.ReadAll(r.Body)
+	_ = r.Body.Close()
+	r.Body = io.NopCloser(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	var body map[string]json.RawMessage
+	if json.Unmarshal(data, &body) != nil {
+		return nil, nil
+	}
+	return body, nil
+}
+
+// issueOwners returns the source and target project of the issue in the
+// route's {id}. A keycard for either side may act on it.
+func (s *Service) issueOwners(r *http.Request) ([]string, error) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil || s.issueStore == nil {
+		return nil, nil
+	}
+	issue, _, err := s.issueStore.GetIssue(r.Context(), id)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return []string{issue.SourceProject, issue.TargetProject}, nil
+}
+
+// memoryOwners returns the project of the memory in the route's {id}.
+func (s *Service) memoryOwners(r *http.Request) ([]string, error) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil || s.memoryStore == nil {
+		return nil, nil
+	}
+	memory, err := s.memoryStore.Get(r.Context(), id)
+	if err != nil || memory == nil {
+		return nil, ignoreNotFound(err)
+	}
+	return []string{memory.Project}, nil
+}
+
+// sessionOwners returns the project of the SDK session in the route's {id}.
+func (s *Service) sessionOwners(r *http.Request) ([]string, error) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil || s.sessionStore == nil {
+		return nil, nil
+	}
+	sess, err := s.sessionStore.GetSessionByID(r.Context(), id)
+	if err != nil || sess == nil {
+		return nil, ignoreNotFound(err)
+	}
+	return []string{sess.Project}, nil
+}
+
+// sessionOwnersByClaudeID returns the project of the session named by the
+// claudeSessionId query parameter.
+func (s *Service) sessionOwnersByClaudeID(r *http.Request) ([]string, error) {
+	return s.claudeSessionOwners(r, r.URL.Query().Get("claudeSessionId"))
+}
+
+// subagentSessionOwners returns the project of the session named by the
+// claudeSessionId body field.
+func (s *Service) subagentSessionOwners(r *http.Request) ([]string, error) {
+	body, err := peekJSONBody(r)
+	if err != nil {
+		return nil, err
+	}
+	var claudeSessionID string
+	if raw, ok := body["claudeSessionId"]; ok {
+		_ = json.Unmarshal(raw, &claudeSessionID)
+	}
+	return s.claudeSessionOwners(r, claudeSessionID)
+}
+
+func (s *Service) claudeSessionOwners(r *http.Request, claudeSessionID string) ([]string, error) {
+	if claudeSessionID == "" || s.sessionStore == nil {
+		return nil, nil
+	}
+	sess, err := s.sessionStore.FindAnySDKSession(r.Context(), claudeSessionID)
+	if err != nil || sess == nil {
+		return nil, ignoreNotFound(err)
+	}
+	return []string{sess.Project}, nil
+}
+
+// ignoreNotFound maps gorm's record-not-found to nil: a missing record has no
+// owners, so the keycard is refused without revealing whether it exists.
+func ignoreNotFound(err error) error {
+	if errors.Is(err, gormlib.ErrRecordNotFound) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,105 @@
+package worker
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+
+	authpkg "github.com/thebtf/engram/internal/auth"
+)
+
+func TestRequireKeycardProject(t *testing.T) {
+	s := &Service{}
+	echo := func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		_, _ = w.Write(body)
+	}
+	router := chi.NewRouter()
+	router.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			id := authpkg.Client("read-write", "uuid-1", "acme", "labs-*")
+			if r.Header.Get("X-Test-Unscoped") != "" {
+				id = authpkg.Client("read-write", "uuid-2")
+			}
+			next.ServeHTTP(w, r.WithContext(authpkg.WithIdentity(r.Context(), id)))
+		})
+	})
+	router.Group(func(r chi.Router) {
+		r.Use(s.requireKeycardProject)
+		r.Get("/api/context/search", echo)
+		r.Post("/api/context/search", echo)
+		r.Get("/api/memories", echo)
+		r.Get("/api/types", echo)
+		r.Get("/api/stats", echo)
+	})
+
+	cases := []struct {
+		name, method, target, body string
+		unscoped                   bool
+		want                       int
+	}{
+		{name: "allowed project", method: "GET", target: "/api/context/search?project=acme", want: http.StatusOK},
+		{name: "glob project", method: "GET", target: "/api/context/search?project=labs-ml", want: http.StatusOK},
+		{name: "other project", method: "GET", target: "/api/context/search?project=globex", want: http.StatusForbidden},
+		{name: "no project", method: "GET", target: "/api/context/search", want: http.StatusForbidden},
+		{name: "body project", method: "POST", target: "/api/context/search", body: `{"project":"acme","query":"x"}`, want: http.StatusOK},
+		{name: "body other project", method: "POST", target: "/api/context/search", body: `{"project":"globex"}`, want: http.StatusForbidden},
+		{name: "query and body disagree", method: "POST", target: "/api/context/search?project=acme", body: `{"project":"globex"}`, want: http.StatusForbidden},
+		{name: "all-project memory list", method: "GET", target: "/api/memories?project=acme&scope=all", want: http.StatusForbidden},
+		{name: "project-free route", method: "GET", target: "/api/types", want: http.StatusOK},
+		{name: "unlisted route", method: "GET", target: "/api/stats", want: http.StatusForbidden},
+		{name: "unscoped keycard", method: "GET", target: "/api/stats", unscoped: true, want: http.StatusOK},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			req := httptest.NewRequest(c.method, c.target, strings.NewReader(c.body))
+			if c.unscoped {
+				req.Header.Set("X-Test-Unscoped", "1")
+			}
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			assert.Equal(t, c.want, rr.Code, rr.Body.String())
+			if c.want == http.StatusOK {
+				assert.Equal(t, c.body, rr.Body.String(), "handler must still see the request body")
+			}
+		})
+	}
+}
+
+// TestRequireKeycardProject_HeaderProject verifies that a project named only
+// by the X-Engram-Project header reaches the handler as ?project=, so list
+// handlers that read the query do not fall back to every project.
+func TestRequireKeycardProject_HeaderProject(t *testing.T) {
+	s := &Service{}
+	router := chi.NewRouter()
+	router.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			id := authpkg.Client("read-write", "uuid-1", "acme")
+			next.ServeHTTP(w, r.WithContext(authpkg.WithIdentity(r.Context(), id)))
+		})
+	})
+	router.Group(func(r chi.Router) {
+		r.Use(s.requireKeycardProject)
+		r.Get("/api/issues", func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(r.URL.Query().Get("project")))
+		})
+	})
+
+	for header, want := range map[string]int{"acme": http.StatusOK, "globex": http.StatusForbidden} {
+		req := httptest.NewRequest(http.MethodGet, "/api/issues", nil)
+		req.Header.Set("X-Engram-Project", header)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, want, rr.Code, rr.Body.String())
+		if want == http.StatusOK {
+			assert.Equal(t, header, rr.Body.String(), "handler must see the header's project as ?project=")
+		}
+	}
+}
@@ -0,0 +1,222 @@
+// Package keycardwatch provides a periodic job that watches dashboard-issued
+// keycards (api_tokens) for usage anomalies (see dbgorm.TokenUsagePolicy).
+//
+// It flags keycards that have not been used for ENGRAM_KEYCARD_UNUSED_DAYS
+// days (default 30) — forgotten credentials that should be revoked — and
+// keycards whose request rate since the previous check is at least
+// ENGRAM_KEYCARD_SPIKE_FACTOR times their usual rate (default 10), with at
+// least ENGRAM_KEYCARD_SPIKE_MIN_REQUESTS requests (default 50) — a leaked or
+// runaway credential. Each new alert is stored in api_token_alerts and
+// reported to the OnAlert callback, which the worker service uses to notify
+// the dashboard. "off" (or 0) disables a check; with both off the job does
+// not run.
+package keycardwatch
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+
+	dbgorm "github.com/thebtf/engram/internal/db/gorm"
+)
+
+// defaultInterval is how often the watcher runs when
+// ENGRAM_KEYCARD_WATCH_INTERVAL is unset or invalid. The usual rate of a
+// keycard is measured over these intervals.
+const defaultInterval = time.Hour
+
+// DefaultPolicy applies to every setting left unset.
+var DefaultPolicy = dbgorm.TokenUsagePolicy{
+	UnusedAfter:      30 * 24 * time.Hour,
+	SpikeFactor:      10,
+	SpikeMinRequests: 50,
+}
+
+// Watcher periodically checks keycard usage and raises alerts.
+type Watcher struct {
+	store  *dbgorm.TokenStore
+	policy dbgorm.TokenUsagePolicy
+	stop   chan struct{}
+	done   chan struct{}
+
+	mu      sync.RWMutex
+	onAlert func(dbgorm.APITokenAlert)
+}
+
+// New creates a Watcher backed by the given database connection, with its
+// policy read from the environment. An invalid setting is logged and its
+// default used instead.
+func New(db *gorm.DB) *Watcher {
+	var store *dbgorm.TokenStore
+	if db != nil {
+		store = dbgorm.NewTokenStore(&dbgorm.Store{DB: db})
+	}
+	policy, err := ParsePolicy(
+		os.Getenv("ENGRAM_KEYCARD_UNUSED_DAYS"),
+		os.Getenv("ENGRAM_KEYCARD_SPIKE_FACTOR"),
+		os.Getenv("ENGRAM_KEYCARD_SPIKE_MIN_REQUESTS"),
+	)
+	if err != nil {
+		log.Warn().Err(err).Msg("keycard watcher: invalid setting, using defaults")
+		policy = DefaultPolicy
+	}
+	return &Watcher{
+		store:  store,
+		policy: policy,
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+}
+
+// SetOnAlert sets the callback invoked for each newly raised alert.
+func (w *Watcher) SetOnAlert(fn func(dbgorm.APITokenAlert)) {
+	w.mu.Lock()
+	w.onAlert = fn
+	w.mu.Unlock()
+}
+
+// Start launches the watcher loop in a background goroutine. It respects ctx
+// for graceful shutdown and also responds to Stop(). Returns immediately.
+func (w *Watcher) Start(ctx context.Context) {
+	if w.policy.UnusedAfter <= 0 && w.policy.SpikeFactor <= 0 {
+		log.Info().Msg("keycard watcher disabled")
+		close(w.done)
+		return
+	}
+	interval := watchInterval()
+	log.Info().
+		Dur("interval", interval).
+		Dur("unused_after", w.policy.UnusedAfter).
+		Float64("spike_factor", w.policy.SpikeFactor).
+		Int64("spike_min_requests", w.policy.SpikeMinRequests).
+		Msg("keycard watcher started")
+
+	go func() {
+		defer close(w.done)
+
+		// Record a starting point right away so the first spike check
+		// compares against a full interval.
+		if _, err := w.check(ctx); err != nil {
+			log.Error().Err(err).Msg("keycard watcher: check failed")
+		}
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				log.Info().Msg("keycard watcher stopped (context cancelled)")
+				return
+			case <-w.stop:
+				log.Info().Msg("keycard watcher stopped")
+				return
+			case <-ticker.C:
+				if _, err := w.check(ctx); err != nil {
+					log.Error().Err(err).Msg("keycard watcher: check failed")
+				}
+			}
+		}
+	}()
+}
+
+// Stop signals the watcher to cease and waits for the goroutine to exit.
+func (w *Watcher) Stop() {
+	select {
+	case <-w.stop:
+		// Already closed — idempotent.
+	default:
+		close(w.stop)
+	}
+	<-w.done
+}
+
+// check runs one usage check and notifies the callback of new alerts.
+func (w *Watcher) check(ctx context.Context) ([]dbgorm.APITokenAlert, error) {
+	if w.store == nil {
+		return nil, nil
+	}
+
+	alerts, err := w.store.CheckTokenUsage(ctx, w.policy, time.Now().UTC())
+	if err != nil {
+		return nil, err
+	}
+
+	w.mu.RLock()
+	onAlert := w.onAlert
+	w.mu.RUnlock()
+	for _, a := range alerts {
+		log.Warn().
+			Str("token_id", a.TokenID).
+			Str("token_name", a.TokenName).
+			Str("kind", a.Kind).
+			Msg("keycard watcher: " + a.Message)
+		if onAlert != nil {
+			onAlert(a)
+		}
+	}
+	return alerts, nil
+}
+
+// CheckOnce runs a single check synchronously and returns the alerts raised.
+// Useful for integration testing where time-based scheduling is not practical.
+func (w *Watcher) CheckOnce(ctx context.Context) ([]dbgorm.APITokenAlert, error) {
+	if w.store == nil {
+		return nil, fmt.Errorf("keycard watcher: db is nil")
+	}
+	return w.check(ctx)
+}
+
+// ParsePolicy parses the ENGRAM_KEYCARD_UNUSED_DAYS,
+// ENGRAM_KEYCARD_SPIKE_FACTOR and ENGRAM_KEYCARD_SPIKE_MIN_REQUESTS values.
+// Empty values take DefaultPolicy's; "off" or 0 disables a check.
+func ParsePolicy(unusedDays, spikeFactor, spikeMinRequests string) (dbgorm.TokenUsagePolicy, error) {
+	policy := DefaultPolicy
+	if v := strings.TrimSpace(unusedDays); v != "" {
+		if v == "off" {
+			v = "0"
+		}
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return policy, fmt.Errorf("invalid ENGRAM_KEYCARD_UNUSED_DAYS %q: want a whole number of days or off", unusedDays)
+		}
+		policy.UnusedAfter = time.Duration(n) * 24 * time.Hour
+	}
+	if v := strings.TrimSpace(spikeFactor); v != "" {
+		if v == "off" {
+			v = "0"
+		}
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil || f < 0 || (f > 0 && f <= 1) {
+			return policy, fmt.Errorf("invalid ENGRAM_KEYCARD_SPIKE_FACTOR %q: want a number above 1 or off", spikeFactor)
+		}
+		policy.SpikeFactor = f
+	}
+	if v := strings.TrimSpace(spikeMinRequests); v != "" {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || n < 0 {
+			return policy, fmt.Errorf("invalid ENGRAM_KEYCARD_SPIKE_MIN_REQUESTS %q: want a whole number", spikeMinRequests)
+		}
+		policy.SpikeMinRequests = n
+	}
+	return policy, nil
+}
+
+// watchInterval returns the configured check interval.
+// Reads ENGRAM_KEYCARD_WATCH_INTERVAL (a Go duration such as "30m");
+// falls back to defaultInterval.
+func watchInterval() time.Duration {
+	if v := os.Getenv("ENGRAM_KEYCARD_WATCH_INTERVAL"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d >= 10*time.Second {
+			return d
+		}
+	}
+	return defaultInterval
+}
@@ -0,0 +1,63 @@
+package keycardwatch
+
+import (
+	"testing"
+	"time"
+
+	dbgorm "github.com/thebtf/engram/internal/db/gorm"
+)
+
+func TestParsePolicy(t *testing.T) {
+	cases := []struct {
+		name                    string
+		unused, factor, minimum string
+		want                    dbgorm.TokenUsagePolicy
+		wantErr                 bool
+	}{
+		{name: "defaults", want: DefaultPolicy},
+		{
+			name: "custom", unused: "14", factor: "5", minimum: "20",
+			want: dbgorm.TokenUsagePolicy{UnusedAfter: 14 * 24 * time.Hour, SpikeFactor: 5, SpikeMinRequests: 20},
+		},
+		{
+			name: "checks off", unused: "off", factor: "0",
+			want: dbgorm.TokenUsagePolicy{SpikeMinRequests: DefaultPolicy.SpikeMinRequests},
+		},
+		{name: "bad days", unused: "two weeks", wantErr: true},
+		{name: "negative days", unused: "-1", wantErr: true},
+		{name: "factor not above 1", factor: "1", wantErr: true},
+		{name: "bad minimum", minimum: "many", wantErr: true},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got, err := ParsePolicy(c.unused, c.factor, c.minimum)
+			if c.wantErr {
+				if err == nil {
+					t.Fatalf("ParsePolicy(%q, %q, %q) = %+v, want error", c.unused, c.factor, c.minimum, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParsePolicy(%q, %q, %q): %v", c.unused, c.factor, c.minimum, err)
+			}
+			if got != c.want {
+				t.Errorf("ParsePolicy(%q, %q, %q) = %+v, want %+v", c.unused, c.factor, c.minimum, got, c.want)
+			}
+		})
+	}
+}
+
+func TestWatchInterval(t *testing.T) {
+	t.Setenv("ENGRAM_KEYCARD_WATCH_INTERVAL", "")
+	if got := watchInterval(); got != defaultInterval {
+		t.Errorf("unset: got %v, want %v", got, defaultInterval)
+	}
+	t.Setenv("ENGRAM_KEYCARD_WATCH_INTERVAL", "15m")
+	if got := watchInterval(); got != 15*time.Minute {
+		t.Errorf("15m: got %v", got)
+	}
+	t.Setenv("ENGRAM_KEYCARD_WATCH_INTERVAL", "1s")
+	if got := watchInterval(); got != defaultInterval {
+		t.Errorf("below minimum: got %v, want %v", got, defaultInterval)
+	}
+}
@@ -0,0 +1,176 @@
+// Package memoryembedder provides a periodic job that keeps memory_embeddings
+// (migration 107) in step with the memories table.
+//
+// store_memory embeds new memories inline, so this job mostly catches what the
+// inline path cannot: memories written before embeddings existed, edits, merges
+// and restores (which change content and so the stored content hash), inline
+// failures (e.g. an unreachable HTTP provider) and a switch to another embedder.
+package memoryembedder
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+
+	dbgorm "github.com/thebtf/engram/internal/db/gorm"
+	"github.com/thebtf/engram/internal/embedding"
+)
+
+const (
+	// defaultInterval is how often the job runs when
+	// ENGRAM_MEMORY_EMBED_INTERVAL is unset or invalid.
+	defaultInterval = time.Minute
+	// batchSize is how many memories are embedded per request to the embedder.
+	batchSize = 64
+	// maxBatchesPerRun bounds one run so a large backlog (first start, model
+	// switch) is worked off over several ticks instead of one long pass.
+	maxBatchesPerRun = 20
+)
+
+// Job periodically embeds memories that have no current embedding.
+type Job struct {
+	store    *dbgorm.MemoryStore
+	embedder embedding.Embedder
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+// New creates a Job backed by the given database connection and embedder.
+func New(db *gorm.DB, embedder embedding.Embedder) *Job {
+	var store *dbgorm.MemoryStore
+	if db != nil {
+		store = dbgorm.NewMemoryStore(&dbgorm.Store{DB: db})
+	}
+	return &Job{
+		store:    store,
+		embedder: embedder,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+}
+
+// Start launches the job loop in a background goroutine. The first run happens
+// immediately so a backlog starts draining at startup. It respects ctx for
+// graceful shutdown and also responds to Stop(). Returns immediately.
+func (j *Job) Start(ctx context.Context) {
+	interval := runInterval()
+	log.Info().
+		Dur("interval", interval).
+		Str("model", j.modelName()).
+		Msg("memory embedder started")
+
+	go func() {
+		defer close(j.done)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			if _, err := j.run(ctx); err != nil {
+				log.Error().Err(err).Msg("memory embedder: run failed")
+			}
+			select {
+			case <-ctx.Done():
+				log.Info().Msg("memory embedder stopped (context cancelled)")
+				return
+			case <-j.stop:
+				log.Info().Msg("memory embedder stopped")
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Stop signals the job to cease and waits for the goroutine to exit.
+func (j *Job) Stop() {
+	select {
+	case <-j.stop:
+		// Already closed — idempotent.
+	default:
+		close(j.stop)
+	}
+	<-j.done
+}
+
+// run embeds up to maxBatchesPerRun batches of stale memories and returns how
+// many embeddings were written.
+func (j *Job) run(ctx context.Context) (int, error) {
+	if j.store == nil || j.embedder == nil {
+		return 0, nil
+	}
+
+	model := j.embedder.Name()
+	written := 0
+	for batch := 0; batch < maxBatchesPerRun; batch++ {
+		if ctx.Err() != nil {
+			return written, nil
+		}
+		memories, err := j.store.ListUnembedded(ctx, model, batchSize)
+		if err != nil {
+			return written, err
+		}
+		if len(memories) == 0 {
+			break
+		}
+
+		texts := make([]string, len(memories))
+		for i, mem := range memories {
+			texts[i] = mem.Content
+		}
+		vectors, err := j.embedder.Embed(ctx, texts)
+		if err != nil {
+			return written, fmt.Errorf("embed %d memories: %w", len(memories), err)
+		}
+		for i, mem := range memories {
+			if err := j.store.UpsertEmbedding(ctx, mem.ID, model, vectors[i], dbgorm.MemoryContentHash(mem.Content)); err != nil {
+				return written, err
+			}
+			written++
+		}
+		if len(memories) < batchSize {
+			break
+		}
+	}
+
+	if written > 0 {
+		log.Info().
+			Int("embedded", written).
+			Str("model", j.modelName()).
+			Msg("memory embedder: embedded memories")
+	}
+	return written, nil
+}
+
+func (j *Job) modelName() string {
+	if j.embedder == nil {
+		return ""
+	}
+	return j.embedder.Name()
+}
+
+// runInterval returns the configured interval.
+// Reads ENGRAM_MEMORY_EMBED_INTERVAL (a Go duration such as "30s");
+// falls back to defaultInterval.
+func runInterval() time.Duration {
+	if v := os.Getenv("ENGRAM_MEMORY_EMBED_INTERVAL"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d >= 5*time.Second {
+			return d
+		}
+	}
+	return defaultInterval
+}
+
+// RunOnce runs a single pass synchronously and returns the number of
+// embeddings written. Useful for integration testing where time-based
+// scheduling is not practical.
+func (j *Job) RunOnce(ctx context.Context) (int, error) {
+	if j.store == nil {
+		return 0, fmt.Errorf("memory embedder: db is nil")
+	}
+	return j.run(ctx)
+}
@@ -0,0 +1,96 @@
+package memoryembedder
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	dbgorm "github.com/thebtf/engram/internal/db/gorm"
+	"github.com/thebtf/engram/internal/embedding"
+	"github.com/thebtf/engram/pkg/models"
+)
+
+// testEmbedderDB opens a migrated postgres test DB.
+// Tests skip when DATABASE_DSN is not set.
+func testEmbedderDB(t *testing.T) (*gorm.DB, func()) {
+	t.Helper()
+	dsn := os.Getenv("DATABASE_DSN")
+	if dsn == "" {
+		t.Skip("DATABASE_DSN not set, skipping memory embedder integration test")
+	}
+
+	store, err := dbgorm.NewStore(dbgorm.Config{DSN: dsn, MaxConns: 2, LogLevel: logger.Silent})
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	return store.DB, func() { _ = store.Close() }
+}
+
+func TestJob_EmbedsNewAndEditedMemories(t *testing.T) {
+	db, cleanup := testEmbedderDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	project := fmt.Sprintf("memory-embedder-%d", time.Now().UnixNano())
+	defer db.Exec(`DELETE FROM memories WHERE project = ?`, project)
+
+	ms := dbgorm.NewMemoryStore(&dbgorm.Store{DB: db})
+	mem, err := ms.Create(ctx, &models.Memory{Project: project, Content: "connection pool exhausted under load"})
+	if err != nil {
+		t.Fatalf("create memory: %v", err)
+	}
+
+	embedder := embedding.NewLocal(64)
+	job := New(db, embedder)
+	if _, err := job.RunOnce(ctx); err != nil {
+		t.Fatalf("RunOnce: %v", err)
+	}
+
+	query, _ := embedding.EmbedOne(ctx, embedder, "connection pool exhausted")
+	hits, err := ms.SearchVector(ctx, dbgorm.MemoryVectorParams{Project: project, Model: embedder.Name(), Vector: query, Limit: 5})
+	if err != nil {
+		t.Fatalf("SearchVector: %v", err)
+	}
+	if len(hits) != 1 || hits[0].Memory.ID != mem.ID {
+		t.Fatalf("expected memory %d to be searchable after RunOnce, got %+v", mem.ID, hits)
+	}
+
+	if _, err := ms.Update(ctx, &models.Memory{ID: mem.ID, Content: "dashboard palette follows the brand colours"}); err != nil {
+		t.Fatalf("update memory: %v", err)
+	}
+	written, err := job.RunOnce(ctx)
+	if err != nil {
+		t.Fatalf("RunOnce after edit: %v", err)
+	}
+	if written < 1 {
+		t.Fatalf("expected the edited memory to be re-embedded, wrote %d", written)
+	}
+}
+
+func TestJob_NilDB(t *testing.T) {
+	t.Parallel()
+
+	if _, err := New(nil, embedding.NewLocal(0)).RunOnce(context.Background()); err == nil {
+		t.Fatal("expected error for nil db")
+	}
+}
+
+func TestRunInterval(t *testing.T) {
+	t.Setenv("ENGRAM_MEMORY_EMBED_INTERVAL", "")
+	if got := runInterval(); got != defaultInterval {
+		t.Errorf("default interval = %v, want %v", got, defaultInterval)
+	}
+	t.Setenv("ENGRAM_MEMORY_EMBED_INTERVAL", "30s")
+	if got := runInterval(); got != 30*time.Second {
+		t.Errorf("interval = %v, want 30s", got)
+	}
+	t.Setenv("ENGRAM_MEMORY_EMBED_INTERVAL", "1s")
+	if got := runInterval(); got != defaultInterval {
+		t.Errorf("sub-5s interval = %v, want default", got)
+	}
+}
@@ -0,0 +1,130 @@
+// Package memorysweeper provides a periodic job that soft-deletes memories whose
+// TTL has elapsed (expires_at <= now, migration 106).
+//
+// Reads already hide expired memories, so the sweeper only has to keep the table
+// tidy: it converts expired rows into ordinary soft-deleted rows, which keeps the
+// partial indexes on deleted_at IS NULL small and makes expiry visible in the
+// dashboard the same way a manual delete is.
+package memorysweeper
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+
+	dbgorm "github.com/thebtf/engram/internal/db/gorm"
+)
+
+// defaultSweepInterval is how often the sweeper runs when
+// ENGRAM_MEMORY_SWEEP_INTERVAL is unset or invalid.
+const defaultSweepInterval = 15 * time.Minute
+
+// Sweeper periodically soft-deletes expired memories.
+type Sweeper struct {
+	store *dbgorm.MemoryStore
+	stop  chan struct{}
+	done  chan struct{}
+}
+
+// New creates a Sweeper backed by the given database connection.
+func New(db *gorm.DB) *Sweeper {
+	var store *dbgorm.MemoryStore
+	if db != nil {
+		store = dbgorm.NewMemoryStore(&dbgorm.Store{DB: db})
+	}
+	return &Sweeper{
+		store: store,
+		stop:  make(chan struct{}),
+		done:  make(chan struct{}),
+	}
+}
+
+// Start launches the sweeper loop in a background goroutine. It respects ctx for
+// graceful shutdown and also responds to Stop(). Returns immediately.
+func (s *Sweeper) Start(ctx context.Context) {
+	interval := sweepInterval()
+	log.Info().
+		Dur("interval", interval).
+		Msg("memory expiry sweeper started")
+
+	go func() {
+		defer close(s.done)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				log.Info().Msg("memory expiry sweeper stopped (context cancelled)")
+				return
+			case <-s.stop:
+				log.Info().Msg("memory expiry sweeper stopped")
+				return
+			case <-ticker.C:
+				if _, err := s.sweep(ctx); err != nil {
+					log.Error().Err(err).Msg("memory expiry sweeper: sweep failed")
+				}
+			}
+		}
+	}()
+}
+
+// Stop signals the sweeper to cease and waits for the goroutine to exit.
+func (s *Sweeper) Stop() {
+	select {
+	case <-s.stop:
+		// Already closed — idempotent.
+	default:
+		close(s.stop)
+	}
+	<-s.done
+}
+
+// sweep soft-deletes memories that expired before now.
+// It is idempotent and safe to call concurrently.
+func (s *Sweeper) sweep(ctx context.Context) (int64, error) {
+	if s.store == nil {
+		return 0, nil
+	}
+
+	now := time.Now().UTC()
+	swept, err := s.store.SoftDeleteExpired(ctx, now)
+	if err != nil {
+		return 0, err
+	}
+
+	if swept > 0 {
+		log.Info().
+			Int64("expired", swept).
+			Time("now", now).
+			Msg("memory expiry sweeper: soft-deleted expired memories")
+	}
+	return swept, nil
+}
+
+// sweepInterval returns the configured sweep interval.
+// Reads ENGRAM_MEMORY_SWEEP_INTERVAL (a Go duration such as "5m");
+// falls back to defaultSweepInterval.
+func sweepInterval() time.Duration {
+	if v := os.Getenv("ENGRAM_MEMORY_SWEEP_INTERVAL"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d >= time.Minute {
+			return d
+		}
+	}
+	return defaultSweepInterval
+}
+
+// SweepOnce runs a single sweep synchronously and returns the number of
+// memories soft-deleted. Useful for integration testing where time-based
+// scheduling is not practical.
+func (s *Sweeper) SweepOnce(ctx context.Context) (int64, error) {
+	if s.store == nil {
+		return 0, fmt.Errorf("memory sweeper: db is nil")
+	}
+	return s.sweep(ctx)
+}
@@ -0,0 +1,90 @@
+package memorysweeper
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	dbgorm "github.com/thebtf/engram/internal/db/gorm"
+	"github.com/thebtf/engram/pkg/models"
+)
+
+// testSweeperDB opens a migrated postgres test DB.
+// Tests skip when DATABASE_DSN is not set.
+func testSweeperDB(t *testing.T) (*gorm.DB, func()) {
+	t.Helper()
+	dsn := os.Getenv("DATABASE_DSN")
+	if dsn == "" {
+		t.Skip("DATABASE_DSN not set, skipping memory sweeper integration test")
+	}
+
+	store, err := dbgorm.NewStore(dbgorm.Config{DSN: dsn, MaxConns: 2, LogLevel: logger.Silent})
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	return store.DB, func() { _ = store.Close() }
+}
+
+func TestSweeper_SoftDeletesExpired(t *testing.T) {
+	db, cleanup := testSweeperDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	project := fmt.Sprintf("memory-sweeper-%d", time.Now().UnixNano())
+	defer db.Exec(`DELETE FROM memories WHERE project = ?`, project)
+
+	ms := dbgorm.NewMemoryStore(&dbgorm.Store{DB: db})
+	past := time.Now().UTC().Add(-time.Minute)
+	future := time.Now().UTC().Add(time.Hour)
+
+	expired, err := ms.Create(ctx, &models.Memory{Project: project, Content: "expired", ExpiresAt: &past})
+	if err != nil {
+		t.Fatalf("create expired: %v", err)
+	}
+	live, err := ms.Create(ctx, &models.Memory{Project: project, Content: "live", ExpiresAt: &future})
+	if err != nil {
+		t.Fatalf("create live: %v", err)
+	}
+
+	swept, err := New(db).SweepOnce(ctx)
+	if err != nil {
+		t.Fatalf("SweepOnce: %v", err)
+	}
+	if swept < 1 {
+		t.Fatalf("expected at least one expired memory swept, got %d", swept)
+	}
+	if _, err := ms.Get(ctx, expired.ID); err == nil {
+		t.Errorf("expired memory %d still active after sweep", expired.ID)
+	}
+	if _, err := ms.Get(ctx, live.ID); err != nil {
+		t.Errorf("live memory %d was swept: %v", live.ID, err)
+	}
+}
+
+func TestSweeper_NilDB(t *testing.T) {
+	t.Parallel()
+
+	if _, err := New(nil).SweepOnce(context.Background()); err == nil {
+		t.Fatal("expected error for nil db")
+	}
+}
+
+func TestSweepInterval(t *testing.T) {
+	t.Setenv("ENGRAM_MEMORY_SWEEP_INTERVAL", "")
+	if got := sweepInterval(); got != defaultSweepInterval {
+		t.Errorf("default interval = %v, want %v", got, defaultSweepInterval)
+	}
+	t.Setenv("ENGRAM_MEMORY_SWEEP_INTERVAL", "5m")
+	if got := sweepInterval(); got != 5*time.Minute {
+		t.Errorf("interval = %v, want 5m", got)
+	}
+	t.Setenv("ENGRAM_MEMORY_SWEEP_INTERVAL", "10s")
+	if got := sweepInterval(); got != defaultSweepInterval {
+		t.Errorf("sub-minute interval = %v, want default", got)
+	}
+}
@@ -110,15 +110,6 @@ func MaxBodySize(maxBytes int64) func(http.Handler) http.Handler {
 	}
 }
 
-// readOnlyAllowedPosts is the set of POST endpoints that read-only client tokens may call.
-// These are search/analytics endpoints that use POST for request bodies but do not mutate state.
-var readOnlyAllowedPosts = map[string]bool{
-	"/api/context/search":         true,
-	"/api/context/inject":         true,
-	"/api/decisions/search":       true,
-	"/api/analytics/search-misses": true,
-}
-
 // TokenAuth provides token-based authentication for the worker HTTP API.
 // Supports five auth methods:
 //  1. Master operator key (ENGRAM_AUTH_ADMIN_TOKEN env var) via X-Auth-Token or Authorization: Bearer header -> admin (Source=master)
@@ -186,6 +177,9 @@ func NewTokenAuth(token string) (*TokenAuth, error) {
 			"/api/auth/setup":           true,
 			"/api/auth/user-login":      true,
 			"/api/auth/register":        true,
+			"/api/auth/oidc/config":     true,
+			"/api/auth/oidc/login":      true,
+			"/api/auth/oidc/callback":   true,
 		},
 	}
 
@@ -326,7 +320,8 @@ func (ta *TokenAuth) Middleware(next http.Handler) http.Handler {
 				switch {
 				case errors.Is(err, authpkg.ErrEmptyToken),
 					errors.Is(err, authpkg.ErrInvalidCredentials),
-					errors.Is(err, authpkg.ErrRevoked):
+					errors.Is(err, authpkg.ErrRevoked),
+					errors.Is(err, authpkg.ErrExpired):
 					// Auth-class errors — bearer was syntactically present
 					// but failed validation. 401 is correct.
 					log.Warn().
@@ -350,14 +345,8 @@ func (ta *TokenAuth) Middleware(next http.Handler) http.Handler {
 				return
 			}
 
-			// Read-only scope gate (FR-6 inheriting v5 behaviour). Applies
-			// to client keycards only — operator key is always admin.
-			if id.Source == authpkg.SourceClient && id.Role == authpkg.RoleReadOnly {
-				if isMutatingMethod(r.Method) && !readOnlyAllowedPosts[r.URL.Path] {
-					http.Error(w, "forbidden: read-only token", http.StatusForbidden)
-					return
-				}
-			}
+			// What the keycard's role may do is enforced per route by
+			// requirePermission, after routing.
 
 			// Stats increment for client keycards only.
 			if id.Source == authpkg.SourceClient && id.KeycardID != "" {
@@ -389,7 +378,11 @@ func (ta *TokenAuth) Middleware(next http.Handler) http.Handler {
 			if authCookie, err := r.Cookie("engram_auth"); err == nil && authCookie.Value != "" {
 				if sess, err := authSessStore.GetSession(authCookie.Value); err == nil {
 					if user, err := uStore.GetUserByID(sess.UserID); err == nil && !user.Disabled {
-						id := authpkg.Session(user.Role)
+						id, err := sessionIdentity(r, validator, user.Role)
+						if err != nil {
+							http.Error(w, "auth store unavailable", http.StatusInternalServerError)
+							return
+						}
 						next.ServeHTTP(w, r.WithContext(buildAuthCtx(r.Context(), id)))
 						return
 					}
@@ -416,7 +409,11 @@ func (ta *TokenAuth) Middleware(next http.Handler) http.Handler {
 					user, err = uStore.CreateUser(authentikEmail, "", "operator")
 				}
 				if err == nil && user != nil && !user.Disabled {
-					id := authpkg.Session(user.Role)
+					id, err := sessionIdentity(r, validator, user.Role)
+					if err != nil {
+						http.Error(w, "auth store unavailable", http.StatusInternalServerError)
+						return
+					}
 					next.ServeHTTP(w, r.WithContext(buildAuthCtx(r.Context(), id)))
 					return
 				}
@@ -429,6 +426,19 @@ func (ta *TokenAuth) Middleware(next http.Handler) http.Handler {
 	})
 }
 
+// sessionIdentity resolves a logged-in user's role to an Identity, including
+// custom roles when the validator is wired.
+func sessionIdentity(r *http.Request, validator *authpkg.Validator, role string) (authpkg.Identity, error) {
+	if validator == nil {
+		return authpkg.Session(role), nil
+	}
+	id, err := validator.SessionIdentity(r.Context(), role)
+	if err != nil {
+		log.Error().Str("path", r.URL.Path).Str("role", role).Err(err).Msg("auth: role lookup failed")
+	}
+	return id, err
+}
+
 // extractHTTPBearer pulls the bearer string from (1) X-Auth-Token header,
 // (2) Authorization: Bearer header, (3) ?token= query for SSE endpoints.
 // Empty string when no bearer is present.
@@ -449,7 +459,7 @@ func extractHTTPBearer(r *http.Request) string {
 }
 
 // mutatingMethods is the set of HTTP verbs that change server state. Used to
-// gate read-only keycards (FR-6 inheriting v5 behaviour).
+// pick a route's write permission (see routePermission).
 var mutatingMethods = []string{"POST", "PUT", "DELETE", "PATCH"}
 
 // isMutatingMethod returns true for HTTP verbs in mutatingMethods.
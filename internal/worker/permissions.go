@@ -0,0 +1,92 @@
+package worker
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rs/zerolog/log"
+
+	authpkg "github.com/thebtf/engram/internal/auth"
+)
+
+// routePermissions maps chi route patterns, by longest listed prefix, to the
+// permission needed to read them and to change them. Routes not listed need
+// memory:read to read and memory:write to change. A zero permission leaves
+// the route open to every authenticated caller.
+var routePermissions = []struct {
+	prefix      string
+	read, write authpkg.Permission
+}{
+	{prefix: "/api/auth/me"},
+	{"/api/auth/roles", "", authpkg.PermAdminUsers},
+	{"/api/auth/tokens", authpkg.PermAdminTokens, authpkg.PermAdminTokens},
+	{"/api/admin/", authpkg.PermAdminUsers, authpkg.PermAdminUsers},
+	{"/api/vault/access-log", authpkg.PermVaultAdmin, authpkg.PermVaultAdmin},
+	{"/api/vault/rotate", authpkg.PermVaultAdmin, authpkg.PermVaultAdmin},
+	{"/api/vault/data-keys/", authpkg.PermVaultAdmin, authpkg.PermVaultAdmin},
+	{"/api/vault/credentials/{name}/policy", authpkg.PermVaultAdmin, authpkg.PermVaultAdmin},
+	{"/api/vault/", authpkg.PermVaultRead, authpkg.PermVaultWrite},
+	{"/api/issues", authpkg.PermIssuesRead, authpkg.PermIssuesWrite},
+	{"/api/instincts/", authpkg.PermMemoryRead, authpkg.PermRulesWrite},
+	{"/api/collections/", authpkg.PermDocsRead, authpkg.PermDocsWrite},
+	{"/api/webhooks", authpkg.PermMemoryRead, authpkg.PermSystemWrite},
+	{"/api/update/", authpkg.PermMemoryRead, authpkg.PermSystemWrite},
+	{"/api/restart", authpkg.PermSystemWrite, authpkg.PermSystemWrite},
+	{"/api/projects", authpkg.PermMemoryRead, authpkg.PermSystemWrite},
+}
+
+// readOnlyAllowedPosts is the set of POST endpoints that only read: search
+// and analytics endpoints that use POST for request bodies but do not mutate
+// state. They need the read permission of their route.
+var readOnlyAllowedPosts = map[string]bool{
+	"/api/context/search":          true,
+	"/api/context/inject":          true,
+	"/api/decisions/search":        true,
+	"/api/analytics/search-misses": true,
+}
+
+// routePermission returns the permission a request with method to the route
+// pattern needs, or "" when any authenticated caller may make it.
+func routePermission(method, pattern string) authpkg.Permission {
+	write := isMutatingMethod(method) && !readOnlyAllowedPosts[pattern]
+	best := -1
+	for i, rp := range routePermissions {
+		if strings.HasPrefix(pattern, rp.prefix) && (best < 0 || len(rp.prefix) > len(routePermissions[best].prefix)) {
+			best = i
+		}
+	}
+	switch {
+	case best >= 0 && write:
+		return routePermissions[best].write
+	case best >= 0:
+		return routePermissions[best].read
+	case write:
+		return authpkg.PermMemoryWrite
+	default:
+		return authpkg.PermMemoryRead
+	}
+}
+
+// requirePermission enforces the caller's role on the routes it wraps (see
+// routePermissions) through authpkg.Authorize. It runs after routing so it
+// can classify the request by route pattern.
+func requirePermission(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		perm := routePermission(r.Method, chi.RouteContext(r.Context()).RoutePattern())
+		if perm == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if err := authpkg.Authorize(r.Context(), perm); err != nil {
+			log.Warn().
+				Str("path", r.URL.Path).
+				Str("role", authpkg.RoleFrom(r.Context())).
+				Err(err).
+				Msg("auth: permission denied")
+			http.Error(w, "forbidden: "+strings.TrimPrefix(err.Error(), "auth: "), http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,88 @@
+package worker
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+
+	authpkg "github.com/thebtf/engram/internal/auth"
+)
+
+func TestRoutePermission(t *testing.T) {
+	cases := []struct {
+		method, pattern string
+		want            authpkg.Permission
+	}{
+		{"GET", "/api/memories", authpkg.PermMemoryRead},
+		{"POST", "/api/memories", authpkg.PermMemoryWrite},
+		{"POST", "/api/context/search", authpkg.PermMemoryRead},
+		{"GET", "/api/issues/{id}", authpkg.PermIssuesRead},
+		{"POST", "/api/issues", authpkg.PermIssuesWrite},
+		{"GET", "/api/vault/credentials/{name}", authpkg.PermVaultRead},
+		{"DELETE", "/api/vault/credentials/{name}", authpkg.PermVaultWrite},
+		{"PATCH", "/api/vault/credentials/{name}/policy", authpkg.PermVaultAdmin},
+		{"GET", "/api/vault/access-log", authpkg.PermVaultAdmin},
+		{"PUT", "/api/collections/{collection}/documents", authpkg.PermDocsWrite},
+		{"POST", "/api/auth/tokens", authpkg.PermAdminTokens},
+		{"GET", "/api/auth/roles", ""},
+		{"PUT", "/api/auth/roles/{name}", authpkg.PermAdminUsers},
+		{"GET", "/api/auth/me", ""},
+		{"POST", "/api/restart", authpkg.PermSystemWrite},
+	}
+	for _, c := range cases {
+		assert.Equal(t, c.want, routePermission(c.method, c.pattern), "%s %s", c.method, c.pattern)
+	}
+}
+
+func TestRequirePermission(t *testing.T) {
+	ci := authpkg.Identity{
+		Role:        "ci",
+		Source:      authpkg.SourceClient,
+		Permissions: []authpkg.Permission{authpkg.PermIssuesRead, authpkg.PermIssuesWrite},
+	}
+	ok := func(w http.ResponseWriter, _ *http.Request) {}
+	router := chi.NewRouter()
+	router.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			id := ci
+			if r.Header.Get("X-Test-Read-Only") != "" {
+				id = authpkg.Client("read-only", "uuid-ro")
+			}
+			next.ServeHTTP(w, r.WithContext(authpkg.WithIdentity(r.Context(), id)))
+		})
+	})
+	router.Group(func(r chi.Router) {
+		r.Use(requirePermission)
+		r.Post("/api/issues", ok)
+		r.Get("/api/vault/credentials/{name}", ok)
+		r.Post("/api/memories", ok)
+		r.Post("/api/context/search", ok)
+	})
+
+	cases := []struct {
+		name, method, target string
+		readOnly             bool
+		want                 int
+	}{
+		{name: "ci files an issue", method: "POST", target: "/api/issues", want: http.StatusOK},
+		{name: "ci reads the vault", method: "GET", target: "/api/vault/credentials/db", want: http.StatusForbidden},
+		{name: "ci stores a memory", method: "POST", target: "/api/memories", want: http.StatusForbidden},
+		{name: "read-only reads the vault", method: "GET", target: "/api/vault/credentials/db", readOnly: true, want: http.StatusOK},
+		{name: "read-only searches", method: "POST", target: "/api/context/search", readOnly: true, want: http.StatusOK},
+		{name: "read-only stores a memory", method: "POST", target: "/api/memories", readOnly: true, want: http.StatusForbidden},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			req := httptest.NewRequest(c.method, c.target, nil)
+			if c.readOnly {
+				req.Header.Set("X-Test-Read-Only", "1")
+			}
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+			assert.Equal(t, c.want, rr.Code, rr.Body.String())
+		})
+	}
+}
@@ -19,6 +19,11 @@ const (
 	// EventTypeRemoved is emitted when a project is soft-deleted via
 	// DELETE /api/projects/{id}.
 	EventTypeRemoved EventType = "project_removed"
+
+	// EventTypeIssueEscalated is emitted when the SLA escalation job raises an
+	// issue targeted at the project. Metadata carries issue_id, priority and
+	// old_priority. It is not streamed to daemons.
+	EventTypeIssueEscalated EventType = "issue_escalated"
 )
 
 // Event carries data for a single project lifecycle transition.
@@ -9,6 +9,7 @@ import (
 	"net"
 	"net/http"
 	"os"
+	"strconv"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -20,6 +21,7 @@ import (
 	"github.com/soheilhy/cmux"
 	httpSwagger "github.com/swaggo/http-swagger"
 	"github.com/thebtf/engram/internal/auth"
+	"github.com/thebtf/engram/internal/auth/oidc"
 	"github.com/thebtf/engram/internal/chunking"
 	gochunking "github.com/thebtf/engram/internal/chunking/golang"
 	mdchunking "github.com/thebtf/engram/internal/chunking/markdown"
@@ -27,6 +29,7 @@ import (
 	"github.com/thebtf/engram/internal/config"
 	"github.com/thebtf/engram/internal/crypto"
 	"github.com/thebtf/engram/internal/db/gorm"
+	"github.com/thebtf/engram/internal/embedding"
 	"github.com/thebtf/engram/internal/grpcserver"
 	"github.com/thebtf/engram/internal/logbuf"
 	"github.com/thebtf/engram/internal/mcp"
@@ -34,6 +37,12 @@ import (
 	"github.com/thebtf/engram/internal/telemetry"
 	"github.com/thebtf/engram/internal/update"
 	"github.com/thebtf/engram/internal/watcher"
+	"github.com/thebtf/engram/internal/webhooks"
+	"github.com/thebtf/engram/internal/worker/issueleases"
+	"github.com/thebtf/engram/internal/worker/issuesla"
+	"github.com/thebtf/engram/internal/worker/keycardwatch"
+	"github.com/thebtf/engram/internal/worker/memoryembedder"
+	"github.com/thebtf/engram/internal/worker/memorysweeper"
 	"github.com/thebtf/engram/internal/worker/projectevents"
 	"github.com/thebtf/engram/internal/worker/reaper"
 	"github.com/thebtf/engram/internal/worker/sdk"
@@ -98,6 +107,7 @@ type Service struct {
 	retrievalStats         map[string]*RetrievalStats
 	sessionStore           *gorm.SessionStore
 	tokenStore             *gorm.TokenStore
+	roleStore              *gorm.RoleStore
 	cancel                 context.CancelFunc
 	cachedObsCounts        map[string]cachedCount
 	config                 *config.Config
@@ -134,13 +144,26 @@ type Service struct {
 	vault                  *crypto.Vault
 	issueStore             *gorm.IssueStore
 	credentialStore        *gorm.CredentialStore
+	credentialDataKeys     *gorm.CredentialDataKeyStore
+	keyring                *crypto.Keyring
 	memoryStore            *gorm.MemoryStore
+	documentStore          *gorm.DocumentStore
 	behavioralRulesStore   *gorm.BehavioralRulesStore
 	vaultOnce              sync.Once
 	vaultErr               error
+	vaultMu                sync.RWMutex // guards vault and keyring after key rotation swaps them
+	vaultRotateMu          sync.Mutex   // one key rotation at a time
 	promptCache            sync.Map // map[int64]promptCacheEntry — last user prompt per session
 	eventBus               *projectevents.Bus
 	projectReaper          *reaper.Reaper
+	memorySweeper          *memorysweeper.Sweeper
+	issueLeaseSweeper      *issueleases.Sweeper
+	issueSLAEscalator      *issuesla.Escalator
+	keycardWatcher         *keycardwatch.Watcher
+	webhookStore           *gorm.WebhookStore
+	webhookPublisher       *webhooks.Publisher
+	webhookDeliverer       *webhooks.Deliverer
+	memoryEmbedder         *memoryembedder.Job
 }
 
 // promptCacheEntry stores a user prompt with a timestamp for eviction.
@@ -181,13 +204,50 @@ type cachedCount struct {
 
 // getVault returns the shared Vault singleton, initializing it once on first call.
 // All errors are cached — a misconfigured vault fails permanently (no retry).
+// The MCP server shares this vault, so a key rotation (setVault) applies to both.
 func (s *Service) getVault() (*crypto.Vault, error) {
 	s.vaultOnce.Do(func() {
 		s.vault, s.vaultErr = crypto.NewVault(s.config)
 	})
+	s.vaultMu.RLock()
+	defer s.vaultMu.RUnlock()
 	return s.vault, s.vaultErr
 }
 
+// setVault replaces the shared Vault and keyring. Used by key rotation. A nil
+// kr makes getKeyring rebuild the keyring from v and the configured KEK.
+func (s *Service) setVault(v *crypto.Vault, kr *crypto.Keyring) {
+	s.vaultOnce.Do(func() {})
+	s.vaultMu.Lock()
+	s.vault, s.vaultErr = v, nil
+	s.keyring = kr
+	s.vaultMu.Unlock()
+}
+
+// getKeyring returns the envelope-encryption keyring: per-project data keys
+// wrapped by the KEK selected with ENGRAM_VAULT_KEK, with the shared Vault's
+// master key for credentials that predate envelope encryption. It is built on
+// first use and shared with the MCP server.
+func (s *Service) getKeyring() (*crypto.Keyring, error) {
+	if _, err := s.getVault(); err != nil {
+		return nil, err
+	}
+	s.vaultMu.Lock()
+	defer s.vaultMu.Unlock()
+	if s.keyring != nil {
+		return s.keyring, nil
+	}
+	if s.credentialDataKeys == nil {
+		return nil, fmt.Errorf("credential data key store not available")
+	}
+	kek, err := crypto.NewKEK(s.config, s.vault)
+	if err != nil {
+		return nil, fmt.Errorf("vault KEK: %w", err)
+	}
+	s.keyring = crypto.NewKeyring(kek, s.credentialDataKeys, s.vault)
+	return s.keyring, nil
+}
+
 // staleVerifyRequest represents a request to verify a stale observation in background
 type staleVerifyRequest struct {
 	cwd           string
@@ -347,6 +407,7 @@ func (s *Service) initializeAsync() {
 	userStore := gorm.NewUserStore(store.DB)
 	invitationStore := gorm.NewInvitationStore(store.DB)
 	authSessionStore := gorm.NewAuthSessionStore(store.DB)
+	roleStore := gorm.NewRoleStore(store.DB)
 
 	// Create injection store for closed-loop learning
 	injectionStore := gorm.NewInjectionStore(store.GetDB())
@@ -360,6 +421,10 @@ func (s *Service) initializeAsync() {
 	// Create issue store for cross-project agent issues
 	issueStore := gorm.NewIssueStore(store.GetDB())
 
+	// Create webhook store and publisher for outbound event webhooks
+	webhookStore := gorm.NewWebhookStore(store.GetDB())
+	webhookPublisher := webhooks.NewPublisher(store.GetDB())
+
 	// Create reasoning trace store for System 2 memory (reasoning chains)
 	reasoningStore := gorm.NewReasoningTraceStore(store)
 
@@ -368,6 +433,7 @@ func (s *Service) initializeAsync() {
 	memoryStore := gorm.NewMemoryStore(store)
 	behavioralRulesStore := gorm.NewBehavioralRulesStore(store)
 	credentialStore := gorm.NewCredentialStore(store)
+	credentialDataKeys := gorm.NewCredentialDataKeyStore(store)
 
 	// Set all the initialized components
 	s.initMu.Lock()
@@ -375,12 +441,16 @@ func (s *Service) initializeAsync() {
 	s.sessionStore = sessionStore
 	s.injectionStore = injectionStore
 	s.issueStore = issueStore
+	s.webhookStore = webhookStore
+	s.webhookPublisher = webhookPublisher
 	s.credentialStore = credentialStore
+	s.credentialDataKeys = credentialDataKeys
 	s.memoryStore = memoryStore
 	s.behavioralRulesStore = behavioralRulesStore
 	s.agentStatsStore = agentStatsStore
 	s.versionStore = versionStore
 	s.tokenStore = tokenStore
+	s.roleStore = roleStore
 	s.relationStore = relationStore
 	s.sessionManager = sessionManager
 	s.processor = processor
@@ -396,6 +466,26 @@ func (s *Service) initializeAsync() {
 
 	// Wire email/password auth stores into TokenAuth middleware and create AuthHandlers.
 	authHandlersInstance := NewAuthHandlers(userStore, invitationStore, authSessionStore)
+	authHandlersInstance.SetRoleStore(roleStore)
+	if cfg := config.Get(); cfg.OIDCIssuer != "" && cfg.OIDCClientID != "" {
+		provider, err := oidc.New(oidc.Config{
+			IssuerURL:    cfg.OIDCIssuer,
+			ClientID:     cfg.OIDCClientID,
+			ClientSecret: cfg.OIDCClientSecret,
+			RedirectURL:  cfg.OIDCRedirectURL,
+			Scopes:       cfg.OIDCScopes,
+		})
+		if err != nil {
+			log.Error().Err(err).Msg("OIDC login disabled")
+		} else {
+			authHandlersInstance.EnableOIDC(provider, oidc.RoleMapping{
+				Claim:          cfg.OIDCRoleClaim,
+				AdminValues:    cfg.OIDCAdminValues,
+				OperatorValues: cfg.OIDCOperatorValues,
+			}, cfg.OIDCProviderName, cfg.OIDCAutoProvision)
+			log.Info().Str("issuer", cfg.OIDCIssuer).Bool("auto_provision", cfg.OIDCAutoProvision).Msg("OIDC login enabled")
+		}
+	}
 	s.initMu.Lock()
 	s.authHandlers = authHandlersInstance
 	s.initMu.Unlock()
@@ -463,6 +553,8 @@ func (s *Service) initializeAsync() {
 		ChunkManager:       chunkManager,
 	})
 	mcpServer.SetInjectionStore(injectionStore)
+	mcpServer.SetVaultFunc(s.getVault)
+	mcpServer.SetKeyringFunc(s.getKeyring)
 
 	// Wire backfill status into MCP server.
 	mcpServer.SetBackfillStatusFunc(func() (any, error) {
@@ -482,6 +574,16 @@ func (s *Service) initializeAsync() {
 	// switched from observations to memories/behavioral_rules.
 	mcpServer.SetMemoryStore(memoryStore)
 	mcpServer.SetBehavioralRulesStore(behavioralRulesStore)
+	mcpServer.SetWebhookPublisher(webhookPublisher)
+
+	// Embedder for semantic memory recall. A misconfigured provider disables
+	// vector search (recall falls back to full-text) rather than failing startup.
+	embedder, embedErr := embedding.New(config.Get())
+	if embedErr != nil {
+		log.Warn().Err(embedErr).Msg("Embedding provider misconfigured, semantic recall disabled")
+		embedder = nil
+	}
+	mcpServer.SetEmbedder(embedder)
 
 	// Wire gRPC server: create adapter over mcpServer and register with the server.
 	// initMu protects s.grpcServer — the cmux goroutine polls for it.
@@ -497,6 +599,7 @@ func (s *Service) initializeAsync() {
 	var grpcValidator *auth.Validator
 	if !strings.EqualFold(strings.TrimSpace(os.Getenv("ENGRAM_AUTH_DISABLED")), "true") {
 		grpcValidator = auth.NewValidator(config.GetWorkerToken(), tokenStore)
+		grpcValidator.SetRoleSource(roleStore)
 	}
 	// Share the validator with the HTTP middleware (FR-2: symmetric validation).
 	if s.tokenAuth != nil && grpcValidator != nil {
@@ -512,6 +615,7 @@ func (s *Service) initializeAsync() {
 
 	s.initMu.Lock()
 	s.collectionRegistry = collectionRegistry
+	s.documentStore = documentStore
 	s.sessionIdxStore = sessionIdxStore
 	s.searchQueryLogStore = searchQueryLogStore
 	s.retrievalStatsLogStore = retrievalStatsLogStore
@@ -526,6 +630,80 @@ func (s *Service) initializeAsync() {
 	s.projectReaper = projectReaper
 	projectReaper.Start(s.ctx)
 
+	// Start memory expiry sweeper (soft-deletes memories past their expires_at).
+	memorySweeper := memorysweeper.New(store.DB)
+	s.memorySweeper = memorySweeper
+	memorySweeper.Start(s.ctx)
+
+	// Start issue lease sweeper (releases issue claims whose lease expired).
+	issueLeaseSweeper := issueleases.New(store.DB)
+	s.issueLeaseSweeper = issueLeaseSweeper
+	issueLeaseSweeper.Start(s.ctx)
+
+	// Start issue SLA escalator (raises the priority of issues past their SLA)
+	// and announce each escalation on SSE, the event bus and webhooks.
+	issueSLAEscalator := issuesla.New(store.DB)
+	issueSLAEscalator.SetOnEscalated(func(esc gorm.IssueEscalation) {
+		s.sseBroadcaster.Broadcast(map[string]any{
+			"type":         "issue",
+			"action":       "escalated",
+			"id":           esc.Issue.ID,
+			"project":      esc.Issue.TargetProject,
+			"priority":     esc.Issue.Priority,
+			"old_priority": esc.OldPriority,
+			"breach":       esc.Breach,
+		})
+		s.eventBus.Emit(projectevents.Event{
+			EventType:       projectevents.EventTypeIssueEscalated,
+			ProjectID:       esc.Issue.TargetProject,
+			TimestampUnixMs: time.Now().UnixMilli(),
+			Reason:          "sla_" + esc.Breach,
+			Metadata: map[string]string{
+				"issue_id":     strconv.FormatInt(esc.Issue.ID, 10),
+				"priority":     esc.Issue.Priority,
+				"old_priority": esc.OldPriority,
+			},
+		})
+		webhookPublisher.PublishIssue(context.Background(), webhooks.EventIssueEscalated, &esc.Issue)
+	})
+	s.issueSLAEscalator = issueSLAEscalator
+	issueSLAEscalator.Start(s.ctx)
+
+	// Start keycard watcher (flags unused keycards and usage spikes) and
+	// announce each alert on SSE for the dashboard.
+	keycardWatcher := keycardwatch.New(store.DB)
+	keycardWatcher.SetOnAlert(func(a gorm.APITokenAlert) {
+		s.sseBroadcaster.Broadcast(map[string]any{
+			"type":       "keycard",
+			"action":     "alert",
+			"id":         a.ID,
+			"token_id":   a.TokenID,
+			"token_name": a.TokenName,
+			"kind":       a.Kind,
+			"message":    a.Message,
+		})
+	})
+	s.keycardWatcher = keycardWatcher
+	keycardWatcher.Start(s.ctx)
+
+	// Start webhook deliverer (posts queued webhook deliveries, retrying with
+	// backoff) and forward project removals from the event bus to webhooks.
+	webhookDeliverer := webhooks.New(store.DB)
+	s.webhookDeliverer = webhookDeliverer
+	webhookDeliverer.Start(s.ctx)
+	s.eventBus.Subscribe(func(ev projectevents.Event) {
+		if ev.EventType == projectevents.EventTypeRemoved {
+			webhookPublisher.Publish(context.Background(), webhooks.EventProjectRemoved, ev.ProjectID, ev)
+		}
+	})
+
+	// Start memory embedder (embeds memories that are new, edited or from another model).
+	if embedder != nil {
+		memoryEmbedder := memoryembedder.New(store.DB, embedder)
+		s.memoryEmbedder = memoryEmbedder
+		memoryEmbedder.Start(s.ctx)
+	}
+
 	// Start queue processor if SDK processor is available
 	if processor != nil {
 		s.wg.Add(1)
@@ -756,11 +934,17 @@ func (s *Service) setupRoutes() {
 	s.router.Post("/api/auth/user-login", s.handleUserLogin)
 	s.router.Post("/api/auth/user-logout", s.handleUserLogout)
 
+	// OIDC login (authorization code + PKCE); 404 unless configured.
+	s.router.Get("/api/auth/oidc/config", s.handleOIDCConfig)
+	s.router.Get("/api/auth/oidc/login", s.handleOIDCLogin)
+	s.router.Get("/api/auth/oidc/callback", s.handleOIDCCallback)
+
 	// Registration (public, requires valid invitation code)
 	s.router.Post("/api/auth/register", s.handleUserRegister)
 
 	// Admin management (requires authenticated admin session)
 	s.router.Route("/api/admin", func(r chi.Router) {
+		r.Use(requirePermission)
 		r.Post("/invitations", s.handleAdminCreateInvitation)
 		r.Get("/invitations", s.handleAdminListInvitations)
 		r.Get("/users", s.handleAdminListUsers)
@@ -787,8 +971,12 @@ func (s *Service) setupRoutes() {
 	s.router.Get("/api/docs/*", httpSwagger.WrapHandler)
 
 	// Admin/management routes — authentication applied globally via setupMiddleware.
-	// Grouped for logical organization; no additional middleware needed.
+	// Grouped for logical organization; callers need the permission in
+	// routePermissions, and project-scoped keycards are limited to the routes
+	// in keycardRoutes.
 	s.router.Group(func(r chi.Router) {
+		r.Use(requirePermission)
+		r.Use(s.requireKeycardProject)
 		// Vector metrics/health endpoints (return disabled status since vectors removed in v5)
 		r.Get("/api/vectors/health", s.handleVectorHealth)
 		r.Get("/api/vector/metrics", s.handleVectorMetrics)
@@ -830,6 +1018,8 @@ func (s *Service) setupRoutes() {
 	s.router.Group(func(r chi.Router) {
 		r.Use(s.requireReady)
 		r.Use(middleware.Timeout(DefaultHTTPTimeout))
+		r.Use(requirePermission)
+		r.Use(s.requireKeycardProject)
 
 		// Session routes
 		r.Post("/api/sessions/init", s.handleSessionInit)
@@ -874,9 +1064,30 @@ func (s *Service) setupRoutes() {
 		// Static routes must come BEFORE /{id} to avoid chi matching them as IDs.
 		r.Get("/api/issues/tracked-projects", s.handleTrackedProjects)
 		r.Post("/api/issues/acknowledge", s.handleAcknowledgeIssues)
+		r.Get("/api/issues/saved-queries", s.handleListIssueQueries)
+		r.Post("/api/issues/saved-queries", s.handleSaveIssueQuery)
+		r.Delete("/api/issues/saved-queries/{name}", s.handleDeleteIssueQuery)
+		r.Get("/api/issues/export", s.handleExportIssues)
+		r.Post("/api/issues/import", s.handleImportIssues)
 		r.Get("/api/issues/{id}", s.handleGetIssue)
 		r.Patch("/api/issues/{id}", s.handleUpdateIssue)
 		r.Delete("/api/issues/{id}", s.handleDeleteIssue)
+		r.Get("/api/issues/{id}/links", s.handleListIssueLinks)
+		r.Post("/api/issues/{id}/links", s.handleCreateIssueLink)
+		r.Delete("/api/issues/{id}/links", s.handleDeleteIssueLink)
+		r.Get("/api/issues/{id}/events", s.handleListIssueEvents)
+		r.Post("/api/issues/{id}/claim", s.handleClaimIssue)
+		r.Post("/api/issues/{id}/renew", s.handleRenewIssueLease)
+		r.Post("/api/issues/{id}/release", s.handleReleaseIssue)
+
+		// Outbound webhooks and their delivery log.
+		// Static routes must come BEFORE /{id} to avoid chi matching them as IDs.
+		r.Get("/api/webhooks", s.handleListWebhooks)
+		r.Post("/api/webhooks", s.handleCreateWebhook)
+		r.Get("/api/webhooks/deliveries", s.handleListWebhookDeliveries)
+		r.Post("/api/webhooks/deliveries/{id}/retry", s.handleRetryWebhookDelivery)
+		r.Patch("/api/webhooks/{id}", s.handleUpdateWebhook)
+		r.Delete("/api/webhooks/{id}", s.handleDeleteWebhook)
 
 		// Relation routes (knowledge graph)
 		r.Get("/api/relations/stats", s.handleGetRelationStats)
@@ -897,13 +1108,25 @@ func (s *Service) setupRoutes() {
 		r.Get("/api/auth/me", s.handleAuthMe)
 		r.Get("/api/auth/tokens", s.handleListTokens)
 		r.Post("/api/auth/tokens", s.handleCreateToken)
+		r.Get("/api/auth/tokens/alerts", s.handleListTokenAlerts)
+		r.Post("/api/auth/tokens/alerts/{id}/dismiss", s.handleDismissTokenAlert)
 		r.Delete("/api/auth/tokens/{id}", s.handleRevokeToken)
+		r.Patch("/api/auth/tokens/{id}/projects", s.handleSetTokenProjects)
+		r.Patch("/api/auth/tokens/{id}/scope", s.handleSetTokenScope)
+		r.Post("/api/auth/tokens/{id}/rotate", s.handleRotateToken)
+		r.Get("/api/auth/roles", s.handleListRoles)
+		r.Put("/api/auth/roles/{name}", s.handleSaveRole)
+		r.Delete("/api/auth/roles/{name}", s.handleDeleteRole)
 
 		// Vault routes
 		r.Get("/api/vault/credentials", s.handleListCredentials)
 		r.Get("/api/vault/credentials/{name}", s.handleGetCredential)
 		r.Post("/api/vault/credentials", s.handleStoreCredential)
 		r.Delete("/api/vault/credentials/{name}", s.handleDeleteCredential)
+		r.Patch("/api/vault/credentials/{name}/policy", s.handleSetCredentialPolicy)
+		r.Get("/api/vault/access-log", s.handleCredentialAccessLog)
+		r.Post("/api/vault/rotate", s.handleRotateVaultKey)
+		r.Post("/api/vault/data-keys/rewrap", s.handleRewrapDataKeys)
 		r.Get("/api/vault/status", s.handleVaultStatus)
 		r.Delete("/api/vault/orphaned-credentials", s.handleDeleteOrphanedCredentials)
 
@@ -911,6 +1134,16 @@ func (s *Service) setupRoutes() {
 		r.Post("/api/memories", s.handleStoreMemoryExplicit)
 		r.Get("/api/memories", s.handleListMemories)
 		r.Delete("/api/memories/{id}", s.handleDeleteMemoryByID)
+		r.Put("/api/memories/{id}", s.handleEditMemory)
+		r.Post("/api/memories/{id}/merge", s.handleMergeMemory)
+		r.Get("/api/memories/{id}/versions", s.handleListMemoryVersions)
+		r.Get("/api/memories/{id}/versions/diff", s.handleDiffMemoryVersions)
+		r.Post("/api/memories/{id}/versions/{version}/restore", s.handleRestoreMemoryVersion)
+
+		// Collection documents (bulk ingestion via engram-import ingest-collection)
+		r.Get("/api/collections/{collection}/documents", s.handleListCollectionDocuments)
+		r.Put("/api/collections/{collection}/documents", s.handleUpsertCollectionDocument)
+		r.Delete("/api/collections/{collection}/documents", s.handleDeactivateCollectionDocument)
 
 		// Token stats
 		r.Get("/api/auth/tokens/{id}/stats", s.handleGetTokenStats)
@@ -15,7 +15,33 @@ type Credential struct {
 	EncryptionKeyFingerprint string     `json:"encryption_key_fingerprint"`
 	Scope                    string     `json:"scope,omitempty"`
 	EditedBy                 string     `json:"edited_by,omitempty"`
+	Access                   string     `json:"access"`
+	AllowedProjects          []string   `json:"allowed_projects"`
+	AllowedKeycards          []string   `json:"allowed_keycards"`
 	EncryptedSecret          []byte     `json:"encrypted_secret"`
 	ID                       int64      `json:"id"`
 	Version                  int        `json:"version"`
 }
+
+// Credential access levels. Reveal lets any caller that passes the
+// credential's project and keycard restrictions decrypt it; read-only lists
+// the credential but keeps its value from everyone but admins.
+const (
+	CredentialAccessReveal   = "reveal"
+	CredentialAccessReadOnly = "read-only"
+)
+
+// CredentialDataKey is a project's credential data key, wrapped by a
+// key-encryption key (KEK). Credentials of the project are encrypted with the
+// data key; the KEK never touches them. KEKID names the KEK that wrapped the
+// key and Fingerprint identifies the unwrapped data key, matching
+// Credential.EncryptionKeyFingerprint of the credentials it encrypts.
+type CredentialDataKey struct {
+	CreatedAt   time.Time `json:"created_at"`
+	RotatedAt   time.Time `json:"rotated_at"`
+	Project     string    `json:"project"`
+	KEKID       string    `json:"kek_id"`
+	Fingerprint string    `json:"fingerprint"`
+	WrappedKey  []byte    `json:"-"`
+	ID          int64     `json:"id"`
+}
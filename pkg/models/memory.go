@@ -9,15 +9,46 @@ import "time"
 // the database and not part of the domain interface.
 //
 // Migration 088 creates this table; migration 080 populates it from observations.
+// Migration 106 adds the structured metadata columns (type, importance, expiry, rejected).
 type Memory struct {
 	CreatedAt   time.Time  `json:"created_at"`
 	UpdatedAt   time.Time  `json:"updated_at"`
 	DeletedAt   *time.Time `json:"deleted_at,omitempty"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
 	Project     string     `json:"project"`
 	Content     string     `json:"content"`
 	SourceAgent string     `json:"source_agent,omitempty"`
 	EditedBy    string     `json:"edited_by,omitempty"`
+	MemoryType  string     `json:"type,omitempty"`
 	Tags        []string   `json:"tags"`
+	Rejected    []string   `json:"rejected,omitempty"`
 	ID          int64      `json:"id"`
+	Importance  float64    `json:"importance"`
 	Version     int        `json:"version"`
 }
+
+// DefaultMemoryImportance is the importance assigned to memories stored without one.
+// Importance is a 0..1 weight; session-start and recall order by it before recency.
+const DefaultMemoryImportance = 0.5
+
+// IsExpired reports whether the memory's TTL has elapsed at the given instant.
+// Memories without an expiry never expire.
+func (m *Memory) IsExpired(now time.Time) bool {
+	return m.ExpiresAt != nil && !m.ExpiresAt.After(now)
+}
+
+// MemoryVersion is one revision of a memory as recorded in the memory_versions table.
+// A row is written for every create, edit, merge and restore, so the history of a
+// memory is the full sequence of its contents with the author of each revision.
+//
+// Migration 105 creates this table and backfills the current revision of every memory.
+type MemoryVersion struct {
+	CreatedAt time.Time `json:"created_at"`
+	Content   string    `json:"content"`
+	EditedBy  string    `json:"edited_by,omitempty"`
+	Note      string    `json:"note,omitempty"`
+	Tags      []string  `json:"tags"`
+	ID        int64     `json:"id"`
+	MemoryID  int64     `json:"memory_id"`
+	Version   int       `json:"version"`
+}
@@ -0,0 +1,124 @@
+package similarity
+
+import (
+	"math"
+	"strings"
+	"unicode"
+)
+
+// ShingleSize is the character n-gram length used for shingle similarity.
+// Trigrams tolerate typos, inflections and reordered clauses while still
+// telling apart texts that merely share vocabulary.
+const ShingleSize = 3
+
+// ShingleProfile is the character-trigram frequency vector of a text, used to
+// detect near-duplicate prose. Build it once per text with NewShingleProfile
+// and compare profiles pairwise.
+type ShingleProfile struct {
+	counts map[string]int
+	norm   float64
+}
+
+// NewShingleProfile normalises text (lower-case; every run of whitespace and
+// punctuation collapsed to a single space) and counts its character trigrams,
+// so re-wrapping or re-punctuating the same sentence leaves the profile as is.
+func NewShingleProfile(text string) ShingleProfile {
+	var b strings.Builder
+	space := true
+	for _, r := range strings.ToLower(text) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+			space = false
+		} else if !space {
+			b.WriteByte(' ')
+			space = true
+		}
+	}
+	runes := []rune(strings.TrimSpace(b.String()))
+
+	p := ShingleProfile{counts: make(map[string]int)}
+	if len(runes) == 0 {
+		return p
+	}
+	if len(runes) < ShingleSize {
+		p.counts[string(runes)] = 1
+	}
+	for i := 0; i+ShingleSize <= len(runes); i++ {
+		p.counts[string(runes[i:i+ShingleSize])]++
+	}
+	var sum float64
+	for _, n := range p.counts {
+		sum += float64(n) * float64(n)
+	}
+	p.norm = math.Sqrt(sum)
+	return p
+}
+
+// Similarity returns the cosine similarity of two profiles: 1 for texts with
+// the same trigram distribution, 0 when they share none (or either is empty).
+func (p ShingleProfile) Similarity(q ShingleProfile) float64 {
+	if p.norm == 0 || q.norm == 0 {
+		return 0
+	}
+	small, large := p.counts, q.counts
+	if len(small) > len(large) {
+		small, large = large, small
+	}
+	var dot float64
+	for shingle, n := range small {
+		dot += float64(n) * float64(large[shingle])
+	}
+	return dot / (p.norm * q.norm)
+}
+
+// ShingleSimilarity is a convenience for comparing two texts once.
+func ShingleSimilarity(a, b string) float64 {
+	return NewShingleProfile(a).Similarity(NewShingleProfile(b))
+}
+
+// ClusterBySimilarity groups profiles whose pairwise similarity is at least
+// threshold, transitively (single linkage: a~b and b~c puts a, b and c in one
+// cluster). Only clusters with two or more members are returned. Members are
+// indexes into profiles in ascending order, and clusters are ordered by their
+// first member. O(n²) comparisons; callers cap n.
+func ClusterBySimilarity(profiles []ShingleProfile, threshold float64) [][]int {
+	parent := make([]int, len(profiles))
+	for i := range parent {
+		parent[i] = i
+	}
+	find := func(i int) int {
+		for parent[i] != i {
+			parent[i] = parent[parent[i]]
+			i = parent[i]
+		}
+		return i
+	}
+
+	for i := range profiles {
+		for j := i + 1; j < len(profiles); j++ {
+			if find(i) == find(j) {
+				continue
+			}
+			if profiles[i].Similarity(profiles[j]) >= threshold {
+				parent[find(j)] = find(i)
+			}
+		}
+	}
+
+	members := make(map[int][]int)
+	roots := make([]int, 0)
+	for i := range profiles {
+		root := find(i)
+		if _, ok := members[root]; !ok {
+			roots = append(roots, root)
+		}
+		members[root] = append(members[root], i)
+	}
+	clusters := make([][]int, 0)
+	for _, root := range roots {
+		if len(members[root]) > 1 {
+			clusters = append(clusters, members[root])
+		}
+	}
+	return clusters
+}
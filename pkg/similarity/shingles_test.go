@@ -0,0 +1,53 @@
+package similarity
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShingleSimilarity(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		min  float64
+		max  float64
+	}{
+		{name: "identical", a: "Use pgx pool for DB access", b: "Use pgx pool for DB access", min: 0.999, max: 1.001},
+		{name: "reformatted", a: "Use pgx pool, not database/sql.", b: "use pgx pool not database sql", min: 0.999, max: 1.001},
+		{name: "near duplicate", a: "Always run migrations inside a transaction so a failure rolls back cleanly", b: "Always run the migrations inside a transaction so that a failure rolls back cleanly", min: 0.85, max: 1},
+		{name: "shared vocabulary", a: "Run migrations before starting the worker", b: "The worker retries failed migrations on start", min: 0.2, max: 0.7},
+		{name: "unrelated", a: "Dashboard colours follow the brand palette", b: "Rate limiter drops requests over quota", min: 0, max: 0.3},
+		{name: "empty", a: "", b: "anything", min: 0, max: 0},
+		{name: "short", a: "ok", b: "ok", min: 0.999, max: 1.001},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ShingleSimilarity(tt.a, tt.b)
+			assert.GreaterOrEqual(t, got, tt.min)
+			assert.LessOrEqual(t, got, tt.max)
+			assert.InDelta(t, got, ShingleSimilarity(tt.b, tt.a), 1e-12, "symmetric")
+		})
+	}
+}
+
+func TestClusterBySimilarity(t *testing.T) {
+	texts := []string{
+		"Always run migrations inside a transaction",       // 0
+		"Dashboard colours follow the brand palette",       // 1
+		"Always run the migrations inside a transaction",   // 2
+		"Rate limiter drops requests over quota",           // 3
+		"always run the migrations inside one transaction", // 4
+		"The dashboard colours follow the brand palette",   // 5
+	}
+	profiles := make([]ShingleProfile, len(texts))
+	for i, text := range texts {
+		profiles[i] = NewShingleProfile(text)
+	}
+
+	clusters := ClusterBySimilarity(profiles, 0.85)
+	assert.Equal(t, [][]int{{0, 2, 4}, {1, 5}}, clusters)
+
+	assert.Empty(t, ClusterBySimilarity(profiles, 1.01))
+	assert.Empty(t, ClusterBySimilarity(nil, 0.5))
+}
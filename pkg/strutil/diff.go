@@ -0,0 +1,71 @@
+package strutil
+
+import "strings"
+
+// LineDiff returns a line-oriented diff that turns a into b. Every line of the
+// output is prefixed with "  " (unchanged), "- " (only in a) or "+ " (only in b).
+// It uses a longest-common-subsequence table, which is quadratic in the number of
+// lines and intended for note-sized inputs such as memory revisions.
+// Returns the empty string when a and b are identical.
+func LineDiff(a, b string) string {
+	if a == b {
+		return ""
+	}
+	al := strings.Split(a, "\n")
+	bl := strings.Split(b, "\n")
+
+	// lcs[i][j] = length of the LCS of al[i:] and bl[j:].
+	lcs := make([][]int, len(al)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(bl)+1)
+	}
+	for i := len(al) - 1; i >= 0; i-- {
+		for j := len(bl) - 1; j >= 0; j-- {
+			if al[i] == bl[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var sb strings.Builder
+	i, j := 0, 0
+	for i < len(al) && j < len(bl) {
+		switch {
+		case al[i] == bl[j]:
+			sb.WriteString("  " + al[i] + "\n")
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			sb.WriteString("- " + al[i] + "\n")
+			i++
+		default:
+			sb.WriteString("+ " + bl[j] + "\n")
+			j++
+		}
+	}
+	for ; i < len(al); i++ {
+		sb.WriteString("- " + al[i] + "\n")
+	}
+	for ; j < len(bl); j++ {
+		sb.WriteString("+ " + bl[j] + "\n")
+	}
+	return sb.String()
+}
+
+// Difference returns the elements of a that do not appear in b, preserving the
+// order of a. The result is never nil so it marshals as a JSON array.
+func Difference(a, b []string) []string {
+	inB := make(map[string]struct{}, len(b))
+	for _, s := range b {
+		inB[s] = struct{}{}
+	}
+	out := make([]string, 0)
+	for _, s := range a {
+		if _, ok := inB[s]; !ok {
+			out = append(out, s)
+		}
+	}
+	return out
+}
@@ -0,0 +1,34 @@
+package strutil
+
+import "testing"
+
+func TestLineDiff(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		want string
+	}{
+		{"identical", "one\ntwo", "one\ntwo", ""},
+		{"append", "one", "one\ntwo", "  one\n+ two\n"},
+		{"remove", "one\ntwo\nthree", "one\nthree", "  one\n- two\n  three\n"},
+		{"replace", "alpha\nbeta", "alpha\ngamma", "  alpha\n- beta\n+ gamma\n"},
+		{"from empty", "", "new", "- \n+ new\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := LineDiff(tt.a, tt.b); got != tt.want {
+				t.Errorf("LineDiff(%q, %q) = %q, want %q", tt.a, tt.b, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDifference(t *testing.T) {
+	got := Difference([]string{"a", "b", "c", "b"}, []string{"b"})
+	if len(got) != 2 || got[0] != "a" || got[1] != "c" {
+		t.Errorf("Difference = %v, want [a c]", got)
+	}
+	if got := Difference(nil, []string{"x"}); got == nil || len(got) != 0 {
+		t.Errorf("Difference(nil, ...) = %#v, want empty non-nil slice", got)
+	}
+}
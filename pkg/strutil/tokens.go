@@ -0,0 +1,47 @@
+package strutil
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// charsPerToken is the ~4 characters per token heuristic used for English text.
+const charsPerToken = 4
+
+// EstimateTokens estimates the token count of chars characters of text,
+// rounding up. It is a cheap heuristic for budgeting context payloads,
+// not a tokenizer.
+func EstimateTokens(chars int) int {
+	if chars <= 0 {
+		return 0
+	}
+	return (chars + charsPerToken - 1) / charsPerToken
+}
+
+// TruncateToTokens shortens s so that EstimateTokens(len(result)) <= tokens.
+// It cuts on a UTF-8 boundary, backs up to the last line or word break in the
+// final fifth of the kept text when there is one, and appends "…" (which is
+// counted against the budget). Returns s unchanged when it already fits and
+// the empty string when tokens <= 0.
+func TruncateToTokens(s string, tokens int) string {
+	if tokens <= 0 {
+		return ""
+	}
+	maxChars := tokens * charsPerToken
+	if len(s) <= maxChars {
+		return s
+	}
+	const ellipsis = "…"
+	cut := maxChars - len(ellipsis)
+	if cut <= 0 {
+		return ""
+	}
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+	kept := s[:cut]
+	if i := strings.LastIndexAny(kept, "\n "); i > 0 && i >= cut*4/5 {
+		kept = kept[:i]
+	}
+	return strings.TrimRight(kept, " \t\n") + ellipsis
+}
@@ -0,0 +1,53 @@
+package strutil
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestEstimateTokens(t *testing.T) {
+	tests := []struct {
+		chars int
+		want  int
+	}{
+		{0, 0},
+		{-5, 0},
+		{1, 1},
+		{4, 1},
+		{5, 2},
+		{400, 100},
+	}
+	for _, tt := range tests {
+		if got := EstimateTokens(tt.chars); got != tt.want {
+			t.Errorf("EstimateTokens(%d) = %d, want %d", tt.chars, got, tt.want)
+		}
+	}
+}
+
+func TestTruncateToTokens(t *testing.T) {
+	if got := TruncateToTokens("short", 10); got != "short" {
+		t.Errorf("fitting input changed: %q", got)
+	}
+	if got := TruncateToTokens("anything", 0); got != "" {
+		t.Errorf("zero budget = %q, want empty", got)
+	}
+
+	long := strings.Repeat("word ", 100)
+	got := TruncateToTokens(long, 10)
+	if EstimateTokens(len(got)) > 10 {
+		t.Errorf("result %q exceeds 10 tokens", got)
+	}
+	if !strings.HasSuffix(got, "…") || strings.HasSuffix(got, " …") {
+		t.Errorf("result %q should end in a word followed by an ellipsis", got)
+	}
+
+	multibyte := strings.Repeat("ключ", 50)
+	got = TruncateToTokens(multibyte, 5)
+	if !utf8.ValidString(got) {
+		t.Errorf("result %q is not valid UTF-8", got)
+	}
+	if EstimateTokens(len(got)) > 5 {
+		t.Errorf("result %q exceeds 5 tokens", got)
+	}
+}
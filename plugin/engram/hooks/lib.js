@@ -474,10 +474,15 @@ function formatIssuesBlock(issues, project) {
     const from = issue.source_project || 'unknown';
     const prefix = issue.status === 'reopened' ? `reopened by: ${from}` : `from: ${from}`;
 
-    // Staleness calculation
+    // Staleness: the server flags issues its SLA escalation job escalated with
+    // no activity since; otherwise fall back to days since acknowledgement.
     let staleTag = '';
     let actionDirective = '';
-    if (issue.acknowledged_at) {
+    if (issue.stale) {
+      const escalations = issue.escalation_count > 1 ? ` x${issue.escalation_count}` : '';
+      staleTag = ` [SLA BREACHED${escalations}, idle ${issue.stale_days || 0}d]`;
+      actionDirective = `  └─ ACTION: SLA breached and escalated — acknowledge now, then resolve or comment with the blocker.\n`;
+    } else if (issue.acknowledged_at) {
       const ackMs = new Date(issue.acknowledged_at).getTime();
       const daysSinceAck = Math.floor((nowMs - ackMs) / 86400000);
       if (daysSinceAck >= staleDays * 2) {
@@ -490,7 +495,11 @@ function formatIssuesBlock(issues, project) {
     }
 
     const type = ((issue.type || '').trim().toUpperCase()) || 'TASK';
-    block += `#${issue.id} [${type}] [${prio}] [${prefix}]${staleTag} ${issue.title}\n`;
+    const blockedBy = Array.isArray(issue.blocked_by) ? issue.blocked_by : [];
+    const blockedTag = blockedBy.length > 0
+      ? ` [BLOCKED by ${blockedBy.map((id) => `#${id}`).join(', ')}]`
+      : '';
+    block += `#${issue.id} [${type}] [${prio}] [${prefix}]${staleTag}${blockedTag} ${issue.title}\n`;
 
     if (actionDirective) {
       block += actionDirective;
@@ -163,3 +163,22 @@ test('JS git ID algorithm matches Go ResolveProjectSlug for canonical test vecto
   assert.strictEqual(jsID, expected, 'JS ID must equal independently computed SHA-256 slice');
   assert.match(jsID, /^[0-9a-f]{8}$/, 'canonical vector must produce 8 hex chars');
 });
+
+test('formatIssuesBlock tags issues blocked by unresolved issues', () => {
+  const block = lib.formatIssuesBlock([
+    { id: 17, title: 'Ship the importer', priority: 'high', type: 'task', status: 'open', source_project: 'b', blocked_by: [42, 43] },
+    { id: 18, title: 'Free to start', priority: 'low', type: 'bug', status: 'open', source_project: 'b' },
+  ], 'a');
+
+  assert.match(block, /#17 \[TASK\] \[HIGH\] \[from: b\] \[BLOCKED by #42, #43\] Ship the importer/);
+  assert.match(block, /#18 \[BUG\] \[LOW\] \[from: b\] Free to start/);
+});
+
+test('formatIssuesBlock flags issues escalated by the SLA job', () => {
+  const block = lib.formatIssuesBlock([
+    { id: 21, title: 'Login broken', priority: 'critical', type: 'bug', status: 'open', source_project: 'b', stale: true, stale_days: 4, escalation_count: 2 },
+  ], 'a');
+
+  assert.match(block, /#21 \[BUG\] \[CRITICAL\] \[from: b\] \[SLA BREACHED x2, idle 4d\] Login broken/);
+  assert.match(block, /ACTION: SLA breached and escalated/);
+});
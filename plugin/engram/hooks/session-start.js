@@ -120,8 +120,13 @@ function formatNoCacheBanner() {
   return '<engram-session-start-unavailable>\nWARNING: Engram session-start context is unavailable and no cache is present. Continuing without injected static context.\n</engram-session-start-unavailable>\n';
 }
 
-async function fetchSessionStartPayload(project) {
-  return lib.requestGet(`/api/context/session-start?project=${encodeURIComponent(project)}`, 5000);
+async function fetchSessionStartPayload(project, sessionID) {
+  let endpoint = `/api/context/session-start?project=${encodeURIComponent(project)}`;
+  if (sessionID) {
+    // Lets the server hide issues another live session has claimed.
+    endpoint += `&session_id=${encodeURIComponent(sessionID)}`;
+  }
+  return lib.requestGet(endpoint, 5000);
 }
 
 function buildCachedSessionStartPayload(overrides = {}) {
@@ -176,7 +181,7 @@ async function handleSessionStart(ctx, input) {
   const { cachePath, payload: cachedPayload } = getSessionStartCachePayload(project);
 
   try {
-    const payload = await fetchSessionStartPayload(project);
+    const payload = await fetchSessionStartPayload(project, sessionID);
     cacheSessionStartPayload(project, payload);
 
     const rules = Array.isArray(payload && payload.rules) ? payload.rules : [];
@@ -187,7 +192,12 @@ async function handleSessionStart(ctx, input) {
       console.error(`[engram] Injecting ${issues.length} active issues for ${project}`);
       const openIds = issues.filter((issue) => issue && issue.status === 'open').map((issue) => issue.id);
       if (openIds.length > 0) {
-        lib.requestPost('/api/issues/acknowledge', { ids: openIds }, 3000).catch(() => {});
+        lib.requestPost('/api/issues/acknowledge', {
+          ids: openIds,
+          project,
+          session_id: sessionID || '',
+          agent_source: 'claude-code',
+        }, 3000).catch(() => {});
       }
     }
     if (rules.length > 0) {
@@ -64,7 +64,10 @@ test('handleSessionStart caches live static payload and renders issues, rules, a
     assert.match(result, /<engram-static-memories>/);
     assert.match(result, /Session-start payload is static-only in v5\./);
     assert.ok(getCalls.some((endpoint) => endpoint.includes('/api/context/session-start?project=engram')));
-    assert.ok(postCalls.some((call) => call.endpoint === '/api/issues/acknowledge'));
+    assert.ok(getCalls.some((endpoint) => endpoint.includes('session_id=sess-live')));
+    const ack = postCalls.find((call) => call.endpoint === '/api/issues/acknowledge');
+    assert.ok(ack);
+    assert.equal(ack.body.session_id, 'sess-live', 'acknowledgement is attributed to the session');
 
     const cachePath = lib.getSessionStartCachePath('engram');
     assert.ok(fs.existsSync(cachePath), 'expected cache file to be written');
@@ -361,12 +361,26 @@ type GetSessionStartContextRequest struct {
 	state protoimpl.MessageState `protogen:"open.v1"`
 	// project is the target project slug used to fetch static context entities.
 	Project string `protobuf:"bytes,1,opt,name=project,proto3" json:"project,omitempty"`
-	// memories_limit is the maximum number of memories to return, ordered by newest first.
-	// Zero means the server default.
+	// memories_limit is the maximum number of memories to return, ordered by importance
+	// then newest first. Expired memories are never returned. Zero means the server default.
 	MemoriesLimit int32 `protobuf:"varint,2,opt,name=memories_limit,json=memoriesLimit,proto3" json:"memories_limit,omitempty"`
 	// issues_limit is the maximum number of active issues to return, ordered by priority then newest first.
 	// Zero means the server default.
-	IssuesLimit   int32 `protobuf:"varint,3,opt,name=issues_limit,json=issuesLimit,proto3" json:"issues_limit,omitempty"`
+	IssuesLimit int32 `protobuf:"varint,3,opt,name=issues_limit,json=issuesLimit,proto3" json:"issues_limit,omitempty"`
+	// token_budget caps the estimated size of the payload (~4 characters per token).
+	// Rules are packed first, then issues by priority, then memories; bodies that do
+	// not fit are truncated and items that cannot fit at all are dropped.
+	// Zero means the server's context_max_tokens setting; that setting being zero disables the cap.
+	TokenBudget int32 `protobuf:"varint,4,opt,name=token_budget,json=tokenBudget,proto3" json:"token_budget,omitempty"`
+	// prompt, cwd and recent_files describe the task the session starts on. When any
+	// is set, memories are ranked by full-text match against the prompt plus tag and
+	// file overlap, ahead of the default importance-then-recency order.
+	Prompt      string   `protobuf:"bytes,5,opt,name=prompt,proto3" json:"prompt,omitempty"`
+	Cwd         string   `protobuf:"bytes,6,opt,name=cwd,proto3" json:"cwd,omitempty"`
+	RecentFiles []string `protobuf:"bytes,7,rep,name=recent_files,json=recentFiles,proto3" json:"recent_files,omitempty"`
+	// session_id identifies the starting session. Issues claimed by another
+	// session with a live lease are left out; the session's own claims stay.
+	SessionId     string `protobuf:"bytes,8,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -422,12 +436,49 @@ func (x *GetSessionStartContextRequest) GetIssuesLimit() int32 {
 	return 0
 }
 
+func (x *GetSessionStartContextRequest) GetTokenBudget() int32 {
+	if x != nil {
+		return x.TokenBudget
+	}
+	return 0
+}
+
+func (x *GetSessionStartContextRequest) GetPrompt() string {
+	if x != nil {
+		return x.Prompt
+	}
+	return ""
+}
+
+func (x *GetSessionStartContextRequest) GetCwd() string {
+	if x != nil {
+		return x.Cwd
+	}
+	return ""
+}
+
+func (x *GetSessionStartContextRequest) GetRecentFiles() []string {
+	if x != nil {
+		return x.RecentFiles
+	}
+	return nil
+}
+
+func (x *GetSessionStartContextRequest) GetSessionId() string {
+	if x != nil {
+		return x.SessionId
+	}
+	return ""
+}
+
 type GetSessionStartContextResponse struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Issues        []*SessionStartIssue   `protobuf:"bytes,1,rep,name=issues,proto3" json:"issues,omitempty"`
-	Rules         []*SessionStartRule    `protobuf:"bytes,2,rep,name=rules,proto3" json:"rules,omitempty"`
-	Memories      []*SessionStartMemory  `protobuf:"bytes,3,rep,name=memories,proto3" json:"memories,omitempty"`
-	GeneratedAt   *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=generated_at,json=generatedAt,proto3" json:"generated_at,omitempty"`
+	state       protoimpl.MessageState `protogen:"open.v1"`
+	Issues      []*SessionStartIssue   `protobuf:"bytes,1,rep,name=issues,proto3" json:"issues,omitempty"`
+	Rules       []*SessionStartRule    `protobuf:"bytes,2,rep,name=rules,proto3" json:"rules,omitempty"`
+	Memories    []*SessionStartMemory  `protobuf:"bytes,3,rep,name=memories,proto3" json:"memories,omitempty"`
+	GeneratedAt *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=generated_at,json=generatedAt,proto3" json:"generated_at,omitempty"`
+	// truncation reports how the token budget shaped the payload. Unset when no budget applied.
+	Truncation    *SessionStartTruncation `protobuf:"bytes,5,opt,name=truncation,proto3" json:"truncation,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -490,6 +541,118 @@ func (x *GetSessionStartContextResponse) GetGeneratedAt() *timestamppb.Timestamp
 	return nil
 }
 
+func (x *GetSessionStartContextResponse) GetTruncation() *SessionStartTruncation {
+	if x != nil {
+		return x.Truncation
+	}
+	return nil
+}
+
+// SessionStartTruncation describes what the token budget cut from a session-start payload.
+type SessionStartTruncation struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// token_budget is the budget that was applied.
+	TokenBudget int32 `protobuf:"varint,1,opt,name=token_budget,json=tokenBudget,proto3" json:"token_budget,omitempty"`
+	// tokens_used is the estimated size of the returned entities.
+	TokensUsed int32 `protobuf:"varint,2,opt,name=tokens_used,json=tokensUsed,proto3" json:"tokens_used,omitempty"`
+	// *_truncated count the returned entities whose body was shortened to fit.
+	RulesTruncated    int32 `protobuf:"varint,3,opt,name=rules_truncated,json=rulesTruncated,proto3" json:"rules_truncated,omitempty"`
+	IssuesTruncated   int32 `protobuf:"varint,4,opt,name=issues_truncated,json=issuesTruncated,proto3" json:"issues_truncated,omitempty"`
+	MemoriesTruncated int32 `protobuf:"varint,5,opt,name=memories_truncated,json=memoriesTruncated,proto3" json:"memories_truncated,omitempty"`
+	// dropped_*_ids list the entities that were fetched but left out entirely.
+	DroppedRuleIds   []int64 `protobuf:"varint,6,rep,packed,name=dropped_rule_ids,json=droppedRuleIds,proto3" json:"dropped_rule_ids,omitempty"`
+	DroppedIssueIds  []int64 `protobuf:"varint,7,rep,packed,name=dropped_issue_ids,json=droppedIssueIds,proto3" json:"dropped_issue_ids,omitempty"`
+	DroppedMemoryIds []int64 `protobuf:"varint,8,rep,packed,name=dropped_memory_ids,json=droppedMemoryIds,proto3" json:"dropped_memory_ids,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *SessionStartTruncation) Reset() {
+	*x = SessionStartTruncation{}
+	mi := &file_proto_engram_v1_engram_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SessionStartTruncation) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SessionStartTruncation) ProtoMessage() {}
+
+func (x *SessionStartTruncation) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_engram_v1_engram_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SessionStartTruncation.ProtoReflect.Descriptor instead.
+func (*SessionStartTruncation) Descriptor() ([]byte, []int) {
+	return file_proto_engram_v1_engram_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *SessionStartTruncation) GetTokenBudget() int32 {
+	if x != nil {
+		return x.TokenBudget
+	}
+	return 0
+}
+
+func (x *SessionStartTruncation) GetTokensUsed() int32 {
+	if x != nil {
+		return x.TokensUsed
+	}
+	return 0
+}
+
+func (x *SessionStartTruncation) GetRulesTruncated() int32 {
+	if x != nil {
+		return x.RulesTruncated
+	}
+	return 0
+}
+
+func (x *SessionStartTruncation) GetIssuesTruncated() int32 {
+	if x != nil {
+		return x.IssuesTruncated
+	}
+	return 0
+}
+
+func (x *SessionStartTruncation) GetMemoriesTruncated() int32 {
+	if x != nil {
+		return x.MemoriesTruncated
+	}
+	return 0
+}
+
+func (x *SessionStartTruncation) GetDroppedRuleIds() []int64 {
+	if x != nil {
+		return x.DroppedRuleIds
+	}
+	return nil
+}
+
+func (x *SessionStartTruncation) GetDroppedIssueIds() []int64 {
+	if x != nil {
+		return x.DroppedIssueIds
+	}
+	return nil
+}
+
+func (x *SessionStartTruncation) GetDroppedMemoryIds() []int64 {
+	if x != nil {
+		return x.DroppedMemoryIds
+	}
+	return nil
+}
+
 type SessionStartIssue struct {
 	state          protoimpl.MessageState `protogen:"open.v1"`
 	Id             int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
@@ -509,13 +672,27 @@ type SessionStartIssue struct {
 	ClosedAt       *timestamppb.Timestamp `protobuf:"bytes,15,opt,name=closed_at,json=closedAt,proto3" json:"closed_at,omitempty"`
 	CreatedAt      *timestamppb.Timestamp `protobuf:"bytes,16,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
 	UpdatedAt      *timestamppb.Timestamp `protobuf:"bytes,17,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
-	unknownFields  protoimpl.UnknownFields
-	sizeCache      protoimpl.SizeCache
+	// reason explains why the issue was included.
+	Reason string `protobuf:"bytes,18,opt,name=reason,proto3" json:"reason,omitempty"`
+	// blocked_by lists the unresolved issues that block this one.
+	BlockedBy []int64 `protobuf:"varint,19,rep,packed,name=blocked_by,json=blockedBy,proto3" json:"blocked_by,omitempty"`
+	// assignee_* describe the session holding a claim on the issue, if any.
+	AssigneeAgent   string                 `protobuf:"bytes,20,opt,name=assignee_agent,json=assigneeAgent,proto3" json:"assignee_agent,omitempty"`
+	AssigneeSession string                 `protobuf:"bytes,21,opt,name=assignee_session,json=assigneeSession,proto3" json:"assignee_session,omitempty"`
+	LeaseExpiresAt  *timestamppb.Timestamp `protobuf:"bytes,22,opt,name=lease_expires_at,json=leaseExpiresAt,proto3" json:"lease_expires_at,omitempty"`
+	// stale is set when the SLA escalation job escalated the issue and nobody
+	// has touched it since; stale_days counts whole days since its last update.
+	Stale           bool                   `protobuf:"varint,23,opt,name=stale,proto3" json:"stale,omitempty"`
+	StaleDays       int32                  `protobuf:"varint,24,opt,name=stale_days,json=staleDays,proto3" json:"stale_days,omitempty"`
+	EscalationCount int32                  `protobuf:"varint,25,opt,name=escalation_count,json=escalationCount,proto3" json:"escalation_count,omitempty"`
+	EscalatedAt     *timestamppb.Timestamp `protobuf:"bytes,26,opt,name=escalated_at,json=escalatedAt,proto3" json:"escalated_at,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
 }
 
 func (x *SessionStartIssue) Reset() {
 	*x = SessionStartIssue{}
-	mi := &file_proto_engram_v1_engram_proto_msgTypes[6]
+	mi := &file_proto_engram_v1_engram_proto_msgTypes[7]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -527,7 +704,7 @@ func (x *SessionStartIssue) String() string {
 func (*SessionStartIssue) ProtoMessage() {}
 
 func (x *SessionStartIssue) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_engram_v1_engram_proto_msgTypes[6]
+	mi := &file_proto_engram_v1_engram_proto_msgTypes[7]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -540,7 +717,7 @@ func (x *SessionStartIssue) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use SessionStartIssue.ProtoReflect.Descriptor instead.
 func (*SessionStartIssue) Descriptor() ([]byte, []int) {
-	return file_proto_engram_v1_engram_proto_rawDescGZIP(), []int{6}
+	return file_proto_engram_v1_engram_proto_rawDescGZIP(), []int{7}
 }
 
 func (x *SessionStartIssue) GetId() int64 {
@@ -662,23 +839,88 @@ func (x *SessionStartIssue) GetUpdatedAt() *timestamppb.Timestamp {
 	return nil
 }
 
+func (x *SessionStartIssue) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+func (x *SessionStartIssue) GetBlockedBy() []int64 {
+	if x != nil {
+		return x.BlockedBy
+	}
+	return nil
+}
+
+func (x *SessionStartIssue) GetAssigneeAgent() string {
+	if x != nil {
+		return x.AssigneeAgent
+	}
+	return ""
+}
+
+func (x *SessionStartIssue) GetAssigneeSession() string {
+	if x != nil {
+		return x.AssigneeSession
+	}
+	return ""
+}
+
+func (x *SessionStartIssue) GetLeaseExpiresAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.LeaseExpiresAt
+	}
+	return nil
+}
+
+func (x *SessionStartIssue) GetStale() bool {
+	if x != nil {
+		return x.Stale
+	}
+	return false
+}
+
+func (x *SessionStartIssue) GetStaleDays() int32 {
+	if x != nil {
+		return x.StaleDays
+	}
+	return 0
+}
+
+func (x *SessionStartIssue) GetEscalationCount() int32 {
+	if x != nil {
+		return x.EscalationCount
+	}
+	return 0
+}
+
+func (x *SessionStartIssue) GetEscalatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.EscalatedAt
+	}
+	return nil
+}
+
 type SessionStartRule struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
-	Project       string                 `protobuf:"bytes,2,opt,name=project,proto3" json:"project,omitempty"`
-	Content       string                 `protobuf:"bytes,3,opt,name=content,proto3" json:"content,omitempty"`
-	EditedBy      string                 `protobuf:"bytes,4,opt,name=edited_by,json=editedBy,proto3" json:"edited_by,omitempty"`
-	Priority      int32                  `protobuf:"varint,5,opt,name=priority,proto3" json:"priority,omitempty"`
-	Version       int32                  `protobuf:"varint,6,opt,name=version,proto3" json:"version,omitempty"`
-	CreatedAt     *timestamppb.Timestamp `protobuf:"bytes,7,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
-	UpdatedAt     *timestamppb.Timestamp `protobuf:"bytes,8,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	Id        int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Project   string                 `protobuf:"bytes,2,opt,name=project,proto3" json:"project,omitempty"`
+	Content   string                 `protobuf:"bytes,3,opt,name=content,proto3" json:"content,omitempty"`
+	EditedBy  string                 `protobuf:"bytes,4,opt,name=edited_by,json=editedBy,proto3" json:"edited_by,omitempty"`
+	Priority  int32                  `protobuf:"varint,5,opt,name=priority,proto3" json:"priority,omitempty"`
+	Version   int32                  `protobuf:"varint,6,opt,name=version,proto3" json:"version,omitempty"`
+	CreatedAt *timestamppb.Timestamp `protobuf:"bytes,7,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	UpdatedAt *timestamppb.Timestamp `protobuf:"bytes,8,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	// reason explains why the rule was included.
+	Reason        string `protobuf:"bytes,9,opt,name=reason,proto3" json:"reason,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *SessionStartRule) Reset() {
 	*x = SessionStartRule{}
-	mi := &file_proto_engram_v1_engram_proto_msgTypes[7]
+	mi := &file_proto_engram_v1_engram_proto_msgTypes[8]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -690,7 +932,7 @@ func (x *SessionStartRule) String() string {
 func (*SessionStartRule) ProtoMessage() {}
 
 func (x *SessionStartRule) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_engram_v1_engram_proto_msgTypes[7]
+	mi := &file_proto_engram_v1_engram_proto_msgTypes[8]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -703,7 +945,7 @@ func (x *SessionStartRule) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use SessionStartRule.ProtoReflect.Descriptor instead.
 func (*SessionStartRule) Descriptor() ([]byte, []int) {
-	return file_proto_engram_v1_engram_proto_rawDescGZIP(), []int{7}
+	return file_proto_engram_v1_engram_proto_rawDescGZIP(), []int{8}
 }
 
 func (x *SessionStartRule) GetId() int64 {
@@ -762,24 +1004,39 @@ func (x *SessionStartRule) GetUpdatedAt() *timestamppb.Timestamp {
 	return nil
 }
 
+func (x *SessionStartRule) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
 type SessionStartMemory struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
-	Project       string                 `protobuf:"bytes,2,opt,name=project,proto3" json:"project,omitempty"`
-	Content       string                 `protobuf:"bytes,3,opt,name=content,proto3" json:"content,omitempty"`
-	Tags          []string               `protobuf:"bytes,4,rep,name=tags,proto3" json:"tags,omitempty"`
-	SourceAgent   string                 `protobuf:"bytes,5,opt,name=source_agent,json=sourceAgent,proto3" json:"source_agent,omitempty"`
-	EditedBy      string                 `protobuf:"bytes,6,opt,name=edited_by,json=editedBy,proto3" json:"edited_by,omitempty"`
-	Version       int32                  `protobuf:"varint,7,opt,name=version,proto3" json:"version,omitempty"`
-	CreatedAt     *timestamppb.Timestamp `protobuf:"bytes,8,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
-	UpdatedAt     *timestamppb.Timestamp `protobuf:"bytes,9,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	state       protoimpl.MessageState `protogen:"open.v1"`
+	Id          int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Project     string                 `protobuf:"bytes,2,opt,name=project,proto3" json:"project,omitempty"`
+	Content     string                 `protobuf:"bytes,3,opt,name=content,proto3" json:"content,omitempty"`
+	Tags        []string               `protobuf:"bytes,4,rep,name=tags,proto3" json:"tags,omitempty"`
+	SourceAgent string                 `protobuf:"bytes,5,opt,name=source_agent,json=sourceAgent,proto3" json:"source_agent,omitempty"`
+	EditedBy    string                 `protobuf:"bytes,6,opt,name=edited_by,json=editedBy,proto3" json:"edited_by,omitempty"`
+	Version     int32                  `protobuf:"varint,7,opt,name=version,proto3" json:"version,omitempty"`
+	CreatedAt   *timestamppb.Timestamp `protobuf:"bytes,8,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	UpdatedAt   *timestamppb.Timestamp `protobuf:"bytes,9,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	// type is the memory type (decision, bugfix, pitfall, ...); empty when unknown.
+	Type string `protobuf:"bytes,10,opt,name=type,proto3" json:"type,omitempty"`
+	// importance is the 0-1 weight the memories are ordered by.
+	Importance float64 `protobuf:"fixed64,11,opt,name=importance,proto3" json:"importance,omitempty"`
+	// expires_at is when the memory's TTL elapses; unset for memories that never expire.
+	ExpiresAt *timestamppb.Timestamp `protobuf:"bytes,12,opt,name=expires_at,json=expiresAt,proto3" json:"expires_at,omitempty"`
+	// reason explains why the memory was included, e.g. which prompt terms, tags or files it matched.
+	Reason        string `protobuf:"bytes,13,opt,name=reason,proto3" json:"reason,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *SessionStartMemory) Reset() {
 	*x = SessionStartMemory{}
-	mi := &file_proto_engram_v1_engram_proto_msgTypes[8]
+	mi := &file_proto_engram_v1_engram_proto_msgTypes[9]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -791,7 +1048,7 @@ func (x *SessionStartMemory) String() string {
 func (*SessionStartMemory) ProtoMessage() {}
 
 func (x *SessionStartMemory) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_engram_v1_engram_proto_msgTypes[8]
+	mi := &file_proto_engram_v1_engram_proto_msgTypes[9]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -804,7 +1061,7 @@ func (x *SessionStartMemory) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use SessionStartMemory.ProtoReflect.Descriptor instead.
 func (*SessionStartMemory) Descriptor() ([]byte, []int) {
-	return file_proto_engram_v1_engram_proto_rawDescGZIP(), []int{8}
+	return file_proto_engram_v1_engram_proto_rawDescGZIP(), []int{9}
 }
 
 func (x *SessionStartMemory) GetId() int64 {
@@ -870,6 +1127,34 @@ func (x *SessionStartMemory) GetUpdatedAt() *timestamppb.Timestamp {
 	return nil
 }
 
+func (x *SessionStartMemory) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *SessionStartMemory) GetImportance() float64 {
+	if x != nil {
+		return x.Importance
+	}
+	return 0
+}
+
+func (x *SessionStartMemory) GetExpiresAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ExpiresAt
+	}
+	return nil
+}
+
+func (x *SessionStartMemory) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
 type NegotiateVersionRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	ClientVersion string                 `protobuf:"bytes,1,opt,name=client_version,json=clientVersion,proto3" json:"client_version,omitempty"`
@@ -879,7 +1164,7 @@ type NegotiateVersionRequest struct {
 
 func (x *NegotiateVersionRequest) Reset() {
 	*x = NegotiateVersionRequest{}
-	mi := &file_proto_engram_v1_engram_proto_msgTypes[9]
+	mi := &file_proto_engram_v1_engram_proto_msgTypes[10]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -891,7 +1176,7 @@ func (x *NegotiateVersionRequest) String() string {
 func (*NegotiateVersionRequest) ProtoMessage() {}
 
 func (x *NegotiateVersionRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_engram_v1_engram_proto_msgTypes[9]
+	mi := &file_proto_engram_v1_engram_proto_msgTypes[10]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -904,7 +1189,7 @@ func (x *NegotiateVersionRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use NegotiateVersionRequest.ProtoReflect.Descriptor instead.
 func (*NegotiateVersionRequest) Descriptor() ([]byte, []int) {
-	return file_proto_engram_v1_engram_proto_rawDescGZIP(), []int{9}
+	return file_proto_engram_v1_engram_proto_rawDescGZIP(), []int{10}
 }
 
 func (x *NegotiateVersionRequest) GetClientVersion() string {
@@ -925,7 +1210,7 @@ type NegotiateVersionResponse struct {
 
 func (x *NegotiateVersionResponse) Reset() {
 	*x = NegotiateVersionResponse{}
-	mi := &file_proto_engram_v1_engram_proto_msgTypes[10]
+	mi := &file_proto_engram_v1_engram_proto_msgTypes[11]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -937,7 +1222,7 @@ func (x *NegotiateVersionResponse) String() string {
 func (*NegotiateVersionResponse) ProtoMessage() {}
 
 func (x *NegotiateVersionResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_engram_v1_engram_proto_msgTypes[10]
+	mi := &file_proto_engram_v1_engram_proto_msgTypes[11]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -950,7 +1235,7 @@ func (x *NegotiateVersionResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use NegotiateVersionResponse.ProtoReflect.Descriptor instead.
 func (*NegotiateVersionResponse) Descriptor() ([]byte, []int) {
-	return file_proto_engram_v1_engram_proto_rawDescGZIP(), []int{10}
+	return file_proto_engram_v1_engram_proto_rawDescGZIP(), []int{11}
 }
 
 func (x *NegotiateVersionResponse) GetCompatible() bool {
@@ -991,7 +1276,7 @@ type CallToolRequest struct {
 
 func (x *CallToolRequest) Reset() {
 	*x = CallToolRequest{}
-	mi := &file_proto_engram_v1_engram_proto_msgTypes[11]
+	mi := &file_proto_engram_v1_engram_proto_msgTypes[12]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1003,7 +1288,7 @@ func (x *CallToolRequest) String() string {
 func (*CallToolRequest) ProtoMessage() {}
 
 func (x *CallToolRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_engram_v1_engram_proto_msgTypes[11]
+	mi := &file_proto_engram_v1_engram_proto_msgTypes[12]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1016,7 +1301,7 @@ func (x *CallToolRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use CallToolRequest.ProtoReflect.Descriptor instead.
 func (*CallToolRequest) Descriptor() ([]byte, []int) {
-	return file_proto_engram_v1_engram_proto_rawDescGZIP(), []int{11}
+	return file_proto_engram_v1_engram_proto_rawDescGZIP(), []int{12}
 }
 
 func (x *CallToolRequest) GetToolName() string {
@@ -1060,7 +1345,7 @@ type CallToolResponse struct {
 
 func (x *CallToolResponse) Reset() {
 	*x = CallToolResponse{}
-	mi := &file_proto_engram_v1_engram_proto_msgTypes[12]
+	mi := &file_proto_engram_v1_engram_proto_msgTypes[13]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1072,7 +1357,7 @@ func (x *CallToolResponse) String() string {
 func (*CallToolResponse) ProtoMessage() {}
 
 func (x *CallToolResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_engram_v1_engram_proto_msgTypes[12]
+	mi := &file_proto_engram_v1_engram_proto_msgTypes[13]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1085,7 +1370,7 @@ func (x *CallToolResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use CallToolResponse.ProtoReflect.Descriptor instead.
 func (*CallToolResponse) Descriptor() ([]byte, []int) {
-	return file_proto_engram_v1_engram_proto_rawDescGZIP(), []int{12}
+	return file_proto_engram_v1_engram_proto_rawDescGZIP(), []int{13}
 }
 
 func (x *CallToolResponse) GetIsError() bool {
@@ -1114,7 +1399,7 @@ type InitializeRequest struct {
 
 func (x *InitializeRequest) Reset() {
 	*x = InitializeRequest{}
-	mi := &file_proto_engram_v1_engram_proto_msgTypes[13]
+	mi := &file_proto_engram_v1_engram_proto_msgTypes[14]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1126,7 +1411,7 @@ func (x *InitializeRequest) String() string {
 func (*InitializeRequest) ProtoMessage() {}
 
 func (x *InitializeRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_engram_v1_engram_proto_msgTypes[13]
+	mi := &file_proto_engram_v1_engram_proto_msgTypes[14]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1139,7 +1424,7 @@ func (x *InitializeRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use InitializeRequest.ProtoReflect.Descriptor instead.
 func (*InitializeRequest) Descriptor() ([]byte, []int) {
-	return file_proto_engram_v1_engram_proto_rawDescGZIP(), []int{13}
+	return file_proto_engram_v1_engram_proto_rawDescGZIP(), []int{14}
 }
 
 func (x *InitializeRequest) GetClientName() string {
@@ -1175,7 +1460,7 @@ type InitializeResponse struct {
 
 func (x *InitializeResponse) Reset() {
 	*x = InitializeResponse{}
-	mi := &file_proto_engram_v1_engram_proto_msgTypes[14]
+	mi := &file_proto_engram_v1_engram_proto_msgTypes[15]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1187,7 +1472,7 @@ func (x *InitializeResponse) String() string {
 func (*InitializeResponse) ProtoMessage() {}
 
 func (x *InitializeResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_engram_v1_engram_proto_msgTypes[14]
+	mi := &file_proto_engram_v1_engram_proto_msgTypes[15]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1200,7 +1485,7 @@ func (x *InitializeResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use InitializeResponse.ProtoReflect.Descriptor instead.
 func (*InitializeResponse) Descriptor() ([]byte, []int) {
-	return file_proto_engram_v1_engram_proto_rawDescGZIP(), []int{14}
+	return file_proto_engram_v1_engram_proto_rawDescGZIP(), []int{15}
 }
 
 func (x *InitializeResponse) GetServerName() string {
@@ -1237,7 +1522,7 @@ type ToolDefinition struct {
 
 func (x *ToolDefinition) Reset() {
 	*x = ToolDefinition{}
-	mi := &file_proto_engram_v1_engram_proto_msgTypes[15]
+	mi := &file_proto_engram_v1_engram_proto_msgTypes[16]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1249,7 +1534,7 @@ func (x *ToolDefinition) String() string {
 func (*ToolDefinition) ProtoMessage() {}
 
 func (x *ToolDefinition) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_engram_v1_engram_proto_msgTypes[15]
+	mi := &file_proto_engram_v1_engram_proto_msgTypes[16]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1262,7 +1547,7 @@ func (x *ToolDefinition) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ToolDefinition.ProtoReflect.Descriptor instead.
 func (*ToolDefinition) Descriptor() ([]byte, []int) {
-	return file_proto_engram_v1_engram_proto_rawDescGZIP(), []int{15}
+	return file_proto_engram_v1_engram_proto_rawDescGZIP(), []int{16}
 }
 
 func (x *ToolDefinition) GetName() string {
@@ -1294,7 +1579,7 @@ type PingRequest struct {
 
 func (x *PingRequest) Reset() {
 	*x = PingRequest{}
-	mi := &file_proto_engram_v1_engram_proto_msgTypes[16]
+	mi := &file_proto_engram_v1_engram_proto_msgTypes[17]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1306,7 +1591,7 @@ func (x *PingRequest) String() string {
 func (*PingRequest) ProtoMessage() {}
 
 func (x *PingRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_engram_v1_engram_proto_msgTypes[16]
+	mi := &file_proto_engram_v1_engram_proto_msgTypes[17]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1319,7 +1604,7 @@ func (x *PingRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use PingRequest.ProtoReflect.Descriptor instead.
 func (*PingRequest) Descriptor() ([]byte, []int) {
-	return file_proto_engram_v1_engram_proto_rawDescGZIP(), []int{16}
+	return file_proto_engram_v1_engram_proto_rawDescGZIP(), []int{17}
 }
 
 type PingResponse struct {
@@ -1331,7 +1616,7 @@ type PingResponse struct {
 
 func (x *PingResponse) Reset() {
 	*x = PingResponse{}
-	mi := &file_proto_engram_v1_engram_proto_msgTypes[17]
+	mi := &file_proto_engram_v1_engram_proto_msgTypes[18]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1343,7 +1628,7 @@ func (x *PingResponse) String() string {
 func (*PingResponse) ProtoMessage() {}
 
 func (x *PingResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_engram_v1_engram_proto_msgTypes[17]
+	mi := &file_proto_engram_v1_engram_proto_msgTypes[18]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1356,7 +1641,7 @@ func (x *PingResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use PingResponse.ProtoReflect.Descriptor instead.
 func (*PingResponse) Descriptor() ([]byte, []int) {
-	return file_proto_engram_v1_engram_proto_rawDescGZIP(), []int{17}
+	return file_proto_engram_v1_engram_proto_rawDescGZIP(), []int{18}
 }
 
 func (x *PingResponse) GetStatus() string {
@@ -1392,16 +1677,35 @@ const file_proto_engram_v1_engram_proto_rawDesc = "" +
 	"\bmetadata\x18\x06 \x03(\v2%.engram.v1.ProjectEvent.MetadataEntryR\bmetadata\x1a;\n" +
 	"\rMetadataEntry\x12\x10\n" +
 	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
-	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\x83\x01\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\x92\x02\n" +
 	"\x1dGetSessionStartContextRequest\x12\x18\n" +
 	"\aproject\x18\x01 \x01(\tR\aproject\x12%\n" +
 	"\x0ememories_limit\x18\x02 \x01(\x05R\rmemoriesLimit\x12!\n" +
-	"\fissues_limit\x18\x03 \x01(\x05R\vissuesLimit\"\x83\x02\n" +
+	"\fissues_limit\x18\x03 \x01(\x05R\vissuesLimit\x12!\n" +
+	"\ftoken_budget\x18\x04 \x01(\x05R\vtokenBudget\x12\x16\n" +
+	"\x06prompt\x18\x05 \x01(\tR\x06prompt\x12\x10\n" +
+	"\x03cwd\x18\x06 \x01(\tR\x03cwd\x12!\n" +
+	"\frecent_files\x18\a \x03(\tR\vrecentFiles\x12\x1d\n" +
+	"\n" +
+	"session_id\x18\b \x01(\tR\tsessionId\"\xc6\x02\n" +
 	"\x1eGetSessionStartContextResponse\x124\n" +
 	"\x06issues\x18\x01 \x03(\v2\x1c.engram.v1.SessionStartIssueR\x06issues\x121\n" +
 	"\x05rules\x18\x02 \x03(\v2\x1b.engram.v1.SessionStartRuleR\x05rules\x129\n" +
 	"\bmemories\x18\x03 \x03(\v2\x1d.engram.v1.SessionStartMemoryR\bmemories\x12=\n" +
-	"\fgenerated_at\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\vgeneratedAt\"\xb1\x05\n" +
+	"\fgenerated_at\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\vgeneratedAt\x12A\n" +
+	"\n" +
+	"truncation\x18\x05 \x01(\v2!.engram.v1.SessionStartTruncationR\n" +
+	"truncation\"\xe3\x02\n" +
+	"\x16SessionStartTruncation\x12!\n" +
+	"\ftoken_budget\x18\x01 \x01(\x05R\vtokenBudget\x12\x1f\n" +
+	"\vtokens_used\x18\x02 \x01(\x05R\n" +
+	"tokensUsed\x12'\n" +
+	"\x0frules_truncated\x18\x03 \x01(\x05R\x0erulesTruncated\x12)\n" +
+	"\x10issues_truncated\x18\x04 \x01(\x05R\x0fissuesTruncated\x12-\n" +
+	"\x12memories_truncated\x18\x05 \x01(\x05R\x11memoriesTruncated\x12(\n" +
+	"\x10dropped_rule_ids\x18\x06 \x03(\x03R\x0edroppedRuleIds\x12*\n" +
+	"\x11dropped_issue_ids\x18\a \x03(\x03R\x0fdroppedIssueIds\x12,\n" +
+	"\x12dropped_memory_ids\x18\b \x03(\x03R\x10droppedMemoryIds\"\x9f\b\n" +
 	"\x11SessionStartIssue\x12\x0e\n" +
 	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x14\n" +
 	"\x05title\x18\x02 \x01(\tR\x05title\x12\x12\n" +
@@ -1424,7 +1728,18 @@ const file_proto_engram_v1_engram_proto_rawDesc = "" +
 	"\n" +
 	"created_at\x18\x10 \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x129\n" +
 	"\n" +
-	"updated_at\x18\x11 \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAt\"\x9f\x02\n" +
+	"updated_at\x18\x11 \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAt\x12\x16\n" +
+	"\x06reason\x18\x12 \x01(\tR\x06reason\x12\x1d\n" +
+	"\n" +
+	"blocked_by\x18\x13 \x03(\x03R\tblockedBy\x12%\n" +
+	"\x0eassignee_agent\x18\x14 \x01(\tR\rassigneeAgent\x12)\n" +
+	"\x10assignee_session\x18\x15 \x01(\tR\x0fassigneeSession\x12D\n" +
+	"\x10lease_expires_at\x18\x16 \x01(\v2\x1a.google.protobuf.TimestampR\x0eleaseExpiresAt\x12\x14\n" +
+	"\x05stale\x18\x17 \x01(\bR\x05stale\x12\x1d\n" +
+	"\n" +
+	"stale_days\x18\x18 \x01(\x05R\tstaleDays\x12)\n" +
+	"\x10escalation_count\x18\x19 \x01(\x05R\x0fescalationCount\x12=\n" +
+	"\fescalated_at\x18\x1a \x01(\v2\x1a.google.protobuf.TimestampR\vescalatedAt\"\xb7\x02\n" +
 	"\x10SessionStartRule\x12\x0e\n" +
 	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x18\n" +
 	"\aproject\x18\x02 \x01(\tR\aproject\x12\x18\n" +
@@ -1435,7 +1750,8 @@ const file_proto_engram_v1_engram_proto_rawDesc = "" +
 	"\n" +
 	"created_at\x18\a \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x129\n" +
 	"\n" +
-	"updated_at\x18\b \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAt\"\xbc\x02\n" +
+	"updated_at\x18\b \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAt\x12\x16\n" +
+	"\x06reason\x18\t \x01(\tR\x06reason\"\xc3\x03\n" +
 	"\x12SessionStartMemory\x12\x0e\n" +
 	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x18\n" +
 	"\aproject\x18\x02 \x01(\tR\aproject\x12\x18\n" +
@@ -1447,7 +1763,15 @@ const file_proto_engram_v1_engram_proto_rawDesc = "" +
 	"\n" +
 	"created_at\x18\b \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x129\n" +
 	"\n" +
-	"updated_at\x18\t \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAt\"@\n" +
+	"updated_at\x18\t \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAt\x12\x12\n" +
+	"\x04type\x18\n" +
+	" \x01(\tR\x04type\x12\x1e\n" +
+	"\n" +
+	"importance\x18\v \x01(\x01R\n" +
+	"importance\x129\n" +
+	"\n" +
+	"expires_at\x18\f \x01(\v2\x1a.google.protobuf.TimestampR\texpiresAt\x12\x16\n" +
+	"\x06reason\x18\r \x01(\tR\x06reason\"@\n" +
 	"\x17NegotiateVersionRequest\x12%\n" +
 	"\x0eclient_version\x18\x01 \x01(\tR\rclientVersion\"\x8a\x01\n" +
 	"\x18NegotiateVersionResponse\x12\x1e\n" +
@@ -1510,7 +1834,7 @@ func file_proto_engram_v1_engram_proto_rawDescGZIP() []byte {
 }
 
 var file_proto_engram_v1_engram_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
-var file_proto_engram_v1_engram_proto_msgTypes = make([]protoimpl.MessageInfo, 19)
+var file_proto_engram_v1_engram_proto_msgTypes = make([]protoimpl.MessageInfo, 20)
 var file_proto_engram_v1_engram_proto_goTypes = []any{
 	(ProjectEventType)(0),                  // 0: engram.v1.ProjectEventType
 	(*SyncProjectStateRequest)(nil),        // 1: engram.v1.SyncProjectStateRequest
@@ -1519,58 +1843,63 @@ var file_proto_engram_v1_engram_proto_goTypes = []any{
 	(*ProjectEvent)(nil),                   // 4: engram.v1.ProjectEvent
 	(*GetSessionStartContextRequest)(nil),  // 5: engram.v1.GetSessionStartContextRequest
 	(*GetSessionStartContextResponse)(nil), // 6: engram.v1.GetSessionStartContextResponse
-	(*SessionStartIssue)(nil),              // 7: engram.v1.SessionStartIssue
-	(*SessionStartRule)(nil),               // 8: engram.v1.SessionStartRule
-	(*SessionStartMemory)(nil),             // 9: engram.v1.SessionStartMemory
-	(*NegotiateVersionRequest)(nil),        // 10: engram.v1.NegotiateVersionRequest
-	(*NegotiateVersionResponse)(nil),       // 11: engram.v1.NegotiateVersionResponse
-	(*CallToolRequest)(nil),                // 12: engram.v1.CallToolRequest
-	(*CallToolResponse)(nil),               // 13: engram.v1.CallToolResponse
-	(*InitializeRequest)(nil),              // 14: engram.v1.InitializeRequest
-	(*InitializeResponse)(nil),             // 15: engram.v1.InitializeResponse
-	(*ToolDefinition)(nil),                 // 16: engram.v1.ToolDefinition
-	(*PingRequest)(nil),                    // 17: engram.v1.PingRequest
-	(*PingResponse)(nil),                   // 18: engram.v1.PingResponse
-	nil,                                    // 19: engram.v1.ProjectEvent.MetadataEntry
-	(*timestamppb.Timestamp)(nil),          // 20: google.protobuf.Timestamp
+	(*SessionStartTruncation)(nil),         // 7: engram.v1.SessionStartTruncation
+	(*SessionStartIssue)(nil),              // 8: engram.v1.SessionStartIssue
+	(*SessionStartRule)(nil),               // 9: engram.v1.SessionStartRule
+	(*SessionStartMemory)(nil),             // 10: engram.v1.SessionStartMemory
+	(*NegotiateVersionRequest)(nil),        // 11: engram.v1.NegotiateVersionRequest
+	(*NegotiateVersionResponse)(nil),       // 12: engram.v1.NegotiateVersionResponse
+	(*CallToolRequest)(nil),                // 13: engram.v1.CallToolRequest
+	(*CallToolResponse)(nil),               // 14: engram.v1.CallToolResponse
+	(*InitializeRequest)(nil),              // 15: engram.v1.InitializeRequest
+	(*InitializeResponse)(nil),             // 16: engram.v1.InitializeResponse
+	(*ToolDefinition)(nil),                 // 17: engram.v1.ToolDefinition
+	(*PingRequest)(nil),                    // 18: engram.v1.PingRequest
+	(*PingResponse)(nil),                   // 19: engram.v1.PingResponse
+	nil,                                    // 20: engram.v1.ProjectEvent.MetadataEntry
+	(*timestamppb.Timestamp)(nil),          // 21: google.protobuf.Timestamp
 }
 var file_proto_engram_v1_engram_proto_depIdxs = []int32{
 	0,  // 0: engram.v1.ProjectEvent.event_type:type_name -> engram.v1.ProjectEventType
-	19, // 1: engram.v1.ProjectEvent.metadata:type_name -> engram.v1.ProjectEvent.MetadataEntry
-	7,  // 2: engram.v1.GetSessionStartContextResponse.issues:type_name -> engram.v1.SessionStartIssue
-	8,  // 3: engram.v1.GetSessionStartContextResponse.rules:type_name -> engram.v1.SessionStartRule
-	9,  // 4: engram.v1.GetSessionStartContextResponse.memories:type_name -> engram.v1.SessionStartMemory
-	20, // 5: engram.v1.GetSessionStartContextResponse.generated_at:type_name -> google.protobuf.Timestamp
-	20, // 6: engram.v1.SessionStartIssue.acknowledged_at:type_name -> google.protobuf.Timestamp
-	20, // 7: engram.v1.SessionStartIssue.resolved_at:type_name -> google.protobuf.Timestamp
-	20, // 8: engram.v1.SessionStartIssue.reopened_at:type_name -> google.protobuf.Timestamp
-	20, // 9: engram.v1.SessionStartIssue.closed_at:type_name -> google.protobuf.Timestamp
-	20, // 10: engram.v1.SessionStartIssue.created_at:type_name -> google.protobuf.Timestamp
-	20, // 11: engram.v1.SessionStartIssue.updated_at:type_name -> google.protobuf.Timestamp
-	20, // 12: engram.v1.SessionStartRule.created_at:type_name -> google.protobuf.Timestamp
-	20, // 13: engram.v1.SessionStartRule.updated_at:type_name -> google.protobuf.Timestamp
-	20, // 14: engram.v1.SessionStartMemory.created_at:type_name -> google.protobuf.Timestamp
-	20, // 15: engram.v1.SessionStartMemory.updated_at:type_name -> google.protobuf.Timestamp
-	16, // 16: engram.v1.InitializeResponse.tools:type_name -> engram.v1.ToolDefinition
-	12, // 17: engram.v1.EngramService.CallTool:input_type -> engram.v1.CallToolRequest
-	14, // 18: engram.v1.EngramService.Initialize:input_type -> engram.v1.InitializeRequest
-	17, // 19: engram.v1.EngramService.Ping:input_type -> engram.v1.PingRequest
-	1,  // 20: engram.v1.EngramService.SyncProjectState:input_type -> engram.v1.SyncProjectStateRequest
-	3,  // 21: engram.v1.EngramService.ProjectEvents:input_type -> engram.v1.ProjectEventsRequest
-	5,  // 22: engram.v1.EngramService.GetSessionStartContext:input_type -> engram.v1.GetSessionStartContextRequest
-	10, // 23: engram.v1.EngramService.NegotiateVersion:input_type -> engram.v1.NegotiateVersionRequest
-	13, // 24: engram.v1.EngramService.CallTool:output_type -> engram.v1.CallToolResponse
-	15, // 25: engram.v1.EngramService.Initialize:output_type -> engram.v1.InitializeResponse
-	18, // 26: engram.v1.EngramService.Ping:output_type -> engram.v1.PingResponse
-	2,  // 27: engram.v1.EngramService.SyncProjectState:output_type -> engram.v1.SyncProjectStateResponse
-	4,  // 28: engram.v1.EngramService.ProjectEvents:output_type -> engram.v1.ProjectEvent
-	6,  // 29: engram.v1.EngramService.GetSessionStartContext:output_type -> engram.v1.GetSessionStartContextResponse
-	11, // 30: engram.v1.EngramService.NegotiateVersion:output_type -> engram.v1.NegotiateVersionResponse
-	24, // [24:31] is the sub-list for method output_type
-	17, // [17:24] is the sub-list for method input_type
-	17, // [17:17] is the sub-list for extension type_name
-	17, // [17:17] is the sub-list for extension extendee
-	0,  // [0:17] is the sub-list for field type_name
+	20, // 1: engram.v1.ProjectEvent.metadata:type_name -> engram.v1.ProjectEvent.MetadataEntry
+	8,  // 2: engram.v1.GetSessionStartContextResponse.issues:type_name -> engram.v1.SessionStartIssue
+	9,  // 3: engram.v1.GetSessionStartContextResponse.rules:type_name -> engram.v1.SessionStartRule
+	10, // 4: engram.v1.GetSessionStartContextResponse.memories:type_name -> engram.v1.SessionStartMemory
+	21, // 5: engram.v1.GetSessionStartContextResponse.generated_at:type_name -> google.protobuf.Timestamp
+	7,  // 6: engram.v1.GetSessionStartContextResponse.truncation:type_name -> engram.v1.SessionStartTruncation
+	21, // 7: engram.v1.SessionStartIssue.acknowledged_at:type_name -> google.protobuf.Timestamp
+	21, // 8: engram.v1.SessionStartIssue.resolved_at:type_name -> google.protobuf.Timestamp
+	21, // 9: engram.v1.SessionStartIssue.reopened_at:type_name -> google.protobuf.Timestamp
+	21, // 10: engram.v1.SessionStartIssue.closed_at:type_name -> google.protobuf.Timestamp
+	21, // 11: engram.v1.SessionStartIssue.created_at:type_name -> google.protobuf.Timestamp
+	21, // 12: engram.v1.SessionStartIssue.updated_at:type_name -> google.protobuf.Timestamp
+	21, // 13: engram.v1.SessionStartIssue.lease_expires_at:type_name -> google.protobuf.Timestamp
+	21, // 14: engram.v1.SessionStartIssue.escalated_at:type_name -> google.protobuf.Timestamp
+	21, // 15: engram.v1.SessionStartRule.created_at:type_name -> google.protobuf.Timestamp
+	21, // 16: engram.v1.SessionStartRule.updated_at:type_name -> google.protobuf.Timestamp
+	21, // 17: engram.v1.SessionStartMemory.created_at:type_name -> google.protobuf.Timestamp
+	21, // 18: engram.v1.SessionStartMemory.updated_at:type_name -> google.protobuf.Timestamp
+	21, // 19: engram.v1.SessionStartMemory.expires_at:type_name -> google.protobuf.Timestamp
+	17, // 20: engram.v1.InitializeResponse.tools:type_name -> engram.v1.ToolDefinition
+	13, // 21: engram.v1.EngramService.CallTool:input_type -> engram.v1.CallToolRequest
+	15, // 22: engram.v1.EngramService.Initialize:input_type -> engram.v1.InitializeRequest
+	18, // 23: engram.v1.EngramService.Ping:input_type -> engram.v1.PingRequest
+	1,  // 24: engram.v1.EngramService.SyncProjectState:input_type -> engram.v1.SyncProjectStateRequest
+	3,  // 25: engram.v1.EngramService.ProjectEvents:input_type -> engram.v1.ProjectEventsRequest
+	5,  // 26: engram.v1.EngramService.GetSessionStartContext:input_type -> engram.v1.GetSessionStartContextRequest
+	11, // 27: engram.v1.EngramService.NegotiateVersion:input_type -> engram.v1.NegotiateVersionRequest
+	14, // 28: engram.v1.EngramService.CallTool:output_type -> engram.v1.CallToolResponse
+	16, // 29: engram.v1.EngramService.Initialize:output_type -> engram.v1.InitializeResponse
+	19, // 30: engram.v1.EngramService.Ping:output_type -> engram.v1.PingResponse
+	2,  // 31: engram.v1.EngramService.SyncProjectState:output_type -> engram.v1.SyncProjectStateResponse
+	4,  // 32: engram.v1.EngramService.ProjectEvents:output_type -> engram.v1.ProjectEvent
+	6,  // 33: engram.v1.EngramService.GetSessionStartContext:output_type -> engram.v1.GetSessionStartContextResponse
+	12, // 34: engram.v1.EngramService.NegotiateVersion:output_type -> engram.v1.NegotiateVersionResponse
+	28, // [28:35] is the sub-list for method output_type
+	21, // [21:28] is the sub-list for method input_type
+	21, // [21:21] is the sub-list for extension type_name
+	21, // [21:21] is the sub-list for extension extendee
+	0,  // [0:21] is the sub-list for field type_name
 }
 
 func init() { file_proto_engram_v1_engram_proto_init() }
@@ -1584,7 +1913,7 @@ func file_proto_engram_v1_engram_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_proto_engram_v1_engram_proto_rawDesc), len(file_proto_engram_v1_engram_proto_rawDesc)),
 			NumEnums:      1,
-			NumMessages:   19,
+			NumMessages:   20,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
@@ -65,6 +65,18 @@ export function useAuth() {
     return false
   }
 
+  async function fetchOIDCConfig(): Promise<{ enabled: boolean; name?: string }> {
+    try {
+      const res = await fetch('/api/auth/oidc/config', { credentials: 'include' })
+      if (res.ok) {
+        return await res.json()
+      }
+    } catch {
+      // ignore
+    }
+    return { enabled: false }
+  }
+
   async function login(token: string): Promise<boolean> {
     try {
       const res = await fetch('/api/auth/login', {
@@ -137,6 +149,7 @@ export function useAuth() {
     checkAuth,
     fetchMe,
     checkSetupNeeded,
+    fetchOIDCConfig,
     login,
     loginWithCredentials,
     logout,
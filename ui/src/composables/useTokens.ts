@@ -1,9 +1,22 @@
-import { ref, onMounted, onUnmounted } from 'vue'
-import type { ApiToken, CreateTokenResponse } from '@/utils/api'
-import { fetchTokens, createToken, revokeToken } from '@/utils/api'
+import { ref, watch, onMounted, onUnmounted } from 'vue'
+import type { ApiToken, CreateTokenResponse, Role, RotateTokenResponse, TokenAlert } from '@/utils/api'
+import {
+  fetchTokens,
+  createToken,
+  revokeToken,
+  rotateToken,
+  setTokenProjects,
+  fetchTokenAlerts,
+  dismissTokenAlert,
+  fetchRoles,
+} from '@/utils/api'
+import { useSSE } from '@/composables/useSSE'
 
 export function useTokens() {
   const tokens = ref<ApiToken[]>([])
+  const alerts = ref<TokenAlert[]>([])
+  /** Custom roles a keycard can be issued with, besides read-write and read-only. */
+  const customRoles = ref<Role[]>([])
   const loading = ref(false)
   const error = ref<string | null>(null)
 
@@ -26,10 +39,29 @@ export function useTokens() {
     }
   }
 
-  async function create(name: string, scope: string): Promise<CreateTokenResponse> {
+  // Alerts are supplemental: a failed load leaves the list as is.
+  async function loadAlerts() {
+    try {
+      alerts.value = await fetchTokenAlerts()
+    } catch {
+      // Non-critical
+    }
+  }
+
+  // Without custom roles the scope picker still offers read-write and read-only.
+  async function loadRoles() {
+    try {
+      customRoles.value = (await fetchRoles()).roles.filter(r => !r.builtin)
+    } catch {
+      // Non-critical
+    }
+  }
+
+  async function create(name: string, scope: string, allowedProjects: string[] = [], expiresIn = ''): Promise<CreateTokenResponse> {
     error.value = null
     try {
-      const result = await createToken({ name, scope }, abortController?.signal)
+      const params = { name, scope, allowed_projects: allowedProjects, ...(expiresIn ? { expires_in: expiresIn } : {}) }
+      const result = await createToken(params, abortController?.signal)
       await loadTokens()
       return result
     } catch (err) {
@@ -50,8 +82,35 @@ export function useTokens() {
     }
   }
 
+  // Errors are rethrown for the editor dialog to show; the token list stays as is.
+  async function saveProjects(id: string, allowedProjects: string[]) {
+    const result = await setTokenProjects(id, allowedProjects)
+    tokens.value = tokens.value.map(t => t.id === id ? { ...t, allowed_projects: result.allowed_projects } : t)
+  }
+
+  // Errors are rethrown for the rotate dialog to show.
+  async function rotate(id: string, grace: string, expiresIn = ''): Promise<RotateTokenResponse> {
+    const result = await rotateToken(id, { grace, ...(expiresIn ? { expires_in: expiresIn } : {}) })
+    await loadTokens()
+    return result
+  }
+
+  async function dismissAlert(id: number) {
+    await dismissTokenAlert(id)
+    alerts.value = alerts.value.filter(a => a.id !== id)
+  }
+
+  const { lastEvent } = useSSE()
+  watch(lastEvent, (event) => {
+    if (event?.type === 'keycard') {
+      loadAlerts()
+    }
+  })
+
   onMounted(() => {
     loadTokens()
+    loadAlerts()
+    loadRoles()
   })
 
   onUnmounted(() => {
@@ -60,10 +119,16 @@ export function useTokens() {
 
   return {
     tokens,
+    alerts,
+    customRoles,
     loading,
     error,
     loadTokens,
+    loadAlerts,
     create,
     revoke,
+    rotate,
+    saveProjects,
+    dismissAlert,
   }
 }
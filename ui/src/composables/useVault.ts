@@ -1,6 +1,13 @@
 import { ref, onMounted, onUnmounted } from 'vue'
-import type { VaultCredential, VaultStatus } from '@/utils/api'
-import { fetchCredentials, fetchCredential, deleteCredential, fetchVaultStatus } from '@/utils/api'
+import type { VaultCredential, VaultStatus, CredentialPolicy, CredentialAccessEntry } from '@/utils/api'
+import {
+  fetchCredentials,
+  fetchCredential,
+  deleteCredential,
+  fetchVaultStatus,
+  setCredentialPolicy,
+  fetchCredentialAccessLog,
+} from '@/utils/api'
 
 export function useVault() {
   const credentials = ref<VaultCredential[]>([])
@@ -9,6 +16,11 @@ export function useVault() {
   const error = ref<string | null>(null)
   const actionError = ref<string | null>(null)
 
+  // Credential access log (admin only): who decrypted what, allowed or denied
+  const accessLog = ref<CredentialAccessEntry[]>([])
+  const accessFilter = ref<{ name?: string; since?: string; denied?: boolean }>({ since: '168h' })
+  const accessLogError = ref<string | null>(null)
+
   // Track revealed credentials: name -> { value, expiresAt }
   const revealedValues = ref<Record<string, { value: string; expiresAt: number }>>({})
   const revealTimers = new Map<string, ReturnType<typeof setTimeout>>()
@@ -29,6 +41,7 @@ export function useVault() {
       ])
       credentials.value = creds || []
       vaultStatus.value = status
+      loadAccessLog()
     } catch (err) {
       if (err instanceof Error && err.name === 'AbortError') return
       error.value = err instanceof Error ? err.message : 'Failed to load vault'
@@ -58,12 +71,16 @@ export function useVault() {
     } catch (err) {
       const msg = err instanceof Error ? err.message : ''
       const normalized = msg.toLowerCase()
-      if (/\b409\b/.test(normalized) || normalized.includes('key mismatch') || normalized.includes('encryption') || normalized.includes('decrypt')) {
+      if (/\b403\b/.test(normalized)) {
+        actionError.value = 'Access denied: this credential\'s policy does not allow you to reveal it.'
+      } else if (/\b409\b/.test(normalized) || normalized.includes('key mismatch') || normalized.includes('encryption') || normalized.includes('decrypt')) {
         actionError.value = 'Cannot decrypt: this credential was encrypted with a different vault key. Set the original ENGRAM_VAULT_KEY to reveal it.'
       } else {
         actionError.value = msg || 'Failed to reveal credential'
       }
     }
+    // Every reveal attempt is audited — show it in the access log right away.
+    loadAccessLog()
   }
 
   function hideCredential(name: string) {
@@ -76,6 +93,29 @@ export function useVault() {
     }
   }
 
+  async function loadAccessLog() {
+    accessLogError.value = null
+    try {
+      accessLog.value = await fetchCredentialAccessLog(accessFilter.value)
+    } catch (err) {
+      const msg = err instanceof Error ? err.message : ''
+      accessLogError.value = msg.includes('HTTP 403')
+        ? 'The access log is only visible to admins.'
+        : msg || 'Failed to load access log'
+    }
+  }
+
+  async function savePolicy(cred: VaultCredential, policy: CredentialPolicy) {
+    actionError.value = null
+    if (!cred.project) throw new Error('Credential has no project')
+    const updated = await setCredentialPolicy(cred.name, cred.project, policy)
+    credentials.value = credentials.value.map(c =>
+      c.id === cred.id
+        ? { ...c, access: updated.access, allowed_projects: updated.allowed_projects, allowed_keycards: updated.allowed_keycards }
+        : c,
+    )
+  }
+
   async function removeCredential(name: string, project?: string) {
     actionError.value = null
     try {
@@ -113,7 +153,12 @@ export function useVault() {
     error,
     actionError,
     revealedValues,
+    accessLog,
+    accessFilter,
+    accessLogError,
     loadCredentials,
+    loadAccessLog,
+    savePolicy,
     revealCredential,
     hideCredential,
     removeCredential,
@@ -0,0 +1,113 @@
+import { ref, onMounted, onUnmounted } from 'vue'
+import type { Webhook, WebhookDelivery } from '@/utils/api'
+import {
+  fetchWebhooks,
+  createWebhook,
+  updateWebhook,
+  deleteWebhook,
+  fetchWebhookDeliveries,
+  retryWebhookDelivery,
+} from '@/utils/api'
+
+export function useWebhooks() {
+  const webhooks = ref<Webhook[]>([])
+  const eventTypes = ref<string[]>([])
+  const deliveries = ref<WebhookDelivery[]>([])
+  const deliveryFilter = ref<{ webhookId?: number; status?: string }>({})
+  const loading = ref(false)
+  const error = ref<string | null>(null)
+
+  let abortController: AbortController | null = null
+
+  async function loadWebhooks() {
+    abortController?.abort()
+    abortController = new AbortController()
+
+    loading.value = true
+    error.value = null
+
+    try {
+      const [hooks, log] = await Promise.all([
+        fetchWebhooks(abortController.signal),
+        fetchWebhookDeliveries(deliveryFilter.value, abortController.signal),
+      ])
+      webhooks.value = hooks.webhooks || []
+      eventTypes.value = hooks.event_types || []
+      deliveries.value = log || []
+    } catch (err) {
+      if (err instanceof Error && err.name === 'AbortError') return
+      error.value = err instanceof Error ? err.message : 'Failed to load webhooks'
+    } finally {
+      loading.value = false
+    }
+  }
+
+  async function loadDeliveries() {
+    try {
+      deliveries.value = await fetchWebhookDeliveries(deliveryFilter.value) || []
+    } catch (err) {
+      error.value = err instanceof Error ? err.message : 'Failed to load deliveries'
+    }
+  }
+
+  async function create(params: { name: string; url: string; events: string[]; project?: string }): Promise<string> {
+    error.value = null
+    const result = await createWebhook(params)
+    await loadWebhooks()
+    return result.secret
+  }
+
+  async function setEnabled(hook: Webhook, enabled: boolean) {
+    error.value = null
+    try {
+      const updated = await updateWebhook(hook.id, { enabled })
+      webhooks.value = webhooks.value.map(w => (w.id === updated.id ? updated : w))
+    } catch (err) {
+      error.value = err instanceof Error ? err.message : 'Failed to update webhook'
+    }
+  }
+
+  async function remove(id: number) {
+    error.value = null
+    try {
+      await deleteWebhook(id)
+      webhooks.value = webhooks.value.filter(w => w.id !== id)
+      deliveries.value = deliveries.value.filter(d => d.webhook_id !== id)
+    } catch (err) {
+      error.value = err instanceof Error ? err.message : 'Failed to delete webhook'
+    }
+  }
+
+  async function retry(id: number) {
+    error.value = null
+    try {
+      await retryWebhookDelivery(id)
+      await loadDeliveries()
+    } catch (err) {
+      error.value = err instanceof Error ? err.message : 'Failed to retry delivery'
+    }
+  }
+
+  onMounted(() => {
+    loadWebhooks()
+  })
+
+  onUnmounted(() => {
+    abortController?.abort()
+  })
+
+  return {
+    webhooks,
+    eventTypes,
+    deliveries,
+    deliveryFilter,
+    loading,
+    error,
+    loadWebhooks,
+    loadDeliveries,
+    create,
+    setEnabled,
+    remove,
+    retry,
+  }
+}
@@ -45,6 +45,11 @@ const routes = [
     name: 'tokens',
     component: () => import('@/views/TokensView.vue'),
   },
+  {
+    path: '/webhooks',
+    name: 'webhooks',
+    component: () => import('@/views/WebhooksView.vue'),
+  },
   {
     path: '/system',
     name: 'system',
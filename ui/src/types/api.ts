@@ -36,7 +36,7 @@ export interface Stats {
 }
 
 export interface SSEEvent {
-  type: 'processing_status' | 'session' | 'heartbeat' | 'connected'
+  type: 'processing_status' | 'session' | 'heartbeat' | 'connected' | 'keycard'
   title?: string
   action?: string
   project?: string
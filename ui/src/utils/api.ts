@@ -210,10 +210,36 @@ export interface VaultCredential {
   name: string
   scope: string
   project?: string
+  access?: CredentialAccess
+  allowed_projects?: string[]
+  allowed_keycards?: string[]
   created_at: string
   updated_at?: string
 }
 
+export type CredentialAccess = 'reveal' | 'read-only'
+
+export interface CredentialPolicy {
+  access: CredentialAccess
+  allowed_projects: string[]
+  allowed_keycards: string[]
+}
+
+export interface CredentialAccessEntry {
+  id: number
+  credential_id: number
+  credential_project: string
+  credential_key: string
+  keycard_id?: string
+  role?: string
+  caller_project?: string
+  session_id?: string
+  source: string
+  allowed: boolean
+  reason?: string
+  created_at: string
+}
+
 export interface VaultStatus {
   encrypted: boolean
   key_fingerprint?: string
@@ -243,6 +269,38 @@ export async function deleteCredential(name: string, project?: string, signal?:
   await deleteJson<Record<string, unknown>>(`${API_BASE}/vault/credentials/${encodeURIComponent(name)}${params}`, { signal })
 }
 
+export async function setCredentialPolicy(
+  name: string,
+  project: string,
+  policy: CredentialPolicy,
+  signal?: AbortSignal,
+): Promise<CredentialPolicy> {
+  return patchJson<CredentialPolicy>(
+    `${API_BASE}/vault/credentials/${encodeURIComponent(name)}/policy?project=${encodeURIComponent(project)}`,
+    policy,
+    { signal },
+  )
+}
+
+export async function fetchCredentialAccessLog(
+  params: { project?: string; name?: string; keycardId?: string; since?: string; denied?: boolean; limit?: number } = {},
+  signal?: AbortSignal,
+): Promise<CredentialAccessEntry[]> {
+  const query = new URLSearchParams()
+  if (params.project) query.set('project', params.project)
+  if (params.name) query.set('name', params.name)
+  if (params.keycardId) query.set('keycard_id', params.keycardId)
+  if (params.since) query.set('since', params.since)
+  if (params.denied) query.set('denied', 'true')
+  if (params.limit) query.set('limit', String(params.limit))
+  const qs = query.toString()
+  const response = await fetchWithRetry<{ entries: CredentialAccessEntry[] }>(
+    `${API_BASE}/vault/access-log${qs ? `?${qs}` : ''}`,
+    { signal },
+  )
+  return response.entries || []
+}
+
 // ============================================================
 // Tokens API
 // ============================================================
@@ -258,6 +316,33 @@ export interface ApiToken {
   error_count?: number
   revoked: boolean
   revoked_at?: string
+  /** Project IDs and glob patterns the token may reach; empty means every project. */
+  allowed_projects: string[]
+  /** When the token stops working; absent means never. */
+  expires_at?: string
+  /** Set once the token was rotated: the ID of its replacement. */
+  successor_id?: string
+}
+
+/** A usage anomaly the keycard watch job raised for a token. */
+export interface TokenAlert {
+  id: number
+  token_id: string
+  token_name: string
+  kind: 'unused' | 'spike'
+  message: string
+  rate: number
+  baseline: number
+  created_at: string
+  resolved_at?: string
+}
+
+export interface RotateTokenResponse {
+  id: string
+  name: string
+  token: string
+  expires_at?: string
+  rotated: { id: string; name: string; expires_at: string }
 }
 
 export interface CreateTokenResponse {
@@ -272,7 +357,7 @@ export async function fetchTokens(signal?: AbortSignal): Promise<ApiToken[]> {
 }
 
 export async function createToken(
-  params: { name: string; scope: string },
+  params: { name: string; scope: string; allowed_projects?: string[]; expires_in?: string },
   signal?: AbortSignal
 ): Promise<CreateTokenResponse> {
   return postJson<CreateTokenResponse>(`${API_BASE}/auth/tokens`, params, { signal })
@@ -282,6 +367,49 @@ export async function revokeToken(id: string, signal?: AbortSignal): Promise<voi
   await deleteJson<Record<string, unknown>>(`${API_BASE}/auth/tokens/${encodeURIComponent(id)}`, { signal })
 }
 
+export async function rotateToken(
+  id: string,
+  params: { grace?: string; expires_in?: string } = {},
+  signal?: AbortSignal,
+): Promise<RotateTokenResponse> {
+  return postJson<RotateTokenResponse>(`${API_BASE}/auth/tokens/${encodeURIComponent(id)}/rotate`, params, { signal })
+}
+
+export async function fetchTokenAlerts(signal?: AbortSignal): Promise<TokenAlert[]> {
+  const response = await fetchWithRetry<{ alerts: TokenAlert[] }>(`${API_BASE}/auth/tokens/alerts`, { signal })
+  return response.alerts || []
+}
+
+export async function dismissTokenAlert(id: number, signal?: AbortSignal): Promise<void> {
+  await postJson<Record<string, unknown>>(`${API_BASE}/auth/tokens/alerts/${id}/dismiss`, {}, { signal })
+}
+
+export async function setTokenProjects(
+  id: string,
+  allowedProjects: string[],
+  signal?: AbortSignal,
+): Promise<{ id: string; allowed_projects: string[] }> {
+  return patchJson<{ id: string; allowed_projects: string[] }>(
+    `${API_BASE}/auth/tokens/${encodeURIComponent(id)}/projects`,
+    { allowed_projects: allowedProjects },
+    { signal },
+  )
+}
+
+/** A role keycards and dashboard users can be assigned: a named bundle of permissions. */
+export interface Role {
+  name: string
+  description: string
+  permissions: string[]
+  builtin: boolean
+  updated_at?: string
+}
+
+export async function fetchRoles(signal?: AbortSignal): Promise<{ roles: Role[]; permissions: string[] }> {
+  const response = await fetchWithRetry<{ roles: Role[]; permissions: string[] }>(`${API_BASE}/auth/roles`, { signal })
+  return { roles: response.roles || [], permissions: response.permissions || [] }
+}
+
 // ============================================================
 // Patterns API
 // ============================================================
@@ -614,3 +742,77 @@ export async function fetchTrackedProjects(signal?: AbortSignal): Promise<string
 export async function fetchConfig(signal?: AbortSignal): Promise<Record<string, Record<string, unknown>>> {
   return fetchWithRetry<Record<string, Record<string, unknown>>>(`${API_BASE}/config`, { signal })
 }
+
+// ============================================================
+// Webhooks API
+// ============================================================
+
+export interface Webhook {
+  id: number
+  name: string
+  url: string
+  events: string[]
+  project: string
+  enabled: boolean
+  created_by: string
+  created_at: string
+  updated_at: string
+}
+
+export interface WebhookDelivery {
+  id: number
+  webhook_id: number
+  event_id: string
+  event_type: string
+  status: 'pending' | 'delivered' | 'failed'
+  attempts: number
+  next_attempt_at: string
+  last_attempt_at?: string
+  response_status?: number
+  last_error?: string
+  delivered_at?: string
+  created_at: string
+}
+
+export async function fetchWebhooks(signal?: AbortSignal): Promise<{ webhooks: Webhook[]; event_types: string[] }> {
+  return fetchWithRetry<{ webhooks: Webhook[]; event_types: string[] }>(`${API_BASE}/webhooks`, { signal })
+}
+
+export async function createWebhook(
+  params: { name: string; url: string; events: string[]; project?: string },
+  signal?: AbortSignal
+): Promise<{ webhook: Webhook; secret: string }> {
+  return postJson<{ webhook: Webhook; secret: string }>(`${API_BASE}/webhooks`, params, { signal })
+}
+
+export async function updateWebhook(
+  id: number,
+  fields: { url?: string; events?: string[]; project?: string; enabled?: boolean },
+  signal?: AbortSignal
+): Promise<Webhook> {
+  return patchJson<Webhook>(`${API_BASE}/webhooks/${id}`, fields, { signal })
+}
+
+export async function deleteWebhook(id: number, signal?: AbortSignal): Promise<void> {
+  await deleteJson<Record<string, unknown>>(`${API_BASE}/webhooks/${id}`, { signal })
+}
+
+export async function fetchWebhookDeliveries(
+  params: { webhookId?: number; status?: string; limit?: number } = {},
+  signal?: AbortSignal
+): Promise<WebhookDelivery[]> {
+  const query = new URLSearchParams()
+  if (params.webhookId) query.set('webhook_id', String(params.webhookId))
+  if (params.status) query.set('status', params.status)
+  if (params.limit) query.set('limit', String(params.limit))
+  const qs = query.toString()
+  const response = await fetchWithRetry<{ deliveries: WebhookDelivery[] }>(
+    `${API_BASE}/webhooks/deliveries${qs ? `?${qs}` : ''}`,
+    { signal },
+  )
+  return response.deliveries
+}
+
+export async function retryWebhookDelivery(id: number, signal?: AbortSignal): Promise<void> {
+  await postJson<{ message: string }>(`${API_BASE}/webhooks/deliveries/${id}/retry`, {}, { signal })
+}